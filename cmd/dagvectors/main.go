@@ -0,0 +1,115 @@
+// Copyright (c) 2017-2018 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// dagvectors generates and verifies the golden consensus vectors of the
+// block DAG.  A vector describes a DAG topology together with the expected
+// order, blue set, main chain tip and coinbase rewards.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/database"
+	_ "github.com/Qitmeer/qitmeer/database/ffldb"
+	"github.com/Qitmeer/qitmeer/params"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+var (
+	vectorsDir = flag.String("dir", "vectors", "directory of the consensus vectors")
+	generate   = flag.Bool("generate", false, "calculate the expected results and write them back to the vectors")
+	network    = flag.String("network", "mainnet", "default network of vectors without one {mainnet,testnet,privnet,mixnet}")
+)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage:", os.Args[0], "[-dir <vectors dir>] [-generate] [-network <name>]")
+		flag.PrintDefaults()
+		fmt.Fprintln(os.Stderr, `
+Verifies the node against every vector in the directory.  With -generate the
+expected results are calculated by this implementation and saved instead.`)
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	vectors, err := blockchain.LoadVectors(*vectorsDir)
+	if err != nil {
+		die(err)
+	}
+	if len(vectors) == 0 {
+		die(fmt.Errorf("no vectors found in %s", *vectorsDir))
+	}
+	failed := 0
+	for _, v := range vectors {
+		if len(v.Network) == 0 {
+			v.Network = *network
+		}
+		par := networkParams(v.Network)
+		if par == nil {
+			die(fmt.Errorf("vector %s: unknown network %s", v.Name, v.Network))
+		}
+		err := withTempDB(par, func(db database.DB) error {
+			if *generate {
+				result, err := blockchain.ComputeVector(db, v, par)
+				if err != nil {
+					return err
+				}
+				v.Expected = result
+				return blockchain.WriteVector(*vectorsDir, v)
+			}
+			return blockchain.CheckVector(db, v, par)
+		})
+		if err != nil {
+			failed++
+			fmt.Printf("FAIL %s: %v\n", v.Name, err)
+			continue
+		}
+		fmt.Printf("ok   %s\n", v.Name)
+	}
+	if failed > 0 {
+		fmt.Printf("%d of %d vectors failed\n", failed, len(vectors))
+		os.Exit(1)
+	}
+}
+
+// withTempDB runs the function with an empty block database which is removed
+// afterwards.
+func withTempDB(par *params.Params, f func(db database.DB) error) error {
+	dir, err := ioutil.TempDir("", "dagvectors")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := database.Create("ffldb", filepath.Join(dir, "blocks_ffldb"), par.Net)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return f(db)
+}
+
+func networkParams(name string) *params.Params {
+	switch name {
+	case params.MainNetParams.Name:
+		return &params.MainNetParams
+	case params.TestNetParams.Name:
+		return &params.TestNetParams
+	case params.PrivNetParams.Name:
+		return &params.PrivNetParams
+	case params.MixNetParams.Name:
+		return &params.MixNetParams
+	}
+	return nil
+}
+
+func die(err error) {
+	fmt.Fprintln(os.Stderr, "error:", err)
+	os.Exit(1)
+}
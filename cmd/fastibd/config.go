@@ -35,6 +35,8 @@ type Config struct {
 	DisableBar bool
 	EndPoint   string
 	ByID       bool
+
+	LegacyDataDir string
 }
 
 func (c *Config) load() error {
@@ -122,6 +122,35 @@ func fastIBD() error {
 					return node.Upgrade()
 				},
 			},
+			&cli.Command{
+				Name:        "migrate",
+				Aliases:     []string{"m"},
+				Category:    "IBD",
+				Usage:       "Migrate the database of an older Qitmeer or Nox release",
+				Description: "Rebuild the database of an older Qitmeer or Nox release from its blocks, in place or into the data directory",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "legacydatadir",
+						Aliases:     []string{"l"},
+						Usage:       "Directory of the legacy data, the data directory is migrated in place if omitted",
+						Destination: &cfg.LegacyDataDir,
+					},
+				},
+				Before: func(c *cli.Context) error {
+					err := cfg.load()
+					if err != nil {
+						return err
+					}
+					node.cfg = cfg
+					return nil
+				},
+				After: func(c *cli.Context) error {
+					return node.exit()
+				},
+				Action: func(c *cli.Context) error {
+					return node.Migrate()
+				},
+			},
 		},
 		Flags: []cli.Flag{
 			&cli.StringFlag{
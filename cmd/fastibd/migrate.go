@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/common/util"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
+	"github.com/Qitmeer/qitmeer/params"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ffldbBlockIdxBucketName is the bucket of the metadata used internally by
+// ffldb to locate the blocks in the flat files.  Its layout has not changed
+// since the first nox releases, unlike the buckets of the chain state.
+var ffldbBlockIdxBucketName = []byte("ffldb-blockidx")
+
+// migrateDirname is the directory of the data directory where the database is
+// rebuilt when migrating in place, until it replaces the legacy one.
+const migrateDirname = "migrate"
+
+// legacyBlock is what the migration keeps in memory of a legacy block to sort
+// the blocks, whose bodies are read again when they are accepted.
+type legacyBlock struct {
+	parents   []hash.Hash
+	timestamp time.Time
+}
+
+// Migrate converts a database of an older qitmeer or nox release, which the
+// current software can't load, to the current format.  The raw blocks are
+// read from the block store without loading the chain state, then they are
+// accepted again in the order of their parents, which rebuilds the DAG, the
+// utxo set, the spend journal and the indexes.  The legacy database is
+// migrated into the data directory when one is passed, otherwise it is
+// rebuilt into a temporary directory which replaces it once the migration
+// succeeded, so a failed migration leaves it untouched.
+func (node *Node) Migrate() error {
+	if node.cfg.DbType != "ffldb" {
+		return fmt.Errorf("The database type %s can't be migrated", node.cfg.DbType)
+	}
+	inPlace := len(node.cfg.LegacyDataDir) == 0
+	legacyDataDir := node.cfg.DataDir
+	dataDir := node.cfg.DataDir
+	if inPlace {
+		dataDir = filepath.Join(node.cfg.DataDir, migrateDirname)
+		// Drop what a failed migration left.
+		if err := os.RemoveAll(dataDir); err != nil {
+			return err
+		}
+	} else {
+		legacyDataDir = util.CleanAndExpandPath(node.cfg.LegacyDataDir)
+		legacyDataDir = filepath.Join(legacyDataDir, params.ActiveNetParams.Name)
+		if legacyDataDir == node.cfg.DataDir {
+			return fmt.Errorf("The legacy data directory is the data directory, omit it to migrate in place")
+		}
+	}
+
+	log.Info(fmt.Sprintf("Read legacy data:%s", legacyDataDir))
+	legacyDB, err := LoadBlockDB(node.cfg.DbType, legacyDataDir, false)
+	if err != nil {
+		return err
+	}
+	legacyBlocks, err := readLegacyBlocks(legacyDB)
+	if err != nil {
+		legacyDB.Close()
+		return err
+	}
+	hashes, err := sortLegacyBlocks(legacyBlocks)
+	if err != nil {
+		legacyDB.Close()
+		return err
+	}
+
+	err = node.openDir(dataDir)
+	if err == nil {
+		err = node.migrateBlocks(legacyDB, hashes)
+	}
+	legacyDB.Close()
+	if inPlace {
+		node.exit()
+		node.db, node.bc = nil, nil
+		if err == nil {
+			err = swapBlockDB(node.cfg.DbType, dataDir, legacyDataDir)
+		}
+		if err != nil {
+			os.RemoveAll(dataDir)
+			return err
+		}
+		if err := os.RemoveAll(dataDir); err != nil {
+			return err
+		}
+		err = node.open()
+	}
+	if err != nil {
+		return err
+	}
+
+	mainTip := node.bc.BlockDAG().GetMainChainTip()
+	log.Info(fmt.Sprintf("Finish migrate: blocks(%d)    ------>Data:%s", len(hashes), node.cfg.DataDir))
+	log.Info(fmt.Sprintf("New Info:%s  mainOrder=%d tips=%d", mainTip.GetHash().String(), mainTip.GetOrder(), node.bc.BlockDAG().GetTips().Size()))
+	return nil
+}
+
+// migrateBlocks accepts the blocks of the legacy database in the given order,
+// reading them one at a time.
+func (node *Node) migrateBlocks(legacyDB database.DB, hashes []hash.Hash) error {
+	mainTip := node.bc.BlockDAG().GetMainChainTip()
+	if mainTip.GetOrder() > 0 {
+		return fmt.Errorf("Your database is not empty, please empty the database.")
+	}
+
+	var bar *ProgressBar
+	if !node.cfg.DisableBar {
+		bar = &ProgressBar{}
+		bar.init("Migrate:")
+		bar.reset(len(hashes))
+		bar.add()
+	} else {
+		log.Info("Migrate...")
+	}
+	for i := range hashes {
+		block, err := fetchLegacyBlock(legacyDB, &hashes[i])
+		if err != nil {
+			return err
+		}
+		err = node.bc.FastAcceptBlock(block)
+		if err != nil {
+			return err
+		}
+		if bar != nil {
+			bar.add()
+		}
+	}
+
+	if bar != nil {
+		bar.setMax()
+		fmt.Println()
+	}
+	return nil
+}
+
+// swapBlockDB replaces the block database of the legacy data directory by the
+// one of the data directory.
+func swapBlockDB(dbType string, dataDir string, legacyDataDir string) error {
+	dbPath := blockDbPath(dbType, dataDir)
+	legacyPath := blockDbPath(dbType, legacyDataDir)
+	backupPath := legacyPath + ".legacy"
+	if err := os.Rename(legacyPath, backupPath); err != nil {
+		return err
+	}
+	if err := os.Rename(dbPath, legacyPath); err != nil {
+		// Put the legacy database back.
+		os.Rename(backupPath, legacyPath)
+		return err
+	}
+	return os.RemoveAll(backupPath)
+}
+
+// readLegacyBlocks returns the parents and the timestamp of all the blocks of
+// the block store of the legacy database except the genesis, which must be
+// the one of the active network.
+func readLegacyBlocks(db database.DB) (map[hash.Hash]*legacyBlock, error) {
+	genesis := params.ActiveNetParams.GenesisHash
+	hasGenesis := false
+	blocks := map[hash.Hash]*legacyBlock{}
+	err := db.View(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(ffldbBlockIdxBucketName)
+		if bucket == nil {
+			return fmt.Errorf("The block store is missing")
+		}
+		var hashes []hash.Hash
+		err := bucket.ForEach(func(k, v []byte) error {
+			h, err := hash.NewHash(k)
+			if err != nil {
+				return err
+			}
+			hashes = append(hashes, *h)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for i := range hashes {
+			if hashes[i].IsEqual(genesis) {
+				hasGenesis = true
+				continue
+			}
+			blockBytes, err := dbTx.FetchBlock(&hashes[i])
+			if err != nil {
+				return err
+			}
+			var block types.Block
+			err = block.Deserialize(bytes.NewReader(blockBytes))
+			if err != nil {
+				return fmt.Errorf("The block %s can't be decoded: %v", hashes[i], err)
+			}
+			lb := &legacyBlock{timestamp: block.Header.Timestamp}
+			for _, parent := range block.Parents {
+				lb.parents = append(lb.parents, *parent)
+			}
+			blocks[hashes[i]] = lb
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !hasGenesis {
+		return nil, fmt.Errorf("The genesis %s of %s is missing",
+			genesis, params.ActiveNetParams.Name)
+	}
+	return blocks, nil
+}
+
+// fetchLegacyBlock returns the block with the given hash from the block store
+// of the legacy database.
+func fetchLegacyBlock(db database.DB, h *hash.Hash) (*types.SerializedBlock, error) {
+	var block *types.SerializedBlock
+	err := db.View(func(dbTx database.Tx) error {
+		blockBytes, err := dbTx.FetchBlock(h)
+		if err != nil {
+			return err
+		}
+		// The fetched bytes are only valid during the transaction.
+		block, err = types.NewBlockFromBytes(append([]byte(nil), blockBytes...))
+		if err != nil {
+			return fmt.Errorf("The block %s can't be decoded: %v", h, err)
+		}
+		return nil
+	})
+	return block, err
+}
+
+// sortLegacyBlocks returns the hashes of the blocks sorted so that every block
+// comes after its parents.  The blocks whose parents are known are sorted by
+// timestamp, then by hash, so the same blocks always give the same DAG.
+func sortLegacyBlocks(blocks map[hash.Hash]*legacyBlock) ([]hash.Hash, error) {
+	genesis := params.ActiveNetParams.GenesisHash
+	missing := map[hash.Hash]int{}
+	children := map[hash.Hash][]hash.Hash{}
+	var ready []hash.Hash
+	for h, block := range blocks {
+		for _, parent := range block.parents {
+			if parent.IsEqual(genesis) {
+				continue
+			}
+			if _, ok := blocks[parent]; !ok {
+				return nil, fmt.Errorf("The parent %s of %s is missing", parent, h)
+			}
+			missing[h]++
+			children[parent] = append(children[parent], h)
+		}
+		if missing[h] == 0 {
+			ready = append(ready, h)
+		}
+	}
+
+	result := make([]hash.Hash, 0, len(blocks))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool {
+			ti := blocks[ready[i]].timestamp
+			tj := blocks[ready[j]].timestamp
+			if !ti.Equal(tj) {
+				return ti.Before(tj)
+			}
+			return ready[i].Less(&ready[j])
+		})
+		h := ready[0]
+		ready = ready[1:]
+		result = append(result, h)
+		for _, child := range children[h] {
+			missing[child]--
+			if missing[child] == 0 {
+				ready = append(ready, child)
+			}
+		}
+	}
+	if len(result) != len(blocks) {
+		return nil, fmt.Errorf("The blocks have a cycle in their parents")
+	}
+	return result, nil
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/core/types/pow"
+	"github.com/Qitmeer/qitmeer/database"
+	_ "github.com/Qitmeer/qitmeer/database/ffldb"
+	"github.com/Qitmeer/qitmeer/params"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCoinbase returns a coinbase paying nothing, which differs by its height.
+func testCoinbase(height int) *types.Transaction {
+	tx := types.NewTransaction()
+	tx.AddTxIn(&types.TxInput{
+		PreviousOut: *types.NewOutPoint(&hash.Hash{}, types.MaxPrevOutIndex),
+		SignScript:  []byte{byte(height), 0},
+	})
+	tx.AddTxOut(types.NewTxOutput(types.Amount{}, []byte{0x51}))
+	return tx
+}
+
+// legacyTestBlocks returns a small DAG on top of the genesis, whose last block
+// merges two branches.
+func legacyTestBlocks() []*types.SerializedBlock {
+	genesis := params.ActiveNetParams.GenesisHash
+	var blocks []*types.SerializedBlock
+	newBlock := func(parents ...*hash.Hash) *hash.Hash {
+		block := &types.Block{Parents: parents}
+		block.AddTransaction(testCoinbase(len(blocks) + 1))
+		block.Header.Timestamp = time.Unix(1600000000+int64(len(blocks)), 0)
+		block.Header.Pow = pow.GetInstance(pow.BLAKE2BD, uint64(len(blocks)), []byte{})
+		blocks = append(blocks, types.NewBlock(block))
+		return blocks[len(blocks)-1].Hash()
+	}
+	a := newBlock(genesis)
+	b := newBlock(a)
+	c := newBlock(genesis)
+	newBlock(b, c)
+	return blocks
+}
+
+// createLegacyTestDB creates a database holding only the block store, with
+// the genesis and the blocks stored in reverse order.
+func createLegacyTestDB(t *testing.T, dataDir string, blocks []*types.SerializedBlock) {
+	db, err := database.Create("ffldb", blockDbPath("ffldb", dataDir),
+		params.ActiveNetParams.Net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	stored := []*types.SerializedBlock{types.NewBlock(params.ActiveNetParams.GenesisBlock)}
+	for i := len(blocks) - 1; i >= 0; i-- {
+		stored = append(stored, blocks[i])
+	}
+	for _, block := range stored {
+		err := db.Update(func(dbTx database.Tx) error {
+			return dbTx.StoreBlock(block)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMigrateInPlace(t *testing.T) {
+	active := params.ActiveNetParams
+	params.ActiveNetParams = &params.PrivNetParam
+	defer func() {
+		params.ActiveNetParams = active
+	}()
+	dataDir, err := ioutil.TempDir("", "fastibd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dataDir)
+
+	blocks := legacyTestBlocks()
+	createLegacyTestDB(t, dataDir, blocks)
+
+	node := &Node{cfg: &Config{DataDir: dataDir, DbType: "ffldb",
+		DAGType: "phantom", DisableBar: true}}
+	err = node.Migrate()
+	defer node.exit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The migrated database replaced the legacy one.
+	for _, name := range []string{migrateDirname, blockDbNamePrefix + "_ffldb.legacy"} {
+		if _, err := os.Stat(filepath.Join(dataDir, name)); !os.IsNotExist(err) {
+			t.Fatalf("%s was left: %v", name, err)
+		}
+	}
+	if node.name != filepath.Base(dataDir) {
+		t.Fatalf("the migrated database was loaded from %s", node.name)
+	}
+	bd := node.bc.BlockDAG()
+	if bd.GetBlockTotal() != uint(len(blocks)+1) {
+		t.Fatalf("migrated %d blocks, want %d", bd.GetBlockTotal(), len(blocks)+1)
+	}
+	for i, block := range blocks {
+		if !bd.HasBlock(block.Hash()) {
+			t.Fatalf("block %d is not in the DAG", i)
+		}
+		if _, err := node.bc.FetchBlockByHash(block.Hash()); err != nil {
+			t.Fatalf("block %d: %v", i, err)
+		}
+	}
+	tip := bd.GetMainChainTip()
+	if !tip.GetHash().IsEqual(blocks[len(blocks)-1].Hash()) {
+		t.Fatalf("got main tip %s, want the merge block", tip.GetHash())
+	}
+}
+
+func TestMigrateInPlaceFailure(t *testing.T) {
+	active := params.ActiveNetParams
+	params.ActiveNetParams = &params.PrivNetParam
+	defer func() {
+		params.ActiveNetParams = active
+	}()
+	dataDir, err := ioutil.TempDir("", "fastibd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dataDir)
+
+	// A block without parents is rejected by the DAG once the blocks
+	// before it were accepted.
+	blocks := legacyTestBlocks()
+	orphan := &types.Block{}
+	orphan.AddTransaction(testCoinbase(len(blocks) + 1))
+	orphan.Header.Timestamp = time.Unix(1700000000, 0)
+	orphan.Header.Pow = pow.GetInstance(pow.BLAKE2BD, 100, []byte{})
+	blocks = append(blocks, types.NewBlock(orphan))
+	createLegacyTestDB(t, dataDir, blocks)
+
+	node := &Node{cfg: &Config{DataDir: dataDir, DbType: "ffldb",
+		DAGType: "phantom", DisableBar: true}}
+	err = node.Migrate()
+	node.exit()
+	if err == nil {
+		t.Fatal("migrated a block without parents")
+	}
+
+	// The legacy database is untouched and the partial one is dropped.
+	if _, err := os.Stat(filepath.Join(dataDir, migrateDirname)); !os.IsNotExist(err) {
+		t.Fatalf("%s was left: %v", migrateDirname, err)
+	}
+	db, err := LoadBlockDB("ffldb", dataDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for i, block := range blocks {
+		err := db.View(func(dbTx database.Tx) error {
+			_, err := dbTx.FetchBlock(block.Hash())
+			return err
+		})
+		if err != nil {
+			t.Fatalf("legacy block %d: %v", i, err)
+		}
+	}
+}
@@ -39,8 +39,18 @@ func (node *Node) init(cfg *Config) error {
 		return err
 	}
 	node.cfg = cfg
+	return node.open()
+}
+
+func (node *Node) open() error {
+	return node.openDir(node.cfg.DataDir)
+}
+
+// openDir loads the block chain of the given data directory.
+func (node *Node) openDir(dataDir string) error {
+	cfg := node.cfg
 	// Load the block database.
-	db, err := LoadBlockDB(cfg.DbType, cfg.DataDir, true)
+	db, err := LoadBlockDB(cfg.DbType, dataDir, true)
 	if err != nil {
 		log.Error("load block database", "error", err)
 		return err
@@ -66,9 +76,9 @@ func (node *Node) init(cfg *Config) error {
 		return err
 	}
 	node.bc = bc
-	node.name = path.Base(cfg.DataDir)
+	node.name = path.Base(dataDir)
 
-	log.Info(fmt.Sprintf("Load Data:%s", cfg.DataDir))
+	log.Info(fmt.Sprintf("Load Data:%s", dataDir))
 
 	return nil
 }
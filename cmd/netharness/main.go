@@ -0,0 +1,176 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// netharness launches a private network of qitmeer nodes on this host and
+// checks that they converge.  The nodes mine blocks and exchange transactions
+// over the RPC, are partitioned into two groups and healed, and restarted one
+// at a time, and after every round all the nodes must agree on the blocks of
+// the DAG, their order and the tips, and hold every transaction sent.
+//
+// Every node listens for its peers on its own loopback address 127.0.0.<n>,
+// which requires a host routing the whole 127.0.0.0/8 to the loopback
+// interface, as Linux does.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/params"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+var (
+	program   = flag.String("qitmeer", "qitmeer", "path of the qitmeer node binary")
+	nodeCount = flag.Int("nodes", 4, "number of nodes of the network")
+	baseDir   = flag.String("dir", "", "home directory of the nodes (a temporary directory by default)")
+	keep      = flag.Bool("keep", false, "keep the home directory of the nodes after a successful run")
+	basePort  = flag.Int("baseport", 39000, "first port of the nodes, each of them using three ports")
+	rounds    = flag.Int("rounds", 3, "number of rounds of the scenario")
+	blocks    = flag.Int("blocks", 4, "number of blocks mined by every node in a round")
+	txCount   = flag.Int("txs", 2, "number of transactions sent in a round")
+	partition = flag.Bool("partition", true, "partition the network in the middle round")
+	restart   = flag.Bool("restart", true, "restart a node in every round")
+	timeout   = flag.Duration("timeout", 2*time.Minute, "time allowed to the nodes to start and to converge")
+)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage:", os.Args[0], "[-qitmeer <binary>] [-nodes <n>] [-dir <dir>] [options]")
+		flag.PrintDefaults()
+		fmt.Fprintln(os.Stderr, `
+Runs the scenario on a private network of nodes and exits with status 1 when
+the nodes did not converge.  The output of every node is kept in qitmeer.out
+in its home directory, which is not removed when the run fails.`)
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	if *nodeCount < 2 {
+		die(fmt.Errorf("the network needs at least 2 nodes"))
+	}
+	if *rounds < 1 || *blocks < 1 || *txCount < 0 {
+		die(fmt.Errorf("invalid scenario: %d rounds of %d blocks and %d "+
+			"transactions", *rounds, *blocks, *txCount))
+	}
+	dir := *baseDir
+	if len(dir) == 0 {
+		var err error
+		dir, err = ioutil.TempDir("", "netharness")
+		if err != nil {
+			die(err)
+		}
+	}
+	nw, err := newNetwork(*program, dir, *nodeCount, *basePort, *timeout)
+	if err != nil {
+		die(err)
+	}
+
+	err = run(nw)
+	stopErr := nw.stop()
+	if err == nil {
+		err = stopErr
+	}
+	if err != nil {
+		fmt.Printf("FAIL: %v\n", err)
+		fmt.Printf("The home directory of the nodes is %s\n", dir)
+		os.Exit(1)
+	}
+	fmt.Println("ok   the nodes converged")
+	if *keep {
+		fmt.Printf("The home directory of the nodes is %s\n", dir)
+	} else {
+		os.RemoveAll(dir)
+	}
+}
+
+// run runs the scenario on the network.
+func run(nw *network) error {
+	err := nw.start([][]*node{nw.nodes})
+	if err != nil {
+		return err
+	}
+	for _, n := range nw.nodes {
+		fmt.Printf("Started %s at %s, RPC port %d\n", n, n.multiAddr(),
+			n.rpcPort)
+	}
+
+	// Mine the coinbases spent by the transactions of the scenario, and
+	// enough blocks on top of them for them to mature.
+	spendable := *rounds * *txCount
+	funding := spendable + int(params.PrivNetParams.CoinbaseMaturity) + 1
+	hashes, err := nw.mine(nw.nodes[:1], funding)
+	if err != nil {
+		return err
+	}
+	nw.coinbases = hashes[:spendable]
+	err = nw.waitConvergence()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Mined %d blocks on %s\n", funding, nw.nodes[0])
+
+	for round := 0; round < *rounds; round++ {
+		_, err := nw.mine(nw.nodes, *blocks)
+		if err != nil {
+			return err
+		}
+		err = nw.sendTxs(*txCount)
+		if err != nil {
+			return err
+		}
+		// Every node mines the transactions it received before any of
+		// them is restarted.
+		_, err = nw.mine(nw.nodes, 1)
+		if err != nil {
+			return err
+		}
+
+		if *partition && round == *rounds/2 {
+			half := len(nw.nodes) / 2
+			err = nw.partition([][]*node{nw.nodes[:half], nw.nodes[half:]})
+			if err != nil {
+				return err
+			}
+			_, err = nw.mine(nw.nodes, *blocks)
+			if err != nil {
+				return err
+			}
+			err = nw.heal()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Round %d: partitioned and healed the network\n",
+				round)
+		}
+		if *restart {
+			n := nw.nodes[round%len(nw.nodes)]
+			err = nw.restart(n)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Round %d: restarted %s\n", round, n)
+		}
+
+		err = nw.waitConvergence()
+		if err != nil {
+			return fmt.Errorf("round %d: %v", round, err)
+		}
+		err = nw.checkTxs()
+		if err != nil {
+			return fmt.Errorf("round %d: %v", round, err)
+		}
+		fmt.Printf("Round %d: %d nodes converged, %d transactions sent\n",
+			round, len(nw.nodes), len(nw.txs))
+	}
+	return nil
+}
+
+func die(err error) {
+	fmt.Fprintln(os.Stderr, "error:", err)
+	os.Exit(1)
+}
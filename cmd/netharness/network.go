@@ -0,0 +1,339 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/address"
+	j "github.com/Qitmeer/qitmeer/core/json"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/core/types/pow"
+	"github.com/Qitmeer/qitmeer/crypto/ecc"
+	"github.com/Qitmeer/qitmeer/params"
+	"strings"
+	"sync"
+	"time"
+)
+
+// txFee is the fee paid by the transactions of the scenario, in atoms.
+const txFee = 100000
+
+// network is the private network of the nodes launched by the harness.  All
+// the blocks are mined to the address of a key generated for the run, so that
+// their coinbases can be spent by the transactions of the scenario.
+type network struct {
+	program string
+	nodes   []*node
+	timeout time.Duration
+
+	privKey    string
+	miningAddr string
+
+	// coinbases are the hashes of the blocks whose coinbase is not spent
+	// yet, the oldest first.
+	coinbases []string
+
+	// txs are the ids of the transactions sent.
+	txs []string
+}
+
+func newNetwork(program string, baseDir string, count int, basePort int,
+	timeout time.Duration) (*network, error) {
+
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		return nil, err
+	}
+	_, pub := ecc.Secp256k1.PrivKeyFromBytes(key)
+	addr, err := address.NewPubKeyHashAddress(
+		hash.Hash160(pub.SerializeCompressed()), &params.PrivNetParams,
+		ecc.ECDSA_Secp256k1)
+	if err != nil {
+		return nil, err
+	}
+	nw := &network{
+		program:    program,
+		timeout:    timeout,
+		privKey:    hex.EncodeToString(key),
+		miningAddr: addr.Encode(),
+	}
+	for i := 0; i < count; i++ {
+		nw.nodes = append(nw.nodes, newNode(i, baseDir, basePort))
+	}
+	return nw, nil
+}
+
+// start starts the nodes of every group connected to the nodes of their group
+// and refusing the nodes of the other groups.  The nodes never started before
+// only connect to the nodes of their group started before them.
+func (nw *network) start(groups [][]*node) error {
+	for _, group := range groups {
+		var denied []string
+		for _, n := range nw.nodes {
+			if !contains(group, n) {
+				denied = append(denied, n.ip)
+			}
+		}
+		for _, n := range group {
+			var peers []string
+			for _, peer := range group {
+				if peer != n && len(peer.peerID) > 0 {
+					peers = append(peers, peer.multiAddr())
+				}
+			}
+			err := n.start(nw.program, nw.miningAddr, peers, denied,
+				nw.timeout)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// stop stops all the nodes and returns the first error.
+func (nw *network) stop() error {
+	var firstErr error
+	for _, n := range nw.nodes {
+		err := n.stop()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// partition restarts the nodes split into the groups.
+func (nw *network) partition(groups [][]*node) error {
+	err := nw.stop()
+	if err != nil {
+		return err
+	}
+	return nw.start(groups)
+}
+
+// heal restarts the nodes connected to each other.
+func (nw *network) heal() error {
+	return nw.partition([][]*node{nw.nodes})
+}
+
+// restart restarts the node connected to all the other nodes.
+func (nw *network) restart(n *node) error {
+	err := n.stop()
+	if err != nil {
+		return err
+	}
+	var peers []string
+	for _, peer := range nw.nodes {
+		if peer != n {
+			peers = append(peers, peer.multiAddr())
+		}
+	}
+	return n.start(nw.program, nw.miningAddr, peers, nil, nw.timeout)
+}
+
+// mine makes every node mine the blocks at the same time, and returns the
+// hashes of the blocks in the order of the nodes.
+func (nw *network) mine(nodes []*node, blocks int) ([]string, error) {
+	var wg sync.WaitGroup
+	hashes := make([][]string, len(nodes))
+	errs := make([]error, len(nodes))
+	for i, n := range nodes {
+		wg.Add(1)
+		go func(i int, n *node) {
+			defer wg.Done()
+			hashes[i], errs[i] = n.client.Generate(uint32(blocks),
+				pow.BLAKE2BD)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("%s: generate: %v", n, errs[i])
+			}
+		}(i, n)
+	}
+	wg.Wait()
+
+	var all []string
+	for i := range nodes {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		all = append(all, hashes[i]...)
+	}
+	return all, nil
+}
+
+// sendTxs sends the transactions spending the oldest unspent coinbases back
+// to the mining address, each of them to the next node in turn.
+func (nw *network) sendTxs(count int) error {
+	for i := 0; i < count; i++ {
+		if len(nw.coinbases) == 0 {
+			return fmt.Errorf("no coinbase left to spend")
+		}
+		n := nw.nodes[len(nw.txs)%len(nw.nodes)]
+		blockHash := nw.coinbases[0]
+		nw.coinbases = nw.coinbases[1:]
+
+		txid, err := nw.spendCoinbase(n, blockHash)
+		if err != nil {
+			return fmt.Errorf("%s: spend coinbase of block %s: %v", n,
+				blockHash, err)
+		}
+		nw.txs = append(nw.txs, txid)
+	}
+	return nil
+}
+
+func (nw *network) spendCoinbase(n *node, blockHash string) (string, error) {
+	block, err := n.client.GetBlockFullTx(blockHash, true)
+	if err != nil {
+		return "", err
+	}
+	if len(block.Tx) == 0 {
+		return "", fmt.Errorf("block has no transactions")
+	}
+	coinbase := block.Tx[0]
+	for index, vout := range coinbase.Vout {
+		if vout.CoinId != uint16(types.MEERID) || vout.Amount <= txFee ||
+			!containsString(vout.ScriptPubKey.Addresses, nw.miningAddr) {
+			continue
+		}
+		raw, err := n.client.CreateRawTransaction(
+			[]j.TransactionInput{{Txid: coinbase.Txid, Vout: uint32(index)}},
+			j.Amounts{nw.miningAddr: vout.Amount - txFee}, 0)
+		if err != nil {
+			return "", err
+		}
+		signed, err := n.client.TxSign(nw.privKey, raw)
+		if err != nil {
+			return "", err
+		}
+		txHash, err := n.client.SendRawTransaction(signed, false)
+		if err != nil {
+			return "", err
+		}
+		return txHash.String(), nil
+	}
+	return "", fmt.Errorf("coinbase %s pays nothing to %s", coinbase.Txid,
+		nw.miningAddr)
+}
+
+// state is the view of the DAG of a node compared for the convergence.
+type state struct {
+	total  int64
+	count  int64
+	tips   string
+	hashes string
+}
+
+func (s *state) String() string {
+	return fmt.Sprintf("total=%d ordered=%d tips=[%s]", s.total, s.count,
+		s.tips)
+}
+
+func (n *node) state() (*state, error) {
+	total, err := n.client.GetBlockTotal()
+	if err != nil {
+		return nil, err
+	}
+	count, err := n.client.GetBlockCount()
+	if err != nil {
+		return nil, err
+	}
+	tips, err := n.client.Tips()
+	if err != nil {
+		return nil, err
+	}
+	hashes, err := n.client.GetBlockhashByRange(0, uint(count-1))
+	if err != nil {
+		return nil, err
+	}
+	s := &state{total: total, count: count, tips: strings.Join(tips, ",")}
+	ordered := make([]string, 0, len(hashes))
+	for _, h := range hashes {
+		ordered = append(ordered, h.String())
+	}
+	s.hashes = strings.Join(ordered, ",")
+	return s, nil
+}
+
+// waitConvergence waits until all the nodes have the same blocks in the same
+// order and the same tips, and returns an error describing the views of the
+// nodes when they did not converge in time.
+func (nw *network) waitConvergence() error {
+	deadline := time.Now().Add(nw.timeout)
+	for {
+		states := make([]*state, len(nw.nodes))
+		var err error
+		converged := true
+		for i, n := range nw.nodes {
+			states[i], err = n.state()
+			if err != nil {
+				err = fmt.Errorf("%s: %v", n, err)
+				converged = false
+				break
+			}
+			if i > 0 && (states[i].total != states[0].total ||
+				states[i].tips != states[0].tips ||
+				states[i].hashes != states[0].hashes) {
+				converged = false
+			}
+		}
+		if converged {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("not converged after %v: %v",
+					nw.timeout, err)
+			}
+			var views []string
+			for i, n := range nw.nodes {
+				views = append(views, fmt.Sprintf("%s: %s", n, states[i]))
+			}
+			return fmt.Errorf("not converged after %v:\n  %s", nw.timeout,
+				strings.Join(views, "\n  "))
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// checkTxs returns an error when one of the transactions sent is not in a
+// block of every node.
+func (nw *network) checkTxs() error {
+	for _, n := range nw.nodes {
+		for _, txid := range nw.txs {
+			tx, err := n.client.GetRawTransactionVerbose(txid)
+			if err != nil {
+				return fmt.Errorf("%s: transaction %s: %v", n, txid, err)
+			}
+			if len(tx.BlockHash) == 0 {
+				return fmt.Errorf("%s: transaction %s is not in a block",
+					n, txid)
+			}
+		}
+	}
+	return nil
+}
+
+func contains(nodes []*node, n *node) bool {
+	for _, v := range nodes {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/rpc/client"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	rpcUser = "netharness"
+	rpcPass = "netharness"
+
+	// stopTimeout is how long a node is given to shut down once
+	// interrupted before it is killed.
+	stopTimeout = 30 * time.Second
+)
+
+// node is a qitmeer node process of the private network.  Every node listens
+// for the peers on its own loopback address, so that the others can be denied
+// by their address to partition the network, and serves the RPC on 127.0.0.1.
+type node struct {
+	index   int
+	ip      string
+	p2pPort int
+	rpcPort int
+	homeDir string
+	peerID  string
+
+	cmd    *exec.Cmd
+	out    *os.File
+	client *client.Client
+}
+
+func newNode(index int, baseDir string, basePort int) *node {
+	return &node{
+		index:   index,
+		ip:      fmt.Sprintf("127.0.0.%d", index+1),
+		p2pPort: basePort + 3*index,
+		rpcPort: basePort + 3*index + 2,
+		homeDir: filepath.Join(baseDir, fmt.Sprintf("node%d", index)),
+	}
+}
+
+func (n *node) String() string {
+	return fmt.Sprintf("node%d", n.index)
+}
+
+// multiAddr returns the address the other nodes connect to, which is only
+// known once the node was started and reported its peer id.
+func (n *node) multiAddr() string {
+	return fmt.Sprintf("/ip4/%s/tcp/%d/p2p/%s", n.ip, n.p2pPort, n.peerID)
+}
+
+func (n *node) args(miningAddr string, peers []string, denied []string) []string {
+	args := []string{
+		"--privnet",
+		"--nodiscovery",
+		"--notls",
+		"--txindex",
+		"--modules=qitmeer",
+		"--modules=miner",
+		"--modules=test",
+		"--miningaddr=" + miningAddr,
+		"--appdata=" + n.homeDir,
+		"--datadir=" + filepath.Join(n.homeDir, "data"),
+		"--logdir=" + filepath.Join(n.homeDir, "log"),
+		"--listen=" + n.ip,
+		"--p2ptcpport=" + strconv.Itoa(n.p2pPort),
+		"--p2pudpport=" + strconv.Itoa(n.p2pPort+1),
+		"--rpclisten=" + net.JoinHostPort("127.0.0.1", strconv.Itoa(n.rpcPort)),
+		"--rpcuser=" + rpcUser,
+		"--rpcpass=" + rpcPass,
+	}
+	for _, peer := range peers {
+		args = append(args, "--addpeer="+peer)
+	}
+	for _, ip := range denied {
+		args = append(args, "--blacklist="+ip+"/32")
+	}
+	return args
+}
+
+// start launches the node connecting to the peers and refusing the peers
+// listening on the denied addresses, and waits until its RPC server answers.
+// The output of the node is appended to qitmeer.out in its home directory.
+func (n *node) start(program string, miningAddr string, peers []string,
+	denied []string, timeout time.Duration) error {
+
+	err := os.MkdirAll(n.homeDir, 0700)
+	if err != nil {
+		return err
+	}
+	n.out, err = os.OpenFile(filepath.Join(n.homeDir, "qitmeer.out"),
+		os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	n.cmd = exec.Command(program, n.args(miningAddr, peers, denied)...)
+	n.cmd.Stdout = n.out
+	n.cmd.Stderr = n.out
+	err = n.cmd.Start()
+	if err != nil {
+		n.out.Close()
+		return fmt.Errorf("%s: %v", n, err)
+	}
+
+	n.client, err = client.New(&client.ConnConfig{
+		Host:         net.JoinHostPort("127.0.0.1", strconv.Itoa(n.rpcPort)),
+		User:         rpcUser,
+		Pass:         rpcPass,
+		DisableTLS:   true,
+		HTTPPostMode: true,
+	}, nil)
+	if err != nil {
+		n.stop()
+		return err
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		info, err := n.client.GetNodeInfo()
+		if err == nil {
+			n.peerID = info.ID
+			return nil
+		}
+		if time.Now().After(deadline) {
+			n.stop()
+			return fmt.Errorf("%s: RPC server not ready after %v: %v",
+				n, timeout, err)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// stop interrupts the node and waits for it to shut down, killing it after
+// stopTimeout.
+func (n *node) stop() error {
+	if n.cmd == nil || n.cmd.Process == nil {
+		return nil
+	}
+	if n.client != nil {
+		n.client.Shutdown()
+		n.client = nil
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- n.cmd.Wait()
+	}()
+	n.cmd.Process.Signal(os.Interrupt)
+
+	// The exit status of an interrupted node is not an error.
+	var err error
+	select {
+	case <-done:
+	case <-time.After(stopTimeout):
+		n.cmd.Process.Kill()
+		<-done
+		err = fmt.Errorf("%s: killed after not stopping for %v", n,
+			stopTimeout)
+	}
+	n.cmd = nil
+	n.out.Close()
+	return err
+}
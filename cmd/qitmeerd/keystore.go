@@ -0,0 +1,74 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/config"
+	"github.com/Qitmeer/qitmeer/crypto/keystore"
+	"github.com/Qitmeer/qitmeer/log"
+	"golang.org/x/crypto/ssh/terminal"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// openKeystore opens the keystore of the data directory when it is enabled,
+// creating it when it does not exist, and unlocks it with the passphrase read
+// from the terminal.
+func openKeystore(cfg *config.Config) (*keystore.Keystore, error) {
+	if !cfg.Keystore {
+		return nil, nil
+	}
+	path := filepath.Join(cfg.DataDir, keystore.DefaultFileName)
+	timeout := time.Duration(cfg.KeystoreTimeout) * time.Second
+
+	if !keystore.Exists(path) {
+		passphrase, err := readPassphrase("Enter the passphrase of the new keystore: ")
+		if err != nil {
+			return nil, err
+		}
+		confirm, err := readPassphrase("Confirm the passphrase: ")
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(passphrase, confirm) {
+			return nil, fmt.Errorf("the passphrases do not match")
+		}
+		ks, err := keystore.Create(path, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		log.Info("Created keystore", "path", path)
+		return ks, ks.Unlock(passphrase, timeout)
+	}
+
+	ks, err := keystore.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	passphrase, err := readPassphrase("Enter the keystore passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	return ks, ks.Unlock(passphrase, timeout)
+}
+
+// readPassphrase prints the prompt and reads a passphrase from the standard
+// input, without echo when it is a terminal.
+func readPassphrase(prompt string) ([]byte, error) {
+	fmt.Print(prompt)
+	fd := int(os.Stdin.Fd())
+	if terminal.IsTerminal(fd) {
+		passphrase, err := terminal.ReadPassword(fd)
+		fmt.Println()
+		return passphrase, err
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return nil, err
+	}
+	return bytes.TrimRight(line, "\r\n"), nil
+}
@@ -61,6 +61,9 @@ func qitmeerdMain(nodeChan chan<- *node.Node) error {
 
 	// Show version and home dir at startup.
 	log.Info("System info", "Qitmeer Version", version.String(), "Go version", runtime.Version())
+	if len(version.Commit) > 0 {
+		log.Info("System info", "Commit", version.Commit, "Build time", version.BuildTime)
+	}
 	log.Info("System info", "Home dir", cfg.HomeDir)
 
 	if cfg.NoFileLogging {
@@ -92,6 +95,30 @@ func qitmeerdMain(nodeChan chan<- *node.Node) error {
 
 		return nil
 	}
+	if cfg.DropAddrActivityIndex {
+		if err := index.DropAddrActivityIndex(db, interrupt); err != nil {
+			log.Error(fmt.Sprintf("%v", err))
+			return err
+		}
+
+		return nil
+	}
+	if cfg.DropUtxoAgeIndex {
+		if err := index.DropUtxoAgeIndex(db, interrupt); err != nil {
+			log.Error(fmt.Sprintf("%v", err))
+			return err
+		}
+
+		return nil
+	}
+	if cfg.DropMinerIndex {
+		if err := index.DropMinerIndex(db, interrupt); err != nil {
+			log.Error(fmt.Sprintf("%v", err))
+			return err
+		}
+
+		return nil
+	}
 	if cfg.DropTxIndex {
 		if err := index.DropTxIndex(db, interrupt); err != nil {
 			log.Error(fmt.Sprintf("%v", err))
@@ -108,8 +135,15 @@ func qitmeerdMain(nodeChan chan<- *node.Node) error {
 		return nil
 	}
 
+	// Unlock the keystore of the node secrets.
+	ks, err := openKeystore(cfg)
+	if err != nil {
+		log.Error("Unable to unlock keystore", "error", err)
+		return err
+	}
+
 	// Create node and start it.
-	n, err := node.NewNode(cfg, db, params.ActiveNetParams.Params, shutdownRequestChannel)
+	n, err := node.NewNode(cfg, db, params.ActiveNetParams.Params, ks, shutdownRequestChannel)
 	if err != nil {
 		log.Error("Unable to start server", "listeners", cfg.Listener, "error", err)
 		return err
@@ -0,0 +1,163 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package crash isolates the panics of the subsystems of the node, such as
+// the sync workers or the RPC handlers, so that a bug in one of them is
+// reported and the subsystem restarted instead of taking the whole node down.
+//
+// A crash report holds the subsystem, the panic, the stack of the panicking
+// goroutine and the recent events of the node, which are the last log
+// records kept by the handler of EventHandler.
+package crash
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/log"
+	"github.com/Qitmeer/qitmeer/metrics"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxEvents is the number of recent events held in a crash report.
+	MaxEvents = 32
+
+	// MaxReports is the number of crash reports kept in memory.
+	MaxReports = 16
+
+	// MaxRestartDelay is the longest delay before a panicking worker is
+	// restarted by Loop.
+	MaxRestartDelay = time.Minute
+)
+
+// restartDelay is the delay before a panicking worker is restarted by Loop
+// for the first time, which doubles with every consecutive panic.
+var restartDelay = time.Second
+
+var panicsCounter = metrics.NewCounter("crash/panics")
+
+// Report is the crash report of a panic recovered in a subsystem.
+type Report struct {
+	Subsystem string
+	Time      time.Time
+	Panic     string
+	Stack     string
+	Events    []string
+}
+
+// String returns the report as multi-line text.
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Crash report of subsystem %s at %s: %s\n", r.Subsystem,
+		r.Time.Format(time.RFC3339), r.Panic)
+	b.WriteString("Recent events:\n")
+	for _, event := range r.Events {
+		b.WriteString("  ")
+		b.WriteString(event)
+		b.WriteString("\n")
+	}
+	b.WriteString("Stack:\n")
+	b.WriteString(r.Stack)
+	return b.String()
+}
+
+var (
+	lock    sync.Mutex
+	events  []string
+	reports []Report
+)
+
+// EventHandler returns a log handler which keeps the last MaxEvents records
+// as the recent events of the crash reports.
+func EventHandler() log.Handler {
+	format := log.LogfmtFormat()
+	return log.FuncHandler(func(r *log.Record) error {
+		event := strings.TrimSuffix(string(format.Format(r)), "\n")
+		lock.Lock()
+		if len(events) == MaxEvents {
+			events = append(events[:0], events[1:]...)
+		}
+		events = append(events, event)
+		lock.Unlock()
+		return nil
+	})
+}
+
+// Recovered reports a panic recovered in the subsystem, with the stack of
+// the calling goroutine, so it must be called by the function deferred in the
+// panicking goroutine once recover returned r.  The report is logged, kept in
+// memory and returned.
+//
+// This function is safe for concurrent access.
+func Recovered(subsystem string, r interface{}) *Report {
+	report := Report{
+		Subsystem: subsystem,
+		Time:      time.Now(),
+		Panic:     fmt.Sprint(r),
+		Stack:     string(debug.Stack()),
+	}
+	lock.Lock()
+	report.Events = append([]string(nil), events...)
+	if len(reports) == MaxReports {
+		reports = append(reports[:0], reports[1:]...)
+	}
+	reports = append(reports, report)
+	lock.Unlock()
+
+	panicsCounter.Inc(1)
+	log.Error(report.String())
+	return &report
+}
+
+// Reports returns the last MaxReports crash reports, the oldest first.
+//
+// This function is safe for concurrent access.
+func Reports() []Report {
+	lock.Lock()
+	defer lock.Unlock()
+	return append([]Report(nil), reports...)
+}
+
+// Run calls fn and reports a panic in it as a crash of the subsystem.  It
+// returns whether fn panicked.
+func Run(subsystem string, fn func()) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			Recovered(subsystem, r)
+			panicked = true
+		}
+	}()
+	fn()
+	return false
+}
+
+// Loop runs the worker fn of the subsystem until it returns without
+// panicking or quit is closed.  A panicking worker is restarted after a delay
+// doubling with every consecutive panic, up to MaxRestartDelay, so the worker
+// must not leave a shared state inconsistent when it panics, and must return
+// once quit is closed.
+func Loop(subsystem string, quit <-chan struct{}, fn func()) {
+	delay := restartDelay
+	for {
+		start := time.Now()
+		if !Run(subsystem, fn) {
+			return
+		}
+		if time.Since(start) > MaxRestartDelay {
+			delay = restartDelay
+		}
+		log.Warn(fmt.Sprintf("Restarting %s in %v", subsystem, delay))
+		select {
+		case <-time.After(delay):
+		case <-quit:
+			return
+		}
+		delay *= 2
+		if delay > MaxRestartDelay {
+			delay = MaxRestartDelay
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package crash
+
+import (
+	"github.com/Qitmeer/qitmeer/log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	handler := EventHandler()
+	handler.Log(&log.Record{Time: time.Now(), Lvl: log.LvlInfo, Msg: "before the crash"})
+
+	if Run("test", func() {}) {
+		t.Fatalf("Run reported a panic of a function returning normally")
+	}
+	if !Run("test", func() { panic("boom") }) {
+		t.Fatalf("Run did not report a panic")
+	}
+
+	reports := Reports()
+	if len(reports) == 0 {
+		t.Fatalf("no crash report kept")
+	}
+	report := reports[len(reports)-1]
+	if report.Subsystem != "test" || report.Panic != "boom" {
+		t.Errorf("unexpected report %s: %s", report.Subsystem, report.Panic)
+	}
+	if !strings.Contains(report.Stack, "TestRun") {
+		t.Errorf("stack misses the panicking function:\n%s", report.Stack)
+	}
+	if len(report.Events) == 0 ||
+		!strings.Contains(report.Events[len(report.Events)-1], "before the crash") {
+		t.Errorf("recent events miss the last record: %v", report.Events)
+	}
+}
+
+func TestLoop(t *testing.T) {
+	restartDelay = time.Millisecond
+
+	runs := 0
+	Loop("test", make(chan struct{}), func() {
+		runs++
+		if runs < 3 {
+			panic("boom")
+		}
+	})
+	if runs != 3 {
+		t.Errorf("worker ran %d times, want 3", runs)
+	}
+
+	// A closed quit channel stops the restarts.
+	quit := make(chan struct{})
+	close(quit)
+	runs = 0
+	Loop("test", quit, func() {
+		runs++
+		panic("boom")
+	})
+	if runs != 1 {
+		t.Errorf("worker ran %d times after quit, want 1", runs)
+	}
+}
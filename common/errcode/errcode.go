@@ -0,0 +1,163 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package errcode classifies the errors of the node by code, so that the
+// callers, such as the RPC server, can handle them without matching their
+// messages.
+//
+// An error carries a code either by being an *Error, by implementing the
+// Coder interface, like the rule errors of the blockchain and of the mempool,
+// or by wrapping such an error.  The errors implement Unwrap and Is so that
+// errors.Is(err, errcode.NotFound) and errors.As work as expected.
+package errcode
+
+import (
+	"fmt"
+)
+
+// Code identifies a kind of error.
+type Code int
+
+// These constants are used to identify a kind of error.
+const (
+	// Unknown is the code of the errors which carry no code.
+	Unknown Code = iota
+
+	// Internal indicates an internal code consistency issue or an
+	// unexpected failure.
+	Internal
+
+	// InvalidParameter indicates a parameter given by the caller is
+	// invalid.
+	InvalidParameter
+
+	// NotFound indicates a requested item does not exist.
+	NotFound
+
+	// Duplicate indicates an item already exists.
+	Duplicate
+
+	// RuleViolation indicates a block or a transaction violates a
+	// consensus or policy rule.
+	RuleViolation
+
+	// Deserialization indicates data failed to decode.
+	Deserialization
+
+	// Database indicates a database failure.
+	Database
+
+	// Unavailable indicates the service can not answer yet, such as
+	// during the initial block download.
+	Unavailable
+)
+
+// Map of Code values back to their constant names for pretty printing.
+var codeStrings = map[Code]string{
+	Unknown:          "Unknown",
+	Internal:         "Internal",
+	InvalidParameter: "InvalidParameter",
+	NotFound:         "NotFound",
+	Duplicate:        "Duplicate",
+	RuleViolation:    "RuleViolation",
+	Deserialization:  "Deserialization",
+	Database:         "Database",
+	Unavailable:      "Unavailable",
+}
+
+// String returns the Code as a human-readable name.
+func (c Code) String() string {
+	if s := codeStrings[c]; s != "" {
+		return s
+	}
+	return fmt.Sprintf("Unknown Code (%d)", int(c))
+}
+
+// Error satisfies the error interface so that a code can be the target of
+// errors.Is.
+func (c Code) Error() string {
+	return c.String()
+}
+
+// Coder is implemented by the errors which carry a code.
+type Coder interface {
+	Code() Code
+}
+
+// Error is an error with a code, which optionally wraps the error causing it.
+type Error struct {
+	ErrorCode   Code   // Describes the kind of error
+	Description string // Human readable description of the issue
+	Err         error  // The wrapped error, if any
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Description
+	}
+	if e.Description == "" {
+		return e.Err.Error()
+	}
+	return e.Description + ": " + e.Err.Error()
+}
+
+// Code returns the code of the error.
+//
+// This is part of the Coder interface.
+func (e *Error) Code() Code {
+	return e.ErrorCode
+}
+
+// Unwrap returns the wrapped error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is returns whether the target is the code of the error, or an *Error with
+// the same code and description.
+func (e *Error) Is(target error) bool {
+	switch t := target.(type) {
+	case Code:
+		return t == e.ErrorCode
+	case *Error:
+		return t.ErrorCode == e.ErrorCode && t.Description == e.Description
+	}
+	return false
+}
+
+// New returns an error with the code and the formatted description.
+func New(c Code, format string, args ...interface{}) error {
+	return &Error{ErrorCode: c, Description: fmt.Sprintf(format, args...)}
+}
+
+// Wrap returns an error with the code which wraps err, or nil when err is
+// nil.  The description may be empty.
+func Wrap(c Code, err error, desc string) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{ErrorCode: c, Description: desc, Err: err}
+}
+
+// CodeOf returns the code of the first error of the chain of err which
+// carries one, or Unknown when none does.
+func CodeOf(err error) Code {
+	for err != nil {
+		if c, ok := err.(Coder); ok {
+			return c.Code()
+		}
+		u, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			break
+		}
+		err = u.Unwrap()
+	}
+	return Unknown
+}
+
+// Is returns whether the code of err is c.
+func Is(err error, c Code) bool {
+	return err != nil && CodeOf(err) == c
+}
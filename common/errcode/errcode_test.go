@@ -0,0 +1,89 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package errcode
+
+import (
+	"fmt"
+	"testing"
+)
+
+// codedError is an error carrying a code through the Coder interface.
+type codedError struct{}
+
+func (codedError) Error() string { return "coded" }
+func (codedError) Code() Code    { return Duplicate }
+
+// wrapper wraps an error without carrying a code.
+type wrapper struct{ err error }
+
+func (w wrapper) Error() string { return "wrapper: " + w.err.Error() }
+func (w wrapper) Unwrap() error { return w.err }
+
+func TestCodeOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code Code
+	}{
+		{"nil", nil, Unknown},
+		{"plain", fmt.Errorf("plain"), Unknown},
+		{"new", New(NotFound, "block %d", 1), NotFound},
+		{"wrap", Wrap(Database, fmt.Errorf("disk"), "store"), Database},
+		{"coder", codedError{}, Duplicate},
+		{"wrapped coder", wrapper{codedError{}}, Duplicate},
+		{"wrapped error", wrapper{New(InvalidParameter, "bad")}, InvalidParameter},
+		{"outer code wins", Wrap(Internal, codedError{}, ""), Internal},
+	}
+	for _, test := range tests {
+		if code := CodeOf(test.err); code != test.code {
+			t.Errorf("%s: got code %v, want %v", test.name, code, test.code)
+		}
+		if test.code != Unknown && !Is(test.err, test.code) {
+			t.Errorf("%s: Is(%v) is false", test.name, test.code)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	cause := fmt.Errorf("disk full")
+	tests := []struct {
+		err error
+		str string
+	}{
+		{New(NotFound, "block %d not found", 3), "block 3 not found"},
+		{Wrap(Database, cause, "store block"), "store block: disk full"},
+		{Wrap(Database, cause, ""), "disk full"},
+	}
+	for i, test := range tests {
+		if str := test.err.Error(); str != test.str {
+			t.Errorf("#%d: got %q, want %q", i, str, test.str)
+		}
+	}
+
+	if Wrap(Internal, nil, "nothing") != nil {
+		t.Errorf("Wrap of nil is not nil")
+	}
+	err := Wrap(Database, cause, "store block").(*Error)
+	if err.Unwrap() != cause {
+		t.Errorf("Unwrap did not return the cause")
+	}
+	if !err.Is(Database) || err.Is(NotFound) {
+		t.Errorf("Is does not match the code")
+	}
+	if !err.Is(&Error{ErrorCode: Database, Description: "store block"}) {
+		t.Errorf("Is does not match an equal error")
+	}
+}
+
+func TestCodeString(t *testing.T) {
+	for code, str := range codeStrings {
+		if code.String() != str || code.Error() != str {
+			t.Errorf("got %q, want %q", code.String(), str)
+		}
+	}
+	if Code(100).String() != "Unknown Code (100)" {
+		t.Errorf("unexpected string for an unknown code: %s", Code(100))
+	}
+}
@@ -122,6 +122,18 @@ func (hash *Hash) IsEqual(target *Hash) bool {
 	return *hash == *target
 }
 
+// Less returns true if hash is less than target as little-endian numbers.  It
+// is the canonical tie-break of the block DAG, and gives the same order as
+// comparing the strings of the hashes without encoding them.
+func (hash *Hash) Less(target *Hash) bool {
+	for i := HashSize - 1; i >= 0; i-- {
+		if hash[i] != target[i] {
+			return hash[i] < target[i]
+		}
+	}
+	return false
+}
+
 // NewHash returns a new Hash from a byte slice.  An error is returned if
 // the number of bytes passed in is not HashSize.
 func NewHash(newHash []byte) (*Hash, error) {
@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"math/rand"
 	"testing"
 )
 
@@ -64,3 +65,24 @@ func TestHashMeerXKeccakV1(t *testing.T) {
 	h = HashMeerXKeccakV1(b)
 	fmt.Println(hex.EncodeToString(h[:]))
 }
+
+func TestHashLess(t *testing.T) {
+	a := MustHexToDecodedHash("01")
+	b := MustHexToDecodedHash("0100")
+	if !a.Less(&b) || b.Less(&a) || a.Less(&a) {
+		t.Fatalf("%s < %s is wrong", a, b)
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		var x, y Hash
+		r.Read(x[:])
+		r.Read(y[:])
+		if i%10 == 0 {
+			y = x
+			y[r.Intn(HashSize)]++
+		}
+		if x.Less(&y) != (x.String() < y.String()) {
+			t.Fatalf("%s < %s differs from the string order", x, y)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package perf keeps the recent performance samples of the node by minute,
+// such as the database accesses by bucket, the RPC calls by method or the
+// block connection stages, so that a consolidated report of the last minutes
+// can be put together for support tickets and tuning.
+package perf
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// HistoryMinutes is the number of minutes of samples kept.
+const HistoryMinutes = 60
+
+// These constants are the categories of the samples recorded by the node.
+const (
+	// DBRead is the category of the reads of the database, by bucket.
+	DBRead = "db/read"
+
+	// DBWrite is the category of the writes and the deletions of the
+	// database, by bucket.
+	DBWrite = "db/write"
+
+	// RPC is the category of the RPC calls, by method.
+	RPC = "rpc"
+
+	// BlockStage is the category of the stages of the connection of the
+	// blocks to the chain.
+	BlockStage = "block/stage"
+)
+
+// slot holds the samples of a name recorded during a minute.
+type slot struct {
+	minute int64
+	count  uint64
+	total  time.Duration
+	max    time.Duration
+}
+
+// series holds the samples of a name for the last minutes, with the slot of
+// a minute at the index of the minute modulo HistoryMinutes.
+type series [HistoryMinutes]slot
+
+// Stat is the summary of the samples of a name over the last minutes.
+type Stat struct {
+	Name  string
+	Count uint64
+	Total time.Duration
+	Max   time.Duration
+}
+
+// Recorder records the samples by category and name.
+type Recorder struct {
+	lock   sync.Mutex
+	series map[string]map[string]*series
+	now    func() time.Time
+}
+
+// NewRecorder returns a new empty recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{
+		series: make(map[string]map[string]*series),
+		now:    time.Now,
+	}
+}
+
+// Record records a sample of the name in the category, which lasted d.  The
+// samples which are only counted, such as the database accesses, are
+// recorded with a zero duration.
+//
+// This function is safe for concurrent access.
+func (r *Recorder) Record(category, name string, d time.Duration) {
+	minute := r.now().Unix() / 60
+
+	r.lock.Lock()
+	names, ok := r.series[category]
+	if !ok {
+		names = make(map[string]*series)
+		r.series[category] = names
+	}
+	s, ok := names[name]
+	if !ok {
+		s = new(series)
+		names[name] = s
+	}
+	sl := &s[minute%HistoryMinutes]
+	if sl.minute != minute {
+		*sl = slot{minute: minute}
+	}
+	sl.count++
+	sl.total += d
+	if d > sl.max {
+		sl.max = d
+	}
+	r.lock.Unlock()
+}
+
+// Stats returns the summaries of the names of the category which have
+// samples over the last minutes, including the current one, sorted by name.
+// The minutes are limited to HistoryMinutes.
+//
+// This function is safe for concurrent access.
+func (r *Recorder) Stats(category string, minutes int) []Stat {
+	if minutes > HistoryMinutes {
+		minutes = HistoryMinutes
+	}
+	first := r.now().Unix()/60 - int64(minutes) + 1
+
+	r.lock.Lock()
+	stats := make([]Stat, 0, len(r.series[category]))
+	for name, s := range r.series[category] {
+		stat := Stat{Name: name}
+		for _, sl := range s {
+			if sl.minute < first || sl.count == 0 {
+				continue
+			}
+			stat.Count += sl.count
+			stat.Total += sl.total
+			if sl.max > stat.Max {
+				stat.Max = sl.max
+			}
+		}
+		if stat.Count > 0 {
+			stats = append(stats, stat)
+		}
+	}
+	r.lock.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+// defaultRecorder is the recorder of the node.
+var defaultRecorder = NewRecorder()
+
+// Record records a sample with the recorder of the node.  See
+// Recorder.Record for details.
+func Record(category, name string, d time.Duration) {
+	defaultRecorder.Record(category, name, d)
+}
+
+// RecordSince records a sample which started at start with the recorder of
+// the node, which makes it handy to defer.
+func RecordSince(category, name string, start time.Time) {
+	defaultRecorder.Record(category, name, time.Since(start))
+}
+
+// Stats returns the summaries of the samples of the category recorded by the
+// node.  See Recorder.Stats for details.
+func Stats(category string, minutes int) []Stat {
+	return defaultRecorder.Stats(category, minutes)
+}
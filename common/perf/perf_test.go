@@ -0,0 +1,57 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package perf
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	r := NewRecorder()
+	r.now = func() time.Time { return now }
+
+	r.Record(RPC, "getBlock", 3*time.Millisecond)
+	r.Record(RPC, "getBlock", 5*time.Millisecond)
+	r.Record(RPC, "getPeerInfo", time.Millisecond)
+	r.Record(DBRead, "utxoset", 0)
+
+	now = now.Add(2 * time.Minute)
+	r.Record(RPC, "getBlock", 2*time.Millisecond)
+
+	tests := []struct {
+		minutes int
+		want    []Stat
+	}{
+		{1, []Stat{
+			{Name: "getBlock", Count: 1, Total: 2 * time.Millisecond, Max: 2 * time.Millisecond},
+		}},
+		{3, []Stat{
+			{Name: "getBlock", Count: 3, Total: 10 * time.Millisecond, Max: 5 * time.Millisecond},
+			{Name: "getPeerInfo", Count: 1, Total: time.Millisecond, Max: time.Millisecond},
+		}},
+	}
+	for _, test := range tests {
+		got := r.Stats(RPC, test.minutes)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d minutes: got %+v, want %+v", test.minutes, got, test.want)
+		}
+	}
+
+	// The slot of a minute is reused once the history wrapped around.
+	now = now.Add(HistoryMinutes * time.Minute)
+	r.Record(RPC, "getBlock", time.Millisecond)
+	got := r.Stats(RPC, HistoryMinutes*2)
+	want := []Stat{{Name: "getBlock", Count: 1, Total: time.Millisecond, Max: time.Millisecond}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrapped history: got %+v, want %+v", got, want)
+	}
+
+	if stats := r.Stats(DBWrite, HistoryMinutes); len(stats) != 0 {
+		t.Errorf("unexpected stats for an unknown category: %+v", stats)
+	}
+}
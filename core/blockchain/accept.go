@@ -8,6 +8,7 @@ package blockchain
 
 import (
 	"fmt"
+	"github.com/Qitmeer/qitmeer/common/perf"
 	"github.com/Qitmeer/qitmeer/core/blockchain/token"
 	"github.com/Qitmeer/qitmeer/core/blockdag"
 	"github.com/Qitmeer/qitmeer/core/types"
@@ -37,6 +38,9 @@ func checkCoinbaseUniqueHeight(blockHeight uint64, block *types.SerializedBlock)
 }
 
 // IsFinalizedTransaction determines whether or not a transaction is finalized.
+// A lock time below txscript.LockTimeThreshold is order based and compared to
+// the main chain height of the block, while a greater lock time is a unix
+// timestamp compared to the block time.
 func IsFinalizedTransaction(tx *types.Tx, blockHeight uint64, blockTime time.Time) bool {
 	// Lock time of zero means the transaction is finalized.
 	msgTx := tx.Transaction()
@@ -71,6 +75,17 @@ func IsFinalizedTransaction(tx *types.Tx, blockHeight uint64, blockTime time.Tim
 	return true
 }
 
+// LockTimeCursor returns the main chain height of the next block and the past
+// median time of the main chain tip.  A transaction whose lock time is below
+// the cursor of its kind is finalized in any next block, since the timestamp
+// of the next block is always after the past median time.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) LockTimeCursor() (uint64, time.Time) {
+	best := b.BestSnapshot()
+	return uint64(best.GraphState.GetMainHeight()) + 1, best.MedianTime
+}
+
 // maybeAcceptBlock potentially accepts a block into the block chain and, if
 // accepted, returns the length of the fork the block extended.  It performs
 // several validation checks which depend on its position within the block chain
@@ -99,7 +114,9 @@ func (b *BlockChain) maybeAcceptBlock(block *types.SerializedBlock, flags Behavi
 	}
 	// The block must pass all of the validation rules which depend on the
 	// position of the block within the block chain.
+	start := time.Now()
 	err := b.checkBlockContext(block, mainParent, flags)
+	perf.RecordSince(perf.BlockStage, "checkBlockContext", start)
 	if err != nil {
 		return err
 	}
@@ -109,8 +126,11 @@ func (b *BlockChain) maybeAcceptBlock(block *types.SerializedBlock, flags Behavi
 	b.pruner.pruneChainIfNeeded()
 
 	//dag
-	newOrders, oldOrders, ib, isMainChainTipChange := b.bd.AddBlock(newNode)
-	if newOrders == nil || newOrders.Len() == 0 || ib == nil {
+	start = time.Now()
+	lastFP := b.bd.GetFinalityPoint()
+	orderChange, ib, isMainChainTipChange := b.bd.AddBlock(newNode)
+	perf.RecordSince(perf.BlockStage, "addBlockToDAG", start)
+	if orderChange.IsEmpty() || ib == nil {
 		return fmt.Errorf("Irreparable error![%s]\n", newNode.GetHash().String())
 	}
 	block.SetOrder(uint64(ib.GetOrder()))
@@ -127,27 +147,41 @@ func (b *BlockChain) maybeAcceptBlock(block *types.SerializedBlock, flags Behavi
 	// blocks that fail to connect available for further analysis.
 	//
 	// Also, store the associated block index entry.
+	start = time.Now()
 	err = b.db.Update(func(dbTx database.Tx) error {
 		if err := dbMaybeStoreBlock(dbTx, block); err != nil {
 			return err
 		}
 		return nil
 	})
+	perf.RecordSince(perf.BlockStage, "storeBlock", start)
 	if err != nil {
 		panic(err.Error())
 	}
 	// Connect the passed block to the chain while respecting proper chain
 	// selection according to the chain with the most proof of work.  This
 	// also handles validation of the transaction scripts.
-	_, err = b.connectDagChain(ib, block, newOrders, oldOrders)
+	_, err = b.connectDagChain(ib, block, orderChange)
 	if err != nil {
 		log.Warn(fmt.Sprintf("%s", err))
 	}
 
-	err = b.updateBestState(ib, block, newOrders)
+	start = time.Now()
+	err = b.updateBestState(ib, block, orderChange.Attached)
+	perf.RecordSince(perf.BlockStage, "updateBestState", start)
+	if err != nil {
+		panic(err.Error())
+	}
+	// The utxo cache is flushed after a reorganization and when the
+	// finality point advances, now that the DAG state is committed.
+	fp := b.bd.GetFinalityPoint()
+	err = b.maybeFlushUtxoCache(orderChange.IsReorganize() || fp != lastFP)
 	if err != nil {
 		panic(err.Error())
 	}
+	if b.assert {
+		b.assertDAGInvariants(ib)
+	}
 	// Notify the caller that the new block was accepted into the block
 	// chain.  The caller would typically want to react by relaying the
 	// inventory to other peers.
@@ -156,6 +190,13 @@ func (b *BlockChain) maybeAcceptBlock(block *types.SerializedBlock, flags Behavi
 		Block:                block,
 		Flags:                flags,
 	})
+	if fp != nil && fp != lastFP {
+		b.sendNotification(FinalityPointAdvanced, &FinalityPointNotifyData{
+			Hash:   fp.GetHash(),
+			Order:  uint64(fp.GetOrder()),
+			Height: uint64(fp.GetHeight()),
+		})
+	}
 
 	return nil
 }
@@ -169,8 +210,9 @@ func (b *BlockChain) FastAcceptBlock(block *types.SerializedBlock) error {
 
 	newNode := NewBlockNode(&block.Block().Header, block.Block().Parents)
 	//dag
-	newOrders, oldOrders, ib, _ := b.bd.AddBlock(newNode)
-	if newOrders == nil || newOrders.Len() == 0 || ib == nil {
+	lastFP := b.bd.GetFinalityPoint()
+	orderChange, ib, _ := b.bd.AddBlock(newNode)
+	if orderChange.IsEmpty() || ib == nil {
 		return fmt.Errorf("Irreparable error![%s]\n", newNode.GetHash().String())
 	}
 
@@ -187,12 +229,17 @@ func (b *BlockChain) FastAcceptBlock(block *types.SerializedBlock) error {
 		return err
 	}
 
-	_, err = b.connectDagChain(ib, block, newOrders, oldOrders)
+	_, err = b.connectDagChain(ib, block, orderChange)
 	if err != nil {
 		log.Warn(fmt.Sprintf("%s", err))
 	}
 
-	return b.updateBestState(ib, block, newOrders)
+	err = b.updateBestState(ib, block, orderChange.Attached)
+	if err != nil {
+		return err
+	}
+	return b.maybeFlushUtxoCache(orderChange.IsReorganize() ||
+		b.bd.GetFinalityPoint() != lastFP)
 }
 
 func (b *BlockChain) updateTokenState(node blockdag.IBlock, block *types.SerializedBlock, rollback bool) error {
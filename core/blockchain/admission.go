@@ -0,0 +1,198 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/errcode"
+	"github.com/Qitmeer/qitmeer/metrics"
+	gometrics "github.com/rcrowley/go-metrics"
+	"sync"
+	"time"
+)
+
+// WorkPriority is the priority of a work item competing for the block
+// processing of the chain.  The lower values are served first.
+type WorkPriority int
+
+const (
+	// PrioritySync is the priority of the blocks downloaded from the peers.
+	PrioritySync WorkPriority = iota
+
+	// PriorityMined is the priority of the blocks mined locally or
+	// submitted through the RPC server.
+	PriorityMined
+
+	// PriorityRescan is the priority of the blocks read by rescans.
+	PriorityRescan
+
+	numWorkPriorities
+)
+
+var workPriorityStrings = [numWorkPriorities]string{
+	PrioritySync:   "sync",
+	PriorityMined:  "mined",
+	PriorityRescan: "rescan",
+}
+
+// String returns the WorkPriority as a human-readable name.
+func (p WorkPriority) String() string {
+	if p >= 0 && p < numWorkPriorities {
+		return workPriorityStrings[p]
+	}
+	return fmt.Sprintf("Unknown WorkPriority (%d)", int(p))
+}
+
+// ErrAdmissionQueueFull is returned when the work can not wait for the block
+// processing because too much work of the same priority is already waiting.
+var ErrAdmissionQueueFull = errcode.New(errcode.Unavailable,
+	"block processing queue is full")
+
+var (
+	admissionWaitTimer   = metrics.NewTimer("blockchain/admission/wait")
+	admissionDepthGauges [numWorkPriorities]gometrics.Gauge
+)
+
+func init() {
+	for p := WorkPriority(0); p < numWorkPriorities; p++ {
+		admissionDepthGauges[p] = metrics.NewGauge("blockchain/admission/depth/" + p.String())
+	}
+}
+
+// admissionQueue lets one work item at a time process blocks, serving the
+// waiting work by priority, then in arrival order.  A queue with a zero depth
+// admits all the work at once, like the chain did without it.
+type admissionQueue struct {
+	lock     sync.Mutex
+	depth    int
+	busy     bool
+	waiting  [numWorkPriorities][]chan struct{}
+	admitted [numWorkPriorities]uint64
+}
+
+// admit waits until the work of the priority may process blocks, and returns
+// the function which must be called once it is done.  The wait is aborted
+// when the context is done.
+func (q *admissionQueue) admit(ctx context.Context, p WorkPriority) (func(), error) {
+	q.lock.Lock()
+	if q.depth <= 0 {
+		q.lock.Unlock()
+		return func() {}, nil
+	}
+	if !q.busy {
+		q.busy = true
+		q.admitted[p]++
+		q.lock.Unlock()
+		return q.release, nil
+	}
+	if len(q.waiting[p]) >= q.depth {
+		q.lock.Unlock()
+		return nil, ErrAdmissionQueueFull
+	}
+	ready := make(chan struct{})
+	q.waiting[p] = append(q.waiting[p], ready)
+	admissionDepthGauges[p].Update(int64(len(q.waiting[p])))
+	q.lock.Unlock()
+
+	start := time.Now()
+	select {
+	case <-ready:
+		admissionWaitTimer.UpdateSince(start)
+		return q.release, nil
+	case <-ctx.Done():
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for i, ch := range q.waiting[p] {
+		if ch == ready {
+			q.waiting[p] = append(q.waiting[p][:i], q.waiting[p][i+1:]...)
+			admissionDepthGauges[p].Update(int64(len(q.waiting[p])))
+			return nil, ctx.Err()
+		}
+	}
+	// The work was admitted while giving up, so hand it over.
+	q.releaseLocked()
+	return nil, ctx.Err()
+}
+
+// release hands the block processing over to the next waiting work.
+func (q *admissionQueue) release() {
+	q.lock.Lock()
+	q.releaseLocked()
+	q.lock.Unlock()
+}
+
+// releaseLocked hands the block processing over to the next waiting work.
+//
+// This function MUST be called with the queue lock held.
+func (q *admissionQueue) releaseLocked() {
+	for p := range q.waiting {
+		if len(q.waiting[p]) == 0 {
+			continue
+		}
+		ready := q.waiting[p][0]
+		q.waiting[p] = q.waiting[p][1:]
+		q.admitted[p]++
+		admissionDepthGauges[p].Update(int64(len(q.waiting[p])))
+		close(ready)
+		return
+	}
+	q.busy = false
+}
+
+// AdmissionStats is the state of the block processing queue.
+type AdmissionStats struct {
+	// Depth is the maximum number of work items of each priority waiting
+	// for the block processing, or zero when the queue is disabled.
+	Depth int
+
+	// Waiting is the number of work items waiting, by priority.
+	Waiting [numWorkPriorities]int
+
+	// Admitted is the number of work items admitted since the start, by
+	// priority.
+	Admitted [numWorkPriorities]uint64
+}
+
+// Admit waits until the work of the priority may process blocks, and returns
+// the function which must be called once it is done.  The work is refused
+// with ErrAdmissionQueueFull when too much work of the same priority is
+// waiting, and the wait is aborted when the context is done.  ProcessBlock
+// admits itself, so the callers only admit the other work, such as rescans.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) Admit(ctx context.Context, p WorkPriority) (func(), error) {
+	return b.admission.admit(ctx, p)
+}
+
+// AdmissionCongested returns whether the work of the priority would be
+// refused by the block processing queue, so that the callers can hold back
+// new work, such as the p2p layer requesting more blocks.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) AdmissionCongested(p WorkPriority) bool {
+	q := &b.admission
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	return q.depth > 0 && len(q.waiting[p]) >= q.depth
+}
+
+// AdmissionStats returns the state of the block processing queue.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) AdmissionStats() *AdmissionStats {
+	q := &b.admission
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	stats := &AdmissionStats{Depth: q.depth, Admitted: q.admitted}
+	for p := range q.waiting {
+		stats.Waiting[p] = len(q.waiting[p])
+	}
+	return stats
+}
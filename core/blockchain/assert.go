@@ -0,0 +1,63 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/types"
+)
+
+// assertDAGInvariants panics when the DAG breaks one of its invariants once
+// the block was added.  See blockdag.CheckInvariants for the invariants.
+func (b *BlockChain) assertDAGInvariants(ib blockdag.IBlock) {
+	err := b.bd.CheckInvariants(ib.GetHash())
+	if err != nil {
+		panic(AssertError(fmt.Sprintf("DAG invariant violated after "+
+			"block %s: %v", ib.GetHash(), err)))
+	}
+}
+
+// assertUtxoConservation panics when the block creates MEER out of thin air,
+// which is when the MEER paid by its connected transactions exceeds the MEER
+// they spend, along with the fees collected by the spent coinbases, plus the
+// subsidy of the block.
+func (b *BlockChain) assertUtxoConservation(block *types.SerializedBlock, stxos []SpentTxOut) {
+	var in, out int64
+	for _, stxo := range stxos {
+		if stxo.Amount.Id == types.MEERID {
+			in += stxo.Amount.Value
+		}
+		if stxo.Fees.Id == types.MEERID {
+			in += stxo.Fees.Value
+		}
+	}
+	for _, tx := range block.Transactions() {
+		// Only the transactions connected by checkTransactionsAndConnect
+		// pay their outputs.
+		if tx.IsDuplicate && !tx.Tx.IsCoinBase() {
+			continue
+		}
+		if types.IsTokenTx(tx.Tx) && !types.IsTokenMintTx(tx.Tx) {
+			continue
+		}
+		for _, txOut := range tx.Tx.TxOut {
+			if txOut.Amount.Id == types.MEERID {
+				out += txOut.Amount.Value
+			}
+		}
+	}
+
+	parents := blockdag.NewIdSet()
+	for _, v := range block.Block().Parents {
+		parents.Add(b.bd.GetBlockId(v))
+	}
+	subsidy := b.subsidyCache.CalcBlockSubsidy(int64(b.bd.GetBlues(parents)))
+	if out > in+subsidy {
+		panic(AssertError(fmt.Sprintf("block %s pays %d atoms of MEER, "+
+			"but spends %d atoms and has a subsidy of %d atoms",
+			block.Hash(), out, in, subsidy)))
+	}
+}
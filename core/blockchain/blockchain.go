@@ -3,10 +3,10 @@
 package blockchain
 
 import (
-	"container/list"
 	"encoding/binary"
 	"fmt"
 	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/common/perf"
 	"github.com/Qitmeer/qitmeer/common/roughtime"
 	"github.com/Qitmeer/qitmeer/common/util"
 	"github.com/Qitmeer/qitmeer/core/blockchain/token"
@@ -70,11 +70,13 @@ type BlockChain struct {
 	// runtime.  They are protected by the chain lock.
 	noVerify      bool
 	noCheckpoints bool
+	readOnly      bool
 
 	// These fields are related to handling of orphan blocks.  They are
 	// protected by a combination of the chain lock and the orphan lock.
 	orphanLock   sync.RWMutex
 	orphans      map[hash.Hash]*orphanBlock
+	prevOrphans  map[hash.Hash][]*orphanBlock
 	oldestOrphan *orphanBlock
 
 	// These fields are related to checkpoint handling.  They are protected
@@ -116,6 +118,23 @@ type BlockChain struct {
 	warningCaches      []thresholdStateCache
 	deploymentCaches   []thresholdStateCache
 	unknownRulesWarned bool
+
+	// prevalidated remembers the candidate blocks which passed the
+	// contextual validation of CheckConnectBlockTemplate.
+	prevalidated prevalidatedCache
+
+	// admission orders the work competing for the block processing.
+	admission admissionQueue
+
+	// blockDelays remembers how late the latest blocks were received.
+	blockDelays blockDelays
+
+	// utxoCache caches the utxo set in front of the database.
+	utxoCache *utxoCache
+
+	// assert enables the checks of the consensus invariants after every
+	// block added to the DAG.
+	assert bool
 }
 
 // Config is a descriptor which specifies the blockchain instance configuration.
@@ -177,6 +196,28 @@ type Config struct {
 
 	// Cache Invalid tx
 	CacheInvalidTx bool
+
+	// AdmissionQueueDepth is the maximum number of work items of each
+	// priority waiting for the block processing.  Zero disables the queue.
+	AdmissionQueueDepth int
+
+	// Assert enables the expensive checks of the consensus invariants after
+	// every block added to the DAG, which panic at the first violation.
+	Assert bool
+
+	// FinalityDepth is the number of main chain blocks on top of the
+	// hourglass block which becomes the finality point.  Blocks which do not
+	// have the finality point in their past are rejected.  Zero disables the
+	// finality point.
+	FinalityDepth uint
+
+	// AnticoneCacheSize is the approximate memory in bytes used to cache the
+	// anticones of the blocks of the DAG.  Zero disables the cache.
+	AnticoneCacheSize uint64
+
+	// UtxoCacheSize is the approximate memory in bytes used to cache the
+	// utxo set.  Zero disables the cache.
+	UtxoCacheSize uint64
 }
 
 // BestState houses information about the current best block and other info
@@ -281,6 +322,19 @@ func (b *BlockChain) OrderRange(startOrder, endOrder uint64) ([]hash.Hash, error
 	return hashes, nil
 }
 
+// setAnticoneSize applies the anticone size override and the scheduled changes
+// of the anticone size of the network to the DAG before it is initialized.
+func setAnticoneSize(bd *blockdag.BlockDAG, par *params.Params) {
+	changes := make([]blockdag.AnticoneSizeChange, 0, len(par.AnticoneSizeChanges))
+	for _, change := range par.AnticoneSizeChanges {
+		changes = append(changes, blockdag.AnticoneSizeChange{
+			MainHeight: uint(change.MainHeight),
+			Size:       change.Size,
+		})
+	}
+	bd.SetAnticoneSize(par.AnticoneSize, changes)
+}
+
 // New returns a BlockChain instance using the provided configuration details.
 func New(config *Config) (*BlockChain, error) {
 	// Enforce required config fields.
@@ -337,14 +391,19 @@ func New(config *Config) (*BlockChain, error) {
 		sigCache:           config.SigCache,
 		indexManager:       config.IndexManager,
 		orphans:            make(map[hash.Hash]*orphanBlock),
+		prevOrphans:        make(map[hash.Hash][]*orphanBlock),
 		CacheInvalidTx:     config.CacheInvalidTx,
 		CacheNotifications: []*Notification{},
 		warningCaches:      newThresholdCaches(VBNumBits),
 		deploymentCaches:   newThresholdCaches(params.DefinedDeployments),
+		admission:          admissionQueue{depth: config.AdmissionQueueDepth},
+		assert:             config.Assert,
+		utxoCache:          newUtxoCache(config.UtxoCacheSize),
 	}
 	b.subsidyCache = NewSubsidyCache(0, b.params)
 
 	b.bd = &blockdag.BlockDAG{}
+	setAnticoneSize(b.bd, par)
 	b.bd.Init(config.DAGType, b.CalcWeight,
 		1.0/float64(par.TargetTimePerBlock/time.Second), b.db, b.getBlockData)
 	// Initialize the chain state from the passed database.  When the db
@@ -353,6 +412,8 @@ func New(config *Config) (*BlockChain, error) {
 	if err := b.initChainState(config.Interrupt); err != nil {
 		return nil, err
 	}
+	b.bd.SetFinalityDepth(config.FinalityDepth)
+	b.bd.SetAnticoneCacheSize(config.AnticoneCacheSize)
 	// Initialize and catch up all of the currently active optional indexes
 	// as needed.
 	if config.IndexManager != nil {
@@ -361,7 +422,13 @@ func New(config *Config) (*BlockChain, error) {
 			return nil, err
 		}
 	}
-	err := b.CheckCacheInvalidTxConfig()
+	// Restore the outputs of the blocks connected after the last flush of
+	// the utxo cache before a crash.
+	err := b.replayUtxoSet()
+	if err != nil {
+		return nil, err
+	}
+	err = b.CheckCacheInvalidTxConfig()
 	if err != nil {
 		return nil, err
 	}
@@ -694,12 +761,28 @@ func (b *BlockChain) BlockByHash(hash *hash.Hash) (*types.SerializedBlock, error
 func (b *BlockChain) HeaderByHash(hash *hash.Hash) (types.BlockHeader, error) {
 	block, err := b.fetchBlockByHash(hash)
 	if err != nil || block == nil {
-		return types.BlockHeader{}, fmt.Errorf("block %s is not known", hash)
+		header, err := b.fetchHeaderByHash(hash)
+		if err != nil {
+			return types.BlockHeader{}, fmt.Errorf("block %s is not known", hash)
+		}
+		return *header, nil
 	}
 
 	return block.Block().Header, nil
 }
 
+// fetchHeaderByHash loads the header of the block from the database, which is
+// kept when the body of the block is pruned.
+func (b *BlockChain) fetchHeaderByHash(hash *hash.Hash) (*types.BlockHeader, error) {
+	var header *types.BlockHeader
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		header, err = dbFetchHeaderByHash(dbTx, hash)
+		return err
+	})
+	return header, err
+}
+
 // FetchBlockByHash searches the internal chain block stores and the database
 // in an attempt to find the requested block.
 //
@@ -788,8 +871,8 @@ func panicf(format string, args ...interface{}) {
 //    This is useful when using checkpoints.
 //
 // This function MUST be called with the chain state lock held (for writes).
-func (b *BlockChain) connectDagChain(ib blockdag.IBlock, block *types.SerializedBlock, newOrders *list.List, oldOrders *list.List) (bool, error) {
-	if newOrders.Len() == 0 {
+func (b *BlockChain) connectDagChain(ib blockdag.IBlock, block *types.SerializedBlock, orderChange *blockdag.OrderChange) (bool, error) {
+	if orderChange.IsEmpty() {
 		return true, nil
 	}
 	//Fast double spent check
@@ -797,7 +880,7 @@ func (b *BlockChain) connectDagChain(ib blockdag.IBlock, block *types.Serialized
 
 	// We are extending the main (best) chain with a new block.  This is the
 	// most common case.
-	if newOrders.Len() == 1 {
+	if !orderChange.IsReorganize() {
 		if !ib.IsOrdered() {
 			return true, nil
 		}
@@ -808,7 +891,9 @@ func (b *BlockChain) connectDagChain(ib blockdag.IBlock, block *types.Serialized
 		view.SetViewpoints([]*hash.Hash{ib.GetHash()})
 
 		stxos := []SpentTxOut{}
+		start := time.Now()
 		err := b.checkConnectBlock(ib, block, view, &stxos)
+		perf.RecordSince(perf.BlockStage, "checkConnectBlock", start)
 		if err != nil {
 			b.bd.InvalidBlock(ib)
 			stxos = []SpentTxOut{}
@@ -820,7 +905,9 @@ func (b *BlockChain) connectDagChain(ib blockdag.IBlock, block *types.Serialized
 		// this block.
 
 		// Connect the block to the main chain.
+		start = time.Now()
 		err = b.connectBlock(ib, block, view, stxos)
+		perf.RecordSince(perf.BlockStage, "connectBlock", start)
 		if err != nil {
 			b.bd.InvalidBlock(ib)
 			return true, err
@@ -842,9 +929,18 @@ func (b *BlockChain) connectDagChain(ib blockdag.IBlock, block *types.Serialized
 	// the blocks that form the new chain to the main chain starting at the
 	// common ancenstor (the point where the chain forked).
 
+	// The detached blocks are disconnected from the utxo set written to
+	// the database, so the utxo cache is flushed first.
+	err := b.utxoCache.flush(b.db)
+	if err != nil {
+		return false, err
+	}
+
 	// Reorganize the chain.
 	log.Debug(fmt.Sprintf("Start DAG REORGANIZE: Block %v is causing a reorganize.", ib.GetHash()))
-	err := b.reorganizeChain(ib, oldOrders, newOrders, block)
+	start := time.Now()
+	err = b.reorganizeChain(ib, orderChange, block)
+	perf.RecordSince(perf.BlockStage, "reorganizeChain", start)
 	if err != nil {
 		return false, err
 	}
@@ -885,7 +981,7 @@ func (b *BlockChain) fastDoubleSpentCheck(ib blockdag.IBlock, block *types.Seria
 	}*/
 }
 
-func (b *BlockChain) updateBestState(ib blockdag.IBlock, block *types.SerializedBlock, attachNodes *list.List) error {
+func (b *BlockChain) updateBestState(ib blockdag.IBlock, block *types.SerializedBlock, attachNodes []blockdag.IBlock) error {
 	// No warnings about unknown rules until the chain is current.
 	if b.isCurrent() {
 		// Warn if any unknown new rules are either about to activate or
@@ -899,8 +995,8 @@ func (b *BlockChain) updateBestState(ib blockdag.IBlock, block *types.Serialized
 	// database and later memory if all database updates are successful.
 	lastState := b.BestSnapshot()
 
-	for e := attachNodes.Front(); e != nil; e = e.Next() {
-		b.bd.UpdateWeight(e.Value.(blockdag.IBlock))
+	for _, attachNode := range attachNodes {
+		b.bd.UpdateWeight(attachNode)
 	}
 
 	// Calculate the number of transactions that would be added by adding
@@ -954,19 +1050,31 @@ func (b *BlockChain) updateBestState(ib blockdag.IBlock, block *types.Serialized
 //
 // This function MUST be called with the chain state lock held (for writes).
 func (b *BlockChain) connectBlock(node blockdag.IBlock, block *types.SerializedBlock, view *UtxoViewpoint, stxos []SpentTxOut) error {
+	if b.assert && !node.GetStatus().KnownInvalid() {
+		b.assertUtxoConservation(block, stxos)
+	}
 	// Atomically insert info into the database.
+	deferred := b.utxoCache.deferWrites()
+	state := &utxoState{order: node.GetOrder(), hash: *node.GetHash()}
 	err := b.db.Update(func(dbTx database.Tx) error {
-		// Update the utxo set using the state of the utxo view.  This
-		// entails removing all of the utxos spent and adding the new
-		// ones created by the block.
-		err := dbPutUtxoView(dbTx, view)
-		if err != nil {
-			return err
+		// Update the utxo set using the state of the utxo view, unless
+		// the utxo cache keeps it until its next flush.  This entails
+		// removing all of the utxos spent and adding the new ones
+		// created by the block.
+		if !deferred {
+			err := dbPutUtxoView(dbTx, view)
+			if err != nil {
+				return err
+			}
+			err = dbPutUtxoState(dbTx, state)
+			if err != nil {
+				return err
+			}
 		}
 
 		// Update the transaction spend journal by adding a record for
 		// the block that contains all txos spent by it.
-		err = dbPutSpendJournalEntry(dbTx, block.Hash(), stxos)
+		err := dbPutSpendJournalEntry(dbTx, block.Hash(), stxos)
 		if err != nil {
 			return err
 		}
@@ -985,8 +1093,10 @@ func (b *BlockChain) connectBlock(node blockdag.IBlock, block *types.SerializedB
 		return err
 	}
 
-	// Prune fully spent entries and mark all entries in the view unmodified
-	// now that the modifications have been committed to the database.
+	// Update the utxo cache, prune fully spent entries and mark all entries
+	// in the view unmodified now that the modifications have been committed
+	// to the database or kept by the cache.
+	b.utxoCache.update(view, state, deferred)
 	view.commit()
 
 	err = b.updateTokenState(node, block, false)
@@ -1033,8 +1143,10 @@ func (b *BlockChain) disconnectBlock(block *types.SerializedBlock, view *UtxoVie
 		return err
 	}
 
-	// Prune fully spent entries and mark all entries in the view unmodified
-	// now that the modifications have been committed to the database.
+	// Update the utxo cache, prune fully spent entries and mark all entries
+	// in the view unmodified now that the modifications have been committed
+	// to the database.
+	b.utxoCache.update(view, nil, false)
 	view.commit()
 
 	b.sendNotification(BlockDisconnected, block)
@@ -1049,25 +1161,17 @@ func (b *BlockChain) FetchSubsidyCache() *SubsidyCache {
 	return b.subsidyCache
 }
 
-// reorganizeChain reorganizes the block chain by disconnecting the nodes in the
-// detachNodes list and connecting the nodes in the attach list.  It expects
-// that the lists are already in the correct order and are in sync with the
-// end of the current best chain.  Specifically, nodes that are being
-// disconnected must be in reverse order (think of popping them off the end of
-// the chain) and nodes the are being attached must be in forwards order
-// (think pushing them onto the end of the chain).
+// reorganizeChain reorganizes the block chain by disconnecting the detached
+// blocks of the order change and connecting its attached blocks.  The detached
+// blocks are in their old order and are disconnected in reverse order (think
+// of popping them off the end of the chain), while the attached blocks are
+// connected in their new order (think pushing them onto the end of the chain).
 //
 // This function MUST be called with the chain state lock held (for writes).
 
-func (b *BlockChain) reorganizeChain(ib blockdag.IBlock, detachNodes *list.List, attachNodes *list.List, newBlock *types.SerializedBlock) error {
-	oldBlocks := []*hash.Hash{}
-	for e := detachNodes.Front(); e != nil; e = e.Next() {
-		ob := e.Value.(*blockdag.BlockOrderHelp)
-		oldBlocks = append(oldBlocks, ob.Block.GetHash())
-	}
-
+func (b *BlockChain) reorganizeChain(ib blockdag.IBlock, orderChange *blockdag.OrderChange, newBlock *types.SerializedBlock) error {
 	b.sendNotification(Reorganization, &ReorganizationNotifyData{
-		OldBlocks: oldBlocks,
+		OldBlocks: orderChange.DetachedHashes(),
 		NewBlock:  newBlock.Hash(),
 		NewOrder:  uint64(ib.GetOrder()),
 	})
@@ -1078,8 +1182,8 @@ func (b *BlockChain) reorganizeChain(ib blockdag.IBlock, detachNodes *list.List,
 	var block *types.SerializedBlock
 	var err error
 
-	for e := detachNodes.Back(); e != nil; e = e.Prev() {
-		n := e.Value.(*blockdag.BlockOrderHelp)
+	for i := len(orderChange.Detached) - 1; i >= 0; i-- {
+		n := orderChange.Detached[i]
 		if n == nil {
 			panic(err.Error())
 		}
@@ -1130,8 +1234,7 @@ func (b *BlockChain) reorganizeChain(ib blockdag.IBlock, detachNodes *list.List,
 		}
 	}
 
-	for e := attachNodes.Front(); e != nil; e = e.Next() {
-		nodeBlock := e.Value.(blockdag.IBlock)
+	for _, nodeBlock := range orderChange.Attached {
 		if nodeBlock.GetID() == ib.GetID() {
 			block = newBlock
 		} else {
@@ -1170,9 +1273,11 @@ func (b *BlockChain) reorganizeChain(ib blockdag.IBlock, detachNodes *list.List,
 
 	}
 
+	b.sendNotification(ChainReorg, newChainReorgNotifyData(orderChange))
+
 	// Log the point where the chain forked and old and new best chain
 	// heads.
-	log.Debug(fmt.Sprintf("End DAG REORGANIZE: Old Len= %d;New Len= %d", attachNodes.Len(), detachNodes.Len()))
+	log.Debug(fmt.Sprintf("End DAG REORGANIZE: Old Len= %d;New Len= %d", len(orderChange.Detached), len(orderChange.Attached)))
 
 	return nil
 }
@@ -1438,8 +1543,14 @@ func (b *BlockChain) CalculateTokenStateRoot(txs []*types.Tx, parents []*hash.Ha
 func (b *BlockChain) getBlockData(hash *hash.Hash) blockdag.IBlockData {
 	block, err := b.fetchBlockByHash(hash)
 	if err != nil {
-		log.Error(err.Error())
-		return nil
+		// The body of a pruned block is gone, but its header is kept and
+		// its parents are known by the DAG.
+		header, herr := b.fetchHeaderByHash(hash)
+		if herr != nil {
+			log.Error(err.Error())
+			return nil
+		}
+		return NewBlockNode(header, nil)
 	}
 	return NewBlockNode(&block.Block().Header, block.Block().Parents)
 }
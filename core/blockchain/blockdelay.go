@@ -0,0 +1,71 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"sync"
+	"time"
+)
+
+// maxBlockDelays is the maximum number of latest blocks whose delay is
+// remembered.
+const maxBlockDelays = 1000
+
+// blockDelay is how long after its timestamp a block was received.
+type blockDelay struct {
+	delay   time.Duration
+	parents []*hash.Hash
+}
+
+// blockDelays remembers how long after their timestamp the latest blocks were
+// received, along with their parents, so that the templates can tell the
+// blocks withheld by their miners and the branches extending them.
+type blockDelays struct {
+	lock    sync.Mutex
+	entries map[hash.Hash]*blockDelay
+	order   []hash.Hash
+}
+
+// add remembers the delay of the block.  The oldest entry is forgotten when
+// too many blocks are remembered.
+func (c *blockDelays) add(h *hash.Hash, delay time.Duration, parents []*hash.Hash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[hash.Hash]*blockDelay)
+	}
+	if _, ok := c.entries[*h]; ok {
+		return
+	}
+	if len(c.order) >= maxBlockDelays {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.entries[*h] = &blockDelay{delay: delay, parents: parents}
+	c.order = append(c.order, *h)
+}
+
+// get returns the delay of the block, or nil when it is not remembered.
+func (c *blockDelays) get(h *hash.Hash) *blockDelay {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.entries[*h]
+}
+
+// BlockDelay returns how long after its timestamp one of the latest blocks was
+// received, along with its parents.  It returns false when the block is not
+// remembered, such as a block loaded from the database.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) BlockDelay(h *hash.Hash) (time.Duration, []*hash.Hash, bool) {
+	d := b.blockDelays.get(h)
+	if d == nil {
+		return 0, nil, false
+	}
+	return d.delay, d.parents, true
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/Qitmeer/qitmeer/core/blockdag"
 	"github.com/Qitmeer/qitmeer/core/types"
 	"github.com/Qitmeer/qitmeer/database"
+	"time"
 )
 
 // IndexManager provides a generic interface that the is called when blocks are
@@ -68,3 +69,25 @@ func (b *BlockChain) GetBlockNode(ib blockdag.IBlock) *BlockNode {
 func (b *BlockChain) GetBlock(h *hash.Hash) blockdag.IBlock {
 	return b.bd.GetBlock(h)
 }
+
+// LatestBlocks returns the timestamps of at most count blocks, from the main
+// chain tip backwards in the order, along with how many of them are red.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) LatestBlocks(count uint) ([]time.Time, uint) {
+	mainOrder := b.bd.GetMainChainTip().GetOrder()
+	timestamps := make([]time.Time, 0, count)
+	reds := uint(0)
+	for i := uint(0); i < count && i <= mainOrder; i++ {
+		ib := b.bd.GetBlockByOrder(mainOrder - i)
+		node := b.GetBlockNode(ib)
+		if node == nil {
+			break
+		}
+		timestamps = append(timestamps, node.Timestamp())
+		if !b.bd.IsBlue(ib.GetID()) {
+			reds++
+		}
+	}
+	return timestamps, reds
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2017-2018 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/database"
+)
+
+// ArchiveBlockFiles moves the block files which only contain blocks that are
+// more than depth orders below the main chain tip to the cold storage of the
+// database.  It returns the number of moved files.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ArchiveBlockFiles(depth uint) (int, error) {
+	archiver, ok := b.db.(database.BlockArchiver)
+	if !ok {
+		return 0, fmt.Errorf("database %s does not support cold storage",
+			b.db.Type())
+	}
+
+	// The block total also counts the blocks which are not ordered, so the
+	// depth is taken from the order of the main chain tip.
+	b.ChainRLock()
+	order := b.bd.GetMainChainTip().GetOrder()
+	if order <= depth {
+		b.ChainRUnlock()
+		return 0, nil
+	}
+	h := b.bd.GetBlockHashByOrder(order - depth)
+	b.ChainRUnlock()
+	if h == nil {
+		return 0, nil
+	}
+	return archiver.ArchiveBlocks(h)
+}
+
+// PruneBlockFiles deletes the block files which only contain blocks that are
+// more than depth orders below the finality point.  The headers and the orders
+// of the pruned blocks are kept.  Nothing is pruned while there is no
+// finality point.  It returns the number of deleted files.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) PruneBlockFiles(depth uint) (int, error) {
+	pruner, ok := b.db.(database.BlockPruner)
+	if !ok {
+		return 0, fmt.Errorf("database %s does not support pruning",
+			b.db.Type())
+	}
+
+	b.ChainRLock()
+	fp := b.bd.GetFinalityPoint()
+	if fp == nil || !fp.IsOrdered() || fp.GetOrder() <= depth {
+		b.ChainRUnlock()
+		return 0, nil
+	}
+	h := b.bd.GetBlockHashByOrder(fp.GetOrder() - depth)
+	b.ChainRUnlock()
+	if h == nil {
+		return 0, nil
+	}
+	return pruner.PruneBlocks(h)
+}
@@ -135,6 +135,45 @@ func (b *BlockChain) BlockHashByOrder(blockOrder uint64) (*hash.Hash, error) {
 	return hash, nil
 }
 
+// RawHeadersByOrder returns the serialized headers of the count blocks
+// following the given order, that order included, along with whether each of
+// the blocks is blue.  Fewer headers are returned when the latest order is
+// reached.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) RawHeadersByOrder(startOrder uint64, count uint64) ([][]byte, []bool, error) {
+	mainOrder := uint64(b.BestSnapshot().GraphState.GetMainOrder())
+	if startOrder > mainOrder {
+		return nil, nil, fmt.Errorf("start order %d is greater than the "+
+			"main order %d", startOrder, mainOrder)
+	}
+	if count > mainOrder-startOrder+1 {
+		count = mainOrder - startOrder + 1
+	}
+
+	hashes := make([]hash.Hash, 0, count)
+	blues := make([]bool, 0, count)
+	for order := startOrder; order < startOrder+count; order++ {
+		ib := b.bd.GetBlockByOrder(uint(order))
+		if ib == nil {
+			return nil, nil, fmt.Errorf("no block of order %d", order)
+		}
+		hashes = append(hashes, *ib.GetHash())
+		blues = append(blues, b.bd.IsBlue(ib.GetID()))
+	}
+
+	var headers [][]byte
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		headers, err = dbTx.FetchBlockHeaders(hashes)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return headers, blues, nil
+}
+
 // MainChainHasBlock returns whether or not the block with the given hash is in
 // the main chain.
 //
@@ -200,7 +239,7 @@ func (b *BlockChain) createChainState() error {
 	genesisBlock.SetOrder(0)
 	header := &genesisBlock.Block().Header
 	node := NewBlockNode(header, genesisBlock.Block().Parents)
-	_, _, ib, _ := b.bd.AddBlock(node)
+	_, ib, _ := b.bd.AddBlock(node)
 	//node.FlushToDB(b)
 	// Initialize the state related to the best block.  Since it is the
 	// genesis block, use its timestamp for the median time.
@@ -265,6 +304,15 @@ func (b *BlockChain) createChainState() error {
 			return err
 		}
 
+		// The statistics of the utxo set are maintained from the
+		// genesis, there is nothing to scan.
+		stats := newUtxoStats()
+		stats.Done = true
+		err = dbPutUtxoStats(dbTx, stats)
+		if err != nil {
+			return err
+		}
+
 		// Add genesis utxo
 		view := NewUtxoViewpoint()
 		view.SetViewpoints([]*hash.Hash{genesisBlock.Hash()})
@@ -275,6 +323,10 @@ func (b *BlockChain) createChainState() error {
 		if err != nil {
 			return err
 		}
+		err = dbPutUtxoState(dbTx, &utxoState{hash: *genesisBlock.Hash()})
+		if err != nil {
+			return err
+		}
 
 		// Store the genesis block into the database.
 		if err := dbTx.StoreBlock(genesisBlock); err != nil {
@@ -451,6 +503,19 @@ func (b *BlockChain) BlockOrderByHash(hash *hash.Hash) (uint64, error) {
 	return uint64(ib.GetOrder()), nil
 }
 
+// BlockOrdersByHash returns the orders of the blocks with the given hashes in
+// the chain, which are blockdag.MaxBlockOrder for the unknown blocks.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) BlockOrdersByHash(hashes []*hash.Hash) []uint64 {
+	orders := b.bd.GetBlockOrders(hashes)
+	result := make([]uint64, len(orders))
+	for i, order := range orders {
+		result[i] = uint64(order)
+	}
+	return result
+}
+
 // dbFetchHeaderByHash uses an existing database transaction to retrieve the
 // block header for the provided hash.
 func dbFetchHeaderByHash(dbTx database.Tx, hash *hash.Hash) (*types.BlockHeader, error) {
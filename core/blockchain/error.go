@@ -8,6 +8,7 @@ package blockchain
 
 import (
 	"fmt"
+	"github.com/Qitmeer/qitmeer/common/errcode"
 )
 
 // HashError identifies an error that indicates a hash was specified that does
@@ -20,6 +21,13 @@ func (e HashError) Error() string {
 	return fmt.Sprintf("hash %v does not exist", string(e))
 }
 
+// Code returns errcode.NotFound.
+//
+// This is part of the errcode.Coder interface.
+func (e HashError) Code() errcode.Code {
+	return errcode.NotFound
+}
+
 // DeploymentError identifies an error that indicates a deployment ID was
 // specified that does not exist.
 type DeploymentError uint32
@@ -30,6 +38,13 @@ func (e DeploymentError) Error() string {
 	return fmt.Sprintf("deployment ID %v does not exist", uint32(e))
 }
 
+// Code returns errcode.InvalidParameter.
+//
+// This is part of the errcode.Coder interface.
+func (e DeploymentError) Code() errcode.Code {
+	return errcode.InvalidParameter
+}
+
 // AssertError identifies an error that indicates an internal code consistency
 // issue and should be treated as a critical and unrecoverable error.
 type AssertError string
@@ -40,6 +55,13 @@ func (e AssertError) Error() string {
 	return "assertion failed: " + string(e)
 }
 
+// Code returns errcode.Internal.
+//
+// This is part of the errcode.Coder interface.
+func (e AssertError) Code() errcode.Code {
+	return errcode.Internal
+}
+
 // ErrorCode identifies a kind of error.
 type ErrorCode int
 
@@ -335,6 +357,27 @@ const (
 	// ErrNoViewpoint
 	ErrNoViewpoint
 
+	// ErrBeforeFinalityPoint indicates that the past of a block does not
+	// contain the finality point, so the block could reorder the blocks
+	// before it.
+	ErrBeforeFinalityPoint
+
+	// ErrTooManyParents indicates the block has more parents than allowed.
+	ErrTooManyParents
+
+	// ErrRedundantParent indicates a parent of the block is in the past of
+	// another one of its parents.
+	ErrRedundantParent
+
+	// ErrParentsTooFar indicates the layers of the parents of the block are
+	// too far apart, so a parent is out of the anticone window of the
+	// others.
+	ErrParentsTooFar
+
+	// ErrTimeBeforeParents indicates the timestamp of the block precedes
+	// the median timestamp of its parents.
+	ErrTimeBeforeParents
+
 	// numErrorCodes is the maximum error code number used in tests.
 	numErrorCodes
 )
@@ -410,6 +453,13 @@ var errorCodeStrings = map[ErrorCode]string{
 
 	ErrNoBlueCoinbase: "ErrNoBlueCoinbase",
 	ErrNoViewpoint:    "ErrNoViewpoint",
+
+	ErrBeforeFinalityPoint: "ErrBeforeFinalityPoint",
+
+	ErrTooManyParents:    "ErrTooManyParents",
+	ErrRedundantParent:   "ErrRedundantParent",
+	ErrParentsTooFar:     "ErrParentsTooFar",
+	ErrTimeBeforeParents: "ErrTimeBeforeParents",
 }
 
 // String returns the ErrorCode as a human-readable name.
@@ -435,6 +485,18 @@ func (e RuleError) Error() string {
 	return e.Description
 }
 
+// Code returns errcode.Duplicate for the blocks and transactions which already
+// exist, and errcode.RuleViolation otherwise.
+//
+// This is part of the errcode.Coder interface.
+func (e RuleError) Code() errcode.Code {
+	switch e.ErrorCode {
+	case ErrDuplicateBlock, ErrDuplicateTx:
+		return errcode.Duplicate
+	}
+	return errcode.RuleViolation
+}
+
 // ruleError creates an RuleError given a set of arguments.
 func ruleError(c ErrorCode, desc string) RuleError {
 	return RuleError{ErrorCode: c, Description: desc}
@@ -0,0 +1,268 @@
+// Copyright (c) 2017-2018 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ChainFixture is a portable description of a small segment of the block
+// DAG together with the utxo entries spent by that segment but created
+// outside of it.  It can be captured from a running chain with ExportFixture
+// and saved under testdata so that order and blue set regressions observed
+// on a real network can be replayed in unit tests.
+type ChainFixture struct {
+	Network string         `json:"network"`
+	DAGType string         `json:"dagtype"`
+	Blocks  []FixtureBlock `json:"blocks"`
+	Utxos   []FixtureUtxo  `json:"utxos"`
+}
+
+// FixtureBlock describes one block of a chain fixture.
+type FixtureBlock struct {
+	Hash      string   `json:"hash"`
+	Parents   []string `json:"parents"`
+	Order     uint64   `json:"order"`
+	Height    uint64   `json:"height"`
+	Blue      bool     `json:"blue"`
+	Timestamp int64    `json:"timestamp"`
+	// Raw is the hex encoded serialized block.  It is optional so that
+	// hand written fixtures can describe only the DAG topology.
+	Raw string `json:"raw,omitempty"`
+}
+
+// FixtureUtxo describes an unspent transaction output which the blocks of a
+// chain fixture depend on.
+type FixtureUtxo struct {
+	TxHash    string `json:"txid"`
+	Index     uint32 `json:"vout"`
+	CoinId    uint16 `json:"coinid"`
+	Amount    int64  `json:"amount"`
+	PkScript  string `json:"pkscript"`
+	BlockHash string `json:"blockhash"`
+	Coinbase  bool   `json:"coinbase,omitempty"`
+}
+
+// fixtureBlockData implements blockdag.IBlockData for a fixture block so the
+// topology can be replayed into a fresh block DAG.
+type fixtureBlockData struct {
+	hash      hash.Hash
+	parents   []*hash.Hash
+	timestamp int64
+}
+
+func (fb *fixtureBlockData) GetHash() *hash.Hash {
+	return &fb.hash
+}
+
+func (fb *fixtureBlockData) GetParents() []*hash.Hash {
+	return fb.parents
+}
+
+func (fb *fixtureBlockData) GetTimestamp() int64 {
+	return fb.timestamp
+}
+
+// ExportFixture captures the blocks with an order in the range
+// [startOrder, endOrder) together with every utxo they spend that was not
+// created inside of the range.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ExportFixture(startOrder, endOrder uint64) (*ChainFixture, error) {
+	if endOrder <= startOrder {
+		return nil, fmt.Errorf("end order must be greater than the start "+
+			"order - got start %d, end %d", startOrder, endOrder)
+	}
+	b.ChainRLock()
+	defer b.ChainRUnlock()
+
+	fixture := &ChainFixture{
+		Network: b.params.Name,
+		DAGType: b.bd.GetName(),
+	}
+	created := make(map[hash.Hash]struct{})
+	for order := startOrder; order < endOrder; order++ {
+		h := b.bd.GetBlockHashByOrder(uint(order))
+		if h == nil {
+			break
+		}
+		ib := b.bd.GetBlock(h)
+		if ib == nil {
+			return nil, fmt.Errorf("no block %s in the DAG", h)
+		}
+		block, err := b.fetchBlockByHash(h)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := block.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		fb := FixtureBlock{
+			Hash:      h.String(),
+			Order:     order,
+			Height:    uint64(ib.GetHeight()),
+			Blue:      b.bd.IsBlue(ib.GetID()),
+			Timestamp: block.Block().Header.Timestamp.Unix(),
+			Raw:       hex.EncodeToString(raw),
+		}
+		for _, parent := range block.Block().Parents {
+			fb.Parents = append(fb.Parents, parent.String())
+		}
+		fixture.Blocks = append(fixture.Blocks, fb)
+
+		stxos, err := b.fetchSpendJournal(block)
+		if err != nil {
+			return nil, err
+		}
+		// The transactions of a block may spend the outputs of the ones
+		// before them, so each transaction is added to the created set
+		// once its own inputs are handled.
+		txs := block.Transactions()
+		spent := make([][]SpentTxOut, len(txs))
+		for _, stxo := range stxos {
+			if int(stxo.TxIndex) < len(txs) {
+				spent[stxo.TxIndex] = append(spent[stxo.TxIndex], stxo)
+			}
+		}
+		for i, tx := range txs {
+			txIns := tx.Tx.TxIn
+			for _, stxo := range spent[i] {
+				if int(stxo.TxInIndex) >= len(txIns) {
+					continue
+				}
+				prevOut := txIns[stxo.TxInIndex].PreviousOut
+				if _, ok := created[prevOut.Hash]; ok {
+					continue
+				}
+				fixture.Utxos = append(fixture.Utxos, FixtureUtxo{
+					TxHash:    prevOut.Hash.String(),
+					Index:     prevOut.OutIndex,
+					CoinId:    uint16(stxo.Amount.Id),
+					Amount:    stxo.Amount.Value,
+					PkScript:  hex.EncodeToString(stxo.PkScript),
+					BlockHash: stxo.BlockHash.String(),
+					Coinbase:  stxo.IsCoinBase,
+				})
+			}
+			created[*tx.Hash()] = struct{}{}
+		}
+	}
+	return fixture, nil
+}
+
+// WriteFixture saves the fixture as indented json to the given path,
+// creating the parent directory when needed.
+func WriteFixture(path string, fixture *ChainFixture) error {
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// LoadFixture reads a chain fixture that was previously saved with
+// WriteFixture.
+func LoadFixture(path string) (*ChainFixture, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fixture := &ChainFixture{}
+	err = json.Unmarshal(data, fixture)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode fixture %s: %v", path, err)
+	}
+	return fixture, nil
+}
+
+// UtxoView returns a utxo viewpoint populated with the utxo entries of the
+// fixture.
+func (f *ChainFixture) UtxoView() (*UtxoViewpoint, error) {
+	view := NewUtxoViewpoint()
+	for _, u := range f.Utxos {
+		txHash, err := hash.NewHashFromStr(u.TxHash)
+		if err != nil {
+			return nil, err
+		}
+		blockHash, err := hash.NewHashFromStr(u.BlockHash)
+		if err != nil {
+			return nil, err
+		}
+		pkScript, err := hex.DecodeString(u.PkScript)
+		if err != nil {
+			return nil, err
+		}
+		entry := &UtxoEntry{
+			amount:    types.Amount{Value: u.Amount, Id: types.CoinID(u.CoinId)},
+			pkScript:  pkScript,
+			blockHash: *blockHash,
+		}
+		if u.Coinbase {
+			entry.packedFlags |= tfCoinBase
+		}
+		view.entries[types.TxOutPoint{Hash: *txHash, OutIndex: u.Index}] = entry
+	}
+	return view, nil
+}
+
+// SerializedBlocks decodes the raw blocks of the fixture in order.  Blocks
+// without raw data are skipped.
+func (f *ChainFixture) SerializedBlocks() ([]*types.SerializedBlock, error) {
+	blocks := make([]*types.SerializedBlock, 0, len(f.Blocks))
+	for _, fb := range f.Blocks {
+		if len(fb.Raw) == 0 {
+			continue
+		}
+		raw, err := hex.DecodeString(fb.Raw)
+		if err != nil {
+			return nil, err
+		}
+		block, err := types.NewBlockFromBytes(raw)
+		if err != nil {
+			return nil, err
+		}
+		if block.Hash().String() != fb.Hash {
+			return nil, fmt.Errorf("fixture block %s has mismatched raw "+
+				"data (hash %s)", fb.Hash, block.Hash())
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// DAGBlocks returns the topology of the fixture as block data that can be
+// added to a block DAG in order.
+func (f *ChainFixture) DAGBlocks() ([]blockdag.IBlockData, error) {
+	result := make([]blockdag.IBlockData, 0, len(f.Blocks))
+	for _, fb := range f.Blocks {
+		h, err := hash.NewHashFromStr(fb.Hash)
+		if err != nil {
+			return nil, err
+		}
+		data := &fixtureBlockData{hash: *h, timestamp: fb.Timestamp}
+		for _, p := range fb.Parents {
+			ph, err := hash.NewHashFromStr(p)
+			if err != nil {
+				return nil, err
+			}
+			data.parents = append(data.parents, ph)
+		}
+		result = append(result, data)
+	}
+	return result, nil
+}
@@ -0,0 +1,249 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/dbnamespace"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/core/types/pow"
+	"github.com/Qitmeer/qitmeer/database"
+	"github.com/Qitmeer/qitmeer/params"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadFixture(t *testing.T) {
+	fixture, err := LoadFixture(filepath.Join("testdata", "fixture_simple.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixture.Blocks) != 4 {
+		t.Fatalf("expected 4 blocks, got %d", len(fixture.Blocks))
+	}
+
+	blocks, err := fixture.DAGBlocks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	merge := blocks[3]
+	if len(merge.GetParents()) != 2 {
+		t.Fatalf("expected 2 parents, got %d", len(merge.GetParents()))
+	}
+	if merge.GetParents()[1].String() != fixture.Blocks[2].Hash {
+		t.Fatalf("unexpected parent %s", merge.GetParents()[1])
+	}
+
+	view, err := fixture.UtxoView()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(view.Entries()) != 2 {
+		t.Fatalf("expected 2 utxo entries, got %d", len(view.Entries()))
+	}
+	txid := hash.MustHexToDecodedHash("377cfb2c535be289f8e40299e8d4c234283c367e20bc5ff67ca18c1ca1337443")
+	entry := view.LookupEntry(types.TxOutPoint{Hash: txid, OutIndex: 0})
+	if entry == nil {
+		t.Fatal("missing utxo entry")
+	}
+	if !entry.IsCoinBase() || entry.Amount().Value != 1200000000 {
+		t.Fatalf("unexpected utxo entry %v", entry)
+	}
+	if entry.BlockHash().String() != fixture.Blocks[0].Hash {
+		t.Fatalf("unexpected utxo block %s", entry.BlockHash())
+	}
+}
+
+func TestWriteFixture(t *testing.T) {
+	fixture, err := LoadFixture(filepath.Join("testdata", "fixture_simple.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "fixture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "testdata", "fixture.json")
+	err = WriteFixture(path, fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadFixture(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fixture, loaded) {
+		t.Fatal("fixture changed after a write and load round trip")
+	}
+}
+
+// newFixtureDAG initializes a phantom block DAG backed by the passed empty
+// database, which finds the block data of the added blocks in datas.
+func newFixtureDAG(t *testing.T, db database.DB, datas map[hash.Hash]blockdag.IBlockData) *blockdag.BlockDAG {
+	par := &params.PrivNetParams
+	bd := &blockdag.BlockDAG{}
+	calcWeight := func(int64, *hash.Hash, blockdag.BlockStatus) int64 {
+		return 1
+	}
+	getBlockData := func(h *hash.Hash) blockdag.IBlockData {
+		return datas[*h]
+	}
+	setAnticoneSize(bd, par)
+	if bd.Init("phantom", calcWeight, 1.0/float64(par.TargetTimePerBlock/time.Second),
+		db, getBlockData) == nil {
+		t.Fatal("failed to initialize the DAG")
+	}
+	return bd
+}
+
+// addFixtureBlock adds the block to the DAG and commits it.
+func addFixtureBlock(t *testing.T, bd *blockdag.BlockDAG, datas map[hash.Hash]blockdag.IBlockData, data blockdag.IBlockData) {
+	datas[*data.GetHash()] = data
+	oc, _, _ := bd.AddBlock(data)
+	if oc.IsEmpty() {
+		t.Fatalf("block %s was rejected by the DAG", data.GetHash())
+	}
+	if err := bd.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestExportFixture exports a merge block whose second transaction spends
+// the output of the first one, and replays the fixture into a fresh DAG.
+func TestExportFixture(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fixture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db := newVectorDB(t, filepath.Join(dir, "export"))
+	defer db.Close()
+	err = db.Update(func(dbTx database.Tx) error {
+		_, err := dbTx.Metadata().CreateBucketIfNotExists(
+			dbnamespace.SpendJournalBucketName)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkScript, err := hex.DecodeString("76a914868b9b6bc7e4a9c804ad3d3d7a2a6be27476941e88ac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newTx := func(prevOut *types.TxOutPoint) *types.Transaction {
+		tx := types.NewTransaction()
+		tx.AddTxIn(types.NewTxInput(prevOut, []byte{}))
+		tx.AddTxOut(types.NewTxOutput(types.Amount{Value: 1000}, pkScript))
+		return tx
+	}
+	external := types.NewOutPoint(&hash.Hash{1}, 0)
+	tx1 := newTx(external)
+	tx1Hash := tx1.TxHash()
+	tx2 := newTx(types.NewOutPoint(&tx1Hash, 0))
+
+	genesisTime := params.PrivNetParams.GenesisBlock.Header.Timestamp
+	datas := make(map[hash.Hash]blockdag.IBlockData)
+	bd := newFixtureDAG(t, db, datas)
+	blocks := make(map[string]*types.SerializedBlock)
+	addBlock := func(tag string, nonce uint64, txs []*types.Transaction, parents ...string) {
+		block := &types.Block{Transactions: txs}
+		block.Header.Timestamp = genesisTime.Add(time.Duration(nonce) * time.Second)
+		block.Header.Pow = pow.GetInstance(pow.BLAKE2BD, nonce, []byte{})
+		for _, p := range parents {
+			block.Parents = append(block.Parents, blocks[p].Hash())
+		}
+		sb := types.NewBlock(block)
+		err := db.Update(func(dbTx database.Tx) error {
+			return dbTx.StoreBlock(sb)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		blocks[tag] = sb
+		addFixtureBlock(t, bd, datas, &fixtureBlockData{
+			hash:      *sb.Hash(),
+			parents:   block.Parents,
+			timestamp: block.Header.Timestamp.Unix(),
+		})
+	}
+	coinbase := func() *types.Transaction {
+		return newTx(types.NewOutPoint(&hash.Hash{}, types.MaxPrevOutIndex))
+	}
+	addBlock("G", 0, []*types.Transaction{coinbase()})
+	addBlock("A", 1, []*types.Transaction{coinbase()}, "G")
+	addBlock("B", 2, []*types.Transaction{coinbase()}, "G")
+	addBlock("C", 3, []*types.Transaction{coinbase(), tx1, tx2}, "A", "B")
+
+	stxos := []SpentTxOut{
+		{Amount: types.Amount{Value: 1000}, PkScript: pkScript,
+			BlockHash: *blocks["A"].Hash(), TxIndex: 1, TxInIndex: 0},
+		{Amount: types.Amount{Value: 1000}, PkScript: pkScript,
+			BlockHash: *blocks["C"].Hash(), TxIndex: 2, TxInIndex: 0},
+	}
+	err = db.Update(func(dbTx database.Tx) error {
+		return dbPutSpendJournalEntry(dbTx, blocks["C"].Hash(), stxos)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &BlockChain{db: db, bd: bd, params: &params.PrivNetParams}
+	fixture, err := b.ExportFixture(0, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixture.Blocks) != 4 {
+		t.Fatalf("expected 4 blocks, got %d", len(fixture.Blocks))
+	}
+	// The output of tx1 is created by the same block, so only the output
+	// spent by tx1 is external.
+	if len(fixture.Utxos) != 1 {
+		t.Fatalf("expected 1 utxo, got %v", fixture.Utxos)
+	}
+	if fixture.Utxos[0].TxHash != external.Hash.String() ||
+		fixture.Utxos[0].BlockHash != blocks["A"].Hash().String() {
+		t.Fatalf("unexpected utxo %v", fixture.Utxos[0])
+	}
+
+	// Replay the topology and compare the order and the colors.
+	replayDB := newVectorDB(t, filepath.Join(dir, "replay"))
+	defer replayDB.Close()
+	replayDatas := make(map[hash.Hash]blockdag.IBlockData)
+	replay := newFixtureDAG(t, replayDB, replayDatas)
+	dagBlocks, err := fixture.DAGBlocks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range dagBlocks {
+		addFixtureBlock(t, replay, replayDatas, data)
+	}
+	for _, fb := range fixture.Blocks {
+		h, err := hash.NewHashFromStr(fb.Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ib := replay.GetBlock(h)
+		if ib == nil {
+			t.Fatalf("block %s was not replayed", fb.Hash)
+		}
+		if uint64(ib.GetOrder()) != fb.Order {
+			t.Errorf("block %s: order %d, recorded %d", fb.Hash,
+				ib.GetOrder(), fb.Order)
+		}
+		if replay.IsBlue(ib.GetID()) != fb.Blue {
+			t.Errorf("block %s: blue %v, recorded %v", fb.Hash,
+				replay.IsBlue(ib.GetID()), fb.Blue)
+		}
+	}
+	if fixture.Blocks[3].Hash != blocks["C"].Hash().String() {
+		t.Fatalf("merge block recorded at order 3 is %s", fixture.Blocks[3].Hash)
+	}
+}
@@ -27,6 +27,14 @@ const (
 	// local clock that is used to determine that it is likley wrong and
 	// hence to show a warning.
 	similarTimeSecs = 3 * 60 // 3 minutes
+
+	// DefaultMaxClockSkew is the default clock skew above which the local
+	// clock is reported as wrong.
+	DefaultMaxClockSkew = time.Minute
+
+	// minClockSkewSamples is the minimum number of time samples needed to
+	// estimate the clock skew.
+	minClockSkewSamples = 5
 )
 
 var (
@@ -51,6 +59,11 @@ type MedianTimeSource interface {
 	// Offset returns the number of seconds to adjust the local clock based
 	// upon the median of the time samples added by AddTimeData.
 	Offset() time.Duration
+
+	// ClockSkew returns the median of the offsets of the time samples,
+	// which is not limited like Offset, and whether it exceeds the maximum
+	// clock skew.  The skew is zero until there are enough samples.
+	ClockSkew() (time.Duration, bool)
 }
 
 // int64Sorter implements sort.Interface to allow a slice of 64-bit integers to
@@ -86,6 +99,9 @@ type medianTime struct {
 	offsets            []int64
 	offsetSecs         int64
 	invalidTimeChecked bool
+	skewSecs           int64
+	maxSkewSecs        int64
+	skewed             bool
 }
 
 // Ensure the medianTime type implements the MedianTimeSource interface.
@@ -143,6 +159,8 @@ func (m *medianTime) AddTimeSample(sourceID string, timeVal time.Time) {
 	log.Debug(fmt.Sprintf("Added time sample of %v (total: %v)", offsetDuration,
 		numOffsets))
 
+	m.updateClockSkew(sortedOffsets)
+
 	// NOTE: The following code intentionally has a bug to mirror the
 	// buggy behavior in Bitcoin Core since the median time is used in the
 	// consensus rules.
@@ -199,6 +217,49 @@ func (m *medianTime) AddTimeSample(sourceID string, timeVal time.Time) {
 	log.Debug("New time offset", "duration", medianDuration)
 }
 
+// updateClockSkew sets the clock skew to the median of the sorted offsets and
+// warns when it starts exceeding the maximum clock skew.  Unlike the offset,
+// the skew is always updated with the true median of the samples since it is
+// not used by the consensus rules.
+//
+// This function MUST be called with the lock held.
+func (m *medianTime) updateClockSkew(sortedOffsets []int64) {
+	numOffsets := len(sortedOffsets)
+	if numOffsets < minClockSkewSamples {
+		return
+	}
+	m.skewSecs = sortedOffsets[numOffsets/2]
+	if numOffsets&0x01 == 0 {
+		m.skewSecs = (sortedOffsets[numOffsets/2-1] + m.skewSecs) / 2
+	}
+
+	skew := time.Duration(m.skewSecs) * time.Second
+	exceeded := m.skewSecs > m.maxSkewSecs || -m.skewSecs > m.maxSkewSecs
+	switch {
+	case exceeded && !m.skewed:
+		log.Warn(fmt.Sprintf("Your clock differs from the time of the "+
+			"network by %v, please check your date and time are "+
+			"correct!  The blocks you mine may be rejected", skew),
+			"samples", numOffsets)
+	case !exceeded && m.skewed:
+		log.Info(fmt.Sprintf("Your clock agrees with the time of the "+
+			"network again (skew %v)", skew))
+	}
+	m.skewed = exceeded
+}
+
+// ClockSkew returns the median of the offsets of the time samples, which is
+// not limited like Offset, and whether it exceeds the maximum clock skew.
+//
+// This function is safe for concurrent access and is part of the
+// MedianTimeSource interface implementation.
+func (m *medianTime) ClockSkew() (time.Duration, bool) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	return time.Duration(m.skewSecs) * time.Second, m.skewed
+}
+
 // Offset returns the number of seconds to adjust the local clock based upon the
 // median of the time samples added by AddTimeData.
 //
@@ -217,8 +278,16 @@ func (m *medianTime) Offset() time.Duration {
 // expects the time samples to be added from the timestamp field of the version
 // message received from remote peers that successfully connect and negotiate.
 func NewMedianTime() MedianTimeSource {
+	return NewMedianTimeWithMaxSkew(DefaultMaxClockSkew)
+}
+
+// NewMedianTimeWithMaxSkew returns a new MedianTimeSource like NewMedianTime
+// which reports the local clock as wrong when the median of the time samples
+// differs from it by more than maxSkew.
+func NewMedianTimeWithMaxSkew(maxSkew time.Duration) MedianTimeSource {
 	return &medianTime{
-		knownIDs: make(map[string]struct{}),
-		offsets:  make([]int64, 0, maxMedianTimeEntries),
+		knownIDs:    make(map[string]struct{}),
+		offsets:     make([]int64, 0, maxMedianTimeEntries),
+		maxSkewSecs: int64(maxSkew / time.Second),
 	}
 }
@@ -105,3 +105,46 @@ func TestMedianTime(t *testing.T) {
 		}
 	}
 }
+
+// TestClockSkew tests the clock skew reported by the medianTime
+// implementation, which is the true median of the samples.
+func TestClockSkew(t *testing.T) {
+	tests := []struct {
+		in       []int64
+		wantSkew int64
+		exceeded bool
+	}{
+		// Not enough samples must result in a skew of 0.
+		{in: []int64{100, 100, 100, 100}, wantSkew: 0},
+
+		// The skew is the median of the samples, including for an even
+		// number of samples.
+		{in: []int64{-13, 57, -4, -23, -12}, wantSkew: -12},
+		{in: []int64{55, -13, 61, -52, 39, 55}, wantSkew: 47},
+
+		// The skew is not limited like the offset.
+		{in: []int64{-4201, -4202, -4203, 4204, -4205}, wantSkew: -4202, exceeded: true},
+		{in: []int64{70, 80, 90, 100, 110}, wantSkew: 90, exceeded: true},
+	}
+
+	for i, test := range tests {
+		filter := NewMedianTimeWithMaxSkew(time.Minute)
+		for j, offset := range test.in {
+			now := time.Unix(roughtime.Now().Unix(), 0)
+			filter.AddTimeSample(strconv.Itoa(j),
+				now.Add(time.Duration(offset)*time.Second))
+		}
+
+		// Allow a fudge factor of one second like TestMedianTime.
+		gotSkew, exceeded := filter.ClockSkew()
+		wantSkew := time.Duration(test.wantSkew) * time.Second
+		if gotSkew != wantSkew && gotSkew != wantSkew-time.Second {
+			t.Errorf("ClockSkew #%d: unexpected skew -- got %v, want %v",
+				i, gotSkew, wantSkew)
+		}
+		if exceeded != test.exceeded {
+			t.Errorf("ClockSkew #%d: unexpected exceeded -- got %v, "+
+				"want %v", i, exceeded, test.exceeded)
+		}
+	}
+}
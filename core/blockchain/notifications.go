@@ -9,6 +9,7 @@ package blockchain
 import (
 	"fmt"
 	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
 	"github.com/Qitmeer/qitmeer/core/event"
 	"github.com/Qitmeer/qitmeer/core/types"
 )
@@ -34,6 +35,18 @@ const (
 	// Reorganization indicates that a blockchain reorganization is in
 	// progress.
 	Reorganization
+
+	// FinalityPointAdvanced indicates that the finality point of the block
+	// DAG advanced to a newer hourglass block.
+	FinalityPointAdvanced
+
+	// OrphanBlockAdded indicates that a block was added to the orphan pool
+	// because some of its parents are missing.
+	OrphanBlockAdded
+
+	// ChainReorg indicates that a reorganization completed and lists the
+	// blocks whose order changed.
+	ChainReorg
 )
 
 // notificationTypeStrings is a map of notification types back to their constant
@@ -43,6 +56,10 @@ var notificationTypeStrings = map[NotificationType]string{
 	BlockConnected:    "BlockConnected",
 	BlockDisconnected: "BlockDisconnected",
 	Reorganization:    "Reorganization",
+
+	FinalityPointAdvanced: "FinalityPointAdvanced",
+	OrphanBlockAdded:      "OrphanBlockAdded",
+	ChainReorg:            "ChainReorg",
 }
 
 // String returns the NotificationType in human-readable form.
@@ -74,6 +91,93 @@ type ReorganizationNotifyData struct {
 	NewOrder  uint64
 }
 
+// FinalityPointNotifyData is the structure for data indicating information
+// about a new finality point.
+type FinalityPointNotifyData struct {
+	Hash   *hash.Hash
+	Order  uint64
+	Height uint64
+}
+
+// OrphanBlockNotifyData is the structure for data indicating information
+// about a block added to the orphan pool.
+type OrphanBlockNotifyData struct {
+	Block *types.SerializedBlock
+
+	// MissingParents are the parents of the block which are neither in
+	// the block DAG nor in the orphan pool.
+	MissingParents []*hash.Hash
+}
+
+// BlockOrder is a block along with its order.
+type BlockOrder struct {
+	Hash  *hash.Hash
+	Order uint64
+}
+
+// BlockReorder is a block whose order was changed by a reorganization.
+type BlockReorder struct {
+	Hash     *hash.Hash
+	OldOrder uint64
+	NewOrder uint64
+}
+
+// ChainReorgNotifyData is the structure for data indicating information
+// about a completed reorganization.  The detached blocks are in their old
+// order and the attached blocks in their new order, so a block which is in
+// both lists was moved from its old order to its new one, while a block which
+// is only attached, such as the new block, was not ordered before.
+type ChainReorgNotifyData struct {
+	Detached []BlockOrder
+	Attached []BlockOrder
+}
+
+// newChainReorgNotifyData returns the notification data of the order change.
+// The attached blocks which are not ordered are left out.
+func newChainReorgNotifyData(oc *blockdag.OrderChange) *ChainReorgNotifyData {
+	data := &ChainReorgNotifyData{
+		Detached: make([]BlockOrder, 0, len(oc.Detached)),
+		Attached: make([]BlockOrder, 0, len(oc.Attached)),
+	}
+	for _, boh := range oc.Detached {
+		data.Detached = append(data.Detached, BlockOrder{
+			Hash:  boh.Block.GetHash(),
+			Order: uint64(boh.OldOrder),
+		})
+	}
+	for _, ib := range oc.Attached {
+		if !ib.IsOrdered() {
+			continue
+		}
+		data.Attached = append(data.Attached, BlockOrder{
+			Hash:  ib.GetHash(),
+			Order: uint64(ib.GetOrder()),
+		})
+	}
+	return data
+}
+
+// Reordered returns the blocks whose order changed, in their new order.
+func (d *ChainReorgNotifyData) Reordered() []BlockReorder {
+	oldOrders := make(map[hash.Hash]uint64, len(d.Detached))
+	for _, bo := range d.Detached {
+		oldOrders[*bo.Hash] = bo.Order
+	}
+	result := []BlockReorder{}
+	for _, bo := range d.Attached {
+		oldOrder, ok := oldOrders[*bo.Hash]
+		if !ok || oldOrder == bo.Order {
+			continue
+		}
+		result = append(result, BlockReorder{
+			Hash:     bo.Hash,
+			OldOrder: oldOrder,
+			NewOrder: bo.Order,
+		})
+	}
+	return result
+}
+
 // Notification defines notification that is sent to the caller via the callback
 // function provided during the call to New and consists of a notification type
 // as well as associated data that depends on the type as follows:
@@ -81,6 +185,9 @@ type ReorganizationNotifyData struct {
 // 	- BlockConnected:        []*types.Block of len 2
 // 	- BlockDisconnected:     []*types.Block of len 2
 //  - Reorganization:        *ReorganizationNotifyData
+//  - FinalityPointAdvanced: *FinalityPointNotifyData
+//  - OrphanBlockAdded:      *OrphanBlockNotifyData
+//  - ChainReorg:            *ChainReorgNotifyData
 
 type Notification struct {
 	Type NotificationType
@@ -0,0 +1,37 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"testing"
+)
+
+// TestChainReorgReordered tests that only the blocks which were detached and
+// attached again at another order are reported as reordered.
+func TestChainReorgReordered(t *testing.T) {
+	a := hash.HashH([]byte("a"))
+	b := hash.HashH([]byte("b"))
+	c := hash.HashH([]byte("c"))
+	n := hash.HashH([]byte("new"))
+
+	data := &ChainReorgNotifyData{
+		Detached: []BlockOrder{{&a, 10}, {&b, 11}, {&c, 12}},
+		Attached: []BlockOrder{{&a, 10}, {&n, 11}, {&b, 12}, {&c, 13}},
+	}
+	reordered := data.Reordered()
+	if len(reordered) != 2 {
+		t.Fatalf("got %d reordered blocks, want 2", len(reordered))
+	}
+	want := []BlockReorder{{&b, 11, 12}, {&c, 12, 13}}
+	for i, br := range reordered {
+		if !br.Hash.IsEqual(want[i].Hash) || br.OldOrder != want[i].OldOrder ||
+			br.NewOrder != want[i].NewOrder {
+			t.Errorf("reordered block %d is %v %d->%d, want %v %d->%d", i,
+				br.Hash, br.OldOrder, br.NewOrder, want[i].Hash,
+				want[i].OldOrder, want[i].NewOrder)
+		}
+	}
+}
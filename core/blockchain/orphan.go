@@ -1,9 +1,11 @@
 package blockchain
 
 import (
+	"fmt"
 	"github.com/Qitmeer/qitmeer/common/hash"
 	"github.com/Qitmeer/qitmeer/common/roughtime"
 	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/event"
 	"github.com/Qitmeer/qitmeer/core/types"
 	"math"
 	"sort"
@@ -135,21 +137,49 @@ func (b *BlockChain) removeOrphanBlock(orphan *orphanBlock) {
 	// Remove the orphan block from the orphan pool.
 	orphanHash := orphan.block.Hash()
 	delete(b.orphans, *orphanHash)
+
+	// Remove the reference from the previous orphan index too.  An indexing
+	// for loop is intentionally used over a range here as range does not
+	// reevaluate the slice on each iteration nor does it adjust the index
+	// for the modified slice.
+	for _, parentHash := range orphan.block.Block().Parents {
+		orphans := b.prevOrphans[*parentHash]
+		for i := 0; i < len(orphans); i++ {
+			if orphans[i].block.Hash().IsEqual(orphanHash) {
+				copy(orphans[i:], orphans[i+1:])
+				orphans[len(orphans)-1] = nil
+				orphans = orphans[:len(orphans)-1]
+				i--
+			}
+		}
+		if len(orphans) == 0 {
+			delete(b.prevOrphans, *parentHash)
+		} else {
+			b.prevOrphans[*parentHash] = orphans
+		}
+	}
+	if b.oldestOrphan == orphan {
+		b.oldestOrphan = nil
+	}
 }
 
 // addOrphanBlock adds the passed block (which is already determined to be
-// an orphan prior calling this function) to the orphan pool.  It lazily cleans
-// up any expired blocks so a separate cleanup poller doesn't need to be run.
+// an orphan prior calling this function) to the orphan pool and indexes it by
+// its parents which are not in the block DAG yet.  It lazily cleans up any
+// expired blocks so a separate cleanup poller doesn't need to be run.
 // It also imposes a maximum limit on the number of outstanding orphan
 // blocks and will remove the oldest received orphan block if the limit is
 // exceeded.
-func (b *BlockChain) addOrphanBlock(block *types.SerializedBlock) {
+//
+// It returns the parents of the block which are neither in the block DAG nor
+// in the orphan pool, so they can be requested from the peers.
+func (b *BlockChain) addOrphanBlock(block *types.SerializedBlock) []*hash.Hash {
 	serializedHeight, err := ExtractCoinbaseHeight(block.Block().Transactions[0])
 	if err != nil {
-		return
+		return nil
 	}
 	if !b.IsOrphanOK(serializedHeight) {
-		return
+		return nil
 	}
 	// Protect concurrent access.  This is intentionally done here instead
 	// of near the top since removeOrphanBlock does its own locking and
@@ -157,12 +187,14 @@ func (b *BlockChain) addOrphanBlock(block *types.SerializedBlock) {
 	b.orphanLock.Lock()
 	defer b.orphanLock.Unlock()
 
+	if b.isOrphan(block.Hash()) {
+		return nil
+	}
 	b.refreshOrphans()
 	// Limit orphan blocks to prevent memory exhaustion.
-	if len(b.orphans)+1 > MaxOrphanBlocks*2 {
+	if len(b.orphans)+1 > MaxOrphanBlocks*2 && b.oldestOrphan != nil {
 		// Remove the oldest orphan to make room for the new one.
 		b.removeOrphanBlock(b.oldestOrphan)
-		b.oldestOrphan = nil
 	}
 
 	// Insert the block into the orphan map with an expiration time
@@ -174,51 +206,91 @@ func (b *BlockChain) addOrphanBlock(block *types.SerializedBlock) {
 		height:     serializedHeight,
 	}
 	b.orphans[*block.Hash()] = oBlock
+
+	// Add to the previous orphan index for every parent which is not in
+	// the block DAG, so the orphan is processed once they arrive.
+	var missing []*hash.Hash
+	for _, parentHash := range block.Block().Parents {
+		if b.bd.HasBlock(parentHash) {
+			continue
+		}
+		b.prevOrphans[*parentHash] = append(b.prevOrphans[*parentHash], oBlock)
+		if !b.isOrphan(parentHash) {
+			missing = append(missing, parentHash)
+		}
+	}
+	return missing
 }
 
 // processOrphans determines if there are any orphans which depend on the passed
-// block hash (they are no longer orphans if true) and potentially accepts them.
-// It repeats the process for the newly accepted blocks (to detect further
-// orphans which may no longer be orphans) until there are no more.
+// block hash (they are no longer orphans if all their parents are in the block
+// DAG) and potentially accepts them.  It repeats the process for the newly
+// accepted blocks (to detect further orphans which may no longer be orphans)
+// until there are no more.
 //
 // The flags do not modify the behavior of this function directly, however they
 // are needed to pass along to maybeAcceptBlock.
 //
-// This function MUST be called with the chain state lock held (for writes).
-func (b *BlockChain) processOrphans(flags BehaviorFlags) error {
-	if len(b.orphans) <= 0 {
-		return nil
-	}
-	queue := orphanBlockSlice{}
-	for _, v := range b.orphans {
-		queue = append(queue, v)
-	}
-	if len(queue) >= 2 {
-		sort.Sort(queue)
-	}
+// This function MUST NOT be called with the chain state lock held since
+// maybeAcceptBlock acquires it.
+func (b *BlockChain) processOrphans(h *hash.Hash, flags BehaviorFlags) error {
+	queue := []*hash.Hash{h}
 	for len(queue) > 0 {
-		cur := queue[0]
+		processHash := queue[0]
+		queue[0] = nil // Prevent GC leak.
 		queue = queue[1:]
 
-		exists := b.bd.HasBlock(cur.block.Hash())
-		if exists {
-			b.RemoveOrphanBlock(cur)
-			continue
+		// Copy the dependents of the block since accepting them
+		// modifies the previous orphan index.
+		b.orphanLock.RLock()
+		orphans := make(orphanBlockSlice, len(b.prevOrphans[*processHash]))
+		copy(orphans, b.prevOrphans[*processHash])
+		b.orphanLock.RUnlock()
+		if len(orphans) >= 2 {
+			sort.Sort(orphans)
 		}
 
-		allExists := true
-		for _, h := range cur.block.Block().Parents {
-			exists := b.bd.HasBlock(h)
+		for _, orphan := range orphans {
+			orphanHash := orphan.block.Hash()
+			if b.bd.HasBlock(orphanHash) {
+				b.RemoveOrphanBlock(orphan)
+				continue
+			}
+			// The orphan still waits for its other parents.
+			allExists := true
+			for _, parentHash := range orphan.block.Block().Parents {
+				if !b.bd.HasBlock(parentHash) {
+					allExists = false
+					break
+				}
+			}
+			if !allExists {
+				continue
+			}
+
+			// Remove the orphan from the pool unless another caller
+			// already did so to process it.
+			b.orphanLock.Lock()
+			exists := b.isOrphan(orphanHash)
+			if exists {
+				b.removeOrphanBlock(orphan)
+			}
+			b.orphanLock.Unlock()
 			if !exists {
-				allExists = false
+				continue
 			}
 
+			err := b.maybeAcceptBlock(orphan.block, flags)
+			if err != nil {
+				log.Debug(fmt.Sprintf("Failed to accept orphan block %s: %v",
+					orphanHash, err))
+				continue
+			}
+			// Add this block to the list of blocks to process so
+			// any orphan blocks that depend on this block are
+			// handled too.
+			queue = append(queue, orphanHash)
 		}
-		if !allExists {
-			continue
-		}
-		b.RemoveOrphanBlock(cur)
-		b.maybeAcceptBlock(cur.block, flags)
 	}
 	return nil
 }
@@ -238,16 +310,32 @@ func (b *BlockChain) getOrphan(hash *hash.Hash) *types.SerializedBlock {
 	return orphan.block
 }
 
+// RefreshOrphans removes the expired orphans and processes the orphans whose
+// parents arrived in the block DAG.
 func (b *BlockChain) RefreshOrphans() error {
 	b.orphanLock.Lock()
 	b.refreshOrphans()
+	var arrived []*hash.Hash
+	for parentHash := range b.prevOrphans {
+		if b.bd.HasBlock(&parentHash) {
+			h := parentHash
+			arrived = append(arrived, &h)
+		}
+	}
 	b.orphanLock.Unlock()
 
-	return b.processOrphans(BFP2PAdd)
+	for _, h := range arrived {
+		err := b.processOrphans(h, BFP2PAdd)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (b *BlockChain) refreshOrphans() {
 	// Remove expired orphan blocks.
+	b.oldestOrphan = nil
 	for _, oBlock := range b.orphans {
 		if roughtime.Now().After(oBlock.expiration) {
 			b.removeOrphanBlock(oBlock)
@@ -265,3 +353,18 @@ func (b *BlockChain) refreshOrphans() {
 		}
 	}
 }
+
+// notifyOrphanBlock notifies the caller that the block was added to the orphan
+// pool, so the missing parents can be requested from the peers.  It is sent at
+// once rather than cached, since the chain state lock is not held for writes
+// when orphans are added.
+func (b *BlockChain) notifyOrphanBlock(block *types.SerializedBlock, missing []*hash.Hash) {
+	if b.events == nil || len(missing) == 0 {
+		return
+	}
+	n := &Notification{Type: OrphanBlockAdded, Data: &OrphanBlockNotifyData{
+		Block:          block,
+		MissingParents: missing,
+	}}
+	b.events.Send(event.New(n))
+}
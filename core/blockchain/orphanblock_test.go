@@ -10,6 +10,9 @@
 package blockchain
 
 import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/core/types/pow"
 	"math/rand"
 	"sort"
 	"testing"
@@ -26,3 +29,43 @@ func Test_SortOrphanBlockSlice(t *testing.T) {
 	}
 
 }
+
+// Test_RemoveOrphanBlockIndex checks that removing orphans keeps the previous
+// orphan index consistent.
+func Test_RemoveOrphanBlockIndex(t *testing.T) {
+	b := &BlockChain{
+		orphans:     make(map[hash.Hash]*orphanBlock),
+		prevOrphans: make(map[hash.Hash][]*orphanBlock),
+	}
+	parentA := hash.MustHexToDecodedHash("01")
+	parentB := hash.MustHexToDecodedHash("02")
+	newOrphan := func(nonce uint64, parents ...*hash.Hash) *orphanBlock {
+		block := &types.Block{Parents: parents}
+		block.Header.Pow = pow.GetInstance(pow.BLAKE2BD, nonce, []byte{})
+		ob := &orphanBlock{block: types.NewBlock(block)}
+		b.orphans[*ob.block.Hash()] = ob
+		for _, h := range parents {
+			b.prevOrphans[*h] = append(b.prevOrphans[*h], ob)
+		}
+		return ob
+	}
+	first := newOrphan(1, &parentA, &parentB)
+	second := newOrphan(2, &parentA)
+	b.oldestOrphan = first
+
+	b.removeOrphanBlock(first)
+	if b.isOrphan(first.block.Hash()) || b.oldestOrphan != nil {
+		t.Fatal("the removed orphan is still in the pool")
+	}
+	if len(b.prevOrphans[parentA]) != 1 || b.prevOrphans[parentA][0] != second {
+		t.Fatalf("parent A has %d dependents, expected 1", len(b.prevOrphans[parentA]))
+	}
+	if _, ok := b.prevOrphans[parentB]; ok {
+		t.Fatal("parent B without dependents is still indexed")
+	}
+
+	b.removeOrphanBlock(second)
+	if len(b.orphans) != 0 || len(b.prevOrphans) != 0 {
+		t.Fatalf("%d orphans and %d parents left", len(b.orphans), len(b.prevOrphans))
+	}
+}
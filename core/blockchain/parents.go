@@ -0,0 +1,119 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/common/util"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"sort"
+	"time"
+)
+
+// checkBlockParents performs the validation checks on the parent set of the
+// block which depend on the DAG, before the block is inserted into it:
+//   - The block must not have more parents than allowed.
+//   - No parent may be in the past of another parent, since it is then already
+//     merged by the latter.
+//   - The layers of the parents must be at most blockdag.MaxTipLayerGap apart,
+//     so that every parent is within the anticone window of the others.
+//   - The timestamp of the block must not precede the median timestamp of its
+//     parents.
+//
+// The checks only apply to the blocks from the ParentsRulesMainHeight of the
+// network, the block being at the given main height.
+//
+// The flags modify the behavior of this function as follows:
+//   - BFFastAdd: The timestamp is not checked, as checkBlockHeaderContext does
+//     not check it against the median time either.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) checkBlockParents(block *types.SerializedBlock, mainHeight uint, flags BehaviorFlags) error {
+	if mainHeight < b.params.ParentsRulesMainHeight {
+		return nil
+	}
+
+	parentHashes := block.Block().Parents
+	if len(parentHashes) > types.MaxParentsPerBlock {
+		str := fmt.Sprintf("block contains too many parents - "+
+			"got %d, max %d", len(parentHashes), types.MaxParentsPerBlock)
+		return ruleError(ErrTooManyParents, str)
+	}
+
+	parents := make([]blockdag.IBlock, 0, len(parentHashes))
+	for _, h := range parentHashes {
+		ib := b.bd.GetBlock(h)
+		if ib == nil {
+			str := fmt.Sprintf("parent %s of block %s is unknown", h,
+				block.Hash())
+			return ruleError(ErrMissingParent, str)
+		}
+		parents = append(parents, ib)
+	}
+
+	minLayer, maxLayer := parents[0].GetLayer(), parents[0].GetLayer()
+	for _, p := range parents[1:] {
+		if p.GetLayer() < minLayer {
+			minLayer = p.GetLayer()
+		}
+		if p.GetLayer() > maxLayer {
+			maxLayer = p.GetLayer()
+		}
+	}
+	if maxLayer-minLayer > blockdag.MaxTipLayerGap {
+		str := fmt.Sprintf("parents of block %s span the layers %d to %d, "+
+			"max gap %d", block.Hash(), minLayer, maxLayer,
+			blockdag.MaxTipLayerGap)
+		return ruleError(ErrParentsTooFar, str)
+	}
+
+	for _, p := range parents {
+		for _, other := range parents {
+			if b.bd.IsAncestor(p.GetHash(), other.GetHash()) {
+				str := fmt.Sprintf("parent %s of block %s is in the "+
+					"past of its parent %s", p.GetHash(), block.Hash(),
+					other.GetHash())
+				return ruleError(ErrRedundantParent, str)
+			}
+		}
+	}
+
+	if flags&BFFastAdd == BFFastAdd {
+		return nil
+	}
+	header := &block.Block().Header
+	medianTime := b.CalcParentsMedianTime(parentHashes)
+	if header.Timestamp.Before(medianTime) {
+		str := fmt.Sprintf("block timestamp of %v precedes the median "+
+			"timestamp %v of its parents", header.Timestamp.Unix(),
+			medianTime.Unix())
+		return ruleError(ErrTimeBeforeParents, str)
+	}
+	return nil
+}
+
+// CalcParentsMedianTime returns the median timestamp of the parents, which the
+// timestamp of a block with the parents must not precede.  The upper one of
+// the two middle timestamps is used for an even number of parents.  The zero
+// time is returned when no parent is known.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) CalcParentsMedianTime(parents []*hash.Hash) time.Time {
+	timestamps := make([]int64, 0, len(parents))
+	for _, h := range parents {
+		node := b.GetBlockNode(b.bd.GetBlock(h))
+		if node == nil {
+			continue
+		}
+		timestamps = append(timestamps, node.GetTimestamp())
+	}
+	if len(timestamps) == 0 {
+		return time.Time{}
+	}
+	sort.Sort(util.TimeSorter(timestamps))
+	return time.Unix(timestamps[len(timestamps)/2], 0)
+}
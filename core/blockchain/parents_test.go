@@ -0,0 +1,118 @@
+package blockchain
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/core/types/pow"
+	"github.com/Qitmeer/qitmeer/params"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestCheckBlockParents checks that each parent set rule accepts the blocks at
+// its limit and rejects the ones past it from the activation main height, and
+// that the rejected blocks pass before it.
+func TestCheckBlockParents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "parents")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db := newVectorDB(t, dir)
+	defer db.Close()
+	datas := make(map[hash.Hash]blockdag.IBlockData)
+	bd := newFixtureDAG(t, db, datas)
+	par := params.PrivNetParams
+	par.ParentsRulesMainHeight = 10
+	b := &BlockChain{db: db, bd: bd, params: &par}
+
+	genesisTime := par.GenesisBlock.Header.Timestamp
+	nonce := uint64(0)
+	newBlock := func(ts time.Duration, parents ...*hash.Hash) *types.SerializedBlock {
+		nonce++
+		block := &types.Block{Parents: parents}
+		block.Header.Timestamp = genesisTime.Add(ts)
+		block.Header.Pow = pow.GetInstance(pow.BLAKE2BD, nonce, []byte{})
+		return types.NewBlock(block)
+	}
+	addBlock := func(ts time.Duration, parents ...*hash.Hash) *hash.Hash {
+		block := newBlock(ts, parents...)
+		addFixtureBlock(t, bd, datas, NewBlockNode(&block.Block().Header,
+			block.Block().Parents))
+		return block.Hash()
+	}
+
+	// A chain of MaxTipLayerGap+2 blocks, a side block at the layer of the
+	// first one and MaxParentsPerBlock+1 siblings, all on the genesis.
+	g := addBlock(0)
+	chain := []*hash.Hash{g}
+	for i := 1; i <= blockdag.MaxTipLayerGap+2; i++ {
+		chain = append(chain, addBlock(time.Duration(i)*10*time.Second, chain[i-1]))
+	}
+	side := addBlock(5*time.Second, g)
+	var siblings []*hash.Hash
+	for i := 0; i <= types.MaxParentsPerBlock; i++ {
+		siblings = append(siblings, addBlock(time.Second, g))
+	}
+
+	// The median timestamp of the first block of the chain and of the side
+	// block is the upper one, 10 seconds after the genesis.
+	late := time.Hour
+	tests := []struct {
+		name   string
+		block  *types.SerializedBlock
+		flags  BehaviorFlags
+		reject bool
+		code   ErrorCode
+	}{
+		{name: "max parents",
+			block: newBlock(late, siblings[:types.MaxParentsPerBlock]...)},
+		{name: "too many parents",
+			block:  newBlock(late, siblings...),
+			reject: true, code: ErrTooManyParents},
+		{name: "independent parents",
+			block: newBlock(late, chain[1], side)},
+		{name: "redundant parent",
+			block:  newBlock(late, chain[2], chain[1]),
+			reject: true, code: ErrRedundantParent},
+		{name: "max layer gap",
+			block: newBlock(late, chain[blockdag.MaxTipLayerGap+1], side)},
+		{name: "parents too far",
+			block:  newBlock(late, chain[blockdag.MaxTipLayerGap+2], side),
+			reject: true, code: ErrParentsTooFar},
+		{name: "time at parents median",
+			block: newBlock(10*time.Second, chain[1], side)},
+		{name: "time before parents",
+			block:  newBlock(9*time.Second, chain[1], side),
+			reject: true, code: ErrTimeBeforeParents},
+		{name: "time before parents fast add",
+			block: newBlock(9*time.Second, chain[1], side),
+			flags: BFFastAdd},
+	}
+	for _, test := range tests {
+		err := b.checkBlockParents(test.block, par.ParentsRulesMainHeight,
+			test.flags)
+		if !test.reject {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", test.name, err)
+			}
+			continue
+		}
+		rerr, ok := err.(RuleError)
+		if !ok || rerr.ErrorCode != test.code {
+			t.Errorf("%s: got %v, want %v", test.name, err, test.code)
+		}
+
+		// The rules do not apply before their activation.
+		err = b.checkBlockParents(test.block, par.ParentsRulesMainHeight-1,
+			test.flags)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v before the activation",
+				test.name, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/merkle"
+	"github.com/Qitmeer/qitmeer/core/serialization"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"sync"
+)
+
+// maxPrevalidatedBlocks is the maximum number of candidate blocks remembered
+// by the pre-validation cache.
+const maxPrevalidatedBlocks = 100
+
+// CandidateHash returns the hash of a block header without its proof of work,
+// which identifies a candidate block across the nonces tried by the miners.
+// The header only commits to the transactions without their signature
+// scripts, so the witness merkle root of the block is hashed as well.
+func CandidateHash(block *types.SerializedBlock) hash.Hash {
+	header := &block.Block().Header
+	merkles := merkle.BuildMerkleTreeStore(block.Transactions(), true)
+	buf := bytes.NewBuffer(make([]byte, 0, types.MaxBlockHeaderPayload+hash.HashSize))
+	sec := uint32(header.Timestamp.Unix())
+	_ = serialization.WriteElements(buf, header.Version, &header.ParentRoot, &header.TxRoot,
+		&header.StateRoot, header.Difficulty, sec, merkles[len(merkles)-1])
+	return hash.DoubleHashH(buf.Bytes())
+}
+
+// prevalidatedCache remembers the candidate blocks which passed the contextual
+// validation of CheckConnectBlockTemplate, keyed by their candidate hash, along
+// with the flags their scripts were verified with.  The scripts of a
+// transaction only depend on the transaction, on the outputs it spends, which
+// never change, and on the flags, so the scripts of a remembered block are not
+// verified again when the mined block is connected under the same flags.  The
+// other checks are always performed since they depend on the state of the DAG.
+type prevalidatedCache struct {
+	lock    sync.Mutex
+	entries map[hash.Hash]txscript.ScriptFlags
+	order   []hash.Hash
+}
+
+// add remembers the candidate block verified with the script flags.  The
+// oldest entry is forgotten when the cache is full.
+func (c *prevalidatedCache) add(h hash.Hash, flags txscript.ScriptFlags) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[hash.Hash]txscript.ScriptFlags)
+	}
+	if _, ok := c.entries[h]; ok {
+		c.entries[h] = flags
+		return
+	}
+	if len(c.order) >= maxPrevalidatedBlocks {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.entries[h] = flags
+	c.order = append(c.order, h)
+}
+
+// has returns whether the candidate block is remembered.
+func (c *prevalidatedCache) has(h hash.Hash) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	_, ok := c.entries[h]
+	return ok
+}
+
+// verified returns whether the scripts of the block were verified with the
+// flags.  The candidate hash of the block is only computed when the cache is
+// not empty.
+func (c *prevalidatedCache) verified(block *types.SerializedBlock, flags txscript.ScriptFlags) bool {
+	c.lock.Lock()
+	empty := len(c.entries) == 0
+	c.lock.Unlock()
+	if empty {
+		return false
+	}
+
+	h := CandidateHash(block)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	verifiedFlags, ok := c.entries[h]
+	return ok && verifiedFlags == flags
+}
+
+// IsPrevalidated returns whether the block, whatever its proof of work, passed
+// the contextual validation of CheckConnectBlockTemplate recently.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) IsPrevalidated(block *types.SerializedBlock) bool {
+	return b.prevalidated.has(CandidateHash(block))
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/core/types/pow"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"testing"
+)
+
+// prevalidateTestBlock returns a candidate block with a single coinbase mined
+// with the nonce.
+func prevalidateTestBlock(nonce uint64) *types.SerializedBlock {
+	coinbase := types.NewTransaction()
+	coinbase.AddTxIn(&types.TxInput{
+		PreviousOut: *types.NewOutPoint(&hash.Hash{}, types.MaxPrevOutIndex),
+		Sequence:    types.MaxTxInSequenceNum,
+		SignScript:  []byte{0x00, 0x00},
+	})
+	coinbase.AddTxOut(types.NewTxOutput(types.Amount{Value: 1, Id: types.MEERID},
+		[]byte{0x51}))
+	block := &types.Block{Parents: []*hash.Hash{{1}}}
+	block.AddTransaction(coinbase)
+	block.Header.Pow = pow.GetInstance(pow.BLAKE2BD, nonce, []byte{})
+	return types.NewBlock(block)
+}
+
+func TestPrevalidatedCache(t *testing.T) {
+	var cache prevalidatedCache
+	flags := txscript.ScriptBip16 | txscript.ScriptVerifySHA256
+
+	// The candidate hash is not computed while the cache is empty, which
+	// would panic for a nil block.
+	if cache.verified(nil, flags) {
+		t.Fatal("block verified by an empty cache")
+	}
+
+	block := prevalidateTestBlock(1)
+	cache.add(CandidateHash(block), flags)
+	if !cache.verified(block, flags) {
+		t.Fatal("remembered block not verified")
+	}
+	// The candidate is the same whatever the proof of work.
+	if !cache.verified(prevalidateTestBlock(2), flags) {
+		t.Fatal("block with another nonce not verified")
+	}
+	// The scripts are verified again under other flags.
+	if cache.verified(block, flags|txscript.ScriptVerifyCleanStack) {
+		t.Fatal("block verified under other flags")
+	}
+	cache.add(CandidateHash(block), flags|txscript.ScriptVerifyCleanStack)
+	if cache.verified(block, flags) || len(cache.order) != 1 {
+		t.Fatal("flags of the remembered block not replaced")
+	}
+
+	// The oldest candidates are forgotten.
+	for i := 0; i < maxPrevalidatedBlocks; i++ {
+		cache.add(hash.Hash{byte(i), 1}, flags)
+	}
+	if cache.has(CandidateHash(block)) || len(cache.entries) != maxPrevalidatedBlocks {
+		t.Fatalf("%d candidates remembered", len(cache.entries))
+	}
+}
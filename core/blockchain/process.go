@@ -6,6 +6,8 @@
 package blockchain
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/Qitmeer/qitmeer/core/types"
 	"github.com/Qitmeer/qitmeer/core/types/pow"
@@ -34,6 +36,30 @@ const (
 	BFNone BehaviorFlags = 0
 )
 
+// ErrReadOnly is returned by ProcessBlock when the chain does not accept new
+// blocks, for example because the disk of the data directory is almost full.
+// It is not a rule error since the block itself may be perfectly valid.
+var ErrReadOnly = errors.New("block chain is read only, new blocks are not accepted")
+
+// SetReadOnly toggles whether new blocks are rejected with ErrReadOnly.  The
+// chain can still be queried while it is read only.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) SetReadOnly(readOnly bool) {
+	b.ChainLock()
+	b.readOnly = readOnly
+	b.ChainUnlock()
+}
+
+// IsReadOnly returns whether new blocks are rejected.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) IsReadOnly() bool {
+	b.ChainRLock()
+	defer b.ChainRUnlock()
+	return b.readOnly
+}
+
 // ProcessBlock is the main workhorse for handling insertion of new blocks into
 // the block chain.  It includes functionality such as rejecting duplicate
 // blocks, ensuring blocks follow all rules, orphan handling, and insertion into
@@ -47,10 +73,28 @@ const (
 // also be zero as expected, because it, by definition, does not connect ot the
 // best chain.
 //
+// The blocks wait for the block processing queue, the blocks downloaded from
+// the peers being served before the other ones.
+//
 // This function is safe for concurrent access.
 func (b *BlockChain) ProcessBlock(block *types.SerializedBlock, flags BehaviorFlags) (bool, error) {
+	priority := PriorityMined
+	if flags&BFP2PAdd == BFP2PAdd {
+		priority = PrioritySync
+	}
+	release, err := b.Admit(context.Background(), priority)
+	if err != nil {
+		return false, err
+	}
+	defer release()
+
 	b.ChainRLock()
 
+	if b.readOnly {
+		b.ChainRUnlock()
+		return false, ErrReadOnly
+	}
+
 	fastAdd := flags&BFFastAdd == BFFastAdd
 
 	blockHash := block.Hash()
@@ -71,7 +115,7 @@ func (b *BlockChain) ProcessBlock(block *types.SerializedBlock, flags BehaviorFl
 	}
 
 	// Perform preliminary sanity checks on the block and its transactions.
-	err := b.checkBlockSanity(block, b.timeSource, flags, b.params)
+	err = b.checkBlockSanity(block, b.timeSource, flags, b.params)
 	if err != nil {
 		b.ChainRUnlock()
 		return false, err
@@ -122,15 +166,21 @@ func (b *BlockChain) ProcessBlock(block *types.SerializedBlock, flags BehaviorFl
 		}
 	}
 
+	// Remember how long after its timestamp the block was received, which
+	// tells the blocks withheld by their miners.
+	b.blockDelays.add(blockHash, b.timeSource.AdjustedTime().Sub(blockHeader.Timestamp),
+		block.Block().Parents)
+
 	// Handle orphan blocks.
 	for _, pb := range block.Block().Parents {
 		if !b.bd.HasBlock(pb) {
 			log.Trace(fmt.Sprintf("Adding orphan block %s with parent %s", blockHash.String(), pb.String()))
-			b.addOrphanBlock(block)
+			missing := b.addOrphanBlock(block)
 
 			// The fork length of orphans is unknown since they, by definition, do
 			// not connect to the best chain.
 			b.ChainRUnlock()
+			b.notifyOrphanBlock(block, missing)
 			return true, nil
 		}
 	}
@@ -144,7 +194,7 @@ func (b *BlockChain) ProcessBlock(block *types.SerializedBlock, flags BehaviorFl
 	// Accept any orphan blocks that depend on this block (they are no
 	// longer orphans) and repeat for those accepted blocks until there are
 	// no more.
-	err = b.RefreshOrphans()
+	err = b.processOrphans(blockHash, flags)
 	if err != nil {
 		return false, err
 	}
@@ -9,5 +9,5 @@ func (b *BlockChain) upgradeDB() error {
 	if b.dbInfo.version == currentDatabaseVersion {
 		return nil
 	}
-	return fmt.Errorf("You can cleanup your block data base by '--cleanup'.Your data is too old (%d -> %d). ", b.dbInfo.version, currentDatabaseVersion)
+	return fmt.Errorf("You can cleanup your block data base by '--cleanup', or migrate it from its blocks by 'fastibd migrate'.Your data is too old (%d -> %d). ", b.dbInfo.version, currentDatabaseVersion)
 }
@@ -236,7 +236,10 @@ func (view *UtxoViewpoint) SetViewpoints(views []*hash.Hash) {
 // Upon completion of this function, the view will contain an entry for each
 // requested transaction.  Fully spent transactions, or those which otherwise
 // don't exist, will result in a nil entry in the view.
-func (view *UtxoViewpoint) fetchUtxosMain(db database.DB, outpoints map[types.TxOutPoint]struct{}) error {
+//
+// The outputs found in the utxo cache, when it is not nil, are not loaded from
+// the database, and the ones loaded are added to it.
+func (view *UtxoViewpoint) fetchUtxosMain(db database.DB, cache *utxoCache, outpoints map[types.TxOutPoint]struct{}) error {
 	// Nothing to do if there are no requested hashes.
 	if len(outpoints) == 0 {
 		return nil
@@ -250,12 +253,32 @@ func (view *UtxoViewpoint) fetchUtxosMain(db database.DB, outpoints map[types.Tx
 	// since other code uses the presence of an entry in the store as a way
 	// to optimize spend and unspend updates to apply only to the specific
 	// utxos that the caller needs access to.
+	if cache != nil {
+		missing := make(map[types.TxOutPoint]struct{}, len(outpoints))
+		for outpoint := range outpoints {
+			entry, ok := cache.get(outpoint)
+			if !ok {
+				missing[outpoint] = struct{}{}
+				continue
+			}
+			if entry != nil {
+				view.entries[outpoint] = entry
+			}
+		}
+		if len(missing) == 0 {
+			return nil
+		}
+		outpoints = missing
+	}
 	return db.View(func(dbTx database.Tx) error {
 		for outpoint := range outpoints {
 			entry, err := dbFetchUtxoEntry(dbTx, outpoint)
 			if err != nil {
 				return err
 			}
+			if cache != nil {
+				cache.put(outpoint, entry)
+			}
 			if entry == nil {
 				continue
 			}
@@ -355,7 +378,7 @@ func (view *UtxoViewpoint) fetchInputUtxos(db database.DB, block *types.Serializ
 			txNeededSet[txIn.PreviousOut] = struct{}{}
 		}
 	}
-	err := view.fetchUtxosMain(db, txNeededSet)
+	err := view.fetchUtxosMain(db, bc.utxoCache, txNeededSet)
 	if err != nil {
 		return err
 	}
@@ -368,7 +391,7 @@ func (view *UtxoViewpoint) fetchInputUtxos(db database.DB, block *types.Serializ
 // fetchUtxos loads the unspent transaction outputs for the provided set of
 // outputs into the view from the database as needed unless they already exist
 // in the view in which case they are ignored.
-func (view *UtxoViewpoint) fetchUtxos(db database.DB, outpoints map[types.TxOutPoint]struct{}) error {
+func (view *UtxoViewpoint) fetchUtxos(db database.DB, cache *utxoCache, outpoints map[types.TxOutPoint]struct{}) error {
 	// Nothing to do if there are no requested outputs.
 	if len(outpoints) == 0 {
 		return nil
@@ -386,7 +409,7 @@ func (view *UtxoViewpoint) fetchUtxos(db database.DB, outpoints map[types.TxOutP
 	}
 
 	// Request the input utxos from the database.
-	return view.fetchUtxosMain(db, neededSet)
+	return view.fetchUtxosMain(db, cache, neededSet)
 }
 
 // connectTransaction updates the view by adding all new utxos created by the
@@ -597,7 +620,7 @@ func (b *BlockChain) FetchUtxoView(tx *types.Tx) (*UtxoViewpoint, error) {
 	view := NewUtxoViewpoint()
 	view.SetViewpoints(b.GetMiningTips())
 	b.ChainRLock()
-	err := view.fetchUtxosMain(b.db, neededSet)
+	err := view.fetchUtxosMain(b.db, b.utxoCache, neededSet)
 	b.ChainRUnlock()
 	if err != nil {
 		return view, err
@@ -620,14 +643,17 @@ func (b *BlockChain) FetchUtxoEntry(outpoint types.TxOutPoint) (*UtxoEntry, erro
 	b.ChainRLock()
 	defer b.ChainRUnlock()
 
-	var entry *UtxoEntry
-	err := b.db.View(func(dbTx database.Tx) error {
-		var err error
-		entry, err = dbFetchUtxoEntry(dbTx, outpoint)
-		return err
-	})
-	if err != nil {
-		return nil, err
+	entry, ok := b.utxoCache.get(outpoint)
+	if !ok {
+		err := b.db.View(func(dbTx database.Tx) error {
+			var err error
+			entry, err = dbFetchUtxoEntry(dbTx, outpoint)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		b.utxoCache.put(outpoint, entry)
 	}
 	if b.IsInvalidOut(entry) {
 		entry = nil
@@ -679,6 +705,26 @@ func dbFetchUtxoEntry(dbTx database.Tx, outpoint types.TxOutPoint) (*UtxoEntry,
 
 func dbPutUtxoView(dbTx database.Tx, view *UtxoViewpoint) error {
 	utxoBucket := dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName)
+	stats, err := dbFetchUtxoStats(dbTx)
+	if err != nil {
+		return err
+	}
+	// updateStats replaces the previous entry of the key in the statistics
+	// of the utxo set by the serialized one, nil when it is removed.
+	updateStats := func(key []byte, serialized []byte) error {
+		if stats == nil || !stats.covers(key) {
+			return nil
+		}
+		if old := utxoBucket.Get(key); old != nil {
+			if err := stats.remove(key, old); err != nil {
+				return err
+			}
+		}
+		if serialized == nil {
+			return nil
+		}
+		return stats.add(key, serialized)
+	}
 	for outpoint, entry := range view.entries {
 		// No need to update the database if the entry was not modified.
 		if entry == nil || !entry.isModified() {
@@ -688,7 +734,10 @@ func dbPutUtxoView(dbTx database.Tx, view *UtxoViewpoint) error {
 		// Remove the utxo entry if it is spent.
 		if entry.IsSpent() {
 			key := outpointKey(outpoint)
-			err := utxoBucket.Delete(*key)
+			err := updateStats(*key, nil)
+			if err == nil {
+				err = utxoBucket.Delete(*key)
+			}
 			recycleOutpointKey(key)
 			if err != nil {
 				return err
@@ -703,6 +752,9 @@ func dbPutUtxoView(dbTx database.Tx, view *UtxoViewpoint) error {
 			return err
 		}
 		key := outpointKey(outpoint)
+		if err := updateStats(*key, serialized); err != nil {
+			return err
+		}
 		err = utxoBucket.Put(*key, serialized)
 		// NOTE: The key is intentionally not recycled here since the
 		// database interface contract prohibits modifications.  It will
@@ -713,7 +765,10 @@ func dbPutUtxoView(dbTx database.Tx, view *UtxoViewpoint) error {
 		}
 	}
 
-	return nil
+	if stats == nil {
+		return nil
+	}
+	return dbPutUtxoStats(dbTx, stats)
 }
 
 const UtxoEntryAmountCoinIDSize = 2
@@ -795,6 +850,10 @@ func serializeUtxoEntry(entry *UtxoEntry) ([]byte, error) {
 	return serialized, nil
 }
 
+// outpointKey returns the key of the output in the utxo set, which is the hash
+// of its transaction followed by its VLQ encoded index, so that each output is
+// stored, loaded and deleted on its own.  The key is taken from a free list and
+// should be returned to it with recycleOutpointKey once it is no longer used.
 func outpointKey(outpoint types.TxOutPoint) *[]byte {
 	// A VLQ employs an MSB encoding, so they are useful not only to reduce
 	// the amount of storage space, but also so iteration of utxos when
@@ -0,0 +1,472 @@
+package blockchain
+
+import (
+	"container/list"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/dbnamespace"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
+	"sync"
+	"time"
+)
+
+// utxoCacheEntrySize is the approximate memory used by a cached utxo besides
+// its public key script, which is also the memory used by a cached missing
+// utxo.
+const utxoCacheEntrySize = 160
+
+// utxoCacheFlushInterval is the longest time the outputs modified by the
+// connected blocks are kept in the cache before they are written to the
+// database.
+const utxoCacheFlushInterval = 5 * time.Minute
+
+// utxoCacheEntry is an output in the utxo cache.  A nil entry records that the
+// output is not in the utxo set, which saves the lookups of the outputs of the
+// new transactions checked for duplicates.
+type utxoCacheEntry struct {
+	outpoint types.TxOutPoint
+	entry    *UtxoEntry
+}
+
+func (e *utxoCacheEntry) size() uint64 {
+	if e.entry == nil {
+		return utxoCacheEntrySize
+	}
+	return utxoCacheEntrySize + uint64(len(e.entry.pkScript))
+}
+
+// utxoState is the order and the hash of the last block whose outputs were
+// applied to the utxo set.
+type utxoState struct {
+	order uint
+	hash  hash.Hash
+}
+
+// utxoStateSize is the size of a serialized utxo state.
+const utxoStateSize = 4 + hash.HashSize
+
+// dbPutUtxoState stores the state of the utxo set written to the database.
+func dbPutUtxoState(dbTx database.Tx, state *utxoState) error {
+	serialized := make([]byte, utxoStateSize)
+	byteOrder.PutUint32(serialized, uint32(state.order))
+	copy(serialized[4:], state.hash[:])
+	return dbTx.Metadata().Put(dbnamespace.UtxoStateKeyName, serialized)
+}
+
+// dbFetchUtxoState returns the state of the utxo set written to the database,
+// or nil when the database predates it.
+func dbFetchUtxoState(dbTx database.Tx) (*utxoState, error) {
+	serialized := dbTx.Metadata().Get(dbnamespace.UtxoStateKeyName)
+	if serialized == nil {
+		return nil, nil
+	}
+	if len(serialized) != utxoStateSize {
+		return nil, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt utxo state: %d bytes",
+				len(serialized)),
+		}
+	}
+	state := &utxoState{order: uint(byteOrder.Uint32(serialized))}
+	copy(state.hash[:], serialized[4:])
+	return state, nil
+}
+
+// utxoCache is a least recently used cache of the utxo set bounded by an
+// approximate memory budget, which saves the database lookups of the outputs
+// spent by the blocks and the memory pool.
+//
+// It is a write-back cache: the outputs modified by the connected blocks are
+// kept dirty in the cache, where they are never evicted, and written to the
+// database by a flush along with the state of the last block applied.  The
+// cache is flushed once the dirty outputs exceed the budget or after
+// utxoCacheFlushInterval, around the reorganizations, when the finality point
+// advances and on shutdown.  The spend journal, the indexes and the DAG are
+// still written when each block is connected, so after a crash the blocks
+// ordered after the stored state are replayed from the database to restore
+// the dirty outputs lost.  The disconnected blocks, and every block when the
+// budget is zero, are written through.
+type utxoCache struct {
+	lock    sync.Mutex
+	maxSize uint64
+	size    uint64
+	entries map[types.TxOutPoint]*list.Element
+	lru     *list.List
+
+	// dirty are the outputs modified since the last flush, the spent ones
+	// included.
+	dirty map[types.TxOutPoint]*UtxoEntry
+
+	// state is the last block applied to the cache, and flushed the one
+	// stored in the database.
+	state     utxoState
+	flushed   utxoState
+	lastFlush time.Time
+}
+
+func newUtxoCache(maxSize uint64) *utxoCache {
+	return &utxoCache{
+		maxSize:   maxSize,
+		entries:   map[types.TxOutPoint]*list.Element{},
+		lru:       list.New(),
+		dirty:     map[types.TxOutPoint]*UtxoEntry{},
+		lastFlush: time.Now(),
+	}
+}
+
+// get returns a copy of the cached entry of the output, and whether the output
+// is cached.  The entry is nil when the output is cached as missing.
+func (uc *utxoCache) get(outpoint types.TxOutPoint) (*UtxoEntry, bool) {
+	uc.lock.Lock()
+	defer uc.lock.Unlock()
+
+	if entry, ok := uc.dirty[outpoint]; ok {
+		if entry.IsSpent() {
+			return nil, true
+		}
+		cached := entry.Clone()
+		cached.packedFlags &^= tfModified
+		return cached, true
+	}
+	elem, ok := uc.entries[outpoint]
+	if !ok {
+		return nil, false
+	}
+	uc.lru.MoveToFront(elem)
+	return elem.Value.(*utxoCacheEntry).entry.Clone(), true
+}
+
+// put caches a copy of the entry of the output loaded from the database, or
+// records that the output is missing when the entry is nil.
+func (uc *utxoCache) put(outpoint types.TxOutPoint, entry *UtxoEntry) {
+	uc.lock.Lock()
+	defer uc.lock.Unlock()
+
+	// The database is behind the dirty outputs.
+	if _, ok := uc.dirty[outpoint]; ok {
+		return
+	}
+	uc.add(outpoint, entry)
+}
+
+// update applies the modifications of the utxo view of a block.  They are kept
+// dirty until the next flush when deferred, otherwise they were just committed
+// to the database.  The state is the block connected, nil for the disconnected
+// ones.
+func (uc *utxoCache) update(view *UtxoViewpoint, state *utxoState, deferred bool) {
+	uc.lock.Lock()
+	defer uc.lock.Unlock()
+
+	for outpoint, entry := range view.entries {
+		if entry == nil || !entry.isModified() {
+			continue
+		}
+		uc.removeDirty(outpoint)
+		if deferred {
+			if elem, ok := uc.entries[outpoint]; ok {
+				uc.removeElement(elem)
+			}
+			dirty := entry.Clone()
+			if dirty.IsSpent() {
+				dirty = &UtxoEntry{}
+				dirty.Spend()
+			}
+			uc.dirty[outpoint] = dirty
+			uc.size += (&utxoCacheEntry{entry: dirty}).size()
+			continue
+		}
+		if entry.IsSpent() {
+			uc.add(outpoint, nil)
+			continue
+		}
+		cached := entry.Clone()
+		cached.packedFlags &^= tfModified
+		uc.add(outpoint, cached)
+	}
+	if state != nil {
+		uc.state = *state
+		if !deferred && len(uc.dirty) == 0 {
+			uc.flushed = *state
+		}
+	}
+	uc.evict()
+}
+
+// deferWrites returns whether the outputs modified by the connected blocks are
+// kept in the cache instead of being written to the database.
+func (uc *utxoCache) deferWrites() bool {
+	uc.lock.Lock()
+	defer uc.lock.Unlock()
+
+	return uc.maxSize > 0
+}
+
+// needsFlush returns whether the dirty outputs exceed the budget or were kept
+// for utxoCacheFlushInterval.
+func (uc *utxoCache) needsFlush(now time.Time) bool {
+	uc.lock.Lock()
+	defer uc.lock.Unlock()
+
+	if len(uc.dirty) == 0 {
+		return false
+	}
+	return uc.size > uc.maxSize || now.Sub(uc.lastFlush) >= utxoCacheFlushInterval
+}
+
+// flush writes the dirty outputs and the state of the last block applied to
+// the database, then keeps the outputs in the cache as clean ones.
+func (uc *utxoCache) flush(db database.DB) error {
+	uc.lock.Lock()
+	defer uc.lock.Unlock()
+
+	if len(uc.dirty) == 0 && uc.state == uc.flushed {
+		return nil
+	}
+	view := NewUtxoViewpoint()
+	for outpoint, entry := range uc.dirty {
+		view.entries[outpoint] = entry
+	}
+	err := db.Update(func(dbTx database.Tx) error {
+		err := dbPutUtxoView(dbTx, view)
+		if err != nil {
+			return err
+		}
+		return dbPutUtxoState(dbTx, &uc.state)
+	})
+	if err != nil {
+		return err
+	}
+
+	for outpoint, entry := range uc.dirty {
+		uc.removeDirty(outpoint)
+		if entry.IsSpent() {
+			uc.add(outpoint, nil)
+			continue
+		}
+		entry.packedFlags &^= tfModified
+		uc.add(outpoint, entry)
+	}
+	uc.flushed = uc.state
+	uc.lastFlush = time.Now()
+	return nil
+}
+
+// setMaxSize sets the memory budget of the cache, zero disables it.  The cache
+// must be flushed before it is disabled.
+func (uc *utxoCache) setMaxSize(maxSize uint64) {
+	uc.lock.Lock()
+	defer uc.lock.Unlock()
+
+	uc.maxSize = maxSize
+	uc.evict()
+}
+
+// add caches the entry of the output in place of the previous one.
+//
+// This function MUST be called with the lock held.
+func (uc *utxoCache) add(outpoint types.TxOutPoint, entry *UtxoEntry) {
+	if elem, ok := uc.entries[outpoint]; ok {
+		uc.removeElement(elem)
+	}
+	if uc.maxSize == 0 {
+		return
+	}
+	ce := &utxoCacheEntry{outpoint: outpoint, entry: entry.Clone()}
+	if ce.size() > uc.maxSize {
+		return
+	}
+	uc.entries[outpoint] = uc.lru.PushFront(ce)
+	uc.size += ce.size()
+	uc.evict()
+}
+
+// evict removes the least recently used clean outputs until the cache fits the
+// budget, or none is left.
+func (uc *utxoCache) evict() {
+	for uc.size > uc.maxSize && uc.lru.Len() > 0 {
+		uc.removeElement(uc.lru.Back())
+	}
+}
+
+func (uc *utxoCache) removeElement(elem *list.Element) {
+	ce := uc.lru.Remove(elem).(*utxoCacheEntry)
+	delete(uc.entries, ce.outpoint)
+	uc.size -= ce.size()
+}
+
+func (uc *utxoCache) removeDirty(outpoint types.TxOutPoint) {
+	entry, ok := uc.dirty[outpoint]
+	if !ok {
+		return
+	}
+	delete(uc.dirty, outpoint)
+	uc.size -= (&utxoCacheEntry{entry: entry}).size()
+}
+
+// SetUtxoCacheSize sets the approximate memory in bytes used to cache the utxo
+// set.  Zero disables the cache once it is flushed.
+func (b *BlockChain) SetUtxoCacheSize(size uint64) error {
+	b.ChainLock()
+	defer b.ChainUnlock()
+
+	if size == 0 {
+		if err := b.utxoCache.flush(b.db); err != nil {
+			return err
+		}
+	}
+	b.utxoCache.setMaxSize(size)
+	return nil
+}
+
+// FlushUtxoCache writes the outputs modified since the last flush to the
+// database, which must be done before shutting down.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FlushUtxoCache() error {
+	b.ChainLock()
+	defer b.ChainUnlock()
+
+	return b.utxoCache.flush(b.db)
+}
+
+// maybeFlushUtxoCache flushes the utxo cache when forced or when it needs it.
+// It is called once the DAG state of the accepted block was committed, so the
+// stored utxo state never refers to an order the DAG did not store.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) maybeFlushUtxoCache(force bool) error {
+	if !force && !b.utxoCache.needsFlush(time.Now()) {
+		return nil
+	}
+	return b.utxoCache.flush(b.db)
+}
+
+// replayUtxoSet restores the outputs modified by the blocks ordered after the
+// utxo state stored in the database, which were lost by a crash before the
+// utxo cache was flushed, and flushes them.
+func (b *BlockChain) replayUtxoSet() error {
+	var state *utxoState
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		state, err = dbFetchUtxoState(dbTx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// blockByOrder returns the block at the order stored by the DAG, or nil
+	// after the last one.
+	blockByOrder := func(order uint) blockdag.IBlock {
+		var h *hash.Hash
+		b.db.View(func(dbTx database.Tx) error {
+			h = b.bd.GetBlockByOrderWithTx(dbTx, order)
+			return nil
+		})
+		if h == nil {
+			return nil
+		}
+		return b.bd.GetBlock(h)
+	}
+
+	var ib blockdag.IBlock
+	if state != nil {
+		ib = blockByOrder(state.order)
+	}
+	if ib == nil {
+		// The utxo set of a database written through before the state
+		// was stored is complete, and the one of a block connected
+		// after the last DAG commit is ahead of the DAG.
+		if state != nil {
+			log.Warn("The utxo set is ahead of the DAG", "order", state.order)
+		}
+		last := b.bd.GetMainChainTip()
+		for next := blockByOrder(last.GetOrder() + 1); next != nil; next = blockByOrder(last.GetOrder() + 1) {
+			last = next
+		}
+		b.utxoCache.state = utxoState{order: last.GetOrder(), hash: *last.GetHash()}
+		return b.utxoCache.flush(b.db)
+	}
+	if !ib.GetHash().IsEqual(&state.hash) {
+		return fmt.Errorf("the utxo set was written up to the block %s "+
+			"which is not at the order %d of the DAG anymore, you can "+
+			"cleanup your block data base by '--cleanup'", state.hash,
+			state.order)
+	}
+	b.utxoCache.state = *state
+	b.utxoCache.flushed = *state
+
+	replayed := 0
+	for ib = blockByOrder(state.order + 1); ib != nil; ib = blockByOrder(ib.GetOrder() + 1) {
+		if replayed == 0 {
+			log.Info("Replaying the blocks missing from the utxo set",
+				"from", ib.GetOrder())
+		}
+		if err := b.replayUtxoBlock(ib); err != nil {
+			return err
+		}
+		replayed++
+		if err := b.maybeFlushUtxoCache(false); err != nil {
+			return err
+		}
+	}
+	if replayed > 0 {
+		log.Info("Replayed the blocks missing from the utxo set", "blocks", replayed)
+	}
+	return b.utxoCache.flush(b.db)
+}
+
+// replayUtxoBlock applies the outputs spent and created by the block to the
+// utxo cache like connectBlock did.  The invalid blocks, and the ones which
+// failed the connection checks so their spend journal is missing, were
+// connected with an empty view.
+func (b *BlockChain) replayUtxoBlock(ib blockdag.IBlock) error {
+	view := NewUtxoViewpoint()
+	view.SetViewpoints([]*hash.Hash{ib.GetHash()})
+	state := &utxoState{order: ib.GetOrder(), hash: *ib.GetHash()}
+	if ib.GetStatus().KnownInvalid() {
+		b.utxoCache.update(view, state, true)
+		return nil
+	}
+
+	block, err := b.fetchBlockByHash(ib.GetHash())
+	if err != nil {
+		return err
+	}
+	block.SetOrder(uint64(ib.GetOrder()))
+	block.SetHeight(ib.GetHeight())
+	b.CalculateDAGDuplicateTxs(block)
+	stxos, err := b.fetchSpendJournal(block)
+	if err != nil {
+		return err
+	}
+	if len(stxos) != b.countSpentOutputs(block) {
+		b.utxoCache.update(view, state, true)
+		return nil
+	}
+
+	err = view.fetchInputUtxos(b.db, block, b)
+	if err != nil {
+		return err
+	}
+	node := b.GetBlockNode(ib)
+	if node == nil {
+		return fmt.Errorf("Block Node error:%s\n", ib.GetHash().String())
+	}
+	for idx, tx := range block.Transactions() {
+		if tx.IsDuplicate && !tx.Tx.IsCoinBase() {
+			continue
+		}
+		if types.IsTokenTx(tx.Tx) && !types.IsTokenMintTx(tx.Tx) {
+			continue
+		}
+		err := view.connectTransaction(tx, node, uint32(idx), nil, b)
+		if err != nil {
+			return err
+		}
+	}
+	b.utxoCache.update(view, state, true)
+	return nil
+}
@@ -0,0 +1,319 @@
+package blockchain
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/dbnamespace"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/core/types/pow"
+	"github.com/Qitmeer/qitmeer/database"
+	"github.com/Qitmeer/qitmeer/params"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func Test_UtxoCache(t *testing.T) {
+	cache := newUtxoCache(3 * utxoCacheEntrySize)
+	op := func(i uint32) types.TxOutPoint {
+		return types.TxOutPoint{Hash: hash.Hash{1}, OutIndex: i}
+	}
+
+	// Missing outputs are cached too.
+	cache.put(op(0), &UtxoEntry{amount: types.Amount{Value: 10}})
+	cache.put(op(1), nil)
+	if entry, ok := cache.get(op(0)); !ok || entry == nil || entry.Amount().Value != 10 {
+		t.Fatalf("unexpected cached entry %v %v", entry, ok)
+	}
+	if entry, ok := cache.get(op(1)); !ok || entry != nil {
+		t.Fatalf("output not cached as missing: %v %v", entry, ok)
+	}
+	if _, ok := cache.get(op(2)); ok {
+		t.Fatal("unknown output cached")
+	}
+
+	// The committed modifications of a view replace the cached entries.
+	view := NewUtxoViewpoint()
+	spent, _ := cache.get(op(0))
+	spent.Spend()
+	view.entries[op(0)] = spent
+	view.entries[op(1)] = &UtxoEntry{amount: types.Amount{Value: 20}, packedFlags: tfModified}
+	cache.update(view, nil, false)
+	if entry, ok := cache.get(op(0)); !ok || entry != nil {
+		t.Fatalf("spent output not cached as missing: %v %v", entry, ok)
+	}
+	entry, ok := cache.get(op(1))
+	if !ok || entry == nil || entry.Amount().Value != 20 || entry.isModified() {
+		t.Fatalf("unexpected updated entry %v %v", entry, ok)
+	}
+
+	// The least recently used outputs are evicted to fit the budget.
+	cache.put(op(2), nil)
+	cache.put(op(3), nil)
+	if _, ok := cache.get(op(0)); ok || len(cache.entries) != 3 {
+		t.Fatalf("%d outputs of %d bytes cached", len(cache.entries), cache.size)
+	}
+	cache.setMaxSize(0)
+	if cache.size != 0 || len(cache.entries) != 0 {
+		t.Fatal("outputs cached while disabled")
+	}
+}
+
+func Test_UtxoCacheWriteBack(t *testing.T) {
+	dir, err := ioutil.TempDir("", "utxocache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db := newVectorDB(t, dir)
+	defer db.Close()
+	err = db.Update(func(dbTx database.Tx) error {
+		_, err := dbTx.Metadata().CreateBucket(dbnamespace.UtxoSetBucketName)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetch := func(outpoint types.TxOutPoint) *UtxoEntry {
+		var entry *UtxoEntry
+		err := db.View(func(dbTx database.Tx) error {
+			var err error
+			entry, err = dbFetchUtxoEntry(dbTx, outpoint)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return entry
+	}
+
+	cache := newUtxoCache(2 * utxoCacheEntrySize)
+	op := func(i uint32) types.TxOutPoint {
+		return types.TxOutPoint{Hash: hash.Hash{1}, OutIndex: i}
+	}
+	view := NewUtxoViewpoint()
+	for i := uint32(0); i < 3; i++ {
+		view.entries[op(i)] = &UtxoEntry{amount: types.Amount{Value: 10},
+			packedFlags: tfModified}
+	}
+	state := &utxoState{order: 1, hash: hash.Hash{2}}
+	cache.update(view, state, true)
+
+	// The dirty outputs are not written nor evicted, even over the budget.
+	if fetch(op(0)) != nil {
+		t.Fatal("dirty output written before the flush")
+	}
+	if entry, ok := cache.get(op(2)); !ok || entry == nil || entry.isModified() {
+		t.Fatalf("unexpected dirty entry %v %v", entry, ok)
+	}
+	cache.put(op(2), nil)
+	if entry, _ := cache.get(op(2)); entry == nil || len(cache.dirty) != 3 {
+		t.Fatal("dirty output replaced by the database")
+	}
+	now := time.Now()
+	if !cache.needsFlush(now) {
+		t.Fatal("no flush needed over the budget")
+	}
+
+	err = cache.flush(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetch(op(0)) == nil || len(cache.dirty) != 0 || cache.size > cache.maxSize {
+		t.Fatalf("%d dirty outputs of %d bytes left after the flush",
+			len(cache.dirty), cache.size)
+	}
+	err = db.View(func(dbTx database.Tx) error {
+		stored, err := dbFetchUtxoState(dbTx)
+		if err == nil && (stored == nil || *stored != *state) {
+			t.Fatalf("stored utxo state %v, want %v", stored, state)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Spending an output deletes it at the next flush, which is needed
+	// once the interval elapsed.
+	view = NewUtxoViewpoint()
+	spent := &UtxoEntry{amount: types.Amount{Value: 10}}
+	spent.Spend()
+	view.entries[op(0)] = spent
+	cache.update(view, &utxoState{order: 2, hash: hash.Hash{3}}, true)
+	if entry, ok := cache.get(op(0)); !ok || entry != nil {
+		t.Fatalf("spent dirty output not missing: %v %v", entry, ok)
+	}
+	if cache.needsFlush(now) || !cache.needsFlush(time.Now().Add(utxoCacheFlushInterval)) {
+		t.Fatal("unexpected flush interval")
+	}
+	err = cache.flush(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetch(op(0)) != nil || fetch(op(1)) == nil {
+		t.Fatal("spent output not deleted by the flush")
+	}
+}
+
+// cacheTestBlock returns a block on the parent paying its subsidy to an
+// anyone-can-spend output, with the transactions after its coinbase.
+func cacheTestBlock(b *BlockChain, parent *hash.Hash, nonce uint64, txs ...*types.Transaction) *types.SerializedBlock {
+	parents := blockdag.NewIdSet()
+	parents.Add(b.bd.GetBlockId(parent))
+	subsidy := b.subsidyCache.CalcBlockSubsidy(int64(b.bd.GetBlues(parents)))
+	coinbase := types.NewTransaction()
+	coinbase.AddTxIn(&types.TxInput{
+		PreviousOut: *types.NewOutPoint(&hash.Hash{}, types.MaxPrevOutIndex),
+		Sequence:    types.MaxTxInSequenceNum,
+		SignScript:  []byte{0x00, 0x00},
+	})
+	coinbase.AddTxOut(types.NewTxOutput(types.Amount{Value: subsidy, Id: types.MEERID},
+		[]byte{0x51}))
+	// The hash of a transaction does not cover its signature scripts.
+	coinbase.AddTxOut(types.NewTxOutput(types.Amount{Id: types.MEERID},
+		[]byte{0x6a, 0x02, byte(nonce), byte(nonce >> 8)}))
+
+	block := &types.Block{Parents: []*hash.Hash{parent}}
+	block.AddTransaction(coinbase)
+	for _, tx := range txs {
+		block.AddTransaction(tx)
+	}
+	block.Header.Timestamp = b.params.GenesisBlock.Header.Timestamp.Add(
+		time.Duration(nonce) * time.Second)
+	block.Header.Pow = pow.GetInstance(pow.BLAKE2BD, nonce, []byte{})
+	return types.NewBlock(block)
+}
+
+// dumpUtxoSet returns the serialized utxo set of the database and the digest
+// of its statistics, whose serialized commitment depends on the updates.
+func dumpUtxoSet(t *testing.T, db database.DB) map[string]string {
+	dump := map[string]string{}
+	err := db.View(func(dbTx database.Tx) error {
+		cursor := dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName).Cursor()
+		for ok := cursor.First(); ok; ok = cursor.Next() {
+			dump[string(cursor.Key())] = string(cursor.Value())
+		}
+		stats, err := dbFetchUtxoStats(dbTx)
+		if err != nil {
+			return err
+		}
+		dump["stats"] = fmt.Sprintf("%d %d %v %x", stats.Count, stats.Size,
+			stats.Amounts, stats.muHash.Digest())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dump
+}
+
+func TestUtxoCacheReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "utxocache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	par := params.PrivNetParams
+	newChain := func(db database.DB, cacheSize uint64) *BlockChain {
+		b, err := New(&Config{
+			DB:            db,
+			ChainParams:   &par,
+			TimeSource:    NewMedianTime(),
+			DAGType:       "phantom",
+			UtxoCacheSize: cacheSize,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	// The reference chain writes the utxo set through.  Its blocks mature
+	// the coinbase of the first one, which the last one spends.
+	refDB := newVectorDB(t, filepath.Join(dir, "ref"))
+	defer refDB.Close()
+	ref := newChain(refDB, 0)
+	var blocks []*types.SerializedBlock
+	tip := par.GenesisHash
+	for nonce := uint64(1); nonce <= uint64(par.CoinbaseMaturity)+2; nonce++ {
+		var txs []*types.Transaction
+		if nonce == uint64(par.CoinbaseMaturity)+2 {
+			coinbase := blocks[0].Transactions()[0]
+			spend := types.NewTransaction()
+			spend.AddTxIn(types.NewTxInput(types.NewOutPoint(coinbase.Hash(), 0), nil))
+			spend.AddTxOut(types.NewTxOutput(coinbase.Tx.TxOut[0].Amount, []byte{0x51}))
+			txs = append(txs, spend)
+		}
+		block := cacheTestBlock(ref, tip, nonce, txs...)
+		if err := ref.FastAcceptBlock(block); err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, block)
+		tip = block.Hash()
+	}
+	for i, block := range blocks {
+		if ref.bd.GetBlock(block.Hash()).GetStatus().KnownInvalid() {
+			t.Fatalf("block %d is invalid", i)
+		}
+	}
+	spent := types.NewOutPoint(blocks[0].Transactions()[0].Hash(), 0)
+	if entry, err := ref.FetchUtxoEntry(*spent); err != nil || entry != nil {
+		t.Fatalf("spent output %v %v", entry, err)
+	}
+	want := dumpUtxoSet(t, refDB)
+
+	tests := []struct {
+		name  string
+		flush bool
+	}{
+		{name: "flushed", flush: true},
+		{name: "crashed"},
+	}
+	for _, test := range tests {
+		path := filepath.Join(dir, test.name)
+		db := newVectorDB(t, path)
+		b := newChain(db, 1<<20)
+		for _, block := range blocks {
+			if err := b.FastAcceptBlock(block); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// The outputs are read from the cache until it is flushed.
+		last := types.NewOutPoint(blocks[len(blocks)-1].Transactions()[0].Hash(), 0)
+		if entry, err := b.FetchUtxoEntry(*last); err != nil || entry == nil {
+			t.Fatalf("%s: cached output %v %v", test.name, entry, err)
+		}
+		if len(dumpUtxoSet(t, db)) != len(want)-len(blocks) {
+			t.Fatalf("%s: utxo set written before the flush", test.name)
+		}
+		if test.flush {
+			if err := b.FlushUtxoCache(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		db.Close()
+
+		// The blocks connected after the last flush are replayed.
+		db, err = database.Open("ffldb", filepath.Join(path, "blocks_ffldb"),
+			par.Net)
+		if err != nil {
+			t.Fatal(err)
+		}
+		newChain(db, 1<<20)
+		got := dumpUtxoSet(t, db)
+		db.Close()
+		if len(got) != len(want) {
+			t.Fatalf("%s: %d utxo records, want %d", test.name, len(got), len(want))
+		}
+		for key, value := range want {
+			if got[key] != value {
+				t.Fatalf("%s: utxo record %x mismatch", test.name, key)
+			}
+		}
+	}
+}
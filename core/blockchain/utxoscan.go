@@ -0,0 +1,113 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/dbnamespace"
+	"github.com/Qitmeer/qitmeer/core/serialization"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
+	"math"
+)
+
+// decodeOutpointKey decodes a key of the utxo set bucket into the outpoint.
+func decodeOutpointKey(key []byte) (types.TxOutPoint, error) {
+	var outpoint types.TxOutPoint
+	if len(key) <= hash.HashSize {
+		return outpoint, fmt.Errorf("utxo key %x is too short", key)
+	}
+	copy(outpoint.Hash[:], key[:hash.HashSize])
+	idx, size := serialization.DeserializeVLQ(key[hash.HashSize:])
+	if size != len(key)-hash.HashSize || idx > math.MaxUint32 {
+		return outpoint, fmt.Errorf("utxo key %x has an invalid index", key)
+	}
+	outpoint.OutIndex = uint32(idx)
+	return outpoint, nil
+}
+
+// utxoScanProgress returns the approximate fraction of the utxo set before the
+// outpoint.  The keys of the utxo set start with the transaction hash, which is
+// uniformly distributed, so the leading bytes of the hash tell how far the
+// scan is.
+func utxoScanProgress(outpoint *types.TxOutPoint) float64 {
+	return float64(binary.BigEndian.Uint64(outpoint.Hash[:8])) / (1 << 64)
+}
+
+// ScanUtxoSet calls fn with the unspent outputs of the utxo set, in the order
+// of the database, starting after the outpoint after when it is not nil.  At
+// most maxEntries outputs are read.  It returns the outpoint of the last output
+// read, from which a later scan resumes, or nil once the end of the set is
+// reached, along with the approximate fraction of the set scanned so far.  The
+// outputs of invalid blocks are skipped.
+//
+// This function is safe for concurrent access, however fn is called once the
+// outputs are read so it sees a consistent view of the utxo set.
+func (b *BlockChain) ScanUtxoSet(after *types.TxOutPoint, maxEntries int,
+	fn func(outpoint types.TxOutPoint, entry *UtxoEntry)) (*types.TxOutPoint, float64, error) {
+
+	type scannedUtxo struct {
+		outpoint types.TxOutPoint
+		entry    *UtxoEntry
+	}
+	var scanned []scannedUtxo
+	var last *types.TxOutPoint
+	b.ChainRLock()
+	// The outputs modified since the last flush of the utxo cache are
+	// written first.
+	if err := b.utxoCache.flush(b.db); err != nil {
+		b.ChainRUnlock()
+		return nil, 0, err
+	}
+	err := b.db.View(func(dbTx database.Tx) error {
+		cursor := dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName).Cursor()
+		var ok bool
+		if after == nil {
+			ok = cursor.First()
+		} else {
+			key := outpointKey(*after)
+			ok = cursor.Seek(*key)
+			// Skip the outpoint of the cursor itself, it was
+			// already scanned.
+			if ok && string(cursor.Key()) == string(*key) {
+				ok = cursor.Next()
+			}
+			recycleOutpointKey(key)
+		}
+		for ; ok && len(scanned) < maxEntries; ok = cursor.Next() {
+			outpoint, err := decodeOutpointKey(cursor.Key())
+			if err != nil {
+				return err
+			}
+			last = &outpoint
+			entry, err := DeserializeUtxoEntry(cursor.Value())
+			if err != nil {
+				return err
+			}
+			scanned = append(scanned, scannedUtxo{outpoint, entry})
+		}
+		if !ok {
+			last = nil
+		}
+		return nil
+	})
+	b.ChainRUnlock()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, utxo := range scanned {
+		if utxo.entry.IsSpent() || b.IsInvalidOut(utxo.entry) {
+			continue
+		}
+		fn(utxo.outpoint, utxo.entry)
+	}
+	if last == nil {
+		return nil, 1, nil
+	}
+	return last, utxoScanProgress(last), nil
+}
@@ -0,0 +1,302 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/dbnamespace"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/crypto/muhash"
+	"github.com/Qitmeer/qitmeer/database"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// utxoStatsInterval is the interval between two batches of the utxo
+	// stats scanner.
+	utxoStatsInterval = 100 * time.Millisecond
+
+	// utxoStatsBatch is the maximum number of outputs scanned in a batch,
+	// which bounds the time the database is locked by the scanner.
+	utxoStatsBatch = 10000
+)
+
+// utxoStats are the statistics of the utxo set stored in the database.  They
+// cover the keys of the utxo set up to Cursor, or the whole set once Done, and
+// are updated along with the utxo set for the keys they cover, so the scanner
+// fills them in the background while the blocks are connected.
+type utxoStats struct {
+	Done    bool            `json:"done"`
+	Cursor  []byte          `json:"cursor"`
+	Count   uint64          `json:"count"`
+	Size    uint64          `json:"size"`
+	Amounts types.AmountMap `json:"amounts"`
+	MuHash  []byte          `json:"muhash"`
+
+	muHash *muhash.MuHash
+}
+
+func newUtxoStats() *utxoStats {
+	return &utxoStats{
+		Amounts: types.AmountMap{},
+		muHash:  muhash.New(),
+	}
+}
+
+// covers returns whether the statistics cover the key of the utxo set.
+func (s *utxoStats) covers(key []byte) bool {
+	return s.Done || (s.Cursor != nil && string(key) <= string(s.Cursor))
+}
+
+// add adds the serialized entry of the key to the statistics.
+func (s *utxoStats) add(key, serialized []byte) error {
+	entry, err := DeserializeUtxoEntry(serialized)
+	if err != nil {
+		return err
+	}
+	s.Count++
+	s.Size += uint64(len(key) + len(serialized))
+	s.Amounts[entry.amount.Id] += entry.amount.Value
+	s.muHash.Add(append(key[:len(key):len(key)], serialized...))
+	return nil
+}
+
+// remove removes the serialized entry of the key from the statistics.
+func (s *utxoStats) remove(key, serialized []byte) error {
+	entry, err := DeserializeUtxoEntry(serialized)
+	if err != nil {
+		return err
+	}
+	s.Count--
+	s.Size -= uint64(len(key) + len(serialized))
+	s.Amounts[entry.amount.Id] -= entry.amount.Value
+	if s.Amounts[entry.amount.Id] == 0 {
+		delete(s.Amounts, entry.amount.Id)
+	}
+	s.muHash.Remove(append(key[:len(key):len(key)], serialized...))
+	return nil
+}
+
+// dbFetchUtxoStats returns the statistics of the utxo set, or nil when the
+// scanner has not started yet.
+func dbFetchUtxoStats(dbTx database.Tx) (*utxoStats, error) {
+	serialized := dbTx.Metadata().Get(dbnamespace.UtxoStatsKeyName)
+	if serialized == nil {
+		return nil, nil
+	}
+	s := newUtxoStats()
+	err := json.Unmarshal(serialized, s)
+	if err == nil {
+		s.muHash, err = muhash.Deserialize(s.MuHash)
+	}
+	if err != nil {
+		return nil, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt utxo stats: %v", err),
+		}
+	}
+	return s, nil
+}
+
+// dbPutUtxoStats stores the statistics of the utxo set.
+func dbPutUtxoStats(dbTx database.Tx, s *utxoStats) error {
+	s.MuHash = s.muHash.Serialize()
+	serialized, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return dbTx.Metadata().Put(dbnamespace.UtxoStatsKeyName, serialized)
+}
+
+// UtxoStats are the statistics of the utxo set at the main chain tip.  The
+// outputs of the invalid blocks are counted, the statistics are those of the
+// raw utxo set.
+type UtxoStats struct {
+	Hash  hash.Hash
+	Order uint
+
+	// Count is the number of unspent outputs, and Size the size of their
+	// keys and serialized entries in the database.
+	Count uint64
+	Size  uint64
+
+	// Amounts are the total amounts of the unspent outputs by coin.
+	Amounts types.AmountMap
+
+	// MuHash is the commitment to the utxo set, which is the same for the
+	// same set whatever the order the outputs were added in, so two nodes
+	// at the same tip have the same commitment.
+	MuHash hash.Hash
+
+	// Complete is whether the statistics cover the whole set, and
+	// Progress the approximate fraction of the set they cover while the
+	// scanner fills them.
+	Complete bool
+	Progress float64
+}
+
+// UtxoStats returns the statistics of the utxo set at the main chain tip, or
+// nil when the scanner has not started yet.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) UtxoStats() (*UtxoStats, error) {
+	b.ChainRLock()
+	defer b.ChainRUnlock()
+
+	// The statistics are updated when the utxo cache is flushed.
+	err := b.utxoCache.flush(b.db)
+	if err != nil {
+		return nil, err
+	}
+	var s *utxoStats
+	err = b.db.View(func(dbTx database.Tx) error {
+		var err error
+		s, err = dbFetchUtxoStats(dbTx)
+		return err
+	})
+	if err != nil || s == nil {
+		return nil, err
+	}
+	best := b.BestSnapshot()
+	stats := &UtxoStats{
+		Hash:     best.Hash,
+		Order:    best.GraphState.GetMainOrder(),
+		Count:    s.Count,
+		Size:     s.Size,
+		Amounts:  s.Amounts,
+		MuHash:   hash.Hash(s.muHash.Digest()),
+		Complete: s.Done,
+		Progress: 1,
+	}
+	if !s.Done {
+		stats.Progress = 0
+		if s.Cursor != nil {
+			outpoint, err := decodeOutpointKey(s.Cursor)
+			if err != nil {
+				return nil, err
+			}
+			stats.Progress = utxoScanProgress(&outpoint)
+		}
+	}
+	return stats, nil
+}
+
+// UtxoStatsScanner computes in the background the statistics of the utxo set
+// of a database created before they were maintained.  The set is scanned in
+// the order of its keys, batch after batch, and the outputs modified by the
+// blocks connected meanwhile are counted once the scan has passed them, so
+// the statistics are exact when the scan completes.
+type UtxoStatsScanner struct {
+	started  int32
+	shutdown int32
+
+	b *BlockChain
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// NewUtxoStatsScanner returns a new scanner of the utxo set.  Use Start to
+// begin scanning.
+func NewUtxoStatsScanner(b *BlockChain) *UtxoStatsScanner {
+	return &UtxoStatsScanner{
+		b:    b,
+		quit: make(chan struct{}),
+	}
+}
+
+// Start begins scanning where the last scan stopped, unless the statistics
+// are complete.
+func (s *UtxoStatsScanner) Start() {
+	if atomic.AddInt32(&s.started, 1) != 1 {
+		return
+	}
+	s.wg.Add(1)
+	go s.handler()
+}
+
+// Stop stops scanning and waits for the scanner to exit.
+func (s *UtxoStatsScanner) Stop() {
+	if atomic.AddInt32(&s.shutdown, 1) != 1 {
+		return
+	}
+	close(s.quit)
+	s.wg.Wait()
+}
+
+func (s *UtxoStatsScanner) handler() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(utxoStatsInterval)
+	defer ticker.Stop()
+	for {
+		done, err := s.scanBatch()
+		if err != nil {
+			log.Error(fmt.Sprintf("Failed to scan the utxo set: %v", err))
+			return
+		}
+		if done {
+			return
+		}
+		select {
+		case <-ticker.C:
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// scanBatch adds the next outputs of the utxo set to the statistics and
+// returns whether the statistics are complete.
+func (s *UtxoStatsScanner) scanBatch() (bool, error) {
+	var done bool
+	err := s.b.db.Update(func(dbTx database.Tx) error {
+		stats, err := dbFetchUtxoStats(dbTx)
+		if err != nil {
+			return err
+		}
+		if stats == nil {
+			log.Info("Computing the utxo set statistics")
+			stats = newUtxoStats()
+		}
+		if stats.Done {
+			done = true
+			return nil
+		}
+
+		cursor := dbTx.Metadata().Bucket(dbnamespace.UtxoSetBucketName).Cursor()
+		var ok bool
+		if stats.Cursor == nil {
+			ok = cursor.First()
+		} else {
+			ok = cursor.Seek(stats.Cursor)
+			// Skip the key of the cursor itself, it was already
+			// counted.
+			if ok && string(cursor.Key()) == string(stats.Cursor) {
+				ok = cursor.Next()
+			}
+		}
+		for n := 0; ok && n < utxoStatsBatch; ok = cursor.Next() {
+			key := cursor.Key()
+			if err := stats.add(key, cursor.Value()); err != nil {
+				return err
+			}
+			stats.Cursor = append([]byte(nil), key...)
+			n++
+		}
+		if !ok {
+			stats.Done = true
+			stats.Cursor = nil
+			done = true
+			log.Info("Computed the utxo set statistics", "outputs", stats.Count)
+		}
+		return dbPutUtxoStats(dbTx, stats)
+	})
+	return done, err
+}
@@ -0,0 +1,73 @@
+package blockchain
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"testing"
+)
+
+func Test_UtxoStats(t *testing.T) {
+	utxo := func(i uint32, value int64, id types.CoinID) ([]byte, []byte) {
+		key := outpointKey(types.TxOutPoint{Hash: hash.Hash{byte(i)}, OutIndex: i})
+		serialized, err := serializeUtxoEntry(&UtxoEntry{
+			amount:   types.Amount{Value: value, Id: id},
+			pkScript: []byte{0x51},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return *key, serialized
+	}
+	k1, v1 := utxo(1, 100, types.MEERID)
+	k2, v2 := utxo(2, 200, types.MEERID)
+	k3, v3 := utxo(3, 300, types.CoinID(1))
+
+	// The statistics only cover the keys up to the cursor until done.
+	stats := newUtxoStats()
+	if stats.covers(k1) {
+		t.Fatal("empty scan covers a key")
+	}
+	stats.Cursor = k2
+	if !stats.covers(k1) || !stats.covers(k2) || stats.covers(k3) {
+		t.Fatal("unexpected keys covered")
+	}
+	stats.Done = true
+	if !stats.covers(k3) {
+		t.Fatal("complete statistics miss a key")
+	}
+
+	// Removing an output gives the statistics of the set without it,
+	// whatever the order the outputs were added in.
+	empty := newUtxoStats().muHash.Digest()
+	for _, kv := range [][2][]byte{{k1, v1}, {k2, v2}, {k3, v3}} {
+		if err := stats.add(kv[0], kv[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	other := newUtxoStats()
+	for _, kv := range [][2][]byte{{k3, v3}, {k1, v1}} {
+		if err := other.add(kv[0], kv[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := stats.remove(k2, v2); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Count != 2 || stats.Size != other.Size ||
+		stats.Amounts[types.MEERID] != 100 || stats.Amounts[types.CoinID(1)] != 300 {
+		t.Fatalf("unexpected statistics %+v", stats)
+	}
+	if stats.muHash.Digest() != other.muHash.Digest() {
+		t.Fatal("commitments of the same set differ")
+	}
+	if err := stats.remove(k1, v1); err != nil {
+		t.Fatal(err)
+	}
+	if err := stats.remove(k3, v3); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Count != 0 || stats.Size != 0 || len(stats.Amounts) != 0 ||
+		stats.muHash.Digest() != empty {
+		t.Fatalf("unexpected statistics of the empty set %+v", stats)
+	}
+}
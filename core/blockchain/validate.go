@@ -519,6 +519,20 @@ func (b *BlockChain) checkBlockContext(block *types.SerializedBlock, mainParent
 	if err != nil {
 		return err
 	}
+
+	// The parents must form a valid parent set in the DAG.
+	err = b.checkBlockParents(block, prevBlock.GetHeight()+1, flags)
+	if err != nil {
+		return err
+	}
+
+	// The block must not reorder the blocks before the finality point.
+	if !b.bd.CheckFinality(block.Block().Parents) {
+		fp := b.bd.GetFinalityPoint()
+		str := fmt.Sprintf("block %s does not have the finality point %s "+
+			"in its past", block.Hash(), fp.GetHash())
+		return ruleError(ErrBeforeFinalityPoint, str)
+	}
 	header := &block.Block().Header
 	fastAdd := flags&BFFastAdd == BFFastAdd
 	if !fastAdd {
@@ -772,24 +786,16 @@ func (b *BlockChain) checkConnectBlock(ib blockdag.IBlock, block *types.Serializ
 		str := "the coinbase for the genesis block is not spendable"
 		return ruleError(ErrMissingTxOut, str)
 	}
-	// Don't run scripts if this node is before the latest known good
-	// checkpoint since the validity is verified via the checkpoints (all
-	// transactions are included in the merkle root hash and any changes
-	// will therefore be detected by the next checkpoint).  This is a huge
-	// optimization because running the scripts is the most time consuming
-	// portion of block handling.
-	checkpoint := b.LatestCheckpoint()
-	runScripts := !b.noVerify
-	if checkpoint != nil && uint64(ib.GetLayer()) <= checkpoint.Layer {
-		runScripts = false
+	runScripts, scriptFlags, err := b.scriptChecks(ib)
+	if err != nil {
+		return err
 	}
-	var scriptFlags txscript.ScriptFlags
-	var err error
-	if runScripts {
-		scriptFlags, err = b.consensusScriptVerifyFlags()
-		if err != nil {
-			return err
-		}
+	// The scripts of a candidate block which passed the contextual
+	// validation of CheckConnectBlockTemplate were verified already with
+	// the same flags.
+	if runScripts && b.prevalidated.verified(block, scriptFlags) {
+		log.Trace("Skipping scripts of prevalidated block", "hash", block.Hash())
+		runScripts = false
 	}
 
 	// At first, we must calculate the dag duplicate tx for block.
@@ -866,6 +872,26 @@ func (b *BlockChain) checkConnectBlock(ib blockdag.IBlock, block *types.Serializ
 // executing transaction scripts to enforce the consensus rules. This includes
 // any flags required as the result of any agendas that have passed and become
 // active.
+// scriptChecks returns whether the scripts of the block are run when it is
+// connected, and the flags they are verified with.
+func (b *BlockChain) scriptChecks(ib blockdag.IBlock) (bool, txscript.ScriptFlags, error) {
+	// Don't run scripts if this node is before the latest known good
+	// checkpoint since the validity is verified via the checkpoints (all
+	// transactions are included in the merkle root hash and any changes
+	// will therefore be detected by the next checkpoint).  This is a huge
+	// optimization because running the scripts is the most time consuming
+	// portion of block handling.
+	checkpoint := b.LatestCheckpoint()
+	if b.noVerify || (checkpoint != nil && uint64(ib.GetLayer()) <= checkpoint.Layer) {
+		return false, 0, nil
+	}
+	scriptFlags, err := b.consensusScriptVerifyFlags()
+	if err != nil {
+		return false, 0, err
+	}
+	return true, scriptFlags, nil
+}
+
 func (b *BlockChain) consensusScriptVerifyFlags() (txscript.ScriptFlags, error) {
 	//TODO, refactor the txvm flag, the flag should decided by node.parent
 	scriptFlags := txscript.ScriptBip16 |
@@ -1265,6 +1291,13 @@ func (b *BlockChain) CheckConnectBlockTemplate(block *types.SerializedBlock) err
 	if err != nil {
 		return err
 	}
+	runScripts, scriptFlags, err := b.scriptChecks(virBlock)
+	if err != nil {
+		return err
+	}
+	if runScripts {
+		b.prevalidated.add(CandidateHash(block), scriptFlags)
+	}
 	return nil
 }
 
@@ -5,10 +5,12 @@ import (
 	"encoding/hex"
 	"github.com/Qitmeer/qitmeer/common/hash"
 	"github.com/Qitmeer/qitmeer/core/address"
+	"github.com/Qitmeer/qitmeer/core/merkle"
 	"github.com/Qitmeer/qitmeer/core/types"
 	"github.com/Qitmeer/qitmeer/engine/txscript"
 	"github.com/Qitmeer/qitmeer/params"
 	"testing"
+	"time"
 )
 
 const QITID types.CoinID = 1
@@ -158,3 +160,77 @@ func createTx() *types.Transaction {
 	tx.AddTxOut(&types.TxOutput{Amount: types.Amount{Value: 1 * 1e8, Id: QITID}, PkScript: tokenChangeScript})
 	return tx
 }
+
+func Test_IsFinalizedTransaction(t *testing.T) {
+	blockTime := time.Unix(1600000000, 0)
+	tests := []struct {
+		lockTime uint32
+		sequence uint32
+		final    bool
+	}{
+		{0, 0, true},
+		{99, 0, true},
+		{100, 0, false},
+		{100, types.MaxTxInSequenceNum, true},
+		{1599999999, 0, true},
+		{1600000000, 0, false},
+		{1600000000, types.MaxTxInSequenceNum, true},
+	}
+	for i, test := range tests {
+		tx := types.NewTransaction()
+		tx.LockTime = test.lockTime
+		tx.AddTxIn(&types.TxInput{
+			PreviousOut: *types.NewOutPoint(&hash.Hash{1}, 0),
+			Sequence:    test.sequence,
+		})
+		final := IsFinalizedTransaction(types.NewTx(tx), 100, blockTime)
+		if final != test.final {
+			t.Errorf("test %d: lock time %d, want final %v, got %v", i,
+				test.lockTime, test.final, final)
+		}
+	}
+}
+
+func Test_VerifyHeader(t *testing.T) {
+	p := &params.PrivNetParams
+	genesis := &p.GenesisBlock.Header
+	if err := VerifyHeader(genesis, nil, nil, p); err != nil {
+		t.Fatalf("genesis header: %v", err)
+	}
+
+	genesisHash := genesis.BlockHash()
+	withParents := func(parents ...*hash.Hash) *types.BlockHeader {
+		paMerkles := merkle.BuildParentsMerkleTreeStore(parents)
+		return &types.BlockHeader{
+			Version:    1,
+			ParentRoot: *paMerkles[len(paMerkles)-1],
+			Timestamp:  genesis.Timestamp.Add(time.Minute),
+			Difficulty: genesis.Difficulty,
+			Pow:        genesis.Pow,
+		}
+	}
+	badRoot := withParents(&genesisHash)
+	badRoot.ParentRoot = hash.Hash{1}
+
+	tests := []struct {
+		name    string
+		header  *types.BlockHeader
+		parents []*types.BlockHeader
+		ctx     *HeaderContext
+		code    ErrorCode
+	}{
+		{"no parents", withParents(&genesisHash), nil, nil, ErrNoParents},
+		{"bad parents root", badRoot, []*types.BlockHeader{genesis}, nil, ErrBadParentsMerkleRoot},
+		{"duplicate parents", withParents(&genesisHash, &genesisHash),
+			[]*types.BlockHeader{genesis, genesis}, nil, ErrDuplicateParent},
+		{"old version", withParents(&genesisHash), []*types.BlockHeader{genesis},
+			&HeaderContext{MinVersion: 2}, ErrBlockVersionTooOld},
+	}
+	for _, test := range tests {
+		err := VerifyHeader(test.header, test.parents, test.ctx, p)
+		rerr, ok := err.(RuleError)
+		if !ok || rerr.ErrorCode != test.code {
+			t.Errorf("%s: want %v, got %v", test.name, test.code, err)
+		}
+	}
+}
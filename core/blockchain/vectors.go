@@ -0,0 +1,224 @@
+// Copyright (c) 2017-2018 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/database"
+	"github.com/Qitmeer/qitmeer/params"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// VectorFileExt is the file extension of consensus vector files.
+const VectorFileExt = ".json"
+
+// ConsensusVector is a machine-readable consensus test vector.  The input is
+// a DAG topology described by block tags, the output is the order, the blue
+// set, the main chain tip and the proof of work reward of each block as
+// calculated by this implementation.
+//
+// Block hashes are derived from the tags with hash.HashH so that alternative
+// implementations can rebuild the exact same DAG.  The weight of a block is
+// the block subsidy for its number of blue ancestors, like on the real chain.
+type ConsensusVector struct {
+	Name     string        `json:"name"`
+	Network  string        `json:"network"`
+	DAGType  string        `json:"dagtype"`
+	Blocks   []VectorBlock `json:"blocks"`
+	Expected *VectorResult `json:"expected,omitempty"`
+}
+
+// VectorBlock is one block of the topology of a consensus vector.  The first
+// block is the genesis and must not have parents.
+type VectorBlock struct {
+	Tag     string   `json:"tag"`
+	Parents []string `json:"parents"`
+}
+
+// VectorResult is the expected output of a consensus vector.
+type VectorResult struct {
+	Order        []string          `json:"order"`
+	Blues        []string          `json:"blues"`
+	MainChainTip string            `json:"mainchaintip"`
+	Rewards      map[string]uint64 `json:"rewards"`
+}
+
+// VectorBlockHash returns the block hash used for the given vector tag.
+func VectorBlockHash(tag string) hash.Hash {
+	return hash.HashH([]byte(tag))
+}
+
+// ComputeVector replays the topology of the vector into a fresh block DAG
+// backed by the passed empty database and returns the consensus result.
+func ComputeVector(db database.DB, v *ConsensusVector, par *params.Params) (*VectorResult, error) {
+	if len(v.Blocks) == 0 {
+		return nil, fmt.Errorf("vector %s has no blocks", v.Name)
+	}
+	if len(v.Blocks[0].Parents) != 0 {
+		return nil, fmt.Errorf("vector %s: genesis %s must not have parents",
+			v.Name, v.Blocks[0].Tag)
+	}
+	if blockdag.NewBlockDAG(v.DAGType) == nil {
+		return nil, fmt.Errorf("vector %s: unknown dag type %s", v.Name, v.DAGType)
+	}
+	subsidyCache := NewSubsidyCache(0, par)
+	datas := make(map[hash.Hash]*fixtureBlockData, len(v.Blocks))
+	tags := make(map[hash.Hash]string, len(v.Blocks))
+
+	bd := &blockdag.BlockDAG{}
+	calcWeight := func(blocks int64, h *hash.Hash, status blockdag.BlockStatus) int64 {
+		if status.KnownInvalid() {
+			return 0
+		}
+		return subsidyCache.CalcBlockSubsidy(blocks)
+	}
+	getBlockData := func(h *hash.Hash) blockdag.IBlockData {
+		if data, ok := datas[*h]; ok {
+			return data
+		}
+		return nil
+	}
+	setAnticoneSize(bd, par)
+	if bd.Init(v.DAGType, calcWeight, 1.0/float64(par.TargetTimePerBlock/time.Second),
+		db, getBlockData) == nil {
+		return nil, fmt.Errorf("vector %s: failed to initialize the DAG", v.Name)
+	}
+
+	result := &VectorResult{Rewards: make(map[string]uint64, len(v.Blocks))}
+	genesisTime := par.GenesisBlock.Header.Timestamp.Unix()
+	for i, vb := range v.Blocks {
+		h := VectorBlockHash(vb.Tag)
+		if _, exists := tags[h]; exists {
+			return nil, fmt.Errorf("vector %s: duplicate block %s", v.Name, vb.Tag)
+		}
+		data := &fixtureBlockData{
+			hash:      h,
+			timestamp: genesisTime + int64(i)*int64(par.TargetTimePerBlock/time.Second),
+		}
+		for _, p := range vb.Parents {
+			ph := VectorBlockHash(p)
+			if _, ok := tags[ph]; !ok {
+				return nil, fmt.Errorf("vector %s: block %s has unknown parent %s",
+					v.Name, vb.Tag, p)
+			}
+			data.parents = append(data.parents, &ph)
+		}
+		blues := int64(0)
+		if len(data.parents) > 0 {
+			blues = int64(bd.GetBlues(bd.GetIdSet(data.parents)))
+		}
+		datas[h] = data
+		tags[h] = vb.Tag
+
+		orderChange, _, _ := bd.AddBlock(data)
+		if orderChange.IsEmpty() {
+			return nil, fmt.Errorf("vector %s: block %s was rejected by the DAG",
+				v.Name, vb.Tag)
+		}
+		err := bd.Commit()
+		if err != nil {
+			return nil, err
+		}
+		result.Rewards[vb.Tag] = CalcBlockWorkSubsidy(subsidyCache, blues, par)
+	}
+
+	for order := uint(0); order < bd.GetBlockTotal(); order++ {
+		h := bd.GetBlockHashByOrder(order)
+		if h == nil {
+			break
+		}
+		result.Order = append(result.Order, tags[*h])
+		if bd.IsBlue(bd.GetBlockId(h)) {
+			result.Blues = append(result.Blues, tags[*h])
+		}
+	}
+	sort.Strings(result.Blues)
+	tip := bd.GetMainChainTip()
+	if tip != nil {
+		result.MainChainTip = tags[*tip.GetHash()]
+	}
+	return result, nil
+}
+
+// CheckVector compares the expected result of the vector with the result
+// calculated by this implementation.
+func CheckVector(db database.DB, v *ConsensusVector, par *params.Params) error {
+	if v.Expected == nil {
+		return fmt.Errorf("vector %s has no expected result", v.Name)
+	}
+	result, err := ComputeVector(db, v, par)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(result.Order, v.Expected.Order) {
+		return fmt.Errorf("vector %s: order mismatch, expected %v, got %v",
+			v.Name, v.Expected.Order, result.Order)
+	}
+	if !reflect.DeepEqual(result.Blues, v.Expected.Blues) {
+		return fmt.Errorf("vector %s: blue set mismatch, expected %v, got %v",
+			v.Name, v.Expected.Blues, result.Blues)
+	}
+	if result.MainChainTip != v.Expected.MainChainTip {
+		return fmt.Errorf("vector %s: main chain tip mismatch, expected %s, got %s",
+			v.Name, v.Expected.MainChainTip, result.MainChainTip)
+	}
+	if !reflect.DeepEqual(result.Rewards, v.Expected.Rewards) {
+		return fmt.Errorf("vector %s: rewards mismatch, expected %v, got %v",
+			v.Name, v.Expected.Rewards, result.Rewards)
+	}
+	return nil
+}
+
+// LoadVectors reads all of the consensus vectors in the given directory in
+// file name order.
+func LoadVectors(dir string) ([]*ConsensusVector, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var vectors []*ConsensusVector
+	for _, fi := range files {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), VectorFileExt) {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, fi.Name()))
+		if err != nil {
+			return nil, err
+		}
+		v := &ConsensusVector{}
+		err = json.Unmarshal(data, v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode vector %s: %v", fi.Name(), err)
+		}
+		if len(v.Name) == 0 {
+			v.Name = strings.TrimSuffix(fi.Name(), VectorFileExt)
+		}
+		vectors = append(vectors, v)
+	}
+	return vectors, nil
+}
+
+// WriteVector saves the consensus vector into the given directory using its
+// name as the file name.
+func WriteVector(dir string, v *ConsensusVector) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, v.Name+VectorFileExt), data, 0644)
+}
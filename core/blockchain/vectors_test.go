@@ -0,0 +1,127 @@
+package blockchain
+
+import (
+	"flag"
+	"github.com/Qitmeer/qitmeer/database"
+	_ "github.com/Qitmeer/qitmeer/database/ffldb"
+	"github.com/Qitmeer/qitmeer/params"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// generate recalculates the expected results of the golden vectors instead of
+// checking them, after a deliberate consensus change:
+//
+//	go test ./core/blockchain -run TestGoldenVectors -generate
+var generate = flag.Bool("generate", false, "write the expected results of the golden vectors in testdata/vectors")
+
+func newVectorDB(t *testing.T, dir string) database.DB {
+	db, err := database.Create("ffldb", filepath.Join(dir, "blocks_ffldb"), params.PrivNetParams.Net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestConsensusVector(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vectors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	v := &ConsensusVector{
+		Name:    "diamond",
+		Network: params.PrivNetParams.Name,
+		DAGType: "phantom",
+		Blocks: []VectorBlock{
+			{Tag: "G"},
+			{Tag: "A", Parents: []string{"G"}},
+			{Tag: "B", Parents: []string{"G"}},
+			{Tag: "C", Parents: []string{"A", "B"}},
+		},
+	}
+	db := newVectorDB(t, filepath.Join(dir, "gen"))
+	result, err := ComputeVector(db, v, &params.PrivNetParams)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Order) != len(v.Blocks) || result.Order[0] != "G" {
+		t.Fatalf("unexpected order %v", result.Order)
+	}
+	if result.MainChainTip != "C" {
+		t.Fatalf("unexpected main chain tip %s", result.MainChainTip)
+	}
+	if result.Rewards["G"] != 0 {
+		t.Fatalf("genesis must not have a reward, got %d", result.Rewards["G"])
+	}
+
+	v.Expected = result
+	err = WriteVector(filepath.Join(dir, "vectors"), v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vectors, err := LoadVectors(filepath.Join(dir, "vectors"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) != 1 {
+		t.Fatalf("expected 1 vector, got %d", len(vectors))
+	}
+	db = newVectorDB(t, filepath.Join(dir, "check"))
+	defer db.Close()
+	err = CheckVector(db, vectors[0], &params.PrivNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// vectorParams returns the parameters of the network a vector is meant for.
+func vectorParams(t *testing.T, v *ConsensusVector) *params.Params {
+	for _, par := range []*params.Params{&params.MainNetParams,
+		&params.TestNetParams, &params.PrivNetParams, &params.MixNetParams} {
+		if par.Name == v.Network {
+			return par
+		}
+	}
+	t.Fatalf("vector %s: unknown network %s", v.Name, v.Network)
+	return nil
+}
+
+// TestGoldenVectors checks the order, blue set, main chain tip and rewards
+// against the golden vectors committed under testdata/vectors.
+func TestGoldenVectors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vectors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	vectorsDir := filepath.Join("testdata", "vectors")
+	vectors, err := LoadVectors(vectorsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) == 0 {
+		t.Fatalf("no vectors in %s", vectorsDir)
+	}
+	for _, v := range vectors {
+		par := vectorParams(t, v)
+		db := newVectorDB(t, filepath.Join(dir, v.Name))
+		if *generate {
+			v.Expected, err = ComputeVector(db, v, par)
+			if err == nil {
+				err = WriteVector(vectorsDir, v)
+			}
+		} else {
+			err = CheckVector(db, v, par)
+		}
+		db.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/merkle"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/params"
+	"time"
+)
+
+// HeaderContext is the context of a block header which can not be derived
+// from the header and its parents alone, since it depends on the DAG.  The
+// verifiers embedding VerifyHeader get it from their own view of the DAG, such
+// as the headers of the main chain.  The checks of the zero fields are
+// skipped.
+type HeaderContext struct {
+	// MainHeight is the main height of the block, which selects the
+	// parameters of the proof of work.
+	MainHeight uint
+
+	// MinVersion is the minimum version of the block.
+	MinVersion uint32
+
+	// ExpectedDifficulty is the difficulty required by the retarget rules
+	// for the block.
+	ExpectedDifficulty uint32
+
+	// PastMedianTime is the median time of the blocks before the block on
+	// the main chain, which the timestamp of the block must be after.
+	PastMedianTime time.Time
+
+	// TimeSource is the clock the timestamp of the block must not be too
+	// far ahead of.  The local clock is used when it is nil.
+	TimeSource MedianTimeSource
+}
+
+// VerifyHeader checks the block header against its parents, given in the
+// order of the parents of the block, and the context, without a database nor
+// a BlockChain, so that bridges and light verifiers can validate the headers
+// of qitmeer as a library.  It checks the parents root, the proof of work,
+// the timestamp, and the version and difficulty of the context.
+//
+// The genesis block of the network, which has no parents, is valid by
+// definition.
+func VerifyHeader(header *types.BlockHeader, parents []*types.BlockHeader, ctx *HeaderContext, chainParams *params.Params) error {
+	if ctx == nil {
+		ctx = &HeaderContext{}
+	}
+	if len(parents) == 0 {
+		if blockHash := header.BlockHash(); blockHash.IsEqual(chainParams.GenesisHash) {
+			return nil
+		}
+		return ruleError(ErrNoParents, "block does not contain "+
+			"any parent")
+	}
+	if len(parents) > types.MaxParentsPerBlock {
+		str := fmt.Sprintf("block contains too many parents - "+
+			"got %d, max %d", len(parents), types.MaxParentsPerBlock)
+		return ruleError(ErrTooManyParents, str)
+	}
+
+	parentHashes := make([]*hash.Hash, 0, len(parents))
+	for _, parent := range parents {
+		h := parent.BlockHash()
+		parentHashes = append(parentHashes, &h)
+	}
+	paMerkles := merkle.BuildParentsMerkleTreeStore(parentHashes)
+	paMerkleRoot := paMerkles[len(paMerkles)-1]
+	if !header.ParentRoot.IsEqual(paMerkleRoot) {
+		str := fmt.Sprintf("block parents merkle root is invalid - block "+
+			"header indicates %v, but calculated value is %v",
+			&header.ParentRoot, paMerkleRoot)
+		return ruleError(ErrBadParentsMerkleRoot, str)
+	}
+	parentsSet := blockdag.NewHashSet()
+	parentsSet.AddList(parentHashes)
+	if len(parentHashes) != parentsSet.Size() {
+		str := fmt.Sprintf("parents:%v", parentHashes)
+		return ruleError(ErrDuplicateParent, str)
+	}
+
+	if header.Version < ctx.MinVersion {
+		str := fmt.Sprintf("block version of %d is older than the "+
+			"minimum version %d", header.Version, ctx.MinVersion)
+		return ruleError(ErrBlockVersionTooOld, str)
+	}
+
+	// The pow type, the proof of work, the precision of the timestamp and
+	// its offset in the future are checked the same way as for the blocks
+	// processed by the chain.
+	timeSource := ctx.TimeSource
+	if timeSource == nil {
+		timeSource = NewMedianTime()
+	}
+	err := checkBlockHeaderSanity(header, timeSource, BFNone, chainParams, ctx.MainHeight)
+	if err != nil {
+		return err
+	}
+
+	if ctx.ExpectedDifficulty != 0 && header.Difficulty != ctx.ExpectedDifficulty {
+		str := fmt.Sprintf("block difficulty of %d is not the"+
+			" expected value of %d", header.Difficulty,
+			ctx.ExpectedDifficulty)
+		return ruleError(ErrUnexpectedDifficulty, str)
+	}
+	if !ctx.PastMedianTime.IsZero() && !header.Timestamp.After(ctx.PastMedianTime) {
+		str := "block timestamp of %v is not after expected %v"
+		str = fmt.Sprintf(str, header.Timestamp.Unix(), ctx.PastMedianTime.Unix())
+		return ruleError(ErrTimeTooOld, str)
+	}
+	return nil
+}
@@ -1,7 +1,8 @@
-package anticone
+package anticone_test
 
 import (
 	"fmt"
+	"github.com/Qitmeer/qitmeer/core/blockdag/anticone"
 	"github.com/Qitmeer/qitmeer/params"
 	"log"
 	"testing"
@@ -29,7 +30,7 @@ func TestAntiCone(t *testing.T) {
 	index := 0
 	for i := 5; i < 100; i += 5 {
 		rate := 1.0 / float64(i)
-		if GetSize(BlockDelay, rate, SecurityLevel) != result[index] {
+		if anticone.GetSize(anticone.BlockDelay, rate, anticone.SecurityLevel) != result[index] {
 			t.Fatal()
 		}
 		index++
@@ -38,14 +39,14 @@ func TestAntiCone(t *testing.T) {
 
 func TestShowParamsAntiCone(t *testing.T) {
 	rate := 1.0 / float64(params.TestNetParams.TargetTimePerBlock/time.Second)
-	fmt.Printf("testnet:%d\n", GetSize(BlockDelay, rate, SecurityLevel))
+	fmt.Printf("testnet:%d\n", anticone.GetSize(anticone.BlockDelay, rate, anticone.SecurityLevel))
 
 	rate = 1.0 / float64(params.MainNetParams.TargetTimePerBlock/time.Second)
-	fmt.Printf("mainnet:%d\n", GetSize(BlockDelay, rate, SecurityLevel))
+	fmt.Printf("mainnet:%d\n", anticone.GetSize(anticone.BlockDelay, rate, anticone.SecurityLevel))
 
 	rate = 1.0 / float64(params.MixNetParams.TargetTimePerBlock/time.Second)
-	fmt.Printf("mixnet:%d\n", GetSize(BlockDelay, rate, SecurityLevel))
+	fmt.Printf("mixnet:%d\n", anticone.GetSize(anticone.BlockDelay, rate, anticone.SecurityLevel))
 
 	rate = 1.0 / float64(params.PrivNetParams.TargetTimePerBlock/time.Second)
-	fmt.Printf("privnet:%d\n", GetSize(BlockDelay, rate, SecurityLevel))
+	fmt.Printf("privnet:%d\n", anticone.GetSize(anticone.BlockDelay, rate, anticone.SecurityLevel))
 }
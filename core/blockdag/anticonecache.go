@@ -0,0 +1,170 @@
+package blockdag
+
+import (
+	"container/list"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"sync"
+)
+
+const (
+	// DefaultAnticoneCacheSize is the default memory budget in bytes of the
+	// anticone cache.
+	DefaultAnticoneCacheSize = 16 * 1024 * 1024
+
+	// anticoneEntrySize is the approximate memory used by a cached anticone
+	// besides its blocks.
+	anticoneEntrySize = 256
+
+	// anticoneBlockSize is the approximate memory used by a block of a
+	// cached anticone.
+	anticoneBlockSize = 64
+)
+
+// anticoneEntry is the anticone of a block in the anticone cache.
+type anticoneEntry struct {
+	block    IBlock
+	anticone *IdSet
+}
+
+func (e *anticoneEntry) size() uint64 {
+	return anticoneEntrySize + uint64(e.anticone.Size())*anticoneBlockSize
+}
+
+// anticoneCache is a least recently used cache of the anticones of the blocks
+// bounded by an approximate memory budget.  The anticone of a block only grows
+// as blocks are added to the DAG, so an entry is updated in place when a block
+// is added instead of being recomputed: it is left as it is when the new block
+// is a descendant of the block, and the new block is added to it otherwise.
+type anticoneCache struct {
+	lock    sync.Mutex
+	maxSize uint64
+	size    uint64
+	entries map[hash.Hash]*list.Element
+	lru     *list.List
+}
+
+func newAnticoneCache(maxSize uint64) *anticoneCache {
+	return &anticoneCache{
+		maxSize: maxSize,
+		entries: map[hash.Hash]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+// get returns a copy of the cached anticone of the block, or nil.
+func (ac *anticoneCache) get(ib IBlock) *IdSet {
+	ac.lock.Lock()
+	defer ac.lock.Unlock()
+
+	elem, ok := ac.entries[*ib.GetHash()]
+	if !ok {
+		return nil
+	}
+	ac.lru.MoveToFront(elem)
+	return elem.Value.(*anticoneEntry).anticone.Clone()
+}
+
+// put caches a copy of the anticone of the block.
+func (ac *anticoneCache) put(ib IBlock, anticone *IdSet) {
+	ac.lock.Lock()
+	defer ac.lock.Unlock()
+
+	if ac.maxSize == 0 {
+		return
+	}
+	if elem, ok := ac.entries[*ib.GetHash()]; ok {
+		ac.removeElement(elem)
+	}
+	entry := &anticoneEntry{block: ib, anticone: anticone.Clone()}
+	if entry.size() > ac.maxSize {
+		return
+	}
+	ac.entries[*ib.GetHash()] = ac.lru.PushFront(entry)
+	ac.size += entry.size()
+	ac.evict()
+}
+
+// addBlock adds the new block to the cached anticones of the blocks which are
+// not in its past.  A block is in the past of the new block when it is one of
+// its parents, or when one of its parents is in its future.  The blocks out of
+// the anticone of a block are in its past when they are on a lower layer, and
+// in its future otherwise.
+func (ac *anticoneCache) addBlock(ib IBlock) {
+	if !ib.HasParents() {
+		return
+	}
+	ac.lock.Lock()
+	defer ac.lock.Unlock()
+
+	for elem := ac.lru.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*anticoneEntry)
+		isFuture := false
+		for id, v := range ib.GetParents().GetMap() {
+			parent := v.(IBlock)
+			if id == entry.block.GetID() ||
+				(!entry.anticone.Has(id) && parent.GetLayer() > entry.block.GetLayer()) {
+				isFuture = true
+				break
+			}
+		}
+		if !isFuture {
+			entry.anticone.AddPair(ib.GetID(), ib)
+			ac.size += anticoneBlockSize
+		}
+	}
+	ac.evict()
+}
+
+// removeBlock removes the block, which is rolled back, from the cache.
+func (ac *anticoneCache) removeBlock(ib IBlock) {
+	ac.lock.Lock()
+	defer ac.lock.Unlock()
+
+	if elem, ok := ac.entries[*ib.GetHash()]; ok {
+		ac.removeElement(elem)
+	}
+	for elem := ac.lru.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*anticoneEntry)
+		if entry.anticone.Has(ib.GetID()) {
+			entry.anticone.Remove(ib.GetID())
+			ac.size -= anticoneBlockSize
+		}
+	}
+}
+
+// clean removes all the entries.
+func (ac *anticoneCache) clean() {
+	ac.lock.Lock()
+	defer ac.lock.Unlock()
+
+	ac.entries = map[hash.Hash]*list.Element{}
+	ac.lru.Init()
+	ac.size = 0
+}
+
+// setMaxSize sets the memory budget of the cache, zero disables it.
+func (ac *anticoneCache) setMaxSize(maxSize uint64) {
+	ac.lock.Lock()
+	defer ac.lock.Unlock()
+
+	ac.maxSize = maxSize
+	ac.evict()
+}
+
+func (ac *anticoneCache) evict() {
+	for ac.size > ac.maxSize {
+		ac.removeElement(ac.lru.Back())
+	}
+}
+
+func (ac *anticoneCache) removeElement(elem *list.Element) {
+	entry := ac.lru.Remove(elem).(*anticoneEntry)
+	delete(ac.entries, *entry.block.GetHash())
+	ac.size -= entry.size()
+}
+
+// SetAnticoneCacheSize sets the approximate memory in bytes used to cache the
+// anticones of the blocks.  Zero disables the cache.
+func (bd *BlockDAG) SetAnticoneCacheSize(size uint64) {
+	bd.anticoneCache.setMaxSize(size)
+}
@@ -0,0 +1,62 @@
+package blockdag
+
+import (
+	"testing"
+)
+
+func Test_AnticoneCache(t *testing.T) {
+	ibd := InitBlockDAG(phantom, "PH_fig2-blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	cache := bd.anticoneCache
+	for _, ib := range tbMap {
+		bd.getAnticone(ib, nil)
+	}
+	if len(cache.entries) != len(tbMap) {
+		t.Fatalf("%d anticones cached, expected %d", len(cache.entries), len(tbMap))
+	}
+
+	// The cached anticones are kept up to date as blocks are added on top
+	// of various tips.
+	for i := 0; i < 20; i++ {
+		tips := bd.GetValidTips()
+		parents := tips[:1+i%len(tips)]
+		oc, _, _ := bd.AddBlock(buildBlock(parents))
+		if oc.IsEmpty() {
+			t.Fatal("block not added")
+		}
+		if err := bd.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cached := map[string]*IdSet{}
+	for tag, ib := range tbMap {
+		cached[tag] = cache.get(ib)
+		if cached[tag] == nil {
+			t.Fatalf("anticone of %s not cached", tag)
+		}
+	}
+	cache.clean()
+	for tag, ib := range tbMap {
+		expected := bd.getAnticone(ib, nil)
+		if !cached[tag].IsEqual(expected) {
+			t.Fatalf("cached anticone of %s is %v, expected %v", tag,
+				cached[tag].SortList(false), expected.SortList(false))
+		}
+	}
+
+	// The least recently used anticones are evicted to fit the budget.
+	cache.clean()
+	bd.SetAnticoneCacheSize(anticoneEntrySize)
+	for _, ib := range tbMap {
+		bd.getAnticone(ib, nil)
+	}
+	if cache.size > anticoneEntrySize || len(cache.entries) > 1 {
+		t.Fatalf("%d anticones of %d bytes cached", len(cache.entries), cache.size)
+	}
+	bd.SetAnticoneCacheSize(0)
+	if cache.size != 0 || len(cache.entries) != 0 {
+		t.Fatal("anticones cached while disabled")
+	}
+}
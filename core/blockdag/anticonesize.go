@@ -0,0 +1,49 @@
+package blockdag
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AnticoneSizeChange is a scheduled change of the anticone size by a protocol
+// upgrade.  The blocks from the main height on color their diff anticones with
+// the new size.
+type AnticoneSizeChange struct {
+	MainHeight uint
+	Size       int
+}
+
+// SetAnticoneSize overrides the anticone size computed from the block rate when
+// size is positive, and schedules the changes of the anticone size.  The size
+// is part of the consensus saved with the DAG, so this function MUST be called
+// before Init.
+func (bd *BlockDAG) SetAnticoneSize(size int, changes []AnticoneSizeChange) {
+	bd.anticoneSize = size
+	bd.anticoneSizeChanges = make([]AnticoneSizeChange, len(changes))
+	copy(bd.anticoneSizeChanges, changes)
+	sort.Slice(bd.anticoneSizeChanges, func(i, j int) bool {
+		return bd.anticoneSizeChanges[i].MainHeight < bd.anticoneSizeChanges[j].MainHeight
+	})
+	if log != nil {
+		for _, change := range bd.anticoneSizeChanges {
+			log.Info(fmt.Sprintf("anticone size:%d from main height %d",
+				change.Size, change.MainHeight))
+		}
+	}
+}
+
+// getAnticoneSize returns the anticone size of the blocks at the main height.
+// The k-chain of a block is walked with the size of the block itself, so the
+// first blocks after a change re-evaluate the blue set boundary with the new
+// size even though their main chain ancestors used the former one, while the
+// blocks before the change keep their colors.
+func (ph *Phantom) getAnticoneSize(mainHeight uint) int {
+	changes := ph.bd.anticoneSizeChanges
+	i := sort.Search(len(changes), func(i int) bool {
+		return changes[i].MainHeight > mainHeight
+	})
+	if i == 0 {
+		return ph.anticoneSize
+	}
+	return changes[i-1].Size
+}
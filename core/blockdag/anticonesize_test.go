@@ -0,0 +1,47 @@
+package blockdag
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Test_AnticoneSizeChanges checks the anticone size of the blocks around the
+// scheduled changes, which are given out of order, set before Init as
+// required.
+func Test_AnticoneSizeChanges(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	dag, cleanup, err := buildDAGWith(randomDAG(r, 1), func(bd *BlockDAG) {
+		bd.SetAnticoneSize(7, []AnticoneSizeChange{
+			{MainHeight: 20, Size: 3},
+			{MainHeight: 10, Size: 5},
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	ph := dag.instance.(*Phantom)
+	if ph.anticoneSize != 7 {
+		t.Fatalf("got the anticone size %d, expected the override 7",
+			ph.anticoneSize)
+	}
+	tests := []struct {
+		mainHeight uint
+		size       int
+	}{
+		{0, 7},
+		{9, 7},
+		{10, 5},
+		{19, 5},
+		{20, 3},
+		{1000, 3},
+	}
+	for _, test := range tests {
+		size := ph.getAnticoneSize(test.mainHeight)
+		if size != test.size {
+			t.Errorf("main height %d has the anticone size %d, expected %d",
+				test.mainHeight, size, test.size)
+		}
+	}
+}
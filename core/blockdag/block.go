@@ -259,7 +259,7 @@ func (b *Block) Encode(w io.Writer) error {
 	// parents
 	parents := []uint{}
 	if b.HasParents() {
-		parents = b.parents.List()
+		parents = b.parents.SortList(false)
 	}
 	parentsSize := len(parents)
 	err = s.WriteElements(w, uint32(parentsSize))
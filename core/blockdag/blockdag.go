@@ -3,6 +3,7 @@ package blockdag
 import (
 	"bytes"
 	"container/list"
+	"context"
 	"fmt"
 	"github.com/Qitmeer/qitmeer/common/hash"
 	"github.com/Qitmeer/qitmeer/common/roughtime"
@@ -50,45 +51,78 @@ const MaxTipLayerGap = 10
 // StableConfirmations
 const StableConfirmations = 10
 
+// maxAnticoneFutureSet is the maximum number of blocks of the future of a
+// block held in memory to compute its anticone, beyond which the reachability
+// index tells whether a block is in its future.
+const maxAnticoneFutureSet = 10000
+
+// dagType is a registered ordering algorithm of the DAG.
+type dagType struct {
+	name   string
+	index  byte
+	create func() IBlockDAG
+}
+
+// dagTypes are the registered ordering algorithms of the DAG.
+var dagTypes []dagType
+
+func init() {
+	for _, t := range []dagType{
+		{phantom, 0, func() IBlockDAG { return &Phantom{} }},
+		{phantom_v2, 1, func() IBlockDAG { return &Phantom_v2{} }},
+		{conflux, 2, func() IBlockDAG { return &Conflux{} }},
+		{spectre, 3, func() IBlockDAG { return &Spectre{} }},
+	} {
+		err := RegisterDAGType(t.name, t.index, t.create)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterDAGType registers an ordering algorithm of the DAG, so that a
+// network can select it by name with --dagtype or the DAGType of its
+// parameters.  The index identifies the algorithm in the database, so it must
+// be unique and must never change.  It must be called before any DAG is
+// created, usually from an init function.
+func RegisterDAGType(name string, index byte, create func() IBlockDAG) error {
+	for _, t := range dagTypes {
+		if t.name == name {
+			return fmt.Errorf("DAG type %s is already registered", name)
+		}
+		if t.index == index {
+			return fmt.Errorf("DAG type index %d of %s is already used "+
+				"by %s", index, name, t.name)
+		}
+	}
+	dagTypes = append(dagTypes, dagType{name, index, create})
+	return nil
+}
+
 // It will create different BlockDAG instances
 func NewBlockDAG(dagType string) IBlockDAG {
-	switch dagType {
-	case phantom:
-		return &Phantom{}
-	case phantom_v2:
-		return &Phantom_v2{}
-	case conflux:
-		return &Conflux{}
-	case spectre:
-		return &Spectre{}
+	for _, t := range dagTypes {
+		if t.name == dagType {
+			return t.create()
+		}
 	}
 	return nil
 }
 
 func GetDAGTypeIndex(dagType string) byte {
-	switch dagType {
-	case phantom:
-		return 0
-	case phantom_v2:
-		return 1
-	case conflux:
-		return 2
-	case spectre:
-		return 3
+	for _, t := range dagTypes {
+		if t.name == dagType {
+			return t.index
+		}
 	}
 	return 0
 }
 
 func GetDAGTypeByIndex(dagType byte) string {
-	switch dagType {
-	case 0:
-		return phantom
-	case 1:
-		return phantom_v2
-	case 2:
-		return conflux
-	case 3:
-		return spectre
+	for _, t := range dagTypes {
+		if t.index == dagType {
+			return t.name
+		}
 	}
 	return phantom
 }
@@ -164,6 +198,8 @@ type BlockDAG struct {
 	blockTotal uint
 
 	// The terminal block is in block dag,this block have not any connecting at present.
+	// The set is copy-on-write: it is replaced instead of modified, so that
+	// a reader may keep iterating it after releasing the state lock.
 	tips *IdSet
 
 	// This is time when the last block have added
@@ -178,7 +214,8 @@ type BlockDAG struct {
 	// different dag types config.
 	instance IBlockDAG
 
-	// state lock
+	// state lock, which is only held for writing while the DAG changes, so
+	// that the queries run concurrently with each other.
 	stateLock sync.RWMutex
 
 	//
@@ -193,6 +230,26 @@ type BlockDAG struct {
 
 	// Rollback mechanism
 	lastSnapshot *DAGSnapshot
+
+	// The number of main chain blocks on top of the finality point, zero
+	// disables the finality point.
+	finalityDepth uint
+
+	// The newest hourglass block of the main chain with finalityDepth
+	// blocks on top of it.
+	finalityPoint IBlock
+
+	// The anticones of the blocks, kept up to date as blocks are added.
+	anticoneCache *anticoneCache
+
+	// The reachability index of the blocks, answering whether a block is
+	// in the past of another.
+	reachability *reachability
+
+	// The anticone size overriding the one computed from the block rate
+	// when positive, and its scheduled changes ordered by main height.
+	anticoneSize        int
+	anticoneSizeChanges []AnticoneSizeChange
 }
 
 // Acquire the name of DAG instance
@@ -214,6 +271,8 @@ func (bd *BlockDAG) Init(dagType string, calcWeight CalcWeight, blockRate float6
 	bd.db = db
 	bd.commitBlock = NewIdSet()
 	bd.lastSnapshot = NewDAGSnapshot()
+	bd.anticoneCache = newAnticoneCache(DefaultAnticoneCacheSize)
+	bd.reachability = newReachability()
 	bd.blockRate = blockRate
 	if bd.blockRate < 0 {
 		bd.blockRate = anticone.DefaultBlockRate
@@ -259,13 +318,14 @@ func (bd *BlockDAG) Init(dagType string, calcWeight CalcWeight, blockRate float6
 }
 
 // This is an entry for update the block dag,you need pass in a block parameter,
-// If add block have failure,it will return false.
-func (bd *BlockDAG) AddBlock(b IBlockData) (*list.List, *list.List, IBlock, bool) {
+// If add block have failure,it will return a nil order change. The order change
+// tells the blocks which are attached to and detached from the order.
+func (bd *BlockDAG) AddBlock(b IBlockData) (*OrderChange, IBlock, bool) {
 	bd.stateLock.Lock()
 	defer bd.stateLock.Unlock()
 
 	if b == nil {
-		return nil, nil, nil, false
+		return nil, nil, false
 	}
 	// Must keep no block in outside.
 	/*	if bd.hasBlock(b.GetHash()) {
@@ -275,18 +335,18 @@ func (bd *BlockDAG) AddBlock(b IBlockData) (*list.List, *list.List, IBlock, bool
 	if bd.blockTotal > 0 {
 		parentsIds := b.GetParents()
 		if len(parentsIds) == 0 {
-			return nil, nil, nil, false
+			return nil, nil, false
 		}
 		for _, v := range parentsIds {
 			pib := bd.getBlock(v)
 			if pib == nil {
-				return nil, nil, nil, false
+				return nil, nil, false
 			}
 			parents = append(parents, pib)
 		}
 
 		if !bd.isDAG(parents) {
-			return nil, nil, nil, false
+			return nil, nil, false
 		}
 	}
 	lastMT := bd.instance.GetMainChainTipId()
@@ -308,7 +368,7 @@ func (bd *BlockDAG) AddBlock(b IBlockData) (*list.List, *list.List, IBlock, bool
 	}
 	bd.lastSnapshot.Clean()
 	bd.lastSnapshot.block = ib
-	bd.lastSnapshot.tips = bd.tips.Clone()
+	bd.lastSnapshot.tips = bd.tips
 	bd.lastSnapshot.lastTime = bd.lastTime
 	//
 	bd.blockTotal++
@@ -333,6 +393,7 @@ func (bd *BlockDAG) AddBlock(b IBlockData) (*list.List, *list.List, IBlock, bool
 
 	//
 	bd.updateTips(ib)
+	bd.anticoneCache.addBlock(ib)
 	//
 	t := time.Unix(b.GetTimestamp(), 0)
 	if bd.lastTime.Before(t) {
@@ -340,14 +401,13 @@ func (bd *BlockDAG) AddBlock(b IBlockData) (*list.List, *list.List, IBlock, bool
 	}
 	//
 	news, olds := bd.instance.AddBlock(ib)
+	bd.addReachability(ib)
 	bd.optimizeReorganizeResult(news, olds)
-	if news == nil {
-		news = list.New()
-	}
-	if olds == nil {
-		olds = list.New()
+	mainTipChanged := lastMT != bd.instance.GetMainChainTipId()
+	if mainTipChanged {
+		bd.updateFinalityPoint()
 	}
-	return news, olds, ib, lastMT != bd.instance.GetMainChainTipId()
+	return newOrderChange(news, olds), ib, mainTipChanged
 }
 
 // Acquire the genesis block of chain
@@ -370,36 +430,45 @@ func (bd *BlockDAG) isDAG(parents []IBlock) bool {
 
 // Total number of blocks
 func (bd *BlockDAG) GetBlockTotal() uint {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 	return bd.blockTotal
 }
 
 // return the terminal blocks, because there maybe more than one, so this is a set.
 func (bd *BlockDAG) GetTips() *HashSet {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	tipsSet := bd.getTipsSet()
 
 	tips := NewHashSet()
-	for k := range bd.tips.GetMap() {
-		ib := bd.getBlockById(k)
+	for _, v := range tipsSet.GetMap() {
+		ib := v.(IBlock)
 		tips.AddPair(ib.GetHash(), ib)
 	}
 	return tips
 }
 
+// getTipsSet returns the current tips set, which may be iterated without the
+// state lock because it is never modified.
+func (bd *BlockDAG) getTipsSet() *IdSet {
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
+
+	return bd.tips
+}
+
 // Acquire the tips array of DAG
 func (bd *BlockDAG) GetTipsList() []IBlock {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
-
+	bd.stateLock.RLock()
 	result := bd.instance.GetTipsList()
+	tipsSet := bd.tips
+	bd.stateLock.RUnlock()
+
 	if result != nil {
 		return result
 	}
 	result = []IBlock{}
-	for k := range bd.tips.GetMap() {
-		result = append(result, bd.getBlockById(k))
+	for _, v := range tipsSet.GetMap() {
+		result = append(result, v.(IBlock))
 	}
 	return result
 }
@@ -411,27 +480,28 @@ func (bd *BlockDAG) BuildMerkleTreeStoreFromTips() []*hash.Hash {
 }
 
 // Refresh the dag tip with new block,it will cause changes in tips set.
+// The tips set is replaced by a new one, see the tips field.
 func (bd *BlockDAG) updateTips(b IBlock) {
-	if bd.tips == nil {
-		bd.tips = NewIdSet()
-		bd.tips.AddPair(b.GetID(), b)
-		return
-	}
-	for k, v := range bd.tips.GetMap() {
-		block := v.(IBlock)
-		if block.HasChildren() {
-			bd.tips.Remove(k)
+	tips := NewIdSet()
+	if bd.tips != nil {
+		for k, v := range bd.tips.GetMap() {
+			block := v.(IBlock)
+			if !block.HasChildren() {
+				tips.AddPair(k, block)
+			}
 		}
 	}
-	bd.tips.AddPair(b.GetID(), b)
+	tips.AddPair(b.GetID(), b)
+	bd.tips = tips
 }
 
 // The last time is when add one block to DAG.
 func (bd *BlockDAG) GetLastTime() *time.Time {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
-	return &bd.lastTime
+	lastTime := bd.lastTime
+	return &lastTime
 }
 
 // Returns a future collection of block. This function is a recursively called function
@@ -450,11 +520,77 @@ func (bd *BlockDAG) getFutureSet(fs *IdSet, b IBlock) {
 	}
 }
 
+// forEachFuture calls fn with the blocks of the future of the block, nearest
+// first, until fn returns false.  It returns whether the whole future was
+// visited.
+func (bd *BlockDAG) forEachFuture(b IBlock, fn func(IBlock) bool) bool {
+	visited := NewIdSet()
+	queue := []IBlock{b}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		children := cur.GetChildren()
+		if children == nil {
+			continue
+		}
+		for k, v := range children.GetMap() {
+			if visited.Has(k) {
+				continue
+			}
+			visited.Add(k)
+			ib := v.(IBlock)
+			if !fn(ib) {
+				return false
+			}
+			queue = append(queue, ib)
+		}
+	}
+	return true
+}
+
+// getFutureSetN returns at most limit blocks of the future of the block, and
+// whether they are the whole future.
+func (bd *BlockDAG) getFutureSetN(b IBlock, limit int) (*IdSet, bool) {
+	fs := NewIdSet()
+	complete := bd.forEachFuture(b, func(ib IBlock) bool {
+		if fs.Size() >= limit {
+			return false
+		}
+		fs.AddPair(ib.GetID(), ib)
+		return true
+	})
+	return fs, complete
+}
+
+// ForEachFuture calls fn with the blocks of the future of the block, nearest
+// first, until fn returns false, so that the future of an old block is not
+// held in memory.  It returns whether the whole future was visited.
+//
+// This function is safe for concurrent access, however fn must not call the
+// DAG.
+func (bd *BlockDAG) ForEachFuture(b IBlock, fn func(IBlock) bool) bool {
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
+
+	return bd.forEachFuture(b, fn)
+}
+
+// GetFutureSetN returns at most limit blocks of the future of the block, and
+// whether they are the whole future.
+//
+// This function is safe for concurrent access.
+func (bd *BlockDAG) GetFutureSetN(b IBlock, limit int) (*IdSet, bool) {
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
+
+	return bd.getFutureSetN(b, limit)
+}
+
 // Query whether a given block is on the main chain.
 // Note that some DAG protocols may not support this feature.
 func (bd *BlockDAG) IsOnMainChain(id uint) bool {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	return bd.isOnMainChain(id)
 }
@@ -467,8 +603,8 @@ func (bd *BlockDAG) isOnMainChain(id uint) bool {
 
 // return the tip of main chain
 func (bd *BlockDAG) GetMainChainTip() IBlock {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	return bd.getMainChainTip()
 }
@@ -478,18 +614,36 @@ func (bd *BlockDAG) getMainChainTip() IBlock {
 	return bd.instance.GetMainChainTip()
 }
 
+// GetMainChainBlockByHeight returns the block of the main chain at the height,
+// or nil when the main chain is lower.  The main chain is the selected parent
+// chain ending at the main chain tip.
+// Note that some DAG protocols may not support this feature.
+func (bd *BlockDAG) GetMainChainBlockByHeight(height uint) IBlock {
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
+
+	ib := bd.getMainChainTip()
+	for ib != nil && ib.GetHeight() > height {
+		ib = bd.getBlockById(ib.GetMainParent())
+	}
+	if ib == nil || ib.GetHeight() != height {
+		return nil
+	}
+	return ib
+}
+
 // return the main parent in the parents
 func (bd *BlockDAG) GetMainParent(parents *IdSet) IBlock {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	return bd.instance.GetMainParent(parents)
 }
 
 // return the main parent in the parents
 func (bd *BlockDAG) GetMainParentByHashs(parents []*hash.Hash) IBlock {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	parentsSet := NewIdSet()
 	for _, p := range parents {
@@ -504,8 +658,8 @@ func (bd *BlockDAG) GetMainParentByHashs(parents []*hash.Hash) IBlock {
 
 // Return current general description of the whole state of DAG
 func (bd *BlockDAG) GetGraphState() *GraphState {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 	return bd.getGraphState()
 }
 
@@ -533,32 +687,36 @@ func (bd *BlockDAG) getGraphState() *GraphState {
 }
 
 // Judging whether block is the virtual tip that it have not future set.
-func isVirtualTip(bs *IdSet, futureSet *IdSet, anticone *IdSet, children *IdSet) bool {
+func isVirtualTip(bs *IdSet, inFuture func(id uint) bool, anticone *IdSet, children *IdSet) bool {
 	for k := range children.GetMap() {
 		if bs.Has(k) {
 			return false
 		}
-		if !futureSet.Has(k) && !anticone.Has(k) {
+		if !inFuture(k) && !anticone.Has(k) {
 			return false
 		}
 	}
 	return true
 }
 
-// This function is used to GetAnticone recursion
-func (bd *BlockDAG) recAnticone(bs *IdSet, futureSet *IdSet, anticone *IdSet, ib IBlock) {
+// This function is used to GetAnticone recursion.  It stops with the error of
+// the context once it is done.
+func (bd *BlockDAG) recAnticone(ctx context.Context, bs *IdSet, inFuture func(id uint) bool, anticone *IdSet, ib IBlock) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	if bs.Has(ib.GetID()) || anticone.Has(ib.GetID()) {
-		return
+		return nil
 	}
 	children := ib.GetChildren()
 	needRecursion := false
 	if children == nil || children.Size() == 0 {
 		needRecursion = true
 	} else {
-		needRecursion = isVirtualTip(bs, futureSet, anticone, children)
+		needRecursion = isVirtualTip(bs, inFuture, anticone, children)
 	}
 	if needRecursion {
-		if !futureSet.Has(ib.GetID()) {
+		if !inFuture(ib.GetID()) {
 			anticone.AddPair(ib.GetID(), ib)
 		}
 		parents := ib.GetParents()
@@ -566,27 +724,90 @@ func (bd *BlockDAG) recAnticone(bs *IdSet, futureSet *IdSet, anticone *IdSet, ib
 		//Because parents can not be empty, so there is no need to judge.
 		for _, v := range parents.GetMap() {
 			pib := v.(IBlock)
-			bd.recAnticone(bs, futureSet, anticone, pib)
+			if err := bd.recAnticone(ctx, bs, inFuture, anticone, pib); err != nil {
+				return err
+			}
 		}
 	}
+	return nil
 }
 
 // This function can get anticone set for an block that you offered in the block dag,If
 // the exclude set is not empty,the final result will exclude set that you passed in.
 func (bd *BlockDAG) getAnticone(b IBlock, exclude *IdSet) *IdSet {
-	futureSet := NewIdSet()
-	bd.getFutureSet(futureSet, b)
+	anticone, _ := bd.getAnticoneCtx(context.Background(), b, exclude)
+	return anticone
+}
+
+// getAnticoneCtx is getAnticone stopping with the error of the context once it
+// is done.
+func (bd *BlockDAG) getAnticoneCtx(ctx context.Context, b IBlock, exclude *IdSet) (*IdSet, error) {
+	// Only the blocks of the DAG are cached, not the virtual blocks.
+	cacheable := bd.getBlockById(b.GetID()) == b
+	if cacheable {
+		anticone := bd.anticoneCache.get(b)
+		if anticone != nil {
+			if exclude != nil {
+				anticone.Exclude(exclude)
+			}
+			return anticone, nil
+		}
+	}
+	// The future of an old block is most of the DAG, so it is only held
+	// in memory when it is small, otherwise the reachability index tells
+	// whether a block is in it.
+	futureSet, complete := bd.getFutureSetN(b, maxAnticoneFutureSet)
+	inFuture := futureSet.Has
+	if !complete {
+		if cacheable && bd.isReachabilityComplete() {
+			inFuture = func(id uint) bool {
+				return bd.reachability.isAncestor(b.GetID(), id)
+			}
+		} else {
+			futureSet = NewIdSet()
+			bd.getFutureSet(futureSet, b)
+			inFuture = futureSet.Has
+		}
+	}
 	anticone := NewIdSet()
 	bs := NewIdSet()
 	bs.AddPair(b.GetID(), b)
 	for _, v := range bd.tips.GetMap() {
 		ib := v.(IBlock)
-		bd.recAnticone(bs, futureSet, anticone, ib)
+		if err := bd.recAnticone(ctx, bs, inFuture, anticone, ib); err != nil {
+			return nil, err
+		}
+	}
+	if cacheable {
+		bd.anticoneCache.put(b, anticone)
 	}
 	if exclude != nil {
 		anticone.Exclude(exclude)
 	}
-	return anticone
+	return anticone, nil
+}
+
+// GetAnticone returns the hashes of the blocks of the anticone of the block,
+// which are neither in its past nor in its future, in the order they were
+// added to the DAG.  The traversal stops with the error of the context once it
+// is done, such as when the RPC call asking for it times out.
+func (bd *BlockDAG) GetAnticone(ctx context.Context, h *hash.Hash) ([]*hash.Hash, error) {
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
+
+	ib := bd.getBlock(h)
+	if ib == nil {
+		return nil, fmt.Errorf("No find block")
+	}
+	anticone, err := bd.getAnticoneCtx(ctx, ib, nil)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]*hash.Hash, 0, anticone.Size())
+	for _, id := range anticone.SortList(false) {
+		hashes = append(hashes, anticone.Get(id).(IBlock).GetHash())
+	}
+	return hashes, nil
 }
 
 // getParentsAnticone
@@ -594,7 +815,7 @@ func (bd *BlockDAG) getParentsAnticone(parents *IdSet) *IdSet {
 	anticone := NewIdSet()
 	for _, v := range bd.tips.GetMap() {
 		ib := v.(IBlock)
-		bd.recAnticone(parents, NewIdSet(), anticone, ib)
+		bd.recAnticone(context.Background(), parents, NewIdSet().Has, anticone, ib)
 	}
 	return anticone
 }
@@ -761,8 +982,8 @@ func (bd *BlockDAG) getDiffAnticone(b IBlock, verbose bool) *IdSet {
 
 // GetConfirmations
 func (bd *BlockDAG) GetConfirmations(id uint) uint {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	block := bd.getBlockById(id)
 	if block == nil {
@@ -803,8 +1024,8 @@ func (bd *BlockDAG) GetConfirmations(id uint) uint {
 }
 
 func (bd *BlockDAG) GetValidTips() []*hash.Hash {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 	tips := bd.getValidTips(true)
 
 	result := []*hash.Hash{}
@@ -881,8 +1102,8 @@ func (bd *BlockDAG) checkLayerGap(parentsNode []IBlock) bool {
 
 // Checking the sub main chain for the parents of tip
 func (bd *BlockDAG) CheckSubMainChainTip(parents []uint) (uint, bool) {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	if len(parents) == 0 {
 		return 0, false
@@ -958,7 +1179,38 @@ func (bd *BlockDAG) Load(dbTx database.Tx, blockTotal uint, genesis *hash.Hash)
 	bd.blockTotal = blockTotal
 	bd.blocks = map[uint]IBlock{}
 	bd.tips = NewIdSet()
-	return bd.instance.Load(dbTx)
+	err = bd.instance.Load(dbTx)
+	if err != nil {
+		return err
+	}
+	err = bd.buildReachability()
+	if err != nil {
+		return err
+	}
+	return bd.checkTips(dbTx)
+}
+
+// checkTips returns an error when the tips rebuilt from the block index are
+// not the tips stored by the last commit.
+func (bd *BlockDAG) checkTips(dbTx database.Tx) error {
+	tips, err := DBGetDAGTips(dbTx)
+	if err != nil {
+		return err
+	}
+	if tips == nil {
+		return nil
+	}
+	consistent := len(tips) == bd.tips.Size()
+	for _, id := range tips {
+		if !bd.tips.Has(id) {
+			consistent = false
+		}
+	}
+	if !consistent {
+		return fmt.Errorf("The tips %v are inconsistent: Stored tips %v",
+			bd.tips.SortList(false), tips)
+	}
+	return nil
 }
 
 func (bd *BlockDAG) Encode(w io.Writer) error {
@@ -985,24 +1237,58 @@ func (bd *BlockDAG) Decode(r io.Reader) error {
 
 // GetBlues
 func (bd *BlockDAG) GetBlues(parents *IdSet) uint {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	return bd.instance.GetBlues(parents)
 }
 
 // IsBlue
 func (bd *BlockDAG) IsBlue(id uint) bool {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	return bd.instance.IsBlue(id)
 }
 
+// IsBlueBlock returns whether the block is in the blue set of the DAG, which
+// is always false for an unknown block or a DAG type without blue set.
+func (bd *BlockDAG) IsBlueBlock(h *hash.Hash) bool {
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
+
+	ib := bd.getBlock(h)
+	if ib == nil {
+		return false
+	}
+	return bd.instance.IsBlue(ib.GetID())
+}
+
+// GetBlueScore returns the blue score of the block, which is the number of
+// blue blocks in its past.
+func (bd *BlockDAG) GetBlueScore(h *hash.Hash) (uint, error) {
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
+
+	ib := bd.getBlock(h)
+	if ib == nil {
+		return 0, fmt.Errorf("No find block")
+	}
+	pb, ok := ib.(*PhantomBlock)
+	if !ok {
+		return 0, fmt.Errorf("The DAG type %s has no blue score", bd.instance.GetName())
+	}
+	return pb.GetBlueNum(), nil
+}
+
 func (bd *BlockDAG) IsHourglass(id uint) bool {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
+	return bd.isHourglass(id)
+}
+
+func (bd *BlockDAG) isHourglass(id uint) bool {
 	if !bd.hasBlockById(id) {
 		return false
 	}
@@ -1056,8 +1342,8 @@ func (bd *BlockDAG) IsHourglass(id uint) bool {
 }
 
 func (bd *BlockDAG) GetParentsMaxLayer(parents *IdSet) (uint, bool) {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	maxLayer := uint(0)
 	for k := range parents.GetMap() {
@@ -1074,8 +1360,8 @@ func (bd *BlockDAG) GetParentsMaxLayer(parents *IdSet) (uint, bool) {
 
 // GetMaturity
 func (bd *BlockDAG) GetMaturity(target uint, views []uint) uint {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	if target == MaxId {
 		return 0
@@ -1183,15 +1469,15 @@ func (bd *BlockDAG) getMaxParents() int {
 
 // The main parent concurrency of block
 func (bd *BlockDAG) GetMainParentConcurrency(b IBlock) int {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 	return bd.instance.GetMainParentConcurrency(b)
 }
 
 // GetBlockConcurrency : Temporarily use blue set of the past blocks as the criterion
 func (bd *BlockDAG) GetBlockConcurrency(h *hash.Hash) (uint, error) {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	ib := bd.getBlock(h)
 	if ib == nil {
@@ -1261,7 +1547,7 @@ func (bd *BlockDAG) commit() error {
 					return e
 				}
 			}
-			return nil
+			return DBPutDAGTips(dbTx, bd.tips.List())
 		})
 		bd.commitBlock.Clean()
 		if err != nil {
@@ -1280,8 +1566,10 @@ func (bd *BlockDAG) rollback() error {
 		log.Debug(fmt.Sprintf("Block DAG try to roll back ... ..."))
 
 		block := bd.lastSnapshot.block
+		bd.reachability.removeLastBlock(bd.getMergeset(block))
 		delete(bd.blocks, block.GetID())
 		bd.commitBlock.Clean()
+		bd.anticoneCache.removeBlock(block)
 
 		for _, v := range block.GetParents().GetMap() {
 			parent, ok := v.(IBlock)
@@ -9,10 +9,15 @@ import (
 	"github.com/Qitmeer/qitmeer/database"
 	l "github.com/Qitmeer/qitmeer/log"
 	"github.com/Qitmeer/qitmeer/params"
+	"go/parser"
+	"go/token"
 	"io"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
 )
 
 // Structure of blocks data
@@ -180,8 +185,8 @@ func InitBlockDAG(dagType string, graph string) IBlockDAG {
 			parents = append(parents, tbMap[parent].GetHash())
 		}
 		block := buildBlock(parents)
-		l, _, ib, _ := bd.AddBlock(block)
-		if l != nil && l.Len() > 0 {
+		oc, ib, _ := bd.AddBlock(block)
+		if !oc.IsEmpty() {
 			tbMap[tbd[i].Tag] = ib
 			err = bd.Commit()
 			if err != nil {
@@ -358,3 +363,60 @@ func getBlocksByTag(tags []string) []uint {
 func exit() {
 	removeBlockDB("./blocks_ffldb")
 }
+
+func Test_RegisterDAGType(t *testing.T) {
+	create := func() IBlockDAG { return &Phantom{} }
+	if err := RegisterDAGType(phantom, 100, create); err == nil {
+		t.Error("registered a DAG type name twice")
+	}
+	if err := RegisterDAGType("phantom_test", GetDAGTypeIndex(spectre), create); err == nil {
+		t.Error("registered a DAG type index twice")
+	}
+	if NewBlockDAG("phantom_test") != nil {
+		t.Error("created an unregistered DAG type")
+	}
+}
+
+// consensusDeps are the packages of qitmeer the consensus may depend on.
+var consensusDeps = map[string]bool{
+	"github.com/Qitmeer/qitmeer/common/hash":            true,
+	"github.com/Qitmeer/qitmeer/common/roughtime":       true,
+	"github.com/Qitmeer/qitmeer/common/util":            true,
+	"github.com/Qitmeer/qitmeer/core/blockdag/anticone": true,
+	"github.com/Qitmeer/qitmeer/core/dbnamespace":       true,
+	"github.com/Qitmeer/qitmeer/core/merkle":            true,
+	"github.com/Qitmeer/qitmeer/core/serialization":     true,
+	"github.com/Qitmeer/qitmeer/core/types":             true,
+	"github.com/Qitmeer/qitmeer/database":               true,
+	"github.com/Qitmeer/qitmeer/log":                    true,
+	"github.com/Qitmeer/qitmeer/metrics":                true,
+}
+
+// Test_ConsensusBoundary checks that the package only imports the allowed
+// packages of qitmeer, so that it does not depend on the chain, the network
+// nor the RPC server.
+func Test_ConsensusBoundary(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, parser.ImportsOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, imp := range f.Imports {
+			path, err := strconv.Unquote(imp.Path.Value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.HasPrefix(path, "github.com/Qitmeer/qitmeer/") && !consensusDeps[path] {
+				t.Errorf("%s imports %s outside of the consensus boundary", file, path)
+			}
+		}
+	}
+}
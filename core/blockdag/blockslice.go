@@ -25,7 +25,7 @@ func (bn BlockHashSlice) Len() int {
 }
 
 func (bn BlockHashSlice) Less(i, j int) bool {
-	return bn[i].GetHash().String() < bn[j].GetHash().String()
+	return bn[i].GetHash().Less(bn[j].GetHash())
 }
 
 func (bn BlockHashSlice) Swap(i, j int) {
@@ -19,8 +19,8 @@ func (bd *BlockDAG) hasBlockById(id uint) bool {
 
 // Is there a block in DAG?
 func (bd *BlockDAG) HasBlockById(id uint) bool {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	return bd.hasBlockById(id)
 }
@@ -37,8 +37,8 @@ func (bd *BlockDAG) hasBlocks(ids []uint) bool {
 
 // Acquire one block by hash
 func (bd *BlockDAG) GetBlock(h *hash.Hash) IBlock {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	return bd.getBlock(h)
 }
@@ -50,8 +50,8 @@ func (bd *BlockDAG) getBlock(h *hash.Hash) IBlock {
 }
 
 func (bd *BlockDAG) GetBlockId(h *hash.Hash) uint {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	return bd.getBlockId(h)
 }
@@ -79,10 +79,49 @@ func (bd *BlockDAG) getBlockId(h *hash.Hash) uint {
 	return id
 }
 
+// GetBlockOrder returns the order of the block, which is MaxBlockOrder when
+// the block is unknown or not ordered yet.
+func (bd *BlockDAG) GetBlockOrder(h *hash.Hash) uint {
+	return bd.GetBlockOrders([]*hash.Hash{h})[0]
+}
+
+// GetBlockOrders returns the orders of the blocks, which are MaxBlockOrder for
+// the blocks unknown or not ordered yet.  The blocks are looked up in a single
+// database transaction, so resolving many blocks at once is much faster than
+// resolving them one by one.
+func (bd *BlockDAG) GetBlockOrders(hs []*hash.Hash) []uint {
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
+
+	orders := make([]uint, len(hs))
+	for i := range orders {
+		orders[i] = MaxBlockOrder
+	}
+	bd.db.View(func(dbTx database.Tx) error {
+		for i, h := range hs {
+			id := MaxId
+			if h == nil {
+				continue
+			}
+			if bd.lastSnapshot.block != nil &&
+				bd.lastSnapshot.block.GetHash().IsEqual(h) {
+				id = bd.lastSnapshot.block.GetID()
+			} else if bid, err := DBGetBlockIdByHash(dbTx, h); err == nil {
+				id = uint(bid)
+			}
+			if ib := bd.getBlockById(id); ib != nil {
+				orders[i] = ib.GetOrder()
+			}
+		}
+		return nil
+	})
+	return orders
+}
+
 // Acquire one block by hash
 func (bd *BlockDAG) GetBlockById(id uint) IBlock {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	return bd.getBlockById(id)
 }
@@ -101,8 +140,8 @@ func (bd *BlockDAG) getBlockById(id uint) IBlock {
 
 // Obtain block hash by global order
 func (bd *BlockDAG) GetBlockHashByOrder(order uint) *hash.Hash {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	ib := bd.getBlockByOrder(order)
 	if ib != nil {
@@ -112,15 +151,15 @@ func (bd *BlockDAG) GetBlockHashByOrder(order uint) *hash.Hash {
 }
 
 func (bd *BlockDAG) GetBlockByOrder(order uint) IBlock {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	return bd.getBlockByOrder(order)
 }
 
 func (bd *BlockDAG) GetBlockByOrderWithTx(dbTx database.Tx, order uint) *hash.Hash {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	ib := bd.doGetBlockByOrder(dbTx, order)
 	if ib != nil {
@@ -177,8 +216,8 @@ func (bd *BlockDAG) GetLastBlock() IBlock {
 // This function need a stable sequence,so call it before sorting the DAG.
 // If the h is invalid,the function will become a little inefficient.
 func (bd *BlockDAG) GetPrevious(id uint) (uint, error) {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	if id == 0 {
 		return 0, fmt.Errorf("no pre")
@@ -199,8 +238,8 @@ func (bd *BlockDAG) GetPrevious(id uint) (uint, error) {
 }
 
 func (bd *BlockDAG) GetBlockHash(id uint) *hash.Hash {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	ib := bd.getBlockById(id)
 	if ib != nil {
@@ -293,8 +332,8 @@ func (bd *BlockDAG) sortBlock(src []*hash.Hash) []*hash.Hash {
 
 // Sort block by id
 func (bd *BlockDAG) SortBlock(src []*hash.Hash) []*hash.Hash {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	return bd.sortBlock(src)
 }
@@ -27,8 +27,8 @@ func (bd *BlockDAG) CheckBlueAndMatureMT(targets []uint, views []uint, max uint)
 }
 
 func (bd *BlockDAG) doCheckBlueAndMature(targets []uint, views []uint, max uint, multithreading bool) error {
-	bd.stateLock.Lock()
-	defer bd.stateLock.Unlock()
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
 
 	targetIBs := []IBlock{}
 	maxTargetLayer := uint(0)
@@ -0,0 +1,90 @@
+package blockdag
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+// Test_ConcurrentReaders adds blocks to the DAG while other goroutines query
+// it, so that running the tests with -race checks the locking.
+func Test_ConcurrentReaders(t *testing.T) {
+	ibd := InitBlockDAG(phantom, "PH_fig2-blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	const (
+		numBlocks  = 50
+		numReaders = 4
+	)
+	startTotal := bd.GetBlockTotal()
+	known := []IBlock{}
+	for _, ib := range tbMap {
+		known = append(known, ib)
+	}
+
+	done := make(chan struct{})
+	errs := make(chan string, numReaders+1)
+	var wg sync.WaitGroup
+	for i := 0; i < numReaders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if bd.GetTips().IsEmpty() || len(bd.GetTipsList()) == 0 {
+					errs <- "no tips"
+					return
+				}
+				for _, ib := range known {
+					if bd.GetBlockOrder(ib.GetHash()) == MaxBlockOrder {
+						errs <- "known block without order"
+						return
+					}
+					_, err := bd.GetAnticone(context.Background(), ib.GetHash())
+					if err != nil {
+						errs <- err.Error()
+						return
+					}
+				}
+				if bd.GetMainChainTip() == nil || bd.GetGraphState() == nil {
+					errs <- "no main chain tip"
+					return
+				}
+				bd.IsBlueBlock(bd.GetMainChainTip().GetHash())
+				bd.GetLastTime()
+			}
+		}()
+	}
+
+	for i := 0; i < numBlocks; i++ {
+		block := buildBlock(bd.GetValidTips())
+		oc, _, _ := bd.AddBlock(block)
+		if oc.IsEmpty() {
+			errs <- "block not added"
+			break
+		}
+		if err := bd.Commit(); err != nil {
+			errs <- err.Error()
+			break
+		}
+	}
+	close(done)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	if bd.GetBlockTotal() != startTotal+numBlocks {
+		t.Fatalf("expected %d blocks, got %d", startTotal+numBlocks,
+			bd.GetBlockTotal())
+	}
+	if !bd.GetTips().Has(bd.GetMainChainTip().GetHash()) {
+		t.Fatal("the main chain tip is not a tip")
+	}
+}
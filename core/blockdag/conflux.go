@@ -83,10 +83,10 @@ func (con *Conflux) AddBlock(b IBlock) (*list.List, *list.List) {
 				i >= uint(oldOrderL) ||
 				oldOrder[i] != con.order[i] {
 				result = list.New()
-				result.PushBack(con.order[i])
+				result.PushBack(con.bd.getBlockById(con.order[i]))
 			}
 		} else {
-			result.PushBack(con.order[i])
+			result.PushBack(con.bd.getBlockById(con.order[i]))
 		}
 
 	}
@@ -173,7 +173,7 @@ func (con *Conflux) updateMainChain(b IBlock, preEpoch *Epoch, main *HashSet) {
 			if child.GetWeight() > nextMain.GetWeight() {
 				nextMain = child
 			} else if child.GetWeight() == nextMain.GetWeight() {
-				if child.GetHash().String() < nextMain.GetHash().String() {
+				if child.GetHash().Less(nextMain.GetHash()) {
 					nextMain = child
 				}
 			}
@@ -0,0 +1,244 @@
+package blockdag
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	s "github.com/Qitmeer/qitmeer/core/serialization"
+	"io"
+	"time"
+)
+
+const (
+	// DAGStateVersion is the version of the wire format of the DAG state
+	// written by Encode.  Decode reads every version up to it.
+	DAGStateVersion = 1
+
+	// maxBlockStateSize is the maximum size of the encoded state of one
+	// block, which bounds the memory allocated while decoding.
+	maxBlockStateSize = 1 << 24
+)
+
+// DAGState is a deterministic and serializable snapshot of the in-memory state
+// of the block DAG: the blocks with their parents, orders, layers, heights and
+// blue sets, the tips and the main chain tip.  The blocks are sorted by id and
+// every set is sorted, so the same DAG always gives the same encoding.
+type DAGState struct {
+	Version      uint32
+	DAGType      string
+	Genesis      hash.Hash
+	LastTime     time.Time
+	Blocks       [][]byte
+	Tips         []uint
+	MainChainTip uint
+}
+
+// Encode writes the DAG state in the current wire format.
+func (ds *DAGState) Encode(w io.Writer) error {
+	err := s.WriteElements(w, uint32(DAGStateVersion), GetDAGTypeIndex(ds.DAGType),
+		&ds.Genesis, ds.LastTime.Unix(), uint32(len(ds.Blocks)))
+	if err != nil {
+		return err
+	}
+	for _, data := range ds.Blocks {
+		err = s.WriteVarBytes(w, 0, data)
+		if err != nil {
+			return err
+		}
+	}
+	err = s.WriteElements(w, uint32(len(ds.Tips)))
+	if err != nil {
+		return err
+	}
+	for _, id := range ds.Tips {
+		err = s.WriteElements(w, uint32(id))
+		if err != nil {
+			return err
+		}
+	}
+	return s.WriteElements(w, uint32(ds.MainChainTip))
+}
+
+// Decode reads a DAG state written by Encode of this or an earlier version.
+func (ds *DAGState) Decode(r io.Reader) error {
+	var version uint32
+	err := s.ReadElements(r, &version)
+	if err != nil {
+		return err
+	}
+	if version == 0 || version > DAGStateVersion {
+		return fmt.Errorf("The DAG state version %d is not supported (max %d)",
+			version, DAGStateVersion)
+	}
+	ds.Version = version
+
+	var dagType byte
+	var lastTime int64
+	var blockTotal uint32
+	err = s.ReadElements(r, &dagType, &ds.Genesis, &lastTime, &blockTotal)
+	if err != nil {
+		return err
+	}
+	ds.DAGType = GetDAGTypeByIndex(dagType)
+	ds.LastTime = time.Unix(lastTime, 0)
+	ds.Blocks = make([][]byte, 0, blockTotal)
+	for i := uint32(0); i < blockTotal; i++ {
+		data, err := s.ReadVarBytes(r, 0, maxBlockStateSize, "block state")
+		if err != nil {
+			return err
+		}
+		ds.Blocks = append(ds.Blocks, data)
+	}
+
+	var tipsSize uint32
+	err = s.ReadElements(r, &tipsSize)
+	if err != nil {
+		return err
+	}
+	ds.Tips = make([]uint, 0, tipsSize)
+	for i := uint32(0); i < tipsSize; i++ {
+		var id uint32
+		err = s.ReadElements(r, &id)
+		if err != nil {
+			return err
+		}
+		ds.Tips = append(ds.Tips, uint(id))
+	}
+
+	var mainChainTip uint32
+	err = s.ReadElements(r, &mainChainTip)
+	if err != nil {
+		return err
+	}
+	ds.MainChainTip = uint(mainChainTip)
+	return nil
+}
+
+// Snapshot returns the state of the DAG, for the tests, the fast sync and the
+// crash recovery.
+func (bd *BlockDAG) Snapshot() (*DAGState, error) {
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
+
+	state := &DAGState{
+		Version:      DAGStateVersion,
+		DAGType:      bd.instance.GetName(),
+		Genesis:      bd.genesis,
+		LastTime:     bd.lastTime,
+		Blocks:       make([][]byte, 0, bd.blockTotal),
+		Tips:         bd.tips.SortList(false),
+		MainChainTip: bd.instance.GetMainChainTipId(),
+	}
+	for id := uint(0); id < bd.blockTotal; id++ {
+		ib := bd.getBlockById(id)
+		if ib == nil {
+			return nil, fmt.Errorf("The block %d is missing", id)
+		}
+		var buf bytes.Buffer
+		err := ib.Encode(&buf)
+		if err != nil {
+			return nil, err
+		}
+		state.Blocks = append(state.Blocks, buf.Bytes())
+	}
+	return state, nil
+}
+
+// Restore rebuilds the in-memory state of the DAG from the snapshot.  Nothing
+// is written to the database.  Only the phantom DAG supports it.
+func (bd *BlockDAG) Restore(state *DAGState) error {
+	bd.stateLock.Lock()
+	defer bd.stateLock.Unlock()
+
+	ph, ok := bd.instance.(*Phantom)
+	if !ok {
+		return fmt.Errorf("The DAG type %s can not be restored", bd.instance.GetName())
+	}
+	if state.DAGType != ph.GetName() {
+		return fmt.Errorf("The dag type is %s, but the snapshot is %s",
+			ph.GetName(), state.DAGType)
+	}
+	if len(state.Blocks) == 0 {
+		return fmt.Errorf("The snapshot has no blocks")
+	}
+
+	blocks := map[uint]IBlock{}
+	var tips *IdSet
+	for i, data := range state.Blocks {
+		block := Block{id: uint(i)}
+		ib := bd.instance.CreateBlock(&block)
+		err := ib.Decode(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		if ib.GetID() != uint(i) {
+			return fmt.Errorf("The block %d of the snapshot has id %d", i, ib.GetID())
+		}
+		if i == 0 && !ib.GetHash().IsEqual(&state.Genesis) {
+			return fmt.Errorf("genesis data mismatch")
+		}
+		if ib.HasParents() {
+			parentsSet := NewIdSet()
+			for k := range ib.GetParents().GetMap() {
+				parent, ok := blocks[k]
+				if !ok {
+					return fmt.Errorf("The parent %d of %s is missing", k, ib.GetHash())
+				}
+				parentsSet.AddPair(k, parent)
+				parent.AddChild(ib)
+			}
+			ib.GetParents().Clean()
+			ib.GetParents().AddSet(parentsSet)
+		}
+		if bd.getBlockData != nil {
+			block.data = bd.getBlockData(ib.GetHash())
+		}
+		blocks[ib.GetID()] = ib
+
+		if tips == nil {
+			tips = NewIdSet()
+		}
+		for k, v := range tips.GetMap() {
+			if v.(IBlock).HasChildren() {
+				tips.Remove(k)
+			}
+		}
+		tips.AddPair(ib.GetID(), ib)
+	}
+	sortedTips := tips.SortList(false)
+	if len(sortedTips) != len(state.Tips) {
+		return fmt.Errorf("The tips %v are inconsistent: Snapshot tips %v",
+			sortedTips, state.Tips)
+	}
+	for i := range sortedTips {
+		if sortedTips[i] != state.Tips[i] {
+			return fmt.Errorf("The tips %v are inconsistent: Snapshot tips %v",
+				sortedTips, state.Tips)
+		}
+	}
+	mainChainTip, ok := blocks[state.MainChainTip]
+	if !ok {
+		return fmt.Errorf("The main chain tip %d is missing", state.MainChainTip)
+	}
+
+	bd.genesis = state.Genesis
+	bd.blocks = blocks
+	bd.blockTotal = uint(len(blocks))
+	bd.tips = tips
+	bd.lastTime = state.LastTime
+	bd.commitOrder = map[uint]uint{}
+	bd.commitBlock.Clean()
+	bd.lastSnapshot.Clean()
+	bd.anticoneCache.clean()
+
+	ph.mainChain.genesis = GenesisId
+	ph.mainChain.tip = mainChainTip.GetID()
+	ph.mainChain.commitBlocks.Clean()
+	ph.virtualBlock.blueDiffAnticone.Clean()
+	ph.virtualBlock.redDiffAnticone.Clean()
+	ph.diffAnticone = bd.getAnticone(mainChainTip, nil)
+
+	bd.finalityPoint = nil
+	bd.updateFinalityPoint()
+	return nil
+}
@@ -27,8 +27,8 @@ type DAGSync struct {
 
 // CalcSyncBlocks
 func (ds *DAGSync) CalcSyncBlocks(gs *GraphState, locator []*hash.Hash, mode SyncMode, maxHashes uint) ([]*hash.Hash, *hash.Hash) {
-	ds.bd.stateLock.Lock()
-	defer ds.bd.stateLock.Unlock()
+	ds.bd.stateLock.RLock()
+	defer ds.bd.stateLock.RUnlock()
 
 	if mode == DirectMode {
 		result := []*hash.Hash{}
@@ -85,8 +85,8 @@ func (ds *DAGSync) CalcSyncBlocks(gs *GraphState, locator []*hash.Hash, mode Syn
 
 // GetMainLocator
 func (ds *DAGSync) GetMainLocator(point *hash.Hash) []*hash.Hash {
-	ds.bd.stateLock.Lock()
-	defer ds.bd.stateLock.Unlock()
+	ds.bd.stateLock.RLock()
+	defer ds.bd.stateLock.RUnlock()
 
 	var endBlock IBlock
 	if point != nil {
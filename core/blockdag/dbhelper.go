@@ -55,6 +55,32 @@ func DBPutDAGInfo(dbTx database.Tx, bd *BlockDAG) error {
 	return dbTx.Metadata().Put(dbnamespace.DagInfoBucketName, buff.Bytes())
 }
 
+// DBPutDAGTips stores the ids of the tips of the dag.
+func DBPutDAGTips(dbTx database.Tx, tips []uint) error {
+	serialized := make([]byte, 4*len(tips))
+	for i, id := range tips {
+		dbnamespace.ByteOrder.PutUint32(serialized[4*i:], uint32(id))
+	}
+	return dbTx.Metadata().Put(dbnamespace.DagTipsBucketName, serialized)
+}
+
+// DBGetDAGTips returns the ids of the tips of the dag, which are nil when the
+// database was created before the tips were stored.
+func DBGetDAGTips(dbTx database.Tx) ([]uint, error) {
+	serialized := dbTx.Metadata().Get(dbnamespace.DagTipsBucketName)
+	if serialized == nil {
+		return nil, nil
+	}
+	if len(serialized)%4 != 0 {
+		return nil, fmt.Errorf("corrupt dag tips of %d bytes", len(serialized))
+	}
+	tips := make([]uint, 0, len(serialized)/4)
+	for i := 0; i < len(serialized); i += 4 {
+		tips = append(tips, uint(dbnamespace.ByteOrder.Uint32(serialized[i:])))
+	}
+	return tips, nil
+}
+
 func DBHasMainChainBlock(dbTx database.Tx, id uint) bool {
 	bucket := dbTx.Metadata().Bucket(dbnamespace.DagMainChainBucketName)
 	var serializedID [4]byte
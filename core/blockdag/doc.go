@@ -0,0 +1,42 @@
+/*
+Package blockdag implements the consensus of the qitmeer DAG: the coloring of
+the blocks (blue or red), their total order and the main chain, independently
+of the transactions, the network and the RPC server.  It can be embedded by
+other node frontends and external tools to order the blocks the same way as
+qitmeer does.
+
+Stable API
+
+The following identifiers form the stable API of the package, which only
+changes in a backward compatible way between the minor versions of qitmeer:
+
+  - BlockDAG, set up with Init and fed with AddBlock and Commit
+  - the queries of BlockDAG about the blocks, their order, color and main
+    chain, such as GetBlock, GetBlockByOrder, GetBlockOrder, IsBlue,
+    IsOnMainChain, GetMainChainTip, GetTipsList, GetAnticone and GetGraphState
+  - IBlock, IBlockDAG and GraphState, the views of the DAG returned by the
+    queries
+  - IBlockData, GetBlockData and CalcWeight, which are implemented by the
+    embedding code
+  - the constants MaxBlockOrder, MaxId, GenesisId, MaxTips and MaxTipLayerGap
+
+The other exported identifiers are used by the other packages of qitmeer and
+may change at any time.
+
+Block access and storage
+
+The package does not know about the blocks of qitmeer.  The blocks added to
+the DAG implement IBlockData, which gives their hash, parents and timestamp,
+and the blocks are looked up through the GetBlockData function passed to
+Init.  The state of the DAG is stored in the database.DB passed to Init, under
+the buckets of the dbnamespace package.
+
+Consensus boundary
+
+The package must not depend on the packages of the chain, the mempool, the
+network nor the RPC server.  Test_ConsensusBoundary fails when it imports a
+package of qitmeer outside of its allowed dependencies, so that the boundary
+can be checked by the static analysis tools and the package be moved to its
+own module without changing its API.
+*/
+package blockdag
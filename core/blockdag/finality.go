@@ -0,0 +1,101 @@
+package blockdag
+
+import "github.com/Qitmeer/qitmeer/common/hash"
+
+// maxFinalitySearch is the maximum number of main chain blocks searched for an
+// hourglass block when the finality point advances.
+const maxFinalitySearch = 100
+
+// SetFinalityDepth sets the number of main chain blocks that must be on top of
+// an hourglass block for it to become the finality point, and updates the
+// finality point accordingly.  Zero disables the finality point.
+func (bd *BlockDAG) SetFinalityDepth(depth uint) {
+	bd.stateLock.Lock()
+	defer bd.stateLock.Unlock()
+
+	bd.finalityDepth = depth
+	bd.finalityPoint = nil
+	bd.updateFinalityPoint()
+}
+
+// GetFinalityPoint returns the finality point, which is the newest hourglass
+// block of the main chain with at least the finality depth of main chain
+// blocks on top of it, or nil when there is none.  Every new block must have
+// the finality point in its past, so that the order of the blocks before it
+// can not change anymore.
+func (bd *BlockDAG) GetFinalityPoint() IBlock {
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
+
+	return bd.finalityPoint
+}
+
+// updateFinalityPoint advances the finality point to the newest hourglass
+// block of the main chain with at least the finality depth of main chain
+// blocks on top of it.
+func (bd *BlockDAG) updateFinalityPoint() {
+	if bd.finalityDepth == 0 {
+		return
+	}
+	tip := bd.getMainChainTip()
+	if tip == nil || tip.GetHeight() < bd.finalityDepth {
+		return
+	}
+	maxHeight := tip.GetHeight() - bd.finalityDepth
+	cur := tip
+	for cur != nil && cur.GetHeight() > maxHeight {
+		cur = bd.getBlockById(cur.GetMainParent())
+	}
+	for i := 0; cur != nil && i < maxFinalitySearch; i++ {
+		if bd.finalityPoint != nil && cur.GetID() == bd.finalityPoint.GetID() {
+			return
+		}
+		if bd.isHourglass(cur.GetID()) {
+			bd.finalityPoint = cur
+			return
+		}
+		cur = bd.getBlockById(cur.GetMainParent())
+	}
+}
+
+// CheckFinality returns whether a block with the parents would have the
+// finality point in its past.  A block which does not could reorder the
+// blocks before the finality point, and must be rejected.
+func (bd *BlockDAG) CheckFinality(parents []*hash.Hash) bool {
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
+
+	fp := bd.finalityPoint
+	if fp == nil {
+		return true
+	}
+	queueSet := NewIdSet()
+	queue := []IBlock{}
+	for _, h := range parents {
+		ib := bd.getBlock(h)
+		if ib == nil || queueSet.Has(ib.GetID()) {
+			continue
+		}
+		queue = append(queue, ib)
+		queueSet.Add(ib.GetID())
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if cur.GetID() == fp.GetID() {
+			return true
+		}
+		if cur.GetLayer() <= fp.GetLayer() || !cur.HasParents() {
+			continue
+		}
+		for _, v := range cur.GetParents().GetMap() {
+			ib := v.(IBlock)
+			if queueSet.Has(ib.GetID()) {
+				continue
+			}
+			queue = append(queue, ib)
+			queueSet.Add(ib.GetID())
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package blockdag
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"testing"
+)
+
+func Test_FinalityPoint(t *testing.T) {
+	ibd := InitBlockDAG(phantom, "CP_Blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	if bd.GetFinalityPoint() != nil {
+		t.Fatal("finality point without finality depth")
+	}
+	bd.SetFinalityDepth(2)
+	fp := bd.GetFinalityPoint()
+	if fp == nil || fp.GetID() != tbMap["J"].GetID() {
+		t.Fatalf("expected finality point J, got %v", fp)
+	}
+	if bd.CheckFinality([]*hash.Hash{tbMap["B"].GetHash()}) {
+		t.Fatal("block before the finality point accepted")
+	}
+	if !bd.CheckFinality([]*hash.Hash{tbMap["B"].GetHash(), tbMap["I"].GetHash()}) {
+		t.Fatal("block after the finality point rejected")
+	}
+	if !bd.CheckFinality([]*hash.Hash{tbMap["J"].GetHash()}) {
+		t.Fatal("block on the finality point rejected")
+	}
+
+	bd.SetFinalityDepth(1)
+	fp = bd.GetFinalityPoint()
+	if fp == nil || fp.GetID() != tbMap["H"].GetID() {
+		t.Fatalf("expected finality point H, got %v", fp)
+	}
+}
@@ -194,7 +194,7 @@ func GetMaxLenHashSet(bsm map[hash.Hash]*HashSet) *hash.Hash {
 				curNum = v.Size()
 				result = k
 			} else if v.Size() == curNum {
-				if k.String() < result.String() {
+				if k.Less(&result) {
 					result = k
 				}
 			}
@@ -10,7 +10,7 @@ func (sh HashSlice) Len() int {
 }
 
 func (sh HashSlice) Less(i, j int) bool {
-	return sh[i].String() < sh[j].String()
+	return sh[i].Less(sh[j])
 }
 
 func (sh HashSlice) Swap(i, j int) {
@@ -0,0 +1,135 @@
+package blockdag
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+)
+
+// CheckInvariants verifies the invariants of the DAG once the block was
+// added, which are too expensive to be checked outside of the assertion mode
+// of the node:
+//
+// The orders of the ordered blocks are exactly 0 to their count minus one,
+// and the blocks without an order are waiting for the order of the virtual
+// block, so the ordered and the waiting blocks add up to the total blocks.
+//
+// The blue set of the block computed again from its parents matches the one
+// computed when it was added.
+//
+// The anticone of the main tip maintained by AddBlock matches the one walked
+// from the tips.
+//
+// This function is safe for concurrent access.
+func (bd *BlockDAG) CheckInvariants(h *hash.Hash) error {
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
+
+	err := bd.checkOrders()
+	if err != nil {
+		return err
+	}
+	ph, ok := bd.instance.(*Phantom)
+	if !ok {
+		return nil
+	}
+	ib := bd.getBlock(h)
+	if ib == nil {
+		return fmt.Errorf("block %s is not in the DAG", h)
+	}
+	err = ph.checkBlueSet(ib.(*PhantomBlock))
+	if err != nil {
+		return err
+	}
+	return ph.checkDiffAnticone()
+}
+
+// checkOrders verifies the orders of the blocks.
+func (bd *BlockDAG) checkOrders() error {
+	if uint(len(bd.blocks)) != bd.blockTotal {
+		return fmt.Errorf("%d blocks in the DAG, but the block total is %d",
+			len(bd.blocks), bd.blockTotal)
+	}
+	var pending *IdSet
+	if ph, ok := bd.instance.(*Phantom); ok {
+		pending = ph.diffAnticone
+	}
+	orders := make(map[uint]uint, len(bd.blocks))
+	for id, ib := range bd.blocks {
+		order := ib.GetOrder()
+		if order == MaxBlockOrder {
+			if pending != nil && !pending.Has(id) {
+				return fmt.Errorf("block %s has no order", ib.GetHash())
+			}
+			continue
+		}
+		if other, ok := orders[order]; ok {
+			return fmt.Errorf("blocks %s and %s have the same order %d",
+				ib.GetHash(), bd.blocks[other].GetHash(), order)
+		}
+		orders[order] = id
+	}
+	for order := uint(0); order < uint(len(orders)); order++ {
+		if _, ok := orders[order]; !ok {
+			return fmt.Errorf("no block has the order %d of the %d "+
+				"ordered blocks", order, len(orders))
+		}
+	}
+	return nil
+}
+
+// checkBlueSet colors the block again from its parents and verifies that the
+// result matches the blue and red sets computed when it was added.
+func (ph *Phantom) checkBlueSet(pb *PhantomBlock) error {
+	if !pb.HasParents() {
+		return nil
+	}
+	block := *pb.Block
+	check := &PhantomBlock{&block, 0, NewIdSet(), NewIdSet()}
+	ph.updateBlockColor(check)
+	ph.updateBlockOrder(check)
+
+	if check.mainParent != pb.mainParent || check.blueNum != pb.blueNum {
+		return fmt.Errorf("block %s recomputed with main parent %d and %d "+
+			"blues, but has main parent %d and %d blues", pb.GetHash(),
+			check.mainParent, check.blueNum, pb.mainParent, pb.blueNum)
+	}
+	if !sameDiffAnticone(check.blueDiffAnticone, pb.blueDiffAnticone) ||
+		!sameDiffAnticone(check.redDiffAnticone, pb.redDiffAnticone) {
+		return fmt.Errorf("block %s recomputed with blue set %v and red "+
+			"set %v, but has blue set %v and red set %v", pb.GetHash(),
+			check.blueDiffAnticone.SortList(false),
+			check.redDiffAnticone.SortList(false),
+			pb.blueDiffAnticone.SortList(false),
+			pb.redDiffAnticone.SortList(false))
+	}
+	return nil
+}
+
+// checkDiffAnticone walks the anticone of the main tip again and verifies
+// that it matches the one maintained incrementally.
+func (ph *Phantom) checkDiffAnticone() error {
+	if ph.mainChain.tip == MaxId {
+		return nil
+	}
+	anticone := ph.bd.getAnticone(ph.bd.getBlockById(ph.mainChain.tip), nil)
+	if !anticone.IsEqual(ph.diffAnticone) {
+		return fmt.Errorf("main tip %d has the anticone %v, but %v is "+
+			"maintained", ph.mainChain.tip, anticone.SortList(false),
+			ph.diffAnticone.SortList(false))
+	}
+	return nil
+}
+
+// sameDiffAnticone returns whether two blue or red sets hold the same blocks
+// at the same indexes.
+func sameDiffAnticone(a, b *IdSet) bool {
+	if !a.IsEqual(b) {
+		return false
+	}
+	for id, index := range a.GetMap() {
+		if b.Get(id) != index {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package blockdag
+
+import (
+	"container/list"
+	"github.com/Qitmeer/qitmeer/common/hash"
+)
+
+// OrderChange describes how the order of the DAG changed when a block was
+// added.  The blocks which were ordered before and keep their order are left
+// out, so the detached blocks must be disconnected, from the last one to the
+// first one, before the attached blocks are connected in their new order.
+type OrderChange struct {
+	// Attached are the blocks which are ordered by the new block, in their
+	// new order.
+	Attached []IBlock
+
+	// Detached are the blocks whose order was undone by the new block, in
+	// their old order.
+	Detached []*BlockOrderHelp
+}
+
+func newOrderChange(news *list.List, olds *list.List) *OrderChange {
+	oc := &OrderChange{}
+	if news != nil {
+		oc.Attached = make([]IBlock, 0, news.Len())
+		for e := news.Front(); e != nil; e = e.Next() {
+			oc.Attached = append(oc.Attached, e.Value.(IBlock))
+		}
+	}
+	if olds != nil {
+		oc.Detached = make([]*BlockOrderHelp, 0, olds.Len())
+		for e := olds.Front(); e != nil; e = e.Next() {
+			oc.Detached = append(oc.Detached, e.Value.(*BlockOrderHelp))
+		}
+	}
+	return oc
+}
+
+// IsEmpty returns true if no block is attached, which means the block wasn't
+// added.
+func (oc *OrderChange) IsEmpty() bool {
+	return oc == nil || len(oc.Attached) == 0
+}
+
+// IsReorganize returns true if other blocks than the new one are attached, so
+// the chain must be reorganized.
+func (oc *OrderChange) IsReorganize() bool {
+	return len(oc.Attached) > 1
+}
+
+// AttachedHashes returns the hashes of the attached blocks in their new order.
+func (oc *OrderChange) AttachedHashes() []*hash.Hash {
+	result := make([]*hash.Hash, 0, len(oc.Attached))
+	for _, ib := range oc.Attached {
+		result = append(result, ib.GetHash())
+	}
+	return result
+}
+
+// DetachedHashes returns the hashes of the detached blocks in their old order.
+func (oc *OrderChange) DetachedHashes() []*hash.Hash {
+	result := make([]*hash.Hash, 0, len(oc.Detached))
+	for _, boh := range oc.Detached {
+		result = append(result, boh.Block.GetHash())
+	}
+	return result
+}
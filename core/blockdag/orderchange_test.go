@@ -0,0 +1,66 @@
+package blockdag
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Test_OrderChange checks that the order change of every added block attaches
+// the block, and that the ordered blocks it attaches and the blocks it
+// detaches follow each other in the order.
+func Test_OrderChange(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	blocks := shuffleDAG(r, randomDAG(r, orderingBlocks))
+	dag, cleanup, err := buildDAG(blocks[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	for _, tb := range blocks[1:] {
+		oc, ib, _ := dag.AddBlock(tb)
+		if oc.IsEmpty() {
+			t.Fatalf("block %s not added", tb.GetHash())
+		}
+		if err := dag.Commit(); err != nil {
+			t.Fatal(err)
+		}
+
+		attached := false
+		var last IBlock
+		for _, b := range oc.Attached {
+			if b == ib {
+				attached = true
+			}
+			if !b.IsOrdered() {
+				continue
+			}
+			if last != nil && b.GetOrder() != last.GetOrder()+1 {
+				t.Fatalf("block %s: %s is attached at %d after %d",
+					tb.GetHash(), b.GetHash(), b.GetOrder(), last.GetOrder())
+			}
+			last = b
+		}
+		if !attached {
+			t.Fatalf("block %s is not attached", tb.GetHash())
+		}
+		for i := 1; i < len(oc.Detached); i++ {
+			if oc.Detached[i].OldOrder != oc.Detached[i-1].OldOrder+1 {
+				t.Fatalf("block %s: %s is detached from %d after %d",
+					tb.GetHash(), oc.Detached[i].Block.GetHash(),
+					oc.Detached[i].OldOrder, oc.Detached[i-1].OldOrder)
+			}
+		}
+
+		for i, h := range oc.AttachedHashes() {
+			if !h.IsEqual(oc.Attached[i].GetHash()) {
+				t.Fatalf("attached hash %d is %s", i, h)
+			}
+		}
+		for i, h := range oc.DetachedHashes() {
+			if !h.IsEqual(oc.Detached[i].Block.GetHash()) {
+				t.Fatalf("detached hash %d is %s", i, h)
+			}
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package blockdag
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/config"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+const (
+	// orderingSeeds is the number of random DAGs built by
+	// Test_OrderingDifferential.
+	orderingSeeds = 8
+
+	// orderingBlocks is the number of blocks of every random DAG.
+	orderingBlocks = 60
+
+	// orderingNodes is the number of DAGs which receive the blocks of a
+	// random DAG, each in a different order.
+	orderingNodes = 3
+)
+
+// randomDAG returns the blocks of a random DAG, parents first, with random
+// hashes so that the tie-breaks are exercised.  The parents of every block
+// are tips of the DAG within MaxTipLayerGap layers of each other.
+func randomDAG(r *rand.Rand, size int) []*TestBlock {
+	newBlock := func(parents []*hash.Hash) *TestBlock {
+		tb := &TestBlock{parents: parents, timeStamp: 1}
+		r.Read(tb.hash[:])
+		return tb
+	}
+	blocks := []*TestBlock{newBlock(nil)}
+	layers := map[hash.Hash]int{blocks[0].hash: 0}
+	tips := []*TestBlock{blocks[0]}
+	for len(blocks) < size {
+		first := tips[r.Intn(len(tips))]
+		parents := []*hash.Hash{first.GetHash()}
+		minLayer, maxLayer := layers[first.hash], layers[first.hash]
+		for _, tip := range r.Perm(len(tips)) {
+			if len(parents) >= 3 || r.Intn(2) == 0 {
+				break
+			}
+			other := tips[tip]
+			layer := layers[other.hash]
+			if other == first || layer-minLayer > MaxTipLayerGap ||
+				maxLayer-layer > MaxTipLayerGap {
+				continue
+			}
+			parents = append(parents, other.GetHash())
+			if layer < minLayer {
+				minLayer = layer
+			}
+			if layer > maxLayer {
+				maxLayer = layer
+			}
+		}
+		tb := newBlock(parents)
+		layers[tb.hash] = maxLayer + 1
+		blocks = append(blocks, tb)
+
+		remaining := []*TestBlock{tb}
+		for _, tip := range tips {
+			referenced := false
+			for _, p := range parents {
+				if p.IsEqual(tip.GetHash()) {
+					referenced = true
+					break
+				}
+			}
+			if !referenced {
+				remaining = append(remaining, tip)
+			}
+		}
+		tips = remaining
+	}
+	return blocks
+}
+
+// shuffleDAG returns the blocks in a random order in which every block still
+// comes after its parents.
+func shuffleDAG(r *rand.Rand, blocks []*TestBlock) []*TestBlock {
+	added := map[hash.Hash]bool{}
+	pending := append([]*TestBlock{}, blocks...)
+	result := []*TestBlock{}
+	for len(pending) > 0 {
+		ready := []int{}
+		for i, tb := range pending {
+			isReady := true
+			for _, p := range tb.parents {
+				if !added[*p] {
+					isReady = false
+					break
+				}
+			}
+			if isReady {
+				ready = append(ready, i)
+			}
+		}
+		i := ready[r.Intn(len(ready))]
+		added[pending[i].hash] = true
+		result = append(result, pending[i])
+		pending = append(pending[:i], pending[i+1:]...)
+	}
+	return result
+}
+
+// buildDAG adds the blocks to a new DAG with its own database.
+func buildDAG(blocks []*TestBlock) (*BlockDAG, func(), error) {
+	return buildDAGWith(blocks, nil)
+}
+
+// buildDAGWith is buildDAG with a setup of the DAG before its Init, such as
+// its consensus parameters.
+func buildDAGWith(blocks []*TestBlock, setup func(*BlockDAG)) (*BlockDAG, func(), error) {
+	dir, err := ioutil.TempDir("", "blockdag")
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := loadBlockDB(&config.Config{DbType: "ffldb", DataDir: dir})
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	cleanup := func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+	dag := &BlockDAG{}
+	if setup != nil {
+		setup(dag)
+	}
+	dag.Init(phantom, CalcBlockWeight, -1, db, nil)
+	for _, tb := range blocks {
+		oc, _, _ := dag.AddBlock(tb)
+		if oc.IsEmpty() {
+			cleanup()
+			return nil, nil, fmt.Errorf("block %s not added", tb.GetHash())
+		}
+		err = dag.Commit()
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+	}
+	return dag, cleanup, nil
+}
+
+// Test_OrderingDifferential builds random DAGs on several nodes, each
+// receiving the blocks in a different order, and checks that all the nodes
+// give every block the same order and color.
+func Test_OrderingDifferential(t *testing.T) {
+	for seed := int64(1); seed <= orderingSeeds; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		blocks := randomDAG(r, orderingBlocks)
+
+		var orders map[hash.Hash]uint
+		var blues map[hash.Hash]bool
+		for node := 0; node < orderingNodes; node++ {
+			received := blocks
+			if node > 0 {
+				received = shuffleDAG(r, blocks)
+			}
+			dag, cleanup, err := buildDAG(received)
+			if err != nil {
+				t.Fatalf("seed %d node %d: %v", seed, node, err)
+			}
+			nodeOrders := map[hash.Hash]uint{}
+			nodeBlues := map[hash.Hash]bool{}
+			for _, tb := range blocks {
+				nodeOrders[tb.hash] = dag.GetBlockOrder(tb.GetHash())
+				nodeBlues[tb.hash] = dag.IsBlueBlock(tb.GetHash())
+			}
+			cleanup()
+
+			if node == 0 {
+				orders, blues = nodeOrders, nodeBlues
+				continue
+			}
+			for _, tb := range blocks {
+				if nodeOrders[tb.hash] != orders[tb.hash] {
+					t.Fatalf("seed %d node %d: block %s has order %d, "+
+						"expected %d", seed, node, tb.GetHash(),
+						nodeOrders[tb.hash], orders[tb.hash])
+				}
+				if nodeBlues[tb.hash] != blues[tb.hash] {
+					t.Fatalf("seed %d node %d: block %s differs in color",
+						seed, node, tb.GetHash())
+				}
+			}
+		}
+	}
+}
+
+func Test_SortHashList(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	set := NewIdSet()
+	for id := uint(0); id < 20; id++ {
+		b := &Block{id: id}
+		r.Read(b.hash[:])
+		set.AddPair(id, b)
+	}
+	list := set.SortHashList(false)
+	for i := 1; i < len(list); i++ {
+		prev := set.Get(list[i-1]).(IBlock).GetHash()
+		cur := set.Get(list[i]).(IBlock).GetHash()
+		if !prev.Less(cur) || prev.String() > cur.String() {
+			t.Fatalf("%s is sorted before %s", prev, cur)
+		}
+	}
+}
@@ -15,12 +15,12 @@ type PhantomBlock struct {
 
 func (pb *PhantomBlock) IsBluer(other *PhantomBlock) bool {
 	if pb.blueNum > other.blueNum ||
-		(pb.blueNum == other.blueNum && pb.GetHash().String() < other.GetHash().String()) {
+		(pb.blueNum == other.blueNum && pb.GetHash().Less(other.GetHash())) {
 		return true
 	}
 
 	if pb.blueNum == other.blueNum &&
-		pb.GetHash().String() < other.GetHash().String() {
+		pb.GetHash().Less(other.GetHash()) {
 		return true
 	}
 	return false
@@ -40,7 +40,7 @@ func (pb *PhantomBlock) Encode(w io.Writer) error {
 	// blueDiffAnticone
 	blueDiffAnticone := []uint{}
 	if pb.blueDiffAnticone != nil && pb.blueDiffAnticone.Size() > 0 {
-		blueDiffAnticone = pb.blueDiffAnticone.List()
+		blueDiffAnticone = pb.blueDiffAnticone.SortList(false)
 	}
 	blueDiffAnticoneSize := len(blueDiffAnticone)
 	err = s.WriteElements(w, uint32(blueDiffAnticoneSize))
@@ -61,7 +61,7 @@ func (pb *PhantomBlock) Encode(w io.Writer) error {
 	// redDiffAnticone
 	redDiffAnticone := []uint{}
 	if pb.redDiffAnticone != nil && pb.redDiffAnticone.Size() > 0 {
-		redDiffAnticone = pb.redDiffAnticone.List()
+		redDiffAnticone = pb.redDiffAnticone.SortList(false)
 	}
 	redDiffAnticoneSize := len(redDiffAnticone)
 	err = s.WriteElements(w, uint32(redDiffAnticoneSize))
@@ -10,12 +10,25 @@ import (
 	"github.com/Qitmeer/qitmeer/core/types"
 	"github.com/Qitmeer/qitmeer/database"
 	"io"
+	"runtime"
+	"sync"
 )
 
 var (
 	BlockRate = anticone.DefaultBlockRate
 )
 
+const (
+	// minParallelColoring is the size of the diff anticone from which its
+	// blocks are colored by a pool of workers.  The smaller ones are
+	// colored faster than the workers are started.
+	minParallelColoring = 64
+
+	// maxColoringWorkers is the maximum number of workers coloring a diff
+	// anticone.
+	maxColoringWorkers = 8
+)
+
 type Phantom struct {
 	// The general foundation framework of DAG
 	bd *BlockDAG
@@ -38,6 +51,9 @@ func (ph *Phantom) GetName() string {
 func (ph *Phantom) Init(bd *BlockDAG) bool {
 	ph.bd = bd
 	ph.anticoneSize = anticone.GetSize(anticone.BlockDelay, bd.blockRate, anticone.SecurityLevel)
+	if bd.anticoneSize > 0 {
+		ph.anticoneSize = bd.anticoneSize
+	}
 
 	if log != nil {
 		log.Info(fmt.Sprintf("anticone size:%d", ph.anticoneSize))
@@ -120,13 +136,15 @@ func (ph *Phantom) getExtremeBlue(bs *IdSet, bluest bool) *PhantomBlock {
 
 func (ph *Phantom) calculateBlueSet(pb *PhantomBlock, diffAnticone *IdSet) {
 	kc := ph.getKChain(pb)
+	blocks := make([]*PhantomBlock, 0, diffAnticone.Size())
 	for _, v := range diffAnticone.GetMap() {
 		cur, ok := v.(*PhantomBlock)
 		if !ok {
 			panic("phantom block type is error.")
 		}
-		ph.colorBlock(kc, cur, pb.blueDiffAnticone, pb.redDiffAnticone)
+		blocks = append(blocks, cur)
 	}
+	ph.colorBlocks(kc, blocks, pb.blueDiffAnticone, pb.redDiffAnticone)
 	if diffAnticone.Size() != pb.blueDiffAnticone.Size()+pb.redDiffAnticone.Size() {
 		log.Error(fmt.Sprintf("error blue set"))
 	}
@@ -135,13 +153,14 @@ func (ph *Phantom) calculateBlueSet(pb *PhantomBlock, diffAnticone *IdSet) {
 
 func (ph *Phantom) getKChain(pb *PhantomBlock) *KChain {
 	var blueCount int = 0
+	anticoneSize := ph.getAnticoneSize(pb.height)
 	result := &KChain{NewIdSet(), 0}
 	curPb := pb
 	for {
 		result.blocks.AddPair(curPb.GetID(), curPb)
 		result.miniLayer = curPb.GetLayer()
 		blueCount += curPb.blueDiffAnticone.Size()
-		if blueCount > ph.anticoneSize || curPb.mainParent == MaxId {
+		if blueCount > anticoneSize || curPb.mainParent == MaxId {
 			break
 		}
 		curPb = ph.getBlock(curPb.mainParent)
@@ -149,6 +168,48 @@ func (ph *Phantom) getKChain(pb *PhantomBlock) *KChain {
 	return result
 }
 
+// colorBlocks colors the blocks of a diff anticone.  The large diff anticones
+// of the wide DAGs are colored by a pool of workers.
+func (ph *Phantom) colorBlocks(kc *KChain, blocks []*PhantomBlock, blueOrder *IdSet, redOrder *IdSet) {
+	workers := runtime.NumCPU()
+	if workers > maxColoringWorkers {
+		workers = maxColoringWorkers
+	}
+	if len(blocks) < minParallelColoring || workers < 2 {
+		for _, b := range blocks {
+			ph.colorBlock(kc, b, blueOrder, redOrder)
+		}
+		return
+	}
+	ph.colorBlocksParallel(kc, blocks, workers, blueOrder, redOrder)
+}
+
+// colorBlocksParallel colors the blocks with the given number of workers.  The
+// coloring rule of a block only reads the DAG, so that the workers share it
+// without locking, and their colors are merged into the sets afterwards.
+func (ph *Phantom) colorBlocksParallel(kc *KChain, blocks []*PhantomBlock, workers int, blueOrder *IdSet, redOrder *IdSet) {
+	blue := make([]bool, len(blocks))
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(blocks); i += workers {
+				blue[i] = ph.coloringRule(kc, blocks[i])
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for i, b := range blocks {
+		if blue[i] {
+			blueOrder.Add(b.GetID())
+		} else {
+			redOrder.Add(b.GetID())
+		}
+	}
+}
+
 func (ph *Phantom) colorBlock(kc *KChain, pb *PhantomBlock, blueOrder *IdSet, redOrder *IdSet) {
 	if ph.coloringRule(kc, pb) {
 		blueOrder.Add(pb.GetID())
@@ -307,9 +368,20 @@ func (ph *Phantom) updateMainChain(buestTip *PhantomBlock, pb *PhantomBlock) (*P
 	ph.rollBackMainChain(intersection)
 
 	ph.updateMainOrder(path, intersection)
+	oldTip := ph.mainChain.tip
 	ph.mainChain.tip = buestTip.GetID()
 
-	ph.diffAnticone = ph.bd.getAnticone(ph.bd.getBlockById(ph.mainChain.tip), nil)
+	// The new block extending the main chain merges its blue and red sets
+	// into its past, so the anticone of the old tip without them is the
+	// anticone of the new block.  The anticone is only walked again when
+	// the main chain is reorganized, which keeps adding a block
+	// proportional to its merge set instead of the size of the DAG.
+	if buestTip == pb && pb.mainParent == oldTip {
+		ph.diffAnticone.RemoveSet(pb.blueDiffAnticone)
+		ph.diffAnticone.RemoveSet(pb.redDiffAnticone)
+	} else {
+		ph.diffAnticone = ph.bd.getAnticone(ph.bd.getBlockById(ph.mainChain.tip), nil)
+	}
 
 	changeOrder := intersectionBlock.GetOrder() + 1
 
@@ -617,9 +689,7 @@ func (ph *Phantom) Load(dbTx database.Tx) error {
 
 		ph.bd.updateTips(ib)
 		//
-		if !ib.IsOrdered() {
-			ph.diffAnticone.AddPair(ib.GetID(), ib)
-		} else {
+		if ib.IsOrdered() {
 			// check order index
 			id, err := DBGetBlockIdByOrder(dbTx, ib.GetOrder())
 			if err != nil {
@@ -633,6 +703,9 @@ func (ph *Phantom) Load(dbTx database.Tx) error {
 	}
 
 	ph.mainChain.tip = ph.GetMainParent(ph.bd.tips).GetID()
+	// The anticone of the main tip is maintained incrementally by AddBlock
+	// from now on, so it is walked once here.
+	ph.diffAnticone = ph.bd.getAnticone(ph.bd.getBlockById(ph.mainChain.tip), nil)
 	return ph.CheckMainChainDB(dbTx)
 }
 
@@ -753,6 +826,9 @@ func (ph *Phantom) GetMainParentConcurrency(b IBlock) int {
 
 func (ph *Phantom) getMaxParents() int {
 	dagMax := ph.anticoneSize + 1
+	if tip := ph.getBlock(ph.mainChain.tip); tip != nil {
+		dagMax = ph.getAnticoneSize(tip.height+1) + 1
+	}
 	if dagMax < types.MaxParentsPerBlock {
 		return dagMax
 	}
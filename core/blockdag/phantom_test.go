@@ -1,8 +1,10 @@
 package blockdag
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/database"
 	_ "github.com/Qitmeer/qitmeer/database/ffldb"
 	"strconv"
 	"testing"
@@ -159,6 +161,23 @@ func Test_IsOnMainChain(t *testing.T) {
 	}
 }
 
+func Test_GetMainChainBlockByHeight(t *testing.T) {
+	ibd := InitBlockDAG(phantom, "PH_fig2-blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	mainTip := bd.GetMainChainTip()
+	for cur := mainTip; cur != nil; cur = bd.GetBlockById(cur.GetMainParent()) {
+		ib := bd.GetMainChainBlockByHeight(cur.GetHeight())
+		if ib == nil || ib.GetID() != cur.GetID() {
+			t.Fatalf("main chain block at height %d is not %s", cur.GetHeight(), getBlockTag(cur.GetID()))
+		}
+	}
+	if bd.GetMainChainBlockByHeight(mainTip.GetHeight()+1) != nil {
+		t.Fatalf("found a main chain block above the main chain tip")
+	}
+}
+
 func Test_LocateBlocks(t *testing.T) {
 	ibd := InitBlockDAG(phantom, "PH_fig2-blocks")
 	if ibd == nil {
@@ -226,8 +245,8 @@ func Test_IsDAG(t *testing.T) {
 		parents = append(parents, tbMap[parent].GetHash())
 	}
 	block := buildBlock(parents)
-	l, _, ib, _ := bd.AddBlock(block)
-	if l != nil && l.Len() > 0 {
+	oc, ib, _ := bd.AddBlock(block)
+	if !oc.IsEmpty() {
 		tbMap["L"] = ib
 	} else {
 		t.Fatalf("Error:%d  L\n", tempHash)
@@ -321,8 +340,8 @@ func Test_Rollback(t *testing.T) {
 	parents = append(parents, tbMap["G"].GetHash())
 
 	block := buildBlock(parents)
-	l, _, ib, _ := bd.AddBlock(block)
-	if l != nil && l.Len() > 0 {
+	oc, ib, _ := bd.AddBlock(block)
+	if !oc.IsEmpty() {
 		tbMap["L"] = ib
 	} else {
 		t.Fatalf("Error:%d  L\n", tempHash)
@@ -350,3 +369,306 @@ func Test_Rollback(t *testing.T) {
 		t.Fatalf("Roll back error")
 	}
 }
+
+func Test_CheckInvariants(t *testing.T) {
+	ibd := InitBlockDAG(phantom, "PH_fig4-blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	ph := ibd.(*Phantom)
+	for tag, ib := range tbMap {
+		if err := bd.CheckInvariants(ib.GetHash()); err != nil {
+			t.Fatalf("%s: %v", tag, err)
+		}
+	}
+
+	// A duplicate order breaks the invariants.
+	tip := ph.getBlock(ph.GetMainChainTipId())
+	order := tip.GetOrder()
+	tip.SetOrder(0)
+	if err := bd.CheckInvariants(tip.GetHash()); err == nil {
+		t.Errorf("duplicate order not detected")
+	}
+	tip.SetOrder(order)
+
+	// So does a wrong blue count.
+	tip.blueNum++
+	if err := bd.CheckInvariants(tip.GetHash()); err == nil {
+		t.Errorf("wrong blue count not detected")
+	}
+	tip.blueNum--
+}
+
+func Test_IncrementalDiffAnticone(t *testing.T) {
+	ibd := InitBlockDAG(phantom, "PH_fig2-blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	ph := ibd.(*Phantom)
+	if err := ph.checkDiffAnticone(); err != nil {
+		t.Fatal(err)
+	}
+
+	addBlock := func(parents ...*hash.Hash) IBlock {
+		_, ib, _ := bd.AddBlock(buildBlock(parents))
+		if ib == nil {
+			t.Fatalf("block with parents %v not added", parents)
+		}
+		if err := bd.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		if err := ph.checkDiffAnticone(); err != nil {
+			t.Fatal(err)
+		}
+		return ib
+	}
+
+	// Extend the main chain while merging the other tips, then fork from
+	// the main parent of the tip, so that the anticone is walked again
+	// once the fork becomes the main chain.
+	addBlock(bd.GetTips().SortList(false)...)
+	tip := ph.getBlock(ph.GetMainChainTipId())
+	fork := addBlock(ph.getBlock(tip.mainParent).GetHash())
+	addBlock(addBlock(fork.GetHash()).GetHash())
+}
+
+func Test_LoadTips(t *testing.T) {
+	ibd := InitBlockDAG(phantom, "PH_fig2-blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	// The chain marks the attached blocks valid, which stores the orders
+	// changed by the reorganizations in the block index.
+	for id := uint(0); id < bd.GetBlockTotal(); id++ {
+		bd.ValidBlock(bd.GetBlockById(id))
+	}
+	if err := bd.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	load := func() (*BlockDAG, error) {
+		loaded := &BlockDAG{}
+		loaded.Init(phantom, CalcBlockWeight, -1, bd.db,
+			func(*hash.Hash) IBlockData { return nil })
+		err := bd.db.View(func(dbTx database.Tx) error {
+			return loaded.Load(dbTx, bd.GetBlockTotal(), bd.GetGenesisHash())
+		})
+		return loaded, err
+	}
+	loaded, err := load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.GetTips().IsEqual(bd.GetTips()) {
+		t.Fatalf("loaded tips %v, expected %v", loaded.GetTips().List(),
+			bd.GetTips().List())
+	}
+
+	// The tips rebuilt from the block index must match the stored ones.
+	err = bd.db.Update(func(dbTx database.Tx) error {
+		return DBPutDAGTips(dbTx, []uint{0})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := load(); err == nil {
+		t.Fatal("inconsistent tips loaded")
+	}
+}
+
+func Test_GetBlockOrders(t *testing.T) {
+	ibd := InitBlockDAG(phantom, "PH_fig2-blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	hs := []*hash.Hash{}
+	expected := []uint{}
+	for _, ib := range tbMap {
+		hs = append(hs, ib.GetHash())
+		expected = append(expected, bd.GetBlock(ib.GetHash()).GetOrder())
+	}
+	hs = append(hs, &hash.ZeroHash, nil)
+	expected = append(expected, MaxBlockOrder, MaxBlockOrder)
+
+	orders := bd.GetBlockOrders(hs)
+	for i := range hs {
+		if orders[i] != expected[i] {
+			t.Errorf("order of %v is %d, expected %d", hs[i], orders[i],
+				expected[i])
+		}
+	}
+	if order := bd.GetBlockOrder(tbMap["A"].GetHash()); order != 0 {
+		t.Errorf("order of the genesis is %d, expected 0", order)
+	}
+}
+
+func Test_BlueState(t *testing.T) {
+	ibd := InitBlockDAG(phantom, "PH_fig2-blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	for tag, ib := range tbMap {
+		if bd.IsBlueBlock(ib.GetHash()) != bd.IsBlue(ib.GetID()) {
+			t.Errorf("blue state of %s differs by hash and by id", tag)
+		}
+	}
+	if bd.IsBlueBlock(&hash.ZeroHash) {
+		t.Error("unknown block is blue")
+	}
+
+	blueScore, err := bd.GetBlueScore(tbMap[testData.PH_MPConcurrency.Input].GetHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blueScore != uint(testData.PH_BConcurrency.Output) {
+		t.Fatalf("blue score is %d, expected %d", blueScore,
+			testData.PH_BConcurrency.Output)
+	}
+	if _, err := bd.GetBlueScore(&hash.ZeroHash); err == nil {
+		t.Error("no error for the blue score of an unknown block")
+	}
+}
+
+func Test_DAGState(t *testing.T) {
+	ibd := InitBlockDAG(phantom, "PH_fig2-blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	state, err := bd.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := state.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	decoded := &DAGState{}
+	if err := decoded.Decode(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := &BlockDAG{}
+	restored.Init(phantom, CalcBlockWeight, -1, bd.db, nil)
+	if err := restored.Restore(decoded); err != nil {
+		t.Fatal(err)
+	}
+	for _, tb := range tbMap {
+		ib := restored.GetBlockById(tb.GetID())
+		if ib == nil || !ib.GetHash().IsEqual(tb.GetHash()) {
+			t.Fatalf("block %s is missing", tb.GetHash())
+		}
+		if ib.GetOrder() != tb.GetOrder() || ib.GetLayer() != tb.GetLayer() ||
+			restored.IsBlue(ib.GetID()) != bd.IsBlue(tb.GetID()) {
+			t.Fatalf("block %s differs after the restore", tb.GetHash())
+		}
+	}
+	if !restored.GetMainChainTip().GetHash().IsEqual(bd.GetMainChainTip().GetHash()) {
+		t.Fatal("main chain tip differs after the restore")
+	}
+
+	// The snapshot of the restored DAG is the same.
+	state, err = restored.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rbuf bytes.Buffer
+	if err := state.Encode(&rbuf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), rbuf.Bytes()) {
+		t.Fatal("snapshot is not deterministic")
+	}
+
+	buf.Bytes()[0] = DAGStateVersion + 1
+	if err := decoded.Decode(bytes.NewReader(buf.Bytes())); err == nil {
+		t.Fatal("decoded a snapshot of an unknown version")
+	}
+}
+
+// buildWideDAG adds width chains of depth blocks on top of the main chain tip
+// and a block merging them, and returns the merging block.
+func buildWideDAG(width int, depth int) IBlock {
+	base := bd.GetMainChainTip().GetHash()
+	tips := []*hash.Hash{}
+	for w := 0; w < width; w++ {
+		parent := base
+		for d := 0; d < depth; d++ {
+			block := buildBlock([]*hash.Hash{parent})
+			bd.AddBlock(block)
+			if err := bd.Commit(); err != nil {
+				return nil
+			}
+			parent = block.GetHash()
+		}
+		tips = append(tips, parent)
+	}
+	_, ib, _ := bd.AddBlock(buildBlock(tips))
+	if err := bd.Commit(); err != nil {
+		return nil
+	}
+	return ib
+}
+
+// wideDiffAnticone returns the k-chain and the blocks of the diff anticone of
+// a block merging a wide DAG.
+func wideDiffAnticone(t testing.TB, ph *Phantom) (*KChain, []*PhantomBlock) {
+	ib := buildWideDAG(16, 8)
+	if ib == nil {
+		t.Fatal("failed to build the wide DAG")
+	}
+	pb := ph.getBlock(ib.GetID())
+	diffAnticone := ph.bd.getDiffAnticone(pb, true)
+	blocks := []*PhantomBlock{}
+	for _, v := range diffAnticone.GetMap() {
+		blocks = append(blocks, v.(*PhantomBlock))
+	}
+	if len(blocks) < minParallelColoring {
+		t.Fatalf("got a diff anticone of %d blocks, want at least %d",
+			len(blocks), minParallelColoring)
+	}
+	return ph.getKChain(pb), blocks
+}
+
+func Test_ColorBlocksParallel(t *testing.T) {
+	ibd := InitBlockDAG(phantom, "PH_fig2-blocks")
+	if ibd == nil {
+		t.FailNow()
+	}
+	ph := ibd.(*Phantom)
+	kc, blocks := wideDiffAnticone(t, ph)
+
+	blue, red := NewIdSet(), NewIdSet()
+	for _, b := range blocks {
+		ph.colorBlock(kc, b, blue, red)
+	}
+	for _, workers := range []int{2, 3, 8} {
+		pblue, pred := NewIdSet(), NewIdSet()
+		ph.colorBlocksParallel(kc, blocks, workers, pblue, pred)
+		if !pblue.IsEqual(blue) || !pred.IsEqual(red) {
+			t.Fatalf("coloring with %d workers differs: %d/%d blue/red blocks, "+
+				"want %d/%d", workers, pblue.Size(), pred.Size(), blue.Size(), red.Size())
+		}
+	}
+}
+
+func Benchmark_ColorBlocks(b *testing.B) {
+	ibd := InitBlockDAG(phantom, "PH_fig2-blocks")
+	if ibd == nil {
+		b.FailNow()
+	}
+	ph := ibd.(*Phantom)
+	kc, blocks := wideDiffAnticone(b, ph)
+
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			blue, red := NewIdSet(), NewIdSet()
+			for _, pb := range blocks {
+				ph.colorBlock(kc, pb, blue, red)
+			}
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ph.colorBlocks(kc, blocks, NewIdSet(), NewIdSet())
+		}
+	})
+}
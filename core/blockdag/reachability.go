@@ -0,0 +1,355 @@
+package blockdag
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+const (
+	// reachabilityAllocDivisor defines the part of the free interval of a
+	// block kept for its later children: a new child is allocated all of
+	// it but its 1/reachabilityAllocDivisor, so that a chain of main
+	// parents goes deep before the intervals are reindexed.
+	reachabilityAllocDivisor = 64
+
+	// reachabilityReindexSlack is the minimum ratio of the interval size of
+	// a block to the size of its subtree for its subtree to be reindexed.
+	reachabilityReindexSlack = 2
+)
+
+// reachabilityNode is the reachability data of a block.  The blocks form a
+// tree along their main parents, and every block has an interval containing
+// the intervals of its descendants in the tree, so a block is a tree
+// ancestor of another when its interval contains the interval of the other.
+// The end of an interval is never allocated to the children, so the interval
+// of a block differs from the intervals of its descendants.
+//
+// The future covering set of a block holds the blocks of its future whose
+// main parent is not in its future, ordered by interval.  They are not in the
+// tree of each other, and every block of the future of the block is in the
+// tree of the block or of a block of the set.
+type reachabilityNode struct {
+	id             uint
+	start          uint64
+	end            uint64
+	parent         *reachabilityNode
+	children       []*reachabilityNode
+	futureCovering []*reachabilityNode
+}
+
+// isTreeAncestorOf returns whether the node is the other node or one of its
+// ancestors in the tree of main parents.
+func (n *reachabilityNode) isTreeAncestorOf(other *reachabilityNode) bool {
+	return n.start <= other.start && other.end <= n.end
+}
+
+// reachability is an index answering whether a block is in the past of
+// another in O(log n), by interval labeling of the tree of main parents and
+// future covering sets.  It is maintained incrementally as blocks are added,
+// the intervals of a subtree being reindexed only when a block has no room
+// left for a new child.
+type reachability struct {
+	nodes []*reachabilityNode
+}
+
+func newReachability() *reachability {
+	return &reachability{}
+}
+
+// getNode returns the node of the block, or nil.
+func (r *reachability) getNode(id uint) *reachabilityNode {
+	if id >= uint(len(r.nodes)) {
+		return nil
+	}
+	return r.nodes[id]
+}
+
+// isAncestor returns whether the block a is in the past of the block b.
+func (r *reachability) isAncestor(a uint, b uint) bool {
+	na := r.getNode(a)
+	nb := r.getNode(b)
+	if na == nil || nb == nil || na == nb {
+		return false
+	}
+	return r.isNodeAncestor(na, nb)
+}
+
+func (r *reachability) isNodeAncestor(na *reachabilityNode, nb *reachabilityNode) bool {
+	if na.isTreeAncestorOf(nb) {
+		return true
+	}
+	// The intervals of the future covering set are disjoint, so only the
+	// last one starting before the block may contain it.
+	fcs := na.futureCovering
+	i := sort.Search(len(fcs), func(i int) bool {
+		return fcs[i].start > nb.start
+	})
+	return i > 0 && fcs[i-1].isTreeAncestorOf(nb)
+}
+
+// addBlock adds the block, which must be the block following the last one
+// added, under its main parent.  The merge set holds the blocks of its past
+// which are neither its main parent nor in the past of its main parent.
+func (r *reachability) addBlock(id uint, mainParent uint, mergeset []uint) error {
+	if id != uint(len(r.nodes)) {
+		return fmt.Errorf("block %d added to the reachability index after "+
+			"block %d", id, len(r.nodes)-1)
+	}
+	node := &reachabilityNode{id: id}
+	parent := r.getNode(mainParent)
+	if parent == nil {
+		if id != GenesisId {
+			return fmt.Errorf("main parent %d of block %d is not in the "+
+				"reachability index", mainParent, id)
+		}
+		node.start = 1
+		node.end = math.MaxUint64 - 1
+		r.nodes = append(r.nodes, node)
+		return nil
+	}
+	r.addTreeChild(parent, node)
+	r.nodes = append(r.nodes, node)
+
+	for _, m := range mergeset {
+		mn := r.getNode(m)
+		if mn == nil {
+			return fmt.Errorf("block %d of the merge set of block %d is not "+
+				"in the reachability index", m, id)
+		}
+		r.addFutureCovering(mn, node)
+	}
+	return nil
+}
+
+// removeLastBlock removes the last block added, along with its merge set.
+func (r *reachability) removeLastBlock(mergeset []uint) {
+	if len(r.nodes) == 0 {
+		return
+	}
+	node := r.nodes[len(r.nodes)-1]
+	r.nodes[len(r.nodes)-1] = nil
+	r.nodes = r.nodes[:len(r.nodes)-1]
+	if node.parent != nil {
+		children := node.parent.children
+		if len(children) > 0 && children[len(children)-1] == node {
+			children[len(children)-1] = nil
+			node.parent.children = children[:len(children)-1]
+		}
+	}
+	for _, m := range mergeset {
+		mn := r.getNode(m)
+		if mn == nil {
+			continue
+		}
+		for i, fn := range mn.futureCovering {
+			if fn == node {
+				mn.futureCovering = append(mn.futureCovering[:i],
+					mn.futureCovering[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// addFutureCovering adds the new block to the future covering set of the
+// block of its merge set, unless it is in the tree of a block of the set.
+func (r *reachability) addFutureCovering(mn *reachabilityNode, node *reachabilityNode) {
+	fcs := mn.futureCovering
+	i := sort.Search(len(fcs), func(i int) bool {
+		return fcs[i].start > node.start
+	})
+	if i > 0 && fcs[i-1].isTreeAncestorOf(node) {
+		return
+	}
+	fcs = append(fcs, nil)
+	copy(fcs[i+1:], fcs[i:])
+	fcs[i] = node
+	mn.futureCovering = fcs
+}
+
+// addTreeChild adds the node as the last child of its main parent and
+// allocates its interval after the intervals of the other children, or
+// reindexes the subtree of an ancestor when there is no room left.
+func (r *reachability) addTreeChild(parent *reachabilityNode, node *reachabilityNode) {
+	start := parent.start
+	if len(parent.children) > 0 {
+		start = parent.children[len(parent.children)-1].end + 1
+	}
+	node.parent = parent
+	parent.children = append(parent.children, node)
+
+	// The children are allocated [start, parent.end - 1].
+	if start < parent.end {
+		free := parent.end - start
+		size := free - free/reachabilityAllocDivisor
+		node.start = start
+		node.end = start + size - 1
+		return
+	}
+	r.reindex(parent)
+}
+
+// subtreeSize returns the number of blocks in the tree of the node.
+func subtreeSize(node *reachabilityNode) uint64 {
+	size := uint64(0)
+	stack := []*reachabilityNode{node}
+	for len(stack) > 0 {
+		cur := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		size++
+		stack = append(stack, cur.children...)
+	}
+	return size
+}
+
+// reindex reallocates the intervals of the tree of the lowest ancestor of the
+// node whose interval is large enough for its tree, in proportion to the
+// sizes of the subtrees.  The children keep their order, so the intervals of
+// the future covering sets stay ordered.
+func (r *reachability) reindex(node *reachabilityNode) {
+	root := node
+	size := subtreeSize(node)
+	for root.parent != nil &&
+		root.end-root.start+1 < reachabilityReindexSlack*size {
+		parent := root.parent
+		parentSize := uint64(1)
+		for _, child := range parent.children {
+			if child == root {
+				parentSize += size
+			} else {
+				parentSize += subtreeSize(child)
+			}
+		}
+		root, size = parent, parentSize
+	}
+
+	// Compute the sizes of all the subtrees, children after parents in
+	// the walk so they are summed in the reverse order.
+	sizes := make(map[*reachabilityNode]uint64)
+	walk := []*reachabilityNode{root}
+	for i := 0; i < len(walk); i++ {
+		walk = append(walk, walk[i].children...)
+	}
+	for i := len(walk) - 1; i >= 0; i-- {
+		s := uint64(1)
+		for _, child := range walk[i].children {
+			s += sizes[child]
+		}
+		sizes[walk[i]] = s
+	}
+
+	// Every interval is at least as large as its subtree, so each child
+	// gets at least the size of its subtree.
+	for _, cur := range walk {
+		if len(cur.children) == 0 {
+			continue
+		}
+		available := cur.end - cur.start
+		total := sizes[cur] - 1
+		start := cur.start
+		for _, child := range cur.children {
+			hi, lo := bits.Mul64(available, sizes[child])
+			share, _ := bits.Div64(hi, lo, total)
+			child.start = start
+			child.end = start + share - 1
+			start += share
+		}
+	}
+	log.Trace(fmt.Sprintf("Reindexed the reachability of %d blocks under "+
+		"block %d", len(walk), root.id))
+}
+
+// getMergeset returns the blocks of the past of the block which are neither
+// its main parent nor in the past of its main parent, using the index for
+// the blocks already added.
+func (bd *BlockDAG) getMergeset(b IBlock) []uint {
+	mainParent := b.GetMainParent()
+	if !b.HasParents() {
+		return nil
+	}
+	var mergeset []uint
+	visited := NewIdSet()
+	queue := b.GetParents().List()
+	for len(queue) > 0 {
+		id := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if visited.Has(id) {
+			continue
+		}
+		visited.Add(id)
+		if id == mainParent || bd.reachability.isAncestor(id, mainParent) {
+			continue
+		}
+		mergeset = append(mergeset, id)
+		ib := bd.getBlockById(id)
+		if ib != nil && ib.HasParents() {
+			queue = append(queue, ib.GetParents().List()...)
+		}
+	}
+	return mergeset
+}
+
+// addReachability adds the block to the reachability index.
+func (bd *BlockDAG) addReachability(b IBlock) {
+	err := bd.reachability.addBlock(b.GetID(), b.GetMainParent(), bd.getMergeset(b))
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to index the reachability of block %s: %v",
+			b.GetHash(), err))
+	}
+}
+
+// buildReachability builds the reachability index of the blocks loaded.
+func (bd *BlockDAG) buildReachability() error {
+	bd.reachability = newReachability()
+	for id := uint(0); id < bd.blockTotal; id++ {
+		ib := bd.getBlockById(id)
+		if ib == nil {
+			return fmt.Errorf("no block %d to index the reachability", id)
+		}
+		err := bd.reachability.addBlock(id, ib.GetMainParent(), bd.getMergeset(ib))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isReachabilityComplete returns whether every block of the DAG is in the
+// reachability index, which is not the case while a block is being added.
+func (bd *BlockDAG) isReachabilityComplete() bool {
+	return bd.reachability != nil &&
+		uint(len(bd.reachability.nodes)) == bd.blockTotal
+}
+
+// isAncestor returns whether the block a is in the past of the block b.
+func (bd *BlockDAG) isAncestor(a IBlock, b IBlock) bool {
+	return bd.reachability.isAncestor(a.GetID(), b.GetID())
+}
+
+// IsAncestor returns whether the block a is in the past of the block b, in
+// O(log n) with the reachability index.  It returns false when a block is
+// unknown or both are the same.
+//
+// This function is safe for concurrent access.
+func (bd *BlockDAG) IsAncestor(a *hash.Hash, b *hash.Hash) bool {
+	bd.stateLock.RLock()
+	defer bd.stateLock.RUnlock()
+
+	ia := bd.getBlock(a)
+	ib := bd.getBlock(b)
+	if ia == nil || ib == nil {
+		return false
+	}
+	return bd.isAncestor(ia, ib)
+}
+
+// IsInPast is IsAncestor, it returns whether the block a is in the past of
+// the block b.
+//
+// This function is safe for concurrent access.
+func (bd *BlockDAG) IsInPast(a *hash.Hash, b *hash.Hash) bool {
+	return bd.IsAncestor(a, b)
+}
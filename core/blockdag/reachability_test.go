@@ -0,0 +1,141 @@
+package blockdag
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+)
+
+// Test_IsAncestor checks the reachability index of a random DAG against the
+// whole pasts of its blocks.
+func Test_IsAncestor(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	dag, cleanup, err := buildDAG(randomDAG(r, orderingBlocks))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	for b := uint(0); b < dag.blockTotal; b++ {
+		ib := dag.getBlockById(b)
+		past := pastOf(ib)
+		for a := uint(0); a < dag.blockTotal; a++ {
+			ia := dag.getBlockById(a)
+			if dag.IsAncestor(ia.GetHash(), ib.GetHash()) != past.Has(a) {
+				t.Fatalf("block %d in the past of block %d is %v, expected %v",
+					a, b, !past.Has(a), past.Has(a))
+			}
+		}
+	}
+}
+
+// Test_ReachabilityReindex checks the index when the interval of the genesis
+// is so small that the intervals are reindexed many times.
+func Test_ReachabilityReindex(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	idx := newReachability()
+	if err := idx.addBlock(GenesisId, 0, nil); err != nil {
+		t.Fatal(err)
+	}
+	idx.nodes[GenesisId].end = 1 << 12
+
+	const size = 500
+	past := []map[uint]bool{{}}
+	for id := uint(1); id < size; id++ {
+		// Pick up to three parents among the last blocks, none of them
+		// in the past of another.
+		candidates := map[uint]bool{}
+		for i := 0; i < 1+r.Intn(3); i++ {
+			low := 0
+			if id > 8 {
+				low = int(id) - 8
+			}
+			candidates[uint(low+r.Intn(int(id)-low))] = true
+		}
+		var ps []uint
+		for p := range candidates {
+			redundant := false
+			for q := range candidates {
+				if past[q][p] {
+					redundant = true
+				}
+			}
+			if !redundant {
+				ps = append(ps, p)
+			}
+		}
+		mainParent := ps[r.Intn(len(ps))]
+		cur := map[uint]bool{}
+		for _, p := range ps {
+			cur[p] = true
+			for q := range past[p] {
+				cur[q] = true
+			}
+		}
+		var mergeset []uint
+		for q := range cur {
+			if q != mainParent && !past[mainParent][q] {
+				mergeset = append(mergeset, q)
+			}
+		}
+		if err := idx.addBlock(id, mainParent, mergeset); err != nil {
+			t.Fatal(err)
+		}
+		past = append(past, cur)
+	}
+
+	for b := uint(0); b < size; b++ {
+		for a := uint(0); a < size; a++ {
+			if idx.isAncestor(a, b) != past[b][a] {
+				t.Fatalf("block %d in the past of block %d is %v, expected %v",
+					a, b, !past[b][a], past[b][a])
+			}
+		}
+	}
+}
+
+// Test_FutureSetN checks the bounded future sets and the anticones computed
+// with the reachability index against the whole future sets.
+func Test_FutureSetN(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	dag, cleanup, err := buildDAG(randomDAG(r, orderingBlocks))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	for id := uint(0); id < dag.blockTotal; id++ {
+		ib := dag.getBlockById(id)
+		expected := NewIdSet()
+		dag.getFutureSet(expected, ib)
+
+		fs, complete := dag.GetFutureSetN(ib, expected.Size())
+		if !complete || !fs.IsEqual(expected) {
+			t.Fatalf("block %d has the future %v, expected %v", id,
+				fs.SortList(false), expected.SortList(false))
+		}
+		if expected.Size() > 0 {
+			fs, complete = dag.GetFutureSetN(ib, expected.Size()-1)
+			if complete || fs.Size() != expected.Size()-1 {
+				t.Fatalf("block %d has %d blocks of its future, expected %d",
+					id, fs.Size(), expected.Size()-1)
+			}
+		}
+
+		inFuture := func(id uint) bool {
+			return dag.reachability.isAncestor(ib.GetID(), id)
+		}
+		anticone := NewIdSet()
+		bs := NewIdSet()
+		bs.AddPair(ib.GetID(), ib)
+		for _, v := range dag.tips.GetMap() {
+			dag.recAnticone(context.Background(), bs, inFuture, anticone, v.(IBlock))
+		}
+		dag.anticoneCache.clean()
+		if !anticone.IsEqual(dag.getAnticone(ib, nil)) {
+			t.Fatalf("block %d has the anticone %v with the reachability "+
+				"index, expected %v", id, anticone.SortList(false),
+				dag.getAnticone(ib, nil).SortList(false))
+		}
+	}
+}
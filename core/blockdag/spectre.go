@@ -50,7 +50,7 @@ func (sp *Spectre) AddBlock(b IBlock) (*list.List, *list.List) {
 	sp.sblocks[block.hash] = &block
 
 	var result *list.List = list.New()
-	result.PushBack(block.GetHash())
+	result.PushBack(b)
 	return result, nil
 }
 
@@ -85,7 +85,7 @@ func (sp *Spectre) Votes() map[hash.Hash]bool {
 
 func (sp *Spectre) InitVote(b1 IBlock, b2 IBlock) (bool, error) {
 	sp.candidate1, sp.candidate2 = b1, b2
-	tiebreak := sp.candidate1.GetHash().String() < sp.candidate2.GetHash().String()
+	tiebreak := sp.candidate1.GetHash().Less(sp.candidate2.GetHash())
 
 	exist1 := sp.bd.hasBlockById(b1.GetID())
 	exist2 := sp.bd.hasBlockById(b2.GetID())
@@ -198,7 +198,7 @@ func (sp *Spectre) Vote(b1 IBlock, b2 IBlock) (bool, error) {
 		}
 	}
 
-	return sp.candidate1.GetHash().String() < sp.candidate2.GetHash().String(), nil
+	return sp.candidate1.GetHash().Less(sp.candidate2.GetHash()), nil
 }
 
 //  TODO: test if there is ancestor-descendant relationship between b1 and b2
@@ -477,7 +477,7 @@ func (sp *Spectre) followParents(virtualBlock IBlock) (bool, bool, error) {
 			continue
 		}
 		if !sp.hasVoted(ph) {
-			return true, sp.candidate1.GetHash().String() < sp.candidate2.GetHash().String(), fmt.Errorf("parent %v not ready", ph)
+			return true, sp.candidate1.GetHash().Less(sp.candidate2.GetHash()), fmt.Errorf("parent %v not ready", ph)
 		}
 		vote := -1
 		if !sp.votes[ph] {
@@ -714,7 +714,7 @@ func (sp *Spectre) voteFromPastSet(votedPast *BlockDAG, tips *HashSet) bool {
 	}
 
 	// break the tie
-	return sp.candidate1.GetHash().String() < sp.candidate2.GetHash().String()
+	return sp.candidate1.GetHash().Less(sp.candidate2.GetHash())
 }
 
 // add voter into voted past set
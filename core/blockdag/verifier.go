@@ -0,0 +1,297 @@
+package blockdag
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/metrics"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// verifyInterval is the interval between two batches of the verifier.
+	verifyInterval = 10 * time.Second
+
+	// verifyBatch is the maximum number of blocks verified in a batch, which
+	// bounds the time the DAG is locked by the verifier.
+	verifyBatch = 100
+)
+
+var (
+	verifyCheckpointGauge = metrics.NewGauge("chain/dagverify/checkpoint")
+	diffAnticoneDrift     = metrics.NewCounter("chain/dagverify/diffanticone")
+	blueNumDrift          = metrics.NewCounter("chain/dagverify/bluenum")
+)
+
+// VerifierStats are the results of the verifier so far.
+type VerifierStats struct {
+	// Checkpoint is the id below which all the blocks were verified.
+	Checkpoint uint
+
+	// DiffAnticoneDrifts is the number of blocks of which the blue and red
+	// sets do not hold exactly the blocks of the past of the block outside
+	// of the past of its main parent.
+	DiffAnticoneDrifts uint
+
+	// BlueNumDrifts is the number of blocks of which the blue count is
+	// inconsistent with the one of their main parent.
+	BlueNumDrifts uint
+
+	// BlueNumRepairs is the number of the inconsistent blue counts which
+	// were repaired, none unless the verifier repairs.
+	BlueNumRepairs uint
+}
+
+// Verifier checks in the background the blue count of the blocks, which is
+// derived from the blue count of the single main parent of a block plus the
+// blue blocks of its diff anticone.  The diff anticone is found by a bounded
+// walk of the DAG, so a drift on a complex DAG shape would propagate to all
+// the descendants of the block.
+//
+// The blocks are verified in the order of their ids, in which the parents of
+// a block come before it, so the blue count of a block is recomputed from the
+// one of its main parent which is already verified.  The diff anticone of the
+// block is computed again exactly from the past of the block and of its main
+// parent and compared with its blue and red sets.  The drifts are only logged
+// and counted, unless the verifier repairs the inconsistent blue counts and
+// saves them.  A repaired count is used by the blocks added afterwards, but
+// the blocks already ordered are not reordered, which is why repairing must
+// be asked for.
+type Verifier struct {
+	started  int32
+	shutdown int32
+
+	bd     *BlockDAG
+	repair bool
+
+	lock  sync.Mutex
+	stats VerifierStats
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// NewVerifier returns a new verifier of the DAG, which repairs the
+// inconsistent blue counts when repair is set.  Use Start to begin verifying.
+func NewVerifier(bd *BlockDAG, repair bool) *Verifier {
+	return &Verifier{
+		bd:     bd,
+		repair: repair,
+		quit:   make(chan struct{}),
+	}
+}
+
+// Start begins verifying the blocks from the genesis.
+func (v *Verifier) Start() {
+	if atomic.AddInt32(&v.started, 1) != 1 {
+		return
+	}
+	v.wg.Add(1)
+	go v.handler()
+}
+
+// Stop stops verifying and waits for the verifier to exit.
+func (v *Verifier) Stop() {
+	if atomic.AddInt32(&v.shutdown, 1) != 1 {
+		return
+	}
+	close(v.quit)
+	v.wg.Wait()
+}
+
+// Stats returns the results of the verifier so far.
+func (v *Verifier) Stats() VerifierStats {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	return v.stats
+}
+
+func (v *Verifier) handler() {
+	defer v.wg.Done()
+
+	ticker := time.NewTicker(verifyInterval)
+	defer ticker.Stop()
+	for {
+		// Catch up with the DAG batch after batch, then wait for new
+		// blocks.
+		for v.verifyBatch() == verifyBatch {
+			select {
+			case <-v.quit:
+				return
+			default:
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-v.quit:
+			return
+		}
+	}
+}
+
+// verifyBatch verifies up to verifyBatch blocks from the checkpoint and
+// returns how many were verified.
+func (v *Verifier) verifyBatch() int {
+	bd := v.bd
+	bd.stateLock.Lock()
+	defer bd.stateLock.Unlock()
+
+	ph, ok := bd.instance.(*Phantom)
+	if !ok {
+		return 0
+	}
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	count := 0
+	repaired := false
+	for ; count < verifyBatch && v.stats.Checkpoint < bd.blockTotal; count++ {
+		pb := ph.getBlock(v.stats.Checkpoint)
+		v.stats.Checkpoint++
+		if pb == nil {
+			continue
+		}
+		drift, blueNum := ph.verifyBlock(pb, v.repair)
+		if drift {
+			v.stats.DiffAnticoneDrifts++
+			diffAnticoneDrift.Inc(1)
+		}
+		if blueNum {
+			v.stats.BlueNumDrifts++
+			blueNumDrift.Inc(1)
+		}
+		if blueNum && v.repair {
+			v.stats.BlueNumRepairs++
+			bd.commitBlock.AddPair(pb.GetID(), pb)
+			repaired = true
+		}
+	}
+	verifyCheckpointGauge.Update(int64(v.stats.Checkpoint))
+	if repaired {
+		err := bd.commit()
+		if err != nil {
+			log.Error(fmt.Sprintf("Failed to save the repaired blue "+
+				"counts: %v", err))
+		}
+	}
+	return count
+}
+
+// verifyBlock compares the blue and red sets of the block with its exact diff
+// anticone, and its blue count with the one of its main parent plus the block
+// itself and its blue set, which replaces it when repair is set.  It returns
+// whether the sets drifted and whether the blue count was inconsistent.
+func (ph *Phantom) verifyBlock(pb *PhantomBlock, repair bool) (bool, bool) {
+	if pb.GetMainParent() == MaxId {
+		return false, false
+	}
+	mp := ph.getBlock(pb.GetMainParent())
+	if mp == nil {
+		return false, false
+	}
+
+	drift := false
+	colored := pb.blueDiffAnticone.Union(pb.redDiffAnticone)
+	diffAnticone := ph.bd.exactDiffAnticone(pb)
+	if !diffAnticone.IsEqual(colored) {
+		drift = true
+		log.Warn(fmt.Sprintf("Block %s has the diff anticone %v, but the "+
+			"blue and red sets %v", pb.GetHash(), diffAnticone.SortList(false),
+			colored.SortList(false)))
+	}
+
+	blueNum := mp.blueNum + 1 + uint(pb.blueDiffAnticone.Size())
+	if pb.blueNum == blueNum {
+		return drift, false
+	}
+	if !repair {
+		log.Warn(fmt.Sprintf("Block %s has %d blues, but its main parent "+
+			"%s and blue set make %d", pb.GetHash(), pb.blueNum,
+			mp.GetHash(), blueNum))
+		return drift, true
+	}
+	log.Warn(fmt.Sprintf("Block %s has %d blues, but its main parent %s "+
+		"and blue set make %d, repairing without reordering the blocks "+
+		"already ordered", pb.GetHash(), pb.blueNum, mp.GetHash(), blueNum))
+	pb.blueNum = blueNum
+	return drift, true
+}
+
+// exactDiffAnticone returns the blocks of the past of the block which are
+// neither its main parent nor in the past of its main parent, walking the
+// past of the main parent only down to the lowest layer of the blocks found.
+func (bd *BlockDAG) exactDiffAnticone(b IBlock) *IdSet {
+	result := NewIdSet()
+	mp := bd.getBlockById(b.GetMainParent())
+	if mp == nil {
+		return result
+	}
+
+	// mainPast is the main parent and the part of its past walked so far,
+	// and pending the blocks of mainPast of which the parents are not.
+	mainPast := NewIdSet()
+	mainPast.Add(mp.GetID())
+	pending := []IBlock{mp}
+	walkMainPast := func(floor uint) {
+		for {
+			var next []IBlock
+			walked := false
+			for _, ib := range pending {
+				if ib.GetLayer() < floor {
+					next = append(next, ib)
+					continue
+				}
+				walked = true
+				if !ib.HasParents() {
+					continue
+				}
+				for _, v := range ib.GetParents().GetMap() {
+					parent := v.(IBlock)
+					if mainPast.Has(parent.GetID()) {
+						continue
+					}
+					mainPast.Add(parent.GetID())
+					next = append(next, parent)
+				}
+			}
+			pending = next
+			if !walked {
+				return
+			}
+		}
+	}
+
+	var queue []IBlock
+	for _, v := range b.GetParents().GetMap() {
+		queue = append(queue, v.(IBlock))
+	}
+	for len(queue) > 0 {
+		// The layers decrease from a block to its parents, so the past of
+		// the main parent down to the lowest layer of the queue holds all
+		// the blocks of the queue which are in it.
+		floor := queue[0].GetLayer()
+		for _, ib := range queue {
+			if ib.GetLayer() < floor {
+				floor = ib.GetLayer()
+			}
+		}
+		walkMainPast(floor)
+
+		var next []IBlock
+		for _, ib := range queue {
+			if mainPast.Has(ib.GetID()) || result.Has(ib.GetID()) {
+				continue
+			}
+			result.AddPair(ib.GetID(), ib)
+			if !ib.HasParents() {
+				continue
+			}
+			for _, v := range ib.GetParents().GetMap() {
+				next = append(next, v.(IBlock))
+			}
+		}
+		queue = next
+	}
+	return result
+}
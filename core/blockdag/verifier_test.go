@@ -0,0 +1,110 @@
+package blockdag
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// pastOf returns all the blocks of the past of the block.
+func pastOf(ib IBlock) *IdSet {
+	past := NewIdSet()
+	queue := []IBlock{ib}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if !cur.HasParents() {
+			continue
+		}
+		for _, v := range cur.GetParents().GetMap() {
+			parent := v.(IBlock)
+			if past.Has(parent.GetID()) {
+				continue
+			}
+			past.Add(parent.GetID())
+			queue = append(queue, parent)
+		}
+	}
+	return past
+}
+
+// Test_ExactDiffAnticone checks the diff anticone of every block of a random
+// DAG against the difference of the whole pasts of the block and of its main
+// parent.
+func Test_ExactDiffAnticone(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	dag, cleanup, err := buildDAG(randomDAG(r, orderingBlocks))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	for id := uint(1); id < dag.blockTotal; id++ {
+		ib := dag.getBlockById(id)
+		mp := dag.getBlockById(ib.GetMainParent())
+		expected := pastOf(ib)
+		expected.RemoveSet(pastOf(mp))
+		expected.Remove(mp.GetID())
+
+		diffAnticone := dag.exactDiffAnticone(ib)
+		if !diffAnticone.IsEqual(expected) {
+			t.Fatalf("block %d has the diff anticone %v, expected %v", id,
+				diffAnticone.SortList(false), expected.SortList(false))
+		}
+	}
+}
+
+// Test_VerifierRepair checks that the verifier finds the blue counts of a DAG
+// consistent, only reports a corrupted one by default and repairs it when
+// asked to.
+func Test_VerifierRepair(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	dag, cleanup, err := buildDAG(randomDAG(r, orderingBlocks))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	v := NewVerifier(dag, true)
+	for v.verifyBatch() > 0 {
+	}
+	stats := v.Stats()
+	if stats.Checkpoint != dag.blockTotal || stats.BlueNumDrifts != 0 {
+		t.Fatalf("unexpected stats %+v of %d blocks", stats, dag.blockTotal)
+	}
+
+	ph := dag.instance.(*Phantom)
+	pb := ph.getBlock(dag.blockTotal / 2)
+	blueNum := pb.blueNum
+	pb.blueNum += 5
+
+	// Without repairing, the blocks whose main parent is the corrupted one
+	// are inconsistent too.
+	drifts := uint(1)
+	for id := uint(0); id < dag.blockTotal; id++ {
+		if ph.getBlock(id).GetMainParent() == pb.GetID() {
+			drifts++
+		}
+	}
+	v = NewVerifier(dag, false)
+	for v.verifyBatch() > 0 {
+	}
+	stats = v.Stats()
+	if stats.BlueNumDrifts != drifts || stats.BlueNumRepairs != 0 {
+		t.Fatalf("unexpected stats %+v without repairing", stats)
+	}
+	if pb.blueNum != blueNum+5 {
+		t.Fatalf("block has %d blues without repairing, expected %d",
+			pb.blueNum, blueNum+5)
+	}
+
+	v = NewVerifier(dag, true)
+	for v.verifyBatch() > 0 {
+	}
+	if v.Stats().BlueNumRepairs != 1 {
+		t.Fatalf("%d blue counts repaired", v.Stats().BlueNumRepairs)
+	}
+	if pb.blueNum != blueNum {
+		t.Fatalf("block has %d blues after the repair, expected %d",
+			pb.blueNum, blueNum)
+	}
+}
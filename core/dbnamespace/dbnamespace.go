@@ -40,6 +40,15 @@ var (
 	// chain state.
 	ChainStateKeyName = []byte("chainstate")
 
+	// UtxoStatsKeyName is the name of the db key used to store the
+	// statistics of the utxo set.
+	UtxoStatsKeyName = []byte("utxostats")
+
+	// UtxoStateKeyName is the name of the db key used to store the order
+	// and the hash of the last block whose outputs were written to the
+	// utxo set.
+	UtxoStateKeyName = []byte("utxostate")
+
 	// SpendJournalBucketName is the name of the db bucket used to house
 	// transactions outputs that are spent in each block.
 	SpendJournalBucketName = []byte("spendjournal")
@@ -66,6 +75,10 @@ var (
 	// DAG Main Chain Blocks
 	DagMainChainBucketName = []byte("dagmainchain")
 
+	// DagTipsBucketName is the name of the db bucket used to house the
+	// tips of the dag at the last commit
+	DagTipsBucketName = []byte("dagtips")
+
 	//TokenBucketName is the name of the db bucket used to house the token balance state
 	//The balance state is updated by the TOKEN_MINT/TOKEN_UNMINT transactions.
 	TokenBucketName = []byte("token")
@@ -77,4 +90,12 @@ var (
 	// BlockIdBucketName is the name of the db bucket used to house to
 	// the block hash -> block DAG Id.
 	BlockIdBucketName = []byte("blockid")
+
+	// SpendProposalBucketName is the name of the db bucket used to house
+	// the multisig spend proposals by transaction hash.
+	SpendProposalBucketName = []byte("spendproposal")
+
+	// AddressLabelBucketName is the name of the db bucket used to house
+	// the labels of the addresses by encoded address.
+	AddressLabelBucketName = []byte("addresslabel")
 )
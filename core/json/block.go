@@ -77,6 +77,120 @@ type GetBlockHeaderVerboseResult struct {
 	PowResult     PowResult `json:"pow"`
 }
 
+// HeadersResult models the data from the getHeaders command and the headers
+// notification.  Headers is the hex-encoded concatenation of the serialized
+// headers of the blocks following StartOrder, each HeaderSize bytes long, and
+// Blues tells for each of them whether the block is blue.
+type HeadersResult struct {
+	StartOrder uint64 `json:"startorder"`
+	HeaderSize int    `json:"headersize"`
+	Headers    string `json:"headers"`
+	Blues      []bool `json:"blues"`
+}
+
+// MainChainBlockResult models the data from the getMainChainTip and
+// getMainChainBlockByHeight commands.  The main chain is the selected parent
+// chain ending at the main chain tip, which lets tools written for a linear
+// chain follow the DAG.
+type MainChainBlockResult struct {
+	Hash   string `json:"hash"`
+	Height uint64 `json:"height"`
+	Order  uint64 `json:"order"`
+}
+
+// BlockStateResult models the data from the getBlockState command.  The blue
+// score is the number of blue blocks in the past of the block, and the order
+// is only meaningful when the block is ordered.
+type BlockStateResult struct {
+	Hash          string `json:"hash"`
+	Order         uint64 `json:"order"`
+	IsOrdered     bool   `json:"isordered"`
+	Height        uint64 `json:"height"`
+	Layer         uint64 `json:"layer"`
+	IsBlue        bool   `json:"isblue"`
+	BlueScore     uint64 `json:"bluescore"`
+	MainChain     bool   `json:"mainchain"`
+	Confirmations uint64 `json:"confirmations"`
+}
+
+// LockTimeCursorResult models the data from the getLockTimeCursor command.  A
+// transaction is finalized in the next block when its lock time is zero, below
+// both Threshold and Height, or not below Threshold and below MedianTime.
+// Height is the order of the next block on the main chain.
+type LockTimeCursorResult struct {
+	Height     uint64 `json:"height"`
+	MedianTime int64  `json:"mediantime"`
+	Threshold  uint32 `json:"threshold"`
+}
+
+// NetworkHashPSResult models the data from the getNetworkHashPS command.  The
+// hash rate is the work of the blocks of the pow type between the start and
+// end orders divided by the time span of their timestamps, in seconds.
+type NetworkHashPSResult struct {
+	PowType    uint8   `json:"powtype"`
+	PowName    string  `json:"powname"`
+	Blocks     uint32  `json:"blocks"`
+	StartOrder uint64  `json:"startorder"`
+	EndOrder   uint64  `json:"endorder"`
+	TimeSpan   int64   `json:"timespan"`
+	HashPS     float64 `json:"hashps"`
+}
+
+// DifficultyResult models a block of the getDifficultyHistory command.  The
+// difficulty is the expected work of the block given its difficulty bits.
+type DifficultyResult struct {
+	Order      uint64  `json:"order"`
+	Hash       string  `json:"hash"`
+	Timestamp  int64   `json:"timestamp"`
+	Bits       string  `json:"bits"`
+	Difficulty float64 `json:"difficulty"`
+}
+
+// DifficultyHistoryResult models the data from the getDifficultyHistory
+// command.
+type DifficultyHistoryResult struct {
+	PowType uint8              `json:"powtype"`
+	PowName string             `json:"powname"`
+	Blocks  []DifficultyResult `json:"blocks"`
+}
+
+// BlockOrderResult models a block along with its order in a chainreorg
+// notification.
+type BlockOrderResult struct {
+	Hash  string `json:"hash"`
+	Order uint64 `json:"order"`
+}
+
+// BlockReorderResult models a block whose order was changed by a
+// reorganization.
+type BlockReorderResult struct {
+	Hash     string `json:"hash"`
+	OldOrder uint64 `json:"oldorder"`
+	NewOrder uint64 `json:"neworder"`
+}
+
+// ChainReorgResult models the data of a chainreorg notification.  The
+// detached blocks are in their old order, the attached blocks in their new
+// order, and the reordered blocks are those in both with different orders.
+type ChainReorgResult struct {
+	Detached  []BlockOrderResult   `json:"detached"`
+	Attached  []BlockOrderResult   `json:"attached"`
+	Reordered []BlockReorderResult `json:"reordered"`
+}
+
+// DagEventResult models an event of a dag notification.  Type is "added" for
+// a block added to the DAG along with its parents, order and color, "color"
+// for a block whose color flipped, and "order" for a block whose order was
+// changed by a reorganization.
+type DagEventResult struct {
+	Type     string   `json:"type"`
+	Hash     string   `json:"hash"`
+	Parents  []string `json:"parents,omitempty"`
+	Order    uint64   `json:"order"`
+	OldOrder uint64   `json:"oldorder,omitempty"`
+	Blue     bool     `json:"blue"`
+}
+
 type TokenState struct {
 	CoinId     uint16 `json:"coinid"`
 	CoinName   string `json:"coinname"`
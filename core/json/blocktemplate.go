@@ -23,6 +23,11 @@ type TemplateRequest struct {
 	SizeLimit  interface{} `json:"sizelimit,omitempty"`
 	MaxVersion uint32      `json:"maxversion,omitempty"`
 
+	// Optional hashes of the transactions forced into or kept out of the
+	// template.
+	IncludeTxs []string `json:"includetxs,omitempty"`
+	ExcludeTxs []string `json:"excludetxs,omitempty"`
+
 	// Basic pool extension from BIP 0023.
 	Target string `json:"target,omitempty"`
 
@@ -101,3 +106,49 @@ type GetBlockTemplateResult struct {
 	RejectReasion string        `json:"reject-reason,omitempty"`
 	BlockFeesMap  map[int]int64 `json:"block_fees_map"`
 }
+
+// TemplateWitnessInput models an output spent by a transaction of a block
+// template.  InTemplate is set when the output is created by an earlier
+// transaction of the template, otherwise it is found in the utxo set.
+type TemplateWitnessInput struct {
+	TxID       string `json:"txid"`
+	Vout       uint32 `json:"vout"`
+	Amount     uint64 `json:"amount"`
+	CoinId     uint16 `json:"coinid"`
+	PkScript   string `json:"pkscript"`
+	BlockHash  string `json:"blockhash,omitempty"`
+	Coinbase   bool   `json:"coinbase"`
+	InTemplate bool   `json:"intemplate"`
+}
+
+// TemplateWitnessTx models a transaction of a block template along with the
+// outputs it spends.
+type TemplateWitnessTx struct {
+	Hash   string                 `json:"hash"`
+	Data   string                 `json:"data"`
+	Fee    int64                  `json:"fee"`
+	Inputs []TemplateWitnessInput `json:"inputs"`
+}
+
+// TemplateWitnessMempoolTx models a transaction of the memory pool when a
+// block template is generated.
+type TemplateWitnessMempoolTx struct {
+	Hash     string `json:"hash"`
+	Size     int    `json:"size"`
+	Fee      int64  `json:"fee"`
+	FeePerKB int64  `json:"feeperkb"`
+	Included bool   `json:"included"`
+}
+
+// BlockTemplateWitnessResult models the data from the getBlockTemplateWitness
+// command.
+type BlockTemplateWitnessResult struct {
+	Height        int64                      `json:"height"`
+	PreviousHash  string                     `json:"previousblockhash"`
+	Parents       []string                   `json:"parents"`
+	CoinbaseTxn   string                     `json:"coinbasetxn"`
+	CoinbaseValue uint64                     `json:"coinbasevalue"`
+	BlockFeesMap  map[int]int64              `json:"block_fees_map"`
+	Transactions  []TemplateWitnessTx        `json:"transactions"`
+	Mempool       []TemplateWitnessMempoolTx `json:"mempool"`
+}
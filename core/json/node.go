@@ -16,6 +16,7 @@ type InfoNodeResult struct {
 	TotalSubsidy        uint64                              `json:"totalsubsidy,omitempty"`
 	GraphState          *GetGraphStateResult                `json:"graphstate,omitempty"`
 	TimeOffset          int64                               `json:"timeoffset,omitempty"`
+	ClockSkew           int64                               `json:"clockskew,omitempty"`
 	PowDiff             *PowDiff                            `json:"pow_diff,omitempty"`
 	Confirmations       int32                               `json:"confirmations,omitempty"`
 	CoinbaseMaturity    int32                               `json:"coinbasematurity,omitempty"`
@@ -25,6 +26,9 @@ type InfoNodeResult struct {
 	ConsensusDeployment map[string]*ConsensusDeploymentDesc `json:"consensusdeployment,omitempty"`
 	Network             string                              `json:"network"`
 	Connections         int32                               `json:"connections"`
+	Services            string                              `json:"services"`
+	ServiceFlags        uint64                              `json:"serviceflags"`
+	Subsystems          map[string]bool                     `json:"subsystems,omitempty"`
 }
 
 // GetPeerInfoResult models the data returned from the getpeerinfo command.
@@ -51,6 +55,91 @@ type GetPeerInfoResult struct {
 	Network    string               `json:"network,omitempty"`
 	Circuit    bool                 `json:"circuit,omitempty"`
 	Bads       int                  `json:"bads,omitempty"`
+	MempoolTxs *uint32              `json:"mempooltxs,omitempty"`
+	FeeFloor   *int64               `json:"feefloor,omitempty"`
+	Blocks     *BlockStatsResult    `json:"blocks,omitempty"`
+}
+
+// BlockStatsResult models the orphan, duplicate and invalid blocks sent by a
+// peer, which are counted across restarts.
+type BlockStatsResult struct {
+	Orphans    uint64 `json:"orphans"`
+	Duplicates uint64 `json:"duplicates"`
+	Invalids   uint64 `json:"invalids"`
+}
+
+// PeerMsgStatsResult models a peer of the getPeerMsgStats command.  The
+// messages are counted by type across restarts, and the errors are the
+// messages of the peer whose handling failed.
+type PeerMsgStatsResult struct {
+	ID       string            `json:"id"`
+	Sent     map[string]uint64 `json:"sent"`
+	Received map[string]uint64 `json:"received"`
+	Errors   map[string]uint64 `json:"errors"`
+}
+
+// SafeModeResult models the data returned by the getSafeMode command.  In safe
+// mode the miner and the relay are paused until acknowledgeSafeMode is called.
+type SafeModeResult struct {
+	Enabled  bool   `json:"enabled"`
+	SafeMode bool   `json:"safemode"`
+	Since    int64  `json:"since,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// SLOStatusResult models the data returned by the getSLOStatus command.  The
+// intervals are in seconds and the rates in percent, Since is the time the
+// alert was last raised or cleared.
+type SLOStatusResult struct {
+	Enabled           bool     `json:"enabled"`
+	Window            uint     `json:"window,omitempty"`
+	Blocks            uint     `json:"blocks"`
+	Reds              uint     `json:"reds"`
+	RedRate           float64  `json:"redrate"`
+	MaxRedRate        uint     `json:"maxredrate,omitempty"`
+	MeanInterval      float64  `json:"meaninterval"`
+	MedianInterval    float64  `json:"medianinterval"`
+	MaxInterval       float64  `json:"maxinterval"`
+	TargetInterval    float64  `json:"targetinterval,omitempty"`
+	IntervalDeviation uint     `json:"intervaldeviation,omitempty"`
+	Alerting          bool     `json:"alerting"`
+	Since             int64    `json:"since,omitempty"`
+	Reasons           []string `json:"reasons,omitempty"`
+}
+
+// MetricsHistoryResult models the data returned by the getMetricsHistory
+// command.  The snapshots are sorted from the oldest to the newest.
+type MetricsHistoryResult struct {
+	Enabled   bool                    `json:"enabled"`
+	Size      uint                    `json:"size,omitempty"`
+	Snapshots []MetricsSnapshotResult `json:"snapshots"`
+}
+
+// MetricsSnapshotResult models a snapshot of the key metrics of the
+// getMetricsHistory command.  The block rate is in blocks per minute since
+// the previous snapshot.
+type MetricsSnapshotResult struct {
+	Time       int64   `json:"time"`
+	Tips       uint    `json:"tips"`
+	MempoolTxs uint    `json:"mempooltxs"`
+	Peers      uint    `json:"peers"`
+	Blocks     uint    `json:"blocks"`
+	BlockRate  float64 `json:"blockrate"`
+}
+
+// FollowerStatusResult models the data returned by the getFollowerStatus
+// command.  The lags are how far the graph state of the node is behind the
+// last graph state received from the leader, which is LeaderUpdated seconds
+// old.
+type FollowerStatusResult struct {
+	Enabled       bool                 `json:"enabled"`
+	Leader        string               `json:"leader,omitempty"`
+	Connected     bool                 `json:"connected"`
+	GraphState    *GetGraphStateResult `json:"graphstate,omitempty"`
+	LeaderState   *GetGraphStateResult `json:"leaderstate,omitempty"`
+	LeaderUpdated int64                `json:"leaderupdated,omitempty"`
+	OrderLag      int64                `json:"orderlag"`
+	BlockLag      int64                `json:"blocklag"`
 }
 
 // GetGraphStateResult data
@@ -61,6 +150,54 @@ type GetGraphStateResult struct {
 	Layer      uint32   `json:"layer"`
 }
 
+// NodeStatsResult models the data returned by the getnodestats command.  The
+// peak memory is the highest among the calls, and the database size is
+// computed again at most every ten minutes.
+type NodeStatsResult struct {
+	Uptime          int64            `json:"uptime"`
+	BlocksConnected uint64           `json:"blocksconnected"`
+	Reorganizations uint64           `json:"reorganizations"`
+	MempoolAdded    uint64           `json:"mempooladded"`
+	MempoolRemoved  uint64           `json:"mempoolremoved"`
+	MempoolSize     int              `json:"mempoolsize"`
+	PeakMemory      uint64           `json:"peakmemory"`
+	DBSize          map[string]int64 `json:"dbsize"`
+}
+
+// PerfReportResult models the data returned by the perfReport command.  The
+// statistics cover the last minutes of the report.
+type PerfReportResult struct {
+	Minutes     uint32           `json:"minutes"`
+	DBReads     []PerfStatResult `json:"dbreads"`
+	DBWrites    []PerfStatResult `json:"dbwrites"`
+	SlowestRPCs []PerfStatResult `json:"slowestrpcs"`
+	BlockStages []PerfStatResult `json:"blockstages"`
+	GC          GCStatsResult    `json:"gc"`
+}
+
+// PerfStatResult models the statistics of a database bucket, an RPC method or
+// a block connection stage of the perfReport command.  The times are in
+// milliseconds, and omitted for the database buckets which are only counted.
+type PerfStatResult struct {
+	Name    string  `json:"name"`
+	Count   uint64  `json:"count"`
+	Total   float64 `json:"total,omitempty"`
+	Average float64 `json:"average,omitempty"`
+	Max     float64 `json:"max,omitempty"`
+}
+
+// GCStatsResult models the garbage collection statistics of the perfReport
+// command.  The pauses are in milliseconds.
+type GCStatsResult struct {
+	NumGC        int64   `json:"numgc"`
+	PauseTotal   float64 `json:"pausetotal"`
+	PauseMax     float64 `json:"pausemax"`
+	LastGC       int64   `json:"lastgc"`
+	HeapAlloc    uint64  `json:"heapalloc"`
+	HeapSys      uint64  `json:"heapsys"`
+	NumGoroutine int     `json:"numgoroutine"`
+}
+
 type GetBanlistResult struct {
 	ID   string `json:"id"`
 	Bads int    `json:"bads"`
@@ -93,3 +230,40 @@ type NetworkInfo struct {
 	MaxGS      string `json:"maxgs,omitempty"`
 	MinGS      string `json:"mings,omitempty"`
 }
+
+// AuditEntryResult models an entry of the getAuditLog command.  The params
+// digest is the sha256 digest of the JSON encoding of the parameters of the
+// call.
+type AuditEntryResult struct {
+	ID           uint64 `json:"id"`
+	Timestamp    int64  `json:"timestamp"`
+	User         string `json:"user"`
+	Remote       string `json:"remote"`
+	Method       string `json:"method"`
+	ParamsDigest string `json:"paramsdigest"`
+	Success      bool   `json:"success"`
+	Error        string `json:"error,omitempty"`
+}
+
+// BuildInfoResult models the data returned by the getBuildInfo command.
+// The commit and the build time are empty when the binary was not built by
+// the makefile.
+type BuildInfoResult struct {
+	Version   string   `json:"version"`
+	Commit    string   `json:"commit"`
+	BuildTime string   `json:"buildtime"`
+	GoVersion string   `json:"goversion"`
+	OS        string   `json:"os"`
+	Arch      string   `json:"arch"`
+	Tags      []string `json:"tags"`
+}
+
+// IndexBackfillResult models an index of the getIndexBackfillInfo command.
+// The order is -1 until the first block is indexed.
+type IndexBackfillResult struct {
+	Name        string  `json:"name"`
+	Order       int64   `json:"order"`
+	TargetOrder int64   `json:"targetorder"`
+	Progress    float64 `json:"progress"`
+	Live        bool    `json:"live"`
+}
@@ -6,24 +6,40 @@ import "encoding/json"
 
 // TxRawResult models the data from the getrawtransaction command.
 type TxRawResult struct {
-	Hex           string `json:"hex"`
-	Txid          string `json:"txid"`
-	TxHash        string `json:"txhash,omitempty"`
-	Size          int32  `json:"size,omitempty"`
-	Version       uint32 `json:"version"`
-	LockTime      uint32 `json:"locktime"`
-	Timestamp     string `json:"timestamp,omitempty"`
-	Expire        uint32 `json:"expire"`
-	Vin           []Vin  `json:"vin"`
-	Vout          []Vout `json:"vout"`
-	BlockHash     string `json:"blockhash,omitempty"`
-	BlockOrder    uint64 `json:"blockorder,omitempty"`
-	TxIndex       uint32 `json:"txindex,omitempty"`
-	Confirmations int64  `json:"confirmations"`
-	Time          int64  `json:"time,omitempty"`
-	Blocktime     int64  `json:"blocktime,omitempty"`
-	Duplicate     bool   `json:"duplicate,omitempty"`
-	Txsvalid      bool   `json:"txsvalid"`
+	Hex           string            `json:"hex"`
+	Txid          string            `json:"txid"`
+	TxHash        string            `json:"txhash,omitempty"`
+	Size          int32             `json:"size,omitempty"`
+	Version       uint32            `json:"version"`
+	LockTime      uint32            `json:"locktime"`
+	Timestamp     string            `json:"timestamp,omitempty"`
+	Expire        uint32            `json:"expire"`
+	Vin           []Vin             `json:"vin"`
+	Vout          []Vout            `json:"vout"`
+	BlockHash     string            `json:"blockhash,omitempty"`
+	BlockOrder    uint64            `json:"blockorder,omitempty"`
+	TxIndex       uint32            `json:"txindex,omitempty"`
+	Confirmations int64             `json:"confirmations"`
+	Time          int64             `json:"time,omitempty"`
+	Blocktime     int64             `json:"blocktime,omitempty"`
+	Duplicate     bool              `json:"duplicate,omitempty"`
+	Txsvalid      bool              `json:"txsvalid"`
+	Finality      *TxFinalityResult `json:"finality,omitempty"`
+}
+
+// TxFinalityResult models the finality of a transaction in the DAG.  Score
+// goes from 0 to 1, it combines the blue confirmations of the block of the
+// transaction, whether the block is behind the latest hourglass block of the
+// main chain and the confirmations of the outputs spent by the transaction.
+// HourglassDistance is negative when the block is not behind the hourglass
+// block.
+type TxFinalityResult struct {
+	Score                 float64 `json:"score"`
+	IsBlue                bool    `json:"isblue"`
+	Confirmations         uint64  `json:"confirmations"`
+	HourglassDistance     int64   `json:"hourglassdistance"`
+	MinInputConfirmations uint64  `json:"mininputconfirmations"`
+	InputsMatured         bool    `json:"inputsmatured"`
 }
 
 // Vin models parts of the tx data.  It is defined separately since
@@ -111,6 +127,234 @@ type ScriptSig struct {
 	Hex string `json:"hex"`
 }
 
+// RescannedTx models a transaction found by the rescan command.
+type RescannedTx struct {
+	TxId          string `json:"txid"`
+	BlockHash     string `json:"blockhash"`
+	Order         uint64 `json:"order"`
+	Confirmations uint   `json:"confirmations"`
+	Hex           string `json:"hex"`
+}
+
+// MempoolStatsResult models the data returned by the getMempoolStats command.
+type MempoolStatsResult struct {
+	Size     int                   `json:"size"`
+	Accepted uint64                `json:"accepted"`
+	Orphaned uint64                `json:"orphaned"`
+	Rejected map[string]uint64     `json:"rejected"`
+	Latency  []LatencyBucketResult `json:"latency"`
+}
+
+// LatencyBucketResult models a bucket of the acceptance latency histogram of
+// the getMempoolStats command.  The bound is in milliseconds, and zero for the
+// last bucket which is unbounded.
+type LatencyBucketResult struct {
+	Bound int64  `json:"bound"`
+	Count uint64 `json:"count"`
+}
+
+// FeeHistogramResult models the data returned by the getFeeHistogram command
+// and the feehistogram notification.  The buckets go from the highest fee
+// rate to the lowest one.
+type FeeHistogramResult struct {
+	Size    int                        `json:"size"`
+	VSize   int64                      `json:"vsize"`
+	Buckets []FeeHistogramBucketResult `json:"buckets"`
+}
+
+// FeeHistogramBucketResult models a bucket of the fee histogram.  FeeRate is
+// the lower bound of the bucket in atoms per byte, and VSize the total size in
+// bytes of its transactions, which is their serialized size as qitmeer has no
+// witness discount.
+type FeeHistogramBucketResult struct {
+	FeeRate int64 `json:"feerate"`
+	Count   int   `json:"count"`
+	VSize   int64 `json:"vsize"`
+}
+
+// DumpMempoolResult models the data returned by the dumpMempool command.
+type DumpMempoolResult struct {
+	File string `json:"file"`
+	Size int    `json:"size"`
+}
+
+// LoadMempoolResult models the data returned by the loadMempool command.
+type LoadMempoolResult struct {
+	Accepted int `json:"accepted"`
+	Skipped  int `json:"skipped"`
+	Rejected int `json:"rejected"`
+}
+
+// DiffMempoolResult models the data returned by the diffMempool command: the
+// transactions added to the second snapshot, removed from the first one, and
+// those of both snapshots whose fee changed.
+type DiffMempoolResult struct {
+	Added      []string                 `json:"added"`
+	Removed    []string                 `json:"removed"`
+	FeeChanged []MempoolFeeChangeResult `json:"feechanged"`
+}
+
+// MempoolFeeChangeResult models a transaction whose fee changed between two
+// mempool snapshots.
+type MempoolFeeChangeResult struct {
+	TxId        string `json:"txid"`
+	OldFee      int64  `json:"oldfee"`
+	NewFee      int64  `json:"newfee"`
+	OldFeePerKB int64  `json:"oldfeeperkb"`
+	NewFeePerKB int64  `json:"newfeeperkb"`
+}
+
+// DoubleSpendProofResult models a double spend proof of the
+// getDoubleSpendProofs command and the doublespendproof notification.  Hex is
+// the serialized proof which holds both spending transactions.
+type DoubleSpendProofResult struct {
+	Hash   string `json:"hash"`
+	TxId   string `json:"txid"`
+	Vout   uint32 `json:"vout"`
+	First  string `json:"first"`
+	Second string `json:"second"`
+	Hex    string `json:"hex"`
+}
+
+// TxSafeToCreditResult models the data from the isTxSafeToCredit command.
+// Safe tells whether the transaction can be credited and Explanation lists
+// the reasons when it can not.  HourglassDistance is the number of orders the
+// block of the transaction is behind the latest hourglass block of the main
+// chain, it is negative when the block is not behind it.
+type TxSafeToCreditResult struct {
+	TxId                  string `json:"txid"`
+	Safe                  bool   `json:"safe"`
+	Explanation           string `json:"explanation"`
+	BlockHash             string `json:"blockhash,omitempty"`
+	Confirmations         uint64 `json:"confirmations"`
+	RequiredConfirmations uint64 `json:"requiredconfirmations"`
+	IsBlue                bool   `json:"isblue"`
+	HourglassDistance     int64  `json:"hourglassdistance"`
+	Tips                  int    `json:"tips"`
+}
+
+// SpendProposalResult models the data of a multisig spend proposal.  Psbt is
+// the partially signed transaction exchanged with the cosigners and
+// Signatures holds the number of signatures collected for each input.
+type SpendProposalResult struct {
+	Id           string `json:"id"`
+	Psbt         string `json:"psbt"`
+	RedeemScript string `json:"redeemscript"`
+	Required     int    `json:"required"`
+	Signatures   []int  `json:"signatures"`
+	Complete     bool   `json:"complete"`
+	Broadcast    bool   `json:"broadcast"`
+}
+
+// ScriptStepResult models an opcode executed by the debugScript command.
+// Script is one of signature, pubkey or redeem and the stacks hold the hex
+// encoded items once the opcode executed, the top of the stack last.
+type ScriptStepResult struct {
+	Script   string   `json:"script"`
+	Offset   int      `json:"offset"`
+	Opcode   string   `json:"opcode"`
+	Stack    []string `json:"stack"`
+	AltStack []string `json:"altstack,omitempty"`
+}
+
+// DebugScriptResult models the data from the debugScript command.
+// FailedStep is the index of the failing step, -1 when the execution
+// succeeded or failed before the first opcode.
+type DebugScriptResult struct {
+	Success      bool               `json:"success"`
+	Error        string             `json:"error,omitempty"`
+	PkScriptType string             `json:"pkscripttype"`
+	FailedStep   int                `json:"failedstep"`
+	Steps        []ScriptStepResult `json:"steps"`
+}
+
+// ActivityBlockResult models a block of the getAddressActivity command.
+type ActivityBlockResult struct {
+	Order     uint64 `json:"order"`
+	Hash      string `json:"hash"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// AddressActivityResult models the data from the getAddressActivity command.
+// Dormancy is the number of orders since the last activity of the address.
+type AddressActivityResult struct {
+	Address    string               `json:"address"`
+	Seen       bool                 `json:"seen"`
+	FirstSeen  *ActivityBlockResult `json:"firstseen,omitempty"`
+	LastActive *ActivityBlockResult `json:"lastactive,omitempty"`
+	TxCount    uint32               `json:"txcount"`
+	Dormancy   uint64               `json:"dormancy"`
+}
+
+// AnalyzeAddressResult models the data from the analyzeAddress command.
+// ReuseCount is the number of transactions paying to the address after the
+// first one, and CoSpentAddresses is the number of other addresses spent
+// together with it.  SelfChangeTxs are the transactions spending from the
+// address and paying back to it.
+type AnalyzeAddressResult struct {
+	Address          string  `json:"address"`
+	TxCount          int     `json:"txcount"`
+	Truncated        bool    `json:"truncated,omitempty"`
+	ReceivingTxs     int     `json:"receivingtxs"`
+	ReuseCount       int     `json:"reusecount"`
+	Outputs          int     `json:"outputs"`
+	Utxos            int     `json:"utxos"`
+	DustUtxos        int     `json:"dustutxos"`
+	DustAmount       float64 `json:"dustamount"`
+	SpendingTxs      int     `json:"spendingtxs"`
+	SelfChangeTxs    int     `json:"selfchangetxs"`
+	CoSpentAddresses int     `json:"cospentaddresses"`
+}
+
+// UtxoAgeBucketResult models a bucket of the getUtxoAgeDistribution command.
+// The ages are the numbers of orders from the ends of the bucket to the main
+// order and Share is the part of the total amount held by the bucket.
+type UtxoAgeBucketResult struct {
+	StartOrder uint64  `json:"startorder"`
+	EndOrder   uint64  `json:"endorder"`
+	MinAge     uint64  `json:"minage"`
+	MaxAge     uint64  `json:"maxage"`
+	Count      uint64  `json:"count"`
+	Amount     float64 `json:"amount"`
+	Share      float64 `json:"share"`
+}
+
+// UtxoAgeDistributionResult models the data from the getUtxoAgeDistribution
+// command.
+type UtxoAgeDistributionResult struct {
+	CoinId       uint16                `json:"coinid"`
+	MainOrder    uint64                `json:"mainorder"`
+	BucketOrders uint32                `json:"bucketorders"`
+	Count        uint64                `json:"count"`
+	Amount       float64               `json:"amount"`
+	Buckets      []UtxoAgeBucketResult `json:"buckets"`
+}
+
+// MinedBlockResult models a block of the getMinerStats command.
+type MinedBlockResult struct {
+	Order           uint64  `json:"order"`
+	Hash            string  `json:"hash"`
+	IsBlue          bool    `json:"isblue"`
+	Subsidy         float64 `json:"subsidy"`
+	ExpectedSubsidy float64 `json:"expectedsubsidy"`
+	Fees            float64 `json:"fees"`
+}
+
+// MinerStatsResult models the data from the getMinerStats command.  Reward
+// is the subsidy plus the fees of the blocks.
+type MinerStatsResult struct {
+	Address         string             `json:"address"`
+	StartOrder      uint64             `json:"startorder"`
+	EndOrder        uint64             `json:"endorder"`
+	Blocks          uint64             `json:"blocks"`
+	BlueBlocks      uint64             `json:"blueblocks"`
+	Subsidy         float64            `json:"subsidy"`
+	ExpectedSubsidy float64            `json:"expectedsubsidy"`
+	Fees            float64            `json:"fees"`
+	Reward          float64            `json:"reward"`
+	BlockList       []MinedBlockResult `json:"blocklist,omitempty"`
+}
+
 // GetUtxoResult models the data from the GetUtxo command.
 type GetUtxoResult struct {
 	BestBlock     string             `json:"bestblock"`
@@ -122,6 +366,45 @@ type GetUtxoResult struct {
 	Coinbase      bool               `json:"coinbase"`
 }
 
+// ScanUtxoSetResult models the data from the scanUtxoSet command.  Cursor
+// resumes the scan after the last output read, it is empty once the whole
+// utxo set is scanned.  Progress is the approximate percentage of the utxo
+// set scanned so far.
+type ScanUtxoSetResult struct {
+	Scanned  uint64              `json:"scanned"`
+	Progress float64             `json:"progress"`
+	Unspents []ScannedUtxoResult `json:"unspents"`
+	Amounts  map[uint16]float64  `json:"amounts"`
+	Cursor   string              `json:"cursor,omitempty"`
+}
+
+// GetTxOutSetInfoResult models the data from the getTxOutSetInfo command.
+// MuHash is the commitment to the utxo set, equal on the nodes with the same
+// utxo set.  The statistics are partial until Complete, Progress is the
+// approximate percentage of the utxo set they cover.
+type GetTxOutSetInfoResult struct {
+	BestBlock string             `json:"bestblock"`
+	Order     uint               `json:"order"`
+	TxOuts    uint64             `json:"txouts"`
+	Bytes     uint64             `json:"bytes"`
+	Amounts   map[uint16]float64 `json:"amounts"`
+	MuHash    string             `json:"muhash"`
+	Complete  bool               `json:"complete"`
+	Progress  float64            `json:"progress"`
+}
+
+// ScannedUtxoResult is an unspent output found by the scanUtxoSet command.
+type ScannedUtxoResult struct {
+	Txid         string  `json:"txid"`
+	Vout         uint32  `json:"vout"`
+	Descriptor   string  `json:"desc"`
+	ScriptPubKey string  `json:"scriptPubKey"`
+	CoinId       uint16  `json:"coinId"`
+	Amount       float64 `json:"amount"`
+	BlockHash    string  `json:"blockhash"`
+	Coinbase     bool    `json:"coinbase"`
+}
+
 // GetRawTransactionsResult models the data from the getrawtransactions
 // command.
 type GetRawTransactionsResult struct {
@@ -140,6 +423,23 @@ type GetRawTransactionsResult struct {
 	Blocktime     int64        `json:"blocktime,omitempty"`
 }
 
+// AddressTransactionsResult models the data from the
+// getRawTransactionsByAddress command.  Cursor resumes the listing after the
+// last transaction, it is empty when there are no more transactions.
+type AddressTransactionsResult struct {
+	Address      string                     `json:"address"`
+	Transactions []AddressTransactionResult `json:"transactions"`
+	Cursor       string                     `json:"cursor,omitempty"`
+}
+
+// AddressTransactionResult is a transaction of an address along with the order
+// of its block and whether it was sent from or received by the address.
+type AddressTransactionResult struct {
+	GetRawTransactionsResult
+	Order     uint64 `json:"order"`
+	Direction string `json:"direction"`
+}
+
 type VinPrevOut struct {
 	Coinbase  string     `json:"coinbase"`
 	Txid      string     `json:"txid"`
@@ -172,6 +472,48 @@ type DecodeRawTransactionResult struct {
 	Vout       []Vout `json:"vout"`
 }
 
+// FundRawTransactionResult models the data from the fundRawTransaction
+// command.
+type FundRawTransactionResult struct {
+	Hex       string  `json:"hex"`
+	Fee       float64 `json:"fee"`
+	ChangePos int     `json:"changepos"`
+}
+
+// ConsolidationTxResult models a transaction built by the consolidateUtxos
+// command, either the unsigned transaction or the partially signed
+// transaction of a spend proposal.
+type ConsolidationTxResult struct {
+	Hex    string  `json:"hex,omitempty"`
+	Psbt   string  `json:"psbt,omitempty"`
+	Inputs int     `json:"inputs"`
+	Amount float64 `json:"amount"`
+	Fee    float64 `json:"fee"`
+}
+
+// ConsolidateUtxosResult models the data from the consolidateUtxos command.
+type ConsolidateUtxosResult struct {
+	Transactions []ConsolidationTxResult `json:"transactions"`
+	Inputs       int                     `json:"inputs"`
+	Fee          float64                 `json:"fee"`
+}
+
+// FrozenCoinResult models an entry of the freeze list returned by the
+// freezeCoins and listFrozenCoins commands.  The target is a frozen outpoint,
+// formatted as <txid>:<index>, or a frozen address.
+type FrozenCoinResult struct {
+	Target string `json:"target"`
+	Reason string `json:"reason,omitempty"`
+	Time   int64  `json:"time"`
+}
+
+// AddressLabelResult models the data returned by the setAddressLabel command.
+// The label is empty when it was removed.
+type AddressLabelResult struct {
+	Address string `json:"address"`
+	Label   string `json:"label"`
+}
+
 // TransactionInput represents the inputs to a transaction.  Specifically a
 // transaction hash and output number pair.
 type TransactionInput struct {
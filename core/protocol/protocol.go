@@ -16,7 +16,16 @@ const (
 	InitialProcotolVersion uint32 = 33
 
 	// ProtocolVersion is the latest protocol version this package supports.
-	ProtocolVersion uint32 = 33
+	ProtocolVersion uint32 = 35
+
+	// GraphStateExtVersion is the protocol version which extends the graph
+	// state exchanged by peers with optional data of the sender, such as its
+	// mempool summary.
+	GraphStateExtVersion uint32 = 34
+
+	// PackageRelayVersion is the protocol version which adds the relay of
+	// packages of dependent transactions accepted together.
+	PackageRelayVersion uint32 = 35
 )
 
 // Network represents which qitmeer network a message belongs to.
@@ -0,0 +1,113 @@
+// Copyright (c) 2017-2018 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"io"
+)
+
+// DoubleSpendProof is the evidence that an output was spent by two different
+// signed transactions.  It holds the spent outpoint and both spending
+// transactions, which lets anyone holding the output's public key script
+// verify the two signatures of the owner independently.
+//
+// The two transactions are ordered by hash so that every node generates the
+// same proof for the same pair of transactions.
+type DoubleSpendProof struct {
+	OutPoint TxOutPoint
+	First    *Transaction
+	Second   *Transaction
+}
+
+// NewDoubleSpendProof returns a proof that the outpoint is spent by both of
+// the passed transactions.
+func NewDoubleSpendProof(op *TxOutPoint, a *Transaction, b *Transaction) *DoubleSpendProof {
+	ha, hb := a.TxHash(), b.TxHash()
+	if bytes.Compare(hb[:], ha[:]) < 0 {
+		a, b = b, a
+	}
+	return &DoubleSpendProof{
+		OutPoint: *op,
+		First:    a,
+		Second:   b,
+	}
+}
+
+// Hash returns the hash which identifies the proof.
+func (p *DoubleSpendProof) Hash() hash.Hash {
+	buf := bytes.NewBuffer(make([]byte, 0, p.SerializeSize()))
+	// Ignore the error returns since the only way the encode could fail
+	// is being out of memory or due to nil pointers, both of which would
+	// cause a run-time panic.
+	_ = p.Encode(buf)
+	return hash.DoubleHashH(buf.Bytes())
+}
+
+// SerializeSize returns the number of bytes it would take to serialize the
+// proof.
+func (p *DoubleSpendProof) SerializeSize() int {
+	// Outpoint hash 32 bytes + outpoint index 4 bytes + both transactions.
+	return hash.HashSize + 4 + p.First.SerializeSize() + p.Second.SerializeSize()
+}
+
+// Encode encodes the proof to w.
+func (p *DoubleSpendProof) Encode(w io.Writer) error {
+	err := WriteOutPoint(w, 0, 0, &p.OutPoint)
+	if err != nil {
+		return err
+	}
+	err = p.First.Encode(w, 0, TxSerializeFull)
+	if err != nil {
+		return err
+	}
+	return p.Second.Encode(w, 0, TxSerializeFull)
+}
+
+// Serialize returns the serialization of the proof.
+func (p *DoubleSpendProof) Serialize() ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, p.SerializeSize()))
+	err := p.Encode(buf)
+	return buf.Bytes(), err
+}
+
+// Decode decodes r into the proof.
+func (p *DoubleSpendProof) Decode(r io.Reader) error {
+	err := ReadOutPoint(r, 0, &p.OutPoint)
+	if err != nil {
+		return err
+	}
+	p.First = NewTransaction()
+	err = p.First.Decode(r, 0)
+	if err != nil {
+		return err
+	}
+	p.Second = NewTransaction()
+	return p.Second.Decode(r, 0)
+}
+
+// SpendingInput returns the index of the input of the transaction which
+// spends the outpoint of the proof.
+func (p *DoubleSpendProof) SpendingInput(tx *Transaction) (int, error) {
+	for i, txIn := range tx.TxIn {
+		if txIn.PreviousOut == p.OutPoint {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("transaction %s does not spend %s:%d", tx.TxHash(),
+		p.OutPoint.Hash, p.OutPoint.OutIndex)
+}
+
+// NewDoubleSpendProofFromBytes returns a proof decoded from the passed bytes.
+func NewDoubleSpendProofFromBytes(serialized []byte) (*DoubleSpendProof, error) {
+	var p DoubleSpendProof
+	err := p.Decode(bytes.NewReader(serialized))
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,79 @@
+package types
+
+import (
+	"bytes"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"testing"
+	"time"
+)
+
+func createDoubleSpendProof(t *testing.T) *DoubleSpendProof {
+	ctime := time.Unix(1600000000, 0)
+	a, err := createTx(&ctime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctime = ctime.Add(time.Second)
+	b, err := createTx(&ctime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The timestamp is not part of the transaction hash, so the second
+	// spend differs by its sequence.
+	b.TxIn[0].Sequence--
+	return NewDoubleSpendProof(&a.TxIn[0].PreviousOut, a, b)
+}
+
+func Test_DoubleSpendProofOrder(t *testing.T) {
+	proof := createDoubleSpendProof(t)
+	swapped := NewDoubleSpendProof(&proof.OutPoint, proof.Second, proof.First)
+
+	first, second := proof.First.TxHash(), proof.Second.TxHash()
+	if bytes.Compare(first[:], second[:]) > 0 {
+		t.Errorf("transactions not ordered by hash, first %s, second %s",
+			first, second)
+	}
+	if proof.Hash() != swapped.Hash() {
+		t.Errorf("want %s, got %s", proof.Hash(), swapped.Hash())
+	}
+}
+
+func Test_DoubleSpendProofSerialize(t *testing.T) {
+	proof := createDoubleSpendProof(t)
+	serialized, err := proof.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(serialized) != proof.SerializeSize() {
+		t.Errorf("want size %d, got %d", proof.SerializeSize(), len(serialized))
+	}
+
+	decoded, err := NewDoubleSpendProofFromBytes(serialized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Hash() != proof.Hash() {
+		t.Errorf("want %s, got %s", proof.Hash(), decoded.Hash())
+	}
+	if decoded.OutPoint != proof.OutPoint {
+		t.Errorf("want outpoint %v, got %v", proof.OutPoint, decoded.OutPoint)
+	}
+}
+
+func Test_DoubleSpendProofSpendingInput(t *testing.T) {
+	proof := createDoubleSpendProof(t)
+	idx, err := proof.SpendingInput(proof.First)
+	if err != nil || idx != 0 {
+		t.Errorf("want input 0, got %d, %v", idx, err)
+	}
+
+	other := NewTransaction()
+	other.AddTxIn(&TxInput{
+		PreviousOut: *NewOutPoint(&hash.Hash{1}, 0),
+		Sequence:    MaxTxInSequenceNum,
+	})
+	_, err = proof.SpendingInput(other)
+	if err == nil {
+		t.Errorf("want error for a transaction not spending the outpoint")
+	}
+}
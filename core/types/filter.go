@@ -28,6 +28,11 @@ const (
 
 	// MaxFilterLoadFilterSize is the maximum size in bytes a filter may be.
 	MaxFilterLoadFilterSize = 36000
+
+	// MaxFilterAddDataSize is the maximum byte size of a data element to
+	// add to the Bloom filter.  It is equal to the maximum element size of
+	// a script.
+	MaxFilterAddDataSize = 520
 )
 
 // MsgFilterAdd implements the Message interface and represents a qitmeer
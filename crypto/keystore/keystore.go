@@ -0,0 +1,330 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package keystore implements an encrypted store of the secrets held by a
+// node, such as its p2p identity key.
+//
+// The secrets are encrypted with AES-256-GCM under a key derived from a
+// passphrase with scrypt.  The keystore must be unlocked with the passphrase
+// before its secrets can be read or written, and it is locked again after an
+// optional timeout.
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/scrypt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultFileName is the name of the keystore file in the data
+	// directory.
+	DefaultFileName = "keystore.json"
+
+	// version is the version of the keystore file format.
+	version = 1
+
+	// keySize is the size of the derived AES-256 key.
+	keySize = 32
+
+	// saltSize is the size of the scrypt salt.
+	saltSize = 32
+)
+
+var (
+	// ErrLocked is returned when a secret is accessed while the keystore is
+	// locked.
+	ErrLocked = errors.New("keystore is locked")
+
+	// ErrWrongPassphrase is returned when the passphrase does not decrypt
+	// the keystore.
+	ErrWrongPassphrase = errors.New("wrong keystore passphrase")
+
+	// ErrSecretNotFound is returned when the keystore has no secret with a
+	// name.
+	ErrSecretNotFound = errors.New("secret not found in keystore")
+
+	// checkData is encrypted along with the secrets so that a passphrase
+	// can be verified when the keystore has no secret.
+	checkData = []byte("qitmeer keystore")
+
+	// scryptN, scryptR and scryptP are the scrypt parameters of the new
+	// keystores.  They are stored in the keystore file.
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// kdfParams are the parameters of the derivation of the key from the
+// passphrase.
+type kdfParams struct {
+	Name string `json:"name"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+	Salt string `json:"salt"`
+}
+
+// keystoreFile is the JSON encoding of a keystore.  The check and the secrets
+// are hex-encoded nonces followed by the sealed data.
+type keystoreFile struct {
+	Version int               `json:"version"`
+	KDF     kdfParams         `json:"kdf"`
+	Check   string            `json:"check"`
+	Secrets map[string]string `json:"secrets"`
+}
+
+// Keystore is an encrypted store of named secrets backed by a file.
+type Keystore struct {
+	mtx   sync.Mutex
+	path  string
+	file  keystoreFile
+	aead  cipher.AEAD
+	timer *time.Timer
+}
+
+// deriveAEAD returns the cipher of the keystore file for the passphrase.
+func deriveAEAD(passphrase []byte, kdf *kdfParams) (cipher.AEAD, error) {
+	if kdf.Name != "scrypt" {
+		return nil, fmt.Errorf("unsupported keystore kdf %s", kdf.Name)
+	}
+	salt, err := hex.DecodeString(kdf.Salt)
+	if err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key(passphrase, salt, kdf.N, kdf.R, kdf.P, keySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts the data, authenticating the name along with it, and returns
+// the hex-encoded nonce and sealed data.
+func seal(aead cipher.AEAD, name string, data []byte) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(aead.Seal(nonce, nonce, data, []byte(name))), nil
+}
+
+// open decrypts the hex-encoded nonce and sealed data of the name.
+func open(aead cipher.AEAD, name string, sealed string) ([]byte, error) {
+	raw, err := hex.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < aead.NonceSize() {
+		return nil, fmt.Errorf("sealed data too short")
+	}
+	nonce := raw[:aead.NonceSize()]
+	return aead.Open(nil, nonce, raw[aead.NonceSize():], []byte(name))
+}
+
+// Exists returns whether there is a keystore file at the path.
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Create creates a new keystore without secret at the path, encrypted with
+// the passphrase.  The returned keystore is unlocked until Lock is called.
+func Create(path string, passphrase []byte) (*Keystore, error) {
+	if Exists(path) {
+		return nil, fmt.Errorf("keystore %s already exists", path)
+	}
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	ks := &Keystore{
+		path: path,
+		file: keystoreFile{
+			Version: version,
+			KDF: kdfParams{
+				Name: "scrypt",
+				N:    scryptN,
+				R:    scryptR,
+				P:    scryptP,
+				Salt: hex.EncodeToString(salt),
+			},
+			Secrets: map[string]string{},
+		},
+	}
+	aead, err := deriveAEAD(passphrase, &ks.file.KDF)
+	if err != nil {
+		return nil, err
+	}
+	ks.file.Check, err = seal(aead, "", checkData)
+	if err != nil {
+		return nil, err
+	}
+	if err := ks.write(); err != nil {
+		return nil, err
+	}
+	ks.aead = aead
+	return ks, nil
+}
+
+// Open reads the keystore file at the path.  The returned keystore is
+// locked.
+func Open(path string) (*Keystore, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ks := &Keystore{path: path}
+	if err := json.Unmarshal(data, &ks.file); err != nil {
+		return nil, fmt.Errorf("failed to decode keystore %s: %v", path, err)
+	}
+	if ks.file.Version != version {
+		return nil, fmt.Errorf("unsupported keystore version %d",
+			ks.file.Version)
+	}
+	if ks.file.Secrets == nil {
+		ks.file.Secrets = map[string]string{}
+	}
+	return ks, nil
+}
+
+// write saves the keystore file, replacing the former one only once the new
+// one is written.
+func (ks *Keystore) write() error {
+	data, err := json.MarshalIndent(&ks.file, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := ks.path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, ks.path)
+}
+
+// Unlock decrypts the keystore with the passphrase.  The keystore is locked
+// again after the timeout, unless the timeout is zero.
+//
+// This function is safe for concurrent access.
+func (ks *Keystore) Unlock(passphrase []byte, timeout time.Duration) error {
+	aead, err := deriveAEAD(passphrase, &ks.file.KDF)
+	if err != nil {
+		return err
+	}
+	if _, err := open(aead, "", ks.file.Check); err != nil {
+		return ErrWrongPassphrase
+	}
+
+	ks.mtx.Lock()
+	defer ks.mtx.Unlock()
+	ks.aead = aead
+	if ks.timer != nil {
+		ks.timer.Stop()
+		ks.timer = nil
+	}
+	if timeout > 0 {
+		ks.timer = time.AfterFunc(timeout, ks.Lock)
+	}
+	return nil
+}
+
+// Lock forgets the key of the keystore, so that its secrets can no longer be
+// accessed until it is unlocked.
+//
+// This function is safe for concurrent access.
+func (ks *Keystore) Lock() {
+	ks.mtx.Lock()
+	defer ks.mtx.Unlock()
+	ks.aead = nil
+	if ks.timer != nil {
+		ks.timer.Stop()
+		ks.timer = nil
+	}
+}
+
+// IsLocked returns whether the keystore is locked.
+//
+// This function is safe for concurrent access.
+func (ks *Keystore) IsLocked() bool {
+	ks.mtx.Lock()
+	defer ks.mtx.Unlock()
+	return ks.aead == nil
+}
+
+// Secret returns the decrypted secret with the name.
+//
+// This function is safe for concurrent access.
+func (ks *Keystore) Secret(name string) ([]byte, error) {
+	ks.mtx.Lock()
+	defer ks.mtx.Unlock()
+	if ks.aead == nil {
+		return nil, ErrLocked
+	}
+	sealed, ok := ks.file.Secrets[name]
+	if !ok {
+		return nil, ErrSecretNotFound
+	}
+	secret, err := open(ks.aead, name, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret %s: %v", name, err)
+	}
+	return secret, nil
+}
+
+// SetSecret encrypts the secret with the name and saves the keystore,
+// replacing any former secret with the name.
+//
+// This function is safe for concurrent access.
+func (ks *Keystore) SetSecret(name string, secret []byte) error {
+	ks.mtx.Lock()
+	defer ks.mtx.Unlock()
+	if ks.aead == nil {
+		return ErrLocked
+	}
+	sealed, err := seal(ks.aead, name, secret)
+	if err != nil {
+		return err
+	}
+	former, hadFormer := ks.file.Secrets[name]
+	ks.file.Secrets[name] = sealed
+	if err := ks.write(); err != nil {
+		if hadFormer {
+			ks.file.Secrets[name] = former
+		} else {
+			delete(ks.file.Secrets, name)
+		}
+		return err
+	}
+	return nil
+}
+
+// Names returns the sorted names of the secrets of the keystore, which are
+// not encrypted.
+//
+// This function is safe for concurrent access.
+func (ks *Keystore) Names() []string {
+	ks.mtx.Lock()
+	defer ks.mtx.Unlock()
+	names := make([]string, 0, len(ks.file.Secrets))
+	for name := range ks.file.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
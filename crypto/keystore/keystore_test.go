@@ -0,0 +1,110 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package keystore
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func init() {
+	// Keep the key derivation cheap for the tests.
+	scryptN = 1 << 10
+}
+
+func tempKeystorePath(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	return filepath.Join(dir, DefaultFileName), func() { os.RemoveAll(dir) }
+}
+
+func TestKeystoreSecrets(t *testing.T) {
+	path, cleanup := tempKeystorePath(t)
+	defer cleanup()
+
+	passphrase := []byte("passphrase")
+	ks, err := Create(path, passphrase)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := Create(path, passphrase); err == nil {
+		t.Fatalf("Create: expected error for an existing keystore")
+	}
+	secret := []byte{0x01, 0x02, 0x03}
+	if err := ks.SetSecret("network.key", secret); err != nil {
+		t.Fatalf("SetSecret: %v", err)
+	}
+
+	ks, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if !ks.IsLocked() {
+		t.Fatalf("Open: expected locked keystore")
+	}
+	if _, err := ks.Secret("network.key"); err != ErrLocked {
+		t.Fatalf("Secret: got %v, want %v", err, ErrLocked)
+	}
+	if err := ks.Unlock([]byte("wrong"), 0); err != ErrWrongPassphrase {
+		t.Fatalf("Unlock: got %v, want %v", err, ErrWrongPassphrase)
+	}
+	if err := ks.Unlock(passphrase, 0); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	got, err := ks.Secret("network.key")
+	if err != nil {
+		t.Fatalf("Secret: %v", err)
+	}
+	if !bytes.Equal(got, secret) {
+		t.Fatalf("Secret: got %x, want %x", got, secret)
+	}
+	if _, err := ks.Secret("unknown"); err != ErrSecretNotFound {
+		t.Fatalf("Secret: got %v, want %v", err, ErrSecretNotFound)
+	}
+	if names := ks.Names(); len(names) != 1 || names[0] != "network.key" {
+		t.Fatalf("Names: got %v", names)
+	}
+
+	// The secrets are not stored in plaintext.
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if bytes.Contains(data, []byte("010203")) {
+		t.Fatalf("keystore file holds the plaintext secret")
+	}
+
+	ks.Lock()
+	if err := ks.SetSecret("other", secret); err != ErrLocked {
+		t.Fatalf("SetSecret: got %v, want %v", err, ErrLocked)
+	}
+}
+
+func TestKeystoreRelock(t *testing.T) {
+	path, cleanup := tempKeystorePath(t)
+	defer cleanup()
+
+	passphrase := []byte("passphrase")
+	ks, err := Create(path, passphrase)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := ks.Unlock(passphrase, 50*time.Millisecond); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if ks.IsLocked() {
+		t.Fatalf("Unlock: expected unlocked keystore")
+	}
+	time.Sleep(200 * time.Millisecond)
+	if !ks.IsLocked() {
+		t.Fatalf("expected keystore locked after the timeout")
+	}
+}
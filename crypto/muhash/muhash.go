@@ -0,0 +1,135 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package muhash implements a multiplicative hash of a set of byte strings in
+// the group of the integers modulo the prime 2^3072 - 1103717.  The hash of a
+// set does not depend on the order its elements were added in, and elements
+// can be removed as well as added, so the hash of a large set which changes a
+// little at a time, such as the utxo set, is maintained incrementally instead
+// of being computed again from all of its elements.
+package muhash
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// ElementSize is the size in bytes of the numbers of the group.
+	ElementSize = 384
+
+	// SerializedSize is the size in bytes of a serialized MuHash.
+	SerializedSize = 2 * ElementSize
+
+	// DigestSize is the size in bytes of the digest of a MuHash.
+	DigestSize = sha256.Size
+)
+
+// prime is the modulus of the group, 2^3072 - 1103717.
+var prime = func() *big.Int {
+	p := new(big.Int).Lsh(big.NewInt(1), ElementSize*8)
+	return p.Sub(p, big.NewInt(1103717))
+}()
+
+// MuHash is the hash of a set of byte strings.  The elements added are
+// multiplied into the numerator and the elements removed into the
+// denominator, so that the division is only done once by Digest.  The zero
+// value is not valid, use New.
+type MuHash struct {
+	numerator   *big.Int
+	denominator *big.Int
+}
+
+// New returns the hash of the empty set.
+func New() *MuHash {
+	return &MuHash{
+		numerator:   big.NewInt(1),
+		denominator: big.NewInt(1),
+	}
+}
+
+// Deserialize returns the hash serialized by Serialize.
+func Deserialize(b []byte) (*MuHash, error) {
+	if len(b) != SerializedSize {
+		return nil, fmt.Errorf("serialized muhash of %d bytes, expected %d",
+			len(b), SerializedSize)
+	}
+	m := &MuHash{
+		numerator:   new(big.Int).SetBytes(b[:ElementSize]),
+		denominator: new(big.Int).SetBytes(b[ElementSize:]),
+	}
+	if m.numerator.Sign() == 0 || m.numerator.Cmp(prime) >= 0 ||
+		m.denominator.Sign() == 0 || m.denominator.Cmp(prime) >= 0 {
+		return nil, fmt.Errorf("serialized muhash out of range")
+	}
+	return m, nil
+}
+
+// element maps the data to a number of the group.  The data is hashed and the
+// hash is expanded to the size of the numbers of the group.
+func element(data []byte) *big.Int {
+	seed := sha256.Sum256(data)
+	var buf [ElementSize]byte
+	var input [sha256.Size + 1]byte
+	copy(input[:], seed[:])
+	for i := 0; i*sha512.Size < ElementSize; i++ {
+		input[sha256.Size] = byte(i)
+		block := sha512.Sum512(input[:])
+		copy(buf[i*sha512.Size:], block[:])
+	}
+	e := new(big.Int).SetBytes(buf[:])
+	e.Mod(e, prime)
+	// Zero has no inverse.  Its probability is negligible, but mapping it
+	// to one keeps the group operations defined.
+	if e.Sign() == 0 {
+		e.SetInt64(1)
+	}
+	return e
+}
+
+// Add adds the data to the set.
+func (m *MuHash) Add(data []byte) {
+	m.numerator.Mul(m.numerator, element(data))
+	m.numerator.Mod(m.numerator, prime)
+}
+
+// Remove removes the data from the set.  The data is expected to be in the
+// set, removing an element which was not added gives the hash of no set.
+func (m *MuHash) Remove(data []byte) {
+	m.denominator.Mul(m.denominator, element(data))
+	m.denominator.Mod(m.denominator, prime)
+}
+
+// normalize returns the number of the group representing the set.
+func (m *MuHash) normalize() *big.Int {
+	inverse := new(big.Int).ModInverse(m.denominator, prime)
+	inverse.Mul(inverse, m.numerator)
+	return inverse.Mod(inverse, prime)
+}
+
+// Serialize returns the numerator followed by the denominator as big endian
+// numbers of ElementSize bytes.
+func (m *MuHash) Serialize() []byte {
+	b := make([]byte, SerializedSize)
+	putElement(b[:ElementSize], m.numerator)
+	putElement(b[ElementSize:], m.denominator)
+	return b
+}
+
+// Digest returns the hash of the set, which is the same for the same elements
+// whatever the order they were added and removed in.
+func (m *MuHash) Digest() [DigestSize]byte {
+	var b [ElementSize]byte
+	putElement(b[:], m.normalize())
+	return sha256.Sum256(b[:])
+}
+
+// putElement writes the number of the group to b as a big endian number of
+// ElementSize bytes.
+func putElement(b []byte, e *big.Int) {
+	eb := e.Bytes()
+	copy(b[ElementSize-len(eb):], eb)
+}
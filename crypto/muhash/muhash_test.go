@@ -0,0 +1,63 @@
+package muhash
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMuHash(t *testing.T) {
+	a, b, c := []byte("a"), []byte("b"), []byte("c")
+
+	// The order of the elements doesn't matter.
+	m1 := New()
+	m1.Add(a)
+	m1.Add(b)
+	m1.Add(c)
+	m2 := New()
+	m2.Add(c)
+	m2.Add(a)
+	m2.Add(b)
+	if m1.Digest() != m2.Digest() {
+		t.Fatal("digest depends on the order of the elements")
+	}
+
+	// Removing an element gives the hash of the set without it.
+	m1.Remove(b)
+	m3 := New()
+	m3.Add(a)
+	m3.Add(c)
+	if m1.Digest() != m3.Digest() {
+		t.Fatal("removed element still hashed")
+	}
+	if m1.Digest() == m2.Digest() {
+		t.Fatal("different sets with the same digest")
+	}
+	m1.Remove(a)
+	m1.Remove(c)
+	if m1.Digest() != New().Digest() {
+		t.Fatal("emptied set differs from the empty set")
+	}
+
+	// The serialized hash keeps adding where it was left.
+	s := m3.Serialize()
+	if len(s) != SerializedSize {
+		t.Fatalf("serialized %d bytes", len(s))
+	}
+	m4, err := Deserialize(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(m4.Serialize(), s) || m4.Digest() != m3.Digest() {
+		t.Fatal("deserialized hash differs")
+	}
+	m4.Add(b)
+	if m4.Digest() != m2.Digest() {
+		t.Fatal("deserialized hash differs after adding")
+	}
+	if _, err := Deserialize(s[1:]); err == nil {
+		t.Fatal("short serialized hash accepted")
+	}
+	if _, err := Deserialize(make([]byte, SerializedSize)); err == nil {
+		t.Fatal("zero serialized hash accepted")
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package signer defines the interface of the signers of a node, so that its
+// private keys can be held by a remote signer, such as an HSM or an isolated
+// host, instead of the node.
+//
+// The remote signers speak JSON-RPC 2.0 over HTTP with two methods:
+//
+//	signer_getPubKey  ()          -> hex-encoded compressed public key
+//	signer_sign       (hash hex)  -> hex-encoded DER signature of the hash
+//
+// NewHandler serves this protocol for any Signer, so that a LocalSigner can be
+// run on an isolated host.
+package signer
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/crypto/ecc/secp256k1"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// methodGetPubKey is the method returning the public key of a remote
+	// signer.
+	methodGetPubKey = "signer_getPubKey"
+
+	// methodSign is the method signing a hash with a remote signer.
+	methodSign = "signer_sign"
+
+	// remoteTimeout is the timeout of a request to a remote signer.
+	remoteTimeout = 10 * time.Second
+
+	// maxRequestSize is the maximum size of a request served by the
+	// handler.
+	maxRequestSize = 1 << 16
+)
+
+// Signer signs hashes with a secp256k1 private key.
+type Signer interface {
+	// PubKey returns the public key of the signer.
+	PubKey() (*secp256k1.PublicKey, error)
+
+	// Sign returns the DER-encoded signature of the hash.
+	Sign(hash []byte) ([]byte, error)
+}
+
+// LocalSigner is a signer holding its private key in memory.
+type LocalSigner struct {
+	key *secp256k1.PrivateKey
+}
+
+// Ensure LocalSigner implements the Signer interface.
+var _ Signer = (*LocalSigner)(nil)
+
+// NewLocalSigner returns a signer with the private key.
+func NewLocalSigner(key *secp256k1.PrivateKey) *LocalSigner {
+	return &LocalSigner{key: key}
+}
+
+// PubKey returns the public key of the private key.
+func (s *LocalSigner) PubKey() (*secp256k1.PublicKey, error) {
+	return s.key.PubKey(), nil
+}
+
+// Sign returns the DER-encoded signature of the hash with the private key.
+func (s *LocalSigner) Sign(hash []byte) ([]byte, error) {
+	sig, err := s.key.Sign(hash)
+	if err != nil {
+		return nil, err
+	}
+	return sig.Serialize(), nil
+}
+
+// request is a JSON-RPC request to a remote signer.
+type request struct {
+	JsonRPC string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+	ID      uint64        `json:"id"`
+}
+
+// responseError is the error of a JSON-RPC response of a remote signer.
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// response is a JSON-RPC response of a remote signer.
+type response struct {
+	JsonRPC string         `json:"jsonrpc"`
+	Result  string         `json:"result,omitempty"`
+	Error   *responseError `json:"error,omitempty"`
+	ID      uint64         `json:"id"`
+}
+
+// RemoteSigner is a signer whose private key is held by a remote signer.  The
+// URL of the remote signer may hold the user and password of a HTTP basic
+// authentication.
+type RemoteSigner struct {
+	url    string
+	client *http.Client
+	nextID uint64
+
+	pubKeyMtx sync.Mutex
+	pubKey    *secp256k1.PublicKey
+}
+
+// Ensure RemoteSigner implements the Signer interface.
+var _ Signer = (*RemoteSigner)(nil)
+
+// NewRemoteSigner returns a signer whose requests are sent to the remote
+// signer at the URL.
+func NewRemoteSigner(url string) *RemoteSigner {
+	return &RemoteSigner{
+		url:    url,
+		client: &http.Client{Timeout: remoteTimeout},
+	}
+}
+
+// call sends a request to the remote signer and returns the decoded hex
+// result.
+func (s *RemoteSigner) call(method string, params ...interface{}) ([]byte, error) {
+	if params == nil {
+		params = []interface{}{}
+	}
+	req := request{
+		JsonRPC: "2.0",
+		Method:  method,
+		Params:  params,
+		ID:      atomic.AddUint64(&s.nextID, 1),
+	}
+	body, err := json.Marshal(&req)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("remote signer %s: %v", method, err)
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote signer %s: %s", method, httpResp.Status)
+	}
+	var resp response
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("remote signer %s: %v", method, err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("remote signer %s: %s", method, resp.Error.Message)
+	}
+	return hex.DecodeString(resp.Result)
+}
+
+// PubKey returns the public key of the remote signer, which is fetched once.
+func (s *RemoteSigner) PubKey() (*secp256k1.PublicKey, error) {
+	s.pubKeyMtx.Lock()
+	defer s.pubKeyMtx.Unlock()
+	if s.pubKey != nil {
+		return s.pubKey, nil
+	}
+	serialized, err := s.call(methodGetPubKey)
+	if err != nil {
+		return nil, err
+	}
+	pubKey, err := secp256k1.ParsePubKey(serialized)
+	if err != nil {
+		return nil, err
+	}
+	s.pubKey = pubKey
+	return pubKey, nil
+}
+
+// Sign returns the DER-encoded signature of the hash by the remote signer.
+// The signature is checked against the public key of the remote signer.
+func (s *RemoteSigner) Sign(hash []byte) ([]byte, error) {
+	pubKey, err := s.PubKey()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := s.call(methodSign, hex.EncodeToString(hash))
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := secp256k1.ParseDERSignature(sig, secp256k1.S256())
+	if err != nil {
+		return nil, fmt.Errorf("remote signer %s: %v", methodSign, err)
+	}
+	if !parsed.Verify(hash, pubKey) {
+		return nil, fmt.Errorf("remote signer %s: invalid signature", methodSign)
+	}
+	return sig, nil
+}
+
+// handler serves the remote signer protocol for a signer.
+type handler struct {
+	signer Signer
+	user   string
+	pass   string
+}
+
+// NewHandler returns a HTTP handler serving the remote signer protocol for the
+// signer.  The requests must use a HTTP basic authentication with the user and
+// password unless the user is empty.
+func NewHandler(signer Signer, user string, pass string) http.Handler {
+	return &handler{signer: signer, user: user, pass: pass}
+}
+
+// authorized returns whether the request holds the credentials of the
+// handler.
+func (h *handler) authorized(r *http.Request) bool {
+	if len(h.user) == 0 {
+		return true
+	}
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	userOk := subtle.ConstantTimeCompare([]byte(user), []byte(h.user)) == 1
+	passOk := subtle.ConstantTimeCompare([]byte(pass), []byte(h.pass)) == 1
+	return userOk && passOk
+}
+
+// ServeHTTP serves a request of the remote signer protocol.
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "405 Method Not Allowed.", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.authorized(r) {
+		w.Header().Add("WWW-Authenticate", `Basic realm="qitmeer signer"`)
+		http.Error(w, "401 Unauthorized.", http.StatusUnauthorized)
+		return
+	}
+	var req struct {
+		Method string   `json:"method"`
+		Params []string `json:"params"`
+		ID     uint64   `json:"id"`
+	}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req)
+	if err != nil {
+		http.Error(w, "400 Bad Request.", http.StatusBadRequest)
+		return
+	}
+	resp := response{JsonRPC: "2.0", ID: req.ID}
+	result, err := h.handle(req.Method, req.Params)
+	if err != nil {
+		resp.Error = &responseError{Code: -1, Message: err.Error()}
+	} else {
+		resp.Result = hex.EncodeToString(result)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&resp)
+}
+
+// handle executes a method of the remote signer protocol.
+func (h *handler) handle(method string, params []string) ([]byte, error) {
+	switch method {
+	case methodGetPubKey:
+		pubKey, err := h.signer.PubKey()
+		if err != nil {
+			return nil, err
+		}
+		return pubKey.SerializeCompressed(), nil
+	case methodSign:
+		if len(params) != 1 {
+			return nil, fmt.Errorf("%s expects 1 parameter", methodSign)
+		}
+		hash, err := hex.DecodeString(params[0])
+		if err != nil {
+			return nil, err
+		}
+		return h.signer.Sign(hash)
+	}
+	return nil, fmt.Errorf("unknown method %s", method)
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package signer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Qitmeer/qitmeer/crypto/ecc/secp256k1"
+)
+
+func TestRemoteSigner(t *testing.T) {
+	key, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("GeneratePrivateKey: %v", err)
+	}
+	server := httptest.NewServer(NewHandler(NewLocalSigner(key), "user", "pass"))
+	defer server.Close()
+
+	url := strings.Replace(server.URL, "http://", "http://user:pass@", 1)
+	remote := NewRemoteSigner(url)
+	pubKey, err := remote.PubKey()
+	if err != nil {
+		t.Fatalf("PubKey: %v", err)
+	}
+	if !bytes.Equal(pubKey.SerializeCompressed(), key.PubKey().SerializeCompressed()) {
+		t.Fatalf("PubKey: got %x, want %x", pubKey.SerializeCompressed(),
+			key.PubKey().SerializeCompressed())
+	}
+
+	hash := sha256.Sum256([]byte("qitmeer"))
+	sig, err := remote.Sign(hash[:])
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	parsed, err := secp256k1.ParseDERSignature(sig, secp256k1.S256())
+	if err != nil {
+		t.Fatalf("ParseDERSignature: %v", err)
+	}
+	if !parsed.Verify(hash[:], key.PubKey()) {
+		t.Fatalf("Sign: invalid signature")
+	}
+
+	// The handler rejects the requests without the credentials.
+	if _, err := NewRemoteSigner(server.URL).PubKey(); err == nil {
+		t.Fatalf("PubKey: expected error without credentials")
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2017-2018 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ColdFile is a block file opened for reading from cold storage.
+type ColdFile interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// ColdStorage is a secondary, usually cheaper and slower, storage for the
+// ancient block files of a database.  Block files are immutable once they
+// are full, so an implementation only needs to store and read whole files.
+// This allows backends such as a mounted archive disk or an object store.
+type ColdStorage interface {
+	// Put stores the named block file with the content of the reader.  An
+	// existing file with the same name is replaced.
+	Put(name string, r io.Reader) error
+
+	// Open opens the named block file for reading.
+	Open(name string) (ColdFile, error)
+
+	// Size returns the size of the named block file.  An error is returned
+	// when the file does not exist.
+	Size(name string) (int64, error)
+}
+
+// BlockArchiver is implemented by databases which are able to move ancient
+// block files into cold storage.  Archived blocks can still be read
+// transparently.
+type BlockArchiver interface {
+	// ArchiveBlocks moves every block file that was completely written
+	// before the given block to cold storage and returns the number of
+	// moved files.
+	ArchiveBlocks(before *hash.Hash) (int, error)
+}
+
+// BlockPruner is implemented by databases which are able to discard the
+// bodies of ancient blocks.  The headers of pruned blocks can still be
+// fetched, while fetching their bodies returns ErrBlockPruned.
+type BlockPruner interface {
+	// PruneBlocks deletes every block file that was completely written
+	// before the given block and returns the number of deleted files.
+	PruneBlocks(before *hash.Hash) (int, error)
+}
+
+// dirColdStorage is a ColdStorage which keeps the block files in a directory
+// of the local file system.
+type dirColdStorage struct {
+	path string
+}
+
+// NewDirColdStorage returns a cold storage backed by the given directory,
+// which is created when needed.
+func NewDirColdStorage(path string) (ColdStorage, error) {
+	err := os.MkdirAll(path, 0700)
+	if err != nil {
+		return nil, err
+	}
+	return &dirColdStorage{path: path}, nil
+}
+
+func (s *dirColdStorage) Put(name string, r io.Reader) error {
+	// Write to a temporary file first so that a crash never leaves a
+	// truncated block file behind.
+	filePath := filepath.Join(s.path, name)
+	tmpPath := filePath + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, r)
+	if err == nil {
+		err = file.Sync()
+	}
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, filePath)
+}
+
+func (s *dirColdStorage) Open(name string) (ColdFile, error) {
+	return os.Open(filepath.Join(s.path, name))
+}
+
+func (s *dirColdStorage) Size(name string) (int64, error) {
+	fi, err := os.Stat(filepath.Join(s.path, name))
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
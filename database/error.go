@@ -118,6 +118,10 @@ const (
 	// ErrBlockNotFound instead.
 	ErrBlockRegionInvalid
 
+	// ErrBlockPruned indicates the body of the requested block was
+	// discarded by pruning.  The header of the block is still available.
+	ErrBlockPruned
+
 	// ***********************************
 	// Support for driver-specific errors.
 	// ***********************************
@@ -154,6 +158,7 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrBlockNotFound:      "ErrBlockNotFound",
 	ErrBlockExists:        "ErrBlockExists",
 	ErrBlockRegionInvalid: "ErrBlockRegionInvalid",
+	ErrBlockPruned:        "ErrBlockPruned",
 	ErrDriverSpecific:     "ErrDriverSpecific",
 }
 
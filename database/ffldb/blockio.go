@@ -12,6 +12,7 @@ package ffldb
 import (
 	"container/list"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"github.com/Qitmeer/qitmeer/common/hash"
 	"github.com/Qitmeer/qitmeer/core/protocol"
@@ -21,6 +22,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 )
 
 const (
@@ -120,6 +122,15 @@ type blockStore struct {
 	// basePath is the base path used for the flat block files and metadata.
 	basePath string
 
+	// coldStorage is the optional secondary storage that ancient block
+	// files are moved to.  Block files missing from basePath are read from
+	// there.
+	coldStorage database.ColdStorage
+
+	// prunedFiles is the number of block files, counted from the first one,
+	// which were deleted by pruning.  It must be accessed atomically.
+	prunedFiles uint32
+
 	// The following fields are related to the flat files which hold the
 	// actual blocks.   The number of open files is limited by maxOpenFiles.
 	//
@@ -227,6 +238,24 @@ func blockFilePath(dbPath string, fileNum uint32) string {
 	return filepath.Join(dbPath, fileName)
 }
 
+// coldFile wraps a block file of the cold storage so it can be used as a
+// read-only filer.
+type coldFile struct {
+	database.ColdFile
+}
+
+func (f *coldFile) WriteAt(b []byte, off int64) (int, error) {
+	return 0, errors.New("cold block file is read only")
+}
+
+func (f *coldFile) Truncate(size int64) error {
+	return errors.New("cold block file is read only")
+}
+
+func (f *coldFile) Sync() error {
+	return nil
+}
+
 // openWriteFile returns a file handle for the passed flat file number in
 // read/write mode.  The file will be created if needed.  It is typically used
 // for the current file that will have all new data appended.  Unlike openFile,
@@ -254,10 +283,21 @@ func (s *blockStore) openWriteFile(fileNum uint32) (filer, error) {
 // This function MUST be called with the overall files mutex (s.obfMutex) locked
 // for WRITES.
 func (s *blockStore) openFile(fileNum uint32) (*lockableFile, error) {
-	// Open the appropriate file as read-only.
+	// Open the appropriate file as read-only.  Files which are not found
+	// locally may have been moved to the cold storage.
+	var file filer
 	filePath := blockFilePath(s.basePath, fileNum)
-	file, err := os.Open(filePath)
-	if err != nil {
+	localFile, err := os.Open(filePath)
+	if err == nil {
+		file = localFile
+	} else if os.IsNotExist(err) && s.coldStorage != nil {
+		cf, coldErr := s.coldStorage.Open(filepath.Base(filePath))
+		if coldErr != nil {
+			return nil, makeDbErr(database.ErrDriverSpecific,
+				coldErr.Error(), coldErr)
+		}
+		file = &coldFile{cf}
+	} else {
 		return nil, makeDbErr(database.ErrDriverSpecific, err.Error(),
 			err)
 	}
@@ -311,6 +351,122 @@ func (s *blockStore) deleteFile(fileNum uint32) error {
 	return nil
 }
 
+// closeFile closes the open file handle for the passed flat file number, if
+// any, and removes it from the least recently used tracking.
+//
+// This function MUST be called with the overall files mutex (s.obfMutex) locked
+// for WRITES.
+func (s *blockStore) closeFile(fileNum uint32) {
+	blockFile, ok := s.openBlockFiles[fileNum]
+	if !ok {
+		return
+	}
+	s.lruMutex.Lock()
+	if elem, ok := s.fileNumToLRUElem[fileNum]; ok {
+		s.openBlocksLRU.Remove(elem)
+		delete(s.fileNumToLRUElem, fileNum)
+	}
+	s.lruMutex.Unlock()
+
+	// Close the file under the write lock for the file in case any readers
+	// are currently reading from it.
+	blockFile.Lock()
+	_ = blockFile.file.Close()
+	blockFile.Unlock()
+	delete(s.openBlockFiles, fileNum)
+}
+
+// archiveFiles moves all of the block files before the passed flat file number
+// to the cold storage and removes the local copies.  The current write file
+// is never archived.  It returns the number of moved files.
+func (s *blockStore) archiveFiles(endFileNum uint32) (int, error) {
+	if s.coldStorage == nil {
+		str := "no cold storage is configured"
+		return 0, makeDbErr(database.ErrDriverSpecific, str, nil)
+	}
+	wc := s.writeCursor
+	wc.RLock()
+	if endFileNum > wc.curFileNum {
+		endFileNum = wc.curFileNum
+	}
+	wc.RUnlock()
+
+	archived := 0
+	for fileNum := uint32(0); fileNum < endFileNum; fileNum++ {
+		filePath := blockFilePath(s.basePath, fileNum)
+		file, err := os.Open(filePath)
+		if os.IsNotExist(err) {
+			// Already archived.
+			continue
+		}
+		if err != nil {
+			return archived, makeDbErr(database.ErrDriverSpecific,
+				err.Error(), err)
+		}
+		err = s.coldStorage.Put(filepath.Base(filePath), file)
+		_ = file.Close()
+		if err != nil {
+			str := fmt.Sprintf("failed to archive file %q: %v",
+				filePath, err)
+			return archived, makeDbErr(database.ErrDriverSpecific, str, err)
+		}
+
+		// Readers reopen the file from the cold storage once the local
+		// copy is gone.
+		s.obfMutex.Lock()
+		s.closeFile(fileNum)
+		err = os.Remove(filePath)
+		s.obfMutex.Unlock()
+		if err != nil {
+			return archived, makeDbErr(database.ErrDriverSpecific,
+				err.Error(), err)
+		}
+		dblog.Debug("Archived block file", "fileNum", fileNum)
+		archived++
+	}
+	return archived, nil
+}
+
+// pruneFiles deletes all of the block files before the passed flat file number
+// and records them as pruned.  The current write file is never pruned.  It
+// returns the number of deleted files.  The pruned file number is persisted by
+// the passed function before any file is deleted.
+func (s *blockStore) pruneFiles(endFileNum uint32, persist func(uint32) error) (int, error) {
+	wc := s.writeCursor
+	wc.RLock()
+	if endFileNum > wc.curFileNum {
+		endFileNum = wc.curFileNum
+	}
+	wc.RUnlock()
+
+	if endFileNum <= atomic.LoadUint32(&s.prunedFiles) {
+		return 0, nil
+	}
+	if err := persist(endFileNum); err != nil {
+		return 0, err
+	}
+	atomic.StoreUint32(&s.prunedFiles, endFileNum)
+
+	pruned := 0
+	for fileNum := uint32(0); fileNum < endFileNum; fileNum++ {
+		s.obfMutex.Lock()
+		s.closeFile(fileNum)
+		err := os.Remove(blockFilePath(s.basePath, fileNum))
+		s.obfMutex.Unlock()
+		if os.IsNotExist(err) {
+			// Already pruned.
+			continue
+		}
+		if err != nil {
+			return pruned, makeDbErr(database.ErrDriverSpecific,
+				err.Error(), err)
+		}
+		dblog.Debug("Pruned block file", "fileNum", fileNum)
+		pruned++
+	}
+	return pruned, nil
+}
+
 // blockFile attempts to return an existing file handle for the passed flat file
 // number if it is already open as well as marking it as most recently used.  It
 // will also open the file when it's not already open subject to the rules
@@ -322,6 +478,11 @@ func (s *blockStore) deleteFile(fileNum uint32) error {
 // separate goroutine to close the file after it is returned from here, but
 // before the caller has acquired a read lock.
 func (s *blockStore) blockFile(fileNum uint32) (*lockableFile, error) {
+	if fileNum < atomic.LoadUint32(&s.prunedFiles) {
+		str := fmt.Sprintf("block file %d was pruned", fileNum)
+		return nil, makeDbErr(database.ErrBlockPruned, str, nil)
+	}
+
 	// When the requested block file is open for writes, return it.
 	wc := s.writeCursor
 	wc.RLock()
@@ -720,14 +881,26 @@ func (s *blockStore) handleRollback(oldBlockFileNum, oldBlockOffset uint32) {
 // current write cursor which is also stored in the metadata.  Thus, it is used
 // to detect unexpected shutdowns in the middle of writes so the block files
 // can be reconciled.
-func scanBlockFiles(dbPath string) (int, uint32) {
+//
+// Block files which were moved to the passed cold storage are part of the
+// scan as well, while the scan starts after the pruned block files.
+func scanBlockFiles(dbPath string, cold database.ColdStorage, prunedFiles uint32) (int, uint32) {
 	lastFile := -1
 	fileLen := uint32(0)
-	for i := 0; ; i++ {
+	for i := int(prunedFiles); ; i++ {
 		filePath := blockFilePath(dbPath, uint32(i))
 		st, err := os.Stat(filePath)
 		if err != nil {
-			break
+			if cold == nil {
+				break
+			}
+			size, err := cold.Size(filepath.Base(filePath))
+			if err != nil {
+				break
+			}
+			lastFile = i
+			fileLen = uint32(size)
+			continue
 		}
 		lastFile = i
 
@@ -740,20 +913,22 @@ func scanBlockFiles(dbPath string) (int, uint32) {
 }
 
 // newBlockStore returns a new block store with the current block file number
-// and offset set and all fields initialized.
-func newBlockStore(basePath string, network protocol.Network) *blockStore {
+// and offset set and all fields initialized.  The cold storage is optional.
+func newBlockStore(basePath string, network protocol.Network, cold database.ColdStorage, prunedFiles uint32) *blockStore {
 	// Look for the end of the latest block to file to determine what the
 	// write cursor position is from the viewpoing of the block files on
 	// disk.
-	fileNum, fileOff := scanBlockFiles(basePath)
+	fileNum, fileOff := scanBlockFiles(basePath, cold, prunedFiles)
 	if fileNum == -1 {
-		fileNum = 0
+		fileNum = int(prunedFiles)
 		fileOff = 0
 	}
 
 	store := &blockStore{
 		network:          network,
 		basePath:         basePath,
+		coldStorage:      cold,
+		prunedFiles:      prunedFiles,
 		maxBlockFileSize: maxBlockFileSize,
 		openBlockFiles:   make(map[uint32]*lockableFile),
 		openBlocksLRU:    list.New(),
@@ -0,0 +1,271 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ffldb
+
+import (
+	"github.com/Qitmeer/qitmeer/core/protocol"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/core/types/pow"
+	"github.com/Qitmeer/qitmeer/database"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// blocksPerTestFile is the number of test blocks written to each block file.
+const blocksPerTestFile = 2
+
+// testBlocks returns n blocks of the same size which differ by their nonce.
+func testBlocks(n int) []*types.SerializedBlock {
+	blocks := make([]*types.SerializedBlock, 0, n)
+	for i := 0; i < n; i++ {
+		block := &types.Block{}
+		block.Header.Timestamp = time.Unix(1600000000, 0)
+		block.Header.Pow = pow.GetInstance(pow.BLAKE2BD, uint64(i), []byte{})
+		blocks = append(blocks, types.NewBlock(block))
+	}
+	return blocks
+}
+
+// openTestDB opens the database at dbPath with block files small enough to
+// hold blocksPerTestFile of the test blocks.
+func openTestDB(t *testing.T, dbPath string, cold database.ColdStorage, create bool) *db {
+	idb, err := openDB(dbPath, protocol.MainNet, cold, create)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdb := idb.(*db)
+	blockBytes, err := testBlocks(1)[0].Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each block is stored with its network, length and checksum.
+	pdb.store.maxBlockFileSize = uint32(blocksPerTestFile * (len(blockBytes) + 12))
+	return pdb
+}
+
+// storeTestBlocks stores the blocks in order, one per transaction.
+func storeTestBlocks(t *testing.T, pdb *db, blocks []*types.SerializedBlock) {
+	for _, block := range blocks {
+		err := pdb.Update(func(dbTx database.Tx) error {
+			return dbTx.StoreBlock(block)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// fetchTestBlock returns the error of fetching the block, after checking
+// that the fetched bytes are the ones of the block.
+func fetchTestBlock(t *testing.T, pdb *db, block *types.SerializedBlock) error {
+	return pdb.View(func(dbTx database.Tx) error {
+		blockBytes, err := dbTx.FetchBlock(block.Hash())
+		if err != nil {
+			return err
+		}
+		want, err := block.Bytes()
+		if err != nil {
+			return err
+		}
+		if string(blockBytes) != string(want) {
+			t.Fatalf("block %s: fetched bytes mismatch", block.Hash())
+		}
+		return nil
+	})
+}
+
+// mustFetchTestBlocks ensures every block can be fetched.
+func mustFetchTestBlocks(t *testing.T, pdb *db, blocks []*types.SerializedBlock) {
+	for i, block := range blocks {
+		if err := fetchTestBlock(t, pdb, block); err != nil {
+			t.Fatalf("block %d: %v", i, err)
+		}
+	}
+}
+
+// tempTestDir returns a temporary directory and a function removing it.
+func tempTestDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "ffldb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func TestArchiveBlocks(t *testing.T) {
+	dir, cleanup := tempTestDir(t)
+	defer cleanup()
+	dbPath := filepath.Join(dir, "blocks_ffldb")
+	cold, err := database.NewDirColdStorage(filepath.Join(dir, "cold"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pdb := openTestDB(t, dbPath, cold, true)
+	blocks := testBlocks(3 * blocksPerTestFile)
+	storeTestBlocks(t, pdb, blocks)
+
+	// The files before the one holding the fifth block are moved, while
+	// the current write file is kept.
+	archived, err := pdb.ArchiveBlocks(blocks[2*blocksPerTestFile].Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archived != 2 {
+		t.Fatalf("archived %d files, want 2", archived)
+	}
+	for fileNum := uint32(0); fileNum < 3; fileNum++ {
+		name := filepath.Base(blockFilePath(dbPath, fileNum))
+		_, localErr := os.Stat(blockFilePath(dbPath, fileNum))
+		_, coldErr := cold.Size(name)
+		if archivedFile := fileNum < 2; archivedFile != os.IsNotExist(localErr) ||
+			archivedFile != (coldErr == nil) {
+			t.Fatalf("file %d: local error %v, cold error %v", fileNum,
+				localErr, coldErr)
+		}
+	}
+	mustFetchTestBlocks(t, pdb, blocks)
+
+	// Archiving again moves nothing.
+	archived, err = pdb.ArchiveBlocks(blocks[2*blocksPerTestFile].Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archived != 0 {
+		t.Fatalf("archived %d files again", archived)
+	}
+	if err := pdb.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The archived files are part of the scan for the write cursor only
+	// with the cold storage.
+	if fileNum, _ := scanBlockFiles(dbPath, nil, 0); fileNum != -1 {
+		t.Fatalf("scan without cold storage found file %d", fileNum)
+	}
+	fileNum, fileLen := scanBlockFiles(dbPath, cold, 0)
+	if fileNum != 2 || fileLen != pdb.store.maxBlockFileSize {
+		t.Fatalf("scan found file %d with length %d, want file 2 with "+
+			"length %d", fileNum, fileLen, pdb.store.maxBlockFileSize)
+	}
+
+	// The archived blocks are fetched from the cold storage after a
+	// restart, and new blocks are written after them.
+	pdb = openTestDB(t, dbPath, cold, false)
+	defer pdb.Close()
+	mustFetchTestBlocks(t, pdb, blocks)
+	more := testBlocks(4 * blocksPerTestFile)[3*blocksPerTestFile:]
+	storeTestBlocks(t, pdb, more)
+	mustFetchTestBlocks(t, pdb, more)
+	if _, err := os.Stat(blockFilePath(dbPath, 3)); err != nil {
+		t.Fatalf("new blocks not written to file 3: %v", err)
+	}
+}
+
+func TestArchiveBlocksWithoutColdStorage(t *testing.T) {
+	dir, cleanup := tempTestDir(t)
+	defer cleanup()
+
+	pdb := openTestDB(t, filepath.Join(dir, "blocks_ffldb"), nil, true)
+	defer pdb.Close()
+	blocks := testBlocks(2 * blocksPerTestFile)
+	storeTestBlocks(t, pdb, blocks)
+
+	last := blocks[len(blocks)-1].Hash()
+	_, err := pdb.ArchiveBlocks(last)
+	if dbErr, ok := err.(database.Error); !ok ||
+		dbErr.ErrorCode != database.ErrDriverSpecific {
+		t.Fatalf("ArchiveBlocks: got %v, want ErrDriverSpecific", err)
+	}
+	mustFetchTestBlocks(t, pdb, blocks)
+}
+
+// mustPrunedTestBlocks ensures the bodies of every block are pruned while
+// their headers can still be fetched.
+func mustPrunedTestBlocks(t *testing.T, pdb *db, blocks []*types.SerializedBlock) {
+	for i, block := range blocks {
+		err := fetchTestBlock(t, pdb, block)
+		if !database.IsError(err, database.ErrBlockPruned) {
+			t.Fatalf("block %d: got %v, want ErrBlockPruned", i, err)
+		}
+		err = pdb.View(func(dbTx database.Tx) error {
+			header, err := dbTx.FetchBlockHeader(block.Hash())
+			if err != nil {
+				return err
+			}
+			blockBytes, err := block.Bytes()
+			if err != nil {
+				return err
+			}
+			if string(header) != string(blockBytes[:blockHdrSize]) {
+				t.Fatalf("block %d: fetched header mismatch", i)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("block %d: %v", i, err)
+		}
+	}
+}
+
+func TestPruneBlocks(t *testing.T) {
+	dir, cleanup := tempTestDir(t)
+	defer cleanup()
+	dbPath := filepath.Join(dir, "blocks_ffldb")
+
+	pdb := openTestDB(t, dbPath, nil, true)
+	blocks := testBlocks(3 * blocksPerTestFile)
+	storeTestBlocks(t, pdb, blocks)
+
+	// The files before the one holding the fifth block are deleted.
+	pruned, err := pdb.PruneBlocks(blocks[2*blocksPerTestFile].Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != 2 {
+		t.Fatalf("pruned %d files, want 2", pruned)
+	}
+	for fileNum := uint32(0); fileNum < 3; fileNum++ {
+		_, err := os.Stat(blockFilePath(dbPath, fileNum))
+		if prunedFile := fileNum < 2; prunedFile != os.IsNotExist(err) {
+			t.Fatalf("file %d: stat error %v", fileNum, err)
+		}
+	}
+	mustPrunedTestBlocks(t, pdb, blocks[:2*blocksPerTestFile])
+	mustFetchTestBlocks(t, pdb, blocks[2*blocksPerTestFile:])
+
+	// The current write file is never pruned.
+	pruned, err = pdb.PruneBlocks(blocks[len(blocks)-1].Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != 0 {
+		t.Fatalf("pruned %d files again", pruned)
+	}
+	mustFetchTestBlocks(t, pdb, blocks[2*blocksPerTestFile:])
+	if err := pdb.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The pruned files are remembered after a restart, and new blocks are
+	// written after the remaining ones.
+	pdb = openTestDB(t, dbPath, nil, false)
+	defer pdb.Close()
+	if pdb.store.prunedFiles != 2 {
+		t.Fatalf("reopened with %d pruned files, want 2",
+			pdb.store.prunedFiles)
+	}
+	mustPrunedTestBlocks(t, pdb, blocks[:2*blocksPerTestFile])
+	mustFetchTestBlocks(t, pdb, blocks[2*blocksPerTestFile:])
+	more := testBlocks(4 * blocksPerTestFile)[3*blocksPerTestFile:]
+	storeTestBlocks(t, pdb, more)
+	mustFetchTestBlocks(t, pdb, more)
+	if _, err := os.Stat(blockFilePath(dbPath, 3)); err != nil {
+		t.Fatalf("new blocks not written to file 3: %v", err)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"sync"
 
 	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/common/perf"
 	"github.com/Qitmeer/qitmeer/core/protocol"
 	"github.com/Qitmeer/qitmeer/core/types"
 	"github.com/Qitmeer/qitmeer/database"
@@ -76,6 +77,10 @@ var (
 	// writeLocKeyName is the key used to store the current write file
 	// location.
 	writeLocKeyName = []byte("ffldb-writeloc")
+
+	// prunedFilesKeyName is the key used to store the number of block
+	// files deleted by pruning.
+	prunedFilesKeyName = []byte("ffldb-prunedfiles")
 )
 
 // Common error strings.
@@ -556,6 +561,10 @@ func newCursor(b *bucket, bucketID []byte, cursorTyp cursorType) *cursor {
 type bucket struct {
 	tx *transaction
 	id [4]byte
+
+	// name is the name of the top level bucket holding the bucket, which
+	// the accesses are recorded under.
+	name string
 }
 
 // Enforce bucket implements the database.Bucket interface.
@@ -574,6 +583,16 @@ func bucketIndexKey(parentID [4]byte, key []byte) []byte {
 	return indexKey
 }
 
+// childBucketName returns the name the accesses of the child bucket with the
+// given key are recorded under: its key for a top level bucket, or else the
+// name of its parent.
+func childBucketName(parent *bucket, key []byte) string {
+	if parent.id == metadataBucketID {
+		return string(key)
+	}
+	return parent.name
+}
+
 // bucketizedKey returns the actual key to use for storing and retrieving a key
 // for the provided bucket ID.  This is required because bucketizing is handled
 // through the use of a unique prefix per bucket.
@@ -603,7 +622,7 @@ func (b *bucket) Bucket(key []byte) database.Bucket {
 		return nil
 	}
 
-	childBucket := &bucket{tx: b.tx}
+	childBucket := &bucket{tx: b.tx, name: childBucketName(b, key)}
 	copy(childBucket.id[:], childID)
 	return childBucket
 }
@@ -662,7 +681,7 @@ func (b *bucket) CreateBucket(key []byte) (database.Bucket, error) {
 		str := fmt.Sprintf("failed to create bucket with key %q", key)
 		return nil, convertErr(str, err)
 	}
-	return &bucket{tx: b.tx, id: childID}, nil
+	return &bucket{tx: b.tx, id: childID, name: childBucketName(b, key)}, nil
 }
 
 // CreateBucketIfNotExists creates and returns a new nested bucket with the
@@ -887,6 +906,7 @@ func (b *bucket) Put(key, value []byte) error {
 		return makeDbErr(database.ErrKeyRequired, str, nil)
 	}
 
+	perf.Record(perf.DBWrite, b.name, 0)
 	return b.tx.putKey(bucketizedKey(b.id, key), value)
 }
 
@@ -910,6 +930,7 @@ func (b *bucket) Get(key []byte) []byte {
 		return nil
 	}
 
+	perf.Record(perf.DBRead, b.name, 0)
 	return b.tx.fetchKey(bucketizedKey(b.id, key))
 }
 
@@ -940,6 +961,7 @@ func (b *bucket) Delete(key []byte) error {
 		return nil
 	}
 
+	perf.Record(perf.DBWrite, b.name, 0)
 	b.tx.deleteKey(bucketizedKey(b.id, key), true)
 	return nil
 }
@@ -1797,6 +1819,9 @@ type db struct {
 // Enforce db implements the database.DB interface.
 var _ database.DB = (*db)(nil)
 
+// Enforce db implements the database.BlockArchiver interface.
+var _ database.BlockArchiver = (*db)(nil)
+
 // Type returns the database driver type the current database instance was
 // created with.
 //
@@ -1855,8 +1880,9 @@ func (db *db) begin(writable bool) (*transaction, error) {
 		pendingKeys:   treap.NewMutable(),
 		pendingRemove: treap.NewMutable(),
 	}
-	tx.metaBucket = &bucket{tx: tx, id: metadataBucketID}
-	tx.blockIdxBucket = &bucket{tx: tx, id: blockIdxBucketID}
+	tx.metaBucket = &bucket{tx: tx, id: metadataBucketID, name: "metadata"}
+	tx.blockIdxBucket = &bucket{tx: tx, id: blockIdxBucketID,
+		name: string(blockIdxBucketName)}
 	return tx, nil
 }
 
@@ -2001,6 +2027,76 @@ func (db *db) Close() error {
 	return closeErr
 }
 
+// ArchiveBlocks moves every block file that was completely written before the
+// given block to the cold storage the database was opened with.  The blocks
+// can still be fetched as usual afterwards.
+//
+// This function is part of the database.BlockArchiver interface implementation.
+func (db *db) ArchiveBlocks(before *hash.Hash) (int, error) {
+	var loc blockLocation
+	err := db.View(func(dbTx database.Tx) error {
+		blockRow, err := dbTx.(*transaction).fetchBlockRow(before)
+		if err != nil {
+			return err
+		}
+		loc = deserializeBlockLoc(blockRow)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return db.store.archiveFiles(loc.blockFileNum)
+}
+
+// PruneBlocks deletes every block file that was completely written before the
+// given block.  The headers of the pruned blocks can still be fetched, while
+// fetching their bodies returns ErrBlockPruned.
+//
+// This function is part of the database.BlockPruner interface implementation.
+func (db *db) PruneBlocks(before *hash.Hash) (int, error) {
+	var loc blockLocation
+	err := db.View(func(dbTx database.Tx) error {
+		blockRow, err := dbTx.(*transaction).fetchBlockRow(before)
+		if err != nil {
+			return err
+		}
+		loc = deserializeBlockLoc(blockRow)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return db.store.pruneFiles(loc.blockFileNum, func(prunedFiles uint32) error {
+		// The pruned file number is written synchronously, since the
+		// block files can not be found anymore once deleted.
+		var serialized [4]byte
+		byteOrder.PutUint32(serialized[:], prunedFiles)
+		err := db.cache.ldb.Put(bucketizedKey(metadataBucketID,
+			prunedFilesKeyName), serialized[:], &opt.WriteOptions{Sync: true})
+		if err != nil {
+			return convertErr(err.Error(), err)
+		}
+		return nil
+	})
+}
+
+// fetchPrunedFiles returns the number of block files deleted by pruning.
+func fetchPrunedFiles(ldb *leveldb.DB) (uint32, error) {
+	serialized, err := ldb.Get(bucketizedKey(metadataBucketID,
+		prunedFilesKeyName), nil)
+	if err == leveldb.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, convertErr(err.Error(), err)
+	}
+	if len(serialized) != 4 {
+		str := "malformed number of pruned block files"
+		return 0, makeDbErr(database.ErrCorruption, str, nil)
+	}
+	return byteOrder.Uint32(serialized), nil
+}
+
 // filesExists reports whether the named file or directory exists.
 func fileExists(name string) bool {
 	if _, err := os.Stat(name); err != nil {
@@ -2042,7 +2138,7 @@ func initDB(ldb *leveldb.DB) error {
 
 // openDB opens the database at the provided path.  database.ErrDbDoesNotExist
 // is returned if the database doesn't exist and the create flag is not set.
-func openDB(dbPath string, network protocol.Network, create bool) (database.DB, error) {
+func openDB(dbPath string, network protocol.Network, cold database.ColdStorage, create bool) (database.DB, error) {
 	// Error if the database doesn't exist and the create flag is not set.
 	metadataDbPath := filepath.Join(dbPath, metadataDbName)
 	dbExists := fileExists(metadataDbPath)
@@ -2076,7 +2172,12 @@ func openDB(dbPath string, network protocol.Network, create bool) (database.DB,
 	// according to the data that is actually on disk.  Also create the
 	// database cache which wraps the underlying leveldb database to provide
 	// write caching.
-	store := newBlockStore(dbPath, network)
+	prunedFiles, err := fetchPrunedFiles(ldb)
+	if err != nil {
+		_ = ldb.Close()
+		return nil, err
+	}
+	store := newBlockStore(dbPath, network, cold, prunedFiles)
 	cache := newDbCache(ldb, store, defaultCacheSize, defaultFlushSecs)
 	pdb := &db{store: store, cache: cache}
 
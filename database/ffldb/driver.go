@@ -19,49 +19,59 @@ const (
 	dbType = "ffldb"
 )
 
-// parseArgs parses the arguments from the database Open/Create methods.
-func parseArgs(funcName string, args ...interface{}) (string, protocol.Network, error) {
-	if len(args) != 2 {
-		return "", 0, fmt.Errorf("invalid arguments to %s.%s -- "+
-			"expected database path and block network", dbType,
-			funcName)
+// parseArgs parses the arguments from the database Open/Create methods.  The
+// third argument, the cold storage for ancient block files, is optional.
+func parseArgs(funcName string, args ...interface{}) (string, protocol.Network, database.ColdStorage, error) {
+	if len(args) != 2 && len(args) != 3 {
+		return "", 0, nil, fmt.Errorf("invalid arguments to %s.%s -- "+
+			"expected database path, block network and optional "+
+			"cold storage", dbType, funcName)
 	}
 
 	dbPath, ok := args[0].(string)
 	if !ok {
-		return "", 0, fmt.Errorf("first argument to %s.%s is invalid -- "+
+		return "", 0, nil, fmt.Errorf("first argument to %s.%s is invalid -- "+
 			"expected database path string", dbType, funcName)
 	}
 
 	network, ok := args[1].(protocol.Network)
 	if !ok {
-		return "", 0, fmt.Errorf("second argument to %s.%s is invalid -- "+
+		return "", 0, nil, fmt.Errorf("second argument to %s.%s is invalid -- "+
 			"expected block network", dbType, funcName)
 	}
 
-	return dbPath, network, nil
+	var cold database.ColdStorage
+	if len(args) == 3 && args[2] != nil {
+		cold, ok = args[2].(database.ColdStorage)
+		if !ok {
+			return "", 0, nil, fmt.Errorf("third argument to %s.%s is "+
+				"invalid -- expected cold storage", dbType, funcName)
+		}
+	}
+
+	return dbPath, network, cold, nil
 }
 
 // openDBDriver is the callback provided during driver registration that opens
 // an existing database for use.
 func openDBDriver(args ...interface{}) (database.DB, error) {
-	dbPath, network, err := parseArgs("Open", args...)
+	dbPath, network, cold, err := parseArgs("Open", args...)
 	if err != nil {
 		return nil, err
 	}
 
-	return openDB(dbPath, network, false)
+	return openDB(dbPath, network, cold, false)
 }
 
 // createDBDriver is the callback provided during driver registration that
 // creates, initializes, and opens a database for use.
 func createDBDriver(args ...interface{}) (database.DB, error) {
-	dbPath, network, err := parseArgs("Create", args...)
+	dbPath, network, cold, err := parseArgs("Create", args...)
 	if err != nil {
 		return nil, err
 	}
 
-	return openDB(dbPath, network, true)
+	return openDB(dbPath, network, cold, true)
 }
 
 // useLogger is the callback provided during driver registration that sets the
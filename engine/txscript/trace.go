@@ -0,0 +1,56 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+// TraceStep is the state of the engine after the execution of an opcode.
+type TraceStep struct {
+	// ScriptIdx and ScriptOff locate the opcode: the script index is 0 for
+	// the signature script, 1 for the public key script and 2 for the
+	// redeem script of a pay-to-script-hash output.
+	ScriptIdx int
+	ScriptOff int
+
+	// Opcode is the disassembly of the executed opcode.
+	Opcode string
+
+	// Stack and AltStack are the stacks once the opcode executed, the last
+	// item being the top of the stack.
+	Stack    [][]byte
+	AltStack [][]byte
+}
+
+// ExecuteWithTrace executes all scripts in the script engine like Execute and
+// returns the trace of every executed opcode.  When an opcode fails, it is the
+// last step of the trace and its error is returned.  A failure of the final
+// checks, such as a false value left on the stack, is returned along with the
+// complete trace.
+func (vm *Engine) ExecuteWithTrace() ([]TraceStep, error) {
+	if vm.version != DefaultScriptVersion {
+		return nil, nil
+	}
+
+	var trace []TraceStep
+	done := false
+	for !done {
+		scriptIdx, scriptOff, err := vm.curPC()
+		if err != nil {
+			return trace, err
+		}
+		step := TraceStep{
+			ScriptIdx: scriptIdx,
+			ScriptOff: scriptOff,
+			Opcode:    vm.scripts[scriptIdx][scriptOff].print(false),
+		}
+
+		done, err = vm.Step()
+		step.Stack = vm.GetStack()
+		step.AltStack = vm.GetAltStack()
+		trace = append(trace, step)
+		if err != nil {
+			return trace, err
+		}
+	}
+	return trace, vm.CheckErrorCondition(true)
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"github.com/Qitmeer/qitmeer/core/types"
+	"testing"
+)
+
+func TestExecuteWithTrace(t *testing.T) {
+	tests := []struct {
+		name      string
+		sigScript []byte
+		pkScript  []byte
+		steps     int
+		err       error
+		opcode    string
+	}{
+		{"success", []byte{OP_1}, []byte{OP_1, OP_EQUAL}, 3, nil, "OP_EQUAL"},
+		{"verify failed", []byte{OP_1}, []byte{OP_2, OP_EQUALVERIFY, OP_1}, 3,
+			ErrStackVerifyFailed, "OP_EQUALVERIFY"},
+		{"false on stack", []byte{OP_1}, []byte{OP_2, OP_EQUAL}, 3,
+			ErrStackScriptFailed, "OP_EQUAL"},
+	}
+	for _, test := range tests {
+		tx := types.NewTransaction()
+		tx.AddTxIn(types.NewTxInput(&types.TxOutPoint{}, test.sigScript))
+		vm, err := NewEngine(test.pkScript, tx, 0, 0, DefaultScriptVersion, nil)
+		if err != nil {
+			t.Fatalf("%s: NewEngine: %v", test.name, err)
+		}
+		trace, err := vm.ExecuteWithTrace()
+		if err != test.err {
+			t.Errorf("%s: got error %v, want %v", test.name, err, test.err)
+		}
+		if len(trace) != test.steps {
+			t.Fatalf("%s: got %d steps, want %d", test.name, len(trace),
+				test.steps)
+		}
+		last := trace[len(trace)-1]
+		if last.Opcode != test.opcode || last.ScriptIdx != 1 {
+			t.Errorf("%s: last step is %s of script %d, want %s of script 1",
+				test.name, last.Opcode, last.ScriptIdx, test.opcode)
+		}
+	}
+}
@@ -6,20 +6,27 @@
 package node
 
 import (
+	"encoding/hex"
 	"fmt"
 	"github.com/Qitmeer/qitmeer/common/math"
+	"github.com/Qitmeer/qitmeer/common/perf"
 	"github.com/Qitmeer/qitmeer/common/roughtime"
 	"github.com/Qitmeer/qitmeer/core/blockchain"
 	"github.com/Qitmeer/qitmeer/core/blockdag"
 	"github.com/Qitmeer/qitmeer/core/json"
 	"github.com/Qitmeer/qitmeer/core/protocol"
 	"github.com/Qitmeer/qitmeer/core/types/pow"
+	"github.com/Qitmeer/qitmeer/database"
 	"github.com/Qitmeer/qitmeer/params"
 	"github.com/Qitmeer/qitmeer/rpc"
 	"github.com/Qitmeer/qitmeer/rpc/client/cmds"
 	"github.com/Qitmeer/qitmeer/services/common"
+	"github.com/Qitmeer/qitmeer/services/mempool"
 	"github.com/Qitmeer/qitmeer/version"
 	"math/big"
+	"runtime"
+	"runtime/debug"
+	"sort"
 	"strconv"
 	"time"
 )
@@ -45,11 +52,12 @@ func (nf *QitmeerFull) apis() []rpc.API {
 }
 
 type PublicBlockChainAPI struct {
-	node *QitmeerFull
+	node  *QitmeerFull
+	stats nodeStats
 }
 
 func NewPublicBlockChainAPI(node *QitmeerFull) *PublicBlockChainAPI {
-	return &PublicBlockChainAPI{node}
+	return &PublicBlockChainAPI{node: node}
 }
 
 // Return the node info
@@ -74,6 +82,22 @@ func (api *PublicBlockChainAPI) GetNodeInfo() (interface{}, error) {
 		Modules:          []string{cmds.DefaultServiceNameSpace, cmds.MinerNameSpace, cmds.TestNameSpace, cmds.LogNameSpace},
 	}
 	ret.GraphState = GetGraphStateResult(best.GraphState)
+	skew, exceeded := api.node.blockManager.GetChain().TimeSource().ClockSkew()
+	ret.ClockSkew = int64(skew.Seconds())
+	if exceeded {
+		ret.Errors = fmt.Sprintf("Your clock differs from the time of the "+
+			"network by %v, please check your date and time", skew)
+	}
+	if api.node.safeMode != nil {
+		if state := api.node.safeMode.State(); state.SafeMode {
+			if len(ret.Errors) > 0 {
+				ret.Errors += "; "
+			}
+			ret.Errors += fmt.Sprintf("Safe mode since %v, mining and "+
+				"relay are paused until acknowledged: %s",
+				state.Since.Truncate(time.Second), state.Reason)
+		}
+	}
 	hostdns := api.node.node.peerServer.HostDNS()
 	if hostdns != nil {
 		ret.DNS = hostdns.String()
@@ -84,6 +108,10 @@ func (api *PublicBlockChainAPI) GetNodeInfo() (interface{}, error) {
 	if len(api.node.node.peerServer.HostAddress()) > 0 {
 		ret.Addresss = api.node.node.peerServer.HostAddress()
 	}
+	services := api.node.node.peerServer.Config().Services
+	ret.Services = services.String()
+	ret.ServiceFlags = uint64(services)
+	ret.Subsystems = api.subsystems(services)
 
 	// soft forks
 	ret.ConsensusDeployment = make(map[string]*json.ConsensusDeploymentDesc)
@@ -165,6 +193,26 @@ func getDifficultyRatio(target *big.Int, params *params.Params, powType pow.PowT
 	return diff
 }
 
+// subsystems returns the optional subsystems of the node and whether they are
+// enabled.
+func (api *PublicBlockChainAPI) subsystems(services protocol.ServiceFlag) map[string]bool {
+	cfg := api.node.node.Config
+	zmq := cfg.Zmqpubhashblock != "" || cfg.Zmqpubrawblock != "" ||
+		cfg.Zmqpubhashtx != "" || cfg.Zmqpubrawtx != ""
+	return map[string]bool{
+		"txindex":           true,
+		"addrindex":         cfg.AddrIndex,
+		"addractivityindex": cfg.AddrActivityIndex,
+		"utxoageindex":      cfg.UtxoAgeIndex,
+		"minerindex":        cfg.MinerIndex,
+		"bloomfilters":      protocol.HasServices(services, protocol.Bloom),
+		"cfilters":          protocol.HasServices(services, protocol.CF),
+		"zmq":               zmq && api.node.blockManager.ZMQEnabled(),
+		"cpuminer":          cfg.Generate,
+		"rpc":               api.node.node.rpcServer != nil,
+	}
+}
+
 // Return the peer info
 func (api *PublicBlockChainAPI) GetPeerInfo(verbose *bool, network *string) (interface{}, error) {
 	vb := false
@@ -234,6 +282,8 @@ func (api *PublicBlockChainAPI) GetPeerInfo(verbose *bool, network *string) (int
 			}
 			info.ConnTime = p.ConnTime.Truncate(time.Second).String()
 			info.GSUpdate = p.GraphStateDur.Truncate(time.Second).String()
+			info.MempoolTxs = p.MempoolTxs
+			info.FeeFloor = p.FeeFloor
 		}
 		if !p.LastSend.IsZero() {
 			info.LastSend = p.LastSend.String()
@@ -244,6 +294,13 @@ func (api *PublicBlockChainAPI) GetPeerInfo(verbose *bool, network *string) (int
 		if len(p.QNR) > 0 {
 			info.QNR = p.QNR
 		}
+		if p.BlockStats != nil {
+			info.Blocks = &json.BlockStatsResult{
+				Orphans:    p.BlockStats.Orphans,
+				Duplicates: p.BlockStats.Duplicates,
+				Invalids:   p.BlockStats.Invalids,
+			}
+		}
 		infos = append(infos, info)
 	}
 	return infos, nil
@@ -283,6 +340,319 @@ func (api *PublicBlockChainAPI) GetTimeInfo() (interface{}, error) {
 	return fmt.Sprintf("Now:%s offset:%s", roughtime.Now(), roughtime.Offset()), nil
 }
 
+// Return the node statistics since the process was started
+func (api *PublicBlockChainAPI) GetNodeStats() (interface{}, error) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	txPool := api.node.txManager.MemPool().(*mempool.TxPool)
+	added, removed := txPool.Churn()
+	now := roughtime.Now()
+	dbSize, err := api.stats.bucketSizes(api.node.db, now)
+	if err != nil {
+		return nil, err
+	}
+	return &json.NodeStatsResult{
+		Uptime:          now.Unix() - api.node.node.startupTime,
+		BlocksConnected: api.node.blockManager.BlocksConnected(),
+		Reorganizations: api.node.blockManager.Reorganizations(),
+		MempoolAdded:    added,
+		MempoolRemoved:  removed,
+		MempoolSize:     len(txPool.TxDescs()),
+		PeakMemory:      api.stats.sampleMemory(&ms),
+		DBSize:          dbSize,
+	}, nil
+}
+
+const (
+	// defaultPerfReportMinutes is the number of minutes covered by the
+	// perfReport command by default.
+	defaultPerfReportMinutes = 10
+
+	// defaultPerfReportCount is the number of entries of each list of the
+	// perfReport command by default.
+	defaultPerfReportCount = 10
+)
+
+// Return a consolidated performance report of the last minutes: the busiest
+// database buckets, the slowest RPC methods, the longest block connection
+// stages and the garbage collection statistics
+func (api *PublicBlockChainAPI) PerfReport(minutes *uint32, count *uint32) (interface{}, error) {
+	mins := uint32(defaultPerfReportMinutes)
+	if minutes != nil {
+		mins = *minutes
+	}
+	if mins == 0 || mins > perf.HistoryMinutes {
+		return nil, rpc.RpcInvalidError("Minutes must be between 1 and %d",
+			perf.HistoryMinutes)
+	}
+	n := defaultPerfReportCount
+	if count != nil {
+		n = int(*count)
+	}
+
+	// top sorts the statistics with the given order and keeps the first n.
+	top := func(category string, less func(a, b *perf.Stat) bool) []json.PerfStatResult {
+		stats := perf.Stats(category, int(mins))
+		sort.SliceStable(stats, func(i, j int) bool {
+			return less(&stats[i], &stats[j])
+		})
+		if len(stats) > n {
+			stats = stats[:n]
+		}
+		results := make([]json.PerfStatResult, 0, len(stats))
+		for _, s := range stats {
+			results = append(results, json.PerfStatResult{
+				Name:    s.Name,
+				Count:   s.Count,
+				Total:   milliseconds(s.Total),
+				Average: milliseconds(s.Total / time.Duration(s.Count)),
+				Max:     milliseconds(s.Max),
+			})
+		}
+		return results
+	}
+	byCount := func(a, b *perf.Stat) bool { return a.Count > b.Count }
+	byMax := func(a, b *perf.Stat) bool { return a.Max > b.Max }
+	byTotal := func(a, b *perf.Stat) bool { return a.Total > b.Total }
+
+	return &json.PerfReportResult{
+		Minutes:     mins,
+		DBReads:     top(perf.DBRead, byCount),
+		DBWrites:    top(perf.DBWrite, byCount),
+		SlowestRPCs: top(perf.RPC, byMax),
+		BlockStages: top(perf.BlockStage, byTotal),
+		GC:          gcStats(time.Now().Add(-time.Duration(mins) * time.Minute)),
+	}, nil
+}
+
+// gcStats returns the garbage collection statistics since the given time.
+// Only the most recent pauses are kept by the runtime, so the pauses of a
+// long period may be incomplete.
+func gcStats(since time.Time) json.GCStatsResult {
+	var gs debug.GCStats
+	debug.ReadGCStats(&gs)
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	result := json.GCStatsResult{
+		HeapAlloc:    ms.HeapAlloc,
+		HeapSys:      ms.HeapSys,
+		NumGoroutine: runtime.NumGoroutine(),
+	}
+	if !gs.LastGC.IsZero() {
+		result.LastGC = gs.LastGC.Unix()
+	}
+	for i, end := range gs.PauseEnd {
+		if end.Before(since) {
+			break
+		}
+		result.NumGC++
+		result.PauseTotal += milliseconds(gs.Pause[i])
+		if pause := milliseconds(gs.Pause[i]); pause > result.PauseMax {
+			result.PauseMax = pause
+		}
+	}
+	return result
+}
+
+// milliseconds returns the duration in milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// Return the version, the commit and the toolchain the node was built with
+func (api *PublicBlockChainAPI) GetBuildInfo() (interface{}, error) {
+	return &json.BuildInfoResult{
+		Version:   version.String(),
+		Commit:    version.Commit,
+		BuildTime: version.BuildTime,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Tags:      version.BuildTags(),
+	}, nil
+}
+
+// Return the messages of each type exchanged with every peer ever seen
+func (api *PublicBlockChainAPI) GetPeerMsgStats() (interface{}, error) {
+	stats := api.node.node.peerServer.Peers().MsgStats()
+	results := make([]*json.PeerMsgStatsResult, 0, len(stats))
+	for pid, ms := range stats {
+		results = append(results, &json.PeerMsgStatsResult{
+			ID:       pid.String(),
+			Sent:     ms.Sent,
+			Received: ms.Received,
+			Errors:   ms.Errors,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+	return results, nil
+}
+
+// Return whether the node is in safe mode, because its peers diverge
+func (api *PublicBlockChainAPI) GetSafeMode() (interface{}, error) {
+	result := &json.SafeModeResult{Enabled: api.node.safeMode != nil}
+	if api.node.safeMode == nil {
+		return result, nil
+	}
+	state := api.node.safeMode.State()
+	result.SafeMode = state.SafeMode
+	if !state.Since.IsZero() {
+		result.Since = state.Since.Unix()
+		result.Reason = state.Reason
+	}
+	return result, nil
+}
+
+// Return the block interval and red block rate of the latest blocks, compared
+// with the targets of the network
+func (api *PublicBlockChainAPI) GetSLOStatus() (interface{}, error) {
+	m := api.node.sloMonitor
+	result := &json.SLOStatusResult{Enabled: m != nil}
+	if m == nil {
+		return result, nil
+	}
+	cfg := m.Config()
+	state := m.State()
+	result.Window = cfg.Window
+	result.Blocks = state.Stats.Blocks
+	result.Reds = state.Stats.Reds
+	result.RedRate = state.Stats.RedRate * 100
+	result.MaxRedRate = cfg.MaxRedRate
+	result.MeanInterval = state.Stats.MeanInterval.Seconds()
+	result.MedianInterval = state.Stats.MedianInterval.Seconds()
+	result.MaxInterval = state.Stats.MaxInterval.Seconds()
+	result.TargetInterval = cfg.TargetInterval.Seconds()
+	result.IntervalDeviation = cfg.IntervalDeviation
+	result.Alerting = state.Alerting
+	if !state.Since.IsZero() {
+		result.Since = state.Since.Unix()
+	}
+	result.Reasons = state.Reasons
+	return result, nil
+}
+
+// Return the latest snapshots of the key metrics of the node, from the oldest
+// to the newest
+func (api *PublicBlockChainAPI) GetMetricsHistory(count *uint) (interface{}, error) {
+	h := api.node.metricsHistory
+	result := &json.MetricsHistoryResult{Enabled: h != nil}
+	if h == nil {
+		return result, nil
+	}
+	n := uint(0)
+	if count != nil {
+		n = *count
+	}
+	snaps := h.Latest(n)
+	result.Size = h.Size()
+	result.Snapshots = make([]json.MetricsSnapshotResult, 0, len(snaps))
+	for _, snap := range snaps {
+		result.Snapshots = append(result.Snapshots, json.MetricsSnapshotResult{
+			Time:       snap.Time,
+			Tips:       snap.Tips,
+			MempoolTxs: snap.MempoolTxs,
+			Peers:      snap.Peers,
+			Blocks:     snap.Blocks,
+			BlockRate:  snap.BlockRate,
+		})
+	}
+	return result, nil
+}
+
+// Return the lag of a follower node behind its leader
+func (api *PublicBlockChainAPI) GetFollowerStatus() (interface{}, error) {
+	ps := api.node.node.peerServer
+	leader := ps.Leader()
+	result := &json.FollowerStatusResult{Enabled: len(leader) > 0}
+	if !result.Enabled {
+		return result, nil
+	}
+	result.Leader = leader.String()
+	best := api.node.blockManager.GetChain().BestSnapshot()
+	result.GraphState = GetGraphStateResult(best.GraphState)
+
+	pe := ps.Peers().Get(leader)
+	if pe == nil {
+		return result, nil
+	}
+	result.Connected = pe.IsConnected()
+	gs := pe.GraphState()
+	if gs == nil {
+		return result, nil
+	}
+	result.LeaderState = GetGraphStateResult(gs)
+	if stats, err := pe.StatsSnapshot(); err == nil {
+		result.LeaderUpdated = int64(stats.GraphStateDur.Seconds())
+	}
+	result.OrderLag = int64(gs.GetMainOrder()) - int64(best.GraphState.GetMainOrder())
+	result.BlockLag = int64(gs.GetTotal()) - int64(best.GraphState.GetTotal())
+	return result, nil
+}
+
+// Return the progress of the optional indexes built in the background
+func (api *PublicBlockChainAPI) GetIndexBackfillInfo() (interface{}, error) {
+	results := []json.IndexBackfillResult{}
+	if api.node.indexManager == nil {
+		return results, nil
+	}
+	for _, status := range api.node.indexManager.BackfillStatuses() {
+		progress := float64(1)
+		if !status.Live && status.TargetOrder >= 0 {
+			progress = float64(status.Order+1) / float64(status.TargetOrder+1)
+		}
+		results = append(results, json.IndexBackfillResult{
+			Name:        status.Name,
+			Order:       status.Order,
+			TargetOrder: status.TargetOrder,
+			Progress:    progress,
+			Live:        status.Live,
+		})
+	}
+	return results, nil
+}
+
+// bucketSizes returns the total size of the keys and values, including all
+// nested buckets, of every top level bucket in the database metadata.
+func bucketSizes(db database.DB) (map[string]int64, error) {
+	sizes := map[string]int64{}
+	err := db.View(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		return meta.ForEachBucket(func(k []byte) error {
+			size, err := bucketSize(meta.Bucket(k))
+			if err != nil {
+				return err
+			}
+			sizes[string(k)] = size
+			return nil
+		})
+	})
+	return sizes, err
+}
+
+func bucketSize(bucket database.Bucket) (int64, error) {
+	if bucket == nil {
+		return 0, nil
+	}
+	size := int64(0)
+	err := bucket.ForEach(func(k, v []byte) error {
+		size += int64(len(k) + len(v))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	err = bucket.ForEachBucket(func(k []byte) error {
+		nested, err := bucketSize(bucket.Bucket(k))
+		size += nested
+		return err
+	})
+	return size, err
+}
+
 func (api *PublicBlockChainAPI) GetNetworkInfo() (interface{}, error) {
 	ps := api.node.node.peerServer
 	peers := ps.Peers().StatsSnapshots()
@@ -391,6 +761,97 @@ func (api *PrivateBlockChainAPI) RemoveBan(id *string) (interface{}, error) {
 	return true, nil
 }
 
+// AcknowledgeSafeMode leaves the safe mode, resuming mining and relay
+func (api *PrivateBlockChainAPI) AcknowledgeSafeMode() (interface{}, error) {
+	if api.node.safeMode == nil {
+		return nil, fmt.Errorf("The safe mode is disabled, see --safemodedivergence")
+	}
+	err := api.node.safeMode.Acknowledge()
+	if err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+// GetAuditLog returns at most count entries of the audit log of the
+// state-changing RPC methods, starting at the entry id start, or the last
+// entries when start is not set.
+func (api *PrivateBlockChainAPI) GetAuditLog(start *uint64, count *uint32) (interface{}, error) {
+	auditLog := api.node.node.rpcServer.AuditLog
+	if auditLog == nil {
+		return nil, fmt.Errorf("RPC audit log must be enabled (--rpcaudit)")
+	}
+	c := uint32(100)
+	if count != nil {
+		c = *count
+	}
+	if c == 0 || c > rpc.MaxAuditEntriesPerRequest {
+		return nil, rpc.RpcInvalidError("Count must be between 1 and %d",
+			rpc.MaxAuditEntriesPerRequest)
+	}
+	entries, err := auditLog.Entries(start, c)
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to fetch audit log")
+	}
+	result := []*json.AuditEntryResult{}
+	for _, e := range entries {
+		result = append(result, &json.AuditEntryResult{
+			ID:           e.ID,
+			Timestamp:    e.Timestamp,
+			User:         e.User,
+			Remote:       e.Remote,
+			Method:       e.Method,
+			ParamsDigest: e.ParamsDigest,
+			Success:      e.Success,
+			Error:        e.Error,
+		})
+	}
+	return result, nil
+}
+
+// UnlockKeystore unlocks the keystore of the node secrets with the passphrase
+// for timeout seconds, or until lockKeystore when the timeout is zero.  The
+// timeout defaults to the one of the configuration.
+func (api *PrivateBlockChainAPI) UnlockKeystore(passphrase string, timeout *uint32) (interface{}, error) {
+	ks := api.node.node.keystore
+	if ks == nil {
+		return nil, fmt.Errorf("Keystore must be enabled (--keystore)")
+	}
+	t := api.node.node.Config.KeystoreTimeout
+	if timeout != nil {
+		t = *timeout
+	}
+	err := ks.Unlock([]byte(passphrase), time.Duration(t)*time.Second)
+	if err != nil {
+		return nil, rpc.RpcInvalidError("Failed to unlock keystore: %v", err)
+	}
+	return true, nil
+}
+
+// LockKeystore locks the keystore of the node secrets.
+func (api *PrivateBlockChainAPI) LockKeystore() (interface{}, error) {
+	ks := api.node.node.keystore
+	if ks == nil {
+		return nil, fmt.Errorf("Keystore must be enabled (--keystore)")
+	}
+	ks.Lock()
+	return true, nil
+}
+
+// SendClusterHint sends the hex encoded template hint to the connected peers of
+// the mining cluster and returns the number of peers it was sent to.
+func (api *PrivateBlockChainAPI) SendClusterHint(hint string) (interface{}, error) {
+	payload, err := hex.DecodeString(hint)
+	if err != nil {
+		return nil, rpc.RpcDecodeHexError(hint)
+	}
+	sent, err := api.node.node.peerServer.SendClusterHint(payload)
+	if err != nil {
+		return nil, rpc.RpcInvalidError("Failed to send cluster hint: %v", err)
+	}
+	return sent, nil
+}
+
 // SetRpcMaxClients
 func (api *PrivateBlockChainAPI) SetRpcMaxClients(max int) (interface{}, error) {
 	if max <= 0 {
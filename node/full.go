@@ -3,6 +3,7 @@ package node
 
 import (
 	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
 	"github.com/Qitmeer/qitmeer/database"
 	"github.com/Qitmeer/qitmeer/engine/txscript"
 	"github.com/Qitmeer/qitmeer/node/notify"
@@ -12,13 +13,19 @@ import (
 	"github.com/Qitmeer/qitmeer/services/acct"
 	"github.com/Qitmeer/qitmeer/services/address"
 	"github.com/Qitmeer/qitmeer/services/blkmgr"
+	"github.com/Qitmeer/qitmeer/services/cmdnotify"
 	"github.com/Qitmeer/qitmeer/services/common"
+	"github.com/Qitmeer/qitmeer/services/diskmon"
 	"github.com/Qitmeer/qitmeer/services/index"
 	"github.com/Qitmeer/qitmeer/services/mempool"
+	"github.com/Qitmeer/qitmeer/services/metricshist"
 	"github.com/Qitmeer/qitmeer/services/miner"
 	"github.com/Qitmeer/qitmeer/services/mining"
 	"github.com/Qitmeer/qitmeer/services/notifymgr"
+	"github.com/Qitmeer/qitmeer/services/safemode"
+	"github.com/Qitmeer/qitmeer/services/slomon"
 	"github.com/Qitmeer/qitmeer/services/tx"
+	"time"
 )
 
 // QitmeerFull implements the qitmeer full node service.
@@ -46,6 +53,23 @@ type QitmeerFull struct {
 	timeSource blockchain.MedianTimeSource
 	// signature cache
 	sigCache *txscript.SigCache
+	// disk space monitor
+	diskMonitor *diskmon.Monitor
+	// safe mode monitor
+	safeMode *safemode.Monitor
+	// block interval and red rate monitor
+	sloMonitor *slomon.Monitor
+	// history of the key metrics
+	metricsHistory *metricshist.History
+	// background verifier of the DAG
+	dagVerifier *blockdag.Verifier
+
+	// background scanner of the utxo set statistics
+	utxoStatsScanner *blockchain.UtxoStatsScanner
+	// optional indexes manager
+	indexManager *index.Manager
+	// notification commands
+	cmdNotify *cmdnotify.Notifier
 }
 
 func (qm *QitmeerFull) Start() error {
@@ -58,12 +82,57 @@ func (qm *QitmeerFull) Start() error {
 
 	qm.blockManager.Start()
 	qm.txManager.Start()
+	if qm.diskMonitor != nil {
+		qm.diskMonitor.Start()
+	}
+	if qm.safeMode != nil {
+		qm.safeMode.Start()
+	}
+	if qm.sloMonitor != nil {
+		qm.sloMonitor.Start()
+	}
+	if qm.metricsHistory != nil {
+		qm.metricsHistory.Start()
+	}
+	if qm.dagVerifier != nil {
+		qm.dagVerifier.Start()
+	}
+	qm.utxoStatsScanner.Start()
+	if qm.indexManager != nil {
+		qm.indexManager.Start()
+	}
+	if qm.cmdNotify != nil {
+		qm.cmdNotify.Start()
+	}
 	return nil
 }
 
 func (qm *QitmeerFull) Stop() error {
 	log.Debug("Stopping Qitmeer full node service")
 
+	if qm.diskMonitor != nil {
+		qm.diskMonitor.Stop()
+	}
+	if qm.safeMode != nil {
+		qm.safeMode.Stop()
+	}
+	if qm.sloMonitor != nil {
+		qm.sloMonitor.Stop()
+	}
+	if qm.metricsHistory != nil {
+		qm.metricsHistory.Stop()
+	}
+	if qm.dagVerifier != nil {
+		qm.dagVerifier.Stop()
+	}
+	qm.utxoStatsScanner.Stop()
+	if qm.indexManager != nil {
+		qm.indexManager.Stop()
+	}
+	if qm.cmdNotify != nil {
+		qm.cmdNotify.Stop()
+	}
+
 	log.Info("try stop bm")
 
 	qm.blockManager.Stop()
@@ -83,7 +152,10 @@ func (qm *QitmeerFull) Stop() error {
 func (qm *QitmeerFull) APIs() []rpc.API {
 	apis := qm.acctmanager.APIs()
 	apis = append(apis, qm.addressApi.APIs()...)
-	apis = append(apis, qm.cpuMiner.APIs()...)
+	// A follower does not mine, so it does not serve the miner either.
+	if len(qm.node.Config.Follow) == 0 {
+		apis = append(apis, qm.cpuMiner.APIs()...)
+	}
 	apis = append(apis, qm.blockManager.API())
 	apis = append(apis, qm.txManager.APIs()...)
 	apis = append(apis, qm.apis()...)
@@ -96,11 +168,12 @@ func newQitmeerFullNode(node *Node) (*QitmeerFull, error) {
 	if err != nil {
 		return nil, err
 	}
+	maxClockSkew := time.Duration(node.Config.MaxClockSkew) * time.Second
 	qm := QitmeerFull{
 		node:        node,
 		db:          node.DB,
 		acctmanager: acctmgr,
-		timeSource:  blockchain.NewMedianTime(),
+		timeSource:  blockchain.NewMedianTimeWithMaxSkew(maxClockSkew),
 		sigCache:    txscript.NewSigCache(node.Config.SigCacheMaxSize),
 	}
 	// Create the transaction and address indexes if needed.
@@ -109,21 +182,51 @@ func newQitmeerFullNode(node *Node) (*QitmeerFull, error) {
 
 	var txIndex *index.TxIndex
 	var addrIndex *index.AddrIndex
-	log.Info("Transaction index is enabled")
-	txIndex = index.NewTxIndex(qm.db)
-	indexes = append(indexes, txIndex)
+	// The transaction index locates the transactions in the block bodies,
+	// which are deleted by pruning.
+	if cfg.Prune > 0 {
+		log.Info("Transaction index is disabled by --prune")
+	} else {
+		log.Info("Transaction index is enabled")
+		txIndex = index.NewTxIndex(qm.db)
+		indexes = append(indexes, txIndex)
+	}
 	if cfg.AddrIndex {
 		log.Info("Address index is enabled")
 		addrIndex = index.NewAddrIndex(qm.db, node.Params)
 		indexes = append(indexes, addrIndex)
 	}
+	var addrActivityIndex *index.AddrActivityIndex
+	if cfg.AddrActivityIndex {
+		log.Info("Address activity index is enabled")
+		addrActivityIndex = index.NewAddrActivityIndex(qm.db, node.Params)
+		indexes = append(indexes, addrActivityIndex)
+	}
+	var utxoAgeIndex *index.UtxoAgeIndex
+	if cfg.UtxoAgeIndex {
+		log.Info("UTXO age index is enabled")
+		utxoAgeIndex = index.NewUtxoAgeIndex(qm.db)
+		indexes = append(indexes, utxoAgeIndex)
+	}
+	var minerIndex *index.MinerIndex
+	if cfg.MinerIndex {
+		log.Info("Miner index is enabled")
+		minerIndex = index.NewMinerIndex(qm.db, node.Params)
+		indexes = append(indexes, minerIndex)
+	}
 	// index-manager
 	var indexManager blockchain.IndexManager
 	if len(indexes) > 0 {
-		indexManager = index.NewManager(qm.db, indexes, node.Params)
+		qm.indexManager = index.NewManager(qm.db, indexes, node.Params)
+		if cfg.BackfillIndexes {
+			log.Info("Index backfill is enabled")
+			qm.indexManager.EnableBackfill()
+		}
+		indexManager = qm.indexManager
 	}
 
-	qm.nfManager = &notifymgr.NotifyMgr{Server: node.peerServer, RpcServer: node.rpcServer}
+	nfManager := &notifymgr.NotifyMgr{Server: node.peerServer, RpcServer: node.rpcServer}
+	qm.nfManager = nfManager
 
 	// block-manager
 	bm, err := blkmgr.NewBlockManager(qm.nfManager, indexManager, node.DB, qm.timeSource, qm.sigCache, node.Config, node.Params,
@@ -132,9 +235,68 @@ func newQitmeerFullNode(node *Node) (*QitmeerFull, error) {
 		return nil, err
 	}
 	qm.blockManager = bm
+	if addrActivityIndex != nil {
+		bm.SetAddrActivityIndex(addrActivityIndex)
+	}
+
+	// notification commands
+	if cfg.BlockNotify != "" || cfg.WalletNotify != "" {
+		qm.cmdNotify, err = cmdnotify.New(&cmdnotify.Config{
+			BlockNotify:       cfg.BlockNotify,
+			WalletNotify:      cfg.WalletNotify,
+			WatchAddrs:        cfg.GetWalletNotifyAddrs(),
+			FetchSpendJournal: bm.GetChain().FetchSpendJournal,
+			FetchUtxoEntry:    bm.GetChain().FetchUtxoEntry,
+		})
+		if err != nil {
+			return nil, err
+		}
+		nfManager.CmdNotify = qm.cmdNotify
+	}
+
+	// disk space monitor
+	if cfg.MinFreeDisk > 0 {
+		qm.diskMonitor = diskmon.New(&diskmon.Config{
+			Path:         cfg.DataDir,
+			MinFreeSpace: cfg.MinFreeDisk * 1024 * 1024,
+			Webhook:      cfg.DiskAlertWebhook,
+			Chain:        bm.GetChain(),
+		})
+	}
+
+	// safe mode monitor
+	if cfg.SafeModeDivergence > 0 {
+		qm.safeMode = safemode.New(&safemode.Config{
+			Divergence: cfg.SafeModeDivergence,
+			Webhook:    cfg.SafeModeWebhook,
+			Network:    node.peerServer,
+		})
+	}
+
+	// block interval and red rate monitor
+	if cfg.SLOWindow > 0 {
+		qm.sloMonitor = slomon.New(&slomon.Config{
+			Window:            cfg.SLOWindow,
+			TargetInterval:    bm.ChainParams().TargetTimePerBlock,
+			IntervalDeviation: cfg.SLOIntervalDeviation,
+			MaxRedRate:        cfg.SLOMaxRedRate,
+			Webhook:           cfg.SLOWebhook,
+			Chain:             bm.GetChain(),
+		})
+	}
+
+	// background verifier of the DAG
+	if cfg.DAGVerify {
+		qm.dagVerifier = blockdag.NewVerifier(bm.GetChain().BlockDAG(),
+			cfg.DAGVerifyRepair)
+	}
+
+	// background scanner of the utxo set statistics, which returns at once
+	// when they are complete
+	qm.utxoStatsScanner = blockchain.NewUtxoStatsScanner(bm.GetChain())
 
 	// txmanager
-	tm, err := tx.NewTxManager(bm, txIndex, addrIndex, cfg, qm.nfManager, qm.sigCache, node.DB)
+	tm, err := tx.NewTxManager(bm, txIndex, addrIndex, addrActivityIndex, utxoAgeIndex, minerIndex, cfg, qm.nfManager, qm.sigCache, node.DB)
 	if err != nil {
 		return nil, err
 	}
@@ -150,9 +312,35 @@ func newQitmeerFullNode(node *Node) (*QitmeerFull, error) {
 		node.rpcServer.BC = bm.GetChain()
 		node.rpcServer.TxIndex = txIndex
 		node.rpcServer.ChainParams = bm.ChainParams()
+		node.rpcServer.FeeHistogram = qm.txManager.MemPool().(*mempool.TxPool).FeeHistogramResult
+	}
+
+	// history of the key metrics
+	if cfg.MetricsHistory > 0 {
+		txPool := qm.txManager.MemPool().(*mempool.TxPool)
+		qm.metricsHistory, err = metricshist.New(&metricshist.Config{
+			Size: cfg.MetricsHistory,
+			DB:   node.DB,
+			Collect: func() metricshist.Metrics {
+				gs := bm.GetChain().BestSnapshot().GraphState
+				return metricshist.Metrics{
+					Tips:       uint(gs.GetTips().Size()),
+					MempoolTxs: uint(txPool.Count()),
+					Peers:      uint(len(node.peerServer.Peers().Connected())),
+					Blocks:     gs.GetTotal(),
+				}
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Cpu Miner
+	txSelection, err := mining.NewTxSelection(cfg.TemplateIncludeTxs, cfg.TemplateExcludeTxs)
+	if err != nil {
+		return nil, err
+	}
 	// Create the mining policy based on the configuration options.
 	// NOTE: The CPU miner relies on the mempool, so the mempool has to be
 	// created before calling the function to create the CPU miner.
@@ -161,9 +349,14 @@ func newQitmeerFullNode(node *Node) (*QitmeerFull, error) {
 		BlockMaxSize:      cfg.BlockMaxSize,
 		BlockPrioritySize: cfg.BlockPrioritySize,
 		TxMinFreeFee:      cfg.MinTxFee, //TODO, duplicated config item with mem-pool
+		TxAgingBlocks:     cfg.TxAgingBlocks,
+		TxAgingMaxSteps:   cfg.TxAgingMaxSteps,
 		StandardVerifyFlags: func() (txscript.ScriptFlags, error) {
 			return common.StandardScriptVerifyFlags()
 		}, //TODO, duplicated config item with mem-pool
+		FreezeList:          tm.FreezeList(),
+		TxSelection:         txSelection,
+		TipWithholdingDelay: time.Duration(cfg.TipWithholdingDelay) * time.Second,
 	}
 	// defaultNumWorkers is the default number of workers to use for mining
 	// and is based on the number of processor cores.  This helps ensure the
@@ -7,6 +7,7 @@ import (
 	"github.com/Qitmeer/qitmeer/common/util"
 	"github.com/Qitmeer/qitmeer/config"
 	"github.com/Qitmeer/qitmeer/core/event"
+	"github.com/Qitmeer/qitmeer/crypto/keystore"
 	"github.com/Qitmeer/qitmeer/database"
 	"github.com/Qitmeer/qitmeer/p2p"
 	"github.com/Qitmeer/qitmeer/params"
@@ -34,6 +35,9 @@ type Node struct {
 	// database layer
 	DB database.DB
 
+	// encrypted keystore of the node secrets, nil when disabled
+	keystore *keystore.Keystore
+
 	// network server
 	peerServer *p2p.Service
 
@@ -50,16 +54,17 @@ type Node struct {
 	events event.Feed
 }
 
-func NewNode(cfg *config.Config, database database.DB, chainParams *params.Params, shutdownRequestChannel chan struct{}) (*Node, error) {
+func NewNode(cfg *config.Config, database database.DB, chainParams *params.Params, ks *keystore.Keystore, shutdownRequestChannel chan struct{}) (*Node, error) {
 
 	n := Node{
-		Config: cfg,
-		DB:     database,
-		Params: chainParams,
-		quit:   make(chan struct{}),
+		Config:   cfg,
+		DB:       database,
+		Params:   chainParams,
+		keystore: ks,
+		quit:     make(chan struct{}),
 	}
 
-	server, err := p2p.NewService(cfg, &n.events, chainParams)
+	server, err := p2p.NewService(cfg, &n.events, chainParams, ks)
 	if err != nil {
 		return nil, err
 	}
@@ -70,6 +75,12 @@ func NewNode(cfg *config.Config, database database.DB, chainParams *params.Param
 		if err != nil {
 			return nil, err
 		}
+		if cfg.RPCAudit {
+			n.rpcServer.AuditLog, err = rpc.NewAuditLog(database)
+			if err != nil {
+				return nil, err
+			}
+		}
 		go func() {
 			<-n.rpcServer.RequestedProcessShutdown()
 			shutdownRequestChannel <- struct{}{}
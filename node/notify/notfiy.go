@@ -1,6 +1,7 @@
 package notify
 
 import (
+	"github.com/Qitmeer/qitmeer/common/hash"
 	"github.com/Qitmeer/qitmeer/core/types"
 	"github.com/libp2p/go-libp2p-core/peer"
 )
@@ -9,8 +10,15 @@ import (
 // and rpc server.
 type Notify interface {
 	AnnounceNewTransactions(newTxs []*types.TxDesc, filters []peer.ID)
+	AnnounceNewPackage(txs []*types.Tx, newTxs []*types.TxDesc, filters []peer.ID)
 	RelayInventory(data interface{}, filters []peer.ID)
 	BroadcastMessage(data interface{})
 	TransactionConfirmed(tx *types.Tx)
 	AddRebroadcastInventory(newTxs []*types.TxDesc)
+	AnnounceDoubleSpendProof(proof *types.DoubleSpendProof, filters []peer.ID)
+	TransactionEvicted(tx *types.Tx, reason string)
+	BlockConnected(block *types.SerializedBlock)
+	MinedBlockStale(h *hash.Hash, reason string, hints []string)
+	MinedBlockFound(block *types.SerializedBlock)
+	ClusterTemplateHint(pid peer.ID, hint []byte)
 }
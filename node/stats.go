@@ -0,0 +1,60 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"github.com/Qitmeer/qitmeer/database"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// dbSizeCacheTTL is how long the bucket sizes of the database are reused by
+// getNodeStats, since they are found by walking the whole metadata.
+const dbSizeCacheTTL = 10 * time.Minute
+
+// nodeStats holds the node statistics which are expensive to compute or which
+// are tracked across the getNodeStats calls.
+type nodeStats struct {
+	lock sync.Mutex
+
+	// peakMemory is the highest memory held by the process among the
+	// samples taken so far.
+	peakMemory uint64
+
+	dbSize     map[string]int64
+	dbSizeTime time.Time
+}
+
+// sampleMemory records the memory currently held by the process and returns
+// the peak of the samples.  The memory released to the OS is not held, even
+// though the runtime does not return it from the memory obtained from the OS.
+func (s *nodeStats) sampleMemory(ms *runtime.MemStats) uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	held := ms.Sys - ms.HeapReleased
+	if held > s.peakMemory {
+		s.peakMemory = held
+	}
+	return s.peakMemory
+}
+
+// bucketSizes returns the sizes of the top level buckets of the database,
+// computing them again when the cached ones are older than dbSizeCacheTTL.
+func (s *nodeStats) bucketSizes(db database.DB, now time.Time) (map[string]int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.dbSize != nil && now.Sub(s.dbSizeTime) < dbSizeCacheTTL {
+		return s.dbSize, nil
+	}
+	sizes, err := bucketSizes(db)
+	if err != nil {
+		return nil, err
+	}
+	s.dbSize, s.dbSizeTime = sizes, now
+	return sizes, nil
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"github.com/Qitmeer/qitmeer/database"
+	_ "github.com/Qitmeer/qitmeer/database/ffldb"
+	"github.com/Qitmeer/qitmeer/params"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestSampleMemory(t *testing.T) {
+	var s nodeStats
+	samples := []struct {
+		sys, released uint64
+		peak          uint64
+	}{
+		{sys: 100, released: 20, peak: 80},
+		{sys: 150, released: 10, peak: 140},
+		// Memory released to the OS lowers the held memory, not the peak.
+		{sys: 150, released: 100, peak: 140},
+		{sys: 200, released: 50, peak: 150},
+	}
+	for i, sample := range samples {
+		ms := runtime.MemStats{Sys: sample.sys, HeapReleased: sample.released}
+		if peak := s.sampleMemory(&ms); peak != sample.peak {
+			t.Fatalf("sample %d: got peak %d, want %d", i, peak, sample.peak)
+		}
+	}
+}
+
+func TestBucketSizesCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nodestats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := database.Create("ffldb", filepath.Join(dir, "blocks_ffldb"),
+		params.PrivNetParams.Net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	put := func(key, value string) {
+		err := db.Update(func(dbTx database.Tx) error {
+			bucket, err := dbTx.Metadata().CreateBucketIfNotExists([]byte("test"))
+			if err != nil {
+				return err
+			}
+			return bucket.Put([]byte(key), []byte(value))
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	put("a", "1234")
+
+	var s nodeStats
+	now := time.Now()
+	sizes, err := s.bucketSizes(db, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sizes["test"] != 5 {
+		t.Fatalf("got size %d, want 5", sizes["test"])
+	}
+
+	// The sizes are reused until they expire.
+	put("b", "1234")
+	sizes, err = s.bucketSizes(db, now.Add(dbSizeCacheTTL-time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sizes["test"] != 5 {
+		t.Fatalf("got size %d before the expiry, want 5", sizes["test"])
+	}
+	sizes, err = s.bucketSizes(db, now.Add(dbSizeCacheTTL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sizes["test"] != 10 {
+		t.Fatalf("got size %d after the expiry, want 10", sizes["test"])
+	}
+}
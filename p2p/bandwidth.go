@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package p2p
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// bandwidthCheckInterval is the interval at which the start of a new day is
+// checked to reset the bandwidth used.
+const bandwidthCheckInterval = time.Minute
+
+// bandwidthBudget switches the node to the blocks-only mode once the p2p
+// traffic of the day exceeds the daily budget, such as for a home node on a
+// metered connection, and back at the start of the next day.  In the
+// blocks-only mode, the transactions of the peers are neither requested nor
+// relayed, and the mempool is not gossiped, while the locally submitted
+// transactions are still accepted and relayed.
+type bandwidthBudget struct {
+	// used is the number of bytes sent and received today.  It must be
+	// accessed atomically.
+	used uint64
+
+	// exceeded tells whether the budget of the day is exceeded.  It must be
+	// accessed atomically.
+	exceeded int32
+
+	// budget is the number of bytes per day, or zero when the budget is
+	// disabled.
+	budget uint64
+
+	// day is the number of the current day since the unix epoch, which is
+	// only accessed by the periodic check.
+	day int64
+}
+
+// add counts the bytes sent or received and returns true when they exceed the
+// budget for the first time of the day.
+func (b *bandwidthBudget) add(size int) bool {
+	if b.budget == 0 {
+		return false
+	}
+	used := atomic.AddUint64(&b.used, uint64(size))
+	if used <= b.budget {
+		return false
+	}
+	return atomic.CompareAndSwapInt32(&b.exceeded, 0, 1)
+}
+
+// isExceeded returns whether the budget of the day is exceeded.
+func (b *bandwidthBudget) isExceeded() bool {
+	return atomic.LoadInt32(&b.exceeded) != 0
+}
+
+// newDay resets the bandwidth used when a new day started and returns true
+// when the budget of the previous day was exceeded.
+func (b *bandwidthBudget) newDay(now time.Time) bool {
+	day := now.Unix() / int64(24*time.Hour/time.Second)
+	if day == b.day {
+		return false
+	}
+	b.day = day
+	atomic.StoreUint64(&b.used, 0)
+	return atomic.CompareAndSwapInt32(&b.exceeded, 1, 0)
+}
+
+// DisableRelayTx returns whether the node does not relay transactions, either
+// because of --blocksonly or because the bandwidth budget of the day is
+// exceeded.
+func (s *Service) DisableRelayTx() bool {
+	return s.cfg.DisableRelayTx || s.bandwidth.isExceeded()
+}
+
+// addBandwidth counts the bytes sent to or received from a peer against the
+// bandwidth budget.
+func (s *Service) addBandwidth(size int) {
+	if s.bandwidth.add(size) && !s.cfg.DisableRelayTx {
+		log.Warn(fmt.Sprintf("The p2p traffic exceeds the daily budget of "+
+			"%d MiB, switching to the blocks-only mode until tomorrow",
+			s.bandwidth.budget>>20))
+	}
+}
+
+// checkBandwidthDay leaves the blocks-only mode of an exceeded bandwidth
+// budget at the start of a new day.
+func (s *Service) checkBandwidthDay() {
+	if s.bandwidth.newDay(time.Now().UTC()) && !s.cfg.DisableRelayTx {
+		log.Info("New day of the bandwidth budget, relaying transactions again")
+	}
+}
@@ -6,6 +6,7 @@ package common
 
 import (
 	"github.com/Qitmeer/qitmeer/core/protocol"
+	"github.com/Qitmeer/qitmeer/crypto/keystore"
 	"github.com/Qitmeer/qitmeer/params"
 	"os"
 )
@@ -43,8 +44,23 @@ type Config struct {
 	// not send inv messages for transactions.
 	DisableRelayTx bool
 	MaxOrphanTxs   int
+	// BloomRateLimit is the maximum number of bloom filter messages and
+	// filtered blocks served to a peer per minute.
+	BloomRateLimit int
 	Params         *params.Params
 	Banning        bool // Open or not ban module
 	DisableListen  bool
 	LANPeers       []string
+	// Keystore holds the private key unless the private key file is set.
+	Keystore *keystore.Keystore
+	// ClusterPeers are the ids of the operator-approved peers of the mining
+	// cluster, which may use the private cluster channel.  The channel is
+	// disabled when there are none.
+	ClusterPeers []string
+	// ClusterRateLimit is the maximum number of cluster messages accepted
+	// from a cluster peer per minute.
+	ClusterRateLimit int
+	// Leader is the address of the trusted node which is the only peer of
+	// a follower node.
+	Leader string
 }
@@ -32,6 +32,7 @@ type P2P interface {
 	RelayNodeInfo() *peer.AddrInfo
 	IncreaseBytesSent(pid peer.ID, size int)
 	IncreaseBytesRecv(pid peer.ID, size int)
+	DisableRelayTx() bool
 }
 
 type P2PRPC interface {
@@ -19,6 +19,10 @@ import (
 
 // InterceptPeerDial tests whether we're permitted to Dial the specified peer.
 func (s *Service) InterceptPeerDial(p peer.ID) (allow bool) {
+	if !s.isFollowed(p) {
+		log.Trace(fmt.Sprintf("peer:%s reason:not the leader", p.String()))
+		return false
+	}
 	if s.isPeerAtLimit() {
 		log.Trace(fmt.Sprintf("peer:%s reason:at peer max limit", p.String()))
 		return false
@@ -51,7 +55,11 @@ func (s *Service) InterceptAccept(n network.ConnMultiaddrs) (allow bool) {
 
 // InterceptSecured tests whether a given connection, now authenticated,
 // is allowed.
-func (s *Service) InterceptSecured(_ network.Direction, _ peer.ID, n network.ConnMultiaddrs) (allow bool) {
+func (s *Service) InterceptSecured(_ network.Direction, p peer.ID, n network.ConnMultiaddrs) (allow bool) {
+	if !s.isFollowed(p) {
+		log.Trace(fmt.Sprintf("peer:%s reason:not the leader", p.String()))
+		return false
+	}
 	return true
 }
 
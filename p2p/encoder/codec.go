@@ -0,0 +1,210 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package encoder
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// CodecVersion is a version of the wire encoding of a message type.  A message
+// type starts with version 1 and gets a new version, introduced by a new
+// protocol version, whenever its encoding changes, such as when a field is
+// added.
+type CodecVersion struct {
+	// Version is the version of the encoding, starting at 1.
+	Version uint32
+
+	// ProtocolVersion is the first protocol version speaking this version.
+	ProtocolVersion uint32
+
+	// New returns an empty message of this version.  It is not used for
+	// the latest version, whose message is the registered message.
+	New func() interface{}
+
+	// Downgrade converts a message of the next version to a message of this
+	// version and Upgrade converts a message of this version to a message of
+	// the next version.  They are not used for the latest version.
+	Downgrade func(msg interface{}) (interface{}, error)
+	Upgrade   func(msg interface{}) (interface{}, error)
+}
+
+// Codec holds the versions of the wire encoding of a message type, from the
+// oldest to the latest.  Only the messages sent on their own are encoded with
+// their codec, a message held by another message is encoded with the codec of
+// the latter.
+type Codec struct {
+	msgType  reflect.Type
+	versions []CodecVersion
+}
+
+var (
+	codecsLock sync.RWMutex
+	codecs     = make(map[reflect.Type]*Codec)
+)
+
+// RegisterCodec registers the versions of the wire encoding of the type of
+// msg, from the oldest to the latest.  The versions must be numbered from 1
+// and be introduced by increasing protocol versions.
+func RegisterCodec(msg interface{}, versions ...CodecVersion) error {
+	msgType := reflect.TypeOf(msg)
+	if msgType == nil || msgType.Kind() != reflect.Ptr {
+		return fmt.Errorf("codec message must be a pointer, not %T", msg)
+	}
+	if len(versions) == 0 {
+		return fmt.Errorf("codec of %v has no version", msgType)
+	}
+	for i, v := range versions {
+		if v.Version != uint32(i+1) {
+			return fmt.Errorf("codec of %v has version %d at position %d",
+				msgType, v.Version, i)
+		}
+		if i > 0 && v.ProtocolVersion <= versions[i-1].ProtocolVersion {
+			return fmt.Errorf("version %d of the codec of %v is introduced "+
+				"by protocol version %d, not after %d", v.Version, msgType,
+				v.ProtocolVersion, versions[i-1].ProtocolVersion)
+		}
+		if i < len(versions)-1 &&
+			(v.New == nil || v.Downgrade == nil || v.Upgrade == nil) {
+			return fmt.Errorf("version %d of the codec of %v does not "+
+				"convert to the next version", v.Version, msgType)
+		}
+	}
+
+	codecsLock.Lock()
+	defer codecsLock.Unlock()
+
+	if _, ok := codecs[msgType]; ok {
+		return fmt.Errorf("codec of %v is already registered", msgType)
+	}
+	codecs[msgType] = &Codec{msgType: msgType, versions: versions}
+	return nil
+}
+
+// MustRegisterCodec performs the same function as RegisterCodec except it
+// panics if there is an error.  This should only be called from package init
+// functions.
+func MustRegisterCodec(msg interface{}, versions ...CodecVersion) {
+	if err := RegisterCodec(msg, versions...); err != nil {
+		panic(fmt.Sprintf("failed to register codec: %v", err))
+	}
+}
+
+// LookupCodec returns the codec of the type of msg, or nil when it has none.
+func LookupCodec(msg interface{}) *Codec {
+	codecsLock.RLock()
+	defer codecsLock.RUnlock()
+
+	return codecs[reflect.TypeOf(msg)]
+}
+
+// RegisteredCodecs returns the registered codecs sorted by message type.
+func RegisteredCodecs() []*Codec {
+	codecsLock.RLock()
+	defer codecsLock.RUnlock()
+
+	result := make([]*Codec, 0, len(codecs))
+	for _, c := range codecs {
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].msgType.String() < result[j].msgType.String()
+	})
+	return result
+}
+
+// MsgType returns the type of the messages of the latest version.
+func (c *Codec) MsgType() reflect.Type {
+	return c.msgType
+}
+
+// Versions returns the versions of the codec, from the oldest to the latest.
+func (c *Codec) Versions() []CodecVersion {
+	return c.versions
+}
+
+// VersionAt returns the version spoken at the protocol version, which is the
+// latest version introduced by that protocol version or a former one.  The
+// oldest version is spoken by the protocol versions before all of them.
+func (c *Codec) VersionAt(pver uint32) *CodecVersion {
+	v := &c.versions[0]
+	for i := range c.versions {
+		if c.versions[i].ProtocolVersion <= pver {
+			v = &c.versions[i]
+		}
+	}
+	return v
+}
+
+// downgrade converts a message of the latest version to the version.
+func (c *Codec) downgrade(msg interface{}, version uint32) (interface{}, error) {
+	var err error
+	for i := len(c.versions) - 2; i >= int(version)-1; i-- {
+		msg, err = c.versions[i].Downgrade(msg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+// upgrade converts a message of the version to the latest version.
+func (c *Codec) upgrade(msg interface{}, version uint32) (interface{}, error) {
+	var err error
+	for i := int(version) - 1; i < len(c.versions)-1; i++ {
+		msg, err = c.versions[i].Upgrade(msg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+// EncodeVersion encodes the message like EncodeWithMaxLength, with the version
+// of its codec spoken at the protocol version.  A message without codec is
+// encoded as is.
+func EncodeVersion(e NetworkEncoding, w io.Writer, msg interface{}, pver uint32) (int, error) {
+	c := LookupCodec(msg)
+	if c == nil {
+		return e.EncodeWithMaxLength(w, msg)
+	}
+	encoded, err := c.downgrade(msg, c.VersionAt(pver).Version)
+	if err != nil {
+		return 0, err
+	}
+	return e.EncodeWithMaxLength(w, encoded)
+}
+
+// DecodeVersion decodes the message like DecodeWithMaxLength, with the
+// version of its codec spoken at the protocol version, and upgrades it to the
+// latest version.  A message without codec is decoded as is.
+func DecodeVersion(e NetworkEncoding, r io.Reader, to interface{}, pver uint32) error {
+	c := LookupCodec(to)
+	if c == nil {
+		return e.DecodeWithMaxLength(r, to)
+	}
+	v := c.VersionAt(pver)
+	if int(v.Version) == len(c.versions) {
+		return e.DecodeWithMaxLength(r, to)
+	}
+	msg := v.New()
+	err := e.DecodeWithMaxLength(r, msg)
+	if err != nil {
+		return err
+	}
+	upgraded, err := c.upgrade(msg, v.Version)
+	if err != nil {
+		return err
+	}
+	if reflect.TypeOf(upgraded) != c.msgType {
+		return fmt.Errorf("codec of %v upgraded version %d to %T",
+			c.msgType, v.Version, upgraded)
+	}
+	reflect.ValueOf(to).Elem().Set(reflect.ValueOf(upgraded).Elem())
+	return nil
+}
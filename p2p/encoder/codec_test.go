@@ -0,0 +1,152 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package encoder
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+const (
+	oldProtocolVersion = 33
+	newProtocolVersion = 34
+)
+
+// testStateV1 is the first version of testState, spoken by the old peers.
+type testStateV1 struct {
+	Total uint64
+}
+
+// testState is the latest version of a message, which gained a field.
+type testState struct {
+	Total uint64
+	Tips  uint64
+}
+
+func init() {
+	MustRegisterCodec(&testState{},
+		CodecVersion{
+			Version:         1,
+			ProtocolVersion: oldProtocolVersion,
+			New:             func() interface{} { return &testStateV1{} },
+			Downgrade: func(msg interface{}) (interface{}, error) {
+				m, ok := msg.(*testState)
+				if !ok {
+					return nil, fmt.Errorf("message is %T", msg)
+				}
+				return &testStateV1{Total: m.Total}, nil
+			},
+			Upgrade: func(msg interface{}) (interface{}, error) {
+				m, ok := msg.(*testStateV1)
+				if !ok {
+					return nil, fmt.Errorf("message is %T", msg)
+				}
+				return &testState{Total: m.Total}, nil
+			},
+		},
+		CodecVersion{Version: 2, ProtocolVersion: newProtocolVersion},
+	)
+}
+
+// TestCodecCompatibility checks every combination of old and new peers: the
+// spoken protocol version is the lowest one of both peers, old peers only
+// know the first version of the message, while new peers use the codec.
+func TestCodecCompatibility(t *testing.T) {
+	e := &SszNetworkEncoder{}
+	sent := &testState{Total: 7, Tips: 3}
+	tests := []struct {
+		sender   uint32
+		receiver uint32
+		want     testState
+	}{
+		{oldProtocolVersion, oldProtocolVersion, testState{Total: 7}},
+		{oldProtocolVersion, newProtocolVersion, testState{Total: 7}},
+		{newProtocolVersion, oldProtocolVersion, testState{Total: 7}},
+		{newProtocolVersion, newProtocolVersion, testState{Total: 7, Tips: 3}},
+	}
+	for _, test := range tests {
+		pver := test.sender
+		if test.receiver < pver {
+			pver = test.receiver
+		}
+
+		var buf bytes.Buffer
+		var err error
+		if test.sender == oldProtocolVersion {
+			_, err = e.EncodeWithMaxLength(&buf, &testStateV1{Total: sent.Total})
+		} else {
+			_, err = EncodeVersion(e, &buf, sent, pver)
+		}
+		if err != nil {
+			t.Fatalf("%d to %d: encode: %v", test.sender, test.receiver, err)
+		}
+
+		got := &testState{}
+		if test.receiver == oldProtocolVersion {
+			old := &testStateV1{}
+			err = e.DecodeWithMaxLength(&buf, old)
+			got.Total = old.Total
+		} else {
+			err = DecodeVersion(e, &buf, got, pver)
+		}
+		if err != nil {
+			t.Fatalf("%d to %d: decode: %v", test.sender, test.receiver, err)
+		}
+		if *got != test.want {
+			t.Errorf("%d to %d: got %+v, want %+v", test.sender,
+				test.receiver, *got, test.want)
+		}
+	}
+}
+
+func TestCodecVersionAt(t *testing.T) {
+	c := LookupCodec(&testState{})
+	if c == nil {
+		t.Fatalf("codec of testState is not registered")
+	}
+	tests := []struct {
+		pver uint32
+		want uint32
+	}{
+		{0, 1},
+		{oldProtocolVersion, 1},
+		{newProtocolVersion, 2},
+		{newProtocolVersion + 1, 2},
+	}
+	for _, test := range tests {
+		if got := c.VersionAt(test.pver).Version; got != test.want {
+			t.Errorf("protocol version %d: got codec version %d, want %d",
+				test.pver, got, test.want)
+		}
+	}
+}
+
+func TestRegisterCodecErrors(t *testing.T) {
+	type msg struct{ A uint64 }
+	tests := []struct {
+		name     string
+		msg      interface{}
+		versions []CodecVersion
+	}{
+		{"not a pointer", msg{}, []CodecVersion{{Version: 1}}},
+		{"no version", &msg{}, nil},
+		{"first version", &msg{}, []CodecVersion{{Version: 2}}},
+		{"protocol version", &msg{}, []CodecVersion{
+			{Version: 1, ProtocolVersion: 34, New: func() interface{} { return &msg{} },
+				Downgrade: func(m interface{}) (interface{}, error) { return m, nil },
+				Upgrade:   func(m interface{}) (interface{}, error) { return m, nil }},
+			{Version: 2, ProtocolVersion: 33}}},
+		{"no conversion", &msg{}, []CodecVersion{
+			{Version: 1, ProtocolVersion: 33},
+			{Version: 2, ProtocolVersion: 34}}},
+		{"duplicate", &testState{}, []CodecVersion{{Version: 1}}},
+	}
+	for _, test := range tests {
+		if err := RegisterCodec(test.msg, test.versions...); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
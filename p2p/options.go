@@ -5,9 +5,9 @@
 package p2p
 
 import (
-	"crypto/ecdsa"
 	"fmt"
 	"github.com/Qitmeer/qitmeer/version"
+	"github.com/libp2p/go-libp2p-core/crypto"
 	"github.com/libp2p/go-libp2p-peerstore/pstoreds"
 	"net"
 	"path"
@@ -41,7 +41,7 @@ const (
 )
 
 // buildOptions for the libp2p host.
-func (s *Service) buildOptions(ip net.IP, priKey *ecdsa.PrivateKey) []libp2p.Option {
+func (s *Service) buildOptions(ip net.IP, priKey crypto.PrivKey) []libp2p.Option {
 	cfg := s.cfg
 	listen, err := multiAddressBuilder(ip.String(), cfg.TCPPort)
 	if err != nil {
@@ -145,9 +145,9 @@ func multiAddressBuilder(ipAddr string, port uint) (ma.Multiaddr, error) {
 // Adds a private key to the libp2p option if the option was provided.
 // If the private key file is missing or cannot be read, or if the
 // private key contents cannot be marshaled, an exception is thrown.
-func privKeyOption(privkey *ecdsa.PrivateKey) libp2p.Option {
+func privKeyOption(privkey crypto.PrivKey) libp2p.Option {
 	return func(cfg *libp2p.Config) error {
 		log.Debug("ECDSA private key generated")
-		return cfg.Apply(libp2p.Identity(ConvertToInterfacePrivkey(privkey)))
+		return cfg.Apply(libp2p.Identity(privkey))
 	}
 }
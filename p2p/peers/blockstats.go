@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package peers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"io/ioutil"
+	"os"
+)
+
+// BlockStatsFileName is the name of the file, in the data directory, holding
+// the block statistics of the peers across restarts.
+const BlockStatsFileName = "peerblockstats.json"
+
+// blockStatsPenaltyInterval is the number of orphan or duplicate blocks sent
+// by a peer for each bad response it is given.  Every invalid block is a bad
+// response.
+const blockStatsPenaltyInterval = 10
+
+// BlockStats counts the orphan, duplicate and invalid blocks sent by a peer.
+type BlockStats struct {
+	Orphans    uint64 `json:"orphans"`
+	Duplicates uint64 `json:"duplicates"`
+	Invalids   uint64 `json:"invalids"`
+}
+
+// blockStats returns the block statistics of the peer, creating them when
+// needed.
+//
+// This function MUST be called with the block statistics lock held.
+func (p *Status) blockStats(pid peer.ID) *BlockStats {
+	if p.blockStatsMap == nil {
+		p.blockStatsMap = make(map[peer.ID]*BlockStats)
+	}
+	bs, ok := p.blockStatsMap[pid]
+	if !ok {
+		bs = &BlockStats{}
+		p.blockStatsMap[pid] = bs
+	}
+	return bs
+}
+
+// RecordOrphanBlock counts an orphan block sent by the peer.
+func (p *Status) RecordOrphanBlock(pid peer.ID) {
+	p.blockStatsLock.Lock()
+	bs := p.blockStats(pid)
+	bs.Orphans++
+	penalty := bs.Orphans%blockStatsPenaltyInterval == 0
+	p.blockStatsLock.Unlock()
+
+	if penalty {
+		p.IncrementBadResponses(pid, "orphan blocks")
+	}
+}
+
+// RecordDuplicateBlock counts a duplicate block sent by the peer.
+func (p *Status) RecordDuplicateBlock(pid peer.ID) {
+	p.blockStatsLock.Lock()
+	bs := p.blockStats(pid)
+	bs.Duplicates++
+	penalty := bs.Duplicates%blockStatsPenaltyInterval == 0
+	p.blockStatsLock.Unlock()
+
+	if penalty {
+		p.IncrementBadResponses(pid, "duplicate blocks")
+	}
+}
+
+// RecordInvalidBlock counts an invalid block sent by the peer.
+func (p *Status) RecordInvalidBlock(pid peer.ID, reason string) {
+	p.blockStatsLock.Lock()
+	p.blockStats(pid).Invalids++
+	p.blockStatsLock.Unlock()
+
+	p.IncrementBadResponses(pid, fmt.Sprintf("invalid block: %s", reason))
+}
+
+// BlockStats returns the block statistics of the peer, or nil when it never
+// sent an orphan, duplicate or invalid block.
+func (p *Status) BlockStats(pid peer.ID) *BlockStats {
+	p.blockStatsLock.Lock()
+	defer p.blockStatsLock.Unlock()
+
+	bs, ok := p.blockStatsMap[pid]
+	if !ok {
+		return nil
+	}
+	stats := *bs
+	return &stats
+}
+
+// LoadBlockStats reads the block statistics of the peers from the file.  A
+// missing file is not an error.
+func (p *Status) LoadBlockStats(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	stored := make(map[string]*BlockStats)
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+
+	p.blockStatsLock.Lock()
+	defer p.blockStatsLock.Unlock()
+	p.blockStatsMap = make(map[peer.ID]*BlockStats, len(stored))
+	for id, bs := range stored {
+		pid, err := peer.Decode(id)
+		if err != nil {
+			log.Warn(fmt.Sprintf("Ignoring block stats of invalid peer %s", id))
+			continue
+		}
+		p.blockStatsMap[pid] = bs
+	}
+	return nil
+}
+
+// SaveBlockStats writes the block statistics of the peers to the file.
+func (p *Status) SaveBlockStats(path string, perm os.FileMode) error {
+	p.blockStatsLock.Lock()
+	stored := make(map[string]*BlockStats, len(p.blockStatsMap))
+	for pid, bs := range p.blockStatsMap {
+		stored[pid.String()] = bs
+	}
+	data, err := json.Marshal(stored)
+	p.blockStatsLock.Unlock()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, perm)
+}
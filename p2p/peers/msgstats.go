@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package peers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"io/ioutil"
+	"os"
+)
+
+// MsgStatsFileName is the name of the file, in the data directory, holding
+// the message statistics of the peers across restarts.
+const MsgStatsFileName = "peermsgstats.json"
+
+// MsgStats counts the messages of each type sent to and received from a peer,
+// and the requests of the peer whose handling failed.
+type MsgStats struct {
+	Sent     map[string]uint64 `json:"sent"`
+	Received map[string]uint64 `json:"received"`
+	Errors   map[string]uint64 `json:"errors"`
+}
+
+func newMsgStats() *MsgStats {
+	return &MsgStats{
+		Sent:     make(map[string]uint64),
+		Received: make(map[string]uint64),
+		Errors:   make(map[string]uint64),
+	}
+}
+
+func (ms *MsgStats) clone() *MsgStats {
+	c := newMsgStats()
+	for t, n := range ms.Sent {
+		c.Sent[t] = n
+	}
+	for t, n := range ms.Received {
+		c.Received[t] = n
+	}
+	for t, n := range ms.Errors {
+		c.Errors[t] = n
+	}
+	return c
+}
+
+// msgStats returns the message statistics of the peer, creating them when
+// needed.
+//
+// This function MUST be called with the message statistics lock held.
+func (p *Status) msgStats(pid peer.ID) *MsgStats {
+	if p.msgStatsMap == nil {
+		p.msgStatsMap = make(map[peer.ID]*MsgStats)
+	}
+	ms, ok := p.msgStatsMap[pid]
+	if !ok {
+		ms = newMsgStats()
+		p.msgStatsMap[pid] = ms
+	}
+	return ms
+}
+
+// RecordMsgSent counts a message of the type sent to the peer.
+func (p *Status) RecordMsgSent(pid peer.ID, msgType string) {
+	p.msgStatsLock.Lock()
+	p.msgStats(pid).Sent[msgType]++
+	p.msgStatsLock.Unlock()
+}
+
+// RecordMsgReceived counts a message of the type received from the peer.
+func (p *Status) RecordMsgReceived(pid peer.ID, msgType string) {
+	p.msgStatsLock.Lock()
+	p.msgStats(pid).Received[msgType]++
+	p.msgStatsLock.Unlock()
+}
+
+// RecordMsgError counts a message of the type received from the peer which
+// could not be handled.
+func (p *Status) RecordMsgError(pid peer.ID, msgType string) {
+	p.msgStatsLock.Lock()
+	p.msgStats(pid).Errors[msgType]++
+	p.msgStatsLock.Unlock()
+}
+
+// MsgStats returns a copy of the message statistics of all the peers ever
+// seen, including the peers which are gone.
+func (p *Status) MsgStats() map[peer.ID]*MsgStats {
+	p.msgStatsLock.Lock()
+	defer p.msgStatsLock.Unlock()
+
+	stats := make(map[peer.ID]*MsgStats, len(p.msgStatsMap))
+	for pid, ms := range p.msgStatsMap {
+		stats[pid] = ms.clone()
+	}
+	return stats
+}
+
+// LoadMsgStats reads the message statistics of the peers from the file.  A
+// missing file is not an error.
+func (p *Status) LoadMsgStats(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	stored := make(map[string]*MsgStats)
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+
+	p.msgStatsLock.Lock()
+	defer p.msgStatsLock.Unlock()
+	p.msgStatsMap = make(map[peer.ID]*MsgStats, len(stored))
+	for id, ms := range stored {
+		pid, err := peer.Decode(id)
+		if err != nil {
+			log.Warn(fmt.Sprintf("Ignoring message stats of invalid peer %s", id))
+			continue
+		}
+		// The clone has all its maps, even if the file misses some.
+		p.msgStatsMap[pid] = ms.clone()
+	}
+	return nil
+}
+
+// SaveMsgStats writes the message statistics of the peers to the file.
+func (p *Status) SaveMsgStats(path string, perm os.FileMode) error {
+	p.msgStatsLock.Lock()
+	stored := make(map[string]*MsgStats, len(p.msgStatsMap))
+	for pid, ms := range p.msgStatsMap {
+		stored[pid.String()] = ms
+	}
+	data, err := json.Marshal(stored)
+	p.msgStatsLock.Unlock()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, perm)
+}
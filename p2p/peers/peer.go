@@ -16,6 +16,7 @@ import (
 	"github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
 	ma "github.com/multiformats/go-multiaddr"
+	"math"
 	"sync"
 	"time"
 )
@@ -25,6 +26,15 @@ var (
 	MaxBadResponses = 50
 )
 
+// GraphStateMempoolFeature is the feature bit of an extended graph state which
+// carries the mempool transaction count and fee floor of the sender.
+const GraphStateMempoolFeature uint32 = 1 << 0
+
+// GraphStateBlocksOnlyFeature is the feature bit of an extended graph state
+// telling that the sender does not relay transactions, which may change after
+// the chain state was exchanged.
+const GraphStateBlocksOnlyFeature uint32 = 1 << 1
+
 // Peer represents a connected p2p network remote node.
 type Peer struct {
 	*peerStatus
@@ -33,6 +43,21 @@ type Peer struct {
 	// Use to fee filter
 	feeFilter int64
 	filter    *bloom.Filter
+	// Use to limit the bloom filter requests
+	bloomTotal    float64 // exponentially decaying total of bloom requests.
+	lastBloomUnix int64   // unix time of the last bloom request.
+	// Use to limit the cluster messages
+	clusterTotal    float64 // exponentially decaying total of cluster messages.
+	lastClusterUnix int64   // unix time of the last cluster message.
+
+	// The mempool summary of the extended graph state
+	hasMempool bool
+	mempoolTxs uint32
+
+	// The relay mode of the latest extended graph state with features,
+	// which overrides the one of the chain state.
+	relayKnown bool
+	blocksOnly bool
 
 	lock       *sync.RWMutex
 	lastSend   time.Time
@@ -184,6 +209,40 @@ func (p *Peer) Filter() *bloom.Filter {
 	return p.filter
 }
 
+// AllowBloomRequests counts n bloom filter requests of the peer within an
+// exponentially decaying ~1 minute window.  It returns false, without counting
+// them, when they would exceed the limit.
+func (p *Peer) AllowBloomRequests(n int, limit int) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	nowUnix := roughtime.Now().Unix()
+	p.bloomTotal *= math.Pow(1.0-1.0/60.0, float64(nowUnix-p.lastBloomUnix))
+	p.lastBloomUnix = nowUnix
+	if p.bloomTotal+float64(n) > float64(limit) {
+		return false
+	}
+	p.bloomTotal += float64(n)
+	return true
+}
+
+// AllowClusterMessage counts a cluster message of the peer within an
+// exponentially decaying ~1 minute window.  It returns false, without counting
+// it, when it would exceed the limit.
+func (p *Peer) AllowClusterMessage(limit int) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	nowUnix := roughtime.Now().Unix()
+	p.clusterTotal *= math.Pow(1.0-1.0/60.0, float64(nowUnix-p.lastClusterUnix))
+	p.lastClusterUnix = nowUnix
+	if p.clusterTotal+1 > float64(limit) {
+		return false
+	}
+	p.clusterTotal++
+	return true
+}
+
 func (p *Peer) node() *qnode.Node {
 	if p.qnr == nil {
 		return nil
@@ -327,6 +386,11 @@ func (p *Peer) StatsSnapshot() (*StatsSnap, error) {
 		ss.GraphState = p.graphState()
 		ss.GraphStateDur = time.Since(p.graphStateTime)
 	}
+	if p.hasMempool {
+		mempoolTxs, feeFloor := p.mempoolTxs, p.feeFilter
+		ss.MempoolTxs = &mempoolTxs
+		ss.FeeFloor = &feeFloor
+	}
 	return ss, nil
 }
 
@@ -347,6 +411,13 @@ func (p *Peer) SetQNR(record *qnr.Record) {
 	p.qnr = record
 }
 
+func (p *Peer) ProtocolVersion() uint32 {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.protocolVersion()
+}
+
 func (p *Peer) protocolVersion() uint32 {
 	if p.chainState == nil {
 		return 0
@@ -435,6 +506,33 @@ func (p *Peer) UpdateGraphState(gs *pb.GraphState) {
 		}*/
 }
 
+// UpdateGraphStateExt updates the graph state of the peer along with its
+// mempool summary.  The fee floor of the summary becomes the fee filter of the
+// peer, below which no transaction is relayed to it.
+func (p *Peer) UpdateGraphStateExt(gs *pb.GraphStateExt) {
+	if gs.GraphState != nil {
+		p.UpdateGraphState(gs.GraphState)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	// A graph state without features comes from a peer which may not
+	// know them, so its relay mode is left to its chain state.
+	if gs.Features != 0 {
+		p.relayKnown = true
+		p.blocksOnly = gs.Features&GraphStateBlocksOnlyFeature != 0
+	}
+	p.hasMempool = gs.Features&GraphStateMempoolFeature != 0
+	if !p.hasMempool {
+		p.mempoolTxs = 0
+		p.feeFilter = 0
+		return
+	}
+	p.mempoolTxs = gs.MempoolTxs
+	p.feeFilter = int64(gs.FeeFloor)
+}
+
 func (p *Peer) UpdateSyncPoint(point *hash.Hash) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
@@ -449,10 +547,15 @@ func (p *Peer) SyncPoint() *hash.Hash {
 	return p.syncPoint
 }
 
+// DisableRelayTx returns whether the peer does not relay transactions, as
+// advertised by its latest graph state or else by its chain state.
 func (p *Peer) DisableRelayTx() bool {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
 
+	if p.relayKnown {
+		return p.blocksOnly
+	}
 	if p.chainState == nil {
 		return false
 	}
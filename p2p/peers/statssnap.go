@@ -33,6 +33,9 @@ type StatsSnap struct {
 	BytesRecv     uint64
 	IsCircuit     bool
 	Bads          int
+	MempoolTxs    *uint32
+	FeeFloor      *int64
+	BlockStats    *BlockStats
 }
 
 func (p *StatsSnap) IsRelay() bool {
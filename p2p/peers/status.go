@@ -21,6 +21,15 @@ type Status struct {
 	lock  sync.RWMutex
 	peers map[peer.ID]*Peer
 
+	// The block statistics are kept for the peers which are gone, so that
+	// they can be persisted across restarts.
+	blockStatsLock sync.Mutex
+	blockStatsMap  map[peer.ID]*BlockStats
+
+	// The message statistics are kept for the peers which are gone too.
+	msgStatsLock sync.Mutex
+	msgStatsMap  map[peer.ID]*MsgStats
+
 	p2p common.P2P
 }
 
@@ -241,6 +250,7 @@ func (p *Status) StatsSnapshots() []*StatsSnap {
 		if err != nil {
 			continue
 		}
+		ss.BlockStats = p.BlockStats(pe.GetID())
 		pes = append(pes, ss)
 	}
 	return pes
@@ -102,30 +102,108 @@ func (m *GraphState) GetTips() []*Hash {
 	return nil
 }
 
+// GraphStateExt extends the graph state with optional data of the sender,
+// which is set when the matching feature bit is set.
+type GraphStateExt struct {
+	GraphState           *GraphState `protobuf:"bytes,100,opt,name=graphState,proto3" json:"graphState,omitempty"`
+	Features             uint32      `protobuf:"varint,101,opt,name=features,proto3" json:"features,omitempty"`
+	MempoolTxs           uint32      `protobuf:"varint,102,opt,name=mempoolTxs,proto3" json:"mempoolTxs,omitempty"`
+	FeeFloor             uint64      `protobuf:"varint,103,opt,name=feeFloor,proto3" json:"feeFloor,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *GraphStateExt) Reset()         { *m = GraphStateExt{} }
+func (m *GraphStateExt) String() string { return proto.CompactTextString(m) }
+func (*GraphStateExt) ProtoMessage()    {}
+func (*GraphStateExt) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6b0f1dfc60bedea3, []int{1}
+}
+func (m *GraphStateExt) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *GraphStateExt) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_GraphStateExt.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *GraphStateExt) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GraphStateExt.Merge(m, src)
+}
+func (m *GraphStateExt) XXX_Size() int {
+	return m.Size()
+}
+func (m *GraphStateExt) XXX_DiscardUnknown() {
+	xxx_messageInfo_GraphStateExt.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GraphStateExt proto.InternalMessageInfo
+
+func (m *GraphStateExt) GetGraphState() *GraphState {
+	if m != nil {
+		return m.GraphState
+	}
+	return nil
+}
+
+func (m *GraphStateExt) GetFeatures() uint32 {
+	if m != nil {
+		return m.Features
+	}
+	return 0
+}
+
+func (m *GraphStateExt) GetMempoolTxs() uint32 {
+	if m != nil {
+		return m.MempoolTxs
+	}
+	return 0
+}
+
+func (m *GraphStateExt) GetFeeFloor() uint64 {
+	if m != nil {
+		return m.FeeFloor
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*GraphState)(nil), "qitmeer.p2p.v1.GraphState")
+	proto.RegisterType((*GraphStateExt)(nil), "qitmeer.p2p.v1.GraphStateExt")
 }
 
 func init() { proto.RegisterFile("graphstate.proto", fileDescriptor_6b0f1dfc60bedea3) }
 
 var fileDescriptor_6b0f1dfc60bedea3 = []byte{
-	// 249 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x12, 0x48, 0x2f, 0x4a, 0x2c,
-	0xc8, 0x28, 0x2e, 0x49, 0x2c, 0x49, 0xd5, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0xe2, 0x2b, 0xcc,
-	0x2c, 0xc9, 0x4d, 0x4d, 0x2d, 0xd2, 0x2b, 0x30, 0x2a, 0xd0, 0x2b, 0x33, 0x94, 0xd2, 0x4d, 0xcf,
-	0x2c, 0xc9, 0x28, 0x4d, 0xd2, 0x4b, 0xce, 0xcf, 0xd5, 0x4f, 0xcf, 0x4f, 0xcf, 0xd7, 0x07, 0x2b,
-	0x4b, 0x2a, 0x4d, 0x03, 0xf3, 0xc0, 0x1c, 0x30, 0x0b, 0xa2, 0x5d, 0x8a, 0x2f, 0x37, 0xb5, 0xb8,
-	0x38, 0x31, 0x3d, 0xb5, 0x18, 0xc2, 0x57, 0xda, 0xcc, 0xc8, 0xc5, 0xe5, 0x0e, 0xb2, 0x23, 0x18,
-	0x64, 0x87, 0x90, 0x08, 0x17, 0x6b, 0x49, 0x7e, 0x49, 0x62, 0x8e, 0x44, 0x8a, 0x02, 0xa3, 0x06,
-	0x6f, 0x10, 0x84, 0x03, 0x12, 0xcd, 0x49, 0xac, 0x4c, 0x2d, 0x92, 0x48, 0x85, 0x88, 0x82, 0x39,
-	0x42, 0x72, 0x5c, 0x5c, 0xb9, 0x89, 0x99, 0x79, 0x1e, 0xa9, 0x99, 0xe9, 0x19, 0x25, 0x12, 0x69,
-	0x60, 0x29, 0x24, 0x11, 0x21, 0x19, 0x2e, 0x4e, 0x10, 0xcf, 0xbf, 0x28, 0x25, 0xb5, 0x48, 0x22,
-	0x1d, 0x2c, 0x8d, 0x10, 0x10, 0xb2, 0xe6, 0x62, 0x29, 0xc9, 0x2c, 0x28, 0x96, 0xc8, 0x50, 0x60,
-	0xd6, 0xe0, 0x36, 0x12, 0xd1, 0x43, 0xf5, 0x96, 0x9e, 0x47, 0x62, 0x71, 0x86, 0x93, 0xe0, 0xa7,
-	0x7b, 0xf2, 0xbc, 0xc5, 0xc5, 0x55, 0xba, 0xb9, 0x89, 0x15, 0x56, 0x4a, 0x86, 0x06, 0x06, 0x4a,
-	0x41, 0x60, 0x4d, 0x4e, 0x02, 0x27, 0x1e, 0xc9, 0x31, 0x5e, 0x78, 0x24, 0xc7, 0xf8, 0xe0, 0x91,
-	0x1c, 0xe3, 0x8c, 0xc7, 0x72, 0x0c, 0x49, 0x6c, 0x60, 0xef, 0x18, 0x03, 0x02, 0x00, 0x00, 0xff,
-	0xff, 0xd5, 0x18, 0x76, 0x52, 0x31, 0x01, 0x00, 0x00,
+	// 311 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5d, 0x90, 0x31, 0x4e, 0xc3, 0x30,
+	0x14, 0x86, 0x89, 0x28, 0x08, 0x5c, 0xa5, 0x2a, 0x56, 0x87, 0x28, 0x42, 0xa1, 0xca, 0xd4, 0xa5,
+	0x6e, 0x1b, 0xb6, 0xb2, 0x55, 0x02, 0xba, 0x21, 0x05, 0x2e, 0xe0, 0x50, 0xc7, 0x89, 0x14, 0x63,
+	0x63, 0x3b, 0xa8, 0xe5, 0x24, 0xec, 0x1c, 0x81, 0x4b, 0x30, 0x72, 0x02, 0x84, 0xe0, 0x06, 0x9c,
+	0x80, 0xc4, 0x46, 0x49, 0x61, 0xb0, 0xe4, 0xef, 0x7f, 0xef, 0xf7, 0x7b, 0xbf, 0x41, 0x9f, 0x4a,
+	0x2c, 0x32, 0xa5, 0xb1, 0x26, 0x48, 0x48, 0xae, 0x39, 0xec, 0xdd, 0xe7, 0x9a, 0x11, 0x22, 0x91,
+	0x88, 0x04, 0x7a, 0x98, 0xf9, 0x63, 0x9a, 0xeb, 0xac, 0x4c, 0xd0, 0x2d, 0x67, 0x13, 0xca, 0x29,
+	0x9f, 0x98, 0xb6, 0xa4, 0x4c, 0x0d, 0x19, 0x30, 0x37, 0x6b, 0xf7, 0x7b, 0x8c, 0x28, 0x85, 0x29,
+	0x51, 0x96, 0xc3, 0x17, 0x07, 0x80, 0xcb, 0x7a, 0xc6, 0x75, 0x3d, 0x03, 0x0e, 0xc0, 0x9e, 0xe6,
+	0x1a, 0x17, 0xde, 0x6a, 0xe8, 0x8c, 0xdc, 0xd8, 0x42, 0xad, 0x16, 0x78, 0x43, 0xa4, 0x47, 0xac,
+	0x6a, 0x00, 0x06, 0x00, 0x30, 0x9c, 0xdf, 0x2d, 0x49, 0x4e, 0x33, 0xed, 0xa5, 0xa6, 0xb4, 0xa5,
+	0xc0, 0x63, 0x70, 0x58, 0xd3, 0x95, 0x5c, 0x55, 0x4e, 0x6a, 0xca, 0xad, 0x00, 0xcf, 0x40, 0x47,
+	0xe7, 0x42, 0x79, 0xd9, 0x70, 0x77, 0xd4, 0x8d, 0x06, 0xe8, 0x6f, 0x2c, 0xb4, 0xc4, 0x2a, 0x5b,
+	0x1c, 0x7d, 0xbf, 0x9f, 0xb8, 0x4a, 0x3d, 0x8e, 0x19, 0x5e, 0xcf, 0xc3, 0xd9, 0x74, 0x1a, 0xc6,
+	0xc6, 0x14, 0x3e, 0x3b, 0xc0, 0x6d, 0xb7, 0x3e, 0x5f, 0x6b, 0x38, 0x07, 0x80, 0x36, 0x82, 0xd9,
+	0xbe, 0x1b, 0xf9, 0xff, 0x1f, 0x6d, 0x2d, 0xf1, 0x56, 0x37, 0xf4, 0xc1, 0x41, 0x4a, 0xb0, 0x2e,
+	0x25, 0x51, 0xbf, 0x09, 0x1b, 0x36, 0x21, 0x09, 0x13, 0x9c, 0x17, 0x37, 0x6b, 0xd5, 0x84, 0x6c,
+	0x14, 0xeb, 0x25, 0x17, 0x05, 0xe7, 0x36, 0x63, 0x27, 0x6e, 0x78, 0xd1, 0x7f, 0xfd, 0x0c, 0x9c,
+	0xb7, 0xea, 0x7c, 0x54, 0xe7, 0xe9, 0x2b, 0xd8, 0x49, 0xf6, 0xcd, 0xa7, 0x9f, 0xfe, 0x00, 0x07,
+	0xaf, 0xc4, 0x76, 0xd7, 0x01, 0x00, 0x00,
 }
 
 func (m *GraphState) Marshal() (dAtA []byte, err error) {
@@ -199,6 +277,68 @@ func (m *GraphState) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *GraphStateExt) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GraphStateExt) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GraphStateExt) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.FeeFloor != 0 {
+		i = encodeVarintGraphstate(dAtA, i, uint64(m.FeeFloor))
+		i--
+		dAtA[i] = 0x6
+		i--
+		dAtA[i] = 0xb8
+	}
+	if m.MempoolTxs != 0 {
+		i = encodeVarintGraphstate(dAtA, i, uint64(m.MempoolTxs))
+		i--
+		dAtA[i] = 0x6
+		i--
+		dAtA[i] = 0xb0
+	}
+	if m.Features != 0 {
+		i = encodeVarintGraphstate(dAtA, i, uint64(m.Features))
+		i--
+		dAtA[i] = 0x6
+		i--
+		dAtA[i] = 0xa8
+	}
+	if m.GraphState != nil {
+		{
+			size, err := m.GraphState.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintGraphstate(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x6
+		i--
+		dAtA[i] = 0xa2
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintGraphstate(dAtA []byte, offset int, v uint64) int {
 	offset -= sovGraphstate(v)
 	base := offset
@@ -240,6 +380,31 @@ func (m *GraphState) Size() (n int) {
 	return n
 }
 
+func (m *GraphStateExt) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.GraphState != nil {
+		l = m.GraphState.Size()
+		n += 2 + l + sovGraphstate(uint64(l))
+	}
+	if m.Features != 0 {
+		n += 2 + sovGraphstate(uint64(m.Features))
+	}
+	if m.MempoolTxs != 0 {
+		n += 2 + sovGraphstate(uint64(m.MempoolTxs))
+	}
+	if m.FeeFloor != 0 {
+		n += 2 + sovGraphstate(uint64(m.FeeFloor))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func sovGraphstate(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -410,6 +575,153 @@ func (m *GraphState) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *GraphStateExt) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowGraphstate
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GraphStateExt: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GraphStateExt: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 100:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field GraphState", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGraphstate
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthGraphstate
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthGraphstate
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.GraphState == nil {
+				m.GraphState = &GraphState{}
+			}
+			if err := m.GraphState.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 101:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Features", wireType)
+			}
+			m.Features = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGraphstate
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Features |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 102:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MempoolTxs", wireType)
+			}
+			m.MempoolTxs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGraphstate
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MempoolTxs |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 103:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FeeFloor", wireType)
+			}
+			m.FeeFloor = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGraphstate
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FeeFloor |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipGraphstate(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthGraphstate
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthGraphstate
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipGraphstate(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
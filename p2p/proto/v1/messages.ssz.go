@@ -826,6 +826,92 @@ func (g *GraphState) SizeSSZ() (size int) {
 	return
 }
 
+// MarshalSSZ ssz marshals the GraphStateExt object
+func (g *GraphStateExt) MarshalSSZ() ([]byte, error) {
+	buf := make([]byte, g.SizeSSZ())
+	return g.MarshalSSZTo(buf[:0])
+}
+
+// MarshalSSZTo ssz marshals the GraphStateExt object to a target array
+func (g *GraphStateExt) MarshalSSZTo(dst []byte) ([]byte, error) {
+	var err error
+	offset := int(20)
+
+	// Offset (0) 'GraphState'
+	dst = ssz.WriteOffset(dst, offset)
+	if g.GraphState == nil {
+		g.GraphState = new(GraphState)
+	}
+	offset += g.GraphState.SizeSSZ()
+
+	// Field (1) 'Features'
+	dst = ssz.MarshalUint32(dst, g.Features)
+
+	// Field (2) 'MempoolTxs'
+	dst = ssz.MarshalUint32(dst, g.MempoolTxs)
+
+	// Field (3) 'FeeFloor'
+	dst = ssz.MarshalUint64(dst, g.FeeFloor)
+
+	// Field (0) 'GraphState'
+	if dst, err = g.GraphState.MarshalSSZTo(dst); err != nil {
+		return nil, err
+	}
+
+	return dst, err
+}
+
+// UnmarshalSSZ ssz unmarshals the GraphStateExt object
+func (g *GraphStateExt) UnmarshalSSZ(buf []byte) error {
+	var err error
+	size := uint64(len(buf))
+	if size < 20 {
+		return errSize
+	}
+
+	tail := buf
+	var o0 uint64
+
+	// Offset (0) 'GraphState'
+	if o0 = ssz.ReadOffset(buf[0:4]); o0 > size {
+		return errOffset
+	}
+
+	// Field (1) 'Features'
+	g.Features = ssz.UnmarshallUint32(buf[4:8])
+
+	// Field (2) 'MempoolTxs'
+	g.MempoolTxs = ssz.UnmarshallUint32(buf[8:12])
+
+	// Field (3) 'FeeFloor'
+	g.FeeFloor = ssz.UnmarshallUint64(buf[12:20])
+
+	// Field (0) 'GraphState'
+	{
+		buf = tail[o0:]
+		if g.GraphState == nil {
+			g.GraphState = new(GraphState)
+		}
+		if err = g.GraphState.UnmarshalSSZ(buf); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// SizeSSZ returns the ssz encoded size in bytes for the GraphStateExt object
+func (g *GraphStateExt) SizeSSZ() (size int) {
+	size = 20
+
+	// Field (0) 'GraphState'
+	if g.GraphState == nil {
+		g.GraphState = new(GraphState)
+	}
+	size += g.GraphState.SizeSSZ()
+
+	return
+}
+
 // MarshalSSZ ssz marshals the SyncQNR object
 func (s *SyncQNR) MarshalSSZ() ([]byte, error) {
 	buf := make([]byte, s.SizeSSZ())
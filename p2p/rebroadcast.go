@@ -1,6 +1,7 @@
 package p2p
 
 import (
+	"github.com/Qitmeer/qitmeer/common/crash"
 	"github.com/Qitmeer/qitmeer/common/hash"
 	"github.com/Qitmeer/qitmeer/core/types"
 	"github.com/Qitmeer/qitmeer/params"
@@ -40,7 +41,10 @@ func (r *Rebroadcast) Start() {
 	log.Info("Starting Rebroadcast")
 
 	r.wg.Add(1)
-	go r.handler()
+	go func() {
+		defer r.wg.Done()
+		crash.Loop("p2p/rebroadcast", r.quit, r.handler)
+	}()
 }
 
 func (r *Rebroadcast) Stop() error {
@@ -105,7 +109,6 @@ cleanup:
 			break cleanup
 		}
 	}
-	r.wg.Done()
 }
 
 func (r *Rebroadcast) AddInventory(h *hash.Hash, data interface{}) {
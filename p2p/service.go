@@ -10,6 +10,9 @@ import (
 	"github.com/Qitmeer/qitmeer/core/blockchain"
 	"github.com/Qitmeer/qitmeer/core/event"
 	pv "github.com/Qitmeer/qitmeer/core/protocol"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/crypto/keystore"
+	"github.com/Qitmeer/qitmeer/crypto/signer"
 	"github.com/Qitmeer/qitmeer/node/notify"
 	"github.com/Qitmeer/qitmeer/p2p/common"
 	"github.com/Qitmeer/qitmeer/p2p/discover"
@@ -25,6 +28,7 @@ import (
 	"github.com/dgraph-io/ristretto"
 	"github.com/gogo/protobuf/proto"
 	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/crypto"
 	"github.com/libp2p/go-libp2p-core/host"
 	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/libp2p/go-libp2p-discovery"
@@ -83,6 +87,13 @@ type Service struct {
 	txMemPool   *mempool.TxPool
 	notify      notify.Notify
 	rebroadcast *Rebroadcast
+
+	// leader is the only peer of a follower node.
+	leader peer.ID
+
+	// bandwidth switches the node to the blocks-only mode once the daily
+	// budget of p2p traffic is exceeded.
+	bandwidth bandwidthBudget
 }
 
 func (s *Service) Start() error {
@@ -143,6 +154,10 @@ func (s *Service) Start() error {
 	}
 
 	runutil.RunEvery(s.ctx, time.Hour, s.Peers().Decay)
+	if s.bandwidth.budget > 0 {
+		s.checkBandwidthDay()
+		runutil.RunEvery(s.ctx, bandwidthCheckInterval, s.checkBandwidthDay)
+	}
 	runutil.RunEvery(s.ctx, refreshRate, func() {
 		s.RefreshQNR()
 	})
@@ -263,10 +278,40 @@ func (s *Service) Peers() *peers.Status {
 	return s.sy.Peers()
 }
 
+// Leader returns the id of the leader of a follower node, which is empty when
+// the node is not a follower.
+func (s *Service) Leader() peer.ID {
+	return s.leader
+}
+
+// isFollowed returns whether the node may connect with the peer, which is any
+// peer unless the node only follows its leader.
+func (s *Service) isFollowed(p peer.ID) bool {
+	return len(s.leader) == 0 || p == s.leader
+}
+
+// PeerProtocolVersion returns the protocol version spoken with the peer, which
+// is the lowest of the protocol versions of the node and of the peer, or the
+// initial protocol version until the chain state of the peer is known.
+func (s *Service) PeerProtocolVersion(pid peer.ID) uint32 {
+	version := pv.InitialProcotolVersion
+	if s.Peers() != nil {
+		pe := s.Peers().Get(pid)
+		if pe != nil && pe.ProtocolVersion() != 0 {
+			version = pe.ProtocolVersion()
+		}
+	}
+	if s.cfg.ProtocolVersion != 0 && version > s.cfg.ProtocolVersion {
+		version = s.cfg.ProtocolVersion
+	}
+	return version
+}
+
 func (s *Service) IncreaseBytesSent(pid peer.ID, size int) {
 	if size <= 0 {
 		return
 	}
+	s.addBandwidth(size)
 	if s.Peers() != nil {
 		pe := s.Peers().Get(pid)
 		if pe != nil {
@@ -279,6 +324,7 @@ func (s *Service) IncreaseBytesRecv(pid peer.ID, size int) {
 	if size <= 0 {
 		return
 	}
+	s.addBandwidth(size)
 	if s.Peers() != nil {
 		pe := s.Peers().Get(pid)
 		if pe != nil {
@@ -457,6 +503,46 @@ func (s *Service) RelayInventory(data interface{}, filters []peer.ID) {
 	s.PeerSync().RelayInventory(data, filters)
 }
 
+func (s *Service) RelayDoubleSpendProof(proof *types.DoubleSpendProof, filters []peer.ID) {
+	s.PeerSync().RelayDoubleSpendProof(proof, filters)
+}
+
+func (s *Service) RelayTxPackage(txs []*types.Tx, filters []peer.ID) {
+	s.PeerSync().RelayTxPackage(txs, filters)
+}
+
+// SendClusterHint sends a template hint to the connected peers of the mining
+// cluster and returns the number of peers it was sent to.
+func (s *Service) SendClusterHint(hint []byte) (int, error) {
+	return s.PeerSync().SendClusterMessage(synch.ClusterTemplateHint, hint)
+}
+
+// PushClusterBlock pushes a block found by the node to the peers of the
+// mining cluster.
+func (s *Service) PushClusterBlock(block *types.SerializedBlock) {
+	s.PeerSync().PushClusterBlock(block)
+}
+
+// MainHeights returns the main height of the chain and the main heights of
+// the graph states of the connected peers, for the safe mode monitor.
+func (s *Service) MainHeights() (uint, []uint) {
+	height := s.BlockChain().BestSnapshot().GraphState.GetMainHeight()
+	var peerHeights []uint
+	for _, pe := range s.Peers().ConnectedPeers() {
+		gs := pe.GraphState()
+		if gs == nil {
+			continue
+		}
+		peerHeights = append(peerHeights, gs.GetMainHeight())
+	}
+	return height, peerHeights
+}
+
+// SetSafeMode stops or resumes the relay of the inventory.
+func (s *Service) SetSafeMode(safeMode bool) {
+	s.PeerSync().SetSafeMode(safeMode)
+}
+
 func (s *Service) BroadcastMessage(data interface{}) {
 
 }
@@ -541,7 +627,7 @@ func (s *Service) Rebroadcast() *Rebroadcast {
 	return s.rebroadcast
 }
 
-func NewService(cfg *config.Config, events *event.Feed, param *params.Params) (*Service, error) {
+func NewService(cfg *config.Config, events *event.Feed, param *params.Params, ks *keystore.Keystore) (*Service, error) {
 	var err error
 	ctx, cancel := context.WithCancel(context.Background())
 	cache, err := ristretto.NewCache(&ristretto.Config{
@@ -563,7 +649,7 @@ func NewService(cfg *config.Config, events *event.Feed, param *params.Params) (*
 	bootnodeAddrs := make([]string, 0) //dest of final list of nodes
 
 	bootnodesTemp := cfg.BootstrapNodes
-	if len(bootnodesTemp) <= 0 {
+	if len(bootnodesTemp) <= 0 && len(cfg.Follow) == 0 {
 		bootnodesTemp = param.Bootstrap
 	}
 	for _, addr := range bootnodesTemp {
@@ -594,6 +680,16 @@ func NewService(cfg *config.Config, events *event.Feed, param *params.Params) (*
 	if cfg.MaxBadResp > 0 {
 		peers.MaxBadResponses = cfg.MaxBadResp
 	}
+
+	services := defaultServices
+	if cfg.PeerBloomFilters {
+		services |= pv.Bloom
+	}
+	// A pruned node can not serve the old blocks.
+	if cfg.Prune > 0 {
+		services &^= pv.Full
+		services |= pv.Light
+	}
 	s := &Service{
 		cfg: &common.Config{
 			NoDiscovery:          cfg.NoDiscovery,
@@ -601,6 +697,7 @@ func NewService(cfg *config.Config, events *event.Feed, param *params.Params) (*
 			StaticPeers:          cfg.AddPeers,
 			BootstrapNodeAddr:    bootnodeAddrs,
 			DataDir:              cfg.DataDir,
+			Keystore:             ks,
 			MaxPeers:             uint(cfg.MaxPeers),
 			MaxInbound:           cfg.MaxInbound,
 			ReadWritePermissions: 0600, //-rw------- Read and Write permissions for user
@@ -609,10 +706,11 @@ func NewService(cfg *config.Config, events *event.Feed, param *params.Params) (*
 			UDPPort:              uint(cfg.P2PUDPPort),
 			Encoding:             "ssz-snappy",
 			ProtocolVersion:      pv.ProtocolVersion,
-			Services:             defaultServices,
+			Services:             services,
 			UserAgent:            BuildUserAgent("Qitmeer"),
 			DisableRelayTx:       cfg.BlocksOnly,
 			MaxOrphanTxs:         cfg.MaxOrphanTxs,
+			BloomRateLimit:       cfg.BloomRateLimit,
 			Params:               param,
 			HostAddress:          cfg.HostIP,
 			HostDNS:              cfg.HostDNS,
@@ -622,16 +720,35 @@ func NewService(cfg *config.Config, events *event.Feed, param *params.Params) (*
 			Banning:              cfg.Banning,
 			DisableListen:        cfg.DisableListen,
 			LANPeers:             lanPeers,
+			ClusterPeers:         cfg.ClusterPeers,
+			ClusterRateLimit:     cfg.ClusterRateLimit,
+			Leader:               cfg.Follow,
 		},
 		ctx:           ctx,
 		cancel:        cancel,
 		exclusionList: cache,
 		isPreGenesis:  true,
 		events:        events,
+		bandwidth:     bandwidthBudget{budget: cfg.BlocksOnlyBudget << 20},
 	}
 	dv5Nodes := parseBootStrapAddrs(s.cfg.BootstrapNodeAddr)
 	s.cfg.Discv5BootStrapAddr = dv5Nodes
 
+	if len(s.cfg.Leader) > 0 {
+		var addr multiaddr.Multiaddr
+		addr, err = multiaddr.NewMultiaddr(s.cfg.Leader)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid leader address %s:%v", s.cfg.Leader, err)
+		}
+		var info *peer.AddrInfo
+		info, err = peer.AddrInfoFromP2pAddr(addr)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid leader address %s:%v", s.cfg.Leader, err)
+		}
+		s.leader = info.ID
+		log.Info(fmt.Sprintf("Follow the leader %s", s.leader.String()))
+	}
+
 	var ipAddr net.IP
 	if len(cfg.Listener) > 0 {
 		ipAddr = net.ParseIP(cfg.Listener)
@@ -640,10 +757,20 @@ func NewService(cfg *config.Config, events *event.Feed, param *params.Params) (*
 		ipAddr = IpAddr()
 	}
 
-	s.privKey, err = privKey(s.cfg)
-	if err != nil {
-		log.Error(fmt.Sprintf("Failed to generate p2p private key:%v", err))
-		return nil, err
+	var identity crypto.PrivKey
+	if len(cfg.RemoteSigner) > 0 {
+		identity, err = newSignerPrivKey(signer.NewRemoteSigner(cfg.RemoteSigner))
+		if err != nil {
+			log.Error(fmt.Sprintf("Failed to fetch p2p public key from remote signer:%v", err))
+			return nil, err
+		}
+	} else {
+		s.privKey, err = privKey(s.cfg)
+		if err != nil {
+			log.Error(fmt.Sprintf("Failed to generate p2p private key:%v", err))
+			return nil, err
+		}
+		identity = ConvertToInterfacePrivkey(s.privKey)
 	}
 	s.metaData, err = metaDataFromConfig(s.cfg)
 	if err != nil {
@@ -655,7 +782,7 @@ func NewService(cfg *config.Config, events *event.Feed, param *params.Params) (*
 		log.Error(fmt.Sprintf("Failed to create address filter:%v", err))
 		return nil, err
 	}
-	opts := s.buildOptions(ipAddr, s.privKey)
+	opts := s.buildOptions(ipAddr, identity)
 	h, err := libp2p.New(s.ctx, opts...)
 	if err != nil {
 		log.Error("Failed to create p2p host")
@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package p2p
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/crypto/signer"
+	"github.com/libp2p/go-libp2p-core/crypto"
+	pbcrypto "github.com/libp2p/go-libp2p-core/crypto/pb"
+)
+
+// signerPrivKey is the libp2p identity of a node whose p2p private key is
+// held by a signer, such as a remote signer.  It signs the handshakes like a
+// secp256k1 private key, but it cannot be serialized.
+type signerPrivKey struct {
+	signer signer.Signer
+	pubKey crypto.PubKey
+}
+
+// Ensure signerPrivKey implements the libp2p PrivKey interface.
+var _ crypto.PrivKey = (*signerPrivKey)(nil)
+
+// newSignerPrivKey returns the libp2p identity of the private key held by the
+// signer.
+func newSignerPrivKey(s signer.Signer) (*signerPrivKey, error) {
+	pubKey, err := s.PubKey()
+	if err != nil {
+		return nil, err
+	}
+	return &signerPrivKey{
+		signer: s,
+		pubKey: convertToInterfacePubkey((*ecdsa.PublicKey)(pubKey)),
+	}, nil
+}
+
+// Sign signs the sha256 hash of the data like a libp2p secp256k1 private key.
+func (k *signerPrivKey) Sign(data []byte) ([]byte, error) {
+	hash := sha256.Sum256(data)
+	return k.signer.Sign(hash[:])
+}
+
+// GetPublic returns the public key of the signer.
+func (k *signerPrivKey) GetPublic() crypto.PubKey {
+	return k.pubKey
+}
+
+// Bytes fails since the private key is held by the signer.
+func (k *signerPrivKey) Bytes() ([]byte, error) {
+	return nil, fmt.Errorf("p2p private key is held by a signer")
+}
+
+// Raw fails since the private key is held by the signer.
+func (k *signerPrivKey) Raw() ([]byte, error) {
+	return nil, fmt.Errorf("p2p private key is held by a signer")
+}
+
+// Type returns the type of the private key held by the signer.
+func (k *signerPrivKey) Type() pbcrypto.KeyType {
+	return pbcrypto.KeyType_Secp256k1
+}
+
+// Equals returns whether the key is the identity of the same signer key.
+func (k *signerPrivKey) Equals(o crypto.Key) bool {
+	other, ok := o.(*signerPrivKey)
+	if !ok {
+		return false
+	}
+	return k.pubKey.Equals(other.pubKey)
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package synch
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/metrics"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRecentBlocks is the maximum number of blocks remembered by the
+	// deduplication window.
+	maxRecentBlocks = 2000
+
+	// recentBlockWindow is how long a requested or received block is not
+	// requested again.
+	recentBlockWindow = 2 * ReqTimeout
+)
+
+var (
+	requestedBlocksCounter    = metrics.NewCounter("p2p/sync/block/requested")
+	duplicateRequestsCounter  = metrics.NewCounter("p2p/sync/block/duplicate/request")
+	duplicateProcessedCounter = metrics.NewCounter("p2p/sync/block/duplicate/processed")
+)
+
+// recentBlock is an entry of the deduplication window.
+type recentBlock struct {
+	time     time.Time
+	received bool
+}
+
+// recentBlocks is the deduplication window of the block downloads.  It
+// remembers the blocks recently requested and received, so that a block
+// announced by several peers is neither requested nor processed again while
+// it is being downloaded, and a block rejected by the chain as invalid is not
+// downloaded again right away.
+type recentBlocks struct {
+	lock   sync.Mutex
+	blocks map[hash.Hash]*recentBlock
+	order  []hash.Hash
+}
+
+// request returns whether the block should be requested, which is when it was
+// neither requested nor received within the window, and records the request.
+func (rb *recentBlocks) request(h *hash.Hash) bool {
+	rb.lock.Lock()
+	defer rb.lock.Unlock()
+
+	if b, ok := rb.blocks[*h]; ok && time.Since(b.time) < recentBlockWindow {
+		duplicateRequestsCounter.Inc(1)
+		return false
+	}
+	rb.add(h, false)
+	requestedBlocksCounter.Inc(1)
+	return true
+}
+
+// receive returns whether the block should be processed, which is when it was
+// not received within the window, and records the block as received.
+func (rb *recentBlocks) receive(h *hash.Hash) bool {
+	rb.lock.Lock()
+	defer rb.lock.Unlock()
+
+	if b, ok := rb.blocks[*h]; ok && b.received &&
+		time.Since(b.time) < recentBlockWindow {
+		duplicateProcessedCounter.Inc(1)
+		return false
+	}
+	rb.add(h, true)
+	return true
+}
+
+// forget removes the block requested but not processed, so that it can be
+// requested again at once.
+func (rb *recentBlocks) forget(h *hash.Hash) {
+	rb.lock.Lock()
+	defer rb.lock.Unlock()
+
+	if b, ok := rb.blocks[*h]; !ok || b.received {
+		return
+	}
+	rb.remove(h)
+}
+
+// discard removes the block even when it was received, so that it is
+// requested and processed again at once.
+func (rb *recentBlocks) discard(h *hash.Hash) {
+	rb.lock.Lock()
+	defer rb.lock.Unlock()
+
+	if _, ok := rb.blocks[*h]; ok {
+		rb.remove(h)
+	}
+}
+
+// retryBlock returns whether a block which failed to be processed with the
+// error may still be valid, so that it must not be skipped by the window.
+// This is the case when the chain failed for another reason than the block,
+// such as being read only, and when the transactions of the copy received do
+// not match the header, since the copy of another peer may be the right one.
+func retryBlock(err error) bool {
+	rerr, ok := err.(blockchain.RuleError)
+	if !ok {
+		return true
+	}
+	switch rerr.ErrorCode {
+	case blockchain.ErrBadMerkleRoot, blockchain.ErrDuplicateTx:
+		return true
+	}
+	return false
+}
+
+// add records the block, forgetting the oldest block when too many blocks are
+// remembered.
+func (rb *recentBlocks) add(h *hash.Hash, received bool) {
+	if rb.blocks == nil {
+		rb.blocks = make(map[hash.Hash]*recentBlock)
+	}
+	if _, ok := rb.blocks[*h]; ok {
+		rb.remove(h)
+	}
+	if len(rb.order) >= maxRecentBlocks {
+		delete(rb.blocks, rb.order[0])
+		rb.order = rb.order[1:]
+	}
+	rb.blocks[*h] = &recentBlock{time: time.Now(), received: received}
+	rb.order = append(rb.order, *h)
+}
+
+func (rb *recentBlocks) remove(h *hash.Hash) {
+	delete(rb.blocks, *h)
+	for i := range rb.order {
+		if rb.order[i] == *h {
+			rb.order = append(rb.order[:i], rb.order[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package synch
+
+import (
+	"errors"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"testing"
+	"time"
+)
+
+func Test_RecentBlocksRequest(t *testing.T) {
+	rb := &recentBlocks{}
+	h := hash.HashH([]byte("block"))
+
+	if !rb.request(&h) {
+		t.Fatal("unknown block not requested")
+	}
+	if rb.request(&h) {
+		t.Fatal("block requested twice within the window")
+	}
+
+	// A block requested but not processed can be requested again once it
+	// is forgotten.
+	rb.forget(&h)
+	if !rb.request(&h) {
+		t.Fatal("forgotten block not requested")
+	}
+
+	// The requests expire with the window.
+	rb.blocks[h].time = time.Now().Add(-recentBlockWindow)
+	if !rb.request(&h) {
+		t.Fatal("block not requested after the window")
+	}
+}
+
+func Test_RecentBlocksReceive(t *testing.T) {
+	rb := &recentBlocks{}
+	h := hash.HashH([]byte("block"))
+
+	rb.request(&h)
+	if !rb.receive(&h) {
+		t.Fatal("requested block not processed")
+	}
+	if rb.receive(&h) {
+		t.Fatal("block processed twice within the window")
+	}
+	if rb.request(&h) {
+		t.Fatal("received block requested again within the window")
+	}
+
+	// A received block is not forgotten, so that the copies of other
+	// peers are skipped.
+	rb.forget(&h)
+	if rb.receive(&h) {
+		t.Fatal("received block forgotten")
+	}
+
+	// A discarded block is downloaded and processed again at once.
+	rb.discard(&h)
+	if !rb.request(&h) || !rb.receive(&h) {
+		t.Fatal("discarded block not processed again")
+	}
+
+	rb.blocks[h].time = time.Now().Add(-recentBlockWindow)
+	if !rb.receive(&h) {
+		t.Fatal("block not processed after the window")
+	}
+}
+
+func Test_RecentBlocksLimit(t *testing.T) {
+	rb := &recentBlocks{}
+	hashes := make([]hash.Hash, maxRecentBlocks+1)
+	for i := range hashes {
+		hashes[i] = hash.HashH([]byte{byte(i), byte(i >> 8)})
+		rb.request(&hashes[i])
+	}
+	if len(rb.blocks) != maxRecentBlocks || len(rb.order) != maxRecentBlocks {
+		t.Fatalf("%d blocks remembered, %d in order, max %d",
+			len(rb.blocks), len(rb.order), maxRecentBlocks)
+	}
+
+	// The oldest block is forgotten first.
+	if !rb.request(&hashes[0]) {
+		t.Fatal("oldest block not forgotten")
+	}
+	if rb.request(&hashes[maxRecentBlocks]) {
+		t.Fatal("newest block forgotten")
+	}
+}
+
+func Test_RetryBlock(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		retry bool
+	}{
+		{"transient", errors.New("disk is full"), true},
+		{"read only", blockchain.ErrReadOnly, true},
+		{"malleated", blockchain.RuleError{ErrorCode: blockchain.ErrBadMerkleRoot}, true},
+		{"duplicated tx", blockchain.RuleError{ErrorCode: blockchain.ErrDuplicateTx}, true},
+		{"invalid", blockchain.RuleError{ErrorCode: blockchain.ErrBlockTooBig}, false},
+	}
+	for _, test := range tests {
+		if got := retryBlock(test.err); got != test.retry {
+			t.Errorf("%s: retryBlock got %v, want %v", test.name, got,
+				test.retry)
+		}
+	}
+}
@@ -55,7 +55,7 @@ func (s *Sync) sendChainStateRequest(ctx context.Context, id peer.ID) error {
 	}
 
 	msg := &pb.ChainState{}
-	if err := s.Encoding().DecodeWithMaxLength(stream, msg); err != nil {
+	if err := s.DecodeResponseMsg(stream, msg); err != nil {
 		return err
 	}
 
@@ -161,9 +161,9 @@ func (s *Sync) validateChainStateMessage(ctx context.Context, msg *pb.ChainState
 	}
 
 	if pe.Direction() == network.DirInbound {
-		// Reject outbound peers that are not full nodes.
+		// Reject outbound peers that are not full or pruned nodes.
 		wantServices := protocol.Full
-		if !protocol.HasServices(protocol.ServiceFlag(msg.Services), wantServices) {
+		if !peers.HasConsensusService(protocol.ServiceFlag(msg.Services)) {
 			// missingServices := wantServices & ^msg.Services
 			missingServices := protocol.MissingServices(protocol.ServiceFlag(msg.Services), wantServices)
 			return retErrInvalidChainState, fmt.Errorf("Rejecting peer %s with services %v "+
@@ -184,7 +184,7 @@ func (s *Sync) getChainState() *pb.ChainState {
 		Services:        uint64(s.p2p.Config().Services),
 		GraphState:      s.getGraphState(),
 		UserAgent:       []byte(s.p2p.Config().UserAgent),
-		DisableRelayTx:  s.p2p.Config().DisableRelayTx,
+		DisableRelayTx:  s.p2p.DisableRelayTx(),
 	}
 
 	return cs
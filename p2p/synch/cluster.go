@@ -0,0 +1,181 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package synch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/p2p/common"
+	"github.com/Qitmeer/qitmeer/p2p/peers"
+	pb "github.com/Qitmeer/qitmeer/p2p/proto/v1"
+	libp2pcore "github.com/libp2p/go-libp2p-core"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// Types of the messages of the private cluster channel.
+const (
+	// ClusterTemplateHint is an opaque hint about the block templates of a
+	// cluster peer, which is passed on to the websocket clients.
+	ClusterTemplateHint uint32 = 1
+
+	// ClusterFoundBlock carries the header of a block just found by a
+	// cluster peer, which is downloaded from it right away.
+	ClusterFoundBlock uint32 = 2
+)
+
+// MaxClusterPayload is the maximum size of the payload of a cluster message.
+const MaxClusterPayload = 4096
+
+// IsClusterPeer returns whether the peer was approved by the operator for the
+// private cluster channel.  The peer ids are authenticated by the secure
+// transport of libp2p.
+func (s *Sync) IsClusterPeer(pid peer.ID) bool {
+	_, ok := s.ClusterPeers[pid]
+	return ok
+}
+
+func (s *Sync) sendClusterRequest(ctx context.Context, pe *peers.Peer, msg *pb.ClusterMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, ReqTimeout)
+	defer cancel()
+
+	stream, err := s.Send(ctx, msg, RPCCluster, pe.GetID())
+	if err != nil {
+		log.Trace(fmt.Sprintf("Failed to send cluster message to peer=%v, err=%v", pe.GetID(), err.Error()))
+		return err
+	}
+	defer func() {
+		if err := stream.Reset(); err != nil {
+			log.Error(fmt.Sprintf("Failed to reset stream with protocol %s,%v", stream.Protocol(), err))
+		}
+	}()
+
+	code, errMsg, err := ReadRspCode(stream, s.Encoding())
+	if err != nil {
+		return err
+	}
+
+	if !code.IsSuccess() {
+		return errors.New(errMsg)
+	}
+	return err
+}
+
+func (s *Sync) clusterHandler(ctx context.Context, msg interface{}, stream libp2pcore.Stream) *common.Error {
+	pe := s.peers.Get(stream.Conn().RemotePeer())
+	if pe == nil {
+		return ErrPeerUnknown
+	}
+	// The channel is private, so the other peers are penalized for trying
+	// to use it.
+	if !s.IsClusterPeer(pe.GetID()) {
+		s.peers.IncrementBadResponses(pe.GetID(), "unapproved cluster peer")
+		return common.NewError(common.ErrBadPeer, fmt.Errorf("peer is not an approved cluster peer"))
+	}
+
+	m, ok := msg.(*pb.ClusterMessage)
+	if !ok {
+		return ErrMessage(fmt.Errorf("message is not type *pb.ClusterMessage"))
+	}
+	if len(m.Payload) > MaxClusterPayload {
+		return ErrMessage(fmt.Errorf("cluster message of %d bytes exceeds the limit", len(m.Payload)))
+	}
+	if !pe.AllowClusterMessage(s.p2p.Config().ClusterRateLimit) {
+		log.Debug(fmt.Sprintf("%s exceeded the cluster rate limit -- ignoring "+
+			"message type %d", pe.GetID(), m.Type))
+		return ErrMessage(fmt.Errorf("cluster rate limit exceeded"))
+	}
+
+	switch m.Type {
+	case ClusterTemplateHint:
+		log.Trace(fmt.Sprintf("Received template hint of %d bytes from cluster peer=%v", len(m.Payload), pe.GetID()))
+		s.p2p.Notify().ClusterTemplateHint(pe.GetID(), m.Payload)
+
+	case ClusterFoundBlock:
+		var header types.BlockHeader
+		if err := header.Deserialize(bytes.NewReader(m.Payload)); err != nil {
+			return ErrMessage(fmt.Errorf("invalid found block header: %v", err))
+		}
+		h := header.BlockHash()
+		log.Debug(fmt.Sprintf("Cluster peer=%v found block %s", pe.GetID(), h))
+		go s.peerSync.fetchClusterBlock(pe, &h)
+
+	default:
+		return ErrMessage(fmt.Errorf("unknown cluster message type %d", m.Type))
+	}
+	return s.EncodeResponseMsg(stream, nil)
+}
+
+// fetchClusterBlock downloads a block found by a cluster peer from it, without
+// waiting for its inventory nor for the peer to become the sync peer.
+func (ps *PeerSync) fetchClusterBlock(pe *peers.Peer, h *hash.Hash) {
+	if ps.sy.p2p.BlockChain().HaveBlock(h) || !ps.recentBlocks.request(h) {
+		return
+	}
+	bd, err := ps.sy.sendGetBlockDataRequest(ps.sy.p2p.Context(), pe.GetID(),
+		&pb.GetBlockDatas{Locator: changeHashsToPBHashs([]*hash.Hash{h})})
+	if err != nil || len(bd.Locator) == 0 {
+		log.Debug(fmt.Sprintf("Failed to fetch block %s from cluster peer=%v: %v", h, pe.GetID(), err))
+		ps.recentBlocks.forget(h)
+		return
+	}
+	block, err := types.NewBlockFromBytes(bd.Locator[0].BlockBytes)
+	if err != nil || !block.Hash().IsEqual(h) {
+		log.Debug(fmt.Sprintf("Invalid block %s from cluster peer=%v", h, pe.GetID()))
+		ps.recentBlocks.forget(h)
+		return
+	}
+	if !ps.recentBlocks.receive(h) {
+		return
+	}
+	ps.blockSources.add(h, pe.GetID())
+	_, err = ps.sy.p2p.BlockChain().ProcessBlock(block, blockchain.BFP2PAdd)
+	if err != nil {
+		log.Error("Failed to process cluster block", "hash", h, "error", err)
+		ps.recordBlockError(pe, err)
+	}
+}
+
+// SendClusterMessage sends the message to all connected cluster peers and
+// returns the number of peers it was sent to.
+func (ps *PeerSync) SendClusterMessage(msgType uint32, payload []byte) (int, error) {
+	if len(ps.sy.ClusterPeers) == 0 {
+		return 0, fmt.Errorf("no cluster peers are configured")
+	}
+	if len(payload) > MaxClusterPayload {
+		return 0, fmt.Errorf("cluster message of %d bytes exceeds the limit of %d bytes",
+			len(payload), MaxClusterPayload)
+	}
+	msg := &pb.ClusterMessage{Type: msgType, Payload: payload}
+	sent := 0
+	ps.sy.Peers().ForPeers(peers.PeerConnected, func(pe *peers.Peer) {
+		if !ps.sy.IsClusterPeer(pe.GetID()) {
+			return
+		}
+		sent++
+		go ps.sy.sendClusterRequest(ps.sy.p2p.Context(), pe, msg)
+	})
+	return sent, nil
+}
+
+// PushClusterBlock pushes the header of a block found by the node to the
+// cluster peers, which download it right away.
+func (ps *PeerSync) PushClusterBlock(block *types.SerializedBlock) {
+	if len(ps.sy.ClusterPeers) == 0 {
+		return
+	}
+	var buf bytes.Buffer
+	if err := block.Block().Header.Serialize(&buf); err != nil {
+		log.Error(fmt.Sprintf("Failed to serialize header of block %s: %v", block.Hash(), err))
+		return
+	}
+	if _, err := ps.SendClusterMessage(ClusterFoundBlock, buf.Bytes()); err != nil {
+		log.Debug(fmt.Sprintf("Failed to push block %s to cluster peers: %v", block.Hash(), err))
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package synch
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/protocol"
+	"github.com/Qitmeer/qitmeer/p2p/common"
+	"github.com/Qitmeer/qitmeer/p2p/encoder"
+	pb "github.com/Qitmeer/qitmeer/p2p/proto/v1"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// codecV1 is the first version of every message, spoken since the initial
+// protocol version.
+var codecV1 = encoder.CodecVersion{
+	Version:         1,
+	ProtocolVersion: protocol.InitialProcotolVersion,
+}
+
+// The wire encoding versions of the messages.  A message whose encoding
+// changes gets a new version introduced by a new protocol version, along with
+// the conversions from and to the former version, so that the peers speaking
+// an older protocol version keep receiving the encoding they know.  The first
+// version of the extended graph state is the plain graph state.
+func init() {
+	encoder.MustRegisterCodec(&pb.ChainState{}, codecV1)
+	encoder.MustRegisterCodec(&pb.GraphStateExt{},
+		encoder.CodecVersion{
+			Version:         1,
+			ProtocolVersion: protocol.InitialProcotolVersion,
+			New:             func() interface{} { return &pb.GraphState{} },
+			Downgrade:       downgradeGraphStateExt,
+			Upgrade:         upgradeGraphState,
+		},
+		encoder.CodecVersion{
+			Version:         2,
+			ProtocolVersion: protocol.GraphStateExtVersion,
+		},
+	)
+	encoder.MustRegisterCodec(&pb.GetBlocks{}, codecV1)
+	encoder.MustRegisterCodec(&pb.DagBlocks{}, codecV1)
+	encoder.MustRegisterCodec(&pb.GetBlockDatas{}, codecV1)
+	encoder.MustRegisterCodec(&pb.BlockDatas{}, codecV1)
+	encoder.MustRegisterCodec(&pb.SyncDAG{}, codecV1)
+	encoder.MustRegisterCodec(&pb.SubDAG{}, codecV1)
+	encoder.MustRegisterCodec(&pb.Hash{}, codecV1)
+	encoder.MustRegisterCodec(&pb.Transaction{}, codecV1)
+	encoder.MustRegisterCodec(&pb.Inventory{}, codecV1)
+	encoder.MustRegisterCodec(&pb.SyncQNR{}, codecV1)
+	encoder.MustRegisterCodec(&pb.MerkleBlockRequest{}, codecV1)
+	encoder.MustRegisterCodec(&pb.MerkleBlockResponse{}, codecV1)
+	encoder.MustRegisterCodec(&pb.FilterAddRequest{}, codecV1)
+	encoder.MustRegisterCodec(&pb.FilterClearRequest{}, codecV1)
+	encoder.MustRegisterCodec(&pb.FilterLoadRequest{}, codecV1)
+	encoder.MustRegisterCodec(&pb.MemPoolRequest{}, codecV1)
+	encoder.MustRegisterCodec(&pb.DoubleSpendProof{}, codecV1)
+	encoder.MustRegisterCodec(&pb.TxPackage{}, codecV1)
+	encoder.MustRegisterCodec(&pb.ClusterMessage{}, codecV1)
+	encoder.MustRegisterCodec(&pb.MetaData{}, codecV1)
+}
+
+// peerProtocolVersioner is implemented by the p2p services which know the
+// protocol version spoken with their peers.
+type peerProtocolVersioner interface {
+	PeerProtocolVersion(pid peer.ID) uint32
+}
+
+// wireVersion returns the protocol version spoken with the peer, which is the
+// initial protocol version when the service does not know it.
+func wireVersion(rpc common.P2PRPC, pid peer.ID) uint32 {
+	v, ok := rpc.(peerProtocolVersioner)
+	if !ok {
+		return protocol.InitialProcotolVersion
+	}
+	return v.PeerProtocolVersion(pid)
+}
+
+// downgradeGraphStateExt converts an extended graph state to the graph state
+// sent to the peers which do not know the extension.
+func downgradeGraphStateExt(msg interface{}) (interface{}, error) {
+	m, ok := msg.(*pb.GraphStateExt)
+	if !ok {
+		return nil, fmt.Errorf("message is not type *pb.GraphStateExt")
+	}
+	if m.GraphState == nil {
+		return &pb.GraphState{}, nil
+	}
+	return m.GraphState, nil
+}
+
+// upgradeGraphState converts the graph state of a peer which does not know the
+// extension to an extended graph state without feature.
+func upgradeGraphState(msg interface{}) (interface{}, error) {
+	m, ok := msg.(*pb.GraphState)
+	if !ok {
+		return nil, fmt.Errorf("message is not type *pb.GraphState")
+	}
+	return &pb.GraphStateExt{GraphState: m}, nil
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package synch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/p2p/common"
+	"github.com/Qitmeer/qitmeer/p2p/peers"
+	pb "github.com/Qitmeer/qitmeer/p2p/proto/v1"
+	libp2pcore "github.com/libp2p/go-libp2p-core"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+func (s *Sync) sendDoubleSpendProofRequest(ctx context.Context, pe *peers.Peer, msg *pb.DoubleSpendProof) error {
+	ctx, cancel := context.WithTimeout(ctx, ReqTimeout)
+	defer cancel()
+
+	stream, err := s.Send(ctx, msg, RPCDoubleSpendProof, pe.GetID())
+	if err != nil {
+		log.Trace(fmt.Sprintf("Failed to send double spend proof to peer=%v, err=%v", pe.GetID(), err.Error()))
+		return err
+	}
+	defer func() {
+		if err := stream.Reset(); err != nil {
+			log.Error(fmt.Sprintf("Failed to reset stream with protocol %s,%v", stream.Protocol(), err))
+		}
+	}()
+
+	code, errMsg, err := ReadRspCode(stream, s.Encoding())
+	if err != nil {
+		return err
+	}
+
+	if !code.IsSuccess() {
+		s.Peers().IncrementBadResponses(stream.Conn().RemotePeer(), "double spend proof rsp")
+		return errors.New(errMsg)
+	}
+	return err
+}
+
+func (s *Sync) doubleSpendProofHandler(ctx context.Context, msg interface{}, stream libp2pcore.Stream) *common.Error {
+	pe := s.peers.Get(stream.Conn().RemotePeer())
+	if pe == nil {
+		return ErrPeerUnknown
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, HandleTimeout)
+	var err error
+	defer func() {
+		cancel()
+	}()
+
+	m, ok := msg.(*pb.DoubleSpendProof)
+	if !ok {
+		err = fmt.Errorf("message is not type *pb.DoubleSpendProof")
+		return ErrMessage(err)
+	}
+	proof, err := types.NewDoubleSpendProofFromBytes(m.ProofBytes)
+	if err != nil {
+		return ErrMessage(err)
+	}
+	isNew, err := s.p2p.TxMemPool().ProcessDoubleSpendProof(proof)
+	if err != nil {
+		return ErrMessage(fmt.Errorf("invalid double spend proof of %v: %v", proof.OutPoint, err))
+	}
+	if isNew {
+		s.p2p.Notify().AnnounceDoubleSpendProof(proof, []peer.ID{pe.GetID()})
+	}
+	e := s.EncodeResponseMsg(stream, nil)
+	if e != nil {
+		return e
+	}
+	return nil
+}
+
+// RelayDoubleSpendProof sends the double spend proof to all connected peers
+// except the filtered ones and those which disabled transaction relaying.
+// Nothing is relayed in safe mode.
+func (ps *PeerSync) RelayDoubleSpendProof(proof *types.DoubleSpendProof, filters []peer.ID) {
+	if ps.IsSafeMode() {
+		return
+	}
+	proofBytes, err := proof.Serialize()
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to serialize double spend proof of %v: %v", proof.OutPoint, err))
+		return
+	}
+	msg := &pb.DoubleSpendProof{ProofBytes: proofBytes}
+
+	filtersM := map[peer.ID]struct{}{}
+	for _, f := range filters {
+		filtersM[f] = struct{}{}
+	}
+	ps.sy.Peers().ForPeers(peers.PeerConnected, func(pe *peers.Peer) {
+		if _, ok := filtersM[pe.GetID()]; ok {
+			return
+		}
+		if pe.DisableRelayTx() {
+			return
+		}
+		log.Trace(fmt.Sprintf("Relay double spend proof of %v to peer(%s)", proof.OutPoint, pe.GetID().String()))
+		go ps.sy.sendDoubleSpendProofRequest(ps.sy.p2p.Context(), pe, msg)
+	})
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/Qitmeer/qitmeer/common/hash"
 	"github.com/Qitmeer/qitmeer/core/blockchain"
 	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
 	"github.com/Qitmeer/qitmeer/p2p/common"
 	"github.com/Qitmeer/qitmeer/p2p/peers"
 	pb "github.com/Qitmeer/qitmeer/p2p/proto/v1"
@@ -48,7 +49,7 @@ func (s *Sync) sendGetBlockDataRequest(ctx context.Context, id peer.ID, locator
 	}
 
 	msg := &pb.BlockDatas{}
-	if err := s.Encoding().DecodeWithMaxLength(stream, msg); err != nil {
+	if err := s.DecodeResponseMsg(stream, msg); err != nil {
 		return nil, err
 	}
 	return msg, err
@@ -80,7 +81,7 @@ func (s *Sync) sendGetMerkleBlockDataRequest(ctx context.Context, id peer.ID, re
 	}
 
 	msg := &pb.MerkleBlockResponse{}
-	if err := s.Encoding().DecodeWithMaxLength(stream, msg); err != nil {
+	if err := s.DecodeResponseMsg(stream, msg); err != nil {
 		return nil, err
 	}
 	return msg, err
@@ -108,6 +109,11 @@ func (s *Sync) getBlockDataHandler(ctx context.Context, msg interface{}, stream
 		}
 		block, err := s.p2p.BlockChain().FetchBlockByHash(blockHash)
 		if err != nil {
+			// The bodies of the pruned blocks are gone, the peer gets
+			// them from a full node.
+			if database.IsError(err, database.ErrBlockPruned) {
+				continue
+			}
 			return ErrMessage(err)
 		}
 
@@ -144,6 +150,12 @@ func (s *Sync) getMerkleBlockDataHandler(ctx context.Context, msg interface{}, s
 	if pe == nil {
 		return ErrPeerUnknown
 	}
+	// Only serve merkle blocks if the server has bloom filtering enabled
+	// and the peer is within the bloom rate limit.
+	if !s.peerSync.EnforceNodeBloomFlag(pe) ||
+		!s.peerSync.allowBloomRequests(pe, len(m.Hashes)) {
+		return nil
+	}
 	filter := pe.Filter()
 	// Do not send a response if the peer doesn't have a filter loaded.
 	if !filter.IsLoaded() {
@@ -160,6 +172,9 @@ func (s *Sync) getMerkleBlockDataHandler(ctx context.Context, msg interface{}, s
 		}
 		block, err := s.p2p.BlockChain().FetchBlockByHash(blockHash)
 		if err != nil {
+			if database.IsError(err, database.ErrBlockPruned) {
+				continue
+			}
 			return ErrMessage(err)
 		}
 		// Generate a merkle block by filtering the requested block according
@@ -187,17 +202,36 @@ func (ps *PeerSync) processGetBlockDatas(pe *peers.Peer, blocks []*hash.Hash) er
 		log.Trace(err.Error())
 		return err
 	}
+	// Hold back the download while the block processing is congested, the
+	// peer update retrying it later.
+	if ps.sy.p2p.BlockChain().AdmissionCongested(blockchain.PrioritySync) {
+		log.Debug("Delaying block download, block processing is congested")
+		go ps.PeerUpdate(pe, false, false)
+		return nil
+	}
 	blocksReady := []*hash.Hash{}
 
 	for _, b := range blocks {
 		if ps.sy.p2p.BlockChain().HaveBlock(b) {
 			continue
 		}
+		if !ps.recentBlocks.request(b) {
+			continue
+		}
 		blocksReady = append(blocksReady, b)
 	}
 	if len(blocksReady) <= 0 {
 		return nil
 	}
+	// The blocks requested but not processed can be requested again.
+	processed := make(map[hash.Hash]struct{})
+	defer func() {
+		for _, b := range blocksReady {
+			if _, ok := processed[*b]; !ok {
+				ps.recentBlocks.forget(b)
+			}
+		}
+	}()
 	if !ps.longSyncMod {
 		bs := ps.sy.p2p.BlockChain().BestSnapshot()
 		if pe.GraphState().GetTotal() >= bs.GraphState.GetTotal()+MaxBlockLocatorsPerMsg {
@@ -223,12 +257,24 @@ func (ps *PeerSync) processGetBlockDatas(pe *peers.Peer, blocks []*hash.Hash) er
 			log.Warn(fmt.Sprintf("getBlocks from:%v", err))
 			break
 		}
+		processed[*block.Hash()] = struct{}{}
+		if !ps.recentBlocks.receive(block.Hash()) {
+			log.Debug(fmt.Sprintf("Skipping duplicate block %s from %s",
+				block.Hash(), pe.GetID()))
+			continue
+		}
+		ps.blockSources.add(block.Hash(), pe.GetID())
 		isOrphan, err := ps.sy.p2p.BlockChain().ProcessBlock(block, behaviorFlags)
 		if err != nil {
 			log.Error("Failed to process block", "hash", block.Hash(), "error", err)
+			ps.recordBlockError(pe, err)
+			if retryBlock(err) {
+				ps.recentBlocks.discard(block.Hash())
+			}
 			break
 		}
 		if isOrphan {
+			ps.sy.Peers().RecordOrphanBlock(pe.GetID())
 			hasOrphan = true
 			break
 		}
@@ -357,3 +403,18 @@ func (ps *PeerSync) OnGetData(sp *peers.Peer, invList []*pb.InvVect) error {
 	}
 	return nil
 }
+
+// recordBlockError counts the block rejected by the chain in the block
+// statistics of the peer which sent it.  The errors which are not rule
+// violations are not the fault of the peer.
+func (ps *PeerSync) recordBlockError(pe *peers.Peer, err error) {
+	rErr, ok := err.(blockchain.RuleError)
+	if !ok {
+		return
+	}
+	if rErr.ErrorCode == blockchain.ErrDuplicateBlock {
+		ps.sy.Peers().RecordDuplicateBlock(pe.GetID())
+		return
+	}
+	ps.sy.Peers().RecordInvalidBlock(pe.GetID(), rErr.Error())
+}
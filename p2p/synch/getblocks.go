@@ -43,7 +43,7 @@ func (s *Sync) sendGetBlocksRequest(ctx context.Context, id peer.ID, blocks *pb.
 	}
 
 	msg := &pb.DagBlocks{}
-	if err := s.Encoding().DecodeWithMaxLength(stream, msg); err != nil {
+	if err := s.DecodeResponseMsg(stream, msg); err != nil {
 		return nil, err
 	}
 
@@ -106,6 +106,29 @@ func (ps *PeerSync) GetBlocks(pe *peers.Peer, blocks []*hash.Hash) {
 	ps.msgChan <- &GetBlocksMsg{pe: pe, blocks: blocks}
 }
 
+// RequestOrphanParents requests the missing parents of an orphan block from
+// the peer which sent the orphan, or from the sync peer when that peer is
+// unknown or no longer connected.
+func (ps *PeerSync) RequestOrphanParents(orphan *hash.Hash, parents []*hash.Hash) {
+	if len(parents) == 0 {
+		return
+	}
+	var pe *peers.Peer
+	if id, ok := ps.blockSources.get(orphan); ok {
+		pe = ps.sy.peers.Get(id)
+	}
+	if pe == nil || !pe.IsConnected() {
+		pe = ps.SyncPeer()
+	}
+	if pe == nil {
+		log.Trace(fmt.Sprintf("No peer to request the parents of orphan %s", orphan))
+		return
+	}
+	log.Trace(fmt.Sprintf("Requesting parents %v of orphan %s from %s", parents,
+		orphan, pe.GetID()))
+	go ps.GetBlocks(pe, parents)
+}
+
 func (s *Sync) GetDataHandler(ctx context.Context, msg interface{}, stream libp2pcore.Stream) *common.Error {
 	ctx, cancel := context.WithTimeout(ctx, HandleTimeout)
 	var err error
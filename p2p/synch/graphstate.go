@@ -15,7 +15,7 @@ import (
 	"sync/atomic"
 )
 
-func (s *Sync) sendGraphStateRequest(ctx context.Context, pe *peers.Peer, gs *pb.GraphState) (*pb.GraphState, error) {
+func (s *Sync) sendGraphStateRequest(ctx context.Context, pe *peers.Peer, gs *pb.GraphStateExt) (*pb.GraphStateExt, error) {
 	ctx, cancel := context.WithTimeout(ctx, ReqTimeout)
 	defer cancel()
 
@@ -39,8 +39,8 @@ func (s *Sync) sendGraphStateRequest(ctx context.Context, pe *peers.Peer, gs *pb
 		return nil, errors.New(errMsg)
 	}
 
-	msg := &pb.GraphState{}
-	if err := s.Encoding().DecodeWithMaxLength(stream, msg); err != nil {
+	msg := &pb.GraphStateExt{}
+	if err := s.DecodeResponseMsg(stream, msg); err != nil {
 		return nil, err
 	}
 
@@ -59,15 +59,19 @@ func (s *Sync) graphStateHandler(ctx context.Context, msg interface{}, stream li
 		cancel()
 	}()
 
-	m, ok := msg.(*pb.GraphState)
+	m, ok := msg.(*pb.GraphStateExt)
 	if !ok {
-		err = fmt.Errorf("message is not type *pb.GraphState")
+		err = fmt.Errorf("message is not type *pb.GraphStateExt")
 		return ErrMessage(err)
 	}
-	pe.UpdateGraphState(m)
+	if m.GraphState == nil {
+		err = fmt.Errorf("graph state is missing")
+		return ErrMessage(err)
+	}
+	pe.UpdateGraphStateExt(m)
 	go s.peerSync.PeerUpdate(pe, false, false)
 
-	e := s.EncodeResponseMsg(stream, s.getGraphState())
+	e := s.EncodeResponseMsg(stream, s.getGraphStateExt())
 	if e != nil {
 		return e
 	}
@@ -80,16 +84,40 @@ func (ps *PeerSync) processUpdateGraphState(pe *peers.Peer) error {
 		log.Trace(err.Error())
 		return err
 	}
-	gs, err := ps.sy.sendGraphStateRequest(ps.sy.p2p.Context(), pe, ps.sy.getGraphState())
+	gs, err := ps.sy.sendGraphStateRequest(ps.sy.p2p.Context(), pe, ps.sy.getGraphStateExt())
 	if err != nil {
 		log.Warn(err.Error())
 		return err
 	}
-	pe.UpdateGraphState(gs)
+	if gs.GraphState == nil {
+		err = fmt.Errorf("graph state is missing")
+		log.Warn(err.Error())
+		return err
+	}
+	pe.UpdateGraphStateExt(gs)
 	go ps.PeerUpdate(pe, false, false)
 	return nil
 }
 
+// getGraphStateExt returns the graph state of the node along with its mempool
+// summary, which is left out when the node does not relay transactions so
+// that its peers stop relaying transactions to it.
+func (s *Sync) getGraphStateExt() *pb.GraphStateExt {
+	gs := &pb.GraphStateExt{GraphState: s.getGraphState()}
+	if s.p2p.DisableRelayTx() {
+		gs.Features |= peers.GraphStateBlocksOnlyFeature
+		return gs
+	}
+	mp := s.p2p.TxMemPool()
+	if mp == nil {
+		return gs
+	}
+	gs.Features |= peers.GraphStateMempoolFeature
+	gs.MempoolTxs = uint32(mp.Count())
+	gs.FeeFloor = uint64(mp.MinRelayTxFee())
+	return gs
+}
+
 func (ps *PeerSync) UpdateGraphState(pe *peers.Peer) {
 	// Ignore if we are shutting down.
 	if atomic.LoadInt32(&ps.shutdown) != 0 {
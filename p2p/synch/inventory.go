@@ -78,7 +78,7 @@ func (s *Sync) handleInventory(msg *pb.Inventory, pe *peers.Peer) error {
 		if InvType(inv.Type) == InvTypeBlock {
 			hasBlocks = true
 		} else if InvType(inv.Type) == InvTypeTx {
-			if s.p2p.Config().DisableRelayTx {
+			if s.p2p.DisableRelayTx() {
 				continue
 			}
 			if s.haveInventory(inv) {
@@ -45,7 +45,7 @@ func (s *Sync) sendMetaDataRequest(ctx context.Context, id peer.ID) (*pb.MetaDat
 		return nil, fmt.Errorf(errMsg)
 	}
 	msg := new(pb.MetaData)
-	if err := s.Encoding().DecodeWithMaxLength(stream, msg); err != nil {
+	if err := s.DecodeResponseMsg(stream, msg); err != nil {
 		return nil, err
 	}
 	return msg, nil
@@ -24,6 +24,11 @@ func (s *Sync) HandlerFilterMsgAdd(ctx context.Context, msg interface{}, stream
 		err = fmt.Errorf("message is not type *MsgFilterAdd")
 		return ErrMessage(err)
 	}
+	if len(m.Data) > types.MaxFilterAddDataSize {
+		err = fmt.Errorf("filteradd data size too large [size %d, max %d]",
+			len(m.Data), types.MaxFilterAddDataSize)
+		return ErrMessage(err)
+	}
 	s.peerSync.msgChan <- &OnFilterAddMsg{pe: pe, data: &types.MsgFilterAdd{
 		Data: m.Data,
 	}}
@@ -64,6 +69,16 @@ func (s *Sync) HandlerFilterMsgLoad(ctx context.Context, msg interface{}, stream
 		err = fmt.Errorf("message is not type *MsgFilterLoad")
 		return ErrMessage(err)
 	}
+	if len(m.Filter) > types.MaxFilterLoadFilterSize {
+		err = fmt.Errorf("filterload filter size too large [size %d, max %d]",
+			len(m.Filter), types.MaxFilterLoadFilterSize)
+		return ErrMessage(err)
+	}
+	if m.HashFuncs < 0 || m.HashFuncs > types.MaxFilterLoadHashFuncs {
+		err = fmt.Errorf("invalid filterload hash function count %d, max %d",
+			m.HashFuncs, types.MaxFilterLoadHashFuncs)
+		return ErrMessage(err)
+	}
 	s.peerSync.msgChan <- &OnFilterLoadMsg{pe: pe, data: &types.MsgFilterLoad{
 		Filter:    m.Filter,
 		HashFuncs: uint32(m.HashFuncs),
@@ -0,0 +1,70 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package synch
+
+import (
+	"context"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/p2p/common"
+	"github.com/Qitmeer/qitmeer/p2p/peers"
+	"github.com/Qitmeer/qitmeer/p2p/runutil"
+	libp2pcore "github.com/libp2p/go-libp2p-core"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// msgStatsSaveInterval is the interval at which the message statistics of the
+// peers are written to the data directory, besides when the node stops.
+const msgStatsSaveInterval = time.Hour
+
+// msgType returns the type of the messages of the topic, which is the name of
+// the rpc method, such as graphstate for RPCGraphState.
+func msgType(baseTopic string) string {
+	parts := strings.Split(strings.Trim(baseTopic, "/"), "/")
+	if len(parts) < 2 {
+		return baseTopic
+	}
+	return parts[len(parts)-2]
+}
+
+// countMsgs wraps the handler of the topic so that the requests received and
+// the requests whose handling failed are counted in the message statistics of
+// the peers.
+func (s *Sync) countMsgs(baseTopic string, handle rpcHandler) rpcHandler {
+	mt := msgType(baseTopic)
+	return func(ctx context.Context, msg interface{}, stream libp2pcore.Stream) *common.Error {
+		pid := stream.Conn().RemotePeer()
+		s.peers.RecordMsgReceived(pid, mt)
+		e := handle(ctx, msg, stream)
+		if e != nil {
+			s.peers.RecordMsgError(pid, mt)
+		}
+		return e
+	}
+}
+
+// msgStatsPath returns the path of the file holding the message statistics of
+// the peers.
+func (s *Sync) msgStatsPath() string {
+	return filepath.Join(s.p2p.Config().DataDir, peers.MsgStatsFileName)
+}
+
+func (s *Sync) saveMsgStats() {
+	err := s.peers.SaveMsgStats(s.msgStatsPath(), s.p2p.Config().ReadWritePermissions)
+	if err != nil {
+		log.Warn(fmt.Sprintf("Failed to save peer message stats:%v", err))
+	}
+}
+
+// maintainMsgStats loads the message statistics of the peers and saves them
+// periodically.
+func (s *Sync) maintainMsgStats() {
+	err := s.peers.LoadMsgStats(s.msgStatsPath())
+	if err != nil {
+		log.Warn(fmt.Sprintf("Failed to load peer message stats:%v", err))
+	}
+	runutil.RunEvery(s.p2p.Context(), msgStatsSaveInterval, s.saveMsgStats)
+}
@@ -6,6 +6,7 @@ package synch
 
 import (
 	"fmt"
+	"github.com/Qitmeer/qitmeer/common/crash"
 	"github.com/Qitmeer/qitmeer/core/blockchain"
 	"github.com/Qitmeer/qitmeer/core/blockdag"
 	"github.com/Qitmeer/qitmeer/core/protocol"
@@ -38,6 +39,17 @@ type PeerSync struct {
 	wg          sync.WaitGroup
 	quit        chan struct{}
 	longSyncMod bool
+
+	// blockSources remembers the peers which sent the blocks not relayed
+	// yet, for the relay policy.
+	blockSources blockSources
+
+	// recentBlocks is the deduplication window of the block downloads.
+	recentBlocks recentBlocks
+
+	// safeMode is 1 while the node is in safe mode, in which the inventory
+	// is not relayed.  It must only be used atomically.
+	safeMode int32
 }
 
 func (ps *PeerSync) Start() error {
@@ -51,7 +63,10 @@ func (ps *PeerSync) Start() error {
 	ps.longSyncMod = false
 
 	ps.wg.Add(1)
-	go ps.handler()
+	go func() {
+		defer ps.wg.Done()
+		crash.Loop("p2p/peersync", ps.quit, ps.handler)
+	}()
 	return nil
 }
 
@@ -71,6 +86,8 @@ func (ps *PeerSync) Stop() error {
 func (ps *PeerSync) handler() {
 	stallTicker := time.NewTicker(stallSampleInterval)
 	defer stallTicker.Stop()
+	reelectTicker := time.NewTicker(syncPeerReelectInterval)
+	defer reelectTicker.Stop()
 
 out:
 	for {
@@ -138,6 +155,9 @@ out:
 		case <-stallTicker.C:
 			ps.handleStallSample()
 
+		case <-reelectTicker.C:
+			ps.reelectSyncPeer()
+
 		case <-ps.quit:
 			break out
 		}
@@ -154,7 +174,6 @@ cleanup:
 		}
 	}
 
-	ps.wg.Done()
 	log.Trace("Peer Sync handler done")
 }
 
@@ -319,47 +338,25 @@ func (ps *PeerSync) startSync() {
 	}
 }
 
-// getBestPeer
+// getBestPeer returns the fastest of the sync candidates, preferring the more
+// advanced graph state and then the larger peer id among the candidates of the
+// same speed.
 func (ps *PeerSync) getBestPeer() *peers.Peer {
-	best := ps.Chain().BestSnapshot()
 	var bestPeer *peers.Peer
-	equalPeers := []*peers.Peer{}
-	for _, sp := range ps.sy.peers.ConnectedPeers() {
-		// Remove sync candidate peers that are no longer candidates due
-		// to passing their latest known block.  NOTE: The < is
-		// intentional as opposed to <=.  While techcnically the peer
-		// doesn't have a later block when it's equal, it will likely
-		// have one soon so it is a reasonable choice.  It also allows
-		// the case where both are at 0 such as during regression test.
-		gs := sp.GraphState()
-		if gs == nil {
+	var bestScore float64
+	for _, sp := range ps.syncCandidates() {
+		score := ps.syncPeerScore(sp)
+		if bestPeer == nil || score > bestScore {
+			bestPeer, bestScore = sp, score
 			continue
 		}
-		if !gs.IsExcellent(best.GraphState) {
+		if score < bestScore {
 			continue
 		}
-		// the best sync candidate is the most updated peer
-		if bestPeer == nil {
-			bestPeer = sp
-			continue
-		}
-		if gs.IsExcellent(bestPeer.GraphState()) {
-			bestPeer = sp
-			if len(equalPeers) > 0 {
-				equalPeers = equalPeers[0:0]
-			}
-		} else if gs.IsEqual(bestPeer.GraphState()) {
-			equalPeers = append(equalPeers, sp)
-		}
-	}
-	if bestPeer == nil {
-		return nil
-	}
-	if len(equalPeers) > 0 {
-		for _, sp := range equalPeers {
-			if sp.GetID().String() > bestPeer.GetID().String() {
-				bestPeer = sp
-			}
+		gs, bestGS := sp.GraphState(), bestPeer.GraphState()
+		if gs.IsExcellent(bestGS) || (gs.IsEqual(bestGS) &&
+			sp.GetID().String() > bestPeer.GetID().String()) {
+			bestPeer, bestScore = sp, score
 		}
 	}
 	return bestPeer
@@ -429,7 +426,47 @@ func (ps *PeerSync) updateSyncPeer(force bool) {
 	ps.startSync()
 }
 
+// SetSafeMode stops or resumes the relay of the inventory.
+func (ps *PeerSync) SetSafeMode(safeMode bool) {
+	if safeMode {
+		atomic.StoreInt32(&ps.safeMode, 1)
+	} else {
+		atomic.StoreInt32(&ps.safeMode, 0)
+	}
+}
+
+// IsSafeMode returns whether the node is in safe mode.
+func (ps *PeerSync) IsSafeMode() bool {
+	return atomic.LoadInt32(&ps.safeMode) != 0
+}
+
+// RelayInventory relays the inventory to the connected peers, except the
+// filtered ones.  The relay of a suspicious block from an unknown peer is
+// delayed by the relay policy.  Nothing is relayed in safe mode.
 func (ps *PeerSync) RelayInventory(data interface{}, filters []peer.ID) {
+	if ps.IsSafeMode() {
+		log.Trace("In safe mode, do not relay the inventory")
+		return
+	}
+	if header, ok := data.(types.BlockHeader); ok {
+		delay := ps.relayBlockDelay(&header)
+		if delay > 0 {
+			time.AfterFunc(delay, func() {
+				if atomic.LoadInt32(&ps.shutdown) != 0 {
+					return
+				}
+				ps.relayInventory(data, filters)
+			})
+			return
+		}
+	}
+	ps.relayInventory(data, filters)
+}
+
+func (ps *PeerSync) relayInventory(data interface{}, filters []peer.ID) {
+	if _, ok := data.(types.BlockHeader); ok {
+		relayedBlocksCounter.Inc(1)
+	}
 	filtersM := map[peer.ID]struct{}{}
 	if len(filters) > 0 {
 		for _, f := range filters {
@@ -483,12 +520,12 @@ func (ps *PeerSync) RelayInventory(data interface{}, filters []peer.ID) {
 // version  that is high enough to observe the bloom filter service support bit,
 // it will be banned since it is intentionally violating the protocol.
 func (ps *PeerSync) EnforceNodeBloomFlag(sp *peers.Peer) bool {
-	services := sp.Services()
+	services := ps.sy.p2p.Config().Services
 	if services&protocol.Bloom != protocol.Bloom {
 		// Disconnect the peer regardless of protocol version or banning
 		// state.
-		log.Debug(fmt.Sprintf("%s sent a filterclear request with no "+
-			"filter loaded -- disconnecting", sp.Node().String()))
+		log.Debug(fmt.Sprintf("%s sent a bloom filter request while "+
+			"bloom filters are disabled -- disconnecting", sp.GetID()))
 		ps.Disconnect(sp)
 		return false
 	}
@@ -496,6 +533,19 @@ func (ps *PeerSync) EnforceNodeBloomFlag(sp *peers.Peer) bool {
 	return true
 }
 
+// allowBloomRequests counts n bloom filter requests of the peer and returns
+// false when the peer exceeds the bloom rate limit.  The requests over the
+// limit are not served and counted as bad responses of the peer.
+func (ps *PeerSync) allowBloomRequests(sp *peers.Peer, n int) bool {
+	if sp.AllowBloomRequests(n, ps.sy.p2p.Config().BloomRateLimit) {
+		return true
+	}
+	log.Debug(fmt.Sprintf("%s exceeded the bloom rate limit -- ignoring "+
+		"%d requests", sp.GetID(), n))
+	ps.sy.peers.IncrementBadResponses(sp.GetID(), "bloom rate limit")
+	return false
+}
+
 // OnFilterAdd is invoked when a peer receives a filteradd qitmeer
 // message and is used by remote peers to add data to an already loaded bloom
 // filter.  The peer will be disconnected if a filter is not loaded when this
@@ -503,7 +553,7 @@ func (ps *PeerSync) EnforceNodeBloomFlag(sp *peers.Peer) bool {
 func (ps *PeerSync) OnFilterAdd(sp *peers.Peer, msg *types.MsgFilterAdd) {
 	// Disconnect and/or ban depending on the node bloom services flag and
 	// negotiated protocol version.
-	if !ps.EnforceNodeBloomFlag(sp) {
+	if !ps.EnforceNodeBloomFlag(sp) || !ps.allowBloomRequests(sp, 1) {
 		return
 	}
 	filter := sp.Filter()
@@ -524,7 +574,7 @@ func (ps *PeerSync) OnFilterAdd(sp *peers.Peer, msg *types.MsgFilterAdd) {
 func (ps *PeerSync) OnFilterClear(sp *peers.Peer, msg *types.MsgFilterClear) {
 	// Disconnect and/or ban depending on the node bloom services flag and
 	// negotiated protocol version.
-	if !ps.EnforceNodeBloomFlag(sp) {
+	if !ps.EnforceNodeBloomFlag(sp) || !ps.allowBloomRequests(sp, 1) {
 		return
 	}
 	filter := sp.Filter()
@@ -547,7 +597,7 @@ func (ps *PeerSync) OnFilterClear(sp *peers.Peer, msg *types.MsgFilterClear) {
 func (ps *PeerSync) OnFilterLoad(sp *peers.Peer, msg *types.MsgFilterLoad) {
 	// Disconnect and/or ban depending on the node bloom services flag and
 	// negotiated protocol version.
-	if !ps.EnforceNodeBloomFlag(sp) {
+	if !ps.EnforceNodeBloomFlag(sp) || !ps.allowBloomRequests(sp, 1) {
 		return
 	}
 	filter := sp.Filter()
@@ -563,11 +613,12 @@ func (ps *PeerSync) OnFilterLoad(sp *peers.Peer, msg *types.MsgFilterLoad) {
 func (ps *PeerSync) OnMemPool(sp *peers.Peer, msg *MsgMemPool) {
 	// Only allow mempool requests if the server has bloom filtering
 	// enabled.
-	services := sp.Services()
-	if services&protocol.Bloom != protocol.Bloom {
-		log.Debug(fmt.Sprintf("%s sent a filterclear request with no "+
-			"filter loaded -- disconnecting", sp.Node().String()))
-		ps.Disconnect(sp)
+	if !ps.EnforceNodeBloomFlag(sp) || !ps.allowBloomRequests(sp, 1) {
+		return
+	}
+
+	// The mempool is not gossiped in the blocks-only mode.
+	if ps.sy.p2p.DisableRelayTx() {
 		return
 	}
 
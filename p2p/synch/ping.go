@@ -90,7 +90,7 @@ func (s *Sync) SendPingRequest(ctx context.Context, id peer.ID) error {
 		return errors.New(errMsg)
 	}
 	msg := new(uint64)
-	if err := s.Encoding().DecodeWithMaxLength(stream, msg); err != nil {
+	if err := s.DecodeResponseMsg(stream, msg); err != nil {
 		return err
 	}
 	valid, err := s.validateSequenceNum(*msg, pe)
@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package synch
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/core/types/pow"
+	"github.com/Qitmeer/qitmeer/metrics"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"math/big"
+	"sync"
+	"time"
+)
+
+const (
+	// relayParentsThreshold is the number of parents above which a block
+	// received from an unknown peer is considered to widen the DAG
+	// abnormally.
+	relayParentsThreshold = types.MaxParentsPerBlock / 2
+
+	// relayWorkDivisor defines the tiny proof of work margin: a block
+	// received from an unknown peer whose work is lower than the work
+	// required at the main chain tip divided by relayWorkDivisor is
+	// considered cheap.
+	relayWorkDivisor = 4
+
+	// relayDelay is the delay before a suspicious block is relayed.
+	relayDelay = 2 * time.Second
+
+	// maxBlockSources is the maximum number of block sources remembered.
+	maxBlockSources = 1000
+)
+
+var (
+	relayedBlocksCounter = metrics.NewCounter("p2p/relay/block/relayed")
+	delayedWideCounter   = metrics.NewCounter("p2p/relay/block/delayed/parents")
+	delayedWorkCounter   = metrics.NewCounter("p2p/relay/block/delayed/work")
+)
+
+// blockSources remembers the peer which sent each block until the block is
+// relayed, so that the relay policy can tell the blocks of unknown peers from
+// the blocks of trusted peers and of the node itself.
+type blockSources struct {
+	lock    sync.Mutex
+	sources map[hash.Hash]peer.ID
+	order   []hash.Hash
+}
+
+// add records the peer which sent the block.  The oldest source is forgotten
+// when too many sources are remembered.
+func (bs *blockSources) add(h *hash.Hash, id peer.ID) {
+	bs.lock.Lock()
+	defer bs.lock.Unlock()
+
+	if bs.sources == nil {
+		bs.sources = make(map[hash.Hash]peer.ID)
+	}
+	if _, ok := bs.sources[*h]; ok {
+		return
+	}
+	if len(bs.order) >= maxBlockSources {
+		delete(bs.sources, bs.order[0])
+		bs.order = bs.order[1:]
+	}
+	bs.sources[*h] = id
+	bs.order = append(bs.order, *h)
+}
+
+// remove returns and forgets the peer which sent the block.  It returns false
+// when the source of the block is unknown, which is the case for the blocks
+// of the node itself.
+func (bs *blockSources) remove(h *hash.Hash) (peer.ID, bool) {
+	bs.lock.Lock()
+	defer bs.lock.Unlock()
+
+	id, ok := bs.sources[*h]
+	if !ok {
+		return "", false
+	}
+	delete(bs.sources, *h)
+	for i := range bs.order {
+		if bs.order[i] == *h {
+			bs.order = append(bs.order[:i], bs.order[i+1:]...)
+			break
+		}
+	}
+	return id, true
+}
+
+// get returns the peer which sent the block without forgetting it.
+func (bs *blockSources) get(h *hash.Hash) (peer.ID, bool) {
+	bs.lock.Lock()
+	defer bs.lock.Unlock()
+
+	id, ok := bs.sources[*h]
+	return id, ok
+}
+
+// relayBlockDelay returns how long the relay of the block should be delayed.
+// Only the blocks received from unknown peers are delayed, when they have an
+// abnormally large parent set or a tiny proof of work margin.  The policy is
+// consensus neutral: the block is already accepted and is always relayed in
+// the end.
+func (ps *PeerSync) relayBlockDelay(header *types.BlockHeader) time.Duration {
+	blockHash := header.BlockHash()
+	id, ok := ps.blockSources.remove(&blockHash)
+	if !ok || ps.sy.IsWhitePeer(id) {
+		return 0
+	}
+	bd := ps.sy.p2p.BlockChain().BlockDAG()
+	ib := bd.GetBlock(&blockHash)
+	if ib != nil && ib.GetParents() != nil &&
+		ib.GetParents().Size() > relayParentsThreshold {
+		log.Debug(fmt.Sprintf("Delaying relay of block %s with %d parents "+
+			"from peer %s", blockHash, ib.GetParents().Size(), id))
+		delayedWideCounter.Inc(1)
+		return relayDelay
+	}
+
+	powType := header.Pow.GetPowType()
+	tip := bd.GetMainChainTip()
+	if tip == nil {
+		return 0
+	}
+	tipDiff := ps.sy.p2p.BlockChain().GetCurrentPowDiff(tip, powType)
+	required := pow.CalcWork(pow.BigToCompact(tipDiff), powType)
+	required.Div(required, big.NewInt(relayWorkDivisor))
+	if pow.CalcWork(header.Difficulty, powType).Cmp(required) < 0 {
+		log.Debug(fmt.Sprintf("Delaying relay of block %s with a tiny "+
+			"proof of work margin from peer %s", blockHash, id))
+		delayedWorkCounter.Inc(1)
+		return relayDelay
+	}
+	return 0
+}
@@ -7,6 +7,7 @@ package synch
 import (
 	"context"
 	"fmt"
+	"github.com/Qitmeer/qitmeer/common/crash"
 	"github.com/Qitmeer/qitmeer/p2p/common"
 	"github.com/Qitmeer/qitmeer/p2p/encoder"
 	"github.com/Qitmeer/qitmeer/p2p/peers"
@@ -16,6 +17,7 @@ import (
 	"github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
 	"github.com/libp2p/go-libp2p-core/protocol"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"time"
@@ -57,6 +59,13 @@ const (
 	RPCMemPool = "/qitmeer/req/mempool/1"
 	// RPCMemPool defines the topic for the getdata rpc method.
 	RPCGetData = "/qitmeer/req/getdata/1"
+	// RPCDoubleSpendProof defines the topic for the double spend proof rpc method.
+	RPCDoubleSpendProof = "/qitmeer/req/dsproof/1"
+	// RPCTxPackage defines the topic for the transaction package rpc method.
+	RPCTxPackage = "/qitmeer/req/txpackage/1"
+	// RPCCluster defines the topic for the private channel of the mining
+	// cluster peers.
+	RPCCluster = "/qitmeer/req/cluster/1"
 )
 
 // Time to first byte timeout. The maximum time to wait for first byte of
@@ -84,9 +93,15 @@ type Sync struct {
 	p2p          common.P2P
 	PeerInterval time.Duration
 	LANPeers     map[peer.ID]struct{}
+	ClusterPeers map[peer.ID]struct{}
 }
 
 func (s *Sync) Start() error {
+	err := s.peers.LoadBlockStats(s.blockStatsPath())
+	if err != nil {
+		log.Warn(fmt.Sprintf("Failed to load peer block stats:%v", err))
+	}
+	s.maintainMsgStats()
 	s.registerHandlers()
 
 	s.AddConnectionHandler()
@@ -98,9 +113,20 @@ func (s *Sync) Start() error {
 }
 
 func (s *Sync) Stop() error {
+	err := s.peers.SaveBlockStats(s.blockStatsPath(), s.p2p.Config().ReadWritePermissions)
+	if err != nil {
+		log.Warn(fmt.Sprintf("Failed to save peer block stats:%v", err))
+	}
+	s.saveMsgStats()
 	return s.peerSync.Stop()
 }
 
+// blockStatsPath returns the path of the file holding the block statistics of
+// the peers.
+func (s *Sync) blockStatsPath() string {
+	return filepath.Join(s.p2p.Config().DataDir, peers.BlockStatsFileName)
+}
+
 func (s *Sync) registerHandlers() {
 	s.registerRPCHandlers()
 	//s.registerSubscribers()
@@ -165,7 +191,7 @@ func (s *Sync) registerRPCHandlers() {
 
 	s.registerRPC(
 		RPCGraphState,
-		&pb.GraphState{},
+		&pb.GraphStateExt{},
 		s.graphStateHandler,
 	)
 
@@ -210,17 +236,42 @@ func (s *Sync) registerRPCHandlers() {
 		&pb.Inventory{},
 		s.GetDataHandler,
 	)
+
+	s.registerRPC(
+		RPCDoubleSpendProof,
+		&pb.DoubleSpendProof{},
+		s.doubleSpendProofHandler,
+	)
+
+	s.registerRPC(
+		RPCTxPackage,
+		&pb.TxPackage{},
+		s.txPackageHandler,
+	)
+
+	// The private cluster channel is only served to the approved peers.
+	if len(s.ClusterPeers) > 0 {
+		s.registerRPC(
+			RPCCluster,
+			&pb.ClusterMessage{},
+			s.clusterHandler,
+		)
+	}
 }
 
 // registerRPC for a given topic with an expected protobuf message type.
 func (s *Sync) registerRPC(topic string, base interface{}, handle rpcHandler) {
-	RegisterRPC(s.p2p, topic, base, handle)
+	RegisterRPC(s.p2p, topic, base, s.countMsgs(topic, handle))
 }
 
 // Send a message to a specific peer. The returned stream may be used for reading, but has been
 // closed for writing.
 func (s *Sync) Send(ctx context.Context, message interface{}, baseTopic string, pid peer.ID) (network.Stream, error) {
-	return Send(ctx, s.p2p, message, baseTopic, pid)
+	stream, err := Send(ctx, s.p2p, message, baseTopic, pid)
+	if err == nil {
+		s.peers.RecordMsgSent(pid, msgType(baseTopic))
+	}
+	return stream, err
 }
 
 func (s *Sync) PeerSync() *PeerSync {
@@ -246,6 +297,10 @@ func (s *Sync) EncodeResponseMsg(stream libp2pcore.Stream, msg interface{}) *com
 	return EncodeResponseMsg(s.p2p, stream, msg, common.ErrNone)
 }
 
+func (s *Sync) DecodeResponseMsg(stream libp2pcore.Stream, to interface{}) error {
+	return DecodeResponseMsg(s.p2p, stream, to)
+}
+
 func (s *Sync) EncodeResponseMsgPro(stream libp2pcore.Stream, msg interface{}, retCode common.ErrorCode) *common.Error {
 	return EncodeResponseMsg(s.p2p, stream, msg, retCode)
 }
@@ -253,7 +308,8 @@ func (s *Sync) EncodeResponseMsgPro(stream libp2pcore.Stream, msg interface{}, r
 func NewSync(p2p common.P2P) *Sync {
 	sy := &Sync{p2p: p2p, peers: peers.NewStatus(p2p),
 		PeerInterval: params.ActiveNetParams.TargetTimePerBlock * 2,
-		LANPeers:     map[peer.ID]struct{}{}}
+		LANPeers:     map[peer.ID]struct{}{},
+		ClusterPeers: map[peer.ID]struct{}{}}
 	sy.peerSync = NewPeerSync(sy)
 
 	for _, pid := range p2p.Config().LANPeers {
@@ -264,6 +320,14 @@ func NewSync(p2p common.P2P) *Sync {
 		}
 		sy.LANPeers[peid] = struct{}{}
 	}
+	for _, pid := range p2p.Config().ClusterPeers {
+		peid, err := peer.Decode(pid)
+		if err != nil {
+			log.Warn(fmt.Sprintf("ClusterPeers configuration error:%s", pid))
+			continue
+		}
+		sy.ClusterPeers[peid] = struct{}{}
+	}
 	return sy
 }
 
@@ -301,7 +365,8 @@ func RegisterRPC(rpc common.P2PRPC, basetopic string, base interface{}, handle r
 			}
 			msgT := reflect.New(ty)
 			msg = msgT.Interface()
-			if err := rpc.Encoding().DecodeWithMaxLength(stream, msg); err != nil {
+			pver := wireVersion(rpc, stream.Conn().RemotePeer())
+			if err := encoder.DecodeVersion(rpc.Encoding(), stream, msg, pver); err != nil {
 				e = common.NewError(common.ErrStreamRead, err)
 				// Debug logs for goodbye errors
 				if strings.Contains(topic, RPCGoodByeTopic) {
@@ -316,10 +381,25 @@ func RegisterRPC(rpc common.P2PRPC, basetopic string, base interface{}, handle r
 		}
 
 		SetRPCStreamDeadlines(stream)
-		e = handle(ctx, msg, stream)
+		e = handleRPC(ctx, topic, handle, msg, stream)
 	})
 }
 
+// handleRPC calls the handler of a request received on the topic.  A panic in
+// the handler is reported as a crash of the sync subsystem and fails the
+// request, instead of taking the node down.
+func handleRPC(ctx context.Context, topic string, handle rpcHandler, msg interface{},
+	stream libp2pcore.Stream) (e *common.Error) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			crash.Recovered("p2p/sync "+topic, r)
+			e = common.NewError(common.ErrMessage, fmt.Errorf("panic: %v", r))
+		}
+	}()
+	return handle(ctx, msg, stream)
+}
+
 func processError(e *common.Error, stream network.Stream, rpc common.P2PRPC) {
 	if e == nil {
 		return
@@ -376,7 +456,7 @@ func Send(ctx context.Context, rpc common.P2PRPC, message interface{}, baseTopic
 	if baseTopic == RPCMetaDataTopic {
 		return stream, nil
 	}
-	size, err := rpc.Encoding().EncodeWithMaxLength(stream, message)
+	size, err := encoder.EncodeVersion(rpc.Encoding(), stream, message, wireVersion(rpc, pid))
 	if err != nil {
 		log.Trace(fmt.Sprintf("encocde rpc message %v to stream failed", message))
 		return nil, err
@@ -397,15 +477,23 @@ func EncodeResponseMsg(rpc common.P2PRPC, stream libp2pcore.Stream, msg interfac
 		return common.NewError(common.ErrStreamWrite, err)
 	}
 	if msg != nil {
-		size, err := rpc.Encoding().EncodeWithMaxLength(stream, msg)
+		pid := stream.Conn().RemotePeer()
+		size, err := encoder.EncodeVersion(rpc.Encoding(), stream, msg, wireVersion(rpc, pid))
 		if err != nil {
 			return common.NewError(common.ErrStreamWrite, err)
 		}
-		rpc.IncreaseBytesSent(stream.Conn().RemotePeer(), size)
+		rpc.IncreaseBytesSent(pid, size)
 	}
 	return nil
 }
 
+// DecodeResponseMsg decodes the response of the peer of the stream with the
+// codec versions spoken with that peer.
+func DecodeResponseMsg(rpc common.P2PRPC, stream libp2pcore.Stream, to interface{}) error {
+	return encoder.DecodeVersion(rpc.Encoding(), stream, to,
+		wireVersion(rpc, stream.Conn().RemotePeer()))
+}
+
 func getTopic(baseTopic string) string {
 	if baseTopic == RPCChainState || baseTopic == RPCGoodByeTopic {
 		return baseTopic
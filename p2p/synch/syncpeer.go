@@ -0,0 +1,110 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package synch
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/protocol"
+	"github.com/Qitmeer/qitmeer/p2p/peers"
+	"time"
+)
+
+const (
+	// syncPeerOrderSlack is the number of blocks of the main order by which
+	// a sync candidate may be behind the most advanced candidate and still
+	// be preferred for its speed.
+	syncPeerOrderSlack = 16
+
+	// syncPeerReelectInterval is the interval at which the sync peer is
+	// compared with the other candidates while the node is not current.
+	syncPeerReelectInterval = 30 * time.Second
+
+	// syncPeerScoreMargin is the factor by which the score of a candidate
+	// must exceed the score of the sync peer to replace it, so that the
+	// sync peer does not flap between peers of about the same speed.
+	syncPeerScoreMargin = 1.5
+
+	// defaultSyncPeerLatency is the latency assumed for a peer which has
+	// not answered a ping yet.
+	defaultSyncPeerLatency = time.Second
+)
+
+// syncCandidates returns the connected full nodes whose graph state is ahead
+// of ours by at most syncPeerOrderSlack blocks less than the most advanced of
+// them.  Pruned nodes are not candidates, since they can not serve the old
+// blocks.
+func (ps *PeerSync) syncCandidates() []*peers.Peer {
+	best := ps.Chain().BestSnapshot()
+	var ahead []*peers.Peer
+	maxOrder := uint(0)
+	for _, sp := range ps.sy.peers.ConnectedPeers() {
+		// Remove sync candidate peers that are no longer candidates due
+		// to passing their latest known block.
+		gs := sp.GraphState()
+		if gs == nil || !gs.IsExcellent(best.GraphState) {
+			continue
+		}
+		if !protocol.HasServices(sp.Services(), protocol.Full) {
+			continue
+		}
+		ahead = append(ahead, sp)
+		if gs.GetMainOrder() > maxOrder {
+			maxOrder = gs.GetMainOrder()
+		}
+	}
+	candidates := ahead[:0]
+	for _, sp := range ahead {
+		if sp.GraphState().GetMainOrder()+syncPeerOrderSlack >= maxOrder {
+			candidates = append(candidates, sp)
+		}
+	}
+	return candidates
+}
+
+// syncPeerScore returns the speed of the peer as a sync peer, the higher the
+// better, which is the average number of bytes per second received from the
+// peer since it connected divided by its latency in seconds.
+func (ps *PeerSync) syncPeerScore(pe *peers.Peer) float64 {
+	throughput := float64(1)
+	if connected := time.Since(pe.ConnectionTime()).Seconds(); connected > 1 {
+		throughput = float64(pe.BytesRecv()) / connected
+		if throughput < 1 {
+			throughput = 1
+		}
+	}
+	latency := ps.sy.p2p.Host().Peerstore().LatencyEWMA(pe.GetID())
+	if latency <= 0 {
+		latency = defaultSyncPeerLatency
+	}
+	return throughput / latency.Seconds()
+}
+
+// reelectSyncPeer replaces the sync peer with the best candidate when the sync
+// peer is no longer a candidate or is much slower than the best candidate, so
+// that the initial download does not stick with a slow peer.
+func (ps *PeerSync) reelectSyncPeer() {
+	sp := ps.SyncPeer()
+	if sp == nil || ps.IsCurrent() {
+		return
+	}
+	bestPeer := ps.getBestPeer()
+	if bestPeer == nil || bestPeer.GetID() == sp.GetID() {
+		return
+	}
+	candidate := false
+	for _, pe := range ps.syncCandidates() {
+		if pe.GetID() == sp.GetID() {
+			candidate = true
+			break
+		}
+	}
+	spScore, bestScore := ps.syncPeerScore(sp), ps.syncPeerScore(bestPeer)
+	if candidate && bestScore <= spScore*syncPeerScoreMargin {
+		return
+	}
+	log.Info(fmt.Sprintf("Switching the sync peer from %s (score %.0f) to %s "+
+		"(score %.0f)", sp.GetID(), spScore, bestPeer.GetID(), bestScore))
+	ps.updateSyncPeer(true)
+}
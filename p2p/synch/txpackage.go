@@ -0,0 +1,131 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package synch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/protocol"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/p2p/common"
+	"github.com/Qitmeer/qitmeer/p2p/peers"
+	pb "github.com/Qitmeer/qitmeer/p2p/proto/v1"
+	"github.com/Qitmeer/qitmeer/services/mempool"
+	libp2pcore "github.com/libp2p/go-libp2p-core"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+func (s *Sync) sendTxPackageRequest(ctx context.Context, pe *peers.Peer, msg *pb.TxPackage) error {
+	ctx, cancel := context.WithTimeout(ctx, ReqTimeout)
+	defer cancel()
+
+	stream, err := s.Send(ctx, msg, RPCTxPackage, pe.GetID())
+	if err != nil {
+		log.Trace(fmt.Sprintf("Failed to send transaction package to peer=%v, err=%v", pe.GetID(), err.Error()))
+		return err
+	}
+	defer func() {
+		if err := stream.Reset(); err != nil {
+			log.Error(fmt.Sprintf("Failed to reset stream with protocol %s,%v", stream.Protocol(), err))
+		}
+	}()
+
+	code, errMsg, err := ReadRspCode(stream, s.Encoding())
+	if err != nil {
+		return err
+	}
+
+	if !code.IsSuccess() {
+		return errors.New(errMsg)
+	}
+	return err
+}
+
+func (s *Sync) txPackageHandler(ctx context.Context, msg interface{}, stream libp2pcore.Stream) *common.Error {
+	pe := s.peers.Get(stream.Conn().RemotePeer())
+	if pe == nil {
+		return ErrPeerUnknown
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, HandleTimeout)
+	var err error
+	defer func() {
+		cancel()
+	}()
+
+	m, ok := msg.(*pb.TxPackage)
+	if !ok {
+		err = fmt.Errorf("message is not type *pb.TxPackage")
+		return ErrMessage(err)
+	}
+	if len(m.Txs) > mempool.MaxPackageCount {
+		err = fmt.Errorf("transaction package of %d transactions exceeds the limit", len(m.Txs))
+		return ErrMessage(err)
+	}
+	// The packages of the peers are ignored in the blocks-only mode, which
+	// is advertised to them, so they are not penalized for sending one.
+	if s.p2p.DisableRelayTx() {
+		log.Debug(fmt.Sprintf("Ignoring transaction package from peer=%v in blocks-only mode", pe.GetID()))
+		return s.EncodeResponseMsg(stream, nil)
+	}
+	txs := make([]*types.Tx, 0, len(m.Txs))
+	for _, pbtx := range m.Txs {
+		tx := changePBTxToTx(pbtx)
+		if tx == nil {
+			return ErrMessage(fmt.Errorf("invalid transaction in package"))
+		}
+		txs = append(txs, types.NewTx(tx))
+	}
+	acceptedTxs, err := s.p2p.TxMemPool().ProcessPackage(txs, true, true)
+	if err != nil {
+		// A package is rejected for the same policy reasons as a single
+		// transaction, so the peer is not penalized for it.
+		log.Debug(fmt.Sprintf("Rejected transaction package from peer=%v: %v", pe.GetID(), err))
+	} else {
+		s.p2p.Notify().AnnounceNewPackage(txs, acceptedTxs, []peer.ID{pe.GetID()})
+	}
+	e := s.EncodeResponseMsg(stream, nil)
+	if e != nil {
+		return e
+	}
+	return nil
+}
+
+// RelayTxPackage sends the transaction package to all connected peers
+// speaking the package relay protocol except the filtered ones and those
+// which disabled transaction relaying.  Nothing is relayed in safe mode.
+func (ps *PeerSync) RelayTxPackage(txs []*types.Tx, filters []peer.ID) {
+	if ps.IsSafeMode() {
+		return
+	}
+	msg := &pb.TxPackage{Txs: make([]*pb.Transaction, 0, len(txs))}
+	for _, tx := range txs {
+		txBytes, err := tx.Tx.Serialize()
+		if err != nil {
+			log.Error(fmt.Sprintf("Failed to serialize transaction %v of package: %v", tx.Hash(), err))
+			return
+		}
+		msg.Txs = append(msg.Txs, &pb.Transaction{TxBytes: txBytes})
+	}
+
+	filtersM := map[peer.ID]struct{}{}
+	for _, f := range filters {
+		filtersM[f] = struct{}{}
+	}
+	ps.sy.Peers().ForPeers(peers.PeerConnected, func(pe *peers.Peer) {
+		if _, ok := filtersM[pe.GetID()]; ok {
+			return
+		}
+		if pe.DisableRelayTx() {
+			return
+		}
+		if wireVersion(ps.sy.p2p, pe.GetID()) < protocol.PackageRelayVersion {
+			return
+		}
+		log.Trace(fmt.Sprintf("Relay package of %d transactions to peer(%s)", len(txs), pe.GetID().String()))
+		go ps.sy.sendTxPackageRequest(ps.sy.p2p.Context(), pe, msg)
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"github.com/Qitmeer/qitmeer/crypto/ecc/secp256k1"
+	"github.com/Qitmeer/qitmeer/crypto/keystore"
 	"github.com/Qitmeer/qitmeer/p2p/common"
 	"github.com/Qitmeer/qitmeer/p2p/iputils"
 	pb "github.com/Qitmeer/qitmeer/p2p/proto/v1"
@@ -25,6 +26,9 @@ import (
 )
 
 const keyPath = "network.key"
+
+// keystoreKeyName is the name of the private key in the keystore.
+const keystoreKeyName = "network.key"
 const metaDataPath = "metaData"
 const PeerStore = "peerstore"
 
@@ -43,9 +47,63 @@ func IpAddr() net.IP {
 // Determines a private key for p2p networking from the p2p service's
 // configuration struct. If no key is found, it generates a new one.
 func privKey(cfg *common.Config) (*ecdsa.PrivateKey, error) {
+	if cfg.Keystore != nil && cfg.PrivateKey == "" {
+		return keystorePrivKey(cfg.Keystore, cfg.DataDir)
+	}
 	return PrivateKey(cfg.DataDir, cfg.PrivateKey, cfg.ReadWritePermissions)
 }
 
+// keystorePrivKey returns the private key for p2p networking held by the
+// keystore.  When the keystore has no key, the key file of the data directory
+// is moved into it, or a new key is generated when there is no key file.
+func keystorePrivKey(ks *keystore.Keystore, dataDir string) (*ecdsa.PrivateKey, error) {
+	rawbytes, err := ks.Secret(keystoreKeyName)
+	if err == nil {
+		unmarshalledKey, err := crypto.UnmarshalSecp256k1PrivateKey(rawbytes)
+		if err != nil {
+			return nil, err
+		}
+		return convertFromInterfacePrivKey(unmarshalledKey), nil
+	}
+	if err != keystore.ErrSecretNotFound {
+		return nil, err
+	}
+
+	defaultKeyPath := path.Join(dataDir, keyPath)
+	_, err = os.Stat(defaultKeyPath)
+	defaultKeysExist := !os.IsNotExist(err)
+	if err != nil && defaultKeysExist {
+		return nil, err
+	}
+	var priv *ecdsa.PrivateKey
+	if defaultKeysExist {
+		priv, err = retrievePrivKeyFromFile(defaultKeyPath)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		generatedKey, _, err := crypto.GenerateSecp256k1Key(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		priv = convertFromInterfacePrivKey(generatedKey)
+	}
+	rawbytes, err = ConvertToInterfacePrivkey(priv).Raw()
+	if err != nil {
+		return nil, err
+	}
+	if err = ks.SetSecret(keystoreKeyName, rawbytes); err != nil {
+		return nil, err
+	}
+	if defaultKeysExist {
+		if err = os.Remove(defaultKeyPath); err != nil {
+			return nil, err
+		}
+		log.Info(fmt.Sprintf("Moved the p2p private key %s into the keystore", defaultKeyPath))
+	}
+	return priv, nil
+}
+
 // Determines a private key for p2p networking from the p2p service's
 // configuration struct. If no key is found, it generates a new one.
 func PrivateKey(dataDir string, privateKeyPath string, readWritePermissions os.FileMode) (*ecdsa.PrivateKey, error) {
@@ -50,6 +50,14 @@ type Checkpoint struct {
 	Hash  *hash.Hash
 }
 
+// AnticoneSizeChange is a scheduled change of the anticone size of the DAG
+// by a protocol upgrade.  The blocks from the main height on color their
+// diff anticones with the new size.
+type AnticoneSizeChange struct {
+	MainHeight uint64
+	Size       int
+}
+
 // ConsensusDeployment defines details related to a specific consensus rule
 // change that is voted in.
 // NOTE: The type of time must be consistent
@@ -281,9 +289,33 @@ type Params struct {
 	BlockRate     float64
 	SecurityLevel float64
 
+	// AnticoneSize overrides the anticone size of the DAG computed from
+	// BlockDelay, BlockRate and SecurityLevel when positive.
+	AnticoneSize int
+
+	// AnticoneSizeChanges are the scheduled changes of the anticone size,
+	// ordered by main height.
+	AnticoneSizeChanges []AnticoneSizeChange
+
+	// ParentsRulesMainHeight is the main height from which the parents of
+	// the blocks must form a valid parent set in the DAG: not too many of
+	// them, none in the past of another, in nearby layers and with a median
+	// timestamp not after the one of the block.  NotScheduled when the
+	// rules are not scheduled on the network.
+	ParentsRulesMainHeight uint
+
+	// DAGType is the ordering algorithm of the DAG of the network, one of
+	// the DAG types registered in the blockdag package.  The nodes of the
+	// network must all use it, and phantom is used when it is empty.
+	DAGType string
+
 	LedgerParams ledger.LedgerParams
 }
 
+// NotScheduled is the activation main height of the consensus rules which are
+// not scheduled on a network.
+const NotScheduled = ^uint(0)
+
 // TotalSubsidyProportions is the sum of POW Reward, POS Reward, and Tax
 // proportions.
 func (p *Params) TotalSubsidyProportions() uint16 {
@@ -101,5 +101,8 @@ var MainNetParams = Params{
 
 	CoinbaseMaturity: 512,
 
+	// The parent set rules of the DAG.
+	ParentsRulesMainHeight: NotScheduled,
+
 	OrganizationPkScript: hexMustDecode("76a914c0f0b73c320e1fe38eb1166a57b953e509c8f93e88ac"),
 }
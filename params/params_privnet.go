@@ -144,4 +144,7 @@ var PrivNetParams = Params{
 	TokenAdminPkScript: hexMustDecode("00000000c96d6d76a914785bfbf4ecad8b72f2582be83616c5d364a3244288ac"),
 
 	CoinbaseMaturity: 16,
+
+	// The parent set rules of the DAG.
+	ParentsRulesMainHeight: 0,
 }
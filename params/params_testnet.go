@@ -97,6 +97,9 @@ var TestNetParams = Params{
 	// Maturity
 	CoinbaseMaturity: 720, // coinbase required 720 * 30 = 6 hours before repent
 
+	// The parent set rules of the DAG.
+	ParentsRulesMainHeight: NotScheduled,
+
 	// Checkpoints ordered from oldest to newest.
 	Checkpoints: []Checkpoint{},
 
@@ -0,0 +1,196 @@
+// Copyright (c) 2017-2018 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package params
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/blockdag/anticone"
+	"math"
+	"strconv"
+	"time"
+)
+
+// maxAnticoneExpect is the upper bound of 2 * BlockDelay * BlockRate that the
+// anticone size calculation supports.
+const maxAnticoneExpect = 1000
+
+// Validate performs a sanity check of the network parameters so that a
+// misconfigured network fails at startup instead of forking or stalling later.
+// The returned error describes which parameter is wrong and how to fix it.
+func (p *Params) Validate() error {
+	if len(p.Name) == 0 {
+		return fmt.Errorf("params: network name must not be empty")
+	}
+	if err := p.validateGenesis(); err != nil {
+		return fmt.Errorf("params %s: %v", p.Name, err)
+	}
+	if err := p.validatePorts(); err != nil {
+		return fmt.Errorf("params %s: %v", p.Name, err)
+	}
+	if err := p.validateSubsidy(); err != nil {
+		return fmt.Errorf("params %s: %v", p.Name, err)
+	}
+	if err := p.validateAnticone(); err != nil {
+		return fmt.Errorf("params %s: %v", p.Name, err)
+	}
+	if err := p.validateCheckpoints(); err != nil {
+		return fmt.Errorf("params %s: %v", p.Name, err)
+	}
+	return nil
+}
+
+func (p *Params) validateGenesis() error {
+	if p.GenesisBlock == nil || p.GenesisHash == nil {
+		return fmt.Errorf("genesis block and genesis hash must be set")
+	}
+	h := p.GenesisBlock.BlockHash()
+	if !h.IsEqual(p.GenesisHash) {
+		return fmt.Errorf("genesis hash %s does not match the hash %s of "+
+			"the serialized genesis block, update GenesisHash", p.GenesisHash, h)
+	}
+	return nil
+}
+
+func (p *Params) validatePorts() error {
+	tcpPort, err := parsePort(p.DefaultPort)
+	if err != nil {
+		return fmt.Errorf("invalid DefaultPort: %v", err)
+	}
+	if p.DefaultUDPPort <= 0 || p.DefaultUDPPort > math.MaxUint16 {
+		return fmt.Errorf("invalid DefaultUDPPort %d, must be in range "+
+			"[1, %d]", p.DefaultUDPPort, math.MaxUint16)
+	}
+	if tcpPort == p.DefaultUDPPort {
+		return fmt.Errorf("DefaultPort and DefaultUDPPort must differ, "+
+			"both are %d", tcpPort)
+	}
+	return nil
+}
+
+func (p *Params) validateSubsidy() error {
+	if p.BaseSubsidy <= 0 {
+		return fmt.Errorf("BaseSubsidy must be positive, got %d", p.BaseSubsidy)
+	}
+	if p.SubsidyReductionInterval <= 0 {
+		return fmt.Errorf("SubsidyReductionInterval must be positive, got %d",
+			p.SubsidyReductionInterval)
+	}
+	// The subsidy is multiplied by MulSubsidy/DivSubsidy every reduction
+	// interval, so the schedule only decreases when the ratio is at most 1.
+	if p.MulSubsidy <= 0 || p.DivSubsidy <= 0 {
+		return fmt.Errorf("MulSubsidy and DivSubsidy must be positive, "+
+			"got %d/%d", p.MulSubsidy, p.DivSubsidy)
+	}
+	if p.MulSubsidy > p.DivSubsidy {
+		return fmt.Errorf("subsidy schedule must not increase, MulSubsidy %d "+
+			"is greater than DivSubsidy %d", p.MulSubsidy, p.DivSubsidy)
+	}
+	if p.TotalSubsidyProportions() == 0 {
+		return fmt.Errorf("the sum of WorkRewardProportion, " +
+			"StakeRewardProportion and BlockTaxProportion must be positive")
+	}
+	return nil
+}
+
+func (p *Params) validateAnticone() error {
+	if p.TargetTimePerBlock < time.Second {
+		return fmt.Errorf("TargetTimePerBlock must be at least one second, "+
+			"got %v", p.TargetTimePerBlock)
+	}
+	delay := p.BlockDelay
+	if delay == 0 {
+		delay = anticone.BlockDelay
+	}
+	rate := p.BlockRate
+	if rate == 0 {
+		rate = 1.0 / float64(p.TargetTimePerBlock/time.Second)
+	}
+	security := p.SecurityLevel
+	if security == 0 {
+		security = anticone.SecurityLevel
+	}
+	if delay < 0 || rate < 0 {
+		return fmt.Errorf("BlockDelay and BlockRate must not be negative, "+
+			"got %v and %v", delay, rate)
+	}
+	if security < 0 || security >= 1 {
+		return fmt.Errorf("SecurityLevel must be in range (0, 1), got %v",
+			security)
+	}
+	if 2*delay*rate >= maxAnticoneExpect {
+		return fmt.Errorf("2 * BlockDelay * BlockRate must be less than %d, "+
+			"got %v, lower BlockDelay or BlockRate", maxAnticoneExpect, 2*delay*rate)
+	}
+	if anticone.GetSize(delay, rate, security) <= 0 {
+		return fmt.Errorf("anticone size of BlockDelay %v, BlockRate %v and "+
+			"SecurityLevel %v is zero, lower the SecurityLevel", delay, rate,
+			security)
+	}
+	if p.AnticoneSize < 0 {
+		return fmt.Errorf("AnticoneSize must not be negative, got %d",
+			p.AnticoneSize)
+	}
+	for i, change := range p.AnticoneSizeChanges {
+		if change.Size <= 0 {
+			return fmt.Errorf("anticone size change at main height %d "+
+				"must be positive, got %d", change.MainHeight, change.Size)
+		}
+		if change.MainHeight == 0 {
+			return fmt.Errorf("anticone size change must not be at the " +
+				"genesis, use AnticoneSize instead")
+		}
+		if i > 0 && change.MainHeight <= p.AnticoneSizeChanges[i-1].MainHeight {
+			return fmt.Errorf("anticone size changes must be ordered by "+
+				"main height, %d follows %d", change.MainHeight,
+				p.AnticoneSizeChanges[i-1].MainHeight)
+		}
+	}
+	return nil
+}
+
+func (p *Params) validateCheckpoints() error {
+	for i, cp := range p.Checkpoints {
+		if cp.Hash == nil {
+			return fmt.Errorf("checkpoint at layer %d has no hash", cp.Layer)
+		}
+		if i > 0 && cp.Layer <= p.Checkpoints[i-1].Layer {
+			return fmt.Errorf("checkpoints must be ordered from oldest to "+
+				"newest, layer %d follows layer %d", cp.Layer,
+				p.Checkpoints[i-1].Layer)
+		}
+	}
+	return nil
+}
+
+// Validate checks the network parameters and makes sure that the RPC port
+// does not collide with the peer-to-peer ports.
+func (p *netParams) Validate() error {
+	err := p.Params.Validate()
+	if err != nil {
+		return err
+	}
+	rpcPort, err := parsePort(p.RpcPort)
+	if err != nil {
+		return fmt.Errorf("params %s: invalid RpcPort: %v", p.Name, err)
+	}
+	tcpPort, _ := strconv.Atoi(p.DefaultPort)
+	if rpcPort == tcpPort || rpcPort == p.DefaultUDPPort {
+		return fmt.Errorf("params %s: RpcPort %d collides with the "+
+			"peer-to-peer ports", p.Name, rpcPort)
+	}
+	return nil
+}
+
+func parsePort(port string) (int, error) {
+	v, err := strconv.Atoi(port)
+	if err != nil {
+		return 0, fmt.Errorf("port %q is not a number", port)
+	}
+	if v <= 0 || v > math.MaxUint16 {
+		return 0, fmt.Errorf("port %d must be in range [1, %d]", v,
+			math.MaxUint16)
+	}
+	return v, nil
+}
@@ -0,0 +1,56 @@
+package params
+
+import (
+	"testing"
+)
+
+func TestValidateNetParams(t *testing.T) {
+	for _, p := range []*netParams{&MainNetParam, &TestNetParam, &PrivNetParam, &MixNetParam} {
+		if err := p.Validate(); err != nil {
+			t.Errorf("%s: %v", p.Name, err)
+		}
+	}
+}
+
+func TestValidateInvalidParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(p *Params)
+	}{
+		{"genesis hash", func(p *Params) { p.GenesisHash = MainNetParams.GenesisHash }},
+		{"same ports", func(p *Params) { p.DefaultUDPPort = 38130 }},
+		{"bad port", func(p *Params) { p.DefaultPort = "port" }},
+		{"increasing subsidy", func(p *Params) { p.MulSubsidy = p.DivSubsidy + 1 }},
+		{"no reduction interval", func(p *Params) { p.SubsidyReductionInterval = 0 }},
+		{"no proportions", func(p *Params) {
+			p.WorkRewardProportion = 0
+			p.StakeRewardProportion = 0
+			p.BlockTaxProportion = 0
+		}},
+		{"anticone expect", func(p *Params) { p.BlockRate = 100 }},
+		{"security level", func(p *Params) { p.SecurityLevel = 1 }},
+		{"anticone size", func(p *Params) { p.AnticoneSize = -1 }},
+		{"anticone size change", func(p *Params) {
+			p.AnticoneSizeChanges = []AnticoneSizeChange{{MainHeight: 10, Size: 0}}
+		}},
+		{"anticone size change order", func(p *Params) {
+			p.AnticoneSizeChanges = []AnticoneSizeChange{
+				{MainHeight: 10, Size: 5},
+				{MainHeight: 10, Size: 6},
+			}
+		}},
+		{"checkpoint order", func(p *Params) {
+			p.Checkpoints = []Checkpoint{
+				{Layer: 10, Hash: p.GenesisHash},
+				{Layer: 5, Hash: p.GenesisHash},
+			}
+		}},
+	}
+	for _, test := range tests {
+		p := PrivNetParams
+		test.modify(&p)
+		if err := p.Validate(); err == nil {
+			t.Errorf("%s: expected a validation error", test.name)
+		}
+	}
+}
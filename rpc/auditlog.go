@@ -0,0 +1,260 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+package rpc
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/database"
+	"golang.org/x/net/context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// MaxAuditEntriesPerRequest is the maximum number of entries returned by the
+// getAuditLog command.
+const MaxAuditEntriesPerRequest = 1000
+
+var (
+	// auditLogBucketName is the name of the db bucket used to house the
+	// audit log.
+	auditLogBucketName = []byte("rpcauditlog")
+
+	// auditedMethods are the RPC methods changing the state of the node
+	// which are recorded in the audit log.
+	auditedMethods = map[string]bool{
+		"submitBlock":               true,
+		"sendRawTransaction":        true,
+		"generate":                  true,
+		"stop":                      true,
+		"removeBan":                 true,
+		"acknowledgeSafeMode":       true,
+		"setRpcMaxClients":          true,
+		"setLogLevel":               true,
+		"dumpMempool":               true,
+		"loadMempool":               true,
+		"freezeCoins":               true,
+		"unfreezeCoins":             true,
+		"externalSignSpendProposal": true,
+		"setAddressLabel":           true,
+		"unlockKeystore":            true,
+		"lockKeystore":              true,
+	}
+
+	// secretParamsMethods are the audited methods whose parameters hold a
+	// secret.  Their parameters are not digested, since a digest of a
+	// passphrase can be brute forced.
+	secretParamsMethods = map[string]bool{
+		"unlockKeystore": true,
+	}
+)
+
+// -----------------------------------------------------------------------------
+// The audit log is an append-only bucket of the calls to the state-changing RPC
+// methods.
+//
+// The serialized key format is:
+//
+//   <entry id>
+//
+//   Field           Type      Size
+//   entry id        uint64    8 bytes (big endian)
+//
+// The entry ids start at zero and increase by one with every entry, so that the
+// entries are sorted by time.  The value is the JSON encoding of the entry.
+// -----------------------------------------------------------------------------
+
+// AuditEntry is a call to a state-changing RPC method.
+type AuditEntry struct {
+	// ID is the position of the entry in the audit log.
+	ID uint64 `json:"-"`
+
+	// Timestamp is the time the call returned, in seconds.
+	Timestamp int64 `json:"timestamp"`
+
+	// User is the authenticated user, empty for a websocket client which
+	// did not authenticate.
+	User string `json:"user"`
+
+	// Remote is the address of the client.
+	Remote string `json:"remote"`
+
+	// Method is the name of the RPC method.
+	Method string `json:"method"`
+
+	// ParamsDigest is the hex-encoded sha256 digest of the JSON encoding
+	// of the parameters, so that the log does not hold raw transactions or
+	// keys.  It is empty for the methods taking a passphrase.
+	ParamsDigest string `json:"paramsdigest"`
+
+	// Success is whether the method returned without error.
+	Success bool `json:"success"`
+
+	// Error is the error returned by the method.
+	Error string `json:"error,omitempty"`
+}
+
+// AuditLog records the calls to the state-changing RPC methods in the
+// database.
+type AuditLog struct {
+	db     database.DB
+	mtx    sync.Mutex
+	nextID uint64
+}
+
+// auditKey returns the key of the audit entry with the id.
+func auditKey(id uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, id)
+	return key
+}
+
+// Record appends the entry to the audit log and sets its id.
+//
+// This function is safe for concurrent access.
+func (a *AuditLog) Record(entry *AuditEntry) error {
+	serialized, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	err = a.db.Update(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(auditLogBucketName)
+		return bucket.Put(auditKey(a.nextID), serialized)
+	})
+	if err != nil {
+		return err
+	}
+	entry.ID = a.nextID
+	a.nextID++
+	return nil
+}
+
+// Entries returns at most count entries of the audit log sorted by id,
+// starting at the id start, or the last count entries when start is nil.
+//
+// This function is safe for concurrent access.
+func (a *AuditLog) Entries(start *uint64, count uint32) ([]*AuditEntry, error) {
+	entries := []*AuditEntry{}
+	err := a.db.View(func(dbTx database.Tx) error {
+		cursor := dbTx.Metadata().Bucket(auditLogBucketName).Cursor()
+		var ok bool
+		if start == nil {
+			ok = cursor.Last()
+		} else {
+			ok = cursor.Seek(auditKey(*start))
+		}
+		for ; ok && uint32(len(entries)) < count; ok = nextAuditEntry(cursor, start == nil) {
+			var entry AuditEntry
+			err := json.Unmarshal(cursor.Value(), &entry)
+			if err != nil {
+				return database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("failed to "+
+						"deserialize audit entry %x: %v",
+						cursor.Key(), err),
+				}
+			}
+			entry.ID = binary.BigEndian.Uint64(cursor.Key())
+			entries = append(entries, &entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if start == nil {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+	return entries, nil
+}
+
+// nextAuditEntry moves the cursor to the previous entry when backward is true,
+// and to the next entry otherwise.
+func nextAuditEntry(cursor database.Cursor, backward bool) bool {
+	if backward {
+		return cursor.Prev()
+	}
+	return cursor.Next()
+}
+
+// NewAuditLog returns the audit log stored in the database, creating it when
+// it does not exist.
+func NewAuditLog(db database.DB) (*AuditLog, error) {
+	a := &AuditLog{db: db}
+	err := db.Update(func(dbTx database.Tx) error {
+		bucket, err := dbTx.Metadata().CreateBucketIfNotExists(auditLogBucketName)
+		if err != nil {
+			return err
+		}
+		cursor := bucket.Cursor()
+		if cursor.Last() {
+			a.nextID = binary.BigEndian.Uint64(cursor.Key()) + 1
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// paramsDigest returns the hex-encoded sha256 digest of the JSON encoding of
+// the arguments of a request.
+func paramsDigest(args []reflect.Value) string {
+	params := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		params = append(params, arg.Interface())
+	}
+	serialized, err := json.Marshal(params)
+	if err != nil {
+		serialized = []byte(fmt.Sprintf("%v", params))
+	}
+	digest := sha256.Sum256(serialized)
+	return hex.EncodeToString(digest[:])
+}
+
+// audit records the call of a state-changing method in the audit log when it
+// is enabled.
+func (s *RpcServer) audit(ctx context.Context, req *serverRequest, reply []reflect.Value) {
+	if s.AuditLog == nil {
+		return
+	}
+	method := formatName(req.callb.method.Name)
+	if !auditedMethods[method] {
+		return
+	}
+	entry := &AuditEntry{
+		Timestamp: time.Now().Unix(),
+		Method:    method,
+		Success:   true,
+	}
+	if !secretParamsMethods[method] {
+		entry.ParamsDigest = paramsDigest(req.args)
+	}
+	if user, ok := ctx.Value("user").(string); ok {
+		entry.User = user
+	}
+	if remote, ok := ctx.Value("remote").(string); ok {
+		entry.Remote = remote
+	}
+	if req.callb.errPos >= 0 && req.callb.errPos < len(reply) &&
+		!reply[req.callb.errPos].IsNil() {
+		entry.Success = false
+		entry.Error = reply[req.callb.errPos].Interface().(error).Error()
+	}
+	err := s.AuditLog.Record(entry)
+	if err != nil {
+		log.Error("Failed to record the audit log", "method", method,
+			"error", err)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpc
+
+import (
+	"github.com/Qitmeer/qitmeer/metrics"
+	"sync"
+	"time"
+)
+
+// MaxResponseCacheEntries is the maximum number of results held by a
+// ResponseCache.
+const MaxResponseCacheEntries = 1000
+
+var (
+	responseCacheHits   = metrics.NewCounter("rpc/cache/hits")
+	responseCacheMisses = metrics.NewCounter("rpc/cache/misses")
+)
+
+// cachedResponse is a result held by a ResponseCache.
+type cachedResponse struct {
+	result  interface{}
+	expires time.Time
+}
+
+// ResponseCache holds the results of expensive and deterministic RPC calls
+// for a short time, so that the clients asking for the same data, such as
+// several explorers, do not compute it again.  The results depend on the tip
+// of the chain, so the owner of the cache must purge it whenever the tip
+// changes.  The cached results are shared by the callers and must not be
+// modified.
+//
+// A nil ResponseCache caches nothing, so the callers need not check whether
+// the cache is enabled.
+type ResponseCache struct {
+	lock    sync.Mutex
+	ttl     time.Duration
+	entries map[string]*cachedResponse
+}
+
+// NewResponseCache returns a cache holding the results for ttl, or nil when
+// ttl is zero.
+func NewResponseCache(ttl time.Duration) *ResponseCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &ResponseCache{
+		ttl:     ttl,
+		entries: make(map[string]*cachedResponse),
+	}
+}
+
+// Get returns the result cached for the key, if any.
+//
+// This function is safe for concurrent access.
+func (c *ResponseCache) Get(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		responseCacheMisses.Inc(1)
+		return nil, false
+	}
+	responseCacheHits.Inc(1)
+	return entry.result, true
+}
+
+// Put caches the result for the key.  The expired results are dropped when
+// the cache is full, and an arbitrary one when none has expired yet.
+//
+// This function is safe for concurrent access.
+func (c *ResponseCache) Put(key string, result interface{}) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= MaxResponseCacheEntries {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= MaxResponseCacheEntries {
+			for k := range c.entries {
+				delete(c.entries, k)
+				break
+			}
+		}
+	}
+	c.entries[key] = &cachedResponse{result: result, expires: now.Add(c.ttl)}
+}
+
+// Purge drops all the cached results, such as when the tip of the chain
+// changes.
+//
+// This function is safe for concurrent access.
+func (c *ResponseCache) Purge() {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	c.entries = make(map[string]*cachedResponse)
+	c.lock.Unlock()
+}
@@ -387,6 +387,32 @@ func (c *Client) GetBlockHeader(hash string, verbose bool) (interface{}, error)
 	return c.GetBlockHeaderAsync(hash, verbose).Receive(verbose)
 }
 
+type FutureGetHeadersResult chan *response
+
+func (r FutureGetHeadersResult) Receive() (*j.HeadersResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+	var headers j.HeadersResult
+	err = json.Unmarshal(res, &headers)
+	if err != nil {
+		return nil, err
+	}
+	return &headers, nil
+}
+
+func (c *Client) GetHeadersAsync(startOrder uint64, count uint32) FutureGetHeadersResult {
+	cmd := cmds.NewGetHeadersCmd(startOrder, count)
+	return c.sendCmd(cmd)
+}
+
+// GetHeaders returns the packed headers of the count blocks following the
+// start order, along with whether each block is blue.
+func (c *Client) GetHeaders(startOrder uint64, count uint32) (*j.HeadersResult, error) {
+	return c.GetHeadersAsync(startOrder, count).Receive()
+}
+
 func (c *Client) GetBlockHeaderRaw(hash string) (string, error) {
 	result, err := c.GetBlockHeader(hash, false)
 	if err != nil {
@@ -461,6 +487,120 @@ func (c *Client) GetMainChainHeight() (int64, error) {
 	return c.GetMainChainHeightAsync().Receive()
 }
 
+type FutureGetMainChainBlockResult chan *response
+
+func (r FutureGetMainChainBlockResult) Receive() (*j.MainChainBlockResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+	var block j.MainChainBlockResult
+	err = json.Unmarshal(res, &block)
+	if err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
+
+func (c *Client) GetMainChainTipAsync() FutureGetMainChainBlockResult {
+	cmd := cmds.NewGetMainChainTipCmd()
+	return c.sendCmd(cmd)
+}
+
+// GetMainChainTip returns the tip of the DAG main chain.
+func (c *Client) GetMainChainTip() (*j.MainChainBlockResult, error) {
+	return c.GetMainChainTipAsync().Receive()
+}
+
+func (c *Client) GetMainChainBlockByHeightAsync(height uint64) FutureGetMainChainBlockResult {
+	cmd := cmds.NewGetMainChainBlockByHeightCmd(height)
+	return c.sendCmd(cmd)
+}
+
+// GetMainChainBlockByHeight returns the block of the DAG main chain at the
+// height.
+func (c *Client) GetMainChainBlockByHeight(height uint64) (*j.MainChainBlockResult, error) {
+	return c.GetMainChainBlockByHeightAsync(height).Receive()
+}
+
+type FutureGetLockTimeCursorResult chan *response
+
+func (r FutureGetLockTimeCursorResult) Receive() (*j.LockTimeCursorResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+	var cursor j.LockTimeCursorResult
+	err = json.Unmarshal(res, &cursor)
+	if err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+func (c *Client) GetLockTimeCursorAsync() FutureGetLockTimeCursorResult {
+	cmd := cmds.NewGetLockTimeCursorCmd()
+	return c.sendCmd(cmd)
+}
+
+// GetLockTimeCursor returns the main chain height and the past median time
+// the lock time of a transaction is compared to in the next block.
+func (c *Client) GetLockTimeCursor() (*j.LockTimeCursorResult, error) {
+	return c.GetLockTimeCursorAsync().Receive()
+}
+
+type FutureGetNetworkHashPSResult chan *response
+
+func (r FutureGetNetworkHashPSResult) Receive() (*j.NetworkHashPSResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+	var result j.NetworkHashPSResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) GetNetworkHashPSAsync(window *uint32, powType *byte) FutureGetNetworkHashPSResult {
+	cmd := cmds.NewGetNetworkHashPSCmd(window, powType)
+	return c.sendCmd(cmd)
+}
+
+// GetNetworkHashPS returns the hash rate of the network for the pow type,
+// estimated over its last window blocks.
+func (c *Client) GetNetworkHashPS(window *uint32, powType *byte) (*j.NetworkHashPSResult, error) {
+	return c.GetNetworkHashPSAsync(window, powType).Receive()
+}
+
+type FutureGetDifficultyHistoryResult chan *response
+
+func (r FutureGetDifficultyHistoryResult) Receive() (*j.DifficultyHistoryResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+	var result j.DifficultyHistoryResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) GetDifficultyHistoryAsync(window *uint32, powType *byte) FutureGetDifficultyHistoryResult {
+	cmd := cmds.NewGetDifficultyHistoryCmd(window, powType)
+	return c.sendCmd(cmd)
+}
+
+// GetDifficultyHistory returns the difficulty of the last window blocks of
+// the pow type.
+func (c *Client) GetDifficultyHistory(window *uint32, powType *byte) (*j.DifficultyHistoryResult, error) {
+	return c.GetDifficultyHistoryAsync(window, powType).Receive()
+}
+
 type FutureGetBlockWeightResult chan *response
 
 func (r FutureGetBlockWeightResult) Receive() (int64, error) {
@@ -628,6 +768,56 @@ func (c *Client) IsBlue(h string) (int, error) {
 	return c.IsBlueAsync(h).Receive()
 }
 
+type FutureGetBlockStateResult chan *response
+
+func (r FutureGetBlockStateResult) Receive() (*j.BlockStateResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+	var state j.BlockStateResult
+	err = json.Unmarshal(res, &state)
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (c *Client) GetBlockStateAsync(h string) FutureGetBlockStateResult {
+	cmd := cmds.NewGetBlockStateCmd(h)
+	return c.sendCmd(cmd)
+}
+
+// GetBlockState returns the position of the block in the DAG and whether it
+// is blue.
+func (c *Client) GetBlockState(h string) (*j.BlockStateResult, error) {
+	return c.GetBlockStateAsync(h).Receive()
+}
+
+type FutureGetAnticoneResult chan *response
+
+func (r FutureGetAnticoneResult) Receive() ([]string, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+	var hashes []string
+	err = json.Unmarshal(res, &hashes)
+	if err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+func (c *Client) GetAnticoneAsync(h string) FutureGetAnticoneResult {
+	cmd := cmds.NewGetAnticoneCmd(h)
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) GetAnticone(h string) ([]string, error) {
+	return c.GetAnticoneAsync(h).Receive()
+}
+
 type FutureIsCurrentResult chan *response
 
 func (r FutureIsCurrentResult) Receive() (bool, error) {
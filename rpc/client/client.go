@@ -9,6 +9,7 @@ import (
 	"container/list"
 	"encoding/json"
 	"fmt"
+	j "github.com/Qitmeer/qitmeer/core/json"
 	"github.com/Qitmeer/qitmeer/params"
 	"github.com/Qitmeer/qitmeer/rpc/client/cmds"
 	"github.com/Qitmeer/qitmeer/rpc/websocket"
@@ -220,6 +221,11 @@ func (c *Client) handleMessage(msg []byte) {
 			log.Warn("Malformed notification: missing params")
 			return
 		}
+		if ntfn.Seq != 0 {
+			c.ntfnStateLock.Lock()
+			c.ntfnState.lastSeq = ntfn.Seq
+			c.ntfnStateLock.Unlock()
+		}
 		// Deliver the notification.
 		log.Trace(fmt.Sprintf("Received notification [%s]", in.Method))
 		c.handleNotification(in.rawNotification)
@@ -412,6 +418,179 @@ func (c *Client) handleNotification(ntfn *rawNotification) {
 
 		c.ntfnHandlers.OnRescanFinish(rawTx)
 
+	// OnRecvTx
+	case cmds.RecvTxNtfnMethod:
+		tx, block, err := parseWatchedTxNtfnParams(ntfn.Params)
+		if err != nil {
+			log.Warn(fmt.Sprintf("Received invalid recvtx "+
+				"notification: %v", err))
+			return
+		}
+		c.trackWatchedOrder(block)
+
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnRecvTx == nil {
+			return
+		}
+
+		c.ntfnHandlers.OnRecvTx(tx, block)
+
+	// OnRedeemingTx
+	case cmds.RedeemingTxNtfnMethod:
+		tx, block, err := parseWatchedTxNtfnParams(ntfn.Params)
+		if err != nil {
+			log.Warn(fmt.Sprintf("Received invalid redeemingtx "+
+				"notification: %v", err))
+			return
+		}
+		c.trackWatchedOrder(block)
+
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnRedeemingTx == nil {
+			return
+		}
+
+		c.ntfnHandlers.OnRedeemingTx(tx, block)
+
+	// OnDoubleSpendProof
+	case cmds.DoubleSpendProofNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnDoubleSpendProof == nil {
+			return
+		}
+
+		proof, err := parseDoubleSpendProofNtfnParams(ntfn.Params)
+		if err != nil {
+			log.Warn(fmt.Sprintf("Received invalid doublespendproof "+
+				"notification: %v", err))
+			return
+		}
+
+		c.ntfnHandlers.OnDoubleSpendProof(proof)
+
+	// OnHeaders
+	case cmds.HeadersNtfnMethod:
+		headers, err := parseHeadersNtfnParams(ntfn.Params)
+		if err != nil {
+			log.Warn(fmt.Sprintf("Received invalid headers "+
+				"notification: %v", err))
+			return
+		}
+		c.trackHeaderOrder(headers)
+
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnHeaders == nil {
+			return
+		}
+
+		c.ntfnHandlers.OnHeaders(headers)
+
+	// OnTxEvicted
+	case cmds.TxEvictedNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnTxEvicted == nil {
+			return
+		}
+
+		txHash, reason, err := parseTxEvictedNtfnParams(ntfn.Params)
+		if err != nil {
+			log.Warn(fmt.Sprintf("Received invalid txevicted "+
+				"notification: %v", err))
+			return
+		}
+
+		c.ntfnHandlers.OnTxEvicted(txHash, reason)
+
+	// OnMinedBlockStale
+	case cmds.MinedBlockStaleNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnMinedBlockStale == nil {
+			return
+		}
+
+		blockHash, reason, hints, err := parseMinedBlockStaleNtfnParams(ntfn.Params)
+		if err != nil {
+			log.Warn(fmt.Sprintf("Received invalid minedblockstale "+
+				"notification: %v", err))
+			return
+		}
+
+		c.ntfnHandlers.OnMinedBlockStale(blockHash, reason, hints)
+
+	// OnFeeHistogram
+	case cmds.FeeHistogramNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnFeeHistogram == nil {
+			return
+		}
+
+		histogram, err := parseFeeHistogramNtfnParams(ntfn.Params)
+		if err != nil {
+			log.Warn(fmt.Sprintf("Received invalid feehistogram "+
+				"notification: %v", err))
+			return
+		}
+
+		c.ntfnHandlers.OnFeeHistogram(histogram)
+
+	// OnChainReorg
+	case cmds.ChainReorgNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnChainReorg == nil {
+			return
+		}
+
+		reorg, err := parseChainReorgNtfnParams(ntfn.Params)
+		if err != nil {
+			log.Warn(fmt.Sprintf("Received invalid chainreorg "+
+				"notification: %v", err))
+			return
+		}
+
+		c.ntfnHandlers.OnChainReorg(reorg)
+
+	// OnDag
+	case cmds.DagNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnDag == nil {
+			return
+		}
+
+		events, err := parseDagNtfnParams(ntfn.Params)
+		if err != nil {
+			log.Warn(fmt.Sprintf("Received invalid dag notification: %v",
+				err))
+			return
+		}
+
+		c.ntfnHandlers.OnDag(events)
+
+	// OnClusterHint
+	case cmds.ClusterHintNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnClusterHint == nil {
+			return
+		}
+
+		peer, hint, err := parseClusterHintNtfnParams(ntfn.Params)
+		if err != nil {
+			log.Warn(fmt.Sprintf("Received invalid clusterhint "+
+				"notification: %v", err))
+			return
+		}
+
+		c.ntfnHandlers.OnClusterHint(peer, hint)
+
 	// OnNodeExit
 	case cmds.NodeExitMethod:
 		// Ignore the notification if the client is not interested in
@@ -478,10 +657,76 @@ func (c *Client) trackRegisteredNtfns(cmd interface{}) {
 	case *cmds.NotifyBlocksCmd:
 		c.ntfnState.notifyBlocks = true
 
+	case *cmds.SubscribeHeadersCmd:
+		c.ntfnState.notifyHeaders = true
+
+	case *cmds.UnsubscribeHeadersCmd:
+		c.ntfnState.notifyHeaders = false
+
+	case *cmds.SubscribeFeeHistogramCmd:
+		c.ntfnState.notifyFeeHistogram = true
+
+	case *cmds.UnsubscribeFeeHistogramCmd:
+		c.ntfnState.notifyFeeHistogram = false
+
+	case *cmds.SubscribeDagCmd:
+		c.ntfnState.notifyDag = true
+
+	case *cmds.UnsubscribeDagCmd:
+		c.ntfnState.notifyDag = false
+
 	case *cmds.NotifyReceivedCmd:
 		for _, addr := range bcmd.Addresses {
 			c.ntfnState.notifyReceived[addr] = struct{}{}
 		}
+
+	case *cmds.StopNotifyReceivedCmd:
+		for _, addr := range bcmd.Addresses {
+			delete(c.ntfnState.notifyReceived, addr)
+		}
+
+	case *cmds.NotifySpentCmd:
+		for _, op := range bcmd.OutPoints {
+			c.ntfnState.notifySpent[op] = struct{}{}
+		}
+
+	case *cmds.StopNotifySpentCmd:
+		for _, op := range bcmd.OutPoints {
+			delete(c.ntfnState.notifySpent, op)
+		}
+	}
+}
+
+// trackWatchedOrder remembers the latest block order of the recvtx and
+// redeemingtx notifications, from which the missed events are requested after
+// a reconnect.
+func (c *Client) trackWatchedOrder(block *cmds.BlockDetails) {
+	if block == nil {
+		return
+	}
+
+	c.ntfnStateLock.Lock()
+	defer c.ntfnStateLock.Unlock()
+
+	if c.ntfnState.lastOrder == nil || *c.ntfnState.lastOrder < block.Order {
+		order := block.Order
+		c.ntfnState.lastOrder = &order
+	}
+}
+
+// trackHeaderOrder remembers the order of the latest header received, from
+// which the missed headers are requested after a reconnect.
+func (c *Client) trackHeaderOrder(headers *j.HeadersResult) {
+	if len(headers.Blues) == 0 {
+		return
+	}
+
+	c.ntfnStateLock.Lock()
+	defer c.ntfnStateLock.Unlock()
+
+	order := headers.StartOrder + uint64(len(headers.Blues)) - 1
+	if c.ntfnState.lastHeaderOrder == nil || *c.ntfnState.lastHeaderOrder < order {
+		c.ntfnState.lastHeaderOrder = &order
 	}
 }
 
@@ -551,10 +796,10 @@ func (c *Client) sendRequest(jReq *jsonRequest) {
 func (c *Client) sendPost(jReq *jsonRequest) {
 	// Generate a request to the configured RPC server.
 	protocol := "http"
-	if !c.config.DisableTLS {
+	if c.config.useTLS() {
 		protocol = "https"
 	}
-	url := protocol + "://" + c.config.Host
+	url := protocol + "://" + c.config.host()
 	bodyReader := bytes.NewReader(jReq.marshalledJSON)
 	httpReq, err := http.NewRequest("POST", url, bodyReader)
 	if err != nil {
@@ -906,6 +1151,22 @@ func (c *Client) reregisterNtfns() error {
 	stateCopy := c.ntfnState.Copy()
 	c.ntfnStateLock.Unlock()
 
+	// Resume the former session if possible.  It keeps the subscriptions
+	// and replays the notifications sent during the disconnect.
+	if stateCopy.sessionID != 0 {
+		err := c.ResumeSession(stateCopy.sessionID, stateCopy.lastSeq)
+		if err == nil {
+			log.Debug(fmt.Sprintf("Resumed session %d from %d",
+				stateCopy.sessionID, stateCopy.lastSeq))
+			return nil
+		}
+		log.Info(fmt.Sprintf("Unable to resume session %d: %v",
+			stateCopy.sessionID, err))
+	}
+	c.ntfnStateLock.Lock()
+	c.ntfnState.lastSeq = 0
+	c.ntfnStateLock.Unlock()
+
 	// Reregister notifyblocks if needed.
 	if stateCopy.notifyBlocks {
 		log.Debug("Reregistering [notifyblocks]")
@@ -913,6 +1174,24 @@ func (c *Client) reregisterNtfns() error {
 			return err
 		}
 	}
+	if stateCopy.notifyHeaders {
+		log.Debug("Reregistering [subscribeheaders]")
+		if err := c.SubscribeHeaders(stateCopy.lastHeaderOrder); err != nil {
+			return err
+		}
+	}
+	if stateCopy.notifyFeeHistogram {
+		log.Debug("Reregistering [subscribefeehistogram]")
+		if err := c.SubscribeFeeHistogram(); err != nil {
+			return err
+		}
+	}
+	if stateCopy.notifyDag {
+		log.Debug("Reregistering [subscribedag]")
+		if err := c.SubscribeDag(); err != nil {
+			return err
+		}
+	}
 	if stateCopy.notifyNewTx || stateCopy.notifyNewTxVerbose {
 		log.Debug(fmt.Sprintf("Reregistering [notifynewtransactions] (verbose=%v)",
 			stateCopy.notifyNewTxVerbose))
@@ -930,11 +1209,38 @@ func (c *Client) reregisterNtfns() error {
 			addresses = append(addresses, addr)
 		}
 		log.Debug("Reregistering [notifyreceived] addresses: %v", addresses)
-		if err := c.notifyReceivedInternal(addresses).Receive(); err != nil {
+		err := c.notifyReceivedInternal(addresses, stateCopy.lastOrder).Receive()
+		if err != nil {
 			return err
 		}
 	}
+	// Reregister the notifyspent outpoints in one command if needed.
+	nslen := len(stateCopy.notifySpent)
+	if nslen > 0 {
+		outPoints := make([]cmds.OutPoint, 0, nslen)
+		for op := range stateCopy.notifySpent {
+			outPoints = append(outPoints, op)
+		}
+		log.Debug(fmt.Sprintf("Reregistering [notifyspent] outpoints: %v", outPoints))
+		err := c.NotifySpentAsync(outPoints, stateCopy.lastOrder).Receive()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Remember the new session to resume it after the next reconnect.
+	return c.trackSession()
+}
 
+// trackSession remembers the id of the current websocket session.
+func (c *Client) trackSession() error {
+	sessionID, err := c.Session()
+	if err != nil {
+		return err
+	}
+	c.ntfnStateLock.Lock()
+	c.ntfnState.sessionID = sessionID
+	c.ntfnStateLock.Unlock()
 	return nil
 }
 
@@ -1006,6 +1312,13 @@ func New(config *ConnConfig, ntfnHandlers *NotificationHandlers) (*Client, error
 		if !client.config.HTTPPostMode && !client.config.DisableAutoReconnect {
 			client.wg.Add(1)
 			go client.wsReconnectHandler()
+			if client.ntfnHandlers != nil {
+				go func() {
+					if err := client.trackSession(); err != nil {
+						log.Warn(fmt.Sprintf("Unable to get the websocket session: %v", err))
+					}
+				}()
+			}
 		}
 	}
 
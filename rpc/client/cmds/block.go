@@ -155,6 +155,20 @@ func NewGetBlockHeaderCmd(hash string, verbose bool) *GetBlockHeaderCmd {
 	}
 }
 
+// GetHeadersCmd requests the headers of the count blocks following the start
+// order, that order included.
+type GetHeadersCmd struct {
+	StartOrder uint64
+	Count      uint32
+}
+
+func NewGetHeadersCmd(startOrder uint64, count uint32) *GetHeadersCmd {
+	return &GetHeadersCmd{
+		StartOrder: startOrder,
+		Count:      count,
+	}
+}
+
 type IsOnMainChainCmd struct {
 	H string
 }
@@ -171,6 +185,28 @@ func NewGetMainChainHeightCmd() *GetMainChainHeightCmd {
 	return &GetMainChainHeightCmd{}
 }
 
+type GetMainChainTipCmd struct{}
+
+func NewGetMainChainTipCmd() *GetMainChainTipCmd {
+	return &GetMainChainTipCmd{}
+}
+
+type GetMainChainBlockByHeightCmd struct {
+	Height uint64
+}
+
+func NewGetMainChainBlockByHeightCmd(height uint64) *GetMainChainBlockByHeightCmd {
+	return &GetMainChainBlockByHeightCmd{
+		Height: height,
+	}
+}
+
+type GetLockTimeCursorCmd struct{}
+
+func NewGetLockTimeCursorCmd() *GetLockTimeCursorCmd {
+	return &GetLockTimeCursorCmd{}
+}
+
 type GetBlockWeightCmd struct {
 	H string
 }
@@ -213,6 +249,26 @@ func NewIsBlueCmd(h string) *IsBlueCmd {
 	}
 }
 
+type GetBlockStateCmd struct {
+	H string
+}
+
+func NewGetBlockStateCmd(h string) *GetBlockStateCmd {
+	return &GetBlockStateCmd{
+		H: h,
+	}
+}
+
+type GetAnticoneCmd struct {
+	H string
+}
+
+func NewGetAnticoneCmd(h string) *GetAnticoneCmd {
+	return &GetAnticoneCmd{
+		H: h,
+	}
+}
+
 type IsCurrentCmd struct {
 }
 
@@ -244,6 +300,51 @@ func NewGetFeesCmd(h string) *GetFeesCmd {
 	}
 }
 
+type RescanRangeCmd struct {
+	Addrs       []string
+	Scripts     []string
+	Start       int64
+	End         int64
+	Descriptors *[]string
+	Range       *uint32
+}
+
+func NewRescanRangeCmd(addrs []string, scripts []string, start int64, end int64,
+	descriptors *[]string, descRange *uint32) *RescanRangeCmd {
+	return &RescanRangeCmd{
+		Addrs:       addrs,
+		Scripts:     scripts,
+		Start:       start,
+		End:         end,
+		Descriptors: descriptors,
+		Range:       descRange,
+	}
+}
+
+type GetNetworkHashPSCmd struct {
+	Window  *uint32
+	PowType *byte
+}
+
+func NewGetNetworkHashPSCmd(window *uint32, powType *byte) *GetNetworkHashPSCmd {
+	return &GetNetworkHashPSCmd{
+		Window:  window,
+		PowType: powType,
+	}
+}
+
+type GetDifficultyHistoryCmd struct {
+	Window  *uint32
+	PowType *byte
+}
+
+func NewGetDifficultyHistoryCmd(window *uint32, powType *byte) *GetDifficultyHistoryCmd {
+	return &GetDifficultyHistoryCmd{
+		Window:  window,
+		PowType: powType,
+	}
+}
+
 func init() {
 	flags := UsageFlag(0)
 
@@ -256,14 +357,23 @@ func init() {
 	MustRegisterCmd("getBestBlockHash", (*GetBestBlockHashCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("getBlockTotal", (*GetBlockTotalCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("getBlockHeader", (*GetBlockHeaderCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getHeaders", (*GetHeadersCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("isOnMainChain", (*IsOnMainChainCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("getMainChainHeight", (*GetMainChainHeightCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getMainChainTip", (*GetMainChainTipCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getMainChainBlockByHeight", (*GetMainChainBlockByHeightCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getLockTimeCursor", (*GetLockTimeCursorCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("getBlockWeight", (*GetBlockWeightCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("getOrphansTotal", (*GetOrphansTotalCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("getBlockByNum", (*GetBlockByNumCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("isBlue", (*IsBlueCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getBlockState", (*GetBlockStateCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getAnticone", (*GetAnticoneCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("isCurrent", (*IsCurrentCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("tips", (*TipsCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("getCoinbase", (*GetCoinbaseCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("getFees", (*GetFeesCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("rescanRange", (*RescanRangeCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getNetworkHashPS", (*GetNetworkHashPSCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getDifficultyHistory", (*GetDifficultyHistoryCmd)(nil), flags, DefaultServiceNameSpace)
 }
@@ -27,24 +27,137 @@ func NewSessionCmd() *SessionCmd {
 	return &SessionCmd{}
 }
 
-// TODO op
+// NotifyReceivedCmd registers the addresses for recvtx notifications of the
+// outputs paying to them.  The received outputs are watched for redeemingtx
+// notifications as well.  When FromOrder is set the blocks from that order up
+// to the latest one are scanned first, so that events missed while the client
+// was disconnected are delivered too.
 type NotifyReceivedCmd struct {
 	Addresses []string
+	FromOrder *uint64
 }
 
-func NewNotifyReceivedCmd(addresses []string) *NotifyReceivedCmd {
+func NewNotifyReceivedCmd(addresses []string, fromOrder *uint64) *NotifyReceivedCmd {
 	return &NotifyReceivedCmd{
 		Addresses: addresses,
+		FromOrder: fromOrder,
 	}
 }
 
+type StopNotifyReceivedCmd struct {
+	Addresses []string
+}
+
+func NewStopNotifyReceivedCmd(addresses []string) *StopNotifyReceivedCmd {
+	return &StopNotifyReceivedCmd{
+		Addresses: addresses,
+	}
+}
+
+// NotifySpentCmd registers the outpoints for redeemingtx notifications of the
+// transactions spending them.  FromOrder works as for NotifyReceivedCmd.
+type NotifySpentCmd struct {
+	OutPoints []OutPoint
+	FromOrder *uint64
+}
+
+func NewNotifySpentCmd(outPoints []OutPoint, fromOrder *uint64) *NotifySpentCmd {
+	return &NotifySpentCmd{
+		OutPoints: outPoints,
+		FromOrder: fromOrder,
+	}
+}
+
+type StopNotifySpentCmd struct {
+	OutPoints []OutPoint
+}
+
+func NewStopNotifySpentCmd(outPoints []OutPoint) *StopNotifySpentCmd {
+	return &StopNotifySpentCmd{
+		OutPoints: outPoints,
+	}
+}
+
+// ResumeSessionCmd resumes a former websocket session after a reconnect.  The
+// notifications following the cursor, the sequence number of the last
+// notification received, are replayed.
+type ResumeSessionCmd struct {
+	SessionID uint64
+	Cursor    uint64
+}
+
+func NewResumeSessionCmd(sessionID uint64, cursor uint64) *ResumeSessionCmd {
+	return &ResumeSessionCmd{
+		SessionID: sessionID,
+		Cursor:    cursor,
+	}
+}
+
+// SubscribeHeadersCmd subscribes to the headers of the connected blocks.  When
+// FromOrder is set the headers of the blocks from that order up to the latest
+// one are sent first.
+type SubscribeHeadersCmd struct {
+	FromOrder *uint64
+}
+
+func NewSubscribeHeadersCmd(fromOrder *uint64) *SubscribeHeadersCmd {
+	return &SubscribeHeadersCmd{
+		FromOrder: fromOrder,
+	}
+}
+
+type UnsubscribeHeadersCmd struct{}
+
+func NewUnsubscribeHeadersCmd() *UnsubscribeHeadersCmd {
+	return &UnsubscribeHeadersCmd{}
+}
+
+// SubscribeFeeHistogramCmd subscribes to the fee histogram of the mempool.
+// The current histogram is sent first, then the histogram is sent again
+// whenever it changes.
+type SubscribeFeeHistogramCmd struct{}
+
+func NewSubscribeFeeHistogramCmd() *SubscribeFeeHistogramCmd {
+	return &SubscribeFeeHistogramCmd{}
+}
+
+type UnsubscribeFeeHistogramCmd struct{}
+
+func NewUnsubscribeFeeHistogramCmd() *UnsubscribeFeeHistogramCmd {
+	return &UnsubscribeFeeHistogramCmd{}
+}
+
+// SubscribeDagCmd subscribes to the topology updates of the DAG: the blocks
+// added with their parents, the color flips and the order shifts.
+type SubscribeDagCmd struct{}
+
+func NewSubscribeDagCmd() *SubscribeDagCmd {
+	return &SubscribeDagCmd{}
+}
+
+type UnsubscribeDagCmd struct{}
+
+func NewUnsubscribeDagCmd() *UnsubscribeDagCmd {
+	return &UnsubscribeDagCmd{}
+}
+
 func init() {
 	// The commands in this file are only usable by websockets.
 	flags := UFWebsocketOnly
 
 	MustRegisterCmd("notifyBlocks", (*NotifyBlocksCmd)(nil), flags, NotifyNameSpace)
 	MustRegisterCmd("notifyReceived", (*NotifyReceivedCmd)(nil), flags, NotifyNameSpace)
+	MustRegisterCmd("stopNotifyReceived", (*StopNotifyReceivedCmd)(nil), flags, NotifyNameSpace)
+	MustRegisterCmd("notifySpent", (*NotifySpentCmd)(nil), flags, NotifyNameSpace)
+	MustRegisterCmd("stopNotifySpent", (*StopNotifySpentCmd)(nil), flags, NotifyNameSpace)
 	MustRegisterCmd("stopNotifyBlocks", (*StopNotifyBlocksCmd)(nil), flags, NotifyNameSpace)
 	MustRegisterCmd("session", (*SessionCmd)(nil), flags, NotifyNameSpace)
+	MustRegisterCmd("resumeSession", (*ResumeSessionCmd)(nil), flags, NotifyNameSpace)
 	MustRegisterCmd("rescan", (*RescanCmd)(nil), flags, NotifyNameSpace)
+	MustRegisterCmd("subscribeHeaders", (*SubscribeHeadersCmd)(nil), flags, NotifyNameSpace)
+	MustRegisterCmd("unsubscribeHeaders", (*UnsubscribeHeadersCmd)(nil), flags, NotifyNameSpace)
+	MustRegisterCmd("subscribeFeeHistogram", (*SubscribeFeeHistogramCmd)(nil), flags, NotifyNameSpace)
+	MustRegisterCmd("unsubscribeFeeHistogram", (*UnsubscribeFeeHistogramCmd)(nil), flags, NotifyNameSpace)
+	MustRegisterCmd("subscribeDag", (*SubscribeDagCmd)(nil), flags, NotifyNameSpace)
+	MustRegisterCmd("unsubscribeDag", (*UnsubscribeDagCmd)(nil), flags, NotifyNameSpace)
 }
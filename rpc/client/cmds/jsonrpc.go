@@ -15,6 +15,7 @@ const (
 	MinerNameSpace          = "miner"
 	TestNameSpace           = "test"
 	LogNameSpace            = "log"
+	FreezeNameSpace         = "freeze"
 	NotifyNameSpace         = ""
 )
 
@@ -151,6 +152,32 @@ var (
 		Code:    -32701,
 		Message: "Hex decode error",
 	}
+	// ErrRPCServer is the code of the errors of the methods which are
+	// not classified.
+	ErrRPCServer = &RPCError{
+		Code:    -32000,
+		Message: "Server error",
+	}
+	ErrRPCNotFound = &RPCError{
+		Code:    -32004,
+		Message: "Not found",
+	}
+	ErrRPCDuplicate = &RPCError{
+		Code:    -32005,
+		Message: "Duplicate",
+	}
+	ErrRPCRuleViolation = &RPCError{
+		Code:    -32006,
+		Message: "Rule violation",
+	}
+	ErrRPCDeserialization = &RPCError{
+		Code:    -32007,
+		Message: "Deserialization error",
+	}
+	ErrRPCUnavailable = &RPCError{
+		Code:    -32008,
+		Message: "Service unavailable",
+	}
 )
 
 func InternalRPCError(errStr, context string) *RPCError {
@@ -9,12 +9,26 @@ import "github.com/Qitmeer/qitmeer/core/types/pow"
 type GetBlockTemplateCmd struct {
 	Capabilities []string
 	PowType      byte
+	IncludeTxs   *[]string
+	ExcludeTxs   *[]string
 }
 
-func NewGetBlockTemplateCmd(capabilities []string, powType byte) *GetBlockTemplateCmd {
+func NewGetBlockTemplateCmd(capabilities []string, powType byte, includeTxs *[]string, excludeTxs *[]string) *GetBlockTemplateCmd {
 	return &GetBlockTemplateCmd{
 		Capabilities: capabilities,
 		PowType:      powType,
+		IncludeTxs:   includeTxs,
+		ExcludeTxs:   excludeTxs,
+	}
+}
+
+type GetBlockTemplateWitnessCmd struct {
+	PowType byte
+}
+
+func NewGetBlockTemplateWitnessCmd(powType byte) *GetBlockTemplateWitnessCmd {
+	return &GetBlockTemplateWitnessCmd{
+		PowType: powType,
 	}
 }
 
@@ -44,6 +58,7 @@ func init() {
 	flags := UsageFlag(0)
 
 	MustRegisterCmd("getBlockTemplate", (*GetBlockTemplateCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getBlockTemplateWitness", (*GetBlockTemplateWitnessCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("submitBlock", (*SubmitBlockCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("generate", (*GenerateCmd)(nil), flags, MinerNameSpace)
 }
@@ -24,6 +24,76 @@ func NewGetTimeInfoCmd() *GetTimeInfoCmd {
 	return &GetTimeInfoCmd{}
 }
 
+type GetNodeStatsCmd struct{}
+
+func NewGetNodeStatsCmd() *GetNodeStatsCmd {
+	return &GetNodeStatsCmd{}
+}
+
+type GetIndexBackfillInfoCmd struct{}
+
+func NewGetIndexBackfillInfoCmd() *GetIndexBackfillInfoCmd {
+	return &GetIndexBackfillInfoCmd{}
+}
+
+type GetBuildInfoCmd struct{}
+
+func NewGetBuildInfoCmd() *GetBuildInfoCmd {
+	return &GetBuildInfoCmd{}
+}
+
+type GetPeerMsgStatsCmd struct{}
+
+func NewGetPeerMsgStatsCmd() *GetPeerMsgStatsCmd {
+	return &GetPeerMsgStatsCmd{}
+}
+
+type GetSafeModeCmd struct{}
+
+func NewGetSafeModeCmd() *GetSafeModeCmd {
+	return &GetSafeModeCmd{}
+}
+
+type GetFollowerStatusCmd struct{}
+
+func NewGetFollowerStatusCmd() *GetFollowerStatusCmd {
+	return &GetFollowerStatusCmd{}
+}
+
+type GetSLOStatusCmd struct{}
+
+func NewGetSLOStatusCmd() *GetSLOStatusCmd {
+	return &GetSLOStatusCmd{}
+}
+
+type GetMetricsHistoryCmd struct {
+	Count *uint
+}
+
+func NewGetMetricsHistoryCmd(count *uint) *GetMetricsHistoryCmd {
+	return &GetMetricsHistoryCmd{
+		Count: count,
+	}
+}
+
+type AcknowledgeSafeModeCmd struct{}
+
+func NewAcknowledgeSafeModeCmd() *AcknowledgeSafeModeCmd {
+	return &AcknowledgeSafeModeCmd{}
+}
+
+type PerfReportCmd struct {
+	Minutes *uint32
+	Count   *uint32
+}
+
+func NewPerfReportCmd(minutes *uint32, count *uint32) *PerfReportCmd {
+	return &PerfReportCmd{
+		Minutes: minutes,
+		Count:   count,
+	}
+}
+
 type StopCmd struct{}
 
 func NewStopCmd() *StopCmd {
@@ -56,6 +126,46 @@ func NewSetRpcMaxClientsCmd(max int) *SetRpcMaxClientsCmd {
 	}
 }
 
+type GetAuditLogCmd struct {
+	Start *uint64
+	Count *uint32
+}
+
+func NewGetAuditLogCmd(start *uint64, count *uint32) *GetAuditLogCmd {
+	return &GetAuditLogCmd{
+		Start: start,
+		Count: count,
+	}
+}
+
+type UnlockKeystoreCmd struct {
+	Passphrase string
+	Timeout    *uint32
+}
+
+func NewUnlockKeystoreCmd(passphrase string, timeout *uint32) *UnlockKeystoreCmd {
+	return &UnlockKeystoreCmd{
+		Passphrase: passphrase,
+		Timeout:    timeout,
+	}
+}
+
+type LockKeystoreCmd struct{}
+
+func NewLockKeystoreCmd() *LockKeystoreCmd {
+	return &LockKeystoreCmd{}
+}
+
+type SendClusterHintCmd struct {
+	Hint string
+}
+
+func NewSendClusterHintCmd(hint string) *SendClusterHintCmd {
+	return &SendClusterHintCmd{
+		Hint: hint,
+	}
+}
+
 type CheckAddressCmd struct {
 	Address string
 	Network string
@@ -85,10 +195,24 @@ func init() {
 	MustRegisterCmd("getPeerInfo", (*GetPeerInfoCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("getRpcInfo", (*GetRpcInfoCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("getTimeInfo", (*GetTimeInfoCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getNodeStats", (*GetNodeStatsCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getIndexBackfillInfo", (*GetIndexBackfillInfoCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getBuildInfo", (*GetBuildInfoCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getPeerMsgStats", (*GetPeerMsgStatsCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getSafeMode", (*GetSafeModeCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getFollowerStatus", (*GetFollowerStatusCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getSLOStatus", (*GetSLOStatusCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getMetricsHistory", (*GetMetricsHistoryCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("perfReport", (*PerfReportCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("stop", (*StopCmd)(nil), flags, TestNameSpace)
 	MustRegisterCmd("banlist", (*BanlistCmd)(nil), flags, TestNameSpace)
 	MustRegisterCmd("removeBan", (*RemoveBanCmd)(nil), flags, TestNameSpace)
+	MustRegisterCmd("acknowledgeSafeMode", (*AcknowledgeSafeModeCmd)(nil), flags, TestNameSpace)
 	MustRegisterCmd("setRpcMaxClients", (*SetRpcMaxClientsCmd)(nil), flags, TestNameSpace)
+	MustRegisterCmd("getAuditLog", (*GetAuditLogCmd)(nil), flags, TestNameSpace)
+	MustRegisterCmd("unlockKeystore", (*UnlockKeystoreCmd)(nil), flags, TestNameSpace)
+	MustRegisterCmd("lockKeystore", (*LockKeystoreCmd)(nil), flags, TestNameSpace)
+	MustRegisterCmd("sendClusterHint", (*SendClusterHintCmd)(nil), flags, TestNameSpace)
 
 	MustRegisterCmd("checkAddress", (*CheckAddressCmd)(nil), flags, DefaultServiceNameSpace)
 
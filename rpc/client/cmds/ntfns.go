@@ -16,6 +16,16 @@ const (
 	RescanProgressNtfnMethod    = "rescanprocess"
 	RescanCompleteNtfnMethod    = "rescancomplete"
 	NodeExitMethod              = "nodeexit"
+	RecvTxNtfnMethod            = "recvtx"
+	RedeemingTxNtfnMethod       = "redeemingtx"
+	DoubleSpendProofNtfnMethod  = "doublespendproof"
+	HeadersNtfnMethod           = "headers"
+	TxEvictedNtfnMethod         = "txevicted"
+	MinedBlockStaleNtfnMethod   = "minedblockstale"
+	FeeHistogramNtfnMethod      = "feehistogram"
+	ChainReorgNtfnMethod        = "chainreorg"
+	DagNtfnMethod               = "dag"
+	ClusterHintNtfnMethod       = "clusterhint"
 )
 
 type BlockConnectedNtfn struct {
@@ -114,6 +124,131 @@ func NewTxAcceptedVerboseNtfn(tx json.DecodeRawTransactionResult) *TxAcceptedVer
 	}
 }
 
+// RecvTxNtfn is sent when a transaction pays to a watched address.  Block is
+// nil for a transaction accepted by the mempool.
+type RecvTxNtfn struct {
+	HexTx string
+	Block *BlockDetails
+}
+
+func NewRecvTxNtfn(hexTx string, block *BlockDetails) *RecvTxNtfn {
+	return &RecvTxNtfn{
+		HexTx: hexTx,
+		Block: block,
+	}
+}
+
+func NewRedeemingTxNtfn(hexTx string, block *BlockDetails) *RedeemingTxNtfn {
+	return &RedeemingTxNtfn{
+		HexTx: hexTx,
+		Block: block,
+	}
+}
+
+// DoubleSpendProofNtfn is sent when the mempool learns that an output spent
+// by one of its transactions was spent by another signed transaction.
+type DoubleSpendProofNtfn struct {
+	Proof json.DoubleSpendProofResult
+}
+
+func NewDoubleSpendProofNtfn(proof json.DoubleSpendProofResult) *DoubleSpendProofNtfn {
+	return &DoubleSpendProofNtfn{
+		Proof: proof,
+	}
+}
+
+// HeadersNtfn is sent to the clients which subscribed to the block headers,
+// for every connected block and for the blocks caught up on subscription.
+type HeadersNtfn struct {
+	Headers json.HeadersResult
+}
+
+func NewHeadersNtfn(headers json.HeadersResult) *HeadersNtfn {
+	return &HeadersNtfn{
+		Headers: headers,
+	}
+}
+
+// TxEvictedNtfn is sent when a transaction is evicted from the mempool
+// because it conflicts with the blocks of the main chain.
+type TxEvictedNtfn struct {
+	TxID   string
+	Reason string
+}
+
+func NewTxEvictedNtfn(txHash string, reason string) *TxEvictedNtfn {
+	return &TxEvictedNtfn{
+		TxID:   txHash,
+		Reason: reason,
+	}
+}
+
+// MinedBlockStaleNtfn is sent when a block mined by the node ends up red or
+// moved back in the order, with hints about the likely causes.
+type MinedBlockStaleNtfn struct {
+	Hash   string
+	Reason string
+	Hints  []string
+}
+
+func NewMinedBlockStaleNtfn(blockHash string, reason string, hints []string) *MinedBlockStaleNtfn {
+	return &MinedBlockStaleNtfn{
+		Hash:   blockHash,
+		Reason: reason,
+		Hints:  hints,
+	}
+}
+
+// FeeHistogramNtfn is sent to the clients which subscribed to the fee
+// histogram of the mempool when it changes.
+type FeeHistogramNtfn struct {
+	Histogram json.FeeHistogramResult
+}
+
+func NewFeeHistogramNtfn(histogram json.FeeHistogramResult) *FeeHistogramNtfn {
+	return &FeeHistogramNtfn{
+		Histogram: histogram,
+	}
+}
+
+// ChainReorgNtfn is sent to the clients which registered for the block
+// notifications when a reorganization changed the order of blocks.
+type ChainReorgNtfn struct {
+	Reorg json.ChainReorgResult
+}
+
+func NewChainReorgNtfn(reorg json.ChainReorgResult) *ChainReorgNtfn {
+	return &ChainReorgNtfn{
+		Reorg: reorg,
+	}
+}
+
+// DagNtfn is sent to the clients which subscribed to the DAG topology with
+// the events of an update of the DAG.
+type DagNtfn struct {
+	Events []json.DagEventResult
+}
+
+func NewDagNtfn(events []json.DagEventResult) *DagNtfn {
+	return &DagNtfn{
+		Events: events,
+	}
+}
+
+// ClusterHintNtfn is sent to the clients which registered for the block
+// notifications when a peer of the mining cluster sent a template hint.
+type ClusterHintNtfn struct {
+	Peer string
+	Hint string
+}
+
+func NewClusterHintNtfn(peer string, hint string) *ClusterHintNtfn {
+	return &ClusterHintNtfn{
+		Peer: peer,
+		Hint: hint,
+	}
+}
+
 func init() {
 	flags := UFWebsocketOnly | UFNotification
 
@@ -127,4 +262,14 @@ func init() {
 	MustRegisterCmd(RescanProgressNtfnMethod, (*RescanProgressNtfn)(nil), flags, NotifyNameSpace)
 	MustRegisterCmd(RescanCompleteNtfnMethod, (*RescanFinishedNtfn)(nil), flags, NotifyNameSpace)
 	MustRegisterCmd(NodeExitMethod, (*NodeExitNtfn)(nil), flags, NotifyNameSpace)
+	MustRegisterCmd(RecvTxNtfnMethod, (*RecvTxNtfn)(nil), flags, NotifyNameSpace)
+	MustRegisterCmd(RedeemingTxNtfnMethod, (*RedeemingTxNtfn)(nil), flags, NotifyNameSpace)
+	MustRegisterCmd(DoubleSpendProofNtfnMethod, (*DoubleSpendProofNtfn)(nil), flags, NotifyNameSpace)
+	MustRegisterCmd(HeadersNtfnMethod, (*HeadersNtfn)(nil), flags, NotifyNameSpace)
+	MustRegisterCmd(TxEvictedNtfnMethod, (*TxEvictedNtfn)(nil), flags, NotifyNameSpace)
+	MustRegisterCmd(MinedBlockStaleNtfnMethod, (*MinedBlockStaleNtfn)(nil), flags, NotifyNameSpace)
+	MustRegisterCmd(FeeHistogramNtfnMethod, (*FeeHistogramNtfn)(nil), flags, NotifyNameSpace)
+	MustRegisterCmd(ChainReorgNtfnMethod, (*ChainReorgNtfn)(nil), flags, NotifyNameSpace)
+	MustRegisterCmd(DagNtfnMethod, (*DagNtfn)(nil), flags, NotifyNameSpace)
+	MustRegisterCmd(ClusterHintNtfnMethod, (*ClusterHintNtfn)(nil), flags, NotifyNameSpace)
 }
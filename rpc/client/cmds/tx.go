@@ -40,6 +40,18 @@ func NewSendRawTransactionCmd(hexTx string, allowHighFees bool) *SendRawTransact
 	}
 }
 
+type SubmitTxPackageCmd struct {
+	HexTxs        []string
+	AllowHighFees bool
+}
+
+func NewSubmitTxPackageCmd(hexTxs []string, allowHighFees bool) *SubmitTxPackageCmd {
+	return &SubmitTxPackageCmd{
+		HexTxs:        hexTxs,
+		AllowHighFees: allowHighFees,
+	}
+}
+
 type GetRawTransactionCmd struct {
 	TxHash  string
 	Verbose bool
@@ -88,6 +100,53 @@ func NewGetRawTransactionsCmd(addre string, vinext bool, count uint, skip uint,
 	}
 }
 
+type ScanUtxoSetCmd struct {
+	Descriptors []string
+	Cursor      *string
+	MaxEntries  *uint
+	Range       *uint32
+}
+
+func NewScanUtxoSetCmd(descriptors []string, cursor *string, maxEntries *uint, descRange *uint32) *ScanUtxoSetCmd {
+	return &ScanUtxoSetCmd{
+		Descriptors: descriptors,
+		Cursor:      cursor,
+		MaxEntries:  maxEntries,
+		Range:       descRange,
+	}
+}
+
+type GetTxOutSetInfoCmd struct{}
+
+func NewGetTxOutSetInfoCmd() *GetTxOutSetInfoCmd {
+	return &GetTxOutSetInfoCmd{}
+}
+
+type GetRawTransactionsByAddressCmd struct {
+	Address    string
+	StartOrder *uint32
+	EndOrder   *uint32
+	Direction  *string
+	CoinId     *uint16
+	Count      *uint
+	Cursor     *string
+	Reverse    *bool
+}
+
+func NewGetRawTransactionsByAddressCmd(address string, startOrder *uint32, endOrder *uint32, direction *string,
+	coinId *uint16, count *uint, cursor *string, reverse *bool) *GetRawTransactionsByAddressCmd {
+	return &GetRawTransactionsByAddressCmd{
+		Address:    address,
+		StartOrder: startOrder,
+		EndOrder:   endOrder,
+		Direction:  direction,
+		CoinId:     coinId,
+		Count:      count,
+		Cursor:     cursor,
+		Reverse:    reverse,
+	}
+}
+
 type TxSignCmd struct {
 	PrivkeyStr string
 	RawTxStr   string
@@ -112,6 +171,290 @@ func NewGetMempoolCmd(txType string, verbose bool) *GetMempoolCmd {
 	}
 }
 
+type GetMempoolStatsCmd struct{}
+
+func NewGetMempoolStatsCmd() *GetMempoolStatsCmd {
+	return &GetMempoolStatsCmd{}
+}
+
+type GetFeeHistogramCmd struct{}
+
+func NewGetFeeHistogramCmd() *GetFeeHistogramCmd {
+	return &GetFeeHistogramCmd{}
+}
+
+type GetDoubleSpendProofsCmd struct {
+	TxID *string
+}
+
+func NewGetDoubleSpendProofsCmd(txID *string) *GetDoubleSpendProofsCmd {
+	return &GetDoubleSpendProofsCmd{
+		TxID: txID,
+	}
+}
+
+type DumpMempoolCmd struct {
+	File *string
+}
+
+func NewDumpMempoolCmd(file *string) *DumpMempoolCmd {
+	return &DumpMempoolCmd{
+		File: file,
+	}
+}
+
+type LoadMempoolCmd struct {
+	File *string
+}
+
+func NewLoadMempoolCmd(file *string) *LoadMempoolCmd {
+	return &LoadMempoolCmd{
+		File: file,
+	}
+}
+
+type DiffMempoolCmd struct {
+	File1 string
+	File2 string
+}
+
+func NewDiffMempoolCmd(file1 string, file2 string) *DiffMempoolCmd {
+	return &DiffMempoolCmd{
+		File1: file1,
+		File2: file2,
+	}
+}
+
+type IsTxSafeToCreditCmd struct {
+	TxHash             string
+	RequiredConfidence *uint32
+}
+
+func NewIsTxSafeToCreditCmd(txHash string, requiredConfidence *uint32) *IsTxSafeToCreditCmd {
+	return &IsTxSafeToCreditCmd{
+		TxHash:             txHash,
+		RequiredConfidence: requiredConfidence,
+	}
+}
+
+type CreateSpendProposalCmd struct {
+	RedeemScript string
+	Inputs       []json.TransactionInput
+	Amounts      json.AdreesAmount
+	LockTime     *int64
+}
+
+func NewCreateSpendProposalCmd(redeemScript string, inputs []json.TransactionInput, amounts json.AdreesAmount, lockTime *int64) *CreateSpendProposalCmd {
+	return &CreateSpendProposalCmd{
+		RedeemScript: redeemScript,
+		Inputs:       inputs,
+		Amounts:      amounts,
+		LockTime:     lockTime,
+	}
+}
+
+type GetSpendProposalCmd struct {
+	Id string
+}
+
+func NewGetSpendProposalCmd(id string) *GetSpendProposalCmd {
+	return &GetSpendProposalCmd{
+		Id: id,
+	}
+}
+
+type ImportSpendProposalCmd struct {
+	Psbt string
+}
+
+func NewImportSpendProposalCmd(psbt string) *ImportSpendProposalCmd {
+	return &ImportSpendProposalCmd{
+		Psbt: psbt,
+	}
+}
+
+type BroadcastSpendProposalCmd struct {
+	Id            string
+	AllowHighFees *bool
+}
+
+func NewBroadcastSpendProposalCmd(id string, allowHighFees *bool) *BroadcastSpendProposalCmd {
+	return &BroadcastSpendProposalCmd{
+		Id:            id,
+		AllowHighFees: allowHighFees,
+	}
+}
+
+type SignSpendProposalCmd struct {
+	PrivkeyStr string
+	Psbt       string
+}
+
+func NewSignSpendProposalCmd(privkeyStr string, psbt string) *SignSpendProposalCmd {
+	return &SignSpendProposalCmd{
+		PrivkeyStr: privkeyStr,
+		Psbt:       psbt,
+	}
+}
+
+type ExternalSignSpendProposalCmd struct {
+	Id string
+}
+
+func NewExternalSignSpendProposalCmd(id string) *ExternalSignSpendProposalCmd {
+	return &ExternalSignSpendProposalCmd{
+		Id: id,
+	}
+}
+
+type DebugScriptCmd struct {
+	SignScript string
+	PkScript   string
+	HexTx      *string
+	Index      *uint32
+}
+
+func NewDebugScriptCmd(signScript string, pkScript string, hexTx *string, index *uint32) *DebugScriptCmd {
+	return &DebugScriptCmd{
+		SignScript: signScript,
+		PkScript:   pkScript,
+		HexTx:      hexTx,
+		Index:      index,
+	}
+}
+
+type GetAddressActivityCmd struct {
+	Address string
+}
+
+func NewGetAddressActivityCmd(address string) *GetAddressActivityCmd {
+	return &GetAddressActivityCmd{
+		Address: address,
+	}
+}
+
+type AnalyzeAddressCmd struct {
+	Address string
+}
+
+func NewAnalyzeAddressCmd(address string) *AnalyzeAddressCmd {
+	return &AnalyzeAddressCmd{
+		Address: address,
+	}
+}
+
+type GetUtxoAgeDistributionCmd struct {
+	CoinId       *uint16
+	BucketOrders *uint32
+}
+
+func NewGetUtxoAgeDistributionCmd(coinId *uint16, bucketOrders *uint32) *GetUtxoAgeDistributionCmd {
+	return &GetUtxoAgeDistributionCmd{
+		CoinId:       coinId,
+		BucketOrders: bucketOrders,
+	}
+}
+
+type GetMinerStatsCmd struct {
+	Address    string
+	StartOrder *uint32
+	EndOrder   *uint32
+	Verbose    *bool
+}
+
+func NewGetMinerStatsCmd(address string, startOrder *uint32, endOrder *uint32, verbose *bool) *GetMinerStatsCmd {
+	return &GetMinerStatsCmd{
+		Address:    address,
+		StartOrder: startOrder,
+		EndOrder:   endOrder,
+		Verbose:    verbose,
+	}
+}
+
+type FundRawTransactionCmd struct {
+	HexTx         string
+	FeeRate       *int64
+	Strategy      *string
+	ChangeAddress *string
+}
+
+func NewFundRawTransactionCmd(hexTx string, feeRate *int64, strategy *string, changeAddress *string) *FundRawTransactionCmd {
+	return &FundRawTransactionCmd{
+		HexTx:         hexTx,
+		FeeRate:       feeRate,
+		Strategy:      strategy,
+		ChangeAddress: changeAddress,
+	}
+}
+
+type ConsolidateUtxosCmd struct {
+	Address      string
+	FeeRate      *int64
+	MaxValue     *int64
+	MaxInputs    *uint32
+	RedeemScript *string
+}
+
+func NewConsolidateUtxosCmd(address string, feeRate *int64, maxValue *int64, maxInputs *uint32, redeemScript *string) *ConsolidateUtxosCmd {
+	return &ConsolidateUtxosCmd{
+		Address:      address,
+		FeeRate:      feeRate,
+		MaxValue:     maxValue,
+		MaxInputs:    maxInputs,
+		RedeemScript: redeemScript,
+	}
+}
+
+type SetAddressLabelCmd struct {
+	Address string
+	Label   string
+}
+
+func NewSetAddressLabelCmd(address string, label string) *SetAddressLabelCmd {
+	return &SetAddressLabelCmd{
+		Address: address,
+		Label:   label,
+	}
+}
+
+type GetAddressesByLabelCmd struct {
+	Label string
+}
+
+func NewGetAddressesByLabelCmd(label string) *GetAddressesByLabelCmd {
+	return &GetAddressesByLabelCmd{
+		Label: label,
+	}
+}
+
+type FreezeCoinsCmd struct {
+	Targets []string
+	Reason  *string
+}
+
+func NewFreezeCoinsCmd(targets []string, reason *string) *FreezeCoinsCmd {
+	return &FreezeCoinsCmd{
+		Targets: targets,
+		Reason:  reason,
+	}
+}
+
+type UnfreezeCoinsCmd struct {
+	Targets []string
+}
+
+func NewUnfreezeCoinsCmd(targets []string) *UnfreezeCoinsCmd {
+	return &UnfreezeCoinsCmd{
+		Targets: targets,
+	}
+}
+
+type ListFrozenCoinsCmd struct{}
+
+func NewListFrozenCoinsCmd() *ListFrozenCoinsCmd {
+	return &ListFrozenCoinsCmd{}
+}
+
 // ws
 type NotifyNewTransactionsCmd struct {
 	Verbose bool
@@ -193,12 +536,42 @@ func init() {
 	MustRegisterCmd("createRawTransaction", (*CreateRawTransactionCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("decodeRawTransaction", (*DecodeRawTransactionCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("sendRawTransaction", (*SendRawTransactionCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("submitTxPackage", (*SubmitTxPackageCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("getRawTransaction", (*GetRawTransactionCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("getUtxo", (*GetUtxoCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("scanUtxoSet", (*ScanUtxoSetCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getTxOutSetInfo", (*GetTxOutSetInfoCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("getRawTransactions", (*GetRawTransactionsCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getRawTransactionsByAddress", (*GetRawTransactionsByAddressCmd)(nil), flags, DefaultServiceNameSpace)
 	MustRegisterCmd("txSign", (*TxSignCmd)(nil), flags, TestNameSpace)
 
 	MustRegisterCmd("getMempool", (*GetMempoolCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getMempoolStats", (*GetMempoolStatsCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getFeeHistogram", (*GetFeeHistogramCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getDoubleSpendProofs", (*GetDoubleSpendProofsCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("isTxSafeToCredit", (*IsTxSafeToCreditCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("dumpMempool", (*DumpMempoolCmd)(nil), flags, TestNameSpace)
+	MustRegisterCmd("loadMempool", (*LoadMempoolCmd)(nil), flags, TestNameSpace)
+	MustRegisterCmd("diffMempool", (*DiffMempoolCmd)(nil), flags, TestNameSpace)
+
+	MustRegisterCmd("createSpendProposal", (*CreateSpendProposalCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getSpendProposal", (*GetSpendProposalCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("importSpendProposal", (*ImportSpendProposalCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("broadcastSpendProposal", (*BroadcastSpendProposalCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("signSpendProposal", (*SignSpendProposalCmd)(nil), flags, TestNameSpace)
+	MustRegisterCmd("externalSignSpendProposal", (*ExternalSignSpendProposalCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("debugScript", (*DebugScriptCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getAddressActivity", (*GetAddressActivityCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("analyzeAddress", (*AnalyzeAddressCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getUtxoAgeDistribution", (*GetUtxoAgeDistributionCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("getMinerStats", (*GetMinerStatsCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("fundRawTransaction", (*FundRawTransactionCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("consolidateUtxos", (*ConsolidateUtxosCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("setAddressLabel", (*SetAddressLabelCmd)(nil), flags, TestNameSpace)
+	MustRegisterCmd("getAddressesByLabel", (*GetAddressesByLabelCmd)(nil), flags, DefaultServiceNameSpace)
+	MustRegisterCmd("freezeCoins", (*FreezeCoinsCmd)(nil), flags, FreezeNameSpace)
+	MustRegisterCmd("unfreezeCoins", (*UnfreezeCoinsCmd)(nil), flags, FreezeNameSpace)
+	MustRegisterCmd("listFrozenCoins", (*ListFrozenCoinsCmd)(nil), flags, FreezeNameSpace)
 
 	// ws
 	MustRegisterCmd("notifynewtransactions", (*NotifyNewTransactionsCmd)(nil), UFWebsocketOnly, NotifyNameSpace)
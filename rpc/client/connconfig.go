@@ -5,6 +5,7 @@
 package client
 
 import (
+	"net"
 	"os"
 	"time"
 )
@@ -14,6 +15,12 @@ type ConnConfig struct {
 	// to.
 	Host string
 
+	// UnixSocket is the path of the Unix domain socket of the RPC server
+	// to connect to instead of Host.  The connections through the socket
+	// need neither TLS nor authentication, so DisableTLS, User, Pass and
+	// CookiePath are ignored when it is set.
+	UnixSocket string
+
 	// Endpoint is the websocket endpoint on the RPC server.  This is
 	// typically "ws".
 	Endpoint string
@@ -75,6 +82,11 @@ type ConnConfig struct {
 }
 
 func (config *ConnConfig) getAuth() (username, passphrase string, err error) {
+	// The Unix socket of the server needs no authentication.
+	if config.UnixSocket != "" {
+		return "", "", nil
+	}
+
 	// Try username+passphrase auth first.
 	if config.Pass != "" {
 		return config.User, config.Pass, nil
@@ -105,3 +117,24 @@ func (config *ConnConfig) retrieveCookie() (username, passphrase string, err err
 
 	return config.cookieLastUser, config.cookieLastPass, config.cookieLastErr
 }
+
+// useTLS returns whether the connections to the RPC server use TLS.
+func (config *ConnConfig) useTLS() bool {
+	return !config.DisableTLS && config.UnixSocket == ""
+}
+
+// host returns the host put in the URLs of the requests to the RPC server.
+func (config *ConnConfig) host() string {
+	if config.UnixSocket != "" {
+		return "localhost"
+	}
+	return config.Host
+}
+
+// netDial dials the RPC server, through its Unix socket when one is set.
+func (config *ConnConfig) netDial(network, addr string) (net.Conn, error) {
+	if config.UnixSocket != "" {
+		return net.Dial("unix", config.UnixSocket)
+	}
+	return net.Dial(network, addr)
+}
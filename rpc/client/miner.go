@@ -22,13 +22,37 @@ func (r FutureGetBlockTemplateResult) Receive() (*j.GetBlockTemplateResult, erro
 	return &template, nil
 }
 
-func (c *Client) GetBlockTemplateAsync(capabilities []string, powType byte) FutureGetBlockTemplateResult {
-	cmd := cmds.NewGetBlockTemplateCmd(capabilities, powType)
+func (c *Client) GetBlockTemplateAsync(capabilities []string, powType byte, includeTxs *[]string, excludeTxs *[]string) FutureGetBlockTemplateResult {
+	cmd := cmds.NewGetBlockTemplateCmd(capabilities, powType, includeTxs, excludeTxs)
 	return c.sendCmd(cmd)
 }
 
-func (c *Client) GetBlockTemplate(capabilities []string, powType byte) (*j.GetBlockTemplateResult, error) {
-	return c.GetBlockTemplateAsync(capabilities, powType).Receive()
+func (c *Client) GetBlockTemplate(capabilities []string, powType byte, includeTxs *[]string, excludeTxs *[]string) (*j.GetBlockTemplateResult, error) {
+	return c.GetBlockTemplateAsync(capabilities, powType, includeTxs, excludeTxs).Receive()
+}
+
+type FutureGetBlockTemplateWitnessResult chan *response
+
+func (r FutureGetBlockTemplateWitnessResult) Receive() (*j.BlockTemplateWitnessResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+	var witness j.BlockTemplateWitnessResult
+	err = json.Unmarshal(res, &witness)
+	if err != nil {
+		return nil, err
+	}
+	return &witness, nil
+}
+
+func (c *Client) GetBlockTemplateWitnessAsync(powType byte) FutureGetBlockTemplateWitnessResult {
+	cmd := cmds.NewGetBlockTemplateWitnessCmd(powType)
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) GetBlockTemplateWitness(powType byte) (*j.BlockTemplateWitnessResult, error) {
+	return c.GetBlockTemplateWitnessAsync(powType).Receive()
 }
 
 type FutureSubmitBlockResult chan *response
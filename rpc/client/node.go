@@ -111,6 +111,242 @@ func (c *Client) GetRpcInfo() (*cmds.JsonRequestStatus, error) {
 	return c.GetRpcInfoAsync().Receive()
 }
 
+type FutureGetNodeStatsResult chan *response
+
+func (r FutureGetNodeStatsResult) Receive() (*j.NodeStatsResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.NodeStatsResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) GetNodeStatsAsync() FutureGetNodeStatsResult {
+	cmd := cmds.NewGetNodeStatsCmd()
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) GetNodeStats() (*j.NodeStatsResult, error) {
+	return c.GetNodeStatsAsync().Receive()
+}
+
+type FuturePerfReportResult chan *response
+
+func (r FuturePerfReportResult) Receive() (*j.PerfReportResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.PerfReportResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) PerfReportAsync(minutes *uint32, count *uint32) FuturePerfReportResult {
+	cmd := cmds.NewPerfReportCmd(minutes, count)
+	return c.sendCmd(cmd)
+}
+
+// PerfReport returns a performance report of the last minutes, 10 when
+// minutes is nil, with at most count entries in each list.
+func (c *Client) PerfReport(minutes *uint32, count *uint32) (*j.PerfReportResult, error) {
+	return c.PerfReportAsync(minutes, count).Receive()
+}
+
+type FutureGetIndexBackfillInfoResult chan *response
+
+func (r FutureGetIndexBackfillInfoResult) Receive() ([]j.IndexBackfillResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []j.IndexBackfillResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (c *Client) GetIndexBackfillInfoAsync() FutureGetIndexBackfillInfoResult {
+	cmd := cmds.NewGetIndexBackfillInfoCmd()
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) GetIndexBackfillInfo() ([]j.IndexBackfillResult, error) {
+	return c.GetIndexBackfillInfoAsync().Receive()
+}
+
+type FutureGetBuildInfoResult chan *response
+
+func (r FutureGetBuildInfoResult) Receive() (*j.BuildInfoResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.BuildInfoResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) GetBuildInfoAsync() FutureGetBuildInfoResult {
+	cmd := cmds.NewGetBuildInfoCmd()
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) GetBuildInfo() (*j.BuildInfoResult, error) {
+	return c.GetBuildInfoAsync().Receive()
+}
+
+type FutureGetPeerMsgStatsResult chan *response
+
+func (r FutureGetPeerMsgStatsResult) Receive() ([]*j.PeerMsgStatsResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*j.PeerMsgStatsResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (c *Client) GetPeerMsgStatsAsync() FutureGetPeerMsgStatsResult {
+	cmd := cmds.NewGetPeerMsgStatsCmd()
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) GetPeerMsgStats() ([]*j.PeerMsgStatsResult, error) {
+	return c.GetPeerMsgStatsAsync().Receive()
+}
+
+type FutureGetSafeModeResult chan *response
+
+func (r FutureGetSafeModeResult) Receive() (*j.SafeModeResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.SafeModeResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) GetSafeModeAsync() FutureGetSafeModeResult {
+	cmd := cmds.NewGetSafeModeCmd()
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) GetSafeMode() (*j.SafeModeResult, error) {
+	return c.GetSafeModeAsync().Receive()
+}
+
+type FutureGetFollowerStatusResult chan *response
+
+func (r FutureGetFollowerStatusResult) Receive() (*j.FollowerStatusResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.FollowerStatusResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) GetFollowerStatusAsync() FutureGetFollowerStatusResult {
+	cmd := cmds.NewGetFollowerStatusCmd()
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) GetFollowerStatus() (*j.FollowerStatusResult, error) {
+	return c.GetFollowerStatusAsync().Receive()
+}
+
+type FutureGetSLOStatusResult chan *response
+
+func (r FutureGetSLOStatusResult) Receive() (*j.SLOStatusResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.SLOStatusResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) GetSLOStatusAsync() FutureGetSLOStatusResult {
+	cmd := cmds.NewGetSLOStatusCmd()
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) GetSLOStatus() (*j.SLOStatusResult, error) {
+	return c.GetSLOStatusAsync().Receive()
+}
+
+type FutureGetMetricsHistoryResult chan *response
+
+func (r FutureGetMetricsHistoryResult) Receive() (*j.MetricsHistoryResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.MetricsHistoryResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) GetMetricsHistoryAsync(count *uint) FutureGetMetricsHistoryResult {
+	cmd := cmds.NewGetMetricsHistoryCmd(count)
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) GetMetricsHistory(count *uint) (*j.MetricsHistoryResult, error) {
+	return c.GetMetricsHistoryAsync(count).Receive()
+}
+
 type FutureGetTimeInfoResult chan *response
 
 func (r FutureGetTimeInfoResult) Receive() (string, error) {
@@ -215,6 +451,126 @@ func (c *Client) RemoveBan(id string) (bool, error) {
 	return c.RemoveBanAsync(id).Receive()
 }
 
+type FutureAcknowledgeSafeModeResult chan *response
+
+func (r FutureAcknowledgeSafeModeResult) Receive() (bool, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return false, err
+	}
+
+	var result bool
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return false, err
+	}
+
+	return result, nil
+}
+
+func (c *Client) AcknowledgeSafeModeAsync() FutureAcknowledgeSafeModeResult {
+	cmd := cmds.NewAcknowledgeSafeModeCmd()
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) AcknowledgeSafeMode() (bool, error) {
+	return c.AcknowledgeSafeModeAsync().Receive()
+}
+
+type FutureGetAuditLogResult chan *response
+
+func (r FutureGetAuditLogResult) Receive() ([]*j.AuditEntryResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*j.AuditEntryResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (c *Client) GetAuditLogAsync(start *uint64, count *uint32) FutureGetAuditLogResult {
+	cmd := cmds.NewGetAuditLogCmd(start, count)
+	return c.sendCmd(cmd)
+}
+
+// GetAuditLog returns the entries of the audit log of the state-changing RPC
+// methods, starting at the entry id start or the last entries when start is
+// nil.
+func (c *Client) GetAuditLog(start *uint64, count *uint32) ([]*j.AuditEntryResult, error) {
+	return c.GetAuditLogAsync(start, count).Receive()
+}
+
+type FutureKeystoreResult chan *response
+
+func (r FutureKeystoreResult) Receive() (bool, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return false, err
+	}
+
+	var result bool
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return false, err
+	}
+
+	return result, nil
+}
+
+func (c *Client) UnlockKeystoreAsync(passphrase string, timeout *uint32) FutureKeystoreResult {
+	cmd := cmds.NewUnlockKeystoreCmd(passphrase, timeout)
+	return c.sendCmd(cmd)
+}
+
+// UnlockKeystore unlocks the keystore of the node secrets for timeout seconds.
+func (c *Client) UnlockKeystore(passphrase string, timeout *uint32) (bool, error) {
+	return c.UnlockKeystoreAsync(passphrase, timeout).Receive()
+}
+
+func (c *Client) LockKeystoreAsync() FutureKeystoreResult {
+	cmd := cmds.NewLockKeystoreCmd()
+	return c.sendCmd(cmd)
+}
+
+// LockKeystore locks the keystore of the node secrets.
+func (c *Client) LockKeystore() (bool, error) {
+	return c.LockKeystoreAsync().Receive()
+}
+
+type FutureSendClusterHintResult chan *response
+
+func (r FutureSendClusterHintResult) Receive() (int, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return 0, err
+	}
+
+	var result int
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return 0, err
+	}
+
+	return result, nil
+}
+
+func (c *Client) SendClusterHintAsync(hint string) FutureSendClusterHintResult {
+	cmd := cmds.NewSendClusterHintCmd(hint)
+	return c.sendCmd(cmd)
+}
+
+// SendClusterHint sends the hex encoded template hint to the connected peers
+// of the mining cluster and returns the number of peers it was sent to.
+func (c *Client) SendClusterHint(hint string) (int, error) {
+	return c.SendClusterHintAsync(hint).Receive()
+}
+
 type FutureSetRpcMaxClientsResult chan *response
 
 func (r FutureSetRpcMaxClientsResult) Receive() (int, error) {
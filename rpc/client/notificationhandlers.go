@@ -27,6 +27,16 @@ type NotificationHandlers struct {
 	OnRescanProgress    func(param *cmds.RescanProgressNtfn)
 	OnRescanFinish      func(param *cmds.RescanFinishedNtfn)
 	OnNodeExit          func(nodeExit *cmds.NodeExitNtfn)
+	OnRecvTx            func(tx *types.Transaction, block *cmds.BlockDetails)
+	OnRedeemingTx       func(tx *types.Transaction, block *cmds.BlockDetails)
+	OnDoubleSpendProof  func(proof *j.DoubleSpendProofResult)
+	OnHeaders           func(headers *j.HeadersResult)
+	OnTxEvicted         func(hash *hash.Hash, reason string)
+	OnMinedBlockStale   func(hash *hash.Hash, reason string, hints []string)
+	OnFeeHistogram      func(histogram *j.FeeHistogramResult)
+	OnChainReorg        func(reorg *j.ChainReorgResult)
+	OnDag               func(events []j.DagEventResult)
+	OnClusterHint       func(peer string, hint []byte)
 
 	OnUnknownNotification func(method string, params []json.RawMessage)
 }
@@ -262,3 +272,180 @@ func parseRescanFinish(params []json.RawMessage) (*cmds.RescanFinishedNtfn,
 		LastTxHash: lastTxHash,
 	}, nil
 }
+
+// parseWatchedTxNtfnParams parses the parameters of a recvtx or redeemingtx
+// notification.  The block details are nil for a mempool transaction.
+func parseWatchedTxNtfnParams(params []json.RawMessage) (*types.Transaction,
+	*cmds.BlockDetails, error) {
+
+	if len(params) == 0 || len(params) > 2 {
+		return nil, nil, wrongNumParams(len(params))
+	}
+
+	var txHex string
+	err := json.Unmarshal(params[0], &txHex)
+	if err != nil {
+		return nil, nil, err
+	}
+	serializedTx, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, nil, err
+	}
+	var tx types.Transaction
+	err = tx.Deserialize(bytes.NewReader(serializedTx))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var block *cmds.BlockDetails
+	if len(params) > 1 {
+		err = json.Unmarshal(params[1], &block)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return &tx, block, nil
+}
+
+// parseDoubleSpendProofNtfnParams parses the parameters of a doublespendproof
+// notification.
+func parseDoubleSpendProofNtfnParams(params []json.RawMessage) (*j.DoubleSpendProofResult,
+	error) {
+
+	if len(params) != 1 {
+		return nil, wrongNumParams(len(params))
+	}
+	var proof j.DoubleSpendProofResult
+	err := json.Unmarshal(params[0], &proof)
+	if err != nil {
+		return nil, err
+	}
+	return &proof, nil
+}
+
+// parseHeadersNtfnParams parses the parameters of a headers notification.
+func parseHeadersNtfnParams(params []json.RawMessage) (*j.HeadersResult, error) {
+	if len(params) != 1 {
+		return nil, wrongNumParams(len(params))
+	}
+	var headers j.HeadersResult
+	err := json.Unmarshal(params[0], &headers)
+	if err != nil {
+		return nil, err
+	}
+	return &headers, nil
+}
+
+// parseTxEvictedNtfnParams parses the parameters of a txevicted notification.
+func parseTxEvictedNtfnParams(params []json.RawMessage) (*hash.Hash, string, error) {
+	if len(params) != 2 {
+		return nil, "", wrongNumParams(len(params))
+	}
+	var txHashStr string
+	err := json.Unmarshal(params[0], &txHashStr)
+	if err != nil {
+		return nil, "", err
+	}
+	var reason string
+	err = json.Unmarshal(params[1], &reason)
+	if err != nil {
+		return nil, "", err
+	}
+	txHash, err := hash.NewHashFromStr(txHashStr)
+	if err != nil {
+		return nil, "", err
+	}
+	return txHash, reason, nil
+}
+
+// parseMinedBlockStaleNtfnParams parses the parameters of a minedblockstale
+// notification.
+func parseMinedBlockStaleNtfnParams(params []json.RawMessage) (*hash.Hash, string, []string, error) {
+	if len(params) != 3 {
+		return nil, "", nil, wrongNumParams(len(params))
+	}
+	var blockHashStr string
+	err := json.Unmarshal(params[0], &blockHashStr)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	var reason string
+	err = json.Unmarshal(params[1], &reason)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	var hints []string
+	err = json.Unmarshal(params[2], &hints)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	blockHash, err := hash.NewHashFromStr(blockHashStr)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return blockHash, reason, hints, nil
+}
+
+// parseFeeHistogramNtfnParams parses the parameters of a feehistogram
+// notification.
+func parseFeeHistogramNtfnParams(params []json.RawMessage) (*j.FeeHistogramResult, error) {
+	if len(params) != 1 {
+		return nil, wrongNumParams(len(params))
+	}
+	var histogram j.FeeHistogramResult
+	err := json.Unmarshal(params[0], &histogram)
+	if err != nil {
+		return nil, err
+	}
+	return &histogram, nil
+}
+
+// parseChainReorgNtfnParams parses the parameters of a chainreorg
+// notification.
+func parseChainReorgNtfnParams(params []json.RawMessage) (*j.ChainReorgResult, error) {
+	if len(params) != 1 {
+		return nil, wrongNumParams(len(params))
+	}
+	var reorg j.ChainReorgResult
+	err := json.Unmarshal(params[0], &reorg)
+	if err != nil {
+		return nil, err
+	}
+	return &reorg, nil
+}
+
+// parseDagNtfnParams parses the parameters of a dag notification.
+func parseDagNtfnParams(params []json.RawMessage) ([]j.DagEventResult, error) {
+	if len(params) != 1 {
+		return nil, wrongNumParams(len(params))
+	}
+	var events []j.DagEventResult
+	err := json.Unmarshal(params[0], &events)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// parseClusterHintNtfnParams parses the parameters of a clusterhint
+// notification.
+func parseClusterHintNtfnParams(params []json.RawMessage) (string, []byte, error) {
+	if len(params) != 2 {
+		return "", nil, wrongNumParams(len(params))
+	}
+	var peer string
+	err := json.Unmarshal(params[0], &peer)
+	if err != nil {
+		return "", nil, err
+	}
+	var hintStr string
+	err = json.Unmarshal(params[1], &hintStr)
+	if err != nil {
+		return "", nil, err
+	}
+	hint, err := hex.DecodeString(hintStr)
+	if err != nil {
+		return "", nil, err
+	}
+	return peer, hint, nil
+}
@@ -4,27 +4,67 @@
 
 package client
 
+import "github.com/Qitmeer/qitmeer/rpc/client/cmds"
+
 type notificationState struct {
 	notifyBlocks       bool
+	notifyHeaders      bool
+	notifyFeeHistogram bool
+	notifyDag          bool
 	notifyNewTx        bool
 	notifyNewTxVerbose bool
 	notifyReceived     map[string]struct{}
+	notifySpent        map[cmds.OutPoint]struct{}
+
+	// lastOrder is the order of the latest block reported by a recvtx or
+	// redeemingtx notification.  The watched addresses and outpoints are
+	// caught up from it when they are reregistered after a reconnect.
+	lastOrder *uint64
+
+	// lastHeaderOrder is the order of the latest header received by a
+	// headers notification.  The headers are caught up from it when they
+	// are subscribed again after a reconnect.
+	lastHeaderOrder *uint64
+
+	// sessionID is the websocket session and lastSeq the sequence number of
+	// the latest notification received in it.  The session is resumed from
+	// lastSeq after a reconnect.
+	sessionID uint64
+	lastSeq   uint64
 }
 
 func (s *notificationState) Copy() *notificationState {
 	var stateCopy notificationState
 	stateCopy.notifyBlocks = s.notifyBlocks
+	stateCopy.notifyHeaders = s.notifyHeaders
+	stateCopy.notifyFeeHistogram = s.notifyFeeHistogram
+	stateCopy.notifyDag = s.notifyDag
 	stateCopy.notifyNewTx = s.notifyNewTx
 	stateCopy.notifyNewTxVerbose = s.notifyNewTxVerbose
 	stateCopy.notifyReceived = make(map[string]struct{})
 	for addr := range s.notifyReceived {
 		stateCopy.notifyReceived[addr] = struct{}{}
 	}
+	stateCopy.notifySpent = make(map[cmds.OutPoint]struct{})
+	for op := range s.notifySpent {
+		stateCopy.notifySpent[op] = struct{}{}
+	}
+	if s.lastOrder != nil {
+		lastOrder := *s.lastOrder
+		stateCopy.lastOrder = &lastOrder
+	}
+	if s.lastHeaderOrder != nil {
+		lastHeaderOrder := *s.lastHeaderOrder
+		stateCopy.lastHeaderOrder = &lastHeaderOrder
+	}
+	stateCopy.sessionID = s.sessionID
+	stateCopy.lastSeq = s.lastSeq
 	return &stateCopy
 }
 
 func newNotificationState() *notificationState {
 	return &notificationState{
 		notifyReceived: make(map[string]struct{}),
+		notifySpent:    make(map[cmds.OutPoint]struct{}),
 	}
 }
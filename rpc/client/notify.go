@@ -1,6 +1,7 @@
 package client
 
 import (
+	"encoding/json"
 	"errors"
 	"github.com/Qitmeer/qitmeer/core/types"
 	"github.com/Qitmeer/qitmeer/rpc/client/cmds"
@@ -62,6 +63,106 @@ func (c *Client) StopNotifyBlocks() error {
 	return c.StopNotifyBlocksAsync().Receive()
 }
 
+type FutureSubscribeHeadersResult chan *response
+
+func (r FutureSubscribeHeadersResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// SubscribeHeadersAsync subscribes to the headers of the connected blocks.  A
+// non-nil fromOrder delivers the headers of the blocks from that order up to
+// the latest one as well.
+func (c *Client) SubscribeHeadersAsync(fromOrder *uint64) FutureSubscribeHeadersResult {
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	// Ignore the notification if the client is not interested in
+	// notifications.
+	if c.ntfnHandlers == nil {
+		return newNilFutureResult()
+	}
+
+	cmd := cmds.NewSubscribeHeadersCmd(fromOrder)
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) SubscribeHeaders(fromOrder *uint64) error {
+	return c.SubscribeHeadersAsync(fromOrder).Receive()
+}
+
+func (c *Client) UnsubscribeHeadersAsync() FutureSubscribeHeadersResult {
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	// Ignore the notification if the client is not interested in
+	// notifications.
+	if c.ntfnHandlers == nil {
+		return newNilFutureResult()
+	}
+
+	cmd := cmds.NewUnsubscribeHeadersCmd()
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) UnsubscribeHeaders() error {
+	return c.UnsubscribeHeadersAsync().Receive()
+}
+
+type FutureSubscribeFeeHistogramResult chan *response
+
+func (r FutureSubscribeFeeHistogramResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// SubscribeFeeHistogramAsync subscribes to the fee histogram of the mempool,
+// which is delivered to the OnFeeHistogram handler now and whenever it
+// changes.
+func (c *Client) SubscribeFeeHistogramAsync() FutureSubscribeFeeHistogramResult {
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	// Ignore the notification if the client is not interested in
+	// notifications.
+	if c.ntfnHandlers == nil {
+		return newNilFutureResult()
+	}
+
+	cmd := cmds.NewSubscribeFeeHistogramCmd()
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) SubscribeFeeHistogram() error {
+	return c.SubscribeFeeHistogramAsync().Receive()
+}
+
+func (c *Client) UnsubscribeFeeHistogramAsync() FutureSubscribeFeeHistogramResult {
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	// Ignore the notification if the client is not interested in
+	// notifications.
+	if c.ntfnHandlers == nil {
+		return newNilFutureResult()
+	}
+
+	cmd := cmds.NewUnsubscribeFeeHistogramCmd()
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) UnsubscribeFeeHistogram() error {
+	return c.UnsubscribeFeeHistogramAsync().Receive()
+}
+
 func (c *Client) NotifyTxsByAddrAsync(reload bool, addr []string, outpoint []cmds.OutPoint) FutureNotifyBlocksResult {
 	// Not supported in HTTP POST mode.
 	if c.config.HTTPPostMode {
@@ -149,7 +250,7 @@ func (r FutureNotifyReceivedResult) Receive() error {
 	return err
 }
 
-func (c *Client) notifyReceivedInternal(addresses []string) FutureNotifyReceivedResult {
+func (c *Client) notifyReceivedInternal(addresses []string, fromOrder *uint64) FutureNotifyReceivedResult {
 	// Not supported in HTTP POST mode.
 	if c.config.HTTPPostMode {
 		return newFutureError(ErrWebsocketsRequired)
@@ -162,7 +263,7 @@ func (c *Client) notifyReceivedInternal(addresses []string) FutureNotifyReceived
 	}
 
 	// Convert addresses to strings.
-	cmd := cmds.NewNotifyReceivedCmd(addresses)
+	cmd := cmds.NewNotifyReceivedCmd(addresses, fromOrder)
 	return c.sendCmd(cmd)
 }
 
@@ -183,7 +284,7 @@ func (c *Client) NotifyReceivedAsync(addresses []types.Address) FutureNotifyRece
 	for _, addr := range addresses {
 		addrs = append(addrs, addr.String())
 	}
-	cmd := cmds.NewNotifyReceivedCmd(addrs)
+	cmd := cmds.NewNotifyReceivedCmd(addrs, nil)
 	return c.sendCmd(cmd)
 }
 
@@ -191,6 +292,95 @@ func (c *Client) NotifyReceived(addresses []types.Address) error {
 	return c.NotifyReceivedAsync(addresses).Receive()
 }
 
+// NotifyReceivedFromAsync registers the addresses like NotifyReceivedAsync and
+// delivers the events of the blocks from the passed order up to the latest
+// one as well.
+func (c *Client) NotifyReceivedFromAsync(addresses []types.Address, fromOrder uint64) FutureNotifyReceivedResult {
+	addrs := make([]string, 0, len(addresses))
+	for _, addr := range addresses {
+		addrs = append(addrs, addr.String())
+	}
+	return c.notifyReceivedInternal(addrs, &fromOrder)
+}
+
+func (c *Client) NotifyReceivedFrom(addresses []types.Address, fromOrder uint64) error {
+	return c.NotifyReceivedFromAsync(addresses, fromOrder).Receive()
+}
+
+func (c *Client) StopNotifyReceivedAsync(addresses []types.Address) FutureNotifyReceivedResult {
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	// Ignore the notification if the client is not interested in
+	// notifications.
+	if c.ntfnHandlers == nil {
+		return newNilFutureResult()
+	}
+
+	addrs := make([]string, 0, len(addresses))
+	for _, addr := range addresses {
+		addrs = append(addrs, addr.String())
+	}
+	cmd := cmds.NewStopNotifyReceivedCmd(addrs)
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) StopNotifyReceived(addresses []types.Address) error {
+	return c.StopNotifyReceivedAsync(addresses).Receive()
+}
+
+type FutureNotifySpentResult chan *response
+
+func (r FutureNotifySpentResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// NotifySpentAsync registers the outpoints for redeemingtx notifications.  A
+// non-nil fromOrder delivers the spends of the blocks from that order up to
+// the latest one as well.
+func (c *Client) NotifySpentAsync(outPoints []cmds.OutPoint, fromOrder *uint64) FutureNotifySpentResult {
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	// Ignore the notification if the client is not interested in
+	// notifications.
+	if c.ntfnHandlers == nil {
+		return newNilFutureResult()
+	}
+
+	cmd := cmds.NewNotifySpentCmd(outPoints, fromOrder)
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) NotifySpent(outPoints []cmds.OutPoint, fromOrder *uint64) error {
+	return c.NotifySpentAsync(outPoints, fromOrder).Receive()
+}
+
+func (c *Client) StopNotifySpentAsync(outPoints []cmds.OutPoint) FutureNotifySpentResult {
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	// Ignore the notification if the client is not interested in
+	// notifications.
+	if c.ntfnHandlers == nil {
+		return newNilFutureResult()
+	}
+
+	cmd := cmds.NewStopNotifySpentCmd(outPoints)
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) StopNotifySpent(outPoints []cmds.OutPoint) error {
+	return c.StopNotifySpentAsync(outPoints).Receive()
+}
+
 type FutureNotifyNewTransactionsResult chan *response
 
 func (r FutureNotifyNewTransactionsResult) Receive() error {
@@ -244,3 +434,104 @@ func (c *Client) StopNotifyNewTransactionsAsync() FutureStopNotifyNewTransaction
 func (c *Client) StopNotifyNewTransactions() error {
 	return c.StopNotifyNewTransactionsAsync().Receive()
 }
+
+type FutureSessionResult chan *response
+
+// Receive waits for the response promised by the future and returns the id of
+// the websocket session.
+func (r FutureSessionResult) Receive() (uint64, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return 0, err
+	}
+	var session struct {
+		SessionID uint64 `json:"sessionid"`
+	}
+	err = json.Unmarshal(res, &session)
+	if err != nil {
+		return 0, err
+	}
+	return session.SessionID, nil
+}
+
+func (c *Client) SessionAsync() FutureSessionResult {
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	cmd := cmds.NewSessionCmd()
+	return c.sendCmd(cmd)
+}
+
+// Session returns the id of the websocket session.
+func (c *Client) Session() (uint64, error) {
+	return c.SessionAsync().Receive()
+}
+
+// ResumeSessionAsync resumes a former websocket session.  The server replays
+// the notifications following the cursor, which is the sequence number of the
+// last notification received.
+func (c *Client) ResumeSessionAsync(sessionID uint64, cursor uint64) FutureSessionResult {
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	cmd := cmds.NewResumeSessionCmd(sessionID, cursor)
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) ResumeSession(sessionID uint64, cursor uint64) error {
+	_, err := c.ResumeSessionAsync(sessionID, cursor).Receive()
+	return err
+}
+
+type FutureSubscribeDagResult chan *response
+
+func (r FutureSubscribeDagResult) Receive() error {
+	_, err := receiveFuture(r)
+	return err
+}
+
+// SubscribeDagAsync subscribes to the topology updates of the DAG, which are
+// delivered to the OnDag handler.
+func (c *Client) SubscribeDagAsync() FutureSubscribeDagResult {
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	// Ignore the notification if the client is not interested in
+	// notifications.
+	if c.ntfnHandlers == nil {
+		return newNilFutureResult()
+	}
+
+	cmd := cmds.NewSubscribeDagCmd()
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) SubscribeDag() error {
+	return c.SubscribeDagAsync().Receive()
+}
+
+func (c *Client) UnsubscribeDagAsync() FutureSubscribeDagResult {
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	// Ignore the notification if the client is not interested in
+	// notifications.
+	if c.ntfnHandlers == nil {
+		return newNilFutureResult()
+	}
+
+	cmd := cmds.NewUnsubscribeDagCmd()
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) UnsubscribeDag() error {
+	return c.UnsubscribeDagAsync().Receive()
+}
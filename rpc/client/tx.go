@@ -81,6 +81,41 @@ func (c *Client) SendRawTransaction(hexTx string, allowHighFees bool) (*hash.Has
 	return c.SendRawTransactionAsync(hexTx, allowHighFees).Receive()
 }
 
+type FutureSubmitTxPackageResult chan *response
+
+func (r FutureSubmitTxPackageResult) Receive() ([]*hash.Hash, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var txHashStrs []string
+	err = json.Unmarshal(res, &txHashStrs)
+	if err != nil {
+		return nil, err
+	}
+	txHashes := make([]*hash.Hash, 0, len(txHashStrs))
+	for _, txHashStr := range txHashStrs {
+		txHash, err := hash.NewHashFromStr(txHashStr)
+		if err != nil {
+			return nil, err
+		}
+		txHashes = append(txHashes, txHash)
+	}
+	return txHashes, nil
+}
+
+func (c *Client) SubmitTxPackageAsync(hexTxs []string, allowHighFees bool) FutureSubmitTxPackageResult {
+	cmd := cmds.NewSubmitTxPackageCmd(hexTxs, allowHighFees)
+	return c.sendCmd(cmd)
+}
+
+// SubmitTxPackage submits the signed transactions of a package, the child
+// last, to be accepted together, and returns their hashes.
+func (c *Client) SubmitTxPackage(hexTxs []string, allowHighFees bool) ([]*hash.Hash, error) {
+	return c.SubmitTxPackageAsync(hexTxs, allowHighFees).Receive()
+}
+
 type FutureGetRawTransactionResult chan *response
 
 func (r FutureGetRawTransactionResult) Receive(verbose bool) (interface{}, error) {
@@ -259,3 +294,685 @@ func (c *Client) GetMempoolAsync(txType string, verbose bool) FutureGetMempoolRe
 func (c *Client) GetMempool(txType string, verbose bool) ([]string, error) {
 	return c.GetMempoolAsync(txType, verbose).Receive()
 }
+
+type FutureGetMempoolStatsResult chan *response
+
+func (r FutureGetMempoolStatsResult) Receive() (*j.MempoolStatsResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.MempoolStatsResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) GetMempoolStatsAsync() FutureGetMempoolStatsResult {
+	cmd := cmds.NewGetMempoolStatsCmd()
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) GetMempoolStats() (*j.MempoolStatsResult, error) {
+	return c.GetMempoolStatsAsync().Receive()
+}
+
+type FutureGetFeeHistogramResult chan *response
+
+func (r FutureGetFeeHistogramResult) Receive() (*j.FeeHistogramResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.FeeHistogramResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) GetFeeHistogramAsync() FutureGetFeeHistogramResult {
+	cmd := cmds.NewGetFeeHistogramCmd()
+	return c.sendCmd(cmd)
+}
+
+// GetFeeHistogram returns the histogram of the fee rates of the mempool
+// transactions weighted by their size.
+func (c *Client) GetFeeHistogram() (*j.FeeHistogramResult, error) {
+	return c.GetFeeHistogramAsync().Receive()
+}
+
+type FutureGetDoubleSpendProofsResult chan *response
+
+func (r FutureGetDoubleSpendProofsResult) Receive() ([]j.DoubleSpendProofResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []j.DoubleSpendProofResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (c *Client) GetDoubleSpendProofsAsync(txID *string) FutureGetDoubleSpendProofsResult {
+	cmd := cmds.NewGetDoubleSpendProofsCmd(txID)
+	return c.sendCmd(cmd)
+}
+
+// GetDoubleSpendProofs returns the double spend proofs of the mempool.  When
+// txID is not nil, only the proofs involving that transaction are returned.
+func (c *Client) GetDoubleSpendProofs(txID *string) ([]j.DoubleSpendProofResult, error) {
+	return c.GetDoubleSpendProofsAsync(txID).Receive()
+}
+
+type FutureDumpMempoolResult chan *response
+
+func (r FutureDumpMempoolResult) Receive() (*j.DumpMempoolResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.DumpMempoolResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) DumpMempoolAsync(file *string) FutureDumpMempoolResult {
+	cmd := cmds.NewDumpMempoolCmd(file)
+	return c.sendCmd(cmd)
+}
+
+// DumpMempool writes a snapshot of the mempool to the .json file of the
+// mempool directory of the node, mempool.json when file is nil.
+func (c *Client) DumpMempool(file *string) (*j.DumpMempoolResult, error) {
+	return c.DumpMempoolAsync(file).Receive()
+}
+
+type FutureLoadMempoolResult chan *response
+
+func (r FutureLoadMempoolResult) Receive() (*j.LoadMempoolResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.LoadMempoolResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) LoadMempoolAsync(file *string) FutureLoadMempoolResult {
+	cmd := cmds.NewLoadMempoolCmd(file)
+	return c.sendCmd(cmd)
+}
+
+// LoadMempool submits the transactions of a snapshot file of the mempool
+// directory of the node to its mempool.
+func (c *Client) LoadMempool(file *string) (*j.LoadMempoolResult, error) {
+	return c.LoadMempoolAsync(file).Receive()
+}
+
+type FutureDiffMempoolResult chan *response
+
+func (r FutureDiffMempoolResult) Receive() (*j.DiffMempoolResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.DiffMempoolResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) DiffMempoolAsync(file1 string, file2 string) FutureDiffMempoolResult {
+	cmd := cmds.NewDiffMempoolCmd(file1, file2)
+	return c.sendCmd(cmd)
+}
+
+// DiffMempool compares two mempool snapshot files of the mempool directory of
+// the node.
+func (c *Client) DiffMempool(file1 string, file2 string) (*j.DiffMempoolResult, error) {
+	return c.DiffMempoolAsync(file1, file2).Receive()
+}
+
+type FutureIsTxSafeToCreditResult chan *response
+
+func (r FutureIsTxSafeToCreditResult) Receive() (*j.TxSafeToCreditResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.TxSafeToCreditResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) IsTxSafeToCreditAsync(txHash string, requiredConfidence *uint32) FutureIsTxSafeToCreditResult {
+	cmd := cmds.NewIsTxSafeToCreditCmd(txHash, requiredConfidence)
+	return c.sendCmd(cmd)
+}
+
+// IsTxSafeToCredit returns whether a transaction can be credited along with
+// the explanation.  When requiredConfidence is nil, the node requires its
+// default number of confirmations.
+func (c *Client) IsTxSafeToCredit(txHash string, requiredConfidence *uint32) (*j.TxSafeToCreditResult, error) {
+	return c.IsTxSafeToCreditAsync(txHash, requiredConfidence).Receive()
+}
+
+type FutureSpendProposalResult chan *response
+
+func (r FutureSpendProposalResult) Receive() (*j.SpendProposalResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.SpendProposalResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) CreateSpendProposalAsync(redeemScript string, inputs []j.TransactionInput, amounts j.AdreesAmount, lockTime *int64) FutureSpendProposalResult {
+	cmd := cmds.NewCreateSpendProposalCmd(redeemScript, inputs, amounts, lockTime)
+	return c.sendCmd(cmd)
+}
+
+// CreateSpendProposal creates a proposal to spend outputs paid to a multisig
+// redeem script.  The returned partially signed transaction is sent to the
+// cosigners.
+func (c *Client) CreateSpendProposal(redeemScript string, inputs []j.TransactionInput, amounts j.AdreesAmount, lockTime *int64) (*j.SpendProposalResult, error) {
+	return c.CreateSpendProposalAsync(redeemScript, inputs, amounts, lockTime).Receive()
+}
+
+func (c *Client) GetSpendProposalAsync(id string) FutureSpendProposalResult {
+	cmd := cmds.NewGetSpendProposalCmd(id)
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) GetSpendProposal(id string) (*j.SpendProposalResult, error) {
+	return c.GetSpendProposalAsync(id).Receive()
+}
+
+func (c *Client) ImportSpendProposalAsync(psbt string) FutureSpendProposalResult {
+	cmd := cmds.NewImportSpendProposalCmd(psbt)
+	return c.sendCmd(cmd)
+}
+
+// ImportSpendProposal adds the signatures of a partially signed transaction
+// returned by a cosigner to the proposal.
+func (c *Client) ImportSpendProposal(psbt string) (*j.SpendProposalResult, error) {
+	return c.ImportSpendProposalAsync(psbt).Receive()
+}
+
+func (c *Client) BroadcastSpendProposalAsync(id string, allowHighFees bool) FutureSendRawTransactionResult {
+	cmd := cmds.NewBroadcastSpendProposalCmd(id, &allowHighFees)
+	return c.sendCmd(cmd)
+}
+
+func (c *Client) BroadcastSpendProposal(id string, allowHighFees bool) (*hash.Hash, error) {
+	return c.BroadcastSpendProposalAsync(id, allowHighFees).Receive()
+}
+
+func (c *Client) SignSpendProposalAsync(privkeyStr string, psbt string) FutureTxSignResult {
+	cmd := cmds.NewSignSpendProposalCmd(privkeyStr, psbt)
+	return c.sendCmd(cmd)
+}
+
+// SignSpendProposal signs a partially signed transaction with the private key
+// of a cosigner.
+func (c *Client) SignSpendProposal(privkeyStr string, psbt string) (string, error) {
+	return c.SignSpendProposalAsync(privkeyStr, psbt).Receive()
+}
+
+func (c *Client) ExternalSignSpendProposalAsync(id string) FutureSpendProposalResult {
+	cmd := cmds.NewExternalSignSpendProposalCmd(id)
+	return c.sendCmd(cmd)
+}
+
+// ExternalSignSpendProposal has the external signer of the node, such as a
+// hardware wallet, sign the proposal.
+func (c *Client) ExternalSignSpendProposal(id string) (*j.SpendProposalResult, error) {
+	return c.ExternalSignSpendProposalAsync(id).Receive()
+}
+
+type FutureDebugScriptResult chan *response
+
+func (r FutureDebugScriptResult) Receive() (*j.DebugScriptResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.DebugScriptResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) DebugScriptAsync(signScript string, pkScript string, hexTx *string, index *uint32) FutureDebugScriptResult {
+	cmd := cmds.NewDebugScriptCmd(signScript, pkScript, hexTx, index)
+	return c.sendCmd(cmd)
+}
+
+// DebugScript executes a signature script against a public key script, or an
+// input of hexTx when it is not nil, and returns the execution trace.
+func (c *Client) DebugScript(signScript string, pkScript string, hexTx *string, index *uint32) (*j.DebugScriptResult, error) {
+	return c.DebugScriptAsync(signScript, pkScript, hexTx, index).Receive()
+}
+
+type FutureScanUtxoSetResult chan *response
+
+func (r FutureScanUtxoSetResult) Receive() (*j.ScanUtxoSetResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.ScanUtxoSetResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) ScanUtxoSetAsync(descriptors []string, cursor *string, maxEntries *uint, descRange *uint32) FutureScanUtxoSetResult {
+	cmd := cmds.NewScanUtxoSetCmd(descriptors, cursor, maxEntries, descRange)
+	return c.sendCmd(cmd)
+}
+
+// ScanUtxoSet scans a part of the utxo set for the unspent outputs matching
+// the descriptors and returns them along with the cursor resuming the scan,
+// which is empty once the whole set is scanned.  The ranged descriptors cover
+// their first descRange child keys.
+func (c *Client) ScanUtxoSet(descriptors []string, cursor *string, maxEntries *uint, descRange *uint32) (*j.ScanUtxoSetResult, error) {
+	return c.ScanUtxoSetAsync(descriptors, cursor, maxEntries, descRange).Receive()
+}
+
+type FutureGetTxOutSetInfoResult chan *response
+
+func (r FutureGetTxOutSetInfoResult) Receive() (*j.GetTxOutSetInfoResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.GetTxOutSetInfoResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) GetTxOutSetInfoAsync() FutureGetTxOutSetInfoResult {
+	cmd := cmds.NewGetTxOutSetInfoCmd()
+	return c.sendCmd(cmd)
+}
+
+// GetTxOutSetInfo returns the statistics of the utxo set at the main chain
+// tip and its commitment.
+func (c *Client) GetTxOutSetInfo() (*j.GetTxOutSetInfoResult, error) {
+	return c.GetTxOutSetInfoAsync().Receive()
+}
+
+type FutureGetRawTransactionsByAddressResult chan *response
+
+func (r FutureGetRawTransactionsByAddressResult) Receive() (*j.AddressTransactionsResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.AddressTransactionsResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) GetRawTransactionsByAddressAsync(address string, startOrder *uint32, endOrder *uint32, direction *string,
+	coinId *uint16, count *uint, cursor *string, reverse *bool) FutureGetRawTransactionsByAddressResult {
+	cmd := cmds.NewGetRawTransactionsByAddressCmd(address, startOrder, endOrder, direction, coinId, count, cursor, reverse)
+	return c.sendCmd(cmd)
+}
+
+// GetRawTransactionsByAddress returns a page of the decoded confirmed
+// transactions of an address filtered by order range, direction and coin, and
+// the cursor of the next page, it requires the address index.
+func (c *Client) GetRawTransactionsByAddress(address string, startOrder *uint32, endOrder *uint32, direction *string,
+	coinId *uint16, count *uint, cursor *string, reverse *bool) (*j.AddressTransactionsResult, error) {
+	return c.GetRawTransactionsByAddressAsync(address, startOrder, endOrder, direction, coinId, count, cursor, reverse).Receive()
+}
+
+type FutureGetAddressActivityResult chan *response
+
+func (r FutureGetAddressActivityResult) Receive() (*j.AddressActivityResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.AddressActivityResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) GetAddressActivityAsync(address string) FutureGetAddressActivityResult {
+	cmd := cmds.NewGetAddressActivityCmd(address)
+	return c.sendCmd(cmd)
+}
+
+// GetAddressActivity returns the first seen and last active blocks of an
+// address, it requires the address activity index.
+func (c *Client) GetAddressActivity(address string) (*j.AddressActivityResult, error) {
+	return c.GetAddressActivityAsync(address).Receive()
+}
+
+type FutureAnalyzeAddressResult chan *response
+
+func (r FutureAnalyzeAddressResult) Receive() (*j.AnalyzeAddressResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.AnalyzeAddressResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) AnalyzeAddressAsync(address string) FutureAnalyzeAddressResult {
+	cmd := cmds.NewAnalyzeAddressCmd(address)
+	return c.sendCmd(cmd)
+}
+
+// AnalyzeAddress returns the reuse, the dust outputs and the linkability
+// metrics of an address, it requires the address index.
+func (c *Client) AnalyzeAddress(address string) (*j.AnalyzeAddressResult, error) {
+	return c.AnalyzeAddressAsync(address).Receive()
+}
+
+type FutureGetUtxoAgeDistributionResult chan *response
+
+func (r FutureGetUtxoAgeDistributionResult) Receive() (*j.UtxoAgeDistributionResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.UtxoAgeDistributionResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) GetUtxoAgeDistributionAsync(coinId *uint16, bucketOrders *uint32) FutureGetUtxoAgeDistributionResult {
+	cmd := cmds.NewGetUtxoAgeDistributionCmd(coinId, bucketOrders)
+	return c.sendCmd(cmd)
+}
+
+// GetUtxoAgeDistribution returns the distribution of the unspent outputs by
+// creation order, it requires the utxo age index.
+func (c *Client) GetUtxoAgeDistribution(coinId *uint16, bucketOrders *uint32) (*j.UtxoAgeDistributionResult, error) {
+	return c.GetUtxoAgeDistributionAsync(coinId, bucketOrders).Receive()
+}
+
+type FutureGetMinerStatsResult chan *response
+
+func (r FutureGetMinerStatsResult) Receive() (*j.MinerStatsResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.MinerStatsResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) GetMinerStatsAsync(address string, startOrder *uint32, endOrder *uint32, verbose bool) FutureGetMinerStatsResult {
+	cmd := cmds.NewGetMinerStatsCmd(address, startOrder, endOrder, &verbose)
+	return c.sendCmd(cmd)
+}
+
+// GetMinerStats returns the income paid to a coinbase address by the blocks
+// between the start and end orders, it requires the miner index.
+func (c *Client) GetMinerStats(address string, startOrder *uint32, endOrder *uint32, verbose bool) (*j.MinerStatsResult, error) {
+	return c.GetMinerStatsAsync(address, startOrder, endOrder, verbose).Receive()
+}
+
+type FutureFundRawTransactionResult chan *response
+
+func (r FutureFundRawTransactionResult) Receive() (*j.FundRawTransactionResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.FundRawTransactionResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) FundRawTransactionAsync(hexTx string, feeRate *int64, strategy *string, changeAddress *string) FutureFundRawTransactionResult {
+	cmd := cmds.NewFundRawTransactionCmd(hexTx, feeRate, strategy, changeAddress)
+	return c.sendCmd(cmd)
+}
+
+// FundRawTransaction adds the inputs spending the outputs of the watched
+// addresses and the change needed to pay the outputs of the unsigned
+// transaction and its fee at feeRate atoms/kB.
+func (c *Client) FundRawTransaction(hexTx string, feeRate *int64, strategy *string, changeAddress *string) (*j.FundRawTransactionResult, error) {
+	return c.FundRawTransactionAsync(hexTx, feeRate, strategy, changeAddress).Receive()
+}
+
+type FutureConsolidateUtxosResult chan *response
+
+func (r FutureConsolidateUtxosResult) Receive() (*j.ConsolidateUtxosResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.ConsolidateUtxosResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) ConsolidateUtxosAsync(address string, feeRate *int64, maxValue *int64, maxInputs *uint32, redeemScript *string) FutureConsolidateUtxosResult {
+	cmd := cmds.NewConsolidateUtxosCmd(address, feeRate, maxValue, maxInputs, redeemScript)
+	return c.sendCmd(cmd)
+}
+
+// ConsolidateUtxos builds the transactions merging the small unspent outputs
+// of a watched address into fewer outputs paid to the address.
+func (c *Client) ConsolidateUtxos(address string, feeRate *int64, maxValue *int64, maxInputs *uint32, redeemScript *string) (*j.ConsolidateUtxosResult, error) {
+	return c.ConsolidateUtxosAsync(address, feeRate, maxValue, maxInputs, redeemScript).Receive()
+}
+
+type FutureSetAddressLabelResult chan *response
+
+func (r FutureSetAddressLabelResult) Receive() (*j.AddressLabelResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result j.AddressLabelResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (c *Client) SetAddressLabelAsync(address string, label string) FutureSetAddressLabelResult {
+	cmd := cmds.NewSetAddressLabelCmd(address, label)
+	return c.sendCmd(cmd)
+}
+
+// SetAddressLabel sets the label of the address, or removes it when the label
+// is empty.
+func (c *Client) SetAddressLabel(address string, label string) (*j.AddressLabelResult, error) {
+	return c.SetAddressLabelAsync(address, label).Receive()
+}
+
+type FutureGetAddressesByLabelResult chan *response
+
+func (r FutureGetAddressesByLabelResult) Receive() ([]string, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (c *Client) GetAddressesByLabelAsync(label string) FutureGetAddressesByLabelResult {
+	cmd := cmds.NewGetAddressesByLabelCmd(label)
+	return c.sendCmd(cmd)
+}
+
+// GetAddressesByLabel returns the addresses with the label.
+func (c *Client) GetAddressesByLabel(label string) ([]string, error) {
+	return c.GetAddressesByLabelAsync(label).Receive()
+}
+
+type FutureFreezeCoinsResult chan *response
+
+func (r FutureFreezeCoinsResult) Receive() ([]j.FrozenCoinResult, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []j.FrozenCoinResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (c *Client) FreezeCoinsAsync(targets []string, reason *string) FutureFreezeCoinsResult {
+	cmd := cmds.NewFreezeCoinsCmd(targets, reason)
+	return c.sendCmd(cmd)
+}
+
+// FreezeCoins blocks the spending of the outpoints, formatted as
+// <txid>:<index>, and of the outputs paying to the addresses on a private
+// network.
+func (c *Client) FreezeCoins(targets []string, reason *string) ([]j.FrozenCoinResult, error) {
+	return c.FreezeCoinsAsync(targets, reason).Receive()
+}
+
+type FutureUnfreezeCoinsResult chan *response
+
+func (r FutureUnfreezeCoinsResult) Receive() (int, error) {
+	res, err := receiveFuture(r)
+	if err != nil {
+		return 0, err
+	}
+
+	var result int
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return 0, err
+	}
+
+	return result, nil
+}
+
+func (c *Client) UnfreezeCoinsAsync(targets []string) FutureUnfreezeCoinsResult {
+	cmd := cmds.NewUnfreezeCoinsCmd(targets)
+	return c.sendCmd(cmd)
+}
+
+// UnfreezeCoins removes the outpoints and the addresses from the freeze list.
+func (c *Client) UnfreezeCoins(targets []string) (int, error) {
+	return c.UnfreezeCoinsAsync(targets).Receive()
+}
+
+func (c *Client) ListFrozenCoinsAsync() FutureFreezeCoinsResult {
+	cmd := cmds.NewListFrozenCoinsCmd()
+	return c.sendCmd(cmd)
+}
+
+// ListFrozenCoins returns the entries of the freeze list.
+func (c *Client) ListFrozenCoins() ([]j.FrozenCoinResult, error) {
+	return c.ListFrozenCoinsAsync().Receive()
+}
@@ -66,12 +66,13 @@ type inMessage struct {
 type rawNotification struct {
 	Method string            `json:"method"`
 	Params []json.RawMessage `json:"params"`
+	Seq    uint64            `json:"seq"`
 }
 
 func newHTTPClient(config *ConnConfig) (*http.Client, error) {
 	// Configure TLS if needed.
 	var tlsConfig *tls.Config
-	if !config.DisableTLS {
+	if config.useTLS() {
 		if len(config.Certificates) > 0 {
 			pool := x509.NewCertPool()
 			pool.AppendCertsFromPEM(config.Certificates)
@@ -83,6 +84,7 @@ func newHTTPClient(config *ConnConfig) (*http.Client, error) {
 
 	client := http.Client{
 		Transport: &http.Transport{
+			Dial:            config.netDial,
 			TLSClientConfig: tlsConfig,
 		},
 	}
@@ -94,7 +96,7 @@ func dial(config *ConnConfig) (*websocket.Conn, error) {
 	// Setup TLS if not disabled.
 	var tlsConfig *tls.Config
 	var scheme = "ws"
-	if !config.DisableTLS {
+	if config.useTLS() {
 		tlsConfig = &tls.Config{
 			MinVersion: tls.VersionTLS12,
 		}
@@ -108,7 +110,10 @@ func dial(config *ConnConfig) (*websocket.Conn, error) {
 
 	// Create a websocket dialer that will be used to make the connection.
 	// It is modified by the proxy setting below as needed.
-	dialer := websocket.Dialer{TLSClientConfig: tlsConfig}
+	dialer := websocket.Dialer{
+		NetDial:         config.netDial,
+		TLSClientConfig: tlsConfig,
+	}
 
 	// The RPC server requires basic authorization, so create a custom
 	// request header with the Authorization header set.
@@ -125,7 +130,7 @@ func dial(config *ConnConfig) (*websocket.Conn, error) {
 	}
 
 	// Dial the connection.
-	url := fmt.Sprintf("%s://%s/%s", scheme, config.Host, config.Endpoint)
+	url := fmt.Sprintf("%s://%s/%s", scheme, config.host(), config.Endpoint)
 	wsConn, resp, err := dialer.Dial(url, requestHeader)
 	if err != nil {
 		if err != websocket.ErrBadHandshake || resp == nil {
@@ -49,10 +49,19 @@ func (e *invalidParamsError) ErrorCode() int { return -32602 }
 
 func (e *invalidParamsError) Error() string { return e.message }
 
-// logic error, callback returned an error
-type callbackError struct{ message string }
+// logic error, callback returned an error.  The code is derived from the
+// errcode of the error when it carries one.
+type callbackError struct {
+	message string
+	code    cmds.RPCErrorCode
+}
 
-func (e *callbackError) ErrorCode() int { return -32000 }
+func (e *callbackError) ErrorCode() int {
+	if e.code == 0 {
+		return int(cmds.ErrRPCServer.Code)
+	}
+	return int(e.code)
+}
 
 func (e *callbackError) Error() string { return e.message }
 
@@ -0,0 +1,22 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpc
+
+import (
+	"net"
+	"net/http"
+)
+
+// unixSocketMode is the file mode of the RPC Unix socket.  Only the user
+// running the node may connect to it, which replaces the authentication of
+// the RPC connections.
+const unixSocketMode = 0600
+
+// isUnixSocketRequest returns whether the HTTP request was received through
+// the RPC Unix socket.
+func isUnixSocketRequest(r *http.Request) bool {
+	addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	return ok && addr.Network() == "unix"
+}
@@ -0,0 +1,16 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+// +build !linux,!darwin
+
+package rpc
+
+import (
+	"errors"
+	"net"
+)
+
+// listenUnixSocket listens for RPC connections on the Unix domain socket at
+// path.
+func listenUnixSocket(path string) (net.Listener, error) {
+	return nil, errors.New("RPC unix socket is not supported on this platform")
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+// +build linux darwin
+
+package rpc
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// listenUnixSocket listens for RPC connections on the Unix domain socket at
+// path.  A socket file left over by a node which did not stop cleanly is
+// removed, but any other file at path is an error.
+func listenUnixSocket(path string) (net.Listener, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, err
+	}
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("RPC unix socket %s exists and is "+
+				"not a socket", path)
+		}
+		err = os.Remove(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Create the socket without permissions for the other users, so that
+	// none of them can connect before the permissions are set.
+	oldMask := syscall.Umask(0177)
+	listener, err := net.Listen("unix", path)
+	syscall.Umask(oldMask)
+	if err != nil {
+		return nil, err
+	}
+	err = os.Chmod(path, unixSocketMode)
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
@@ -2,63 +2,96 @@ package rpc
 
 import (
 	"fmt"
+	"github.com/Qitmeer/qitmeer/common/errcode"
 	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/rpc/client/cmds"
 )
 
 // RpcNoTxInfoError is a convenience function for returning a nicely formatted
 // RPC error which indicates there is no information available for the provided
 // transaction hash.
 func RpcNoTxInfoError(txHash *hash.Hash) error {
-	return fmt.Errorf("No information available about transaction %v", txHash)
+	return errcode.New(errcode.NotFound, "No information available about transaction %v", txHash)
 }
 
 // RpcInvalidError is a convenience function to convert an invalid parameter
 // error to an RPC error with the appropriate code set.
 func RpcInvalidError(fmtStr string, args ...interface{}) error {
 	str := fmt.Sprintf(fmtStr, args...)
-	return fmt.Errorf("Invalid Parameter : %s", str)
+	return errcode.New(errcode.InvalidParameter, "Invalid Parameter : %s", str)
 }
 
 // RpcDecodeHexError is a convenience function for returning a nicely formatted
 // RPC error which indicates the provided hex string failed to decode.
 func RpcDecodeHexError(gotHex string) error {
-	return fmt.Errorf("Argument must be hexadecimal string (not %q)", gotHex)
+	return errcode.New(errcode.InvalidParameter, "Argument must be hexadecimal string (not %q)", gotHex)
 }
 
 // RpcDeserializetionError is a convenience function to convert a
 // deserialization error to an RPC error
 func RpcDeserializationError(fmtStr string, args ...interface{}) error {
 	str := fmt.Sprintf(fmtStr, args...)
-	return fmt.Errorf("Deserialization Error : %s", str)
+	return errcode.New(errcode.Deserialization, "Deserialization Error : %s", str)
 }
 
 // RpcDuplicateTxError is a convenience function to convert a
 // rejected duplicate tx  error to an RPC error
 func RpcDuplicateTxError(fmtStr string, args ...interface{}) error {
 	str := fmt.Sprintf(fmtStr, args...)
-	return fmt.Errorf("Duplicate Tx Error : %s", str)
+	return errcode.New(errcode.Duplicate, "Duplicate Tx Error : %s", str)
 }
 
 // RpcRuleError is a convenience function to convert a
 // rule error to an RPC error
 func RpcRuleError(fmtStr string, args ...interface{}) error {
 	str := fmt.Sprintf(fmtStr, args...)
-	return fmt.Errorf("Rule Error : %s", str)
+	return errcode.New(errcode.RuleViolation, "Rule Error : %s", str)
 }
 
 // RpcAddressKeyError is a convenience function to convert an address/key error to
 // an RPC error.
 func RpcAddressKeyError(fmtStr string, args ...interface{}) error {
 	msg := fmt.Sprintf(fmtStr, args...)
-	return fmt.Errorf("Invalid AddressOrKey : %s", msg)
+	return errcode.New(errcode.InvalidParameter, "Invalid AddressOrKey : %s", msg)
 }
 
 func RpcInternalError(err, context string) error {
-	return fmt.Errorf("%s : %s", context, err)
+	return errcode.New(errcode.Internal, "%s : %s", context, err)
 }
 
 //LL(getblocktemplate RPC) 2018-10-28
 //client errors.
 func RPCClientInInitialDownloadError(err, context string) error {
-	return fmt.Errorf("%s : %s", context, err)
+	return errcode.New(errcode.Unavailable, "%s : %s", context, err)
+}
+
+// RPCSafeModeError is returned by the mining calls while the node is in safe
+// mode.
+func RPCSafeModeError() error {
+	return errcode.New(errcode.Unavailable, "Node in safe mode : mining is "+
+		"paused until the safe mode is acknowledged")
+}
+
+// rpcErrorCode returns the JSON-RPC error code of the code carried by err.
+// The errors without a code are reported with the generic server error code.
+func rpcErrorCode(err error) cmds.RPCErrorCode {
+	switch errcode.CodeOf(err) {
+	case errcode.Internal:
+		return cmds.ErrRPCInternal.Code
+	case errcode.InvalidParameter:
+		return cmds.ErrRPCInvalidParams.Code
+	case errcode.NotFound:
+		return cmds.ErrRPCNotFound.Code
+	case errcode.Duplicate:
+		return cmds.ErrRPCDuplicate.Code
+	case errcode.RuleViolation:
+		return cmds.ErrRPCRuleViolation.Code
+	case errcode.Deserialization:
+		return cmds.ErrRPCDeserialization.Code
+	case errcode.Database:
+		return cmds.ErrRPCDatabase.Code
+	case errcode.Unavailable:
+		return cmds.ErrRPCUnavailable.Code
+	}
+	return cmds.ErrRPCServer.Code
 }
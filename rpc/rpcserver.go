@@ -7,9 +7,12 @@ import (
 	"crypto/subtle"
 	"encoding/base64"
 	"fmt"
+	"github.com/Qitmeer/qitmeer/common/crash"
+	"github.com/Qitmeer/qitmeer/common/perf"
 	"github.com/Qitmeer/qitmeer/config"
 	"github.com/Qitmeer/qitmeer/core/blockchain"
 	"github.com/Qitmeer/qitmeer/core/event"
+	"github.com/Qitmeer/qitmeer/core/json"
 	"github.com/Qitmeer/qitmeer/params"
 	"github.com/Qitmeer/qitmeer/rpc/websocket"
 	"github.com/Qitmeer/qitmeer/services/index"
@@ -19,7 +22,6 @@ import (
 	"net"
 	"net/http"
 	"reflect"
-	"runtime"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -59,7 +61,12 @@ type RpcServer struct {
 	BC          *blockchain.BlockChain
 	TxIndex     *index.TxIndex
 	ChainParams *params.Params
+	AuditLog    *AuditLog
 	listeners   []net.Listener
+
+	// FeeHistogram returns the fee histogram of the mempool which is sent
+	// to the websocket clients subscribed to it.
+	FeeHistogram func() *json.FeeHistogramResult
 }
 
 // service represents a registered object
@@ -243,6 +250,13 @@ func (s *RpcServer) startHTTP(listenAddrs []string) error {
 	if err != nil {
 		return err
 	}
+	if s.config.RPCUnixSocket != "" {
+		listener, err := listenUnixSocket(s.config.RPCUnixSocket)
+		if err != nil {
+			return err
+		}
+		listeners = append(listeners, listener)
+	}
 	s.listeners = listeners
 	for _, listener := range listeners {
 		s.wg.Add(1)
@@ -292,10 +306,16 @@ func (s *RpcServer) decrementClients() {
 // TODO, repalace Basic Authentication
 // checkAuth checks the HTTP Basic authentication supplied by a wallet or RPC
 // client in the HTTP request r.  If the supplied authentication does not match
-// the username and password expected, a non-nil error is returned.
+// the username and password expected, a non-nil error is returned.  The
+// requests received through the RPC Unix socket need no authentication.
 //
 // This check is time-constant.
 func (s *RpcServer) checkAuth(r *http.Request, require bool) (bool, error) {
+	// The clients of the Unix socket are authorized by its permissions.
+	if isUnixSocketRequest(r) {
+		return true, nil
+	}
+
 	authhdr := r.Header["Authorization"]
 	if len(authhdr) <= 0 {
 		if require {
@@ -359,6 +379,7 @@ func (s *RpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request) {
 	ctx = context.WithValue(ctx, "remote", r.RemoteAddr)
 	ctx = context.WithValue(ctx, "scheme", r.Proto)
 	ctx = context.WithValue(ctx, "local", r.Host)
+	ctx = context.WithValue(ctx, "user", s.config.RPCUser)
 
 	// Read and close the JSON-RPC request body from the caller.
 	body := io.LimitReader(r.Body, maxRequestContentLength)
@@ -386,10 +407,7 @@ func (s *RpcServer) serveRequest(ctx context.Context, codec ServerCodec, singleS
 
 	defer func() {
 		if err := recover(); err != nil {
-			const size = 64 << 10
-			buf := make([]byte, size)
-			buf = buf[:runtime.Stack(buf, false)]
-			log.Error(string(buf))
+			crash.Recovered("rpc", err)
 		}
 		s.codecsMu.Lock()
 		s.codecs.Remove(codec)
@@ -400,6 +418,15 @@ func (s *RpcServer) serveRequest(ctx context.Context, codec ServerCodec, singleS
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	// Cancel the pending requests when the server stops.
+	go func() {
+		select {
+		case <-s.quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
 	// if the codec supports notification include a notifier that callbacks can use
 	// to send notification to clients. It is tied to the codec/connection. If the
 	// connection is closed the notifier will stop and cancels all active subscriptions.
@@ -609,12 +636,12 @@ func (s *RpcServer) handle(ctx context.Context, codec ServerCodec, req *serverRe
 		if len(req.args) >= 1 && req.args[0].Kind() == reflect.String {
 			notifier, supported := NotifierFromContext(ctx)
 			if !supported { // interface doesn't support subscriptions (e.g. http)
-				return codec.CreateErrorResponse(&req.id, &callbackError{ErrNotificationsUnsupported.Error()}), nil
+				return codec.CreateErrorResponse(&req.id, &callbackError{message: ErrNotificationsUnsupported.Error()}), nil
 			}
 
 			subid := ID(req.args[0].String())
 			if err := notifier.unsubscribe(subid); err != nil {
-				return codec.CreateErrorResponse(&req.id, &callbackError{err.Error()}), nil
+				return codec.CreateErrorResponse(&req.id, &callbackError{message: err.Error()}), nil
 			}
 
 			return codec.CreateResponse(req.id, true), nil
@@ -625,7 +652,7 @@ func (s *RpcServer) handle(ctx context.Context, codec ServerCodec, req *serverRe
 	if req.callb.isSubscribe {
 		subid, err := s.createSubscription(ctx, codec, req)
 		if err != nil {
-			return codec.CreateErrorResponse(&req.id, &callbackError{err.Error()}), nil
+			return codec.CreateErrorResponse(&req.id, &callbackError{message: err.Error()}), nil
 		}
 
 		// active the subscription after the sub id was successfully sent to the client
@@ -646,6 +673,12 @@ func (s *RpcServer) handle(ctx context.Context, codec ServerCodec, req *serverRe
 
 	arguments := []reflect.Value{req.callb.receiver}
 	if req.callb.hasCtx {
+		if s.config.RPCTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx,
+				time.Duration(s.config.RPCTimeout)*time.Second)
+			defer cancel()
+		}
 		arguments = append(arguments, reflect.ValueOf(ctx))
 	}
 	if len(req.args) > 0 {
@@ -654,21 +687,40 @@ func (s *RpcServer) handle(ctx context.Context, codec ServerCodec, req *serverRe
 
 	s.AddRequstStatus(req)
 	// execute RPC method and return result
-	reply := req.callb.method.Func.Call(arguments)
+	start := time.Now()
+	reply, err := callMethod(req, arguments)
+	perf.RecordSince(perf.RPC, formatName(req.callb.method.Name), start)
 	s.RemoveRequstStatus(req)
+	if err != nil {
+		return codec.CreateErrorResponse(&req.id, &callbackError{message: err.Error(), code: rpcErrorCode(err)}), nil
+	}
+	s.audit(ctx, req, reply)
 	if len(reply) == 0 {
 		return codec.CreateResponse(req.id, nil), nil
 	}
 	if req.callb.errPos >= 0 { // test if method returned an error
 		if !reply[req.callb.errPos].IsNil() {
 			e := reply[req.callb.errPos].Interface().(error)
-			res := codec.CreateErrorResponse(&req.id, &callbackError{e.Error()})
+			res := codec.CreateErrorResponse(&req.id, &callbackError{message: e.Error(), code: rpcErrorCode(e)})
 			return res, nil
 		}
 	}
 	return codec.CreateResponse(req.id, reply[0].Interface()), nil
 }
 
+// callMethod calls the method of a request.  A panic in the method is reported
+// as a crash of the rpc subsystem and fails the call with an internal error,
+// instead of taking the node down.
+func callMethod(req *serverRequest, arguments []reflect.Value) (reply []reflect.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			crash.Recovered("rpc/"+formatName(req.callb.method.Name), r)
+			err = RpcInternalError(fmt.Sprint(r), "Panic in "+formatName(req.callb.method.Name))
+		}
+	}()
+	return req.callb.method.Func.Call(arguments), nil
+}
+
 // createSubscription will call the subscription callback and returns the subscription id or error.
 func (s *RpcServer) createSubscription(ctx context.Context, c ServerCodec, req *serverRequest) (ID, error) {
 	// subscription have as first argument the context following optional arguments
@@ -7,6 +7,7 @@ package rpc
 import (
 	"fmt"
 	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/address"
 	"github.com/Qitmeer/qitmeer/core/blockchain"
 	"github.com/Qitmeer/qitmeer/core/event"
 	"github.com/Qitmeer/qitmeer/core/types"
@@ -82,6 +83,15 @@ func (s *RpcServer) handleNotifyMsg(notification *blockchain.Notification) {
 			break
 		}
 		s.ntfnMgr.NotifyReorganization(rnd)
+
+	case blockchain.ChainReorg:
+		cd, ok := notification.Data.(*blockchain.ChainReorgNotifyData)
+		if !ok {
+			log.Warn("Chain reorg notification is not " +
+				"ChainReorgNotifyData.")
+			break
+		}
+		s.ntfnMgr.NotifyChainReorg(cd)
 	}
 }
 
@@ -92,6 +102,30 @@ func (s *RpcServer) NotifyNewTransactions(txns []*types.TxDesc) {
 	}
 }
 
+// NotifyDoubleSpendProof notifies websocket clients about a double spend
+// proof of the mempool.
+func (s *RpcServer) NotifyDoubleSpendProof(proof *types.DoubleSpendProof) {
+	s.ntfnMgr.NotifyDoubleSpendProof(proof)
+}
+
+// NotifyTxEvicted notifies websocket clients about a transaction evicted from
+// the mempool.
+func (s *RpcServer) NotifyTxEvicted(tx *types.Tx, reason string) {
+	s.ntfnMgr.NotifyTxEvicted(tx, reason)
+}
+
+// NotifyMinedBlockStale notifies websocket clients about a block mined by the
+// node which ended up red or moved back in the order.
+func (s *RpcServer) NotifyMinedBlockStale(h *hash.Hash, reason string, hints []string) {
+	s.ntfnMgr.NotifyMinedBlockStale(h, reason, hints)
+}
+
+// NotifyClusterHint notifies websocket clients about a template hint received
+// from a peer of the mining cluster.
+func (s *RpcServer) NotifyClusterHint(peer string, hint []byte) {
+	s.ntfnMgr.NotifyClusterHint(peer, hint)
+}
+
 func (s *RpcServer) WebsocketHandler(conn *websocket.Conn, remoteAddr string, isAdmin bool) {
 	// Clear the read deadline that was set before the websocket hijacked
 	// the connection.
@@ -117,8 +151,15 @@ func (s *RpcServer) WebsocketHandler(conn *websocket.Conn, remoteAddr string, is
 	s.ntfnMgr.AddClient(client)
 	client.Start()
 	client.WaitForShutdown()
-	s.ntfnMgr.RemoveClient(client)
 	log.Info(fmt.Sprintf("Disconnected websocket client %s", remoteAddr))
+
+	// Keep the subscriptions of the client alive for a while, so that it
+	// is able to resume its session after a brief disconnect.  The parked
+	// session does not count against the websocket limit.
+	s.ntfnMgr.ParkClient(client)
+	time.AfterFunc(sessionTimeout, func() {
+		s.ntfnMgr.RemoveClient(client)
+	})
 }
 
 type wsCommandHandler func(*wsClient, interface{}) (interface{}, error)
@@ -128,12 +169,23 @@ var wsHandlersBeforeInit = map[string]wsCommandHandler{
 	"notifyBlocks":              handleNotifyBlocks,
 	"stopNotifyBlocks":          handleStopNotifyBlocks,
 	"session":                   handleSession,
+	"resumeSession":             handleResumeSession,
 	"notifynewtransactions":     handleNotifyNewTransactions,
 	"stopnotifynewtransactions": handleStopNotifyNewTransactions,
 	"notifyTxsByAddr":           handleNotifyTxsByAddr,
 	"stopnotifyTxsByAddr":       handleStopNotifyTxsByAddr,
 	"rescan":                    handleRescan,
 	"notifyTxsConfirmed":        handleNotifyTxsConfirmed,
+	"notifyReceived":            handleNotifyReceived,
+	"stopNotifyReceived":        handleStopNotifyReceived,
+	"notifySpent":               handleNotifySpent,
+	"stopNotifySpent":           handleStopNotifySpent,
+	"subscribeHeaders":          handleSubscribeHeaders,
+	"unsubscribeHeaders":        handleUnsubscribeHeaders,
+	"subscribeFeeHistogram":     handleSubscribeFeeHistogram,
+	"unsubscribeFeeHistogram":   handleUnsubscribeFeeHistogram,
+	"subscribeDag":              handleSubscribeDag,
+	"unsubscribeDag":            handleUnsubscribeDag,
 }
 
 func handleNotifyBlocks(wsc *wsClient, icmd interface{}) (interface{}, error) {
@@ -147,7 +199,27 @@ func handleStopNotifyBlocks(wsc *wsClient, icmd interface{}) (interface{}, error
 }
 
 func handleSession(wsc *wsClient, icmd interface{}) (interface{}, error) {
-	return &SessionResult{SessionID: wsc.sessionID}, nil
+	wsc.Lock()
+	sessionID := wsc.sessionID
+	wsc.Unlock()
+	return &SessionResult{SessionID: sessionID}, nil
+}
+
+// handleResumeSession implements the resumeSession command extension for
+// websocket connections.  The client takes over the subscriptions of its
+// former session and receives the notifications following its cursor.
+func handleResumeSession(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*cmds.ResumeSessionCmd)
+	if !ok {
+		return nil, cmds.ErrRPCInternal
+	}
+	err := wsc.server.ntfnMgr.ResumeSession(wsc, cmd.SessionID, cmd.Cursor)
+	if err != nil {
+		return nil, cmds.NewRPCError(cmds.ErrRPCInvalidParams.Code, err.Error())
+	}
+	log.Info(fmt.Sprintf("Websocket client %s resumed session %d from %d",
+		wsc.addr, cmd.SessionID, cmd.Cursor))
+	return &SessionResult{SessionID: cmd.SessionID}, nil
 }
 
 func handleNotifyNewTransactions(wsc *wsClient, icmd interface{}) (interface{}, error) {
@@ -350,3 +422,190 @@ func handleNotifyTxsConfirmed(wsc *wsClient, icmd interface{}) (interface{}, err
 	wsc.server.ntfnMgr.RegisterTxConfirm(wsc)
 	return nil, nil
 }
+
+// handleNotifyReceived implements the notifyReceived command extension for
+// websocket connections.  The client is notified of every transaction paying
+// to one of the addresses and of the spends of the received outputs.
+func handleNotifyReceived(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*cmds.NotifyReceivedCmd)
+	if !ok {
+		return nil, cmds.ErrRPCInternal
+	}
+	addrs, err := decodeAddresses(cmd.Addresses)
+	if err != nil {
+		return nil, err
+	}
+	wsc.server.ntfnMgr.RegisterTxOutAddressRequests(wsc, addrs)
+	if cmd.FromOrder == nil {
+		return nil, nil
+	}
+
+	// Deliver the events of the blocks the client may have missed and
+	// watch the outputs which were received but not spent yet.
+	addrMap := make(map[string]struct{}, len(addrs))
+	for _, addr := range addrs {
+		addrMap[addr] = struct{}{}
+	}
+	unspent := make(map[types.TxOutPoint]struct{})
+	err = catchUpWatched(wsc, addrMap, unspent, *cmd.FromOrder)
+	if err != nil {
+		if err == ErrClientQuit {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(unspent) > 0 {
+		ops := make([]*types.TxOutPoint, 0, len(unspent))
+		for op := range unspent {
+			opCopy := op
+			ops = append(ops, &opCopy)
+		}
+		wsc.server.ntfnMgr.RegisterSpentRequests(wsc, ops)
+	}
+	return nil, nil
+}
+
+func handleStopNotifyReceived(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*cmds.StopNotifyReceivedCmd)
+	if !ok {
+		return nil, cmds.ErrRPCInternal
+	}
+	addrs, err := decodeAddresses(cmd.Addresses)
+	if err != nil {
+		return nil, err
+	}
+	wsc.server.ntfnMgr.UnregisterTxOutAddressRequests(wsc, addrs)
+	return nil, nil
+}
+
+// handleNotifySpent implements the notifySpent command extension for
+// websocket connections.  The client is notified of every transaction
+// spending one of the outpoints.
+func handleNotifySpent(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*cmds.NotifySpentCmd)
+	if !ok {
+		return nil, cmds.ErrRPCInternal
+	}
+	ops, err := decodeOutPoints(cmd.OutPoints)
+	if err != nil {
+		return nil, err
+	}
+	wsc.server.ntfnMgr.RegisterSpentRequests(wsc, ops)
+	if cmd.FromOrder == nil {
+		return nil, nil
+	}
+
+	// Deliver the spends the client may have missed and stop watching the
+	// outpoints which are already spent.
+	unspent := make(map[types.TxOutPoint]struct{}, len(ops))
+	for _, op := range ops {
+		unspent[*op] = struct{}{}
+	}
+	err = catchUpWatched(wsc, nil, unspent, *cmd.FromOrder)
+	if err != nil {
+		if err == ErrClientQuit {
+			return nil, nil
+		}
+		return nil, err
+	}
+	spent := make([]*types.TxOutPoint, 0, len(ops))
+	for _, op := range ops {
+		if _, ok := unspent[*op]; !ok {
+			spent = append(spent, op)
+		}
+	}
+	if len(spent) > 0 {
+		wsc.server.ntfnMgr.UnregisterSpentRequests(wsc, spent)
+	}
+	return nil, nil
+}
+
+func handleStopNotifySpent(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*cmds.StopNotifySpentCmd)
+	if !ok {
+		return nil, cmds.ErrRPCInternal
+	}
+	ops, err := decodeOutPoints(cmd.OutPoints)
+	if err != nil {
+		return nil, err
+	}
+	wsc.server.ntfnMgr.UnregisterSpentRequests(wsc, ops)
+	return nil, nil
+}
+
+// handleSubscribeHeaders implements the subscribeHeaders command extension for
+// websocket connections.  The client is sent the header of every connected
+// block, preceded by the headers from the requested order when it is set.
+func handleSubscribeHeaders(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*cmds.SubscribeHeadersCmd)
+	if !ok {
+		return nil, cmds.ErrRPCInternal
+	}
+	wsc.server.ntfnMgr.RegisterHeaderUpdates(wsc)
+	if cmd.FromOrder == nil {
+		return nil, nil
+	}
+	err := catchUpHeaders(wsc, *cmd.FromOrder)
+	if err != nil && err != ErrClientQuit {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func handleUnsubscribeHeaders(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.UnregisterHeaderUpdates(wsc)
+	return nil, nil
+}
+
+// handleSubscribeFeeHistogram implements the subscribeFeeHistogram command
+// extension for websocket connections.  The client is sent the fee histogram
+// of the mempool, then the histogram again whenever it changes.
+func handleSubscribeFeeHistogram(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.RegisterFeeHistogramUpdates(wsc)
+	return nil, nil
+}
+
+func handleUnsubscribeFeeHistogram(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.UnregisterFeeHistogramUpdates(wsc)
+	return nil, nil
+}
+
+// handleSubscribeDag implements the subscribeDag command extension for
+// websocket connections.  The client is sent the blocks added to the DAG with
+// their parents, then the color flips and the order shifts of the blocks.
+func handleSubscribeDag(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.RegisterDagUpdates(wsc)
+	return nil, nil
+}
+
+func handleUnsubscribeDag(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.UnregisterDagUpdates(wsc)
+	return nil, nil
+}
+
+// decodeAddresses decodes the passed addresses and returns their encoded form,
+// which is the key of the address subscriptions.
+func decodeAddresses(addrs []string) ([]string, error) {
+	encoded := make([]string, 0, len(addrs))
+	for _, a := range addrs {
+		addr, err := address.DecodeAddress(a)
+		if err != nil {
+			return nil, cmds.NewRPCError(cmds.ErrRPCInvalidParams.Code,
+				fmt.Sprintf("Invalid address %s: %v", a, err))
+		}
+		encoded = append(encoded, addr.Encode())
+	}
+	return encoded, nil
+}
+
+func decodeOutPoints(outPoints []cmds.OutPoint) ([]*types.TxOutPoint, error) {
+	ops := make([]*types.TxOutPoint, 0, len(outPoints))
+	for i := range outPoints {
+		h, err := hash.NewHashFromStr(outPoints[i].Hash)
+		if err != nil {
+			return nil, rpcDecodeHexError(outPoints[i].Hash)
+		}
+		ops = append(ops, types.NewOutPoint(h, outPoints[i].Index))
+	}
+	return ops, nil
+}
@@ -9,11 +9,13 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"github.com/Qitmeer/qitmeer/common/errcode"
 	"github.com/Qitmeer/qitmeer/common/marshal"
 	"github.com/Qitmeer/qitmeer/common/network"
 	"github.com/Qitmeer/qitmeer/common/roughtime"
 	"github.com/Qitmeer/qitmeer/common/util"
 	"github.com/Qitmeer/qitmeer/config"
+	"github.com/Qitmeer/qitmeer/core/json"
 	"github.com/Qitmeer/qitmeer/core/types"
 	"github.com/Qitmeer/qitmeer/crypto/certgen"
 	"github.com/Qitmeer/qitmeer/rpc/client/cmds"
@@ -354,6 +356,9 @@ func createMarshalledReply(id, result interface{}, replyErr error) ([]byte, erro
 	if replyErr != nil {
 		if jErr, ok := replyErr.(*cmds.RPCError); ok {
 			jsonErr = jErr
+		} else if code := errcode.CodeOf(replyErr); code != errcode.Unknown &&
+			code != errcode.Internal {
+			jsonErr = cmds.NewRPCError(rpcErrorCode(replyErr), replyErr.Error())
 		} else {
 			jsonErr = cmds.InternalRPCError(replyErr.Error(), "")
 		}
@@ -408,3 +413,21 @@ func GetTxsHexFromBlock(block *types.SerializedBlock, duplicate bool) ([]string,
 	}
 	return txs, nil
 }
+
+// DoubleSpendProofResult returns the json representation of a double spend
+// proof.
+func DoubleSpendProofResult(proof *types.DoubleSpendProof) (*json.DoubleSpendProofResult, error) {
+	proofBytes, err := proof.Serialize()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to serialize double spend proof:%v", err)
+	}
+	proofHash := proof.Hash()
+	return &json.DoubleSpendProofResult{
+		Hash:   proofHash.String(),
+		TxId:   proof.OutPoint.Hash.String(),
+		Vout:   proof.OutPoint.OutIndex,
+		First:  proof.First.TxHash().String(),
+		Second: proof.Second.TxHash().String(),
+		Hex:    hex.EncodeToString(proofBytes),
+	}, nil
+}
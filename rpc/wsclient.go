@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/Qitmeer/qitmeer/core/serialization"
+	"github.com/Qitmeer/qitmeer/core/types"
 	"github.com/Qitmeer/qitmeer/rpc/client/cmds"
 	"github.com/Qitmeer/qitmeer/rpc/websocket"
 	"io"
@@ -53,6 +54,10 @@ type wsClient struct {
 	// to the session ID indicates that the client reconnected.
 	sessionID uint64
 
+	// session numbers and keeps the notifications for the replay on
+	// resumption.  It is replaced when the client resumes a former session.
+	session *wsSession
+
 	// verboseTxUpdates specifies whether a client has requested verbose
 	// information about all new transactions.
 	verboseTxUpdates bool
@@ -62,6 +67,12 @@ type wsClient struct {
 	// `rescanblocks` methods.
 	filterData *wsClientFilter
 
+	// addrRequests and spentRequests are the addresses and outpoints the
+	// client watched by notifyReceived and notifySpent.  They are only
+	// accessed by the notification manager handler goroutine.
+	addrRequests  map[string]struct{}
+	spentRequests map[types.TxOutPoint]struct{}
+
 	// Networking infrastructure.
 	serviceRequestSem semaphore
 	ntfnChan          chan []byte
@@ -140,7 +151,10 @@ out:
 		c.serviceRequestSem.acquire()
 		go func() {
 			defer codec.Close()
-			ctx := context.Background()
+			ctx := context.WithValue(context.Background(), "remote", c.addr)
+			if c.isAdmin {
+				ctx = context.WithValue(ctx, "user", c.server.config.RPCUser)
+			}
 			c.server.ServeSingleRequest(ctx, codec, OptionMethodInvocation)
 
 			c.serviceRequestSem.release()
@@ -316,6 +330,22 @@ cleanup:
 }
 
 func (c *wsClient) QueueNotification(marshalledJSON []byte) error {
+	// Record the notification in the session first, even if the client
+	// is disconnected, so that it is replayed when the session is resumed.
+	c.Lock()
+	session := c.session
+	c.Unlock()
+	marshalledJSON, err := session.record(marshalledJSON)
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to record notification: %v", err))
+		return err
+	}
+	return c.queueRecorded(marshalledJSON)
+}
+
+// queueRecorded queues a notification which is already recorded in the
+// session.
+func (c *wsClient) queueRecorded(marshalledJSON []byte) error {
 	// Don't queue the message if disconnected.
 	if c.Disconnected() {
 		return ErrClientQuit
@@ -343,7 +373,10 @@ func newWebsocketClient(server *RpcServer, conn *websocket.Conn,
 		sendChan:          make(chan wsResponse, websocketSendBufferSize),
 		quit:              make(chan struct{}),
 		TxConfirms:        &WatchTxConfirmServer{},
+		addrRequests:      make(map[string]struct{}),
+		spentRequests:     make(map[types.TxOutPoint]struct{}),
 	}
+	client.session = newWSSession(sessionID)
 	return client, nil
 }
 
@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package rpc
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/json"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/rpc/client/cmds"
+)
+
+// maxDagColors is the maximum number of latest blocks whose color is
+// remembered to detect their color flips.
+const maxDagColors = 10000
+
+// Types of the events of the dag notifications.
+const (
+	dagEventAdded = "added"
+	dagEventColor = "color"
+	dagEventOrder = "order"
+)
+
+// dagColors remembers the colors of the latest blocks sent to the dag
+// subscribers, so that only the blocks whose color flipped are sent again.  It
+// is only accessed by the notification handler.
+type dagColors struct {
+	colors map[hash.Hash]bool
+	order  []hash.Hash
+}
+
+// set remembers the color of the block and returns whether it flipped.  The
+// oldest block is forgotten when too many blocks are remembered.
+func (c *dagColors) set(h *hash.Hash, blue bool) bool {
+	if c.colors == nil {
+		c.colors = make(map[hash.Hash]bool)
+	}
+	old, ok := c.colors[*h]
+	if ok {
+		c.colors[*h] = blue
+		return old != blue
+	}
+	if len(c.order) >= maxDagColors {
+		delete(c.colors, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.colors[*h] = blue
+	c.order = append(c.order, *h)
+	return false
+}
+
+// dagAddedEvents returns the event of a block added to the DAG.
+func (m *wsNotificationManager) dagAddedEvents(colors *dagColors, block *types.SerializedBlock) []json.DagEventResult {
+	bd := m.server.BC.BlockDAG()
+	ib := bd.GetBlock(block.Hash())
+	if ib == nil {
+		return nil
+	}
+	blue := bd.IsBlue(ib.GetID())
+	colors.set(block.Hash(), blue)
+
+	parents := make([]string, 0, len(block.Block().Parents))
+	for _, parent := range block.Block().Parents {
+		parents = append(parents, parent.String())
+	}
+	return []json.DagEventResult{{
+		Type:    dagEventAdded,
+		Hash:    block.Hash().String(),
+		Parents: parents,
+		Order:   uint64(ib.GetOrder()),
+		Blue:    blue,
+	}}
+}
+
+// dagReorgEvents returns the events of a reorganization: the order shifts of
+// the reordered blocks and the color flips of the attached blocks.
+func (m *wsNotificationManager) dagReorgEvents(colors *dagColors, cd *blockchain.ChainReorgNotifyData) []json.DagEventResult {
+	bd := m.server.BC.BlockDAG()
+	events := []json.DagEventResult{}
+	reordered := make(map[hash.Hash]uint64)
+	for _, br := range cd.Reordered() {
+		reordered[*br.Hash] = br.OldOrder
+	}
+	for _, bo := range cd.Attached {
+		ib := bd.GetBlock(bo.Hash)
+		if ib == nil {
+			continue
+		}
+		blue := bd.IsBlue(ib.GetID())
+		if oldOrder, ok := reordered[*bo.Hash]; ok {
+			events = append(events, json.DagEventResult{
+				Type:     dagEventOrder,
+				Hash:     bo.Hash.String(),
+				Order:    bo.Order,
+				OldOrder: oldOrder,
+				Blue:     blue,
+			})
+		}
+		if colors.set(bo.Hash, blue) {
+			events = append(events, json.DagEventResult{
+				Type:  dagEventColor,
+				Hash:  bo.Hash.String(),
+				Order: bo.Order,
+				Blue:  blue,
+			})
+		}
+	}
+	return events
+}
+
+// notifyDag sends a dag notification with the events to the clients which
+// subscribed to the DAG topology.
+func (m *wsNotificationManager) notifyDag(clients map[chan struct{}]*wsClient, events []json.DagEventResult) {
+	if len(events) == 0 {
+		return
+	}
+	marshalledJSON, err := cmds.MarshalCmd(nil, cmds.NewDagNtfn(events))
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to marshal dag notification: %v", err))
+		return
+	}
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
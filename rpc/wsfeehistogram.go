@@ -0,0 +1,43 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package rpc
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/json"
+	"github.com/Qitmeer/qitmeer/rpc/client/cmds"
+	"reflect"
+	"time"
+)
+
+// feeHistogramInterval is how often the fee histogram of the mempool is
+// checked for changes, which bounds the rate of the feehistogram
+// notifications however busy the mempool is.
+const feeHistogramInterval = 5 * time.Second
+
+// notifyFeeHistogram sends the fee histogram of the mempool to the clients
+// when it differs from the last one sent, or always when last is nil.  It
+// returns the histogram sent, or last when nothing was sent.
+func (m *wsNotificationManager) notifyFeeHistogram(clients map[chan struct{}]*wsClient,
+	last *json.FeeHistogramResult) *json.FeeHistogramResult {
+
+	if m.server.FeeHistogram == nil {
+		return last
+	}
+	histogram := m.server.FeeHistogram()
+	if last != nil && reflect.DeepEqual(histogram, last) {
+		return last
+	}
+	marshalledJSON, err := cmds.MarshalCmd(nil, cmds.NewFeeHistogramNtfn(*histogram))
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to marshal fee histogram "+
+			"notification: %v", err))
+		return last
+	}
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+	return histogram
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package rpc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/json"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/rpc/client/cmds"
+)
+
+// MaxHeadersPerRequest is the maximum number of headers returned by the
+// getHeaders command and sent by a single headers notification.
+const MaxHeadersPerRequest = 2000
+
+// HeadersResult returns the packed headers of the count blocks following the
+// start order, that order included.
+func HeadersResult(bc *blockchain.BlockChain, startOrder uint64, count uint64) (*json.HeadersResult, error) {
+	if count > MaxHeadersPerRequest {
+		count = MaxHeadersPerRequest
+	}
+	headers, blues, err := bc.RawHeadersByOrder(startOrder, count)
+	if err != nil {
+		return nil, err
+	}
+	packed := make([]byte, 0, len(headers)*types.MaxBlockHeaderPayload)
+	for _, header := range headers {
+		packed = append(packed, header...)
+	}
+	return &json.HeadersResult{
+		StartOrder: startOrder,
+		HeaderSize: types.MaxBlockHeaderPayload,
+		Headers:    hex.EncodeToString(packed),
+		Blues:      blues,
+	}, nil
+}
+
+// notifyHeaders sends the header of a connected block to the clients which
+// subscribed to the headers.  A block connected again with a new order after
+// a reorganization is sent again.
+func (m *wsNotificationManager) notifyHeaders(clients map[chan struct{}]*wsClient, block *types.SerializedBlock) {
+	var headerBuf bytes.Buffer
+	err := block.Block().Header.Serialize(&headerBuf)
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to serialize header of block %s: %v",
+			block.Hash(), err))
+		return
+	}
+	isBlue := false
+	ib := m.server.BC.BlockDAG().GetBlock(block.Hash())
+	if ib != nil {
+		isBlue = m.server.BC.BlockDAG().IsBlue(ib.GetID())
+	}
+	ntfn := cmds.NewHeadersNtfn(json.HeadersResult{
+		StartOrder: block.Order(),
+		HeaderSize: types.MaxBlockHeaderPayload,
+		Headers:    hex.EncodeToString(headerBuf.Bytes()),
+		Blues:      []bool{isBlue},
+	})
+	marshalledJSON, err := cmds.MarshalCmd(nil, ntfn)
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to marshal headers notification: %v",
+			err))
+		return
+	}
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
+// catchUpHeaders sends the headers of the blocks from the passed order up to
+// the latest one, in batches of MaxHeadersPerRequest headers.  Live headers
+// are already sent when the catch up runs, hence a client may receive a header
+// twice and must handle duplicates.
+func catchUpHeaders(wsc *wsClient, fromOrder uint64) error {
+	chain := wsc.server.BC
+	mainOrder := uint64(chain.BestSnapshot().GraphState.GetMainOrder())
+	for order := fromOrder; order <= mainOrder; order += MaxHeadersPerRequest {
+		// Stop the catch up if the client disconnected.
+		select {
+		case <-wsc.quit:
+			return ErrClientQuit
+		default:
+		}
+
+		result, err := HeadersResult(chain, order, MaxHeadersPerRequest)
+		if err != nil {
+			log.Error(fmt.Sprintf("Error looking up headers from order "+
+				"%d: %v", order, err))
+			return cmds.ErrRPCBlockNotFound
+		}
+		marshalledJSON, err := cmds.MarshalCmd(nil, cmds.NewHeadersNtfn(*result))
+		if err != nil {
+			log.Error(fmt.Sprintf("Failed to marshal headers "+
+				"notification: %v", err))
+			return nil
+		}
+		err = wsc.QueueNotification(marshalledJSON)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package rpc
 
 import (
+	"encoding/hex"
 	"fmt"
 	"github.com/Qitmeer/qitmeer/common/hash"
 	"github.com/Qitmeer/qitmeer/common/marshal"
@@ -26,11 +27,31 @@ type notificationReorganization struct {
 	NewOrder  uint64
 }
 
+type notificationChainReorg blockchain.ChainReorgNotifyData
+
 type notificationTxAcceptedByMempool struct {
 	isNew bool
 	tx    *types.Tx
 }
 
+type notificationDoubleSpendProof types.DoubleSpendProof
+
+type notificationTxEvicted struct {
+	tx     *types.Tx
+	reason string
+}
+
+type notificationMinedBlockStale struct {
+	hash   *hash.Hash
+	reason string
+	hints  []string
+}
+
+type notificationClusterHint struct {
+	peer string
+	hint []byte
+}
+
 type notificationTxByBlock struct {
 	blk *types.SerializedBlock
 	tx  *types.Tx
@@ -39,13 +60,54 @@ type notificationTxByBlock struct {
 // Notification control requests
 type notificationRegisterClient wsClient
 type notificationUnregisterClient wsClient
+type notificationParkClient wsClient
 type notificationRegisterBlocks wsClient
 type notificationRegisterTxConfirms wsClient
 type notificationUnregisterBlocks wsClient
+type notificationRegisterHeaders wsClient
+type notificationUnregisterHeaders wsClient
+type notificationRegisterFeeHistogram wsClient
+type notificationRegisterDag wsClient
+type notificationUnregisterDag wsClient
+type notificationUnregisterFeeHistogram wsClient
 type notificationRegisterNewMempoolTxs wsClient
 type notificationUnregisterNewMempoolTxs wsClient
 type notificationScanComplete wsClient
 
+type notificationRegisterAddr struct {
+	wsc   *wsClient
+	addrs []string
+}
+
+type notificationUnregisterAddr struct {
+	wsc   *wsClient
+	addrs []string
+}
+
+type notificationRegisterSpent struct {
+	wsc *wsClient
+	ops []*types.TxOutPoint
+}
+
+type notificationUnregisterSpent struct {
+	wsc *wsClient
+	ops []*types.TxOutPoint
+}
+
+type notificationResumeSession struct {
+	wsc       *wsClient
+	sessionID uint64
+	cursor    uint64
+	done      chan error
+}
+
+// parkedSession is the session of a disconnected client, whose subscriptions
+// are kept alive until the client resumes it or it expires.
+type parkedSession struct {
+	wsc    *wsClient
+	parked time.Time
+}
+
 type wsNotificationManager struct {
 	server            *RpcServer
 	queueNotification chan interface{}
@@ -53,6 +115,10 @@ type wsNotificationManager struct {
 	numClients        chan int
 	wg                sync.WaitGroup
 	quit              chan struct{}
+
+	// maxParkedSessions is the number of sessions of disconnected clients
+	// kept alive, beyond which the oldest is dropped.
+	maxParkedSessions int
 }
 
 func (m *wsNotificationManager) Start() {
@@ -75,8 +141,51 @@ func (m *wsNotificationManager) notificationHandler() {
 	// clients is a map of all currently connected websocket clients.
 	clients := make(map[chan struct{}]*wsClient)
 	blockNotifications := make(map[chan struct{}]*wsClient)
+	headerNotifications := make(map[chan struct{}]*wsClient)
+	feeHistogramNotifications := make(map[chan struct{}]*wsClient)
+	dagNotifications := make(map[chan struct{}]*wsClient)
 	txNotifications := make(map[chan struct{}]*wsClient)
 	txConfirms := make(map[chan struct{}]*wsClient)
+	watchedAddrs := make(map[string]map[chan struct{}]*wsClient)
+	watchedOutPoints := make(map[types.TxOutPoint]map[chan struct{}]*wsClient)
+
+	// sessions are the parked sessions of the disconnected clients by ID.
+	// They do not count against the websocket limit.
+	sessions := make(map[uint64]*parkedSession)
+
+	// removeClient removes any requests made by the client as well as the
+	// client itself.
+	removeClient := func(wsc *wsClient) {
+		delete(blockNotifications, wsc.quit)
+		delete(headerNotifications, wsc.quit)
+		delete(feeHistogramNotifications, wsc.quit)
+		delete(dagNotifications, wsc.quit)
+		delete(txNotifications, wsc.quit)
+		delete(txConfirms, wsc.quit)
+		for addr := range wsc.addrRequests {
+			m.removeAddrRequest(watchedAddrs, wsc, addr)
+		}
+		for op := range wsc.spentRequests {
+			m.removeSpentRequest(watchedOutPoints, wsc, op)
+		}
+		delete(clients, wsc.quit)
+
+		// The session is kept when it was resumed by another client.
+		parked, ok := sessions[wsc.sessionID]
+		if ok && parked.wsc == wsc {
+			delete(sessions, wsc.sessionID)
+		}
+	}
+
+	// The fee histogram is polled rather than sent for every mempool
+	// change, and only sent again when it changed.
+	var lastFeeHistogram *json.FeeHistogramResult
+	feeHistogramTicker := time.NewTicker(feeHistogramInterval)
+	defer feeHistogramTicker.Stop()
+
+	// The colors of the latest blocks are remembered to send their flips to
+	// the dag subscribers.
+	colors := &dagColors{}
 
 out:
 	for {
@@ -93,6 +202,18 @@ out:
 					m.notifyBlockConnected(blockNotifications,
 						block)
 				}
+				if len(headerNotifications) != 0 {
+					m.notifyHeaders(headerNotifications, block)
+				}
+				if len(watchedAddrs) != 0 || len(watchedOutPoints) != 0 {
+					for _, tx := range block.Transactions() {
+						if tx.IsDuplicate {
+							continue
+						}
+						m.notifyRelevantTx(watchedAddrs, watchedOutPoints,
+							tx, block)
+					}
+				}
 
 			case *notificationBlockDisconnected:
 				block := (*types.SerializedBlock)(n)
@@ -108,6 +229,8 @@ out:
 					m.notifyBlockAccepted(blockNotifications,
 						block)
 				}
+				m.notifyDag(dagNotifications,
+					m.dagAddedEvents(colors, block))
 				if band.IsMainChainTipChange {
 					// do something
 					if len(txConfirms) != 0 {
@@ -124,11 +247,39 @@ out:
 					m.notifyReorganization(blockNotifications, n)
 				}
 
+			case *notificationChainReorg:
+				if len(blockNotifications) != 0 {
+					m.notifyChainReorg(blockNotifications, n)
+				}
+				m.notifyDag(dagNotifications, m.dagReorgEvents(colors,
+					(*blockchain.ChainReorgNotifyData)(n)))
+
 			case *notificationTxAcceptedByMempool:
 
 				if n.isNew && len(txNotifications) != 0 {
 					m.notifyForNewTx(txNotifications, n.tx)
 				}
+				if n.isNew && (len(watchedAddrs) != 0 || len(watchedOutPoints) != 0) {
+					m.notifyRelevantTx(watchedAddrs, watchedOutPoints,
+						n.tx, nil)
+				}
+
+			case *notificationDoubleSpendProof:
+				m.notifyDoubleSpendProof(txNotifications, watchedOutPoints,
+					(*types.DoubleSpendProof)(n))
+
+			case *notificationTxEvicted:
+				m.notifyTxEvicted(txNotifications, watchedOutPoints, n)
+
+			case *notificationMinedBlockStale:
+				if len(blockNotifications) != 0 {
+					m.notifyMinedBlockStale(blockNotifications, n)
+				}
+
+			case *notificationClusterHint:
+				if len(blockNotifications) != 0 {
+					m.notifyClusterHint(blockNotifications, n)
+				}
 
 			case *notificationRegisterBlocks:
 				wsc := (*wsClient)(n)
@@ -142,17 +293,62 @@ out:
 				wsc := (*wsClient)(n)
 				delete(blockNotifications, wsc.quit)
 
+			case *notificationRegisterHeaders:
+				wsc := (*wsClient)(n)
+				headerNotifications[wsc.quit] = wsc
+
+			case *notificationUnregisterHeaders:
+				wsc := (*wsClient)(n)
+				delete(headerNotifications, wsc.quit)
+
+			case *notificationRegisterFeeHistogram:
+				wsc := (*wsClient)(n)
+				feeHistogramNotifications[wsc.quit] = wsc
+				m.notifyFeeHistogram(map[chan struct{}]*wsClient{
+					wsc.quit: wsc}, nil)
+
+			case *notificationUnregisterFeeHistogram:
+				wsc := (*wsClient)(n)
+				delete(feeHistogramNotifications, wsc.quit)
+
+			case *notificationRegisterDag:
+				wsc := (*wsClient)(n)
+				dagNotifications[wsc.quit] = wsc
+
+			case *notificationUnregisterDag:
+				wsc := (*wsClient)(n)
+				delete(dagNotifications, wsc.quit)
+
 			case *notificationRegisterClient:
 				wsc := (*wsClient)(n)
 				clients[wsc.quit] = wsc
 
 			case *notificationUnregisterClient:
-				wsc := (*wsClient)(n)
-				// Remove any requests made by the client as well as
-				// the client itself.
-				delete(blockNotifications, wsc.quit)
+				removeClient((*wsClient)(n))
 
+			case *notificationParkClient:
+				wsc := (*wsClient)(n)
+				if _, ok := clients[wsc.quit]; !ok {
+					break
+				}
 				delete(clients, wsc.quit)
+				if m.maxParkedSessions <= 0 {
+					removeClient(wsc)
+					break
+				}
+				for len(sessions) >= m.maxParkedSessions {
+					var oldest *parkedSession
+					for _, parked := range sessions {
+						if oldest == nil || parked.parked.Before(oldest.parked) {
+							oldest = parked
+						}
+					}
+					removeClient(oldest.wsc)
+				}
+				sessions[wsc.sessionID] = &parkedSession{
+					wsc:    wsc,
+					parked: time.Now(),
+				}
 
 			case *notificationRegisterNewMempoolTxs:
 				wsc := (*wsClient)(n)
@@ -163,10 +359,110 @@ out:
 				wsc := (*wsClient)(n)
 				delete(txNotifications, wsc.quit)
 
+			case *notificationRegisterAddr:
+				m.addAddrRequests(watchedAddrs, n.wsc, n.addrs)
+
+			case *notificationUnregisterAddr:
+				for _, addr := range n.addrs {
+					m.removeAddrRequest(watchedAddrs, n.wsc, addr)
+				}
+
+			case *notificationRegisterSpent:
+				m.addSpentRequests(watchedOutPoints, n.wsc, n.ops)
+
+			case *notificationUnregisterSpent:
+				for _, op := range n.ops {
+					m.removeSpentRequest(watchedOutPoints, n.wsc, *op)
+				}
+
+			case *notificationResumeSession:
+				parked, ok := sessions[n.sessionID]
+				if !ok {
+					n.done <- fmt.Errorf("session %d is unknown, "+
+						"expired or in use", n.sessionID)
+					break
+				}
+				old := parked.wsc
+				old.Lock()
+				session := old.session
+				old.Unlock()
+				ntfns, err := session.since(n.cursor)
+				if err != nil {
+					n.done <- err
+					break
+				}
+
+				// Replay the missed notifications before moving
+				// the subscriptions, so that the notifications
+				// arrive in order.
+				for _, ntfn := range ntfns {
+					n.wsc.queueRecorded(ntfn)
+				}
+				wsc := n.wsc
+				if _, ok := blockNotifications[old.quit]; ok {
+					delete(blockNotifications, old.quit)
+					blockNotifications[wsc.quit] = wsc
+				}
+				if _, ok := headerNotifications[old.quit]; ok {
+					delete(headerNotifications, old.quit)
+					headerNotifications[wsc.quit] = wsc
+				}
+				if _, ok := feeHistogramNotifications[old.quit]; ok {
+					delete(feeHistogramNotifications, old.quit)
+					feeHistogramNotifications[wsc.quit] = wsc
+				}
+				if _, ok := dagNotifications[old.quit]; ok {
+					delete(dagNotifications, old.quit)
+					dagNotifications[wsc.quit] = wsc
+				}
+				if _, ok := txNotifications[old.quit]; ok {
+					delete(txNotifications, old.quit)
+					txNotifications[wsc.quit] = wsc
+				}
+				if _, ok := txConfirms[old.quit]; ok {
+					delete(txConfirms, old.quit)
+					txConfirms[wsc.quit] = wsc
+				}
+				for addr := range old.addrRequests {
+					m.removeAddrRequest(watchedAddrs, old, addr)
+					m.addAddrRequests(watchedAddrs, wsc, []string{addr})
+				}
+				for op := range old.spentRequests {
+					opCopy := op
+					m.removeSpentRequest(watchedOutPoints, old, op)
+					m.addSpentRequests(watchedOutPoints, wsc,
+						[]*types.TxOutPoint{&opCopy})
+				}
+				old.Lock()
+				verboseTxUpdates := old.verboseTxUpdates
+				filterData := old.filterData
+				old.Unlock()
+				old.TxConfirmsLock.Lock()
+				txConfirmServer := old.TxConfirms
+				old.TxConfirmsLock.Unlock()
+
+				wsc.Lock()
+				delete(sessions, n.sessionID)
+				wsc.sessionID = n.sessionID
+				wsc.session = session
+				wsc.verboseTxUpdates = verboseTxUpdates
+				wsc.filterData = filterData
+				wsc.Unlock()
+				wsc.TxConfirmsLock.Lock()
+				wsc.TxConfirms = txConfirmServer
+				wsc.TxConfirmsLock.Unlock()
+				n.done <- nil
+
 			default:
 				log.Warn("Unhandled notification type")
 			}
 
+		case <-feeHistogramTicker.C:
+			if len(feeHistogramNotifications) != 0 {
+				lastFeeHistogram = m.notifyFeeHistogram(
+					feeHistogramNotifications, lastFeeHistogram)
+			}
+
 		case m.numClients <- len(clients):
 
 		case <-m.quit:
@@ -279,6 +575,55 @@ func (m *wsNotificationManager) notifyReorganization(clients map[chan struct{}]*
 	}
 }
 
+// NotifyChainReorg passes a completed reorganization to the notification
+// manager.
+func (m *wsNotificationManager) NotifyChainReorg(cd *blockchain.ChainReorgNotifyData) {
+	select {
+	case m.queueNotification <- (*notificationChainReorg)(cd):
+	case <-m.quit:
+	}
+}
+
+// notifyChainReorg sends a chainreorg notification with the detached,
+// attached and reordered blocks to the clients receiving the block
+// notifications.
+func (m *wsNotificationManager) notifyChainReorg(clients map[chan struct{}]*wsClient, n *notificationChainReorg) {
+	cd := (*blockchain.ChainReorgNotifyData)(n)
+	result := json.ChainReorgResult{
+		Detached:  make([]json.BlockOrderResult, 0, len(cd.Detached)),
+		Attached:  make([]json.BlockOrderResult, 0, len(cd.Attached)),
+		Reordered: []json.BlockReorderResult{},
+	}
+	for _, bo := range cd.Detached {
+		result.Detached = append(result.Detached, json.BlockOrderResult{
+			Hash:  bo.Hash.String(),
+			Order: bo.Order,
+		})
+	}
+	for _, bo := range cd.Attached {
+		result.Attached = append(result.Attached, json.BlockOrderResult{
+			Hash:  bo.Hash.String(),
+			Order: bo.Order,
+		})
+	}
+	for _, br := range cd.Reordered() {
+		result.Reordered = append(result.Reordered, json.BlockReorderResult{
+			Hash:     br.Hash.String(),
+			OldOrder: br.OldOrder,
+			NewOrder: br.NewOrder,
+		})
+	}
+	marshalledJSON, err := cmds.MarshalCmd(nil, cmds.NewChainReorgNtfn(result))
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to marshal chain reorg notification: "+
+			"%v", err))
+		return
+	}
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
 func (m *wsNotificationManager) NumClients() (n int) {
 	select {
 	case n = <-m.numClients:
@@ -298,10 +643,44 @@ func (m *wsNotificationManager) RemoveClient(wsc *wsClient) {
 	}
 }
 
+// ParkClient parks the session of the disconnected client, so that its
+// subscriptions are kept alive for another client to resume the session.  The
+// client does not count against the websocket limit anymore.
+func (m *wsNotificationManager) ParkClient(wsc *wsClient) {
+	select {
+	case m.queueNotification <- (*notificationParkClient)(wsc):
+	case <-m.quit:
+	}
+}
+
 func (m *wsNotificationManager) RegisterBlockUpdates(wsc *wsClient) {
 	m.queueNotification <- (*notificationRegisterBlocks)(wsc)
 }
 
+func (m *wsNotificationManager) RegisterHeaderUpdates(wsc *wsClient) {
+	m.queueNotification <- (*notificationRegisterHeaders)(wsc)
+}
+
+func (m *wsNotificationManager) UnregisterHeaderUpdates(wsc *wsClient) {
+	m.queueNotification <- (*notificationUnregisterHeaders)(wsc)
+}
+
+func (m *wsNotificationManager) RegisterFeeHistogramUpdates(wsc *wsClient) {
+	m.queueNotification <- (*notificationRegisterFeeHistogram)(wsc)
+}
+
+func (m *wsNotificationManager) UnregisterFeeHistogramUpdates(wsc *wsClient) {
+	m.queueNotification <- (*notificationUnregisterFeeHistogram)(wsc)
+}
+
+func (m *wsNotificationManager) RegisterDagUpdates(wsc *wsClient) {
+	m.queueNotification <- (*notificationRegisterDag)(wsc)
+}
+
+func (m *wsNotificationManager) UnregisterDagUpdates(wsc *wsClient) {
+	m.queueNotification <- (*notificationUnregisterDag)(wsc)
+}
+
 func (m *wsNotificationManager) RegisterTxConfirm(wsc *wsClient) {
 	m.queueNotification <- (*notificationRegisterTxConfirms)(wsc)
 }
@@ -326,6 +705,65 @@ func (m *wsNotificationManager) RegisterNotifyComplete(wsc *wsClient) {
 	m.queueNotification <- (*notificationScanComplete)(wsc)
 }
 
+// RegisterTxOutAddressRequests requests recvtx notifications to the passed
+// websocket client when a transaction output pays to one of the passed
+// addresses.  The addresses must be in their encoded form.
+func (m *wsNotificationManager) RegisterTxOutAddressRequests(wsc *wsClient, addrs []string) {
+	m.queueNotification <- &notificationRegisterAddr{
+		wsc:   wsc,
+		addrs: addrs,
+	}
+}
+
+// UnregisterTxOutAddressRequests removes recvtx notifications of the passed
+// addresses for the websocket client.
+func (m *wsNotificationManager) UnregisterTxOutAddressRequests(wsc *wsClient, addrs []string) {
+	m.queueNotification <- &notificationUnregisterAddr{
+		wsc:   wsc,
+		addrs: addrs,
+	}
+}
+
+// RegisterSpentRequests requests redeemingtx notifications to the passed
+// websocket client when one of the passed outpoints is spent.
+func (m *wsNotificationManager) RegisterSpentRequests(wsc *wsClient, ops []*types.TxOutPoint) {
+	m.queueNotification <- &notificationRegisterSpent{
+		wsc: wsc,
+		ops: ops,
+	}
+}
+
+// UnregisterSpentRequests removes redeemingtx notifications of the passed
+// outpoints for the websocket client.
+func (m *wsNotificationManager) UnregisterSpentRequests(wsc *wsClient, ops []*types.TxOutPoint) {
+	m.queueNotification <- &notificationUnregisterSpent{
+		wsc: wsc,
+		ops: ops,
+	}
+}
+
+// ResumeSession moves the subscriptions of a disconnected client to the
+// passed client and replays the notifications following the cursor.
+func (m *wsNotificationManager) ResumeSession(wsc *wsClient, sessionID uint64, cursor uint64) error {
+	n := &notificationResumeSession{
+		wsc:       wsc,
+		sessionID: sessionID,
+		cursor:    cursor,
+		done:      make(chan error, 1),
+	}
+	select {
+	case m.queueNotification <- n:
+	case <-m.quit:
+		return ErrClientQuit
+	}
+	select {
+	case err := <-n.done:
+		return err
+	case <-m.quit:
+		return ErrClientQuit
+	}
+}
+
 func (m *wsNotificationManager) NotifyMempoolTx(tx *types.Tx, isNew bool) {
 	n := &notificationTxAcceptedByMempool{
 		isNew: isNew,
@@ -338,6 +776,155 @@ func (m *wsNotificationManager) NotifyMempoolTx(tx *types.Tx, isNew bool) {
 	}
 }
 
+// NotifyDoubleSpendProof passes a double spend proof generated or received by
+// the mempool to the notification manager.
+func (m *wsNotificationManager) NotifyDoubleSpendProof(proof *types.DoubleSpendProof) {
+	select {
+	case m.queueNotification <- (*notificationDoubleSpendProof)(proof):
+	case <-m.quit:
+	}
+}
+
+// notifyDoubleSpendProof sends a doublespendproof notification to the clients
+// receiving the mempool transactions and to the clients watching the double
+// spent outpoint.
+func (m *wsNotificationManager) notifyDoubleSpendProof(txClients map[chan struct{}]*wsClient,
+	opMap map[types.TxOutPoint]map[chan struct{}]*wsClient, proof *types.DoubleSpendProof) {
+
+	clientsToNotify := make(map[chan struct{}]*wsClient, len(txClients))
+	for quitChan, wsc := range txClients {
+		clientsToNotify[quitChan] = wsc
+	}
+	for quitChan, wsc := range opMap[proof.OutPoint] {
+		clientsToNotify[quitChan] = wsc
+	}
+	if len(clientsToNotify) == 0 {
+		return
+	}
+
+	result, err := DoubleSpendProofResult(proof)
+	if err != nil {
+		log.Error(err.Error())
+		return
+	}
+	marshalledJSON, err := cmds.MarshalCmd(nil, cmds.NewDoubleSpendProofNtfn(*result))
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to marshal double spend proof "+
+			"notification: %v", err))
+		return
+	}
+	for _, wsc := range clientsToNotify {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
+// NotifyTxEvicted passes a transaction evicted from the mempool to the
+// notification manager.
+func (m *wsNotificationManager) NotifyTxEvicted(tx *types.Tx, reason string) {
+	n := &notificationTxEvicted{
+		tx:     tx,
+		reason: reason,
+	}
+
+	select {
+	case m.queueNotification <- n:
+	case <-m.quit:
+	}
+}
+
+// notifyTxEvicted sends a txevicted notification to the clients receiving the
+// mempool transactions and to the clients watching an outpoint spent by the
+// evicted transaction.
+func (m *wsNotificationManager) notifyTxEvicted(txClients map[chan struct{}]*wsClient,
+	opMap map[types.TxOutPoint]map[chan struct{}]*wsClient, n *notificationTxEvicted) {
+
+	clientsToNotify := make(map[chan struct{}]*wsClient, len(txClients))
+	for quitChan, wsc := range txClients {
+		clientsToNotify[quitChan] = wsc
+	}
+	for _, txIn := range n.tx.Tx.TxIn {
+		for quitChan, wsc := range opMap[txIn.PreviousOut] {
+			clientsToNotify[quitChan] = wsc
+		}
+	}
+	if len(clientsToNotify) == 0 {
+		return
+	}
+
+	marshalledJSON, err := cmds.MarshalCmd(nil, cmds.NewTxEvictedNtfn(n.tx.Hash().String(), n.reason))
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to marshal tx evicted "+
+			"notification: %v", err))
+		return
+	}
+	for _, wsc := range clientsToNotify {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
+// NotifyMinedBlockStale passes a block mined by the node which ended up red or
+// moved back in the order to the notification manager.
+func (m *wsNotificationManager) NotifyMinedBlockStale(h *hash.Hash, reason string, hints []string) {
+	n := &notificationMinedBlockStale{
+		hash:   h,
+		reason: reason,
+		hints:  hints,
+	}
+
+	select {
+	case m.queueNotification <- n:
+	case <-m.quit:
+	}
+}
+
+// notifyMinedBlockStale sends a minedblockstale notification to the clients
+// receiving the block notifications, such as the miners.
+func (m *wsNotificationManager) notifyMinedBlockStale(clients map[chan struct{}]*wsClient,
+	n *notificationMinedBlockStale) {
+
+	marshalledJSON, err := cmds.MarshalCmd(nil,
+		cmds.NewMinedBlockStaleNtfn(n.hash.String(), n.reason, n.hints))
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to marshal mined block stale "+
+			"notification: %v", err))
+		return
+	}
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
+// NotifyClusterHint passes a template hint received from a peer of the mining
+// cluster to the notification manager.
+func (m *wsNotificationManager) NotifyClusterHint(peer string, hint []byte) {
+	n := &notificationClusterHint{
+		peer: peer,
+		hint: hint,
+	}
+
+	select {
+	case m.queueNotification <- n:
+	case <-m.quit:
+	}
+}
+
+// notifyClusterHint sends a clusterhint notification to the clients receiving
+// the block notifications, such as the pool software.
+func (m *wsNotificationManager) notifyClusterHint(clients map[chan struct{}]*wsClient,
+	n *notificationClusterHint) {
+
+	marshalledJSON, err := cmds.MarshalCmd(nil,
+		cmds.NewClusterHintNtfn(n.peer, hex.EncodeToString(n.hint)))
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to marshal cluster hint "+
+			"notification: %v", err))
+		return
+	}
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
 func (m *wsNotificationManager) NotifyBlockTx(wsc *wsClient, tx *types.Tx, blk *types.SerializedBlock) {
 	m.notifyForBlockTx(wsc, tx, blk)
 }
@@ -561,6 +1148,7 @@ func newWsNotificationManager(server *RpcServer) *wsNotificationManager {
 		notificationMsgs:  make(chan interface{}),
 		numClients:        make(chan int),
 		quit:              make(chan struct{}),
+		maxParkedSessions: server.config.RPCMaxWebsockets,
 	}
 }
 
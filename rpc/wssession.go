@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/rpc/client/cmds"
+	"sync"
+	"time"
+)
+
+const (
+	// sessionRingSize is the number of the latest notifications which are
+	// kept for each websocket session to be replayed on resumption.
+	sessionRingSize = 1024
+
+	// sessionTimeout is how long the subscriptions of a disconnected
+	// websocket client are kept alive, waiting for the client to resume its
+	// session.
+	sessionTimeout = 2 * time.Minute
+)
+
+// seqNotification is a notification stamped with the sequence number of its
+// session.
+type seqNotification struct {
+	cmds.Request
+	Seq uint64 `json:"seq"`
+}
+
+type sessionNtfn struct {
+	seq uint64
+	msg []byte
+}
+
+// wsSession numbers the notifications sent to a websocket client and keeps
+// the latest of them in a ring buffer.  A reconnecting client resumes the
+// session from the sequence number of the last notification it received, its
+// cursor, so that the notifications sent during the disconnect are replayed.
+type wsSession struct {
+	mtx sync.Mutex
+
+	id   uint64
+	seq  uint64
+	ring [sessionRingSize]sessionNtfn
+}
+
+func newWSSession(id uint64) *wsSession {
+	return &wsSession{
+		id: id,
+	}
+}
+
+// record stamps the notification with the next sequence number and keeps it
+// in the ring buffer.  The stamped notification is returned.
+func (s *wsSession) record(marshalledJSON []byte) ([]byte, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var ntfn seqNotification
+	err := json.Unmarshal(marshalledJSON, &ntfn.Request)
+	if err != nil {
+		return nil, err
+	}
+	ntfn.Seq = s.seq + 1
+	stamped, err := json.Marshal(&ntfn)
+	if err != nil {
+		return nil, err
+	}
+	s.seq = ntfn.Seq
+	s.ring[s.seq%sessionRingSize] = sessionNtfn{seq: s.seq, msg: stamped}
+	return stamped, nil
+}
+
+// since returns the notifications following the passed cursor.  An error is
+// returned when the cursor is unknown or the notifications following it are
+// not kept anymore.
+func (s *wsSession) since(cursor uint64) ([][]byte, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if cursor > s.seq {
+		return nil, fmt.Errorf("cursor %d is ahead of the session "+
+			"sequence %d", cursor, s.seq)
+	}
+	if s.seq-cursor > sessionRingSize {
+		return nil, fmt.Errorf("cursor %d is too old, only the latest "+
+			"%d notifications are kept", cursor, sessionRingSize)
+	}
+	ntfns := make([][]byte, 0, s.seq-cursor)
+	for seq := cursor + 1; seq <= s.seq; seq++ {
+		ntfns = append(ntfns, s.ring[seq%sessionRingSize].msg)
+	}
+	return ntfns, nil
+}
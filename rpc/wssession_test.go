@@ -0,0 +1,164 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/config"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"testing"
+)
+
+// testNtfn returns a marshalled notification carrying the number.
+func testNtfn(i int) []byte {
+	return []byte(fmt.Sprintf(`{"jsonrpc":"2.0","method":"test","params":[%d],"id":null}`, i))
+}
+
+// ntfnSeq returns the sequence number and the parameter of a recorded
+// notification.
+func ntfnSeq(t *testing.T, msg []byte) (uint64, string) {
+	var ntfn seqNotification
+	err := json.Unmarshal(msg, &ntfn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ntfn.Seq, string(ntfn.Params[0])
+}
+
+func TestWSSession(t *testing.T) {
+	s := newWSSession(1)
+	for i := 1; i <= 3; i++ {
+		stamped, err := s.record(testNtfn(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seq, param := ntfnSeq(t, stamped); seq != uint64(i) || param != fmt.Sprint(i) {
+			t.Fatalf("notification %d stamped %d with %s", i, seq, param)
+		}
+	}
+
+	ntfns, err := s.since(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ntfns) != 2 {
+		t.Fatalf("%d notifications since 1, want 2", len(ntfns))
+	}
+	for i, ntfn := range ntfns {
+		if seq, _ := ntfnSeq(t, ntfn); seq != uint64(i+2) {
+			t.Fatalf("notification %d has sequence %d", i, seq)
+		}
+	}
+	if ntfns, err := s.since(3); err != nil || len(ntfns) != 0 {
+		t.Fatalf("unexpected notifications since the last one: %v %v", ntfns, err)
+	}
+	if _, err := s.since(4); err == nil {
+		t.Fatal("no error for a cursor ahead of the session")
+	}
+	if _, err := s.record([]byte("not json")); err == nil || s.seq != 3 {
+		t.Fatal("invalid notification recorded")
+	}
+
+	// Once the ring overflows, only the latest notifications are replayed.
+	for i := 4; i <= sessionRingSize+4; i++ {
+		if _, err := s.record(testNtfn(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.since(3); err == nil {
+		t.Fatal("no error for an overwritten cursor")
+	}
+	ntfns, err = s.since(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ntfns) != sessionRingSize {
+		t.Fatalf("%d notifications replayed, want %d", len(ntfns), sessionRingSize)
+	}
+	if seq, param := ntfnSeq(t, ntfns[0]); seq != 5 || param != "5" {
+		t.Fatalf("first replayed notification %d with %s", seq, param)
+	}
+}
+
+// testWSClient returns a websocket client without connection.
+func testWSClient(sessionID uint64, disconnected bool) *wsClient {
+	return &wsClient{
+		disconnected:  disconnected,
+		sessionID:     sessionID,
+		session:       newWSSession(sessionID),
+		ntfnChan:      make(chan []byte, 1),
+		quit:          make(chan struct{}),
+		TxConfirms:    &WatchTxConfirmServer{},
+		addrRequests:  make(map[string]struct{}),
+		spentRequests: make(map[types.TxOutPoint]struct{}),
+	}
+}
+
+func TestParkedSessions(t *testing.T) {
+	m := newWsNotificationManager(&RpcServer{
+		config: &config.Config{RPCMaxWebsockets: 1},
+	})
+	m.Start()
+	defer m.Stop()
+	// flush waits for the queued notifications to be handled, since the
+	// resumptions are queued behind them.
+	flush := func() {
+		m.ResumeSession(testWSClient(0, false), 0, 0)
+	}
+
+	// A parked client does not count against the websocket limit.
+	c1 := testWSClient(1, true)
+	m.AddClient(c1)
+	flush()
+	if n := m.NumClients(); n != 1 {
+		t.Fatalf("%d clients, want 1", n)
+	}
+	m.ParkClient(c1)
+	flush()
+	if n := m.NumClients(); n != 0 {
+		t.Fatalf("%d clients after parking, want 0", n)
+	}
+
+	// The oldest session is dropped beyond the parked sessions limit.
+	c2 := testWSClient(2, true)
+	m.AddClient(c2)
+	m.RegisterBlockUpdates(c2)
+	c2.QueueNotification(testNtfn(1))
+	m.ParkClient(c2)
+
+	c3 := testWSClient(3, false)
+	m.AddClient(c3)
+	flush()
+	if err := m.ResumeSession(c3, 1, 0); err == nil {
+		t.Fatal("resumed a dropped session")
+	}
+	if err := m.ResumeSession(c3, 2, 1); err != nil {
+		t.Fatal(err)
+	}
+	if c3.sessionID != 2 || c3.session != c2.session {
+		t.Fatalf("session %d not resumed", c3.sessionID)
+	}
+	if err := m.ResumeSession(testWSClient(4, false), 2, 1); err == nil {
+		t.Fatal("resumed a session in use")
+	}
+
+	// The missed notifications are replayed to the resuming client.
+	c4 := testWSClient(4, false)
+	m.AddClient(c4)
+	m.ParkClient(c3)
+	c3.disconnected = true
+	if err := m.ResumeSession(c4, 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if seq, param := ntfnSeq(t, <-c4.ntfnChan); seq != 1 || param != "1" {
+		t.Fatalf("replayed notification %d with %s", seq, param)
+	}
+
+	// The live clients are removed before the manager stops, which
+	// notifies them otherwise.
+	m.RemoveClient(c4)
+	flush()
+}
@@ -0,0 +1,286 @@
+/*
+ * Copyright (c) 2017-2020 The qitmeer developers
+ */
+
+package rpc
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/marshal"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/rpc/client/cmds"
+)
+
+// The functions in this file implement the address and outpoint scoped
+// subscriptions of the notifyReceived and notifySpent commands.  The
+// subscription maps are owned by the notification handler goroutine of the
+// wsNotificationManager, which is also the only user of the addrRequests and
+// spentRequests of a wsClient.
+
+// addAddrRequests adds the websocket client to the watchers of the passed
+// addresses.
+func (m *wsNotificationManager) addAddrRequests(addrMap map[string]map[chan struct{}]*wsClient,
+	wsc *wsClient, addrs []string) {
+
+	for _, addr := range addrs {
+		wsc.addrRequests[addr] = struct{}{}
+
+		cmap, ok := addrMap[addr]
+		if !ok {
+			cmap = make(map[chan struct{}]*wsClient)
+			addrMap[addr] = cmap
+		}
+		cmap[wsc.quit] = wsc
+	}
+}
+
+// removeAddrRequest removes the websocket client from the watchers of the
+// passed address.
+func (m *wsNotificationManager) removeAddrRequest(addrMap map[string]map[chan struct{}]*wsClient,
+	wsc *wsClient, addr string) {
+
+	delete(wsc.addrRequests, addr)
+
+	cmap, ok := addrMap[addr]
+	if !ok {
+		return
+	}
+	delete(cmap, wsc.quit)
+	if len(cmap) == 0 {
+		delete(addrMap, addr)
+	}
+}
+
+// addSpentRequests adds the websocket client to the watchers of the passed
+// outpoints.
+func (m *wsNotificationManager) addSpentRequests(opMap map[types.TxOutPoint]map[chan struct{}]*wsClient,
+	wsc *wsClient, ops []*types.TxOutPoint) {
+
+	for _, op := range ops {
+		wsc.spentRequests[*op] = struct{}{}
+
+		cmap, ok := opMap[*op]
+		if !ok {
+			cmap = make(map[chan struct{}]*wsClient)
+			opMap[*op] = cmap
+		}
+		cmap[wsc.quit] = wsc
+	}
+}
+
+// removeSpentRequest removes the websocket client from the watchers of the
+// passed outpoint.
+func (m *wsNotificationManager) removeSpentRequest(opMap map[types.TxOutPoint]map[chan struct{}]*wsClient,
+	wsc *wsClient, op types.TxOutPoint) {
+
+	delete(wsc.spentRequests, op)
+
+	cmap, ok := opMap[op]
+	if !ok {
+		return
+	}
+	delete(cmap, wsc.quit)
+	if len(cmap) == 0 {
+		delete(opMap, op)
+	}
+}
+
+// notifyRelevantTx sends recvtx and redeemingtx notifications for a mempool
+// transaction, when block is nil, or for a transaction of a connected block.
+func (m *wsNotificationManager) notifyRelevantTx(addrMap map[string]map[chan struct{}]*wsClient,
+	opMap map[types.TxOutPoint]map[chan struct{}]*wsClient, tx *types.Tx,
+	block *types.SerializedBlock) {
+
+	m.notifyForTxOuts(addrMap, opMap, tx, block)
+	m.notifyForTxIns(opMap, tx, block)
+}
+
+// notifyForTxOuts sends a recvtx notification to every client watching an
+// address paid by the transaction.  The paying outputs are watched for the
+// client afterwards, so it is notified when they are spent.
+func (m *wsNotificationManager) notifyForTxOuts(addrMap map[string]map[chan struct{}]*wsClient,
+	opMap map[types.TxOutPoint]map[chan struct{}]*wsClient, tx *types.Tx,
+	block *types.SerializedBlock) {
+
+	if len(addrMap) == 0 {
+		return
+	}
+
+	clientsToNotify := make(map[chan struct{}]*wsClient)
+	for i, txOut := range tx.Tx.TxOut {
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+			txOut.PkScript, m.server.ChainParams)
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			cmap, ok := addrMap[addr.Encode()]
+			if !ok {
+				continue
+			}
+			op := []*types.TxOutPoint{types.NewOutPoint(tx.Hash(), uint32(i))}
+			for quitChan, wsc := range cmap {
+				m.addSpentRequests(opMap, wsc, op)
+				clientsToNotify[quitChan] = wsc
+			}
+		}
+	}
+	if len(clientsToNotify) == 0 {
+		return
+	}
+
+	marshalledJSON, err := marshalWatchedTxNtfn(cmds.RecvTxNtfnMethod, tx, block)
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to marshal recvtx notification: %v", err))
+		return
+	}
+	for _, wsc := range clientsToNotify {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
+// notifyForTxIns sends a redeemingtx notification to every client watching an
+// outpoint spent by the transaction.  Outpoints spent by a block transaction
+// are no longer watched, while a mempool spend keeps the outpoint watched
+// since the transaction might never be mined.
+func (m *wsNotificationManager) notifyForTxIns(opMap map[types.TxOutPoint]map[chan struct{}]*wsClient,
+	tx *types.Tx, block *types.SerializedBlock) {
+
+	if len(opMap) == 0 || tx.Tx.IsCoinBase() {
+		return
+	}
+
+	clientsToNotify := make(map[chan struct{}]*wsClient)
+	for _, txIn := range tx.Tx.TxIn {
+		prevOut := txIn.PreviousOut
+		cmap, ok := opMap[prevOut]
+		if !ok {
+			continue
+		}
+		for quitChan, wsc := range cmap {
+			if block != nil {
+				m.removeSpentRequest(opMap, wsc, prevOut)
+			}
+			clientsToNotify[quitChan] = wsc
+		}
+	}
+	if len(clientsToNotify) == 0 {
+		return
+	}
+
+	marshalledJSON, err := marshalWatchedTxNtfn(cmds.RedeemingTxNtfnMethod, tx, block)
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to marshal redeemingtx notification: %v", err))
+		return
+	}
+	for _, wsc := range clientsToNotify {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
+// marshalWatchedTxNtfn marshals a recvtx or redeemingtx notification.
+func marshalWatchedTxNtfn(method string, tx *types.Tx, block *types.SerializedBlock) ([]byte, error) {
+	txHex, err := marshal.MessageToHex(tx.Tx)
+	if err != nil {
+		return nil, err
+	}
+	var details *cmds.BlockDetails
+	if block != nil {
+		details = &cmds.BlockDetails{
+			Order: block.Order(),
+			Hash:  block.Hash().String(),
+			Index: tx.Index(),
+			Time:  block.Block().Header.Timestamp.Unix(),
+		}
+	}
+	var ntfn interface{}
+	if method == cmds.RecvTxNtfnMethod {
+		ntfn = cmds.NewRecvTxNtfn(txHex, details)
+	} else {
+		ntfn = cmds.NewRedeemingTxNtfn(txHex, details)
+	}
+	return cmds.MarshalCmd(nil, ntfn)
+}
+
+// catchUpWatched scans the blocks from the passed order up to the latest one
+// and sends the recvtx and redeemingtx notifications for the watched addresses
+// and outpoints, so that a reconnecting client receives the events it missed.
+// The unspent map is updated with the outputs received and spent during the
+// scan.  Live notifications are already registered when the scan runs, hence
+// a client may receive an event twice and must handle duplicates.
+func catchUpWatched(wsc *wsClient, addrs map[string]struct{},
+	unspent map[types.TxOutPoint]struct{}, fromOrder uint64) error {
+
+	chain := wsc.server.BC
+	mainOrder := uint64(chain.BestSnapshot().GraphState.GetMainOrder())
+	for order := fromOrder; order <= mainOrder; order++ {
+		// Stop the scan if the client disconnected.
+		select {
+		case <-wsc.quit:
+			return ErrClientQuit
+		default:
+		}
+
+		blk, err := chain.BlockByOrder(order)
+		if err != nil {
+			log.Error(fmt.Sprintf("Error looking up block of order %d: %v",
+				order, err))
+			return cmds.ErrRPCBlockNotFound
+		}
+		blk.SetOrder(order)
+		chain.CalculateDAGDuplicateTxs(blk)
+		for _, tx := range blk.Transactions() {
+			if tx.IsDuplicate {
+				continue
+			}
+			received := false
+			for i, txOut := range tx.Tx.TxOut {
+				_, outAddrs, _, err := txscript.ExtractPkScriptAddrs(
+					txOut.PkScript, wsc.server.ChainParams)
+				if err != nil {
+					continue
+				}
+				for _, addr := range outAddrs {
+					if _, ok := addrs[addr.Encode()]; ok {
+						unspent[*types.NewOutPoint(tx.Hash(), uint32(i))] = struct{}{}
+						received = true
+					}
+				}
+			}
+			redeemed := false
+			if !tx.Tx.IsCoinBase() {
+				for _, txIn := range tx.Tx.TxIn {
+					if _, ok := unspent[txIn.PreviousOut]; ok {
+						delete(unspent, txIn.PreviousOut)
+						redeemed = true
+					}
+				}
+			}
+
+			if received {
+				err = queueWatchedTxNtfn(wsc, cmds.RecvTxNtfnMethod, tx, blk)
+				if err != nil {
+					return err
+				}
+			}
+			if redeemed {
+				err = queueWatchedTxNtfn(wsc, cmds.RedeemingTxNtfnMethod, tx, blk)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func queueWatchedTxNtfn(wsc *wsClient, method string, tx *types.Tx, block *types.SerializedBlock) error {
+	marshalledJSON, err := marshalWatchedTxNtfn(method, tx, block)
+	if err != nil {
+		log.Error(fmt.Sprintf("Failed to marshal %s notification: %v",
+			method, err))
+		return nil
+	}
+	return wsc.QueueNotification(marshalledJSON)
+}
@@ -134,6 +134,138 @@ function get_mempool(){
   get_result "$data"
 }
 
+function get_mempool_stats(){
+  local data='{"jsonrpc":"2.0","method":"getMempoolStats","params":[],"id":1}'
+  get_result "$data"
+}
+
+function get_fee_histogram(){
+  local data='{"jsonrpc":"2.0","method":"getFeeHistogram","params":[],"id":1}'
+  get_result "$data"
+}
+
+function dump_mempool(){
+  local file=$1
+  if [ "$file" == "" ]; then
+    file="null"
+  else
+    file='"'$file'"'
+  fi
+  local data='{"jsonrpc":"2.0","method":"test_dumpMempool","params":['$file'],"id":1}'
+  get_result "$data"
+}
+
+function load_mempool(){
+  local file=$1
+  if [ "$file" == "" ]; then
+    file="null"
+  else
+    file='"'$file'"'
+  fi
+  local data='{"jsonrpc":"2.0","method":"test_loadMempool","params":['$file'],"id":1}'
+  get_result "$data"
+}
+
+function diff_mempool(){
+  local file1=$1
+  local file2=$2
+  local data='{"jsonrpc":"2.0","method":"test_diffMempool","params":["'$file1'","'$file2'"],"id":1}'
+  get_result "$data"
+}
+
+function get_double_spend_proofs(){
+  local txid=$1
+  if [ "$txid" == "" ]; then
+    txid="null"
+  else
+    txid='"'$txid'"'
+  fi
+  local data='{"jsonrpc":"2.0","method":"getDoubleSpendProofs","params":['$txid'],"id":1}'
+  get_result "$data"
+}
+
+function is_tx_safe_to_credit(){
+  local txid=$1
+  local confirmations=$2
+  if [ "$confirmations" == "" ]; then
+    confirmations="null"
+  fi
+  local data='{"jsonrpc":"2.0","method":"isTxSafeToCredit","params":["'$txid'",'$confirmations'],"id":1}'
+  get_result "$data"
+}
+
+function create_spend_proposal(){
+  local redeem_script=$1
+  local inputs=$2
+  local amounts=$3
+  local data='{"jsonrpc":"2.0","method":"createSpendProposal","params":["'$redeem_script'",'$inputs','$amounts'],"id":1}'
+  get_result "$data"
+}
+
+function get_spend_proposal(){
+  local id=$1
+  local data='{"jsonrpc":"2.0","method":"getSpendProposal","params":["'$id'"],"id":1}'
+  get_result "$data"
+}
+
+function import_spend_proposal(){
+  local psbt=$1
+  local data='{"jsonrpc":"2.0","method":"importSpendProposal","params":["'$psbt'"],"id":1}'
+  get_result "$data"
+}
+
+function broadcast_spend_proposal(){
+  local id=$1
+  local allow_high_fees=$2
+  if [ "$allow_high_fees" == "" ]; then
+    allow_high_fees="false"
+  fi
+  local data='{"jsonrpc":"2.0","method":"broadcastSpendProposal","params":["'$id'",'$allow_high_fees'],"id":1}'
+  get_result "$data"
+}
+
+function sign_spend_proposal(){
+  local private_key=$1
+  local psbt=$2
+  local data='{"jsonrpc":"2.0","method":"test_signSpendProposal","params":["'$private_key'","'$psbt'"],"id":1}'
+  get_result "$data"
+}
+
+function external_sign_spend_proposal(){
+  local id=$1
+  local data='{"jsonrpc":"2.0","method":"externalSignSpendProposal","params":["'$id'"],"id":1}'
+  get_result "$data"
+}
+
+function debug_script(){
+  local sign_script=$1
+  local pk_script=$2
+  local raw_tx=$3
+  local index=$4
+  if [ "$raw_tx" == "" ]; then
+    raw_tx="null"
+  else
+    raw_tx='"'$raw_tx'"'
+  fi
+  if [ "$index" == "" ]; then
+    index="null"
+  fi
+  local data='{"jsonrpc":"2.0","method":"debugScript","params":["'$sign_script'","'$pk_script'",'$raw_tx','$index'],"id":1}'
+  get_result "$data"
+}
+
+function get_address_activity(){
+  local address=$1
+  local data='{"jsonrpc":"2.0","method":"getAddressActivity","params":["'$address'"],"id":1}'
+  get_result "$data"
+}
+
+function analyze_address(){
+  local address=$1
+  local data='{"jsonrpc":"2.0","method":"analyzeAddress","params":["'$address'"],"id":1}'
+  get_result "$data"
+}
+
 # return block by hash
 #   func (s *PublicBlockChainAPI) GetBlockByHash(ctx context.Context, blockHash common.Hash, fullTx bool) (map[string]interface{}, error)
 function get_block_by_hash(){
@@ -164,6 +296,16 @@ function get_blockheader_by_hash(){
   get_result "$data"
 }
 
+function get_headers(){
+  local start_order=$1
+  local count=$2
+  if [ "$count" == "" ]; then
+    count=1
+  fi
+  local data='{"jsonrpc":"2.0","method":"getHeaders","params":['$start_order','$count'],"id":1}'
+  get_result "$data"
+}
+
 
 # return tx by hash
 function get_tx_by_id(){
@@ -198,6 +340,68 @@ function get_utxo() {
   get_result "$data"
 }
 
+# scan the UTXO set for the outputs of comma separated descriptors
+function scan_utxo_set() {
+  local descriptors=$1
+  local cursor=$2
+  local max_entries=$3
+  local range=$4
+  # the descriptors are separated by semicolons since multi() has commas
+  descriptors='["'$(echo "$descriptors" | sed 's/;/","/g')'"]'
+  if [ "$cursor" == "" ]; then
+    cursor="null"
+  else
+    cursor='"'$cursor'"'
+  fi
+  if [ "$max_entries" == "" ]; then
+    max_entries="null"
+  fi
+  if [ "$range" == "" ]; then
+    range="null"
+  fi
+  local data='{"jsonrpc":"2.0","method":"scanUtxoSet","params":['$descriptors','$cursor','$max_entries','$range'],"id":1}'
+  get_result "$data"
+}
+
+# return the statistics of the UTXO set
+function get_tx_out_set_info() {
+  local data='{"jsonrpc":"2.0","method":"getTxOutSetInfo","params":[],"id":1}'
+  get_result "$data"
+}
+
+# return the distribution of the UTXOs by creation order
+function get_utxo_age_distribution() {
+  local coin_id=$1
+  local bucket_orders=$2
+  if [ "$coin_id" == "" ]; then
+    coin_id="0"
+  fi
+  if [ "$bucket_orders" == "" ]; then
+    bucket_orders="1000"
+  fi
+  local data='{"jsonrpc":"2.0","method":"getUtxoAgeDistribution","params":['$coin_id','$bucket_orders'],"id":1}'
+  get_result "$data"
+}
+
+# return the income paid to a coinbase address
+function get_miner_stats() {
+  local address=$1
+  local start_order=$2
+  local end_order=$3
+  local verbose=$4
+  if [ "$start_order" == "" ]; then
+    start_order="null"
+  fi
+  if [ "$end_order" == "" ]; then
+    end_order="null"
+  fi
+  if [ "$verbose" == "" ]; then
+    verbose="false"
+  fi
+  local data='{"jsonrpc":"2.0","method":"getMinerStats","params":["'$address'",'$start_order','$end_order','$verbose'],"id":1}'
+  get_result "$data"
+}
+
 function tx_sign(){
    local private_key=$1
    local raw_tx=$2
@@ -268,6 +472,92 @@ function decode_raw_tx(){
   get_result "$data"
 }
 
+function fund_raw_tx(){
+  local input=$1
+  local fee_rate=$2
+  local strategy=$3
+  local change_address=$4
+  if [ "$fee_rate" == "" ]; then
+    fee_rate="null"
+  fi
+  if [ "$strategy" == "" ]; then
+    strategy="null"
+  else
+    strategy='"'$strategy'"'
+  fi
+  if [ "$change_address" == "" ]; then
+    change_address="null"
+  else
+    change_address='"'$change_address'"'
+  fi
+  local data='{"jsonrpc":"2.0","method":"fundRawTransaction","params":["'$input'",'$fee_rate','$strategy','$change_address'],"id":1}'
+  get_result "$data"
+}
+
+function consolidate_utxos(){
+  local address=$1
+  local fee_rate=$2
+  local max_value=$3
+  local max_inputs=$4
+  local redeem_script=$5
+  if [ "$fee_rate" == "" ]; then
+    fee_rate="null"
+  fi
+  if [ "$max_value" == "" ]; then
+    max_value="null"
+  fi
+  if [ "$max_inputs" == "" ]; then
+    max_inputs="null"
+  fi
+  if [ "$redeem_script" == "" ]; then
+    redeem_script="null"
+  else
+    redeem_script='"'$redeem_script'"'
+  fi
+  local data='{"jsonrpc":"2.0","method":"consolidateUtxos","params":["'$address'",'$fee_rate','$max_value','$max_inputs','$redeem_script'],"id":1}'
+  get_result "$data"
+}
+
+# set the label of an address, an empty label removes it
+function set_address_label(){
+  local address=$1
+  local label=$2
+  local data='{"jsonrpc":"2.0","method":"test_setAddressLabel","params":["'$address'","'$label'"],"id":1}'
+  get_result "$data"
+}
+
+function get_addresses_by_label(){
+  local label=$1
+  local data='{"jsonrpc":"2.0","method":"getAddressesByLabel","params":["'$label'"],"id":1}'
+  get_result "$data"
+}
+
+# freeze the outpoints (txid:index) and addresses, on a private network only
+function freeze_coins(){
+  local inputs=$1
+  local reason=$2
+  if [ "$reason" == "" ]; then
+    reason="null"
+  else
+    reason='"'$reason'"'
+  fi
+  local targets=$(echo $inputs | sed 's/,/","/g')
+  local data='{"jsonrpc":"2.0","method":"freeze_freezeCoins","params":[["'$targets'"],'$reason'],"id":1}'
+  get_result "$data"
+}
+
+function unfreeze_coins(){
+  local inputs=$1
+  local targets=$(echo $inputs | sed 's/,/","/g')
+  local data='{"jsonrpc":"2.0","method":"freeze_unfreezeCoins","params":[["'$targets'"]],"id":1}'
+  get_result "$data"
+}
+
+function list_frozen_coins(){
+  local data='{"jsonrpc":"2.0","method":"freeze_listFrozenCoins","params":[],"id":1}'
+  get_result "$data"
+}
+
 function send_raw_tx(){
   local input=$1
   local allow_high_fee=$2
@@ -279,6 +569,19 @@ function send_raw_tx(){
   get_result "$data"
 }
 
+# submit a package of signed raw txs, the child last
+function submit_tx_package(){
+  local inputs=$1
+  local allow_high_fee=$2
+  if [ "$allow_high_fee" == "" ]; then
+    allow_high_fee="false"
+  fi
+  local txs=$(echo $inputs | sed 's/,/","/g')
+
+  local data='{"jsonrpc":"2.0","method":"submitTxPackage","params":[["'$txs'"],'$allow_high_fee'],"id":1}'
+  get_result "$data"
+}
+
 function generate() {
   local count=$1
   local powtype=$2
@@ -301,13 +604,35 @@ function is_on_mainchain(){
   get_result "$data"
 }
 
+# the include and exclude lists are comma separated transaction hashes
 function get_block_template(){
   local capabilities=$1
   local powtype=$2
+  local include=$3
+  local exclude=$4
   if [ "$powtype" == "" ]; then
     powtype=6
   fi
-  local data='{"jsonrpc":"2.0","method":"getBlockTemplate","params":[["'$capabilities'"],'$powtype'],"id":1}'
+  if [ "$include" == "" ]; then
+    include="null"
+  else
+    include='["'${include//,/\",\"}'"]'
+  fi
+  if [ "$exclude" == "" ]; then
+    exclude="null"
+  else
+    exclude='["'${exclude//,/\",\"}'"]'
+  fi
+  local data='{"jsonrpc":"2.0","method":"getBlockTemplate","params":[["'$capabilities'"],'$powtype','$include','$exclude'],"id":1}'
+  get_result "$data"
+}
+
+function get_block_template_witness(){
+  local powtype=$1
+  if [ "$powtype" == "" ]; then
+    powtype=6
+  fi
+  local data='{"jsonrpc":"2.0","method":"getBlockTemplateWitness","params":['$powtype'],"id":1}'
   get_result "$data"
 }
 
@@ -316,6 +641,48 @@ function get_mainchain_height(){
   get_result "$data"
 }
 
+function get_mainchain_tip(){
+  local data='{"jsonrpc":"2.0","method":"getMainChainTip","params":[],"id":1}'
+  get_result "$data"
+}
+
+function get_mainchain_block(){
+  local height=$1
+  local data='{"jsonrpc":"2.0","method":"getMainChainBlockByHeight","params":['$height'],"id":1}'
+  get_result "$data"
+}
+
+function get_locktime_cursor(){
+  local data='{"jsonrpc":"2.0","method":"getLockTimeCursor","params":[],"id":1}'
+  get_result "$data"
+}
+
+function get_network_hashps(){
+  local window=$1
+  local pow_type=$2
+  if [ "$window" == "" ]; then
+    window="null"
+  fi
+  if [ "$pow_type" == "" ]; then
+    pow_type="null"
+  fi
+  local data='{"jsonrpc":"2.0","method":"getNetworkHashPS","params":['$window','$pow_type'],"id":1}'
+  get_result "$data"
+}
+
+function get_difficulty_history(){
+  local window=$1
+  local pow_type=$2
+  if [ "$window" == "" ]; then
+    window="null"
+  fi
+  if [ "$pow_type" == "" ]; then
+    pow_type="null"
+  fi
+  local data='{"jsonrpc":"2.0","method":"getDifficultyHistory","params":['$window','$pow_type'],"id":1}'
+  get_result "$data"
+}
+
 function get_block_weight(){
   local block_hash=$1
   local data='{"jsonrpc":"2.0","method":"getBlockWeight","params":["'$block_hash'"],"id":1}'
@@ -343,6 +710,39 @@ function get_node_info(){
   get_result "$data"
 }
 
+function get_node_stats(){
+  local data='{"jsonrpc":"2.0","method":"getNodeStats","params":[],"id":null}'
+  get_result "$data"
+}
+
+function perf_report(){
+  local minutes=$1
+  local count=$2
+  if [ "$minutes" == "" ]; then
+    minutes="null"
+  fi
+  if [ "$count" == "" ]; then
+    count="null"
+  fi
+  local data='{"jsonrpc":"2.0","method":"perfReport","params":['$minutes','$count'],"id":null}'
+  get_result "$data"
+}
+
+function get_index_backfill_info(){
+  local data='{"jsonrpc":"2.0","method":"getIndexBackfillInfo","params":[],"id":null}'
+  get_result "$data"
+}
+
+function get_build_info(){
+  local data='{"jsonrpc":"2.0","method":"getBuildInfo","params":[],"id":null}'
+  get_result "$data"
+}
+
+function get_peer_msg_stats(){
+  local data='{"jsonrpc":"2.0","method":"getPeerMsgStats","params":[],"id":null}'
+  get_result "$data"
+}
+
 function get_peer_info(){
   local verbose=$1
   local network=$2
@@ -405,6 +805,69 @@ function remove_ban(){
   get_result "$data"
 }
 
+function get_safe_mode(){
+  local data='{"jsonrpc":"2.0","method":"getSafeMode","params":[],"id":null}'
+  get_result "$data"
+}
+
+function get_follower_status(){
+  local data='{"jsonrpc":"2.0","method":"getFollowerStatus","params":[],"id":null}'
+  get_result "$data"
+}
+
+function get_slo_status(){
+  local data='{"jsonrpc":"2.0","method":"getSLOStatus","params":[],"id":null}'
+  get_result "$data"
+}
+
+function get_metrics_history(){
+  local count=$1
+  if [ "$count" == "" ]; then
+    count="null"
+  fi
+  local data='{"jsonrpc":"2.0","method":"getMetricsHistory","params":['$count'],"id":1}'
+  get_result "$data"
+}
+
+function acknowledge_safe_mode(){
+  local data='{"jsonrpc":"2.0","method":"test_acknowledgeSafeMode","params":[],"id":null}'
+  get_result "$data"
+}
+
+function get_audit_log(){
+  local start=$1
+  local count=$2
+  if [ "$start" == "" ]; then
+    start="null"
+  fi
+  if [ "$count" == "" ]; then
+    count="null"
+  fi
+  local data='{"jsonrpc":"2.0","method":"test_getAuditLog","params":['$start','$count'],"id":1}'
+  get_result "$data"
+}
+
+function unlock_keystore(){
+  local passphrase=$1
+  local timeout=$2
+  if [ "$timeout" == "" ]; then
+    timeout="null"
+  fi
+  local data='{"jsonrpc":"2.0","method":"test_unlockKeystore","params":["'$passphrase'",'$timeout'],"id":1}'
+  get_result "$data"
+}
+
+function lock_keystore(){
+  local data='{"jsonrpc":"2.0","method":"test_lockKeystore","params":[],"id":1}'
+  get_result "$data"
+}
+
+function send_cluster_hint(){
+  local hint=$1
+  local data='{"jsonrpc":"2.0","method":"test_sendClusterHint","params":["'$hint'"],"id":1}'
+  get_result "$data"
+}
+
 function set_rpc_maxclients(){
   local max=$1
   local data='{"jsonrpc":"2.0","method":"test_setRpcMaxClients","params":['$max'],"id":null}'
@@ -438,18 +901,103 @@ function get_rawtxs(){
   get_result "$data"
 }
 
+# return the decoded transactions of an address page by page
+function get_rawtxs_by_address(){
+  local address=$1
+  local start_order=$2
+  local end_order=$3
+  local direction=$4
+  local coin_id=$5
+  local count=$6
+  local cursor=$7
+  local reverse=$8
+  if [ "$start_order" == "" ]; then
+    start_order="null"
+  fi
+  if [ "$end_order" == "" ]; then
+    end_order="null"
+  fi
+  if [ "$direction" == "" ]; then
+    direction="null"
+  else
+    direction='"'$direction'"'
+  fi
+  if [ "$coin_id" == "" ]; then
+    coin_id="null"
+  fi
+  if [ "$count" == "" ]; then
+    count="100"
+  fi
+  if [ "$cursor" == "" ]; then
+    cursor="null"
+  else
+    cursor='"'$cursor'"'
+  fi
+  if [ "$reverse" == "" ]; then
+    reverse="false"
+  fi
+  local data='{"jsonrpc":"2.0","method":"getRawTransactionsByAddress","params":["'$address'",'$start_order','$end_order','$direction','$coin_id','$count','$cursor','$reverse'],"id":1}'
+  get_result "$data"
+}
+
 function is_blue(){
   local block_hash=$1
   local data='{"jsonrpc":"2.0","method":"isBlue","params":["'$block_hash'"],"id":1}'
   get_result "$data"
 }
 
+function get_block_state(){
+  local block_hash=$1
+  local data='{"jsonrpc":"2.0","method":"getBlockState","params":["'$block_hash'"],"id":1}'
+  get_result "$data"
+}
+
+function get_anticone(){
+  local block_hash=$1
+  local data='{"jsonrpc":"2.0","method":"getAnticone","params":["'$block_hash'"],"id":1}'
+  get_result "$data"
+}
+
 function get_fees(){
   local block_hash=$1
   local data='{"jsonrpc":"2.0","method":"getFees","params":["'$block_hash'"],"id":1}'
   get_result "$data"
 }
 
+function rescan(){
+  local addr=$1
+  local start=$2
+  local end=$3
+  if [ "$start" == "" ]; then
+    start=0
+  fi
+  if [ "$end" == "" ]; then
+    end=-1
+  fi
+  local data='{"jsonrpc":"2.0","method":"rescanRange","params":[["'$addr'"],[],'$start','$end'],"id":1}'
+  get_result "$data"
+}
+
+function rescan_descriptors(){
+  local descriptors=$1
+  local start=$2
+  local end=$3
+  local range=$4
+  # the descriptors are separated by semicolons since multi() has commas
+  descriptors='["'$(echo "$descriptors" | sed 's/;/","/g')'"]'
+  if [ "$start" == "" ]; then
+    start=0
+  fi
+  if [ "$end" == "" ]; then
+    end=-1
+  fi
+  if [ "$range" == "" ]; then
+    range="null"
+  fi
+  local data='{"jsonrpc":"2.0","method":"rescanRange","params":[[],[],'$start','$end','$descriptors','$range'],"id":1}'
+  get_result "$data"
+}
+
 function time_info(){
   local block_hash=$1
   local data='{"jsonrpc":"2.0","method":"getTimeInfo","id":1}'
@@ -487,6 +1035,10 @@ function get_result(){
   fi
 
   local data=$1
+  if [ -n "$socket" ]; then
+    get_result_unix_socket "$data"
+    return
+  fi
   local current_result=$(curl -s -k -u "$user:$pass" -X POST -H 'Content-Type: application/json' --data $data $proto://$host:$port)
   local result=$(echo $current_result|jq -r -M '.result')
   if [ $DEBUG -gt 0 ]; then
@@ -503,6 +1055,25 @@ function get_result(){
   fi
 }
 
+# the unix socket of the node needs neither tls nor user/password
+function get_result_unix_socket(){
+  local data=$1
+  local current_result=$(curl -s --unix-socket "$socket" -X POST -H 'Content-Type: application/json' --data $data http://localhost)
+  local result=$(echo $current_result|jq -r -M '.result')
+  if [ $DEBUG -gt 0 ]; then
+    local current_cmd="curl -s --unix-socket "$socket" -X POST -H 'Content-Type: application/json' --data '"$data"' http://localhost"
+    echo "$current_cmd" >> "./cli.debug"
+    echo "$current_result" >> "./cli.debug"
+  fi
+
+  local hashjson=$(echo $result |grep "{")
+  if [ "$hashjson" == "" ]; then
+      echo $result
+  else
+      echo $result |jq .
+  fi
+}
+
 # -------------------------
 # util functions
 # -------------------------
@@ -538,8 +1109,22 @@ function usage(){
   echo "  stop"
   echo "  banlist"
   echo "  removeban"
+  echo "  safemode"
+  echo "  acksafemode   ;resume mining and relay after the safe mode"
+  echo "  followerstatus ;the lag of a follower node behind its leader"
+  echo "  slostatus     ;the block interval and red block rate against the targets"
+  echo "  metricshistory <count,default=all> ;the latest snapshots of the key metrics"
+  echo "  auditlog <start_id,default=last entries> <count,default=100>"
+  echo "  unlockkeystore <passphrase> <timeout_seconds,default=config>"
+  echo "  lockkeystore"
+  echo "  clusterhint <hex>"
   echo "  loglevel [trace, debug, info, warn, error, critical]"
   echo "  timeinfo"
+  echo "  nodestats"
+  echo "  perfreport <minutes,default=10> <count,default=10>"
+  echo "  indexbackfill"
+  echo "  buildinfo"
+  echo "  peermsgstats"
   echo "block  :"
   echo "  block <order|hash>"
   echo "  blockid <id>"
@@ -547,14 +1132,24 @@ function usage(){
   echo "  block_count"
   echo "  block_local"
   echo "  blockrange <start,end>"
+  echo "  headers <start order> <count,default=1>"
   echo "  mainHeight"
+  echo "  mainTip"
+  echo "  mainBlock <height>"
+  echo "  locktime"
+  echo "  hashps <window,default=120> <pow_type,default=main tip>"
+  echo "  diffhistory <window,default=120> <pow_type,default=main tip>"
   echo "  weight <hash>"
   echo "  orphanstotal"
   echo "  isblue <hash>   ;return [0:not blue;  1：blue  2：Cannot confirm]"
+  echo "  blockstate <hash>   ;order, blue state and blue score of the block"
+  echo "  anticone <hash>"
   echo "  iscurrent"
   echo "  tips"
   echo "  coinbase <hash>"
   echo "  fees <hash>"
+  echo "  rescan <address> [start order] [end order]"
+  echo "  rescandesc <descriptor;...> [start order] [end order] [range,default=1000]"
   echo "  tokeninfo"
   echo "  submitblock"
   echo "tx     :"
@@ -565,12 +1160,43 @@ function usage(){
   echo "  createRawTxV2"
   echo "  createTokenRawTx"
   echo "  txSign <rawTx>"
+  echo "  fundRawTx <rawTx> <fee_rate,default=min relay fee> <strategy,bnb|largestfirst,default=bnb> <change_address,default=first watched address>"
+  echo "  consolidateUtxos <address> <fee_rate,default=min relay fee> <max_value,default=any> <max_inputs,default=200> <redeem_script,default=none>"
+  echo "  setAddressLabel <address> <label,default=remove the label>"
+  echo "  getAddressesByLabel <label>"
+  echo "  freezeCoins <txid:index|address,...> <reason,default=none>"
+  echo "  unfreezeCoins <txid:index|address,...>"
+  echo "  listFrozenCoins"
   echo "  sendRawTx <signedRawTx>"
+  echo "  submitTxPackage <signedRawTx,...,childRawTx> <allow_high_fees,default=false>"
   echo "  getrawtxs <address>"
+  echo "  addrtxs <address> <start_order,default=0> <end_order,default=last> <direction:sent|received,default=all> <coin_id,default=all> <count,default=100> <cursor,default=none> <reverse,default=false>"
+  echo "  mempool <type,default=regular> <verbose,default=false>"
+  echo "  mempoolstats"
+  echo "  feehistogram"
+  echo "  dumpmempool <file,default=mempool.json>"
+  echo "  loadmempool <file,default=mempool.json>"
+  echo "  diffmempool <file1> <file2>"
+  echo "  dsproofs <tx_id,default=all>"
+  echo "  safetocredit <tx_id> <confirmations,default=10>"
+  echo "  createproposal <redeem_script> <inputs> <amounts>"
+  echo "  getproposal <id>"
+  echo "  importproposal <psbt>"
+  echo "  signproposal <private_key> <psbt>"
+  echo "  extsignproposal <id>"
+  echo "  broadcastproposal <id> <allow_high_fees,default=false>"
+  echo "  debugscript <sign_script> <pk_script> <raw_tx,default=none> <index,default=0>"
+  echo "  addractivity <address>"
+  echo "  analyzeaddr <address>"
+  echo "  minerstats <address> <start_order,default=0> <end_order,default=last> <verbose,default=false>"
   echo "utxo   :"
   echo "  getutxo <tx_id> <index> <include_mempool,default=true>"
+  echo "  scanutxos <descriptor;...> <cursor,default=none> <max_entries,default=100000> <range,default=1000>"
+  echo "  utxoages <coin_id,default=0> <bucket_orders,default=1000>"
+  echo "  txoutsetinfo"
   echo "miner  :"
-  echo "  template"
+  echo "  template <capabilities> <pow_type,default=6> <include_txs> <exclude_txs>"
+  echo "  templatewitness <pow_type,default=6>"
   echo "  generate <num>"
 }
 
@@ -751,7 +1377,7 @@ function call_get_block() {
 
 # main logic
 if [ $? != 0 ]; then
-  echo "Usage: -h [host] -p [port] "
+  echo "Usage: -h [host] -p [port] | -socket [path]"
   exit;
 fi
 #echo "$@"
@@ -768,6 +1394,10 @@ while [ $# -gt 0 ] ;do
       port=$2
       #echo "port is $port"
       shift;;
+    -socket)
+      socket=$2
+      #echo "socket is $socket"
+      shift;;
     --user)
       user=$2
       #echo "user is $user"
@@ -835,18 +1465,46 @@ elif [ "$1" == "header" ]; then
   shift
   get_blockheader_by_hash $@
 
+elif [ "$1" == "headers" ]; then
+  shift
+  get_headers $@
+
 elif [ "$1" == "main" ]; then
     shift
     is_on_mainchain $1
 
 elif [ "$1" == "template" ]; then
     shift
-    get_block_template $1 | jq .
+    get_block_template $@ | jq .
+
+elif [ "$1" == "templatewitness" ]; then
+    shift
+    get_block_template_witness $1 | jq .
 
 elif [ "$1" == "mainHeight" ]; then
     shift
     get_mainchain_height
 
+elif [ "$1" == "mainTip" ]; then
+    shift
+    get_mainchain_tip
+
+elif [ "$1" == "mainBlock" ]; then
+    shift
+    get_mainchain_block $1
+
+elif [ "$1" == "locktime" ]; then
+    shift
+    get_locktime_cursor
+
+elif [ "$1" == "hashps" ]; then
+    shift
+    get_network_hashps $@
+
+elif [ "$1" == "diffhistory" ]; then
+    shift
+    get_difficulty_history $@
+
 elif [ "$1" == "weight" ]; then
     shift
     get_block_weight $1
@@ -859,6 +1517,22 @@ elif [ "$1" == "isblue" ]; then
   shift
   is_blue $@
 
+elif [ "$1" == "blockstate" ]; then
+  shift
+  get_block_state $@
+
+elif [ "$1" == "anticone" ]; then
+  shift
+  get_anticone $@
+
+elif [ "$1" == "rescan" ]; then
+  shift
+  rescan $@
+
+elif [ "$1" == "rescandesc" ]; then
+  shift
+  rescan_descriptors "$@"
+
 elif [ "$1" == "fees" ]; then
   shift
   get_fees $@
@@ -871,6 +1545,26 @@ elif [ "$1" == "nodeinfo" ]; then
   shift
   get_node_info
 
+elif [ "$1" == "nodestats" ]; then
+  shift
+  get_node_stats
+
+elif [ "$1" == "perfreport" ]; then
+  shift
+  perf_report $@
+
+elif [ "$1" == "indexbackfill" ]; then
+  shift
+  get_index_backfill_info
+
+elif [ "$1" == "buildinfo" ]; then
+  shift
+  get_build_info
+
+elif [ "$1" == "peermsgstats" ]; then
+  shift
+  get_peer_msg_stats
+
 elif [ "$1" == "peerinfo" ]; then
   shift
   get_peer_info $@
@@ -917,6 +1611,42 @@ elif [ "$1" == "removeban" ]; then
   shift
   remove_ban $@
 
+elif [ "$1" == "safemode" ]; then
+  shift
+  get_safe_mode
+
+elif [ "$1" == "acksafemode" ]; then
+  shift
+  acknowledge_safe_mode
+
+elif [ "$1" == "followerstatus" ]; then
+  shift
+  get_follower_status
+
+elif [ "$1" == "slostatus" ]; then
+  shift
+  get_slo_status
+
+elif [ "$1" == "metricshistory" ]; then
+  shift
+  get_metrics_history $@
+
+elif [ "$1" == "auditlog" ]; then
+  shift
+  get_audit_log $@
+
+elif [ "$1" == "unlockkeystore" ]; then
+  shift
+  unlock_keystore $@
+
+elif [ "$1" == "lockkeystore" ]; then
+  shift
+  lock_keystore
+
+elif [ "$1" == "clusterhint" ]; then
+  shift
+  send_cluster_hint $@
+
 ## Tx
 elif [ "$1" == "tx" ]; then
   shift
@@ -941,14 +1671,50 @@ elif [ "$1" == "decodeRawTx" ]; then
   shift
   decode_raw_tx $@
 
+elif [ "$1" == "fundRawTx" ]; then
+  shift
+  fund_raw_tx $@
+
+elif [ "$1" == "consolidateUtxos" ]; then
+  shift
+  consolidate_utxos $@
+
+elif [ "$1" == "setAddressLabel" ]; then
+  shift
+  set_address_label $@
+
+elif [ "$1" == "getAddressesByLabel" ]; then
+  shift
+  get_addresses_by_label $@
+
+elif [ "$1" == "freezeCoins" ]; then
+  shift
+  freeze_coins $@
+
+elif [ "$1" == "unfreezeCoins" ]; then
+  shift
+  unfreeze_coins $@
+
+elif [ "$1" == "listFrozenCoins" ]; then
+  shift
+  list_frozen_coins $@
+
 elif [ "$1" == "sendRawTx" ]; then
   shift
   send_raw_tx $@
 
+elif [ "$1" == "submitTxPackage" ]; then
+  shift
+  submit_tx_package $@
+
 elif [ "$1" == "getrawtxs" ]; then
   shift
   get_rawtxs $@
 
+elif [ "$1" == "addrtxs" ]; then
+  shift
+  get_rawtxs_by_address $@
+
 elif [ "$1" == "get_tx_by_block_and_index" ]; then
   shift
   # note: the input is block number & tx index in hex
@@ -959,6 +1725,74 @@ elif [ "$1" == "mempool" ]; then
   shift
   get_mempool $@
 
+elif [ "$1" == "mempoolstats" ]; then
+  shift
+  get_mempool_stats
+
+elif [ "$1" == "feehistogram" ]; then
+  shift
+  get_fee_histogram
+
+elif [ "$1" == "dumpmempool" ]; then
+  shift
+  dump_mempool $@
+
+elif [ "$1" == "loadmempool" ]; then
+  shift
+  load_mempool $@
+
+elif [ "$1" == "diffmempool" ]; then
+  shift
+  diff_mempool $@
+
+elif [ "$1" == "dsproofs" ]; then
+  shift
+  get_double_spend_proofs $@
+
+elif [ "$1" == "safetocredit" ]; then
+  shift
+  is_tx_safe_to_credit $@
+
+elif [ "$1" == "createproposal" ]; then
+  shift
+  create_spend_proposal $@
+
+elif [ "$1" == "getproposal" ]; then
+  shift
+  get_spend_proposal $@
+
+elif [ "$1" == "importproposal" ]; then
+  shift
+  import_spend_proposal $@
+
+elif [ "$1" == "signproposal" ]; then
+  shift
+  sign_spend_proposal $@
+
+elif [ "$1" == "extsignproposal" ]; then
+  shift
+  external_sign_spend_proposal $@
+
+elif [ "$1" == "broadcastproposal" ]; then
+  shift
+  broadcast_spend_proposal $@
+
+elif [ "$1" == "debugscript" ]; then
+  shift
+  debug_script $@
+
+elif [ "$1" == "addractivity" ]; then
+  shift
+  get_address_activity $@
+
+elif [ "$1" == "analyzeaddr" ]; then
+  shift
+  analyze_address $@
+
+elif [ "$1" == "minerstats" ]; then
+  shift
+  get_miner_stats $@
+
 
 elif [ "$1" == "txSign" ]; then
   shift
@@ -971,6 +1805,18 @@ elif [ "$1" == "getutxo" ]; then
   shift
   get_utxo $@
 
+elif [ "$1" == "scanutxos" ]; then
+  shift
+  scan_utxo_set $@
+
+elif [ "$1" == "utxoages" ]; then
+  shift
+  get_utxo_age_distribution $@
+
+elif [ "$1" == "txoutsetinfo" ]; then
+  shift
+  get_tx_out_set_info $@
+
 ## Accounts
 elif [ "$1" == "newaccount" ]; then
   shift
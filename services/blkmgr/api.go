@@ -4,6 +4,7 @@ package blkmgr
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"fmt"
 	"github.com/Qitmeer/qitmeer/common/hash"
@@ -25,6 +26,13 @@ const (
 func (b *BlockManager) GetChain() *blockchain.BlockChain {
 	return b.chain
 }
+
+// RPCCache returns the cache of the results of the expensive RPC calls, which
+// is purged whenever the tip of the chain changes, or nil when it is disabled.
+func (b *BlockManager) RPCCache() *rpc.ResponseCache {
+	return b.rpcCache
+}
+
 func (b *BlockManager) API() rpc.API {
 	return rpc.API{
 		NameSpace: cmds.DefaultServiceNameSpace,
@@ -138,6 +146,13 @@ func (api *PublicBlockAPI) GetBlock(h hash.Hash, verbose *bool, inclTx *bool, fu
 		fTx = *fullTx
 	}
 
+	cacheKey := fmt.Sprintf("getBlock/%s/%v/%v", h, iTx, fTx)
+	if vb {
+		if fields, ok := api.bm.rpcCache.Get(cacheKey); ok {
+			return fields, nil
+		}
+	}
+
 	// Load the raw block bytes from the database.
 	// Note :
 	// FetchBlockByHash differs from BlockByHash in that this one also returns blocks
@@ -189,6 +204,7 @@ func (api *PublicBlockAPI) GetBlock(h hash.Hash, verbose *bool, inclTx *bool, fu
 	if err != nil {
 		return nil, err
 	}
+	api.bm.rpcCache.Put(cacheKey, fields)
 	return fields, nil
 }
 
@@ -207,6 +223,13 @@ func (api *PublicBlockAPI) GetBlockV2(h hash.Hash, verbose *bool, inclTx *bool,
 		fTx = *fullTx
 	}
 
+	cacheKey := fmt.Sprintf("getBlockV2/%s/%v/%v", h, iTx, fTx)
+	if vb {
+		if fields, ok := api.bm.rpcCache.Get(cacheKey); ok {
+			return fields, nil
+		}
+	}
+
 	// Load the raw block bytes from the database.
 	// Note :
 	// FetchBlockByHash differs from BlockByHash in that this one also returns blocks
@@ -252,6 +275,7 @@ func (api *PublicBlockAPI) GetBlockV2(h hash.Hash, verbose *bool, inclTx *bool,
 	if err != nil {
 		return nil, err
 	}
+	api.bm.rpcCache.Put(cacheKey, fields)
 	return fields, nil
 
 }
@@ -318,6 +342,20 @@ func (api *PublicBlockAPI) GetBlockHeader(hash hash.Hash, verbose bool) (interfa
 
 }
 
+// GetHeaders returns the serialized headers of the count blocks following the
+// start order, packed together, along with whether each block is blue.  At
+// most rpc.MaxHeadersPerRequest headers are returned.
+func (api *PublicBlockAPI) GetHeaders(startOrder uint64, count uint32) (interface{}, error) {
+	if count == 0 {
+		return nil, rpc.RpcInvalidError("Count must be greater than zero")
+	}
+	result, err := rpc.HeadersResult(api.bm.chain, startOrder, uint64(count))
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to fetch headers")
+	}
+	return result, nil
+}
+
 // Query whether a given block is on the main chain.
 // Note that some DAG protocols may not support this feature.
 func (api *PublicBlockAPI) IsOnMainChain(h hash.Hash) (interface{}, error) {
@@ -335,6 +373,43 @@ func (api *PublicBlockAPI) GetMainChainHeight() (interface{}, error) {
 	return strconv.FormatUint(uint64(api.bm.GetChain().BlockDAG().GetMainChainTip().GetHeight()), 10), nil
 }
 
+// GetMainChainTip returns the tip of the DAG main chain.
+// Note that some DAG protocols may not support this feature.
+func (api *PublicBlockAPI) GetMainChainTip() (interface{}, error) {
+	return mainChainBlockResult(api.bm.GetChain().BlockDAG().GetMainChainTip()), nil
+}
+
+// GetMainChainBlockByHeight returns the block of the DAG main chain at the
+// height.
+// Note that some DAG protocols may not support this feature.
+func (api *PublicBlockAPI) GetMainChainBlockByHeight(height uint64) (interface{}, error) {
+	ib := api.bm.GetChain().BlockDAG().GetMainChainBlockByHeight(uint(height))
+	if ib == nil {
+		return nil, rpc.RpcInvalidError("No main chain block at height %d", height)
+	}
+	return mainChainBlockResult(ib), nil
+}
+
+// GetLockTimeCursor returns the cursors the lock time of a transaction is
+// compared to in the next block, so that wallets can build time locked
+// transactions.
+func (api *PublicBlockAPI) GetLockTimeCursor() (interface{}, error) {
+	height, medianTime := api.bm.GetChain().LockTimeCursor()
+	return &json.LockTimeCursorResult{
+		Height:     height,
+		MedianTime: medianTime.Unix(),
+		Threshold:  txscript.LockTimeThreshold,
+	}, nil
+}
+
+func mainChainBlockResult(ib blockdag.IBlock) *json.MainChainBlockResult {
+	return &json.MainChainBlockResult{
+		Hash:   ib.GetHash().String(),
+		Height: uint64(ib.GetHeight()),
+		Order:  uint64(ib.GetOrder()),
+	}
+}
+
 // Return the weight of block
 func (api *PublicBlockAPI) GetBlockWeight(h hash.Hash) (interface{}, error) {
 	block, err := api.bm.chain.FetchBlockByHash(&h)
@@ -392,6 +467,49 @@ func (api *PublicBlockAPI) IsBlue(h hash.Hash) (interface{}, error) {
 	return 0, nil
 }
 
+// GetBlockState returns the position of the block in the DAG and whether it
+// is blue, so that explorers can color the blocks.
+func (api *PublicBlockAPI) GetBlockState(h hash.Hash) (interface{}, error) {
+	bd := api.bm.chain.BlockDAG()
+	ib := bd.GetBlock(&h)
+	if ib == nil {
+		return nil, rpc.RpcInternalError(fmt.Errorf("no block").Error(), fmt.Sprintf("Block not found: %s", h.String()))
+	}
+	blueScore, err := bd.GetBlueScore(&h)
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to get the blue score")
+	}
+	return json.BlockStateResult{
+		Hash:          h.String(),
+		Order:         uint64(ib.GetOrder()),
+		IsOrdered:     ib.IsOrdered(),
+		Height:        uint64(ib.GetHeight()),
+		Layer:         uint64(ib.GetLayer()),
+		IsBlue:        bd.IsBlueBlock(&h),
+		BlueScore:     uint64(blueScore),
+		MainChain:     bd.IsOnMainChain(ib.GetID()),
+		Confirmations: uint64(bd.GetConfirmations(ib.GetID())),
+	}, nil
+}
+
+// GetAnticone returns the hashes of the blocks which are neither in the past
+// nor in the future of the block.  The traversal is aborted when the call is
+// cancelled or times out.
+func (api *PublicBlockAPI) GetAnticone(ctx context.Context, h hash.Hash) (interface{}, error) {
+	anticone, err := api.bm.chain.BlockDAG().GetAnticone(ctx, &h)
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return nil, rpc.RpcInternalError(err.Error(), "Anticone traversal aborted")
+	}
+	if err != nil {
+		return nil, rpc.RpcInvalidError("Block not found: %s", h.String())
+	}
+	hashes := make([]string, 0, len(anticone))
+	for _, ah := range anticone {
+		hashes = append(hashes, ah.String())
+	}
+	return hashes, nil
+}
+
 // Return IsCurrent
 func (api *PublicBlockAPI) IsCurrent() (interface{}, error) {
 	return api.bm.IsCurrent(), nil
@@ -17,7 +17,9 @@ import (
 	"github.com/Qitmeer/qitmeer/node/notify"
 	"github.com/Qitmeer/qitmeer/p2p"
 	"github.com/Qitmeer/qitmeer/params"
+	"github.com/Qitmeer/qitmeer/rpc"
 	"github.com/Qitmeer/qitmeer/services/common/progresslog"
+	"github.com/Qitmeer/qitmeer/services/mempool"
 	"github.com/Qitmeer/qitmeer/services/zmq"
 	"sync"
 	"sync/atomic"
@@ -36,6 +38,10 @@ const (
 	// maxStallDuration is the time after which we will disconnect our
 	// current sync peer if we haven't made progress.
 	MaxBlockStallDuration = 3 * time.Second
+
+	// archiveInterval is the number of connected blocks between two
+	// attempts to move ancient block files to the cold storage.
+	archiveInterval = 1000
 )
 
 // BlockManager provides a concurrency safe block manager for handling all
@@ -44,6 +50,13 @@ type BlockManager struct {
 	started  int32
 	shutdown int32
 
+	// The following variables must only be used atomically.
+	blocksConnected uint64
+	reorganizations uint64
+	archiving       int32
+	pruning         int32
+	revalidating    int32
+
 	config *config.Config
 	params *params.Params
 
@@ -78,8 +91,17 @@ type BlockManager struct {
 	//tx manager
 	txManager TxManager
 
+	// optional index narrowing the rescans
+	addrActivityIndex AddrActivityIndex
+
 	// network server
 	peerServer *p2p.Service
+
+	// results of the expensive RPC calls, purged when the tip changes
+	rpcCache *rpc.ResponseCache
+
+	// blocks mined by the node waiting to be checked
+	minedBlocks minedBlocks
 }
 
 // NewBlockManager returns a new block manager.
@@ -97,20 +119,26 @@ func NewBlockManager(ntmgr notify.Notify, indexManager blockchain.IndexManager,
 		headerList:     list.New(),
 		quit:           make(chan struct{}),
 		peerServer:     peerServer,
+		rpcCache:       rpc.NewResponseCache(time.Duration(cfg.RPCCacheTTL) * time.Second),
 	}
 
 	// Create a new block chain instance with the appropriate configuration.
 	var err error
 	bm.chain, err = blockchain.New(&blockchain.Config{
-		DB:             db,
-		Interrupt:      interrupt,
-		ChainParams:    par,
-		TimeSource:     timeSource,
-		Events:         events,
-		SigCache:       sigCache,
-		IndexManager:   indexManager,
-		DAGType:        cfg.DAGType,
-		CacheInvalidTx: cfg.CacheInvalidTx,
+		DB:                  db,
+		Interrupt:           interrupt,
+		ChainParams:         par,
+		TimeSource:          timeSource,
+		Events:              events,
+		SigCache:            sigCache,
+		IndexManager:        indexManager,
+		DAGType:             cfg.DAGType,
+		CacheInvalidTx:      cfg.CacheInvalidTx,
+		AdmissionQueueDepth: cfg.AdmissionQueueDepth,
+		Assert:              cfg.Assert,
+		FinalityDepth:       cfg.FinalityDepth,
+		AnticoneCacheSize:   uint64(cfg.AnticoneCacheSize) * 1024 * 1024,
+		UtxoCacheSize:       uint64(cfg.UtxoCacheSize) * 1024 * 1024,
 	})
 	if err != nil {
 		return nil, err
@@ -146,6 +174,12 @@ func NewBlockManager(ntmgr notify.Notify, indexManager blockchain.IndexManager,
 // handleNotifyMsg handles notifications from blockchain.  It does things such
 // as request orphan block parents and relay accepted blocks to connected peers.
 func (b *BlockManager) handleNotifyMsg(notification *blockchain.Notification) {
+	switch notification.Type {
+	case blockchain.BlockAccepted, blockchain.BlockDisconnected,
+		blockchain.Reorganization, blockchain.ChainReorg:
+		b.rpcCache.Purge()
+	}
+
 	switch notification.Type {
 	// A block has been accepted into the block chain.  Relay it to other peers
 	// and possibly notify RPC clients with the winning tickets.
@@ -211,6 +245,11 @@ func (b *BlockManager) handleNotifyMsg(notification *blockchain.Notification) {
 		}
 
 		block := blockSlice[0]
+		b.checkMinedBlocks()
+		connected := atomic.AddUint64(&b.blocksConnected, 1)
+		if len(b.config.ColdDataDir) > 0 && connected%archiveInterval == 0 {
+			b.archiveBlockFiles()
+		}
 		// Remove all of the transactions (except the coinbase) in the
 		// connected block from the transaction pool.  Secondly, remove any
 		// transactions which are now double spends as a result of these
@@ -234,7 +273,10 @@ func (b *BlockManager) handleNotifyMsg(notification *blockchain.Notification) {
 			}
 		*/
 
+		mempool.NotifyPluginsBlockConnected(block)
+
 		b.zmqNotify.BlockConnected(block)
+		b.notify.BlockConnected(block)
 
 	// A block has been disconnected from the main block chain.
 	case blockchain.BlockDisconnected:
@@ -248,6 +290,8 @@ func (b *BlockManager) handleNotifyMsg(notification *blockchain.Notification) {
 	// The blockchain is reorganizing.
 	case blockchain.Reorganization:
 		log.Trace("Chain reorganization notification")
+		atomic.AddUint64(&b.reorganizations, 1)
+		b.revalidateMempool()
 		/*
 			rd, ok := notification.Data.(*blockchain.ReorganizationNotifyData)
 			if !ok {
@@ -264,13 +308,128 @@ func (b *BlockManager) handleNotifyMsg(notification *blockchain.Notification) {
 			// will be no longer valid.
 			b.cachedCurrentTemplate = nil
 		*/
+	// A reorganization completed.
+	case blockchain.ChainReorg:
+		cd, ok := notification.Data.(*blockchain.ChainReorgNotifyData)
+		if !ok {
+			log.Warn("Chain reorg notification is malformed")
+			break
+		}
+		log.Debug("Chain reorg", "detached", len(cd.Detached),
+			"attached", len(cd.Attached), "reordered", len(cd.Reordered()))
+
+	// The finality point of the block DAG advanced.
+	case blockchain.FinalityPointAdvanced:
+		fd, ok := notification.Data.(*blockchain.FinalityPointNotifyData)
+		if !ok {
+			log.Warn("Finality point notification is malformed")
+			break
+		}
+		log.Info("Finality point advanced", "hash", fd.Hash, "order", fd.Order,
+			"height", fd.Height)
+		if b.config.Prune > 0 {
+			b.pruneBlockFiles()
+		}
+
+	// A block was added to the orphan pool.  Request its missing parents,
+	// the orphan is processed once they arrive.
+	case blockchain.OrphanBlockAdded:
+		od, ok := notification.Data.(*blockchain.OrphanBlockNotifyData)
+		if !ok {
+			log.Warn("Orphan block notification is malformed")
+			break
+		}
+		b.peerServer.PeerSync().RequestOrphanParents(od.Block.Hash(),
+			od.MissingParents)
 	}
 }
 
+// archiveBlockFiles moves ancient block files to the cold storage in the
+// background unless a previous run is still in progress.
+func (b *BlockManager) archiveBlockFiles() {
+	if !atomic.CompareAndSwapInt32(&b.archiving, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&b.archiving, 0)
+		n, err := b.chain.ArchiveBlockFiles(b.config.ColdStorageDepth)
+		if err != nil {
+			log.Error("Failed to move block files to cold storage", "error", err)
+			return
+		}
+		if n > 0 {
+			log.Info("Moved block files to cold storage", "files", n)
+		}
+	}()
+}
+
+// pruneBlockFiles deletes the block files below the finality point in the
+// background unless a previous run is still in progress.
+func (b *BlockManager) pruneBlockFiles() {
+	if !atomic.CompareAndSwapInt32(&b.pruning, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&b.pruning, 0)
+		n, err := b.chain.PruneBlockFiles(b.config.Prune)
+		if err != nil {
+			log.Error("Failed to prune block files", "error", err)
+			return
+		}
+		if n > 0 {
+			log.Info("Pruned block files", "files", n)
+		}
+	}()
+}
+
+// revalidateMempool evicts the mempool transactions which conflict with the
+// new order of the DAG in the background, unless a previous run has not
+// started yet.  The revalidation waits for the reorganization to release the
+// chain lock.
+func (b *BlockManager) revalidateMempool() {
+	if !atomic.CompareAndSwapInt32(&b.revalidating, 0, 1) {
+		return
+	}
+	go func() {
+		atomic.StoreInt32(&b.revalidating, 0)
+		evicted := b.GetTxManager().MemPool().RevalidateTransactions()
+		for _, e := range evicted {
+			b.notify.TransactionEvicted(e.Tx, e.Reason)
+		}
+		if len(evicted) > 0 {
+			log.Info("Evicted conflicting transactions from the mempool",
+				"count", len(evicted))
+		}
+	}()
+}
+
+// ZMQEnabled returns whether the node was built with the ZMQ notifications.
+func (b *BlockManager) ZMQEnabled() bool {
+	return b.zmqNotify.IsEnable()
+}
+
+// BlocksConnected returns the number of blocks connected since the block
+// manager was created.
+func (b *BlockManager) BlocksConnected() uint64 {
+	return atomic.LoadUint64(&b.blocksConnected)
+}
+
+// Reorganizations returns the number of chain reorganizations since the
+// block manager was created.
+func (b *BlockManager) Reorganizations() uint64 {
+	return atomic.LoadUint64(&b.reorganizations)
+}
+
 func (b *BlockManager) IsCurrent() bool {
 	return b.peerServer.PeerSync().IsCurrent()
 }
 
+// IsSafeMode returns whether the node is in safe mode, in which it neither
+// mines nor relays.
+func (b *BlockManager) IsSafeMode() bool {
+	return b.peerServer.PeerSync().IsSafeMode()
+}
+
 // Start begins the core block handler which processes block and inv messages.
 func (b *BlockManager) Start() {
 	// Already started?
@@ -300,6 +459,11 @@ func (b *BlockManager) Stop() error {
 func (b *BlockManager) WaitForStop() {
 	log.Info("Wait For Block manager stop ...")
 	b.wg.Wait()
+	// Write the outputs kept by the utxo cache, otherwise they are
+	// replayed at the next start.
+	if err := b.chain.FlushUtxoCache(); err != nil {
+		log.Error("Failed to flush the utxo cache", "err", err)
+	}
 	log.Info("Block manager stopped")
 }
 
@@ -414,6 +578,14 @@ out:
 					acceptedTxs: acceptedTxs,
 					err:         err,
 				}
+			case processPackageMsg:
+				log.Trace("blkmgr msgChan processPackageMsg", "msg", msg)
+				acceptedTxs, err := b.GetTxManager().MemPool().ProcessPackage(msg.txs,
+					msg.rateLimit, msg.allowHighFees)
+				msg.reply <- processTransactionResponse{
+					acceptedTxs: acceptedTxs,
+					err:         err,
+				}
 			case isCurrentMsg:
 				log.Trace("blkmgr msgChan isCurrentMsg", "msg", msg)
 				msg.isCurrentReply <- b.IsCurrent()
@@ -519,6 +691,27 @@ func (b *BlockManager) ProcessTransaction(tx *types.Tx, allowOrphans bool,
 	return response.acceptedTxs, response.err
 }
 
+// processPackageMsg is a message type to be sent across the message channel
+// for requesting a package of transactions to be processed through the block
+// manager.
+type processPackageMsg struct {
+	txs           []*types.Tx
+	rateLimit     bool
+	allowHighFees bool
+	reply         chan processTransactionResponse
+}
+
+// ProcessPackage makes use of ProcessPackage on an internal instance of a
+// block chain.  It is funneled through the block manager since blockchain is
+// not safe for concurrent access.
+func (b *BlockManager) ProcessPackage(txs []*types.Tx, rateLimit bool,
+	allowHighFees bool) ([]*types.TxDesc, error) {
+	reply := make(chan processTransactionResponse, 1)
+	b.msgChan <- processPackageMsg{txs, rateLimit, allowHighFees, reply}
+	response := <-reply
+	return response.acceptedTxs, response.err
+}
+
 // isCurrentMsg is a message type to be sent across the message channel for
 // requesting whether or not the block manager believes it is synced with
 // the currently connected peers.
@@ -587,6 +780,10 @@ func (b *BlockManager) GetTxManager() TxManager {
 	return b.txManager
 }
 
+func (b *BlockManager) SetAddrActivityIndex(addrActivityIndex AddrActivityIndex) {
+	b.addrActivityIndex = addrActivityIndex
+}
+
 func (b *BlockManager) subscribe(events *event.Feed) {
 	ch := make(chan *event.Event)
 	sub := events.Subscribe(ch)
@@ -0,0 +1,160 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+package blkmgr
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/json"
+	"github.com/Qitmeer/qitmeer/core/types/pow"
+	"github.com/Qitmeer/qitmeer/rpc"
+	"math/big"
+)
+
+const (
+	// DefaultDifficultyWindow is the number of blocks of a pow type the
+	// network hash rate and the difficulty history are computed over by
+	// default.
+	DefaultDifficultyWindow = 120
+
+	// MaxDifficultyWindow is the maximum number of blocks of a pow type the
+	// network hash rate and the difficulty history are computed over.
+	MaxDifficultyWindow = 10000
+
+	// MaxDifficultyScan is the maximum number of orders walked back from
+	// the main order to find the blocks of a pow type, so that a pow type
+	// mined rarely or never does not make a request scan the whole DAG.
+	MaxDifficultyScan = 10 * MaxDifficultyWindow
+)
+
+// powBlock is a block of a pow type along with its order.
+type powBlock struct {
+	order uint64
+	node  *blockchain.BlockNode
+}
+
+// powBlocks returns the last count blocks of the pow type sorted by order,
+// fewer when the last MaxDifficultyScan orders do not have as many.
+func (api *PublicBlockAPI) powBlocks(powType pow.PowType, count uint32) []powBlock {
+	bd := api.bm.chain.BlockDAG()
+	mainOrder := int64(api.bm.chain.BestSnapshot().GraphState.GetMainOrder())
+	minOrder := mainOrder - MaxDifficultyScan + 1
+	if minOrder < 0 {
+		minOrder = 0
+	}
+	blocks := []powBlock{}
+	for order := mainOrder; order >= minOrder && uint32(len(blocks)) < count; order-- {
+		node := api.bm.chain.GetBlockNode(bd.GetBlockByOrder(uint(order)))
+		if node == nil || node.GetPowType() != powType {
+			continue
+		}
+		blocks = append(blocks, powBlock{order: uint64(order), node: node})
+	}
+	for i, j := 0, len(blocks)-1; i < j; i, j = i+1, j-1 {
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+	return blocks
+}
+
+// difficultyArgs returns the window and the pow type of the getNetworkHashPS
+// and getDifficultyHistory commands.  The pow type defaults to the one of the
+// main chain tip.
+func (api *PublicBlockAPI) difficultyArgs(window *uint32, powType *byte) (uint32, pow.PowType, error) {
+	w := uint32(DefaultDifficultyWindow)
+	if window != nil {
+		w = *window
+	}
+	if w == 0 || w > MaxDifficultyWindow {
+		return 0, 0, rpc.RpcInvalidError("Window must be between 1 and %d",
+			MaxDifficultyWindow)
+	}
+	if powType != nil {
+		pt := pow.PowType(*powType)
+		if pow.GetPowName(pt) == "" {
+			return 0, 0, rpc.RpcInvalidError("Unknown pow type %d", *powType)
+		}
+		return w, pt, nil
+	}
+	tip := api.bm.chain.GetBlockNode(api.bm.chain.BlockDAG().GetMainChainTip())
+	if tip == nil {
+		return 0, 0, rpc.RpcInternalError(fmt.Errorf("no block").Error(),
+			"Main chain tip not found")
+	}
+	return w, tip.GetPowType(), nil
+}
+
+// blockWork returns the expected work of a block with the difficulty bits.
+func blockWork(bits uint32, powType pow.PowType) float64 {
+	work, _ := new(big.Float).SetInt(pow.CalcWork(bits, powType)).Float64()
+	return work
+}
+
+// GetNetworkHashPS returns the hash rate of the network for a pow type,
+// estimated from the work and the timestamps of its last window blocks within
+// the last MaxDifficultyScan orders.  The hash rate is zero when the window
+// does not span any time.
+func (api *PublicBlockAPI) GetNetworkHashPS(window *uint32, powType *byte) (interface{}, error) {
+	w, pt, err := api.difficultyArgs(window, powType)
+	if err != nil {
+		return nil, err
+	}
+	blocks := api.powBlocks(pt, w)
+	result := &json.NetworkHashPSResult{
+		PowType: uint8(pt),
+		PowName: pow.GetPowName(pt),
+		Blocks:  uint32(len(blocks)),
+	}
+	if len(blocks) == 0 {
+		return result, nil
+	}
+	result.StartOrder = blocks[0].order
+	result.EndOrder = blocks[len(blocks)-1].order
+
+	// The work of the first block was done before the window, so it only
+	// bounds the time span.
+	minTime := blocks[0].node.GetTimestamp()
+	maxTime := minTime
+	var work float64
+	for _, b := range blocks[1:] {
+		t := b.node.GetTimestamp()
+		if t < minTime {
+			minTime = t
+		}
+		if t > maxTime {
+			maxTime = t
+		}
+		work += blockWork(b.node.Difficulty(), pt)
+	}
+	result.TimeSpan = maxTime - minTime
+	if result.TimeSpan > 0 {
+		result.HashPS = work / float64(result.TimeSpan)
+	}
+	return result, nil
+}
+
+// GetDifficultyHistory returns the difficulty of the last window blocks of a
+// pow type within the last MaxDifficultyScan orders sorted by order, as stored
+// in their headers.
+func (api *PublicBlockAPI) GetDifficultyHistory(window *uint32, powType *byte) (interface{}, error) {
+	w, pt, err := api.difficultyArgs(window, powType)
+	if err != nil {
+		return nil, err
+	}
+	blocks := api.powBlocks(pt, w)
+	result := &json.DifficultyHistoryResult{
+		PowType: uint8(pt),
+		PowName: pow.GetPowName(pt),
+		Blocks:  make([]json.DifficultyResult, 0, len(blocks)),
+	}
+	for _, b := range blocks {
+		bits := b.node.Difficulty()
+		result.Blocks = append(result.Blocks, json.DifficultyResult{
+			Order:      b.order,
+			Hash:       b.node.GetHash().String(),
+			Timestamp:  b.node.GetTimestamp(),
+			Bits:       fmt.Sprintf("%08x", bits),
+			Difficulty: blockWork(bits, pt),
+		})
+	}
+	return result, nil
+}
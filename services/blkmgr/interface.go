@@ -8,8 +8,18 @@ import (
 	"github.com/Qitmeer/qitmeer/common/hash"
 	"github.com/Qitmeer/qitmeer/core/blockchain"
 	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/services/index"
+	"github.com/Qitmeer/qitmeer/services/mempool"
 )
 
+// AddrActivityIndex finds the orders of the blocks where an address was first
+// seen and last active.
+type AddrActivityIndex interface {
+	TipOrder() (uint32, error)
+
+	AddrActivity(addr types.Address) (*index.AddrActivity, error)
+}
+
 type TxManager interface {
 	MemPool() TxPool
 }
@@ -33,4 +43,8 @@ type TxPool interface {
 	PruneExpiredTx()
 
 	ProcessTransaction(tx *types.Tx, allowOrphan, rateLimit, allowHighFees bool) ([]*types.TxDesc, error)
+
+	ProcessPackage(txs []*types.Tx, rateLimit, allowHighFees bool) ([]*types.TxDesc, error)
+
+	RevalidateTransactions() []*mempool.EvictedTx
 }
@@ -0,0 +1,180 @@
+// Copyright (c) 2017-2018 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blkmgr
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"sync"
+	"time"
+)
+
+const (
+	// minedBlockCheckDepth is the number of main chain blocks on top of a
+	// mined block after which its color and order are checked.
+	minedBlockCheckDepth = 10
+
+	// minedBlockMaxReorder is the number of orders by which a mined block
+	// may move back before it is reported.
+	minedBlockMaxReorder = 10
+
+	// maxMinedBlocks is the maximum number of mined blocks waiting for the
+	// check.
+	maxMinedBlocks = 1000
+
+	// Reasons of the notification of a stale mined block.
+	MinedBlockRed       = "red"
+	MinedBlockReordered = "reordered"
+)
+
+// minedBlock is a block mined by the node, with the state of the DAG when it
+// was submitted.
+type minedBlock struct {
+	hash      hash.Hash
+	height    uint
+	order     uint
+	timestamp time.Time
+	submitted time.Time
+
+	// onMainTip is whether the main parent of the block was the main chain
+	// tip when it was submitted.
+	onMainTip bool
+
+	// missingTips is the number of tips not referenced by the block when it
+	// was submitted.
+	missingTips int
+}
+
+// minedBlocks are the mined blocks waiting for minedBlockCheckDepth main chain
+// blocks on top of them.
+type minedBlocks struct {
+	lock   sync.Mutex
+	blocks []*minedBlock
+}
+
+// ProcessMinedBlock processes a block mined by the node like ProcessBlock,
+// and watches the accepted block so that a notification is sent if it ends up
+// red or moved back in the order.
+func (b *BlockManager) ProcessMinedBlock(block *types.SerializedBlock) (bool, error) {
+	// The state of the DAG when the block is submitted is used to diagnose
+	// the block later.
+	bd := b.chain.BlockDAG()
+	mainTip := bd.GetMainChainTip()
+	mainParent := bd.GetMainParentByHashs(block.Block().Parents)
+	onMainTip := mainTip != nil && mainParent != nil &&
+		mainParent.GetHash().IsEqual(mainTip.GetHash())
+
+	parents := make(map[hash.Hash]struct{}, len(block.Block().Parents))
+	for _, p := range block.Block().Parents {
+		parents[*p] = struct{}{}
+	}
+	missingTips := 0
+	for _, tip := range bd.GetTipsList() {
+		if _, ok := parents[*tip.GetHash()]; !ok {
+			missingTips++
+		}
+	}
+	submitted := time.Now()
+
+	isOrphan, err := b.ProcessBlock(block, blockchain.BFNone)
+	if err != nil || isOrphan {
+		return isOrphan, err
+	}
+	// The cluster peers download the block right away instead of waiting
+	// for its inventory.
+	b.notify.MinedBlockFound(block)
+	ib := bd.GetBlock(block.Hash())
+	if ib != nil {
+		b.minedBlocks.add(&minedBlock{
+			hash:        *block.Hash(),
+			height:      ib.GetHeight(),
+			order:       ib.GetOrder(),
+			timestamp:   block.Block().Header.Timestamp,
+			submitted:   submitted,
+			onMainTip:   onMainTip,
+			missingTips: missingTips,
+		})
+	}
+	return isOrphan, err
+}
+
+func (mb *minedBlocks) add(block *minedBlock) {
+	mb.lock.Lock()
+	defer mb.lock.Unlock()
+
+	if len(mb.blocks) >= maxMinedBlocks {
+		mb.blocks = mb.blocks[1:]
+	}
+	mb.blocks = append(mb.blocks, block)
+}
+
+// checkMinedBlocks notifies the mined blocks which ended up red, or which
+// moved back by more than minedBlockMaxReorder orders, once they have
+// minedBlockCheckDepth main chain blocks on top of them.
+func (b *BlockManager) checkMinedBlocks() {
+	bd := b.chain.BlockDAG()
+	mainHeight := bd.GetMainChainTip().GetHeight()
+
+	b.minedBlocks.lock.Lock()
+	var checked []*minedBlock
+	pending := b.minedBlocks.blocks[:0]
+	for _, mb := range b.minedBlocks.blocks {
+		if mainHeight < mb.height+minedBlockCheckDepth {
+			pending = append(pending, mb)
+			continue
+		}
+		checked = append(checked, mb)
+	}
+	b.minedBlocks.blocks = pending
+	b.minedBlocks.lock.Unlock()
+
+	for _, mb := range checked {
+		ib := bd.GetBlock(&mb.hash)
+		if ib == nil {
+			continue
+		}
+		var reason string
+		if !bd.IsBlueBlock(&mb.hash) {
+			reason = MinedBlockRed
+		} else if ib.GetOrder() > mb.order+minedBlockMaxReorder {
+			reason = MinedBlockReordered
+		} else {
+			continue
+		}
+		hints := b.minedBlockHints(mb, reason)
+		log.Warn("Mined block is stale", "hash", mb.hash, "reason", reason,
+			"order", blockdag.GetOrderLogStr(ib.GetOrder()), "hints", hints)
+		b.notify.MinedBlockStale(&mb.hash, reason, hints)
+	}
+}
+
+// minedBlockHints returns the likely causes of a stale mined block.
+func (b *BlockManager) minedBlockHints(mb *minedBlock, reason string) []string {
+	hints := []string{}
+	if !mb.onMainTip {
+		hints = append(hints, "parent selection: the main parent of the "+
+			"block was not the main chain tip when it was submitted, "+
+			"refresh the block templates more often")
+	}
+	if mb.missingTips > 0 {
+		hints = append(hints, fmt.Sprintf("parent selection: the block "+
+			"did not reference %d of the tips when it was submitted",
+			mb.missingTips))
+	}
+	if delay := mb.submitted.Sub(mb.timestamp); delay > b.params.TargetTimePerBlock {
+		hints = append(hints, fmt.Sprintf("stale template: the block was "+
+			"submitted %s after the time of its template",
+			delay.Truncate(time.Second)))
+	}
+	if mb.onMainTip && reason == MinedBlockRed {
+		hints = append(hints, "late propagation: the block built on the "+
+			"main chain tip but competing blocks reached the network "+
+			"first, check the connectivity and the latency to the peers")
+	}
+	return hints
+}
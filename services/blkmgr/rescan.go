@@ -0,0 +1,256 @@
+// Copyright (c) 2017-2018 The qitmeer developers
+
+package blkmgr
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/marshal"
+	"github.com/Qitmeer/qitmeer/core/address"
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/json"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/params"
+	"github.com/Qitmeer/qitmeer/rpc"
+	"github.com/Qitmeer/qitmeer/wallet"
+)
+
+// maxRescanRange is the maximum number of block orders a single rescan
+// request may cover.
+const maxRescanRange = 10000
+
+// rescanFilter matches the outputs paying to a set of addresses or scripts
+// and the inputs spending them.
+type rescanFilter struct {
+	params    *params.Params
+	addrs     map[string]types.Address
+	scripts   map[string]struct{}
+	outpoints map[types.TxOutPoint]struct{}
+}
+
+func newRescanFilter(par *params.Params, addrs []string, scripts []string,
+	descriptors []string, descRange uint32) (*rescanFilter, error) {
+	f := &rescanFilter{
+		params:    par,
+		addrs:     make(map[string]types.Address, len(addrs)),
+		scripts:   make(map[string]struct{}, len(scripts)),
+		outpoints: make(map[types.TxOutPoint]struct{}),
+	}
+	for _, a := range addrs {
+		addr, err := address.DecodeAddress(a)
+		if err != nil {
+			return nil, rpc.RpcAddressKeyError("Could not decode "+
+				"address: %v", err)
+		}
+		f.addrs[addr.Encode()] = addr
+	}
+	for _, s := range scripts {
+		script, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, rpc.RpcDecodeHexError(s)
+		}
+		f.scripts[string(script)] = struct{}{}
+	}
+	for _, desc := range descriptors {
+		d, err := wallet.ParseDescriptor(desc, par)
+		if err != nil {
+			return nil, rpc.RpcInvalidError("Invalid descriptor %s: %v",
+				desc, err)
+		}
+		if addr := d.Address(); addr != nil {
+			f.addrs[addr.Encode()] = addr
+			continue
+		}
+		descScripts, err := d.Scripts(0, descRange)
+		if err != nil {
+			return nil, rpc.RpcInvalidError("Invalid descriptor %s: %v",
+				desc, err)
+		}
+		for _, script := range descScripts {
+			f.scripts[string(script)] = struct{}{}
+		}
+	}
+	return f, nil
+}
+
+func (f *rescanFilter) matchScript(pkScript []byte) bool {
+	if _, ok := f.scripts[string(pkScript)]; ok {
+		return true
+	}
+	if len(f.addrs) == 0 {
+		return false
+	}
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript, f.params)
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if _, ok := f.addrs[addr.Encode()]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTx returns whether the transaction pays to or spends from the filter.
+// Matching outputs are added to the filter so that later spends are found.
+func (f *rescanFilter) matchTx(tx *types.Tx) bool {
+	matched := false
+	msgTx := tx.Transaction()
+	if !msgTx.IsCoinBase() {
+		for _, txIn := range msgTx.TxIn {
+			if _, ok := f.outpoints[txIn.PreviousOut]; ok {
+				matched = true
+				break
+			}
+		}
+	}
+	for i, txOut := range msgTx.TxOut {
+		if f.matchScript(txOut.PkScript) {
+			matched = true
+			f.outpoints[*types.NewOutPoint(tx.Hash(), uint32(i))] = struct{}{}
+		}
+	}
+	return matched
+}
+
+// narrowRescanRange narrows the order range [start, end] to the blocks where
+// the addresses of the filter were active according to the address activity
+// index, which records both the outputs paying to an address and the inputs
+// spending them.  The range is kept when there is no index, when the index is
+// behind the end of the range or when a script of the filter pays to no
+// address.  The returned range is empty when no address was ever seen.
+func (api *PublicBlockAPI) narrowRescanRange(filter *rescanFilter, start int64, end int64) (int64, int64) {
+	idx := api.bm.addrActivityIndex
+	if idx == nil {
+		return start, end
+	}
+	tip, err := idx.TipOrder()
+	if err != nil || int64(tip) < end {
+		return start, end
+	}
+	addrs := make([]types.Address, 0, len(filter.addrs))
+	for _, addr := range filter.addrs {
+		addrs = append(addrs, addr)
+	}
+	for script := range filter.scripts {
+		_, scriptAddrs, _, err := txscript.ExtractPkScriptAddrs([]byte(script),
+			filter.params)
+		if err != nil || len(scriptAddrs) == 0 {
+			return start, end
+		}
+		addrs = append(addrs, scriptAddrs...)
+	}
+
+	first, last := int64(-1), int64(-1)
+	for _, addr := range addrs {
+		activity, err := idx.AddrActivity(addr)
+		if err != nil {
+			// The address type is not indexed.
+			return start, end
+		}
+		if activity == nil {
+			continue
+		}
+		if first < 0 || int64(activity.FirstSeen) < first {
+			first = int64(activity.FirstSeen)
+		}
+		if int64(activity.LastActive) > last {
+			last = int64(activity.LastActive)
+		}
+	}
+	if first < 0 {
+		return start, start - 1
+	}
+	if first > start {
+		start = first
+	}
+	if last < end {
+		end = last
+	}
+	return start, end
+}
+
+// RescanRange scans the blocks in the order range [start, end] for
+// transactions paying to the given addresses, scripts or output script
+// descriptors and transactions spending those outputs.  The ranged
+// descriptors cover the scripts of their first descRange child keys.  An end
+// of -1 scans up to the latest block.  Only the blocks where the addresses
+// were active are scanned when the address activity index is enabled.  The
+// scan is aborted when the call is cancelled or times out.
+func (api *PublicBlockAPI) RescanRange(ctx context.Context, addrs []string, scripts []string, start int64, end int64,
+	descriptors *[]string, descRange *uint32) (interface{}, error) {
+
+	var descs []string
+	if descriptors != nil {
+		descs = *descriptors
+	}
+	if len(addrs) == 0 && len(scripts) == 0 && len(descs) == 0 {
+		return nil, rpc.RpcInvalidError("No addresses, scripts or " +
+			"descriptors to rescan")
+	}
+	numKeys := uint32(wallet.DefaultDescriptorRange)
+	if descRange != nil {
+		numKeys = *descRange
+	}
+	mainOrder := int64(api.bm.chain.BestSnapshot().GraphState.GetMainOrder())
+	if end == LatestBlockOrder || end > mainOrder {
+		end = mainOrder
+	}
+	if start < 0 || start > end {
+		return nil, rpc.RpcInvalidError("Invalid order range [%d, %d]", start, end)
+	}
+	if end-start >= maxRescanRange {
+		return nil, rpc.RpcInvalidError("Order range exceeds the limit of %d blocks",
+			maxRescanRange)
+	}
+	filter, err := newRescanFilter(api.bm.params, addrs, scripts, descs, numKeys)
+	if err != nil {
+		return nil, err
+	}
+	start, end = api.narrowRescanRange(filter, start, end)
+
+	bd := api.bm.chain.BlockDAG()
+	result := []json.RescannedTx{}
+	for order := start; order <= end; order++ {
+		if err := ctx.Err(); err != nil {
+			return nil, rpc.RpcInternalError(err.Error(),
+				fmt.Sprintf("Rescan aborted at order %d", order))
+		}
+		release, err := api.bm.chain.Admit(ctx, blockchain.PriorityRescan)
+		if err != nil {
+			return nil, rpc.RpcInternalError(err.Error(),
+				fmt.Sprintf("Rescan aborted at order %d", order))
+		}
+		blk, err := api.bm.chain.BlockByOrder(uint64(order))
+		release()
+		if err != nil {
+			return nil, err
+		}
+		ib := bd.GetBlock(blk.Hash())
+		if ib == nil {
+			return nil, fmt.Errorf("no block %s in the DAG", blk.Hash())
+		}
+		confirmations := bd.GetConfirmations(ib.GetID())
+		api.bm.chain.CalculateDAGDuplicateTxs(blk)
+		for _, tx := range blk.Transactions() {
+			if tx.IsDuplicate || !filter.matchTx(tx) {
+				continue
+			}
+			txHex, err := marshal.MessageToHex(tx.Tx)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, json.RescannedTx{
+				TxId:          tx.Hash().String(),
+				BlockHash:     blk.Hash().String(),
+				Order:         uint64(order),
+				Confirmations: confirmations,
+				Hex:           txHex,
+			})
+		}
+	}
+	return result, nil
+}
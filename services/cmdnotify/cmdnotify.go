@@ -0,0 +1,225 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+// Package cmdnotify executes the shell commands configured by --blocknotify
+// and --walletnotify, replacing %s in them by the hash of the block or of the
+// transaction.
+package cmdnotify
+
+import (
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// maxPendingNotifications is the maximum number of notifications waiting to
+// be handled.  Notifications are dropped when the commands can not keep up.
+const maxPendingNotifications = 1000
+
+// Config is the configuration of the notifier.
+type Config struct {
+	// BlockNotify is the command executed when a block is connected to
+	// the main chain.
+	BlockNotify string
+
+	// WalletNotify is the command executed when a transaction paying to
+	// or spending from a watched address enters the memory pool or a
+	// block connected to the main chain.
+	WalletNotify string
+
+	// WatchAddrs are the addresses of the watched wallet.
+	WatchAddrs []types.Address
+
+	// FetchSpendJournal returns the outputs spent by a block connected to
+	// the main chain.
+	FetchSpendJournal func(block *types.SerializedBlock) ([]blockchain.SpentTxOut, error)
+
+	// FetchUtxoEntry returns the unspent output of the outpoint.
+	FetchUtxoEntry func(outpoint types.TxOutPoint) (*blockchain.UtxoEntry, error)
+}
+
+// Notifier executes the notification commands.  The commands never run on the
+// goroutine reporting the block or the transaction, so that a slow command can
+// not stall the chain.
+type Notifier struct {
+	started  int32
+	shutdown int32
+
+	cfg Config
+
+	// watched holds the public key scripts of the watched addresses.
+	watched map[string]struct{}
+
+	queue chan func()
+	wg    sync.WaitGroup
+	quit  chan struct{}
+}
+
+// New returns a new notifier.  Use Start to begin handling notifications.
+func New(cfg *Config) (*Notifier, error) {
+	watched := make(map[string]struct{}, len(cfg.WatchAddrs))
+	for _, addr := range cfg.WatchAddrs {
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return nil, err
+		}
+		watched[string(pkScript)] = struct{}{}
+	}
+	return &Notifier{
+		cfg:     *cfg,
+		watched: watched,
+		queue:   make(chan func(), maxPendingNotifications),
+		quit:    make(chan struct{}),
+	}, nil
+}
+
+// Start begins handling notifications.
+func (n *Notifier) Start() {
+	if atomic.AddInt32(&n.started, 1) != 1 {
+		return
+	}
+	n.wg.Add(1)
+	go n.handler()
+}
+
+// Stop stops handling notifications and waits for the notifier to exit.  The
+// pending notifications are dropped.
+func (n *Notifier) Stop() {
+	if atomic.AddInt32(&n.shutdown, 1) != 1 {
+		return
+	}
+	close(n.quit)
+	n.wg.Wait()
+}
+
+// BlockConnected executes the block command for the block, and the wallet
+// command for each of its transactions of the watched wallet.
+func (n *Notifier) BlockConnected(block *types.SerializedBlock) {
+	walletNotify := n.cfg.WalletNotify != "" && len(n.watched) > 0
+	if n.cfg.BlockNotify == "" && !walletNotify {
+		return
+	}
+	n.enqueue(func() {
+		if n.cfg.BlockNotify != "" {
+			execute(n.cfg.BlockNotify, block.Hash().String())
+		}
+		if walletNotify {
+			n.notifyBlockTransactions(block)
+		}
+	})
+}
+
+// TransactionAccepted executes the wallet command when the transaction
+// accepted to the memory pool belongs to the watched wallet.
+func (n *Notifier) TransactionAccepted(tx *types.Tx) {
+	if n.cfg.WalletNotify == "" || len(n.watched) == 0 {
+		return
+	}
+	n.enqueue(func() {
+		if n.paysWatched(tx) || n.spendsWatched(tx) {
+			execute(n.cfg.WalletNotify, tx.Hash().String())
+		}
+	})
+}
+
+// enqueue adds the notification to the queue, or drops it when the queue is
+// full.
+func (n *Notifier) enqueue(job func()) {
+	select {
+	case n.queue <- job:
+	default:
+		log.Warn("Dropping notification command, too many are pending")
+	}
+}
+
+func (n *Notifier) handler() {
+	defer n.wg.Done()
+
+	for {
+		select {
+		case job := <-n.queue:
+			job()
+		case <-n.quit:
+			return
+		}
+	}
+}
+
+// notifyBlockTransactions executes the wallet command for each transaction of
+// the block which pays to or spends from a watched address.
+func (n *Notifier) notifyBlockTransactions(block *types.SerializedBlock) {
+	spending := make(map[uint32]bool)
+	stxos, err := n.cfg.FetchSpendJournal(block)
+	if err != nil {
+		log.Warn("Unable to fetch spend journal", "block", block.Hash(), "error", err)
+	}
+	for _, stxo := range stxos {
+		if n.isWatched(stxo.PkScript) {
+			spending[stxo.TxIndex] = true
+		}
+	}
+	for i, tx := range block.Transactions() {
+		if spending[uint32(i)] || n.paysWatched(tx) {
+			execute(n.cfg.WalletNotify, tx.Hash().String())
+		}
+	}
+}
+
+// paysWatched returns whether an output of the transaction pays to a watched
+// address.
+func (n *Notifier) paysWatched(tx *types.Tx) bool {
+	for _, txOut := range tx.Tx.TxOut {
+		if n.isWatched(txOut.PkScript) {
+			return true
+		}
+	}
+	return false
+}
+
+// spendsWatched returns whether an input of the transaction spends an unspent
+// output paying to a watched address.
+func (n *Notifier) spendsWatched(tx *types.Tx) bool {
+	if tx.Tx.IsCoinBase() || types.IsTokenTx(tx.Tx) {
+		return false
+	}
+	for _, txIn := range tx.Tx.TxIn {
+		entry, err := n.cfg.FetchUtxoEntry(txIn.PreviousOut)
+		if err != nil || entry == nil {
+			continue
+		}
+		if n.isWatched(entry.PkScript()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Notifier) isWatched(pkScript []byte) bool {
+	_, ok := n.watched[string(pkScript)]
+	return ok
+}
+
+// execute runs the command through the shell, with %s replaced by the hash,
+// without waiting for it to exit.
+func execute(command string, hash string) {
+	command = strings.Replace(command, "%s", hash, -1)
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	if err := cmd.Start(); err != nil {
+		log.Warn("Failed to execute notification command", "command", command, "error", err)
+		return
+	}
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			log.Warn("Notification command failed", "command", command, "error", err)
+		}
+	}()
+}
@@ -26,8 +26,19 @@ func LoadBlockDB(cfg *config.Config) (database.DB, error) {
 	// The database name is based on the database type.
 	dbPath := blockDbPath(cfg.DbType, cfg)
 
+	// The cold storage for ancient block files is optional.
+	var cold database.ColdStorage
+	if len(cfg.ColdDataDir) > 0 {
+		var err error
+		cold, err = database.NewDirColdStorage(cfg.ColdDataDir)
+		if err != nil {
+			return nil, err
+		}
+		log.Info("Cold block storage is enabled", "path", cfg.ColdDataDir)
+	}
+
 	log.Info("Loading block database", "dbPath", dbPath)
-	db, err := database.Open(cfg.DbType, dbPath, params.ActiveNetParams.Net)
+	db, err := database.Open(cfg.DbType, dbPath, params.ActiveNetParams.Net, cold)
 	if err != nil {
 		// Return the error if it's not because the database doesn't
 		// exist.
@@ -41,7 +52,7 @@ func LoadBlockDB(cfg *config.Config) (database.DB, error) {
 		if err != nil {
 			return nil, err
 		}
-		db, err = database.Create(cfg.DbType, dbPath, params.ActiveNetParams.Net)
+		db, err = database.Create(cfg.DbType, dbPath, params.ActiveNetParams.Net, cold)
 		if err != nil {
 			return nil, err
 		}
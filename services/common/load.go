@@ -6,10 +6,12 @@ package common
 
 import (
 	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
 	"github.com/Qitmeer/qitmeer/common/roughtime"
 	"github.com/Qitmeer/qitmeer/common/util"
 	"github.com/Qitmeer/qitmeer/config"
 	"github.com/Qitmeer/qitmeer/core/address"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
 	"github.com/Qitmeer/qitmeer/log"
 	"github.com/Qitmeer/qitmeer/params"
 	"github.com/Qitmeer/qitmeer/services/mempool"
@@ -34,14 +36,27 @@ const (
 	defaultGenerate               = false
 	defaultBlockMinSize           = 0
 	defaultBlockMaxSize           = 375000
+	defaultTxAgingMaxSteps        = 10
 	defaultMaxRPCClients          = 10
 	defaultMaxRPCWebsockets       = 25
 	defaultMaxRPCConcurrentReqs   = 20
+	defaultRPCCacheTTL            = 10 // seconds
 	defaultMaxPeers               = 50
 	defaultMiningStateSync        = false
 	defaultMaxInboundPeersPerHost = 25 // The default max total of inbound peer for host
 	defaultTrickleInterval        = 10 * time.Second
 	defaultCacheInvalidTx         = false
+	defaultMinFreeDisk            = 512 // MB
+	defaultColdStorageDepth       = 100000
+	defaultBloomRateLimit         = 100
+	defaultClusterRateLimit       = 120
+	defaultKeystoreTimeout        = 300 // seconds
+	defaultMaxClockSkew           = 60  // seconds
+	defaultAnticoneCacheSize      = 16  // MiB
+	defaultUtxoCacheSize          = 64  // MiB
+	defaultSLOIntervalDeviation   = 50  // percent
+	defaultSLOMaxRedRate          = 10  // percent
+	defaultMetricsHistory         = 1440
 )
 const (
 	defaultSigCacheMaxSize = 100000
@@ -79,18 +94,30 @@ func LoadConfig() (*config.Config, []string, error) {
 		RPCMaxClients:        defaultMaxRPCClients,
 		RPCMaxWebsockets:     defaultMaxRPCWebsockets,
 		RPCMaxConcurrentReqs: defaultMaxRPCConcurrentReqs,
+		RPCCacheTTL:          defaultRPCCacheTTL,
 		Generate:             defaultGenerate,
 		MaxPeers:             defaultMaxPeers,
 		MinTxFee:             mempool.DefaultMinRelayTxFee,
 		BlockMinSize:         defaultBlockMinSize,
 		BlockMaxSize:         defaultBlockMaxSize,
+		TxAgingMaxSteps:      defaultTxAgingMaxSteps,
 		SigCacheMaxSize:      defaultSigCacheMaxSize,
 		MiningStateSync:      defaultMiningStateSync,
-		DAGType:              defaultDAGType,
 		Banning:              true,
 		MaxInbound:           defaultMaxInboundPeersPerHost,
+		BloomRateLimit:       defaultBloomRateLimit,
+		ClusterRateLimit:     defaultClusterRateLimit,
 		CacheInvalidTx:       defaultCacheInvalidTx,
 		NTP:                  false,
+		MaxClockSkew:         defaultMaxClockSkew,
+		MinFreeDisk:          defaultMinFreeDisk,
+		ColdStorageDepth:     defaultColdStorageDepth,
+		KeystoreTimeout:      defaultKeystoreTimeout,
+		AnticoneCacheSize:    defaultAnticoneCacheSize,
+		UtxoCacheSize:        defaultUtxoCacheSize,
+		SLOIntervalDeviation: defaultSLOIntervalDeviation,
+		SLOMaxRedRate:        defaultSLOMaxRedRate,
+		MetricsHistory:       defaultMetricsHistory,
 	}
 
 	// Pre-parse the command line options to see if an alternative config
@@ -257,6 +284,32 @@ func LoadConfig() (*config.Config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Fail fast on inconsistent network parameters.
+	if err := params.ActiveNetParams.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
+	// A node ordering the DAG with another algorithm than the network would
+	// fork from it.
+	if netDAGType := params.ActiveNetParams.DAGType; len(netDAGType) > 0 {
+		if len(cfg.DAGType) > 0 && cfg.DAGType != netDAGType {
+			err := fmt.Errorf("%s: the DAG type of %s is %s, not %s",
+				funcName, params.ActiveNetParams.Name, netDAGType,
+				cfg.DAGType)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		cfg.DAGType = netDAGType
+	} else if len(cfg.DAGType) == 0 {
+		cfg.DAGType = defaultDAGType
+	}
+	if blockdag.NewBlockDAG(cfg.DAGType) == nil {
+		err := fmt.Errorf("%s: unknown DAG type %s", funcName, cfg.DAGType)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+
 	// Add default port to all rpc listener addresses if needed and remove
 	// duplicate addresses.
 	cfg.RPCListeners = normalizeAddresses(cfg.RPCListeners,
@@ -322,6 +375,13 @@ func LoadConfig() (*config.Config, []string, error) {
 	// worry about changing names per network and such.
 	cfg.DataDir = util.CleanAndExpandPath(cfg.DataDir)
 	cfg.DataDir = filepath.Join(cfg.DataDir, params.ActiveNetParams.Name)
+	if len(cfg.ColdDataDir) > 0 {
+		cfg.ColdDataDir = util.CleanAndExpandPath(cfg.ColdDataDir)
+		cfg.ColdDataDir = filepath.Join(cfg.ColdDataDir, params.ActiveNetParams.Name)
+	}
+	if len(cfg.RPCUnixSocket) > 0 {
+		cfg.RPCUnixSocket = util.CleanAndExpandPath(cfg.RPCUnixSocket)
+	}
 
 	// Set logging file if presented
 	if !cfg.NoFileLogging {
@@ -358,6 +418,36 @@ func LoadConfig() (*config.Config, []string, error) {
 		return nil, nil, err
 	}
 
+	// --addractivityindex and --dropaddractivityindex do not mix.
+	if cfg.AddrActivityIndex && cfg.DropAddrActivityIndex {
+		err := fmt.Errorf("%s: the --addractivityindex and "+
+			"--dropaddractivityindex options may not be activated at "+
+			"the same time", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --utxoageindex and --droputxoageindex do not mix.
+	if cfg.UtxoAgeIndex && cfg.DropUtxoAgeIndex {
+		err := fmt.Errorf("%s: the --utxoageindex and --droputxoageindex "+
+			"options may not be activated at the same time",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --minerindex and --dropminerindex do not mix.
+	if cfg.MinerIndex && cfg.DropMinerIndex {
+		err := fmt.Errorf("%s: the --minerindex and --dropminerindex "+
+			"options may not be activated at the same time",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// --addrindex and --droptxindex do not mix.
 	if cfg.AddrIndex && cfg.DropTxIndex {
 		err := fmt.Errorf("%s: the --addrindex and --droptxindex "+
@@ -370,6 +460,81 @@ func LoadConfig() (*config.Config, []string, error) {
 		return nil, nil, err
 	}
 
+	// --prune needs a finality point to prune below.
+	if cfg.Prune > 0 && cfg.FinalityDepth == 0 {
+		err := fmt.Errorf("%s: the --prune option requires the "+
+			"--finalitydepth option", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --dagverifyrepair repairs what --dagverify finds.
+	if cfg.DAGVerifyRepair && !cfg.DAGVerify {
+		err := fmt.Errorf("%s: the --dagverifyrepair option requires the "+
+			"--dagverify option", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// The indexes catching up from the stored blocks need the bodies of all
+	// blocks, which are deleted by --prune.
+	if cfg.Prune > 0 {
+		for _, index := range []struct {
+			option  string
+			enabled bool
+		}{
+			{"--addrindex", cfg.AddrIndex},
+			{"--addractivityindex", cfg.AddrActivityIndex},
+			{"--utxoageindex", cfg.UtxoAgeIndex},
+			{"--minerindex", cfg.MinerIndex},
+			{"--backfillindexes", cfg.BackfillIndexes},
+		} {
+			if !index.enabled {
+				continue
+			}
+			err := fmt.Errorf("%s: the --prune and %s options "+
+				"may not be activated at the same time", funcName,
+				index.option)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
+	// --follow only connects with the leader and does not mine.
+	if len(cfg.Follow) > 0 {
+		if cfg.Generate {
+			err := fmt.Errorf("%s: the --follow and --generate options "+
+				"may not be activated at the same time", funcName)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.NoDiscovery = true
+		cfg.BootstrapNodes = nil
+		cfg.AddPeers = []string{cfg.Follow}
+	}
+
+	// --prune and --colddatadir do not mix.
+	if cfg.Prune > 0 && len(cfg.ColdDataDir) > 0 {
+		err := fmt.Errorf("%s: the --prune and --colddatadir options "+
+			"may not be activated at the same time", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// The red block rate is a percentage.
+	if cfg.SLOMaxRedRate > 100 {
+		err := fmt.Errorf("%s: the --slomaxredrate option must be a "+
+			"percentage, got %d", funcName, cfg.SLOMaxRedRate)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Check mining addresses are valid and saved parsed versions.
 	for _, strAddr := range cfg.MiningAddrs {
 		addr, err := address.DecodeAddress(strAddr)
@@ -392,6 +557,42 @@ func LoadConfig() (*config.Config, []string, error) {
 		cfg.SetMiningAddrs(addr)
 	}
 
+	// Check the transactions of --templateincludetx and --templateexcludetx
+	// are valid hashes.
+	for _, txs := range [][]string{cfg.TemplateIncludeTxs, cfg.TemplateExcludeTxs} {
+		for _, strHash := range txs {
+			_, err := hash.NewHashFromStr(strHash)
+			if err != nil {
+				str := "%s: transaction hash '%s' failed to decode: %v"
+				err := fmt.Errorf(str, funcName, strHash, err)
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, usageMessage)
+				return nil, nil, err
+			}
+		}
+	}
+
+	// Check the watched addresses of --walletnotify are valid and save
+	// parsed versions.
+	for _, strAddr := range cfg.WalletNotifyAddrs {
+		addr, err := address.DecodeAddress(strAddr)
+		if err != nil {
+			str := "%s: wallet notify address '%s' failed to decode: %v"
+			err := fmt.Errorf(str, funcName, strAddr, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		if !address.IsForNetwork(addr, params.ActiveNetParams.Params) {
+			str := "%s: wallet notify address '%s' is on the wrong network"
+			err := fmt.Errorf(str, funcName, strAddr)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.SetWalletNotifyAddrs(addr)
+	}
+
 	// Ensure there is at least one mining address when the generate flag is
 	// set.
 	if cfg.Generate && len(cfg.MiningAddrs) == 0 {
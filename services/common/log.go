@@ -4,6 +4,7 @@ package common
 
 import (
 	"fmt"
+	"github.com/Qitmeer/qitmeer/common/crash"
 	"github.com/Qitmeer/qitmeer/log"
 	"github.com/Qitmeer/qitmeer/log/term"
 	"github.com/jrick/logrotate/rotator"
@@ -68,7 +69,10 @@ func init() {
 	// and Go runtime exceptions are printed to stderr as well.
 	logWrite = &logWriter{}
 	logWrite.Init()
-	glogger = log.NewGlogHandler(log.StreamHandler(io.Writer(logWrite), log.TerminalFormat(logWrite.IsUseColor())))
+	// The records are also kept as the recent events of the crash reports.
+	glogger = log.NewGlogHandler(log.MultiHandler(
+		log.StreamHandler(io.Writer(logWrite), log.TerminalFormat(logWrite.IsUseColor())),
+		crash.EventHandler()))
 
 	log.Root().SetHandler(glogger)
 
@@ -0,0 +1,200 @@
+// Copyright (c) 2017-2018 The qitmeer developers
+
+// Package diskmon watches the free space of the data directory and switches
+// the block chain into a read only mode before the disk runs full, so that
+// the database is never corrupted by a failed write.
+package diskmon
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/Qitmeer/qitmeer/metrics"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// checkInterval is the interval between two checks of the free space.
+	checkInterval = time.Minute
+
+	// resumeMargin is the extra free space on top of the threshold that is
+	// required to leave the low space mode again.  It avoids toggling the
+	// mode when the free space hovers around the threshold.
+	resumeMargin = 64 * 1024 * 1024
+
+	// webhookTimeout is the timeout of a webhook alert.
+	webhookTimeout = 10 * time.Second
+)
+
+var (
+	freeSpaceGauge = metrics.NewGauge("system/disk/free")
+	lowSpaceGauge  = metrics.NewGauge("system/disk/lowspace")
+)
+
+// Chain is the part of the block chain the monitor protects.
+type Chain interface {
+	SetReadOnly(readOnly bool)
+}
+
+// Config is the configuration of the disk space monitor.
+type Config struct {
+	// Path is the directory which is monitored, usually the data directory.
+	Path string
+
+	// MinFreeSpace is the free space in bytes below which the chain stops
+	// accepting new blocks.
+	MinFreeSpace uint64
+
+	// Webhook is an optional URL that receives a HTTP POST with an Alert
+	// whenever the low space mode is entered or left.
+	Webhook string
+
+	// Chain is switched into read only mode while the space is low.
+	Chain Chain
+}
+
+// Alert is the json payload sent to the webhook.
+type Alert struct {
+	Path         string `json:"path"`
+	LowSpace     bool   `json:"lowspace"`
+	FreeSpace    uint64 `json:"freespace"`
+	MinFreeSpace uint64 `json:"minfreespace"`
+	Time         int64  `json:"time"`
+}
+
+// Monitor periodically checks the free disk space.
+type Monitor struct {
+	started  int32
+	shutdown int32
+
+	// lowSpace is 1 while the free space is low.  It must only be used
+	// atomically.
+	lowSpace int32
+
+	cfg       Config
+	freeSpace func(path string) (uint64, error)
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// New returns a new disk space monitor.  Use Start to begin monitoring.
+func New(cfg *Config) *Monitor {
+	return &Monitor{
+		cfg:       *cfg,
+		freeSpace: FreeSpace,
+		quit:      make(chan struct{}),
+	}
+}
+
+// Start begins monitoring the free space.
+func (m *Monitor) Start() {
+	if atomic.AddInt32(&m.started, 1) != 1 {
+		return
+	}
+	if err := m.check(); err != nil {
+		log.Warn("Disk space monitor is disabled", "path", m.cfg.Path, "error", err)
+		return
+	}
+	m.wg.Add(1)
+	go m.handler()
+}
+
+// Stop stops monitoring and waits for the monitor to exit.
+func (m *Monitor) Stop() {
+	if atomic.AddInt32(&m.shutdown, 1) != 1 {
+		return
+	}
+	close(m.quit)
+	m.wg.Wait()
+}
+
+// IsLowSpace returns whether the monitor is in the low space mode.
+func (m *Monitor) IsLowSpace() bool {
+	return atomic.LoadInt32(&m.lowSpace) != 0
+}
+
+func (m *Monitor) handler() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.check(); err != nil {
+				log.Error("Failed to read the free disk space", "path", m.cfg.Path, "error", err)
+			}
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// check reads the free space and enters or leaves the low space mode.
+func (m *Monitor) check() error {
+	free, err := m.freeSpace(m.cfg.Path)
+	if err != nil {
+		return err
+	}
+	freeSpaceGauge.Update(int64(free))
+
+	lowSpace := m.IsLowSpace()
+	if !lowSpace && free < m.cfg.MinFreeSpace {
+		m.setLowSpace(true, free)
+		log.Error("Low disk space, stop accepting new blocks until space is freed",
+			"path", m.cfg.Path, "free", free, "min", m.cfg.MinFreeSpace)
+	} else if lowSpace && free >= m.cfg.MinFreeSpace+resumeMargin {
+		m.setLowSpace(false, free)
+		log.Info("Disk space was freed, resume accepting new blocks",
+			"path", m.cfg.Path, "free", free)
+	} else if lowSpace {
+		log.Warn("Disk space is still low", "path", m.cfg.Path, "free", free)
+	}
+	return nil
+}
+
+func (m *Monitor) setLowSpace(lowSpace bool, free uint64) {
+	if lowSpace {
+		atomic.StoreInt32(&m.lowSpace, 1)
+		lowSpaceGauge.Update(1)
+	} else {
+		atomic.StoreInt32(&m.lowSpace, 0)
+		lowSpaceGauge.Update(0)
+	}
+	if m.cfg.Chain != nil {
+		m.cfg.Chain.SetReadOnly(lowSpace)
+	}
+	if len(m.cfg.Webhook) > 0 {
+		m.wg.Add(1)
+		go m.sendAlert(&Alert{
+			Path:         m.cfg.Path,
+			LowSpace:     lowSpace,
+			FreeSpace:    free,
+			MinFreeSpace: m.cfg.MinFreeSpace,
+			Time:         time.Now().Unix(),
+		})
+	}
+}
+
+func (m *Monitor) sendAlert(alert *Alert) {
+	defer m.wg.Done()
+
+	data, err := json.Marshal(alert)
+	if err != nil {
+		log.Error("Failed to encode the disk space alert", "error", err)
+		return
+	}
+	client := http.Client{Timeout: webhookTimeout}
+	resp, err := client.Post(m.cfg.Webhook, "application/json", bytes.NewReader(data))
+	if err != nil {
+		log.Error("Failed to send the disk space alert", "url", m.cfg.Webhook, "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.Warn("Disk space alert was not accepted", "url", m.cfg.Webhook, "status", resp.Status)
+	}
+}
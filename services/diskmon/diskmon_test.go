@@ -0,0 +1,44 @@
+package diskmon
+
+import (
+	"testing"
+)
+
+type testChain struct {
+	readOnly bool
+}
+
+func (c *testChain) SetReadOnly(readOnly bool) {
+	c.readOnly = readOnly
+}
+
+func TestLowSpaceMode(t *testing.T) {
+	chain := &testChain{}
+	free := uint64(0)
+	m := New(&Config{Path: ".", MinFreeSpace: 1000, Chain: chain})
+	m.freeSpace = func(path string) (uint64, error) {
+		return free, nil
+	}
+
+	tests := []struct {
+		free     uint64
+		lowSpace bool
+	}{
+		{free: 2000, lowSpace: false},
+		{free: 999, lowSpace: true},
+		// stay read only until the margin is freed as well
+		{free: 1000 + resumeMargin - 1, lowSpace: true},
+		{free: 1000 + resumeMargin, lowSpace: false},
+		{free: 1000, lowSpace: false},
+	}
+	for i, test := range tests {
+		free = test.free
+		if err := m.check(); err != nil {
+			t.Fatal(err)
+		}
+		if m.IsLowSpace() != test.lowSpace || chain.readOnly != test.lowSpace {
+			t.Fatalf("test %d: expected low space %v, got %v (chain read only %v)",
+				i, test.lowSpace, m.IsLowSpace(), chain.readOnly)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+// Copyright (c) 2017-2018 The qitmeer developers
+
+// +build !linux,!darwin
+
+package diskmon
+
+import "errors"
+
+// FreeSpace returns the number of bytes available to an unprivileged user on
+// the file system holding the given path.
+func FreeSpace(path string) (uint64, error) {
+	return 0, errors.New("Not implemented")
+}
@@ -0,0 +1,18 @@
+// Copyright (c) 2017-2018 The qitmeer developers
+
+// +build linux darwin
+
+package diskmon
+
+import "syscall"
+
+// FreeSpace returns the number of bytes available to an unprivileged user on
+// the file system holding the given path.
+func FreeSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	err := syscall.Statfs(path, &stat)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
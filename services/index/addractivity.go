@@ -0,0 +1,393 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package index
+
+import (
+	"fmt"
+
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/params"
+)
+
+const (
+	// addrActivityIndexName is the human-readable name for the index.
+	addrActivityIndexName = "address activity index"
+
+	// addrActivityEntrySize is the number of bytes an address activity
+	// entry consumes.  It consists of 4 bytes first seen order + 4 bytes
+	// last active order + 4 bytes transaction count.
+	addrActivityEntrySize = 4 + 4 + 4
+
+	// addrActivityUndoSize is the number of bytes an undo entry of a block
+	// consumes.  It consists of the address key + 4 bytes previous last
+	// active order + 4 bytes previous transaction count.
+	addrActivityUndoSize = addrKeySize + 4 + 4
+)
+
+var (
+	// addrActivityIndexKey is the key of the address activity index and
+	// the db bucket used to house it.
+	addrActivityIndexKey = []byte("addractivityidx")
+
+	// addrActivityUndoBucketName is the name of the bucket nested in the
+	// index bucket which houses the undo entries of the blocks.
+	addrActivityUndoBucketName = []byte("undo")
+)
+
+// -----------------------------------------------------------------------------
+// The address activity index maps addresses referenced in the blockchain to
+// the order of the block where they were first seen, the order of the block
+// where they were last active and the number of transactions involving them.
+// It allows to find dormant addresses without scanning the address index.
+//
+// The serialized key format is the address key of the address index:
+//
+//   <addr type><addr hash>
+//
+//   Field           Type      Size
+//   addr type       uint8     1 byte
+//   addr hash       hash160   20 bytes
+//   -----
+//   Total: 21 bytes
+//
+// The serialized value format is:
+//
+//   <first seen order><last active order><tx count>
+//
+//   Field              Type      Size
+//   first seen order   uint32    4 bytes
+//   last active order  uint32    4 bytes
+//   tx count           uint32    4 bytes
+//   -----
+//   Total: 12 bytes
+//
+// Since the last active order of an address can not be recovered when a block
+// is disconnected, the former values of the entries updated by each block are
+// kept in the nested undo bucket, keyed by the order of the block:
+//
+//   [<addr type><addr hash><last active order><tx count>,...]
+//
+//   Field              Type      Size
+//   addr type          uint8     1 byte
+//   addr hash          hash160   20 bytes
+//   last active order  uint32    4 bytes
+//   tx count           uint32    4 bytes
+//   -----
+//   Total: 29 bytes per address involved in the block
+//
+// The entries of the addresses first seen in a block are removed when it is
+// disconnected.
+// -----------------------------------------------------------------------------
+
+// AddrActivity holds the activity of an address in the blockchain.
+type AddrActivity struct {
+	// FirstSeen is the order of the block where the address was first seen.
+	FirstSeen uint32
+
+	// LastActive is the order of the latest block involving the address.
+	LastActive uint32
+
+	// TxCount is the number of transactions involving the address.
+	TxCount uint32
+}
+
+// serializeAddrActivity serializes the activity of an address according to
+// the format described in detail above.
+func serializeAddrActivity(activity *AddrActivity) []byte {
+	serialized := make([]byte, addrActivityEntrySize)
+	byteOrder.PutUint32(serialized, activity.FirstSeen)
+	byteOrder.PutUint32(serialized[4:], activity.LastActive)
+	byteOrder.PutUint32(serialized[8:], activity.TxCount)
+	return serialized
+}
+
+// deserializeAddrActivity decodes the activity of an address from the passed
+// serialized byte slice according to the format described in detail above.
+func deserializeAddrActivity(serialized []byte) (*AddrActivity, error) {
+	if len(serialized) < addrActivityEntrySize {
+		return nil, errDeserialize("unexpected end of data")
+	}
+	return &AddrActivity{
+		FirstSeen:  byteOrder.Uint32(serialized[0:4]),
+		LastActive: byteOrder.Uint32(serialized[4:8]),
+		TxCount:    byteOrder.Uint32(serialized[8:12]),
+	}, nil
+}
+
+// dbFetchAddrActivity returns the activity of the address key, or nil when the
+// address was never seen.
+func dbFetchAddrActivity(bucket internalBucket, addrKey [addrKeySize]byte) (*AddrActivity, error) {
+	serialized := bucket.Get(addrKey[:])
+	if serialized == nil {
+		return nil, nil
+	}
+	activity, err := deserializeAddrActivity(serialized)
+	if err != nil {
+		return nil, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("failed to deserialize "+
+				"address activity for key %x: %v", addrKey, err),
+		}
+	}
+	return activity, nil
+}
+
+// AddrActivityIndex implements an address activity index.  For every address
+// referenced in the blockchain it records the order of the block where the
+// address was first seen, the order of the block where it was last active,
+// either credited or debited, and the number of transactions involving it.
+type AddrActivityIndex struct {
+	// The following fields are set when the instance is created and can't
+	// be changed afterwards, so there is no need to protect them with a
+	// separate mutex.
+	db          database.DB
+	chainParams *params.Params
+}
+
+// Ensure the AddrActivityIndex type implements the Indexer interface.
+var _ Indexer = (*AddrActivityIndex)(nil)
+
+// Ensure the AddrActivityIndex type implements the NeedsInputser interface.
+var _ NeedsInputser = (*AddrActivityIndex)(nil)
+
+// NeedsInputs signals that the index requires the referenced inputs in order
+// to properly create the index.
+//
+// This implements the NeedsInputser interface.
+func (idx *AddrActivityIndex) NeedsInputs() bool {
+	return true
+}
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *AddrActivityIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *AddrActivityIndex) Key() []byte {
+	return addrActivityIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *AddrActivityIndex) Name() string {
+	return addrActivityIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the address
+// activity index and the nested bucket for the undo entries.
+//
+// This is part of the Indexer interface.
+func (idx *AddrActivityIndex) Create(dbTx database.Tx) error {
+	bucket, err := dbTx.Metadata().CreateBucket(addrActivityIndexKey)
+	if err != nil {
+		return err
+	}
+	_, err = bucket.CreateBucket(addrActivityUndoBucketName)
+	return err
+}
+
+// indexPkScript adds the standard addresses of the passed public key script to
+// the set of addresses involved in a transaction.
+func (idx *AddrActivityIndex) indexPkScript(addrs map[[addrKeySize]byte]struct{}, pkScript []byte) {
+	_, scriptAddrs, _, err := txscript.ExtractPkScriptAddrs(pkScript,
+		idx.chainParams)
+	if err != nil {
+		return
+	}
+	for _, addr := range scriptAddrs {
+		addrKey, err := addrToKey(addr, idx.chainParams)
+		if err != nil {
+			// Ignore unsupported address types.
+			continue
+		}
+		addrs[addrKey] = struct{}{}
+	}
+}
+
+// indexBlock returns the number of transactions of the block involving each
+// address, either by spending one of its outputs or by paying it.
+func (idx *AddrActivityIndex) indexBlock(block *types.SerializedBlock, stxos []blockchain.SpentTxOut) map[[addrKeySize]byte]uint32 {
+	txCounts := make(map[[addrKeySize]byte]uint32)
+	index := 0
+	for txIdx, tx := range block.Transactions() {
+		if tx.IsDuplicate {
+			continue
+		}
+		txAddrs := make(map[[addrKeySize]byte]struct{})
+		// Coinbases do not reference any inputs.
+		if txIdx != 0 {
+			for range tx.Transaction().TxIn {
+				if index >= len(stxos) {
+					break
+				}
+				idx.indexPkScript(txAddrs, stxos[index].PkScript)
+				index++
+			}
+		}
+		for _, txOut := range tx.Transaction().TxOut {
+			idx.indexPkScript(txAddrs, txOut.PkScript)
+		}
+		for addrKey := range txAddrs {
+			txCounts[addrKey]++
+		}
+	}
+	return txCounts
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer updates the activity of each
+// address the transactions in the block involve and saves their former
+// activity in the undo entry of the block.
+//
+// This is part of the Indexer interface.
+func (idx *AddrActivityIndex) ConnectBlock(dbTx database.Tx, block *types.SerializedBlock, stxos []blockchain.SpentTxOut) error {
+	txCounts := idx.indexBlock(block, stxos)
+	if len(txCounts) == 0 {
+		return nil
+	}
+
+	order := uint32(block.Order())
+	bucket := dbTx.Metadata().Bucket(addrActivityIndexKey)
+	undo := make([]byte, 0, len(txCounts)*addrActivityUndoSize)
+	for addrKey, txCount := range txCounts {
+		activity, err := dbFetchAddrActivity(bucket, addrKey)
+		if err != nil {
+			return err
+		}
+		if activity == nil {
+			activity = &AddrActivity{FirstSeen: order}
+		}
+
+		var undoEntry [addrActivityUndoSize]byte
+		copy(undoEntry[:], addrKey[:])
+		byteOrder.PutUint32(undoEntry[addrKeySize:], activity.LastActive)
+		byteOrder.PutUint32(undoEntry[addrKeySize+4:], activity.TxCount)
+		undo = append(undo, undoEntry[:]...)
+
+		activity.LastActive = order
+		activity.TxCount += txCount
+		err = bucket.Put(addrKey[:], serializeAddrActivity(activity))
+		if err != nil {
+			return err
+		}
+	}
+
+	undoBucket := bucket.Bucket(addrActivityUndoBucketName)
+	return undoBucket.Put(orderUndoKey(order), undo)
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer restores the activity of each
+// address the transactions in the block involve from the undo entry of the
+// block and removes the addresses first seen in the block.
+//
+// This is part of the Indexer interface.
+func (idx *AddrActivityIndex) DisconnectBlock(dbTx database.Tx, block *types.SerializedBlock, stxos []blockchain.SpentTxOut) error {
+	order := uint32(block.Order())
+	bucket := dbTx.Metadata().Bucket(addrActivityIndexKey)
+	undoBucket := bucket.Bucket(addrActivityUndoBucketName)
+	undoKey := orderUndoKey(order)
+	undo := undoBucket.Get(undoKey)
+	if len(undo)%addrActivityUndoSize != 0 {
+		return database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt address activity undo "+
+				"entry for order %d", order),
+		}
+	}
+
+	for offset := 0; offset < len(undo); offset += addrActivityUndoSize {
+		var addrKey [addrKeySize]byte
+		copy(addrKey[:], undo[offset:])
+		activity, err := dbFetchAddrActivity(bucket, addrKey)
+		if err != nil {
+			return err
+		}
+		if activity == nil {
+			return AssertError(fmt.Sprintf("address activity for "+
+				"key %x to undo at order %d is missing", addrKey,
+				order))
+		}
+
+		if activity.FirstSeen == order {
+			err = bucket.Delete(addrKey[:])
+		} else {
+			activity.LastActive = byteOrder.Uint32(undo[offset+addrKeySize:])
+			activity.TxCount = byteOrder.Uint32(undo[offset+addrKeySize+4:])
+			err = bucket.Put(addrKey[:], serializeAddrActivity(activity))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return undoBucket.Delete(undoKey)
+}
+
+// TipOrder returns the order of the latest block covered by the index, which
+// is behind the chain while the index is backfilled.
+//
+// This function is safe for concurrent access.
+func (idx *AddrActivityIndex) TipOrder() (uint32, error) {
+	var order uint32
+	err := idx.db.View(func(dbTx database.Tx) error {
+		var err error
+		_, order, err = dbFetchIndexerTip(dbTx, addrActivityIndexKey)
+		return err
+	})
+	return order, err
+}
+
+// AddrActivity returns the activity of the passed address in the blockchain,
+// or nil when the address was never seen.
+//
+// This function is safe for concurrent access.
+func (idx *AddrActivityIndex) AddrActivity(addr types.Address) (*AddrActivity, error) {
+	addrKey, err := addrToKey(addr, idx.chainParams)
+	if err != nil {
+		return nil, err
+	}
+
+	var activity *AddrActivity
+	err = idx.db.View(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(addrActivityIndexKey)
+		activity, err = dbFetchAddrActivity(bucket, addrKey)
+		return err
+	})
+	return activity, err
+}
+
+// NewAddrActivityIndex returns a new instance of an indexer that is used to
+// record the first seen and last active orders of all addresses in the
+// blockchain.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewAddrActivityIndex(db database.DB, chainParams *params.Params) *AddrActivityIndex {
+	return &AddrActivityIndex{
+		db:          db,
+		chainParams: chainParams,
+	}
+}
+
+// DropAddrActivityIndex drops the address activity index from the provided
+// database if it exists.
+func DropAddrActivityIndex(db database.DB, interrupt <-chan struct{}) error {
+	return dropIndex(db, addrActivityIndexKey, addrActivityIndexName,
+		interrupt)
+}
@@ -0,0 +1,274 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package index
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/math"
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
+	"github.com/Qitmeer/qitmeer/log"
+	"github.com/Qitmeer/qitmeer/services/common/progresslog"
+	"sync/atomic"
+	"time"
+)
+
+// backfillRetryInterval is the time to wait before retrying to backfill an
+// index after an error.
+const backfillRetryInterval = time.Minute
+
+// BackfillStatus is the progress of an index built in the background.
+type BackfillStatus struct {
+	Name string
+
+	// Order is the order of the last block indexed, or -1 when no block
+	// was indexed yet.
+	Order int64
+
+	// TargetOrder is the main order of the chain when the status was
+	// last updated.
+	TargetOrder int64
+
+	// Live is whether the index caught up and follows the chain.
+	Live bool
+}
+
+// EnableBackfill makes the manager build the indexes which are behind the
+// chain, such as newly enabled ones, in the background once started instead of
+// catching them up during Init.  The transaction index is always caught up
+// during Init since the chain relies on it to detect duplicate transactions.
+//
+// This function MUST be called before Init.
+func (m *Manager) EnableBackfill() {
+	m.backfill = true
+}
+
+// BackfillStatuses returns the progress of the indexes built in the
+// background since the start of the node.
+//
+// This function is safe for concurrent access.
+func (m *Manager) BackfillStatuses() []BackfillStatus {
+	m.backfillLock.Lock()
+	defer m.backfillLock.Unlock()
+
+	statuses := make([]BackfillStatus, 0, len(m.backfillStatuses))
+	for _, indexer := range m.enabledIndexes {
+		if status, ok := m.backfillStatuses[indexer.Name()]; ok {
+			statuses = append(statuses, *status)
+		}
+	}
+	return statuses
+}
+
+// isBackfilling returns whether the index is built in the background and did
+// not catch up with the chain yet.
+//
+// This function is safe for concurrent access.
+func (m *Manager) isBackfilling(indexer Indexer) bool {
+	m.backfillLock.Lock()
+	defer m.backfillLock.Unlock()
+
+	status, ok := m.backfillStatuses[indexer.Name()]
+	return ok && !status.Live
+}
+
+// markBackfilling records that the index at the order is built in the
+// background.
+func (m *Manager) markBackfilling(indexer Indexer, order uint32, bestOrder uint32) {
+	m.backfillLock.Lock()
+	defer m.backfillLock.Unlock()
+
+	if m.backfillStatuses == nil {
+		m.backfillStatuses = make(map[string]*BackfillStatus)
+	}
+	status := &BackfillStatus{
+		Name:        indexer.Name(),
+		Order:       int64(order),
+		TargetOrder: int64(bestOrder),
+	}
+	if order == math.MaxUint32 {
+		status.Order = -1
+	}
+	m.backfillStatuses[indexer.Name()] = status
+}
+
+// updateBackfill updates the progress of the index built in the background.
+func (m *Manager) updateBackfill(indexer Indexer, order int64, bestOrder int64, live bool) {
+	m.backfillLock.Lock()
+	defer m.backfillLock.Unlock()
+
+	status := m.backfillStatuses[indexer.Name()]
+	status.Order = order
+	status.TargetOrder = bestOrder
+	status.Live = live
+}
+
+// Start begins building the indexes which were left behind by Init in the
+// background.
+func (m *Manager) Start() {
+	if atomic.AddInt32(&m.started, 1) != 1 {
+		return
+	}
+	for _, indexer := range m.enabledIndexes {
+		if !m.isBackfilling(indexer) {
+			continue
+		}
+		m.wg.Add(1)
+		go m.backfillHandler(indexer)
+	}
+}
+
+// Stop stops building the indexes in the background and waits for the
+// builders to exit.  The indexes are resumed on the next start.
+func (m *Manager) Stop() {
+	if atomic.AddInt32(&m.shutdown, 1) != 1 {
+		return
+	}
+	close(m.quit)
+	m.wg.Wait()
+}
+
+// backfillHandler builds the index from the stored blocks until it catches
+// up with the chain, retrying after errors.
+func (m *Manager) backfillHandler(indexer Indexer) {
+	defer m.wg.Done()
+
+	log.Info(fmt.Sprintf("Building %s in the background", indexer.Name()))
+	for {
+		err := m.backfillIndex(indexer)
+		if err == nil {
+			return
+		}
+		if err == errInterruptRequested {
+			log.Info(fmt.Sprintf("Stopped building %s", indexer.Name()))
+			return
+		}
+		log.Error(fmt.Sprintf("Failed to build %s", indexer.Name()), "error", err)
+		select {
+		case <-time.After(backfillRetryInterval):
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// backfillIndex connects the stored blocks to the index one at a time.  The
+// blocks are loaded without the chain lock held, so that the node keeps
+// processing blocks, and connected with it held once checked to still be at
+// their order.  The index goes live, being updated along with the chain, as
+// soon as it reaches the main order of the chain.
+func (m *Manager) backfillIndex(indexer Indexer) error {
+	chain := m.chain
+	progressLogger := progresslog.NewBlockProgressLogger(
+		fmt.Sprintf("Backfilled %s with", indexer.Name()), log.Root())
+	for {
+		if interruptRequested(m.quit) {
+			return errInterruptRequested
+		}
+		order, err := m.fetchIndexerOrder(indexer)
+		if err != nil {
+			return err
+		}
+		next := order + 1
+		bestOrder := int64(chain.BestSnapshot().GraphState.GetMainOrder())
+		m.updateBackfill(indexer, order, bestOrder, false)
+
+		if next > bestOrder {
+			live, err := m.maybeGoLive(indexer)
+			if err != nil {
+				return err
+			}
+			if live {
+				return nil
+			}
+			continue
+		}
+
+		var block *types.SerializedBlock
+		err = m.db.View(func(dbTx database.Tx) error {
+			block, err = chain.DBFetchBlockByOrder(dbTx, uint64(next))
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		chain.CalculateDAGDuplicateTxs(block)
+		var spentTxos []blockchain.SpentTxOut
+		if indexNeedsInputs(indexer) {
+			spentTxos, err = chain.FetchSpendJournal(block)
+			if err != nil {
+				return err
+			}
+		}
+
+		chain.ChainLock()
+		err = m.backfillBlock(indexer, block, spentTxos)
+		chain.ChainUnlock()
+		if err != nil {
+			return err
+		}
+		progressLogger.LogBlockHeight(block)
+	}
+}
+
+// backfillBlock connects the block to the index unless the order of the DAG
+// changed since it was loaded.
+//
+// This function MUST be called with the chain lock held (for writes).
+func (m *Manager) backfillBlock(indexer Indexer, block *types.SerializedBlock, stxos []blockchain.SpentTxOut) error {
+	h := m.chain.BlockDAG().GetBlockHashByOrder(uint(block.Order()))
+	if h == nil || !h.IsEqual(block.Hash()) {
+		return nil
+	}
+	order, err := m.fetchIndexerOrder(indexer)
+	if err != nil {
+		return err
+	}
+	if order+1 != int64(block.Order()) {
+		return nil
+	}
+	return m.db.Update(func(dbTx database.Tx) error {
+		return dbIndexConnectBlock(dbTx, indexer, block, stxos)
+	})
+}
+
+// maybeGoLive makes the index live when it is at the main order of the chain,
+// and returns whether it did.
+func (m *Manager) maybeGoLive(indexer Indexer) (bool, error) {
+	m.chain.ChainLock()
+	defer m.chain.ChainUnlock()
+
+	order, err := m.fetchIndexerOrder(indexer)
+	if err != nil {
+		return false, err
+	}
+	bestOrder := int64(m.chain.BestSnapshot().GraphState.GetMainOrder())
+	if order < bestOrder {
+		return false, nil
+	}
+	m.updateBackfill(indexer, order, bestOrder, true)
+	log.Info(fmt.Sprintf("%s caught up to order %d and is now live",
+		indexer.Name(), order))
+	return true, nil
+}
+
+// fetchIndexerOrder returns the order of the tip of the index, or -1 when it
+// has no entries yet.
+func (m *Manager) fetchIndexerOrder(indexer Indexer) (int64, error) {
+	var order uint32
+	err := m.db.View(func(dbTx database.Tx) error {
+		var err error
+		_, order, err = dbFetchIndexerTip(dbTx, indexer.Key())
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if order == math.MaxUint32 {
+		return -1, nil
+	}
+	return int64(order), nil
+}
@@ -10,6 +10,7 @@ package index
 import (
 	"encoding/binary"
 	"errors"
+	"github.com/Qitmeer/qitmeer/common/errcode"
 	"github.com/Qitmeer/qitmeer/core/blockchain"
 	"github.com/Qitmeer/qitmeer/core/types"
 	"github.com/Qitmeer/qitmeer/database"
@@ -75,6 +76,13 @@ func (e AssertError) Error() string {
 	return "assertion failed: " + string(e)
 }
 
+// Code returns errcode.Internal.
+//
+// This is part of the errcode.Coder interface.
+func (e AssertError) Code() errcode.Code {
+	return errcode.Internal
+}
+
 // errDeserialize signifies that a problem was encountered when deserializing
 // data.
 type errDeserialize string
@@ -100,6 +108,14 @@ type internalBucket interface {
 	Delete(key []byte) error
 }
 
+// orderUndoKey returns the key of the undo entry of the block at the order in
+// the undo bucket of an index.
+func orderUndoKey(order uint32) []byte {
+	key := make([]byte, 4)
+	byteOrder.PutUint32(key, order)
+	return key
+}
+
 // interruptRequested returns true when the provided channel has been closed.
 // This simplifies early shutdown slightly since the caller can just use an if
 // statement instead of a select.
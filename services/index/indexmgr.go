@@ -14,6 +14,7 @@ import (
 	"github.com/Qitmeer/qitmeer/log"
 	"github.com/Qitmeer/qitmeer/params"
 	"github.com/Qitmeer/qitmeer/services/common/progresslog"
+	"sync"
 )
 
 // Manager defines an index manager that manages multiple optional indexes and
@@ -23,6 +24,16 @@ type Manager struct {
 	params         *params.Params
 	db             database.DB
 	enabledIndexes []Indexer
+
+	// The indexes built in the background, see EnableBackfill.
+	backfill         bool
+	chain            *blockchain.BlockChain
+	backfillLock     sync.Mutex
+	backfillStatuses map[string]*BackfillStatus
+	started          int32
+	shutdown         int32
+	wg               sync.WaitGroup
+	quit             chan struct{}
 }
 
 // Ensure the Manager type implements the blockchain.IndexManager interface.
@@ -37,6 +48,7 @@ func NewManager(db database.DB, enabledIndexes []Indexer, params *params.Params)
 		db:             db,
 		enabledIndexes: enabledIndexes,
 		params:         params,
+		quit:           make(chan struct{}),
 	}
 }
 
@@ -53,6 +65,7 @@ func (m *Manager) Init(chain *blockchain.BlockChain, interrupt <-chan struct{})
 	if len(m.enabledIndexes) == 0 {
 		return nil
 	}
+	m.chain = chain
 
 	if interruptRequested(interrupt) {
 		return errInterruptRequested
@@ -83,6 +96,12 @@ func (m *Manager) Init(chain *blockchain.BlockChain, interrupt <-chan struct{})
 		if err := indexer.Init(); err != nil {
 			return err
 		}
+		if indexer.Name() == utxoAgeIndexName {
+			indexer.(*UtxoAgeIndex).chain = chain
+		}
+		if indexer.Name() == minerIndexName {
+			indexer.(*MinerIndex).chain = chain
+		}
 		if indexer.Name() == txIndexName {
 			indexer.(*TxIndex).chain = chain
 			if chain.CacheInvalidTx {
@@ -175,6 +194,15 @@ func (m *Manager) Init(chain *blockchain.BlockChain, interrupt <-chan struct{})
 				return err
 			}
 			orderShow := int64(order)
+			// Leave the indexes which are behind to the backfill.
+			if m.backfill && indexer.Name() != txIndexName &&
+				(order == math.MaxUint32 || order < bestOrder) {
+				m.markBackfilling(indexer, order, bestOrder)
+				indexerOrders[i] = int64(bestOrder)
+				log.Info(fmt.Sprintf("%s will be built in the background",
+					indexer.Name()))
+				continue
+			}
 			if order == math.MaxUint32 {
 				lowestOrder = -1
 				indexerOrders[i] = -1
@@ -361,6 +389,11 @@ func (m *Manager) ConnectBlock(dbTx database.Tx, block *types.SerializedBlock, s
 	// Call each of the currently active optional indexes with the block
 	// being connected so they can update accordingly.
 	for _, index := range m.enabledIndexes {
+		// The indexes built in the background connect the block
+		// themselves.
+		if m.isBackfilling(index) {
+			continue
+		}
 		err := dbIndexConnectBlock(dbTx, index, block, stxos)
 		if err != nil {
 			return err
@@ -379,6 +412,17 @@ func (m *Manager) DisconnectBlock(dbTx database.Tx, block *types.SerializedBlock
 	// Call each of the currently active optional indexes with the block
 	// being disconnected so they can update accordingly.
 	for _, index := range m.enabledIndexes {
+		// The indexes built in the background only need to disconnect
+		// the block when they already reached it.
+		if m.isBackfilling(index) {
+			tipHash, _, err := dbFetchIndexerTip(dbTx, index.Key())
+			if err != nil {
+				return err
+			}
+			if !tipHash.IsEqual(block.Hash()) {
+				continue
+			}
+		}
 		err := m.dbIndexDisconnectBlock(dbTx, index, block, stxos)
 		if err != nil {
 			return err
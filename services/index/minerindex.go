@@ -0,0 +1,337 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package index
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/params"
+)
+
+const (
+	// minerIndexName is the human-readable name for the index.
+	minerIndexName = "miner index"
+
+	// minerKeySize is the number of bytes a mined block key consumes.  It
+	// consists of the address key + 4 bytes block order.
+	minerKeySize = addrKeySize + 4
+
+	// minerEntrySize is the number of bytes a mined block entry consumes.
+	// It consists of 8 bytes subsidy + 8 bytes expected subsidy + 8 bytes
+	// fees.
+	minerEntrySize = 8 + 8 + 8
+)
+
+var (
+	// minerIndexKey is the key of the miner index and the db bucket used to
+	// house it.
+	minerIndexKey = []byte("mineridx")
+)
+
+// -----------------------------------------------------------------------------
+// The miner index maps the address paid by the subsidy output of the coinbase
+// of every valid block to the block, along with the subsidy paid, the subsidy
+// expected from the blue blocks of its parents and the fees of its
+// transactions, all of them in atoms of MEER.
+//
+// The serialized key format is:
+//
+//   <addr type><addr hash><block order>
+//
+//   Field           Type      Size
+//   addr type       uint8     1 byte
+//   addr hash       hash160   20 bytes
+//   block order     uint32    4 bytes (big endian)
+//   -----
+//   Total: 25 bytes
+//
+// The block order is big endian so that the blocks of an address are sorted
+// by order.
+//
+// The serialized value format is:
+//
+//   <subsidy><expected subsidy><fees>
+//
+//   Field              Type      Size
+//   subsidy            int64     8 bytes
+//   expected subsidy   int64     8 bytes
+//   fees               int64     8 bytes
+//   -----
+//   Total: 24 bytes
+// -----------------------------------------------------------------------------
+
+// MinedBlock holds the income of a block paid to a miner, in atoms of MEER.
+type MinedBlock struct {
+	// Order is the order of the block.
+	Order uint32
+
+	// Subsidy is the subsidy paid by the coinbase.
+	Subsidy int64
+
+	// ExpectedSubsidy is the subsidy expected from the blue blocks of the
+	// parents of the block.
+	ExpectedSubsidy int64
+
+	// Fees is the total fees of the transactions of the block.
+	Fees int64
+}
+
+// minerKey returns the key of the block order mined by the address key.
+func minerKey(addrKey [addrKeySize]byte, order uint32) []byte {
+	key := make([]byte, minerKeySize)
+	copy(key, addrKey[:])
+	binary.BigEndian.PutUint32(key[addrKeySize:], order)
+	return key
+}
+
+// serializeMinedBlock serializes the income of a mined block according to the
+// format described in detail above.
+func serializeMinedBlock(mined *MinedBlock) []byte {
+	serialized := make([]byte, minerEntrySize)
+	byteOrder.PutUint64(serialized, uint64(mined.Subsidy))
+	byteOrder.PutUint64(serialized[8:], uint64(mined.ExpectedSubsidy))
+	byteOrder.PutUint64(serialized[16:], uint64(mined.Fees))
+	return serialized
+}
+
+// deserializeMinedBlock decodes the income of the block at the order from the
+// passed serialized byte slice according to the format described in detail
+// above.
+func deserializeMinedBlock(order uint32, serialized []byte) (*MinedBlock, error) {
+	if len(serialized) < minerEntrySize {
+		return nil, errDeserialize("unexpected end of data")
+	}
+	return &MinedBlock{
+		Order:           order,
+		Subsidy:         int64(byteOrder.Uint64(serialized[0:8])),
+		ExpectedSubsidy: int64(byteOrder.Uint64(serialized[8:16])),
+		Fees:            int64(byteOrder.Uint64(serialized[16:24])),
+	}, nil
+}
+
+// MinerIndex implements an index of the blocks paying each coinbase address.
+// It allows the miners to audit their income against the subsidy expected by
+// the consensus rules and the fees of the transactions they mined.
+type MinerIndex struct {
+	db          database.DB
+	chainParams *params.Params
+	chain       *blockchain.BlockChain
+}
+
+// Ensure the MinerIndex type implements the Indexer interface.
+var _ Indexer = (*MinerIndex)(nil)
+
+// Ensure the MinerIndex type implements the NeedsInputser interface.
+var _ NeedsInputser = (*MinerIndex)(nil)
+
+// NeedsInputs signals that the index requires the referenced inputs in order
+// to compute the fees of the blocks.
+//
+// This implements the NeedsInputser interface.
+func (idx *MinerIndex) NeedsInputs() bool {
+	return true
+}
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *MinerIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *MinerIndex) Key() []byte {
+	return minerIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *MinerIndex) Name() string {
+	return minerIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the miner
+// index.
+//
+// This is part of the Indexer interface.
+func (idx *MinerIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(minerIndexKey)
+	return err
+}
+
+// coinbaseAddrKey returns the address key of the address paid by the subsidy
+// output of the coinbase of the block.  It returns false when the output does
+// not pay a supported address.
+func (idx *MinerIndex) coinbaseAddrKey(block *types.SerializedBlock) ([addrKeySize]byte, bool) {
+	coinbase := block.Transactions()[0].Transaction()
+	if len(coinbase.TxOut) <= blockchain.CoinbaseOutput_subsidy {
+		return [addrKeySize]byte{}, false
+	}
+	pkScript := coinbase.TxOut[blockchain.CoinbaseOutput_subsidy].PkScript
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript,
+		idx.chainParams)
+	if err != nil || len(addrs) == 0 {
+		return [addrKeySize]byte{}, false
+	}
+	addrKey, err := addrToKey(addrs[0], idx.chainParams)
+	if err != nil {
+		return [addrKeySize]byte{}, false
+	}
+	return addrKey, true
+}
+
+// blockFees returns the fees in MEER of the transactions of the block like
+// BlockChain.CalculateFees does, using the passed spent outputs.
+func blockFees(block *types.SerializedBlock, stxos []blockchain.SpentTxOut) int64 {
+	transactions := block.Transactions()
+	var totalOut, totalIn int64
+	for i, tx := range transactions {
+		if i == 0 || tx.Tx.IsCoinBase() || tx.IsDuplicate {
+			continue
+		}
+		for _, txOut := range tx.Transaction().TxOut {
+			if txOut.Amount.Id == types.MEERID {
+				totalOut += txOut.Amount.Value
+			}
+		}
+	}
+	for _, stxo := range stxos {
+		if int(stxo.TxIndex) >= len(transactions) ||
+			transactions[stxo.TxIndex].IsDuplicate {
+			continue
+		}
+		if stxo.Amount.Id == types.MEERID {
+			totalIn += stxo.Amount.Value + stxo.Fees.Value
+		}
+	}
+	if totalIn < totalOut {
+		return 0
+	}
+	return totalIn - totalOut
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds the block to the blocks of
+// the address paid by its coinbase unless the block is invalid.
+//
+// This is part of the Indexer interface.
+func (idx *MinerIndex) ConnectBlock(dbTx database.Tx, block *types.SerializedBlock, stxos []blockchain.SpentTxOut) error {
+	addrKey, ok := idx.coinbaseAddrKey(block)
+	if !ok {
+		return nil
+	}
+
+	bd := idx.chain.BlockDAG()
+	node := bd.GetBlock(block.Hash())
+	if node == nil {
+		return fmt.Errorf("no node %s", block.Hash())
+	}
+	if node.GetStatus().KnownInvalid() {
+		return nil
+	}
+
+	mined := &MinedBlock{
+		Fees: blockFees(block, stxos),
+	}
+	coinbase := block.Transactions()[0].Transaction()
+	subsidyOut := coinbase.TxOut[blockchain.CoinbaseOutput_subsidy]
+	if subsidyOut.Amount.Id == types.MEERID {
+		mined.Subsidy = subsidyOut.Amount.Value
+	}
+	blues := bd.GetBlues(bd.GetIdSet(block.Block().Parents))
+	mined.ExpectedSubsidy = int64(blockchain.CalcBlockWorkSubsidy(
+		idx.chain.FetchSubsidyCache(), int64(blues), idx.chainParams))
+
+	bucket := dbTx.Metadata().Bucket(minerIndexKey)
+	return bucket.Put(minerKey(addrKey, uint32(block.Order())),
+		serializeMinedBlock(mined))
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the block from the
+// blocks of the address paid by its coinbase.
+//
+// This is part of the Indexer interface.
+func (idx *MinerIndex) DisconnectBlock(dbTx database.Tx, block *types.SerializedBlock, stxos []blockchain.SpentTxOut) error {
+	addrKey, ok := idx.coinbaseAddrKey(block)
+	if !ok {
+		return nil
+	}
+	bucket := dbTx.Metadata().Bucket(minerIndexKey)
+	return bucket.Delete(minerKey(addrKey, uint32(block.Order())))
+}
+
+// MinedBlocks returns the blocks paying the passed address between the start
+// and end orders, both included, sorted by order.
+//
+// This function is safe for concurrent access.
+func (idx *MinerIndex) MinedBlocks(addr types.Address, start, end uint32) ([]MinedBlock, error) {
+	addrKey, err := addrToKey(addr, idx.chainParams)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []MinedBlock
+	err = idx.db.View(func(dbTx database.Tx) error {
+		cursor := dbTx.Metadata().Bucket(minerIndexKey).Cursor()
+		for ok := cursor.Seek(minerKey(addrKey, start)); ok; ok = cursor.Next() {
+			key := cursor.Key()
+			if len(key) != minerKeySize ||
+				!bytes.Equal(key[:addrKeySize], addrKey[:]) {
+				break
+			}
+			order := binary.BigEndian.Uint32(key[addrKeySize:])
+			if order > end {
+				break
+			}
+			mined, err := deserializeMinedBlock(order, cursor.Value())
+			if err != nil {
+				return database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("failed to "+
+						"deserialize mined block for key "+
+						"%x: %v", key, err),
+				}
+			}
+			blocks = append(blocks, *mined)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// NewMinerIndex returns a new instance of an indexer that is used to create a
+// mapping of the coinbase addresses to the blocks paying them.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewMinerIndex(db database.DB, chainParams *params.Params) *MinerIndex {
+	return &MinerIndex{
+		db:          db,
+		chainParams: chainParams,
+	}
+}
+
+// DropMinerIndex drops the miner index from the provided database if it
+// exists.
+func DropMinerIndex(db database.DB, interrupt <-chan struct{}) error {
+	return dropIndex(db, minerIndexKey, minerIndexName, interrupt)
+}
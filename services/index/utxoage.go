@@ -0,0 +1,410 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package index
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+)
+
+const (
+	// utxoAgeIndexName is the human-readable name for the index.
+	utxoAgeIndexName = "utxo age index"
+
+	// UtxoAgeBucketOrders is the number of block orders covered by a bucket
+	// of the utxo age distribution.
+	UtxoAgeBucketOrders = 1000
+
+	// utxoAgeKeySize is the number of bytes a bucket key consumes.  It
+	// consists of 2 bytes coin id + 4 bytes bucket number.
+	utxoAgeKeySize = 2 + 4
+
+	// utxoAgeEntrySize is the number of bytes a bucket entry consumes.  It
+	// consists of 8 bytes output count + 8 bytes amount.
+	utxoAgeEntrySize = 8 + 8
+
+	// utxoAgeUndoSize is the number of bytes an undo entry of a block
+	// consumes.  It consists of the bucket key + 8 bytes count change + 8
+	// bytes amount change.
+	utxoAgeUndoSize = utxoAgeKeySize + 8 + 8
+)
+
+var (
+	// utxoAgeIndexKey is the key of the utxo age index and the db bucket
+	// used to house it.
+	utxoAgeIndexKey = []byte("utxoageidx")
+
+	// utxoAgeUndoBucketName is the name of the bucket nested in the index
+	// bucket which houses the undo entries of the blocks.
+	utxoAgeUndoBucketName = []byte("undo")
+)
+
+// -----------------------------------------------------------------------------
+// The utxo age index keeps the distribution of the unspent transaction outputs
+// by the order of the block creating them.  The orders are grouped in buckets
+// of UtxoAgeBucketOrders orders, each one holding the number and the amount of
+// the unspent outputs of a coin created in its orders.  The buckets are updated
+// with the outputs created and spent by every connected block.
+//
+// The order of the block creating a spent output is the internal block id of
+// the transaction index minus one, hence this index requires the transaction
+// index.
+//
+// The serialized key format is:
+//
+//   <coin id><bucket>
+//
+//   Field           Type      Size
+//   coin id         uint16    2 bytes
+//   bucket          uint32    4 bytes
+//   -----
+//   Total: 6 bytes
+//
+// The serialized value format is:
+//
+//   <count><amount>
+//
+//   Field           Type      Size
+//   count           uint64    8 bytes
+//   amount          int64     8 bytes
+//   -----
+//   Total: 16 bytes
+//
+// The changes made by each block are kept in the nested undo bucket, keyed by
+// the order of the block, so that disconnecting it reverts exactly what
+// connecting it did:
+//
+//   [<coin id><bucket><count change><amount change>,...]
+//
+//   Field           Type      Size
+//   coin id         uint16    2 bytes
+//   bucket          uint32    4 bytes
+//   count change    int64     8 bytes
+//   amount change   int64     8 bytes
+//   -----
+//   Total: 22 bytes per bucket changed by the block
+// -----------------------------------------------------------------------------
+
+// UtxoAgeBucket holds the unspent outputs of a coin created in a range of
+// UtxoAgeBucketOrders block orders.
+type UtxoAgeBucket struct {
+	// StartOrder is the first order of the bucket.
+	StartOrder uint32
+
+	// Count is the number of unspent outputs.
+	Count uint64
+
+	// Amount is the total amount of the unspent outputs.
+	Amount int64
+}
+
+// utxoAgeKey identifies a bucket of the distribution of a coin.
+type utxoAgeKey struct {
+	coinId types.CoinID
+	bucket uint32
+}
+
+// utxoAgeChange is the change of a bucket made by a block.
+type utxoAgeChange struct {
+	count  int64
+	amount int64
+}
+
+// serialize returns the serialized bucket key according to the format
+// described in detail above.
+func (k utxoAgeKey) serialize() []byte {
+	serialized := make([]byte, utxoAgeKeySize)
+	byteOrder.PutUint16(serialized, uint16(k.coinId))
+	byteOrder.PutUint32(serialized[2:], k.bucket)
+	return serialized
+}
+
+// deserializeUtxoAgeKey decodes a bucket key according to the format described
+// in detail above.
+func deserializeUtxoAgeKey(serialized []byte) utxoAgeKey {
+	return utxoAgeKey{
+		coinId: types.CoinID(byteOrder.Uint16(serialized[0:2])),
+		bucket: byteOrder.Uint32(serialized[2:6]),
+	}
+}
+
+// dbApplyUtxoAgeChange adds the change to the bucket entry and removes the
+// entry once it holds no output.
+func dbApplyUtxoAgeChange(bucket internalBucket, key utxoAgeKey, change utxoAgeChange) error {
+	serializedKey := key.serialize()
+	var count, amount int64
+	serialized := bucket.Get(serializedKey)
+	if serialized != nil {
+		if len(serialized) < utxoAgeEntrySize {
+			return database.Error{
+				ErrorCode: database.ErrCorruption,
+				Description: fmt.Sprintf("corrupt utxo age entry "+
+					"for key %x", serializedKey),
+			}
+		}
+		count = int64(byteOrder.Uint64(serialized[0:8]))
+		amount = int64(byteOrder.Uint64(serialized[8:16]))
+	}
+
+	count += change.count
+	amount += change.amount
+	if count < 0 {
+		return AssertError(fmt.Sprintf("utxo age entry for key %x "+
+			"holds %d outputs", serializedKey, count))
+	}
+	if count == 0 {
+		return bucket.Delete(serializedKey)
+	}
+
+	serialized = make([]byte, utxoAgeEntrySize)
+	byteOrder.PutUint64(serialized, uint64(count))
+	byteOrder.PutUint64(serialized[8:], uint64(amount))
+	return bucket.Put(serializedKey, serialized)
+}
+
+// UtxoAgeIndex implements an index of the distribution of the unspent
+// transaction outputs by the order of the block creating them, such as for
+// the charts of the age of the coins.
+type UtxoAgeIndex struct {
+	db    database.DB
+	chain *blockchain.BlockChain
+}
+
+// Ensure the UtxoAgeIndex type implements the Indexer interface.
+var _ Indexer = (*UtxoAgeIndex)(nil)
+
+// Ensure the UtxoAgeIndex type implements the NeedsInputser interface.
+var _ NeedsInputser = (*UtxoAgeIndex)(nil)
+
+// NeedsInputs signals that the index requires the referenced inputs in order
+// to properly create the index.
+//
+// This implements the NeedsInputser interface.
+func (idx *UtxoAgeIndex) NeedsInputs() bool {
+	return true
+}
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *UtxoAgeIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *UtxoAgeIndex) Key() []byte {
+	return utxoAgeIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *UtxoAgeIndex) Name() string {
+	return utxoAgeIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the utxo age
+// index and the nested bucket for the undo entries.
+//
+// This is part of the Indexer interface.
+func (idx *UtxoAgeIndex) Create(dbTx database.Tx) error {
+	bucket, err := dbTx.Metadata().CreateBucket(utxoAgeIndexKey)
+	if err != nil {
+		return err
+	}
+	_, err = bucket.CreateBucket(utxoAgeUndoBucketName)
+	return err
+}
+
+// blockChanges returns the changes of the buckets made by the outputs created
+// and spent by the block.  The block of a spent output is looked up in the
+// block id index of the transaction index.
+func (idx *UtxoAgeIndex) blockChanges(dbTx database.Tx, block *types.SerializedBlock, stxos []blockchain.SpentTxOut) (map[utxoAgeKey]*utxoAgeChange, error) {
+	changes := make(map[utxoAgeKey]*utxoAgeChange)
+	addChange := func(order uint32, amount types.Amount, count int64) {
+		key := utxoAgeKey{coinId: amount.Id, bucket: order / UtxoAgeBucketOrders}
+		change := changes[key]
+		if change == nil {
+			change = &utxoAgeChange{}
+			changes[key] = change
+		}
+		change.count += count
+		change.amount += count * amount.Value
+	}
+
+	// The outputs of an invalid block are not added to the utxo set.
+	node := idx.chain.BlockDAG().GetBlock(block.Hash())
+	if node == nil {
+		return nil, fmt.Errorf("no node %s", block.Hash())
+	}
+	if node.GetStatus().KnownInvalid() {
+		return changes, nil
+	}
+
+	order := uint32(block.Order())
+	for _, tx := range block.Transactions() {
+		if tx.IsDuplicate {
+			continue
+		}
+		for _, txOut := range tx.Transaction().TxOut {
+			if txscript.IsUnspendable(txOut.PkScript) {
+				continue
+			}
+			addChange(order, txOut.Amount, 1)
+		}
+	}
+
+	for i := range stxos {
+		blockID, err := dbFetchBlockIDByHash(dbTx, &stxos[i].BlockHash)
+		if err != nil {
+			return nil, err
+		}
+		addChange(blockID-1, stxos[i].Amount, -1)
+	}
+	return changes, nil
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds the outputs created by the
+// block to its bucket, removes the outputs it spends from their buckets and
+// saves the changes in the undo entry of the block.
+//
+// This is part of the Indexer interface.
+func (idx *UtxoAgeIndex) ConnectBlock(dbTx database.Tx, block *types.SerializedBlock, stxos []blockchain.SpentTxOut) error {
+	changes, err := idx.blockChanges(dbTx, block, stxos)
+	if err != nil {
+		return err
+	}
+
+	bucket := dbTx.Metadata().Bucket(utxoAgeIndexKey)
+	undo := make([]byte, 0, len(changes)*utxoAgeUndoSize)
+	for key, change := range changes {
+		if change.count == 0 && change.amount == 0 {
+			continue
+		}
+		err := dbApplyUtxoAgeChange(bucket, key, *change)
+		if err != nil {
+			return err
+		}
+
+		var undoEntry [utxoAgeUndoSize]byte
+		copy(undoEntry[:], key.serialize())
+		byteOrder.PutUint64(undoEntry[utxoAgeKeySize:], uint64(change.count))
+		byteOrder.PutUint64(undoEntry[utxoAgeKeySize+8:], uint64(change.amount))
+		undo = append(undo, undoEntry[:]...)
+	}
+	if len(undo) == 0 {
+		return nil
+	}
+
+	undoBucket := bucket.Bucket(utxoAgeUndoBucketName)
+	return undoBucket.Put(orderUndoKey(uint32(block.Order())), undo)
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer reverts the changes saved
+// in the undo entry of the block.
+//
+// This is part of the Indexer interface.
+func (idx *UtxoAgeIndex) DisconnectBlock(dbTx database.Tx, block *types.SerializedBlock, stxos []blockchain.SpentTxOut) error {
+	order := uint32(block.Order())
+	bucket := dbTx.Metadata().Bucket(utxoAgeIndexKey)
+	undoBucket := bucket.Bucket(utxoAgeUndoBucketName)
+	undoKey := orderUndoKey(order)
+	undo := undoBucket.Get(undoKey)
+	if undo == nil {
+		return nil
+	}
+	if len(undo)%utxoAgeUndoSize != 0 {
+		return database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt utxo age undo entry "+
+				"for order %d", order),
+		}
+	}
+
+	for offset := 0; offset < len(undo); offset += utxoAgeUndoSize {
+		key := deserializeUtxoAgeKey(undo[offset:])
+		change := utxoAgeChange{
+			count:  -int64(byteOrder.Uint64(undo[offset+utxoAgeKeySize:])),
+			amount: -int64(byteOrder.Uint64(undo[offset+utxoAgeKeySize+8:])),
+		}
+		err := dbApplyUtxoAgeChange(bucket, key, change)
+		if err != nil {
+			return err
+		}
+	}
+
+	return undoBucket.Delete(undoKey)
+}
+
+// Distribution returns the buckets of the unspent outputs of the coin which
+// hold outputs, sorted by order.
+//
+// This function is safe for concurrent access.
+func (idx *UtxoAgeIndex) Distribution(coinId types.CoinID) ([]UtxoAgeBucket, error) {
+	var buckets []UtxoAgeBucket
+	err := idx.db.View(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(utxoAgeIndexKey)
+		return bucket.ForEach(func(k, v []byte) error {
+			// Skip the nested undo bucket.
+			if len(k) != utxoAgeKeySize || v == nil {
+				return nil
+			}
+			key := deserializeUtxoAgeKey(k)
+			if key.coinId != coinId {
+				return nil
+			}
+			if len(v) < utxoAgeEntrySize {
+				return database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("corrupt utxo age "+
+						"entry for key %x", k),
+				}
+			}
+			buckets = append(buckets, UtxoAgeBucket{
+				StartOrder: key.bucket * UtxoAgeBucketOrders,
+				Count:      byteOrder.Uint64(v[0:8]),
+				Amount:     int64(byteOrder.Uint64(v[8:16])),
+			})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].StartOrder < buckets[j].StartOrder
+	})
+	return buckets, nil
+}
+
+// NewUtxoAgeIndex returns a new instance of an indexer that is used to keep
+// the distribution of the unspent transaction outputs by the order of the
+// block creating them.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewUtxoAgeIndex(db database.DB) *UtxoAgeIndex {
+	return &UtxoAgeIndex{db: db}
+}
+
+// DropUtxoAgeIndex drops the utxo age index from the provided database if it
+// exists.
+func DropUtxoAgeIndex(db database.DB, interrupt <-chan struct{}) error {
+	return dropIndex(db, utxoAgeIndexKey, utxoAgeIndexName, interrupt)
+}
@@ -1,10 +1,13 @@
 package mempool
 
 import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/json"
 	"github.com/Qitmeer/qitmeer/log"
 	"github.com/Qitmeer/qitmeer/rpc"
 	"github.com/Qitmeer/qitmeer/rpc/client/cmds"
 	"sort"
+	"time"
 )
 
 func (t *TxPool) API() rpc.API {
@@ -15,6 +18,27 @@ func (t *TxPool) API() rpc.API {
 	}
 }
 
+// FeeHistogramResult returns the fee histogram of the pool as sent to the RPC
+// clients.
+//
+// This function is safe for concurrent access.
+func (t *TxPool) FeeHistogramResult() *json.FeeHistogramResult {
+	buckets := t.FeeHistogram()
+	result := &json.FeeHistogramResult{
+		Buckets: make([]json.FeeHistogramBucketResult, 0, len(buckets)),
+	}
+	for _, bucket := range buckets {
+		result.Size += bucket.Count
+		result.VSize += bucket.Size
+		result.Buckets = append(result.Buckets, json.FeeHistogramBucketResult{
+			FeeRate: bucket.FeeRate,
+			Count:   bucket.Count,
+			VSize:   bucket.Size,
+		})
+	}
+	return result
+}
+
 type PublicMempoolAPI struct {
 	txPool *TxPool
 }
@@ -36,3 +60,58 @@ func (api *PublicMempoolAPI) GetMempool(txType *string, verbose bool) (interface
 	sort.Strings(hashStrings)
 	return hashStrings, nil
 }
+
+// GetMempoolStats returns the number of transactions accepted, orphaned and
+// rejected by reason since the node was started, along with the histogram of
+// the time it took to process them.
+func (api *PublicMempoolAPI) GetMempoolStats() (interface{}, error) {
+	stats := api.txPool.Stats()
+	result := &json.MempoolStatsResult{
+		Size:     len(api.txPool.TxDescs()),
+		Accepted: stats.Accepted,
+		Orphaned: stats.Orphaned,
+		Rejected: make(map[string]uint64, len(stats.Rejected)),
+		Latency:  make([]json.LatencyBucketResult, 0, len(stats.Latency)),
+	}
+	for reason, count := range stats.Rejected {
+		result.Rejected[reason.String()] = count
+	}
+	for _, bucket := range stats.Latency {
+		result.Latency = append(result.Latency, json.LatencyBucketResult{
+			Bound: int64(bucket.Bound / time.Millisecond),
+			Count: bucket.Count,
+		})
+	}
+	return result, nil
+}
+
+// GetFeeHistogram returns the histogram of the fee rates of the mempool
+// transactions weighted by their size, from the highest fee rate to the lowest
+// one.
+func (api *PublicMempoolAPI) GetFeeHistogram() (interface{}, error) {
+	return api.txPool.FeeHistogramResult(), nil
+}
+
+// GetDoubleSpendProofs returns the double spend proofs of the outputs spent by
+// the mempool transactions.  When txID is set, only the proofs involving that
+// transaction are returned.
+func (api *PublicMempoolAPI) GetDoubleSpendProofs(txID *string) (interface{}, error) {
+	var txHash *hash.Hash
+	if txID != nil {
+		h, err := hash.NewHashFromStr(*txID)
+		if err != nil {
+			return nil, rpc.RpcDecodeHexError(*txID)
+		}
+		txHash = h
+	}
+	proofs := api.txPool.DoubleSpendProofs(txHash)
+	result := make([]*json.DoubleSpendProofResult, 0, len(proofs))
+	for _, proof := range proofs {
+		r, err := rpc.DoubleSpendProofResult(proof)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
@@ -11,18 +11,16 @@ import (
 	"github.com/Qitmeer/qitmeer/core/message"
 	"github.com/Qitmeer/qitmeer/core/types"
 	"github.com/Qitmeer/qitmeer/engine/txscript"
-	"time"
 )
 
 // checkTransactionStandard performs a series of checks on a transaction to
 // ensure it is a "standard" transaction.  A standard transaction is one that
 // conforms to several additional limiting cases over what is considered a
-// "sane" transaction such as having a version in the supported range, being
-// finalized, conforming to more stringent size constraints, having scripts
-// of recognized forms, and not containing "dust" outputs (those that are
-// so small it costs more to process them than they are worth).
-func checkTransactionStandard(tx *types.Tx, height uint64,
-	medianTime time.Time, minRelayTxFee types.Amount,
+// "sane" transaction such as having a version in the supported range,
+// conforming to more stringent size constraints, having scripts of recognized
+// forms, and not containing "dust" outputs (those that are so small it costs
+// more to process them than they are worth).
+func checkTransactionStandard(tx *types.Tx, minRelayTxFee types.Amount,
 	maxTxVersion uint16) error {
 
 	// The transaction must be a currently supported version and serialize
@@ -42,22 +40,14 @@ func checkTransactionStandard(tx *types.Tx, height uint64,
 		return txRuleError(message.RejectNonstandard, str)
 	}
 
-	// The transaction must be finalized to be standard and therefore
-	// considered for inclusion in a block.
-	// TODO fix type conversion
-	if !blockchain.IsFinalizedTransaction(tx, height, medianTime) {
-		return txRuleError(message.RejectNonstandard,
-			"transaction is not finalized")
-	}
-
 	// Since extremely large transactions with a lot of inputs can cost
 	// almost as much to process as the sender fees, limit the maximum
 	// size of a transaction.  This also helps mitigate CPU exhaustion
 	// attacks.
 	serializedLen := msgTx.SerializeSize()
-	if serializedLen > maxStandardTxSize {
+	if serializedLen > MaxStandardTxSize {
 		str := fmt.Sprintf("transaction size of %v is larger than max "+
-			"allowed size of %v", serializedLen, maxStandardTxSize)
+			"allowed size of %v", serializedLen, MaxStandardTxSize)
 		return txRuleError(message.RejectNonstandard, str)
 	}
 
@@ -193,6 +183,13 @@ func checkPkScriptStandard(pkScript []byte,
 	return nil
 }
 
+// IsDust returns whether or not the passed transaction output amount is
+// considered dust by the memory pool, so that the callers building
+// transactions can avoid such outputs.  See isDust for details.
+func IsDust(txOut *types.TxOutput, minRelayTxFee types.Amount) bool {
+	return isDust(txOut, minRelayTxFee)
+}
+
 // isDust returns whether or not the passed transaction output amount is
 // considered dust or not based on the passed minimum transaction relay fee.
 // Dust is defined in terms of the minimum transaction relay fee.  In
@@ -285,7 +282,8 @@ func (mp *TxPool) checkPoolDoubleSpend(tx *types.Tx) error {
 		if txR, exists := mp.outpoints[txIn.PreviousOut]; exists {
 			str := fmt.Sprintf("transaction %v in the pool "+
 				"already spends the same coins", txR.Hash())
-			return txRuleError(message.RejectDuplicate, str)
+			return txRuleErrorReason(message.RejectDuplicate,
+				ReasonDoubleSpend, str)
 		}
 	}
 	return nil
@@ -79,4 +79,9 @@ type Config struct {
 
 	// block chain
 	BC *blockchain.BlockChain
+
+	// OnDoubleSpendProof defines the optional function which is called
+	// with the double spend proofs generated by the pool.  It is called
+	// with the pool lock held and must not call back into the pool.
+	OnDoubleSpendProof func(proof *types.DoubleSpendProof)
 }
@@ -0,0 +1,152 @@
+// Copyright (c) 2017-2018 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/log"
+)
+
+// maxDoubleSpendProofs is the maximum number of double spend proofs kept by
+// the pool.
+const maxDoubleSpendProofs = 1000
+
+// recordDoubleSpend generates a double spend proof for every output spent by
+// the passed transaction which is already spent by a transaction in the pool.
+// The proofs are only kept when both transactions are properly signed.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) recordDoubleSpend(tx *types.Tx) {
+	for _, txIn := range tx.Tx.TxIn {
+		poolTx, exists := mp.outpoints[txIn.PreviousOut]
+		if !exists {
+			continue
+		}
+		if _, exists := mp.dsProofs[txIn.PreviousOut]; exists {
+			continue
+		}
+		proof := types.NewDoubleSpendProof(&txIn.PreviousOut, poolTx.Tx, tx.Tx)
+		err := mp.verifyDoubleSpendProof(proof)
+		if err != nil {
+			log.Trace("Not generating double spend proof", "tx", tx.Hash(),
+				"err", err)
+			continue
+		}
+		if !mp.addDoubleSpendProof(proof) {
+			continue
+		}
+		log.Info("Generated double spend proof", "outpoint",
+			txIn.PreviousOut, "tx", poolTx.Hash(), "doublespend", tx.Hash())
+		if mp.cfg.OnDoubleSpendProof != nil {
+			mp.cfg.OnDoubleSpendProof(proof)
+		}
+	}
+}
+
+// verifyDoubleSpendProof checks that both transactions of the proof are
+// different and carry a valid signature for the spent output.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) verifyDoubleSpendProof(proof *types.DoubleSpendProof) error {
+	if proof.First.TxHash() == proof.Second.TxHash() {
+		return fmt.Errorf("double spend proof of %v holds the same "+
+			"transaction twice", proof.OutPoint)
+	}
+	if proof.First.IsCoinBase() || proof.Second.IsCoinBase() {
+		return fmt.Errorf("double spend proof of %v holds a coinbase",
+			proof.OutPoint)
+	}
+
+	utxoView, err := mp.fetchInputUtxos(types.NewTx(proof.First))
+	if err != nil {
+		return err
+	}
+	entry := utxoView.LookupEntry(proof.OutPoint)
+	if entry == nil {
+		return fmt.Errorf("output %v of double spend proof is unknown",
+			proof.OutPoint)
+	}
+	flags, err := mp.cfg.Policy.StandardVerifyFlags()
+	if err != nil {
+		return err
+	}
+	for _, tx := range []*types.Transaction{proof.First, proof.Second} {
+		idx, err := proof.SpendingInput(tx)
+		if err != nil {
+			return err
+		}
+		vm, err := txscript.NewEngine(entry.PkScript(), tx, idx, flags,
+			txscript.DefaultScriptVersion, mp.cfg.SigCache)
+		if err != nil {
+			return err
+		}
+		err = vm.Execute()
+		if err != nil {
+			return fmt.Errorf("failed to validate input %s:%d of double "+
+				"spend proof: %v", tx.TxHash(), idx, err)
+		}
+	}
+	return nil
+}
+
+// addDoubleSpendProof keeps the proof.  It returns false when the proof was
+// not added because the pool already holds too many proofs.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) addDoubleSpendProof(proof *types.DoubleSpendProof) bool {
+	if len(mp.dsProofs) >= maxDoubleSpendProofs {
+		log.Debug("Dropping double spend proof, too many proofs",
+			"outpoint", proof.OutPoint)
+		return false
+	}
+	mp.dsProofs[proof.OutPoint] = proof
+	return true
+}
+
+// ProcessDoubleSpendProof verifies a double spend proof received from a peer
+// and keeps it when it concerns an output spent by a transaction in the pool.
+// It returns whether the proof is new and should be relayed, which is left to
+// the caller.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) ProcessDoubleSpendProof(proof *types.DoubleSpendProof) (bool, error) {
+	mp.mtx.Lock()
+	defer mp.mtx.Unlock()
+
+	if _, exists := mp.dsProofs[proof.OutPoint]; exists {
+		return false, nil
+	}
+	if _, exists := mp.outpoints[proof.OutPoint]; !exists {
+		return false, nil
+	}
+	err := mp.verifyDoubleSpendProof(proof)
+	if err != nil {
+		return false, err
+	}
+	return mp.addDoubleSpendProof(proof), nil
+}
+
+// DoubleSpendProofs returns the double spend proofs of the outputs spent by
+// the transactions in the pool.  When txHash is not nil, only the proofs
+// involving that transaction are returned.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) DoubleSpendProofs(txHash *hash.Hash) []*types.DoubleSpendProof {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	proofs := make([]*types.DoubleSpendProof, 0, len(mp.dsProofs))
+	for _, proof := range mp.dsProofs {
+		if txHash != nil && proof.First.TxHash() != *txHash &&
+			proof.Second.TxHash() != *txHash {
+			continue
+		}
+		proofs = append(proofs, proof)
+	}
+	return proofs
+}
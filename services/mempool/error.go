@@ -7,6 +7,7 @@
 package mempool
 
 import (
+	"github.com/Qitmeer/qitmeer/common/errcode"
 	"github.com/Qitmeer/qitmeer/core/blockchain"
 	"github.com/Qitmeer/qitmeer/core/message"
 )
@@ -29,6 +30,12 @@ func (e RuleError) Error() string {
 	return e.Err.Error()
 }
 
+// Unwrap returns the underlying TxRuleError or blockchain.RuleError, so that
+// errcode.CodeOf returns its code.
+func (e RuleError) Unwrap() error {
+	return e.Err
+}
+
 // TxRuleError identifies a rule violation.  It is used to indicate that
 // processing of a transaction failed due to one of the many validation
 // rules.  The caller can use type assertions to determine if a failure was
@@ -37,6 +44,7 @@ func (e RuleError) Error() string {
 type TxRuleError struct {
 	RejectCode  message.RejectCode // The code to send with reject messages
 	Description string             // Human readable description of the issue
+	Reason      RejectReason       // The reason used by the pool statistics
 }
 
 // Error satisfies the error interface and prints human-readable errors.
@@ -44,6 +52,17 @@ func (e TxRuleError) Error() string {
 	return e.Description
 }
 
+// Code returns errcode.Duplicate for the transactions which already exist,
+// and errcode.RuleViolation otherwise.
+//
+// This is part of the errcode.Coder interface.
+func (e TxRuleError) Code() errcode.Code {
+	if e.RejectCode == message.RejectDuplicate {
+		return errcode.Duplicate
+	}
+	return errcode.RuleViolation
+}
+
 // txRuleError creates an underlying TxRuleError with the given a set of
 // arguments and returns a RuleError that encapsulates it.
 func txRuleError(c message.RejectCode, desc string) RuleError {
@@ -52,6 +71,14 @@ func txRuleError(c message.RejectCode, desc string) RuleError {
 	}
 }
 
+// txRuleErrorReason creates an underlying TxRuleError with a reason which is
+// not implied by the reject code and returns a RuleError that encapsulates it.
+func txRuleErrorReason(c message.RejectCode, reason RejectReason, desc string) RuleError {
+	return RuleError{
+		Err: TxRuleError{RejectCode: c, Description: desc, Reason: reason},
+	}
+}
+
 // chainRuleError returns a RuleError that encapsulates the given
 // blockchain.RuleError.
 func chainRuleError(chainErr blockchain.RuleError) RuleError {
@@ -0,0 +1,93 @@
+// Copyright (c) 2017-2018 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+// feeRateBuckets are the lower bounds in atoms per byte of the buckets of the
+// fee histogram.  The last bucket counts everything above the last bound.
+var feeRateBuckets = [...]int64{
+	0, 1, 2, 3, 4, 5, 6, 8, 10, 12, 15, 20, 30, 40, 50, 60, 70, 80, 90, 100,
+	125, 150, 175, 200, 250, 300, 350, 400, 500, 600, 700, 800, 900, 1000,
+	1200, 1400, 1700, 2000,
+}
+
+// FeeHistogramBucket is a bucket of the fee histogram of the pool.
+type FeeHistogramBucket struct {
+	// FeeRate is the lower bound in atoms per byte of the fee rates of the
+	// transactions of the bucket.
+	FeeRate int64
+
+	// Count is the number of transactions of the bucket.
+	Count int
+
+	// Size is the total serialized size of the transactions of the bucket.
+	Size int64
+}
+
+// feeHistogram is the histogram of the fee rates of the transactions in the
+// pool, weighted by their size.  It is updated as transactions are added to
+// and removed from the pool so it never walks the pool.
+type feeHistogram struct {
+	counts [len(feeRateBuckets)]int
+	sizes  [len(feeRateBuckets)]int64
+}
+
+// bucket returns the index of the bucket of the fee rate.
+func (h *feeHistogram) bucket(feeRate int64) int {
+	for i := len(feeRateBuckets) - 1; i > 0; i-- {
+		if feeRate >= feeRateBuckets[i] {
+			return i
+		}
+	}
+	return 0
+}
+
+// add counts a transaction of the passed fee and size.
+func (h *feeHistogram) add(fee int64, size int64) {
+	if size <= 0 {
+		return
+	}
+	i := h.bucket(fee / size)
+	h.counts[i]++
+	h.sizes[i] += size
+}
+
+// remove uncounts a transaction of the passed fee and size.
+func (h *feeHistogram) remove(fee int64, size int64) {
+	if size <= 0 {
+		return
+	}
+	i := h.bucket(fee / size)
+	h.counts[i]--
+	h.sizes[i] -= size
+}
+
+// buckets returns the non-empty buckets from the highest fee rate to the
+// lowest one, which is the order in which miners pick the transactions.
+func (h *feeHistogram) buckets() []FeeHistogramBucket {
+	result := []FeeHistogramBucket{}
+	for i := len(feeRateBuckets) - 1; i >= 0; i-- {
+		if h.counts[i] == 0 {
+			continue
+		}
+		result = append(result, FeeHistogramBucket{
+			FeeRate: feeRateBuckets[i],
+			Count:   h.counts[i],
+			Size:    h.sizes[i],
+		})
+	}
+	return result
+}
+
+// FeeHistogram returns the non-empty buckets of the histogram of the fee rates
+// of the transactions in the pool, from the highest fee rate to the lowest
+// one.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) FeeHistogram() []FeeHistogramBucket {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	return mp.feeHistogram.buckets()
+}
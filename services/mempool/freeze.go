@@ -0,0 +1,283 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/address"
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/protocol"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/log"
+	"github.com/Qitmeer/qitmeer/params"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FreezePluginName is the name of the acceptance plugin of the freeze list.
+const FreezePluginName = "freeze"
+
+// frozenCoinsBucketName is the name of the db bucket used to house the
+// freeze list.
+var frozenCoinsBucketName = []byte("frozencoins")
+
+// -----------------------------------------------------------------------------
+// The freeze list is a bucket of the frozen outpoints and addresses.
+//
+// The key is the target of the entry, which is either an outpoint formatted as
+// <txid>:<index> or an encoded address.  The value is the JSON encoding of the
+// entry.
+// -----------------------------------------------------------------------------
+
+// FrozenCoin is an entry of the freeze list.
+type FrozenCoin struct {
+	// Target is the frozen outpoint, formatted as <txid>:<index>, or the
+	// frozen address.
+	Target string `json:"target"`
+
+	// Reason is the free-form reason given by the operator.
+	Reason string `json:"reason,omitempty"`
+
+	// Time is the time the target was frozen, in seconds.
+	Time int64 `json:"time"`
+}
+
+// FreezeList is the policy of a permissioned network blocking the spending of
+// the configured outpoints and of the outputs paying to the configured
+// addresses.  The transactions spending them are neither accepted into the
+// memory pool nor selected for the block templates, but the blocks spending
+// them stay valid, so the list never splits the chain.
+//
+// The list is stored in the database and can only be enabled on a private
+// network.
+type FreezeList struct {
+	mtx    sync.RWMutex
+	db     database.DB
+	params *params.Params
+	coins  map[string]*FrozenCoin
+}
+
+// NewFreezeList returns the freeze list stored in the database, creating it
+// when it does not exist.  An error is returned on a public network.
+func NewFreezeList(db database.DB, param *params.Params) (*FreezeList, error) {
+	if param.Net != protocol.PrivNet {
+		return nil, fmt.Errorf("the freeze list can only be enabled on a "+
+			"private network, not on %s", param.Name)
+	}
+	fl := &FreezeList{
+		db:     db,
+		params: param,
+		coins:  make(map[string]*FrozenCoin),
+	}
+	err := db.Update(func(dbTx database.Tx) error {
+		bucket, err := dbTx.Metadata().CreateBucketIfNotExists(frozenCoinsBucketName)
+		if err != nil {
+			return err
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var coin FrozenCoin
+			err := json.Unmarshal(v, &coin)
+			if err != nil {
+				return database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("failed to "+
+						"deserialize frozen coin %s: %v", k, err),
+				}
+			}
+			fl.coins[string(k)] = &coin
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Freeze list is enabled", "entries", len(fl.coins))
+	return fl, nil
+}
+
+// normalizeTarget returns the canonical form of an outpoint formatted as
+// <txid>:<index> or of an address of the network.
+func (fl *FreezeList) normalizeTarget(target string) (string, error) {
+	if i := strings.LastIndex(target, ":"); i >= 0 {
+		txHash, err := hash.NewHashFromStr(target[:i])
+		if err != nil {
+			return "", fmt.Errorf("invalid outpoint %s: %v", target, err)
+		}
+		index, err := strconv.ParseUint(target[i+1:], 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("invalid outpoint %s: %v", target, err)
+		}
+		return outPointTarget(types.NewOutPoint(txHash, uint32(index))), nil
+	}
+	addr, err := address.DecodeAddress(target)
+	if err != nil {
+		return "", fmt.Errorf("invalid address %s: %v", target, err)
+	}
+	if !address.IsForNetwork(addr, fl.params) {
+		return "", fmt.Errorf("address %s is not for %s", target,
+			fl.params.Name)
+	}
+	return addr.Encode(), nil
+}
+
+// outPointTarget returns the target of the entry freezing the outpoint.
+func outPointTarget(op *types.TxOutPoint) string {
+	return fmt.Sprintf("%s:%d", op.Hash, op.OutIndex)
+}
+
+// Freeze adds the outpoints, formatted as <txid>:<index>, and the addresses
+// to the freeze list, and returns their entries.  Freezing a frozen target
+// again replaces its reason.  Nothing is frozen when one of the targets is
+// invalid.
+//
+// This function is safe for concurrent access.
+func (fl *FreezeList) Freeze(targets []string, reason string) ([]FrozenCoin, error) {
+	now := time.Now().Unix()
+	coins := make([]FrozenCoin, 0, len(targets))
+	for _, target := range targets {
+		key, err := fl.normalizeTarget(target)
+		if err != nil {
+			return nil, err
+		}
+		coins = append(coins, FrozenCoin{Target: key, Reason: reason, Time: now})
+	}
+
+	fl.mtx.Lock()
+	defer fl.mtx.Unlock()
+
+	err := fl.db.Update(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(frozenCoinsBucketName)
+		for _, coin := range coins {
+			serialized, err := json.Marshal(coin)
+			if err != nil {
+				return err
+			}
+			err = bucket.Put([]byte(coin.Target), serialized)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range coins {
+		coin := coins[i]
+		fl.coins[coin.Target] = &coin
+		log.Info("Frozen coins", "target", coin.Target, "reason", reason)
+	}
+	return coins, nil
+}
+
+// Unfreeze removes the outpoints and the addresses from the freeze list.
+// Nothing is unfrozen when one of the targets is invalid or not frozen.
+//
+// This function is safe for concurrent access.
+func (fl *FreezeList) Unfreeze(targets []string) error {
+	keys := make([]string, 0, len(targets))
+	for _, target := range targets {
+		key, err := fl.normalizeTarget(target)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+
+	fl.mtx.Lock()
+	defer fl.mtx.Unlock()
+
+	for _, key := range keys {
+		if _, ok := fl.coins[key]; !ok {
+			return fmt.Errorf("%s is not frozen", key)
+		}
+	}
+	err := fl.db.Update(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(frozenCoinsBucketName)
+		for _, key := range keys {
+			err := bucket.Delete([]byte(key))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		delete(fl.coins, key)
+		log.Info("Unfrozen coins", "target", key)
+	}
+	return nil
+}
+
+// Coins returns the entries of the freeze list sorted by target.
+//
+// This function is safe for concurrent access.
+func (fl *FreezeList) Coins() []FrozenCoin {
+	fl.mtx.RLock()
+	coins := make([]FrozenCoin, 0, len(fl.coins))
+	for _, coin := range fl.coins {
+		coins = append(coins, *coin)
+	}
+	fl.mtx.RUnlock()
+	sort.Slice(coins, func(i, j int) bool {
+		return coins[i].Target < coins[j].Target
+	})
+	return coins
+}
+
+// Name returns the name of the acceptance plugin of the freeze list.
+func (fl *FreezeList) Name() string {
+	return FreezePluginName
+}
+
+// CheckTransaction returns an error when the transaction spends a frozen
+// outpoint or an output paying to a frozen address.  The outputs spent are
+// looked up in the utxo view, so it must hold the inputs of the transaction.
+//
+// This function is safe for concurrent access.
+func (fl *FreezeList) CheckTransaction(tx *types.Tx, utxoView *blockchain.UtxoViewpoint) error {
+	if tx.Tx.IsCoinBase() {
+		return nil
+	}
+	fl.mtx.RLock()
+	defer fl.mtx.RUnlock()
+
+	if len(fl.coins) == 0 {
+		return nil
+	}
+	for _, txIn := range tx.Tx.TxIn {
+		key := outPointTarget(&txIn.PreviousOut)
+		if _, ok := fl.coins[key]; ok {
+			return fmt.Errorf("outpoint %s is frozen", key)
+		}
+		entry := utxoView.LookupEntry(txIn.PreviousOut)
+		if entry == nil {
+			continue
+		}
+		_, addrs, _, _ := txscript.ExtractPkScriptAddrs(entry.PkScript(),
+			fl.params)
+		for _, addr := range addrs {
+			if _, ok := fl.coins[addr.Encode()]; ok {
+				return fmt.Errorf("outpoint %s pays to the frozen "+
+					"address %s", key, addr.Encode())
+			}
+		}
+	}
+	return nil
+}
+
+// BlockConnected does nothing, since the blocks spending frozen coins stay
+// valid.
+func (fl *FreezeList) BlockConnected(block *types.SerializedBlock) {}
@@ -25,7 +25,10 @@ import (
 // peers.
 type TxPool struct {
 	// The following variables must only be used atomically.
-	lastUpdated int64 // last time pool was updated.
+	lastUpdated  int64  // last time pool was updated.
+	totalAdded   uint64 // transactions added since start.
+	totalRemoved uint64 // transactions removed since start.
+	stats        txPoolStats // processing statistics since start.
 
 	mtx           sync.RWMutex
 	cfg           Config
@@ -33,6 +36,8 @@ type TxPool struct {
 	orphans       map[hash.Hash]*types.Tx
 	orphansByPrev map[hash.Hash]map[hash.Hash]*types.Tx
 	outpoints     map[types.TxOutPoint]*types.Tx
+	dsProofs      map[types.TxOutPoint]*types.DoubleSpendProof
+	feeHistogram  feeHistogram
 
 	pennyTotal    float64 // exponentially decaying total for penny spends.
 	lastPennyUnix int64   // unix time of last ``penny spend''
@@ -47,6 +52,7 @@ func New(cfg *Config) *TxPool {
 		orphans:       make(map[hash.Hash]*types.Tx),
 		orphansByPrev: make(map[hash.Hash]map[hash.Hash]*types.Tx),
 		outpoints:     make(map[types.TxOutPoint]*types.Tx),
+		dsProofs:      make(map[types.TxOutPoint]*types.DoubleSpendProof),
 	}
 }
 
@@ -106,8 +112,11 @@ func (mp *TxPool) removeTransaction(theTx *types.Tx, removeRedeemers bool) {
 
 		for _, txIn := range txDesc.Tx.Transaction().TxIn {
 			delete(mp.outpoints, txIn.PreviousOut)
+			delete(mp.dsProofs, txIn.PreviousOut)
 		}
 		delete(mp.pool, *txHash)
+		mp.feeHistogram.remove(txDesc.Fee, int64(txDesc.Tx.Tx.SerializeSize()))
+		atomic.AddUint64(&mp.totalRemoved, 1)
 		atomic.StoreInt64(&mp.lastUpdated, roughtime.Now().Unix())
 	}
 }
@@ -166,9 +175,11 @@ func (mp *TxPool) addTransaction(utxoView *blockchain.UtxoViewpoint,
 		StartingPriority: CalcPriority(msgTx, utxoView, height, mp.cfg.BD),
 	}
 	mp.pool[*tx.Hash()] = txD
+	mp.feeHistogram.add(fee, int64(tx.Tx.SerializeSize()))
 	for _, txIn := range msgTx.TxIn {
 		mp.outpoints[txIn.PreviousOut] = tx
 	}
+	atomic.AddUint64(&mp.totalAdded, 1)
 	atomic.StoreInt64(&mp.lastUpdated, roughtime.Now().Unix())
 
 	// Add unconfirmed address index entries associated with the transaction
@@ -182,6 +193,14 @@ func (mp *TxPool) addTransaction(utxoView *blockchain.UtxoViewpoint,
 	return txD
 }
 
+// Churn returns the number of transactions added to and removed from the
+// pool since it was created.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) Churn() (added uint64, removed uint64) {
+	return atomic.LoadUint64(&mp.totalAdded), atomic.LoadUint64(&mp.totalRemoved)
+}
+
 //Call addTransaction
 func (mp *TxPool) AddTransaction(utxoView *blockchain.UtxoViewpoint,
 	tx *types.Tx, height uint64, fee int64) {
@@ -190,10 +209,11 @@ func (mp *TxPool) AddTransaction(utxoView *blockchain.UtxoViewpoint,
 
 // maybeAcceptTransaction is the internal function which implements the public
 // MaybeAcceptTransaction.  See the comment for MaybeAcceptTransaction for
-// more details.
+// more details.  The fee of a transaction of a package is not checked on its
+// own since it is checked along with the package.
 //
 // This function MUST be called with the mempool lock held (for writes).
-func (mp *TxPool) maybeAcceptTransaction(tx *types.Tx, isNew, rateLimit, allowHighFees bool) ([]*hash.Hash, *TxDesc, error) {
+func (mp *TxPool) maybeAcceptTransaction(tx *types.Tx, isNew, rateLimit, allowHighFees, packaged bool) ([]*hash.Hash, *TxDesc, error) {
 	msgTx := tx.Transaction()
 	txHash := tx.Hash()
 
@@ -248,12 +268,19 @@ func (mp *TxPool) maybeAcceptTransaction(tx *types.Tx, isNew, rateLimit, allowHi
 			txHash, msgTx.Expire)
 		return nil, nil, txRuleError(message.RejectInvalid, str)
 	}
+	// Don't accept transactions that are not finalized as of the next block,
+	// which could not be mined.  The past median time is used for the time
+	// based lock times since the timestamp of the next block is always
+	// after it, so that an accepted transaction is final in any next block.
+	medianTime := mp.cfg.PastMedianTime()
+	if !blockchain.IsFinalizedTransaction(tx, nextBlockHeight, medianTime) {
+		str := fmt.Sprintf("transaction %v is not finalized", txHash)
+		return nil, nil, txRuleError(message.RejectNonstandard, str)
+	}
 	// Don't allow non-standard transactions if the mempool config forbids
 	// their acceptance and relaying.
-	medianTime := mp.cfg.PastMedianTime()
 	if !mp.cfg.Policy.AcceptNonStd {
-		err := checkTransactionStandard(tx, nextBlockHeight,
-			medianTime, mp.cfg.Policy.MinRelayTxFee,
+		err := checkTransactionStandard(tx, mp.cfg.Policy.MinRelayTxFee,
 			mp.cfg.Policy.MaxTxVersion)
 		if err != nil {
 			// Attempt to extract a reject code from the error so
@@ -279,6 +306,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *types.Tx, isNew, rateLimit, allowHi
 	// which examines the actual spend data and prevents double spends.
 	err = mp.checkPoolDoubleSpend(tx)
 	if err != nil {
+		mp.recordDoubleSpend(tx)
 		return nil, nil, err
 	}
 
@@ -319,6 +347,13 @@ func (mp *TxPool) maybeAcceptTransaction(tx *types.Tx, isNew, rateLimit, allowHi
 			return nil, nil, err
 		}
 
+		// Give the registered acceptance plugins the chance to veto the
+		// transaction.
+		err = checkPlugins(tx, utxoView)
+		if err != nil {
+			return nil, nil, err
+		}
+
 		// Add to transaction pool.
 		txD := mp.addTransaction(utxoView, tx, nextBlockHeight, 0)
 
@@ -458,7 +493,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *types.Tx, isNew, rateLimit, allowHi
 		txFee.Value = txFees[txFee.Id]
 	}
 
-	if txFee.Value < minFee {
+	if !packaged && txFee.Value < minFee {
 		str := fmt.Sprintf("transaction %v has %v fees which "+
 			"is under the required amount of %v, tx size is %v bytes, policy-rate is %v/byte.", txHash,
 			txFee, minFee, serializedSize, mp.cfg.Policy.MinRelayTxFee.Value/1000)
@@ -471,7 +506,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *types.Tx, isNew, rateLimit, allowHi
 	// are exempted.
 	//
 	// This applies to non-stake transactions only.
-	if isNew && !mp.cfg.Policy.DisableRelayPriority && txFee.Value < minFee {
+	if isNew && !packaged && !mp.cfg.Policy.DisableRelayPriority && txFee.Value < minFee {
 
 		currentPriority := CalcPriority(msgTx, utxoView,
 			nextBlockHeight, mp.cfg.BD)
@@ -487,7 +522,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *types.Tx, isNew, rateLimit, allowHi
 	// Free-to-relay transactions are rate limited here to prevent
 	// penny-flooding with tiny transactions as a form of attack.
 	// This applies to non-stake transactions only.
-	if rateLimit && txFee.Value < minFee {
+	if rateLimit && !packaged && txFee.Value < minFee {
 		nowUnix := roughtime.Now().Unix()
 		// Decay passed data with an exponentially decaying ~10 minute
 		// window.
@@ -518,10 +553,10 @@ func (mp *TxPool) maybeAcceptTransaction(tx *types.Tx, isNew, rateLimit, allowHi
 
 		mrtf := types.Amount{Id: txFee.Id, Value: mp.cfg.Policy.MinRelayTxFee.Value}
 		if txFee.Value > maxFee {
-			err = fmt.Errorf("transaction %v has %v fee which is above the "+
+			str := fmt.Sprintf("transaction %v has %v fee which is above the "+
 				"allowHighFee check threshold amount of %v (= %v byte * %v/kB * %v)", txHash,
 				txFee.Value, maxFee, serializedSize, mrtf.Format(types.AmountAtom), maxRelayFeeMultiplier)
-			return nil, nil, err
+			return nil, nil, txRuleErrorReason(message.RejectInvalid, ReasonHighFee, str)
 		}
 	}
 
@@ -540,6 +575,13 @@ func (mp *TxPool) maybeAcceptTransaction(tx *types.Tx, isNew, rateLimit, allowHi
 		return nil, nil, err
 	}
 
+	// Give the registered acceptance plugins the chance to veto the
+	// transaction.
+	err = checkPlugins(tx, utxoView)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Add to transaction pool.
 	txD := mp.addTransaction(utxoView, tx, nextBlockHeight, txFee.Value)
 
@@ -593,6 +635,18 @@ func (mp *TxPool) ProcessTransaction(tx *types.Tx, allowOrphan, rateLimit, allow
 	// Protect concurrent access.
 	mp.mtx.Lock()
 	defer mp.mtx.Unlock()
+
+	start := time.Now()
+	acceptedTxs, err := mp.processTransaction(tx, allowOrphan, rateLimit, allowHighFees)
+	mp.stats.record(acceptedTxs, err, time.Since(start))
+	return acceptedTxs, err
+}
+
+// processTransaction is the internal function which implements the public
+// ProcessTransaction.  See the comment for ProcessTransaction for more details.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) processTransaction(tx *types.Tx, allowOrphan, rateLimit, allowHighFees bool) ([]*types.TxDesc, error) {
 	var err error
 	defer func() {
 		if err != nil {
@@ -603,7 +657,7 @@ func (mp *TxPool) ProcessTransaction(tx *types.Tx, allowOrphan, rateLimit, allow
 	// Potentially accept the transaction to the memory pool.
 	var missingParents []*hash.Hash
 	missingParents, txD, err := mp.maybeAcceptTransaction(tx, true, rateLimit,
-		allowHighFees)
+		allowHighFees, false)
 	if err != nil {
 		return nil, err
 	}
@@ -642,7 +696,8 @@ func (mp *TxPool) ProcessTransaction(tx *types.Tx, allowOrphan, rateLimit, allow
 		str := fmt.Sprintf("orphan transaction %v references "+
 			"outputs of unknown or fully-spent "+
 			"transaction %v", tx.Hash(), missingParents[0])
-		return nil, txRuleError(message.RejectDuplicate, str)
+		return nil, txRuleErrorReason(message.RejectDuplicate,
+			ReasonMissingInputs, str)
 	}
 
 	// Potentially add the orphan transaction to the orphan pool.
@@ -672,6 +727,13 @@ func (mp *TxPool) maybeAddOrphan(tx *types.Tx) error {
 		return txRuleError(message.RejectNonstandard, str)
 	}
 
+	// Ignore orphan transactions when the orphan pool is full.
+	if mp.cfg.Policy.MaxOrphanTxs > 0 && len(mp.orphans) >= mp.cfg.Policy.MaxOrphanTxs {
+		str := fmt.Sprintf("orphan transaction %v exceeds the limit of "+
+			"%d orphans", tx.Hash(), mp.cfg.Policy.MaxOrphanTxs)
+		return txRuleErrorReason(message.RejectNonstandard, ReasonOrphanLimit, str)
+	}
+
 	// Add the orphan if the none of the above disqualified it.
 	mp.addOrphan(tx)
 
@@ -689,7 +751,7 @@ func (mp *TxPool) maybeAddOrphan(tx *types.Tx) error {
 func (mp *TxPool) MaybeAcceptTransaction(tx *types.Tx, isNew, rateLimit bool) ([]*hash.Hash, error) {
 	// Protect concurrent access.
 	mp.mtx.Lock()
-	hashes, _, err := mp.maybeAcceptTransaction(tx, isNew, rateLimit, true)
+	hashes, _, err := mp.maybeAcceptTransaction(tx, isNew, rateLimit, true, false)
 	mp.mtx.Unlock()
 
 	return hashes, err
@@ -780,7 +842,7 @@ func (mp *TxPool) processOrphans(h *hash.Hash) []*TxDesc {
 			// Potentially accept the transaction into the
 			// transaction pool.
 			missingParents, txD, err := mp.maybeAcceptTransaction(tx,
-				true, true, true)
+				true, true, true, false)
 			if err != nil {
 				// TODO: Remove orphans that depend on this
 				// failed transaction.
@@ -862,6 +924,19 @@ func (mp *TxPool) ProcessOrphans(hash *hash.Hash) []*types.TxDesc {
 	return acceptedTxnsT
 }
 
+// CheckSpend checks whether the passed outpoint is already spent by a
+// transaction in the mempool.  If that's the case the spending transaction
+// will be returned, if not nil will be returned.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) CheckSpend(op types.TxOutPoint) *types.Tx {
+	mp.mtx.RLock()
+	txR := mp.outpoints[op]
+	mp.mtx.RUnlock()
+
+	return txR
+}
+
 // FetchTransaction returns the requested transaction from the transaction pool.
 // This only fetches from the main transaction pool and does not include
 // orphans.
@@ -976,6 +1051,23 @@ func (mp *TxPool) LastUpdated() time.Time {
 	return time.Unix(atomic.LoadInt64(&mp.lastUpdated), 0)
 }
 
+// Count returns the number of transactions in the main pool.  It does not
+// include the orphan pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) Count() int {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	return len(mp.pool)
+}
+
+// MinRelayTxFee returns the minimum transaction fee in atoms/kB accepted by
+// the pool, below which transactions are not relayed.
+func (mp *TxPool) MinRelayTxFee() int64 {
+	return mp.cfg.Policy.MinRelayTxFee.Value
+}
+
 // MiningDescs returns a slice of mining descriptors for all the transactions
 // in the pool.
 //
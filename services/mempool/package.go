@@ -0,0 +1,186 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/message"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/log"
+	"time"
+)
+
+const (
+	// MaxPackageCount is the maximum number of transactions of a package.
+	MaxPackageCount = 25
+
+	// MaxPackageSize is the maximum total serialized size of the
+	// transactions of a package.
+	MaxPackageSize = 101000
+)
+
+// checkPackage checks the transactions form a package: a child transaction,
+// last, along with unconfirmed ancestors, sorted so that every transaction
+// comes after the transactions of the package it spends.  Every transaction
+// but the child must be spent by a later transaction of the package.
+func checkPackage(txs []*types.Tx) error {
+	if len(txs) < 2 {
+		return txRuleError(message.RejectInvalid,
+			"a package has at least two transactions")
+	}
+	if len(txs) > MaxPackageCount {
+		str := fmt.Sprintf("package of %d transactions exceeds the limit "+
+			"of %d transactions", len(txs), MaxPackageCount)
+		return txRuleError(message.RejectNonstandard, str)
+	}
+
+	size := 0
+	position := make(map[hash.Hash]int, len(txs))
+	for i, tx := range txs {
+		size += tx.Tx.SerializeSize()
+		if _, exists := position[*tx.Hash()]; exists {
+			str := fmt.Sprintf("transaction %v is duplicated in the "+
+				"package", tx.Hash())
+			return txRuleError(message.RejectInvalid, str)
+		}
+		position[*tx.Hash()] = i
+	}
+	if size > MaxPackageSize {
+		str := fmt.Sprintf("package of %d bytes exceeds the limit of %d "+
+			"bytes", size, MaxPackageSize)
+		return txRuleError(message.RejectNonstandard, str)
+	}
+
+	spent := make([]bool, len(txs))
+	for i, tx := range txs {
+		if tx.Tx.IsCoinBase() || types.IsTokenTx(tx.Tx) {
+			continue
+		}
+		for _, txIn := range tx.Tx.TxIn {
+			parent, exists := position[txIn.PreviousOut.Hash]
+			if !exists {
+				continue
+			}
+			if parent > i {
+				str := fmt.Sprintf("transaction %v of the package "+
+					"comes before its parent %v", tx.Hash(),
+					txIn.PreviousOut.Hash)
+				return txRuleError(message.RejectInvalid, str)
+			}
+			spent[parent] = true
+		}
+	}
+	for i, tx := range txs[:len(txs)-1] {
+		if !spent[i] {
+			str := fmt.Sprintf("transaction %v of the package is not "+
+				"spent by the package", tx.Hash())
+			return txRuleError(message.RejectInvalid, str)
+		}
+	}
+	return nil
+}
+
+// ProcessPackage validates a package of dependent transactions, as described
+// by checkPackage, and accepts all of them to the memory pool or none.  The
+// fees of the transactions are checked against the minimum relay fee for the
+// whole package rather than one by one, so that a child paying for its
+// parents brings them into the pool along with it.  The transactions of the
+// package which are already in the pool are skipped and do not count towards
+// the fees of the package.
+//
+// It returns a slice of transactions added to the mempool, which includes the
+// transactions of the package along with any orphan transactions that were
+// added as a result of the package being accepted.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) ProcessPackage(txs []*types.Tx, rateLimit, allowHighFees bool) ([]*types.TxDesc, error) {
+	// Protect concurrent access.
+	mp.mtx.Lock()
+	defer mp.mtx.Unlock()
+
+	start := time.Now()
+	acceptedTxs, err := mp.processPackage(txs, rateLimit, allowHighFees)
+	mp.stats.record(acceptedTxs, err, time.Since(start))
+	return acceptedTxs, err
+}
+
+// processPackage is the internal function which implements the public
+// ProcessPackage.  See the comment for ProcessPackage for more details.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) processPackage(txs []*types.Tx, rateLimit, allowHighFees bool) ([]*types.TxDesc, error) {
+	err := checkPackage(txs)
+	if err != nil {
+		return nil, err
+	}
+
+	var added []*TxDesc
+	var orphans []*types.Tx
+	rollback := func() {
+		for i := len(added) - 1; i >= 0; i-- {
+			mp.removeTransaction(added[i].Tx, false)
+		}
+		for _, tx := range orphans {
+			mp.addOrphan(tx)
+		}
+	}
+
+	var fee, size int64
+	for _, tx := range txs {
+		if mp.isTransactionInPool(tx.Hash()) {
+			continue
+		}
+		// A transaction of the package waiting in the orphan pool is
+		// accepted along with its parents.
+		if mp.isOrphanInPool(tx.Hash()) {
+			mp.removeOrphan(tx.Hash())
+			orphans = append(orphans, tx)
+		}
+
+		missingParents, txD, err := mp.maybeAcceptTransaction(tx, true,
+			rateLimit, allowHighFees, true)
+		if err == nil && len(missingParents) > 0 {
+			str := fmt.Sprintf("transaction %v of the package references "+
+				"outputs of unknown or fully-spent transaction %v",
+				tx.Hash(), missingParents[0])
+			err = txRuleErrorReason(message.RejectDuplicate,
+				ReasonMissingInputs, str)
+		}
+		if err != nil {
+			rollback()
+			return nil, err
+		}
+		added = append(added, txD)
+		fee += txD.Fee
+		size += int64(tx.Tx.SerializeSize())
+	}
+	if len(added) == 0 {
+		return nil, txRuleError(message.RejectDuplicate,
+			"already have all the transactions of the package")
+	}
+
+	minFee := calcMinRequiredTxRelayFee(size, mp.cfg.Policy.MinRelayTxFee)
+	if fee < minFee {
+		rollback()
+		str := fmt.Sprintf("package has %v fees which is under the "+
+			"required amount of %v for %v bytes", fee, minFee, size)
+		return nil, txRuleError(message.RejectInsufficientFee, str)
+	}
+
+	acceptedTxs := make([]*types.TxDesc, 0, len(added))
+	for _, txD := range added {
+		acceptedTxs = append(acceptedTxs, &txD.TxDesc)
+	}
+	for _, txD := range added {
+		for _, td := range mp.processOrphans(txD.Tx.Hash()) {
+			acceptedTxs = append(acceptedTxs, &td.TxDesc)
+		}
+	}
+
+	log.Debug("Accepted package", "txs", len(added), "fee", fee, "size", size)
+
+	return acceptedTxs, nil
+}
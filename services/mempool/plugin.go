@@ -0,0 +1,146 @@
+// Copyright (c) 2017-2018 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/message"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/log"
+	"plugin"
+	"sort"
+	"sync"
+)
+
+// PluginSymbol is the name of the exported symbol that a Go plugin loaded
+// by LoadPlugin must provide.  The symbol must be a variable or value that
+// implements the AcceptPlugin interface.
+const PluginSymbol = "AcceptPlugin"
+
+// AcceptPlugin defines a policy hook which is invoked while transactions are
+// accepted into the memory pool and while blocks are connected to the chain.
+//
+// Plugins only have policy power: a plugin may veto a transaction from being
+// accepted into (and therefore relayed and mined from) the local memory pool,
+// but it can never make a block invalid.  Blocks are only observed.
+type AcceptPlugin interface {
+	// Name returns the unique name of the plugin.
+	Name() string
+
+	// CheckTransaction is invoked after the transaction passed all of the
+	// consensus and standardness checks and right before it is added to the
+	// pool.  Returning a non-nil error vetoes the transaction.
+	CheckTransaction(tx *types.Tx, utxoView *blockchain.UtxoViewpoint) error
+
+	// BlockConnected is invoked for every block connected to the chain.
+	BlockConnected(block *types.SerializedBlock)
+}
+
+var (
+	pluginsLock sync.RWMutex
+	plugins     = make(map[string]AcceptPlugin)
+)
+
+// RegisterPlugin adds an in-process acceptance plugin.  An error is returned
+// if a plugin with the same name has already been registered.
+//
+// This function is safe for concurrent access.
+func RegisterPlugin(p AcceptPlugin) error {
+	pluginsLock.Lock()
+	defer pluginsLock.Unlock()
+
+	if _, exists := plugins[p.Name()]; exists {
+		return fmt.Errorf("acceptance plugin %q is already registered", p.Name())
+	}
+	plugins[p.Name()] = p
+	log.Info("Registered acceptance plugin", "name", p.Name())
+	return nil
+}
+
+// UnregisterPlugin removes the acceptance plugin with the given name.
+//
+// This function is safe for concurrent access.
+func UnregisterPlugin(name string) {
+	pluginsLock.Lock()
+	delete(plugins, name)
+	pluginsLock.Unlock()
+}
+
+// LoadPlugin opens the Go plugin at the given path and registers the
+// AcceptPlugin exported by it under the PluginSymbol name.
+func LoadPlugin(path string) error {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open acceptance plugin %s: %v", path, err)
+	}
+	sym, err := p.Lookup(PluginSymbol)
+	if err != nil {
+		return fmt.Errorf("acceptance plugin %s: %v", path, err)
+	}
+	switch ap := sym.(type) {
+	case AcceptPlugin:
+		return RegisterPlugin(ap)
+	case *AcceptPlugin:
+		if *ap == nil {
+			return fmt.Errorf("acceptance plugin %s: symbol %s is nil",
+				path, PluginSymbol)
+		}
+		return RegisterPlugin(*ap)
+	}
+	return fmt.Errorf("acceptance plugin %s: symbol %s does not implement "+
+		"mempool.AcceptPlugin", path, PluginSymbol)
+}
+
+// Plugins returns the names of all registered acceptance plugins in sorted
+// order.
+//
+// This function is safe for concurrent access.
+func Plugins() []string {
+	pluginsLock.RLock()
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	pluginsLock.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// activePlugins returns the registered plugins ordered by name so that the
+// invocation order is deterministic.
+func activePlugins() []AcceptPlugin {
+	names := Plugins()
+	pluginsLock.RLock()
+	ps := make([]AcceptPlugin, 0, len(names))
+	for _, name := range names {
+		if p, ok := plugins[name]; ok {
+			ps = append(ps, p)
+		}
+	}
+	pluginsLock.RUnlock()
+	return ps
+}
+
+// checkPlugins runs the transaction through every registered plugin and
+// returns a non standard rule error for the first veto.
+func checkPlugins(tx *types.Tx, utxoView *blockchain.UtxoViewpoint) error {
+	for _, p := range activePlugins() {
+		if err := p.CheckTransaction(tx, utxoView); err != nil {
+			str := fmt.Sprintf("transaction %v rejected by plugin %s: %v",
+				tx.Hash(), p.Name(), err)
+			return txRuleError(message.RejectNonstandard, str)
+		}
+	}
+	return nil
+}
+
+// NotifyPluginsBlockConnected informs every registered plugin about a block
+// that was connected to the chain.
+func NotifyPluginsBlockConnected(block *types.SerializedBlock) {
+	for _, p := range activePlugins() {
+		p.BlockConnected(block)
+	}
+}
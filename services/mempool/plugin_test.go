@@ -0,0 +1,143 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"errors"
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/message"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/core/types/pow"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testPlugin is an acceptance plugin which records its invocations in a
+// shared log and vetoes every transaction when veto is set.
+type testPlugin struct {
+	name  string
+	veto  error
+	calls *[]string
+}
+
+func (p *testPlugin) Name() string {
+	return p.name
+}
+
+func (p *testPlugin) CheckTransaction(tx *types.Tx, utxoView *blockchain.UtxoViewpoint) error {
+	*p.calls = append(*p.calls, "check:"+p.name)
+	return p.veto
+}
+
+func (p *testPlugin) BlockConnected(block *types.SerializedBlock) {
+	*p.calls = append(*p.calls, "block:"+p.name)
+}
+
+// testBlock returns an empty block to notify the plugins of.
+func testBlock() *types.SerializedBlock {
+	block := &types.Block{}
+	block.Header.Pow = pow.GetInstance(pow.BLAKE2BD, 0, []byte{})
+	return types.NewBlock(block)
+}
+
+// registerTestPlugins registers the passed plugins and returns a function
+// which removes them again.
+func registerTestPlugins(t *testing.T, ps ...*testPlugin) func() {
+	for _, p := range ps {
+		if err := RegisterPlugin(p); err != nil {
+			t.Fatalf("RegisterPlugin(%s): %v", p.name, err)
+		}
+	}
+	return func() {
+		for _, p := range ps {
+			UnregisterPlugin(p.name)
+		}
+	}
+}
+
+// TestPluginRegistry ensures plugins are unique by name and are listed in
+// sorted order.
+func TestPluginRegistry(t *testing.T) {
+	var calls []string
+	cleanup := registerTestPlugins(t,
+		&testPlugin{name: "b", calls: &calls},
+		&testPlugin{name: "a", calls: &calls})
+	defer cleanup()
+
+	if err := RegisterPlugin(&testPlugin{name: "a", calls: &calls}); err == nil {
+		t.Fatal("RegisterPlugin: expected error for a duplicate name")
+	}
+	if got, want := Plugins(), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Plugins: got %v, want %v", got, want)
+	}
+	UnregisterPlugin("a")
+	if got, want := Plugins(), []string{"b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Plugins after unregister: got %v, want %v", got, want)
+	}
+}
+
+// TestPluginOrder ensures the plugins check transactions and observe blocks
+// in the order of their names, whatever the order of registration.
+func TestPluginOrder(t *testing.T) {
+	var calls []string
+	cleanup := registerTestPlugins(t,
+		&testPlugin{name: "c", calls: &calls},
+		&testPlugin{name: "a", calls: &calls},
+		&testPlugin{name: "b", calls: &calls})
+	defer cleanup()
+
+	tx := types.NewTx(types.NewTransaction())
+	if err := checkPlugins(tx, nil); err != nil {
+		t.Fatalf("checkPlugins: unexpected error %v", err)
+	}
+	NotifyPluginsBlockConnected(testBlock())
+
+	want := []string{"check:a", "check:b", "check:c",
+		"block:a", "block:b", "block:c"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls: got %v, want %v", calls, want)
+	}
+}
+
+// TestPluginVeto ensures the first veto stops the remaining plugins and is
+// turned into a non standard rule error, which is how the transaction is
+// rejected by the memory pool and reported to the peers.
+func TestPluginVeto(t *testing.T) {
+	var calls []string
+	cleanup := registerTestPlugins(t,
+		&testPlugin{name: "a", calls: &calls},
+		&testPlugin{name: "b", veto: errors.New("vetoed"), calls: &calls},
+		&testPlugin{name: "c", veto: errors.New("unreached"), calls: &calls})
+	defer cleanup()
+
+	tx := types.NewTx(types.NewTransaction())
+	err := checkPlugins(tx, nil)
+	if err == nil {
+		t.Fatal("checkPlugins: expected a veto")
+	}
+	if _, ok := err.(RuleError); !ok {
+		t.Fatalf("checkPlugins: got %T, want RuleError", err)
+	}
+	code, reason := ErrToRejectErr(err)
+	if code != message.RejectNonstandard {
+		t.Fatalf("reject code: got %v, want %v", code,
+			message.RejectNonstandard)
+	}
+	if !strings.Contains(reason, "plugin b") ||
+		!strings.Contains(reason, "vetoed") {
+		t.Fatalf("reject reason %q does not name the vetoing plugin", reason)
+	}
+	if want := []string{"check:a", "check:b"}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls: got %v, want %v", calls, want)
+	}
+
+	// Blocks are observed by every plugin, vetoing or not.
+	calls = nil
+	NotifyPluginsBlockConnected(testBlock())
+	if want := []string{"block:a", "block:b", "block:c"}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls: got %v, want %v", calls, want)
+	}
+}
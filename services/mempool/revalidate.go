@@ -0,0 +1,109 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/log"
+)
+
+// EvictedTx is a transaction evicted from the pool by a revalidation, along
+// with the reason of the eviction.
+type EvictedTx struct {
+	Tx     *types.Tx
+	Reason string
+}
+
+// RevalidateTransactions evicts the transactions of the pool which spend an
+// output that is spent or unknown from the point of view of the main chain,
+// which happens after the order of the DAG changed.  The transactions which
+// spend the outputs of an evicted transaction are evicted as well.
+//
+// The utxos are fetched without the pool lock held, so that the revalidation
+// can not block the chain.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) RevalidateTransactions() []*EvictedTx {
+	descs := mp.TxDescs()
+	inPool := make(map[hash.Hash]struct{}, len(descs))
+	for _, desc := range descs {
+		inPool[*desc.Tx.Hash()] = struct{}{}
+	}
+
+	conflicts := make(map[hash.Hash]string)
+	for _, desc := range descs {
+		tx := desc.Tx
+		if types.IsTokenTx(tx.Tx) {
+			continue
+		}
+		utxoView, err := mp.cfg.FetchUtxoView(tx)
+		if err != nil {
+			log.Warn("Unable to fetch utxo view", "tx", tx.Hash(), "err", err)
+			continue
+		}
+		for _, txIn := range tx.Tx.TxIn {
+			prevOut := txIn.PreviousOut
+			if _, exists := inPool[prevOut.Hash]; exists {
+				continue
+			}
+			entry := utxoView.LookupEntry(prevOut)
+			if entry == nil || entry.IsSpent() {
+				conflicts[*tx.Hash()] = fmt.Sprintf("input %v is spent or unknown",
+					prevOut)
+				break
+			}
+		}
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	mp.mtx.Lock()
+	defer mp.mtx.Unlock()
+
+	var evicted []*EvictedTx
+	for txHash, reason := range conflicts {
+		desc, exists := mp.pool[txHash]
+		if !exists {
+			continue
+		}
+		redeemers := make(map[hash.Hash]*types.Tx)
+		mp.findRedeemers(desc.Tx, redeemers)
+		mp.removeTransaction(desc.Tx, true)
+		evicted = append(evicted, &EvictedTx{Tx: desc.Tx, Reason: reason})
+		for _, tx := range redeemers {
+			evicted = append(evicted, &EvictedTx{
+				Tx:     tx,
+				Reason: fmt.Sprintf("spends evicted transaction %v", txHash),
+			})
+		}
+	}
+	for _, e := range evicted {
+		log.Debug("Evicted conflicting transaction from the mempool",
+			"tx", e.Tx.Hash(), "reason", e.Reason)
+	}
+	return evicted
+}
+
+// findRedeemers adds the transactions of the pool which spend the outputs of
+// the passed transaction to the redeemers, recursively.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) findRedeemers(tx *types.Tx, redeemers map[hash.Hash]*types.Tx) {
+	for i := range tx.Tx.TxOut {
+		outpoint := types.NewOutPoint(tx.Hash(), uint32(i))
+		redeemer, exists := mp.outpoints[*outpoint]
+		if !exists {
+			continue
+		}
+		if _, found := redeemers[*redeemer.Hash()]; found {
+			continue
+		}
+		redeemers[*redeemer.Hash()] = redeemer
+		mp.findRedeemers(redeemer, redeemers)
+	}
+}
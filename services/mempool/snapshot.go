@@ -0,0 +1,223 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/log"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+)
+
+// SnapshotVersion is the version of the format of the mempool snapshot files.
+const SnapshotVersion = 1
+
+// Snapshot is the content of the mempool at a given time, as written to a
+// snapshot file.  The transactions are sorted by the time they were added to
+// the pool, so that the parents come before their children.
+type Snapshot struct {
+	Version  int          `json:"version"`
+	Time     int64        `json:"time"`
+	BestHash string       `json:"besthash"`
+	Txs      []SnapshotTx `json:"txs"`
+}
+
+// SnapshotTx is a transaction of a mempool snapshot.
+type SnapshotTx struct {
+	TxID     string `json:"txid"`
+	Added    int64  `json:"added"`
+	Height   int64  `json:"height"`
+	Fee      int64  `json:"fee"`
+	FeePerKB int64  `json:"feeperkb"`
+	Hex      string `json:"hex"`
+}
+
+// SnapshotFeeChange is a transaction found in both snapshots of a diff with a
+// different fee.
+type SnapshotFeeChange struct {
+	Old *SnapshotTx
+	New *SnapshotTx
+}
+
+// SnapshotDiff is the difference between two mempool snapshots.  All the lists
+// are sorted by transaction id.
+type SnapshotDiff struct {
+	Added      []*SnapshotTx
+	Removed    []*SnapshotTx
+	FeeChanged []SnapshotFeeChange
+}
+
+// Snapshot returns the current content of the pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) Snapshot() (*Snapshot, error) {
+	descs := mp.TxDescs()
+	sort.Slice(descs, func(i, j int) bool {
+		return descs[i].Added.Before(descs[j].Added)
+	})
+	snap := &Snapshot{
+		Version: SnapshotVersion,
+		Time:    time.Now().Unix(),
+		Txs:     make([]SnapshotTx, 0, len(descs)),
+	}
+	if best := mp.cfg.BestHash(); best != nil {
+		snap.BestHash = best.String()
+	}
+	for _, desc := range descs {
+		txBytes, err := desc.Tx.Tx.Serialize()
+		if err != nil {
+			return nil, err
+		}
+		snap.Txs = append(snap.Txs, SnapshotTx{
+			TxID:     desc.Tx.Hash().String(),
+			Added:    desc.Added.Unix(),
+			Height:   desc.Height,
+			Fee:      desc.Fee,
+			FeePerKB: desc.FeePerKB,
+			Hex:      hex.EncodeToString(txBytes),
+		})
+	}
+	return snap, nil
+}
+
+// LoadSnapshot submits the transactions of the snapshot to the pool under the
+// usual policy, and returns the number of transactions accepted, skipped as
+// already known and rejected.  The accepted transactions are not relayed.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) LoadSnapshot(snap *Snapshot) (int, int, int, error) {
+	txs := make([]*types.Tx, 0, len(snap.Txs))
+	for _, st := range snap.Txs {
+		txBytes, err := hex.DecodeString(st.Hex)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("invalid transaction %s: %v", st.TxID, err)
+		}
+		tx, err := types.NewTxFromBytes(txBytes)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("invalid transaction %s: %v", st.TxID, err)
+		}
+		txs = append(txs, tx)
+	}
+
+	accepted, skipped, rejected := 0, 0, 0
+	for _, tx := range txs {
+		if mp.HaveTransaction(tx.Hash()) {
+			skipped++
+			continue
+		}
+		// Orphans are allowed since the children of transactions
+		// added within the same second may come first.
+		acceptedTxs, err := mp.ProcessTransaction(tx, true, false, false)
+		if err != nil {
+			log.Debug("Rejected snapshot transaction", "tx", tx.Hash(), "err", err)
+			rejected++
+			continue
+		}
+		accepted += len(acceptedTxs)
+	}
+	return accepted, skipped, rejected, nil
+}
+
+// WriteSnapshotFile writes the snapshot to the file.  An existing file is only
+// replaced when it is a mempool snapshot, so that a wrong name can not destroy
+// another file.
+func WriteSnapshotFile(path string, snap *Snapshot, perm os.FileMode) error {
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if os.IsExist(err) {
+		f, err = openSnapshotFile(path)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// openSnapshotFile truncates and opens the existing file for writing, unless it
+// is not a regular file nor a mempool snapshot.
+func openSnapshotFile(path string) (*os.File, error) {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.Mode().IsRegular() {
+		return nil, fmt.Errorf("refusing to overwrite %s: not a regular file", path)
+	}
+	if _, err := ReadSnapshotFile(path); err != nil {
+		return nil, fmt.Errorf("refusing to overwrite %s: %v", path, err)
+	}
+	return os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+}
+
+// ReadSnapshotFile reads a snapshot from the file, which must have the current
+// version of the format.
+func ReadSnapshotFile(path string) (*Snapshot, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	snap := &Snapshot{}
+	if err := json.Unmarshal(data, snap); err != nil {
+		return nil, fmt.Errorf("invalid mempool snapshot %s: %v", path, err)
+	}
+	if snap.Version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported version %d of mempool snapshot %s",
+			snap.Version, path)
+	}
+	return snap, nil
+}
+
+// DiffSnapshots returns the transactions added to the snapshot b, removed
+// from the snapshot a, and those of both snapshots whose fee changed.
+func DiffSnapshots(a, b *Snapshot) *SnapshotDiff {
+	oldTxs := make(map[string]*SnapshotTx, len(a.Txs))
+	for i := range a.Txs {
+		oldTxs[a.Txs[i].TxID] = &a.Txs[i]
+	}
+	newTxs := make(map[string]*SnapshotTx, len(b.Txs))
+	for i := range b.Txs {
+		newTxs[b.Txs[i].TxID] = &b.Txs[i]
+	}
+
+	diff := &SnapshotDiff{}
+	for id, nt := range newTxs {
+		ot, ok := oldTxs[id]
+		if !ok {
+			diff.Added = append(diff.Added, nt)
+			continue
+		}
+		if ot.Fee != nt.Fee || ot.FeePerKB != nt.FeePerKB {
+			diff.FeeChanged = append(diff.FeeChanged, SnapshotFeeChange{Old: ot, New: nt})
+		}
+	}
+	for id, ot := range oldTxs {
+		if _, ok := newTxs[id]; !ok {
+			diff.Removed = append(diff.Removed, ot)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool {
+		return diff.Added[i].TxID < diff.Added[j].TxID
+	})
+	sort.Slice(diff.Removed, func(i, j int) bool {
+		return diff.Removed[i].TxID < diff.Removed[j].TxID
+	})
+	sort.Slice(diff.FeeChanged, func(i, j int) bool {
+		return diff.FeeChanged[i].New.TxID < diff.FeeChanged[j].New.TxID
+	})
+	return diff
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteSnapshotFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A new file is created and a previous snapshot replaced.
+	path := filepath.Join(dir, "mempool.json")
+	for i := int64(1); i <= 2; i++ {
+		err = WriteSnapshotFile(path, &Snapshot{Version: SnapshotVersion, Time: i}, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		snap, err := ReadSnapshotFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if snap.Time != i {
+			t.Fatalf("snapshot time %d, want %d", snap.Time, i)
+		}
+	}
+
+	// Any other file is kept.
+	other := filepath.Join(dir, "keystore.json")
+	content := []byte(`{"crypto":{}}`)
+	err = ioutil.WriteFile(other, content, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = WriteSnapshotFile(other, &Snapshot{Version: SnapshotVersion}, 0600)
+	if err == nil {
+		t.Fatal("overwrote a file which is not a snapshot")
+	}
+	data, err := ioutil.ReadFile(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(content) {
+		t.Fatalf("file changed to %s", data)
+	}
+}
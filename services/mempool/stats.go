@@ -0,0 +1,208 @@
+// Copyright (c) 2017-2018 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/message"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/metrics"
+	gometrics "github.com/rcrowley/go-metrics"
+	"sync/atomic"
+	"time"
+)
+
+// RejectReason describes why a transaction was not accepted to the pool.  It
+// is finer grained than the reject code sent to peers and is used for the
+// pool statistics.
+type RejectReason int
+
+const (
+	// ReasonOther is any reason which is not classified otherwise.
+	ReasonOther RejectReason = iota
+
+	// ReasonInvalid is a transaction breaking the consensus rules.
+	ReasonInvalid
+
+	// ReasonFeeTooLow is a transaction paying less than the minimum relay
+	// fee or exceeding the free transaction rate limit.
+	ReasonFeeTooLow
+
+	// ReasonHighFee is a transaction paying an absurdly high fee.
+	ReasonHighFee
+
+	// ReasonNonStandard is a transaction breaking the standardness policy.
+	ReasonNonStandard
+
+	// ReasonDuplicate is a transaction which is already known.
+	ReasonDuplicate
+
+	// ReasonDoubleSpend is a transaction spending the same coins as a
+	// transaction in the pool.
+	ReasonDoubleSpend
+
+	// ReasonMissingInputs is an orphan transaction which was not allowed
+	// to enter the orphan pool.
+	ReasonMissingInputs
+
+	// ReasonOrphanLimit is an orphan transaction which could not be kept
+	// because the orphan pool is full.
+	ReasonOrphanLimit
+
+	numRejectReasons
+)
+
+var rejectReasonStrings = [numRejectReasons]string{
+	ReasonOther:         "other",
+	ReasonInvalid:       "invalid",
+	ReasonFeeTooLow:     "feetoolow",
+	ReasonHighFee:       "highfee",
+	ReasonNonStandard:   "nonstandard",
+	ReasonDuplicate:     "duplicate",
+	ReasonDoubleSpend:   "doublespend",
+	ReasonMissingInputs: "missinginputs",
+	ReasonOrphanLimit:   "orphanlimit",
+}
+
+// String returns the RejectReason as a human-readable name.
+func (r RejectReason) String() string {
+	if r >= 0 && r < numRejectReasons {
+		return rejectReasonStrings[r]
+	}
+	return rejectReasonStrings[ReasonOther]
+}
+
+// latencyBuckets are the upper bounds of the acceptance latency histogram.
+// The last bucket of the histogram counts everything above the last bound.
+var latencyBuckets = [...]time.Duration{
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+var (
+	acceptedCounter  = metrics.NewCounter("txpool/accepted")
+	orphanedCounter  = metrics.NewCounter("txpool/orphaned")
+	latencyTimer     = metrics.NewTimer("txpool/latency")
+	rejectedCounters [numRejectReasons]gometrics.Counter
+)
+
+func init() {
+	for r := RejectReason(0); r < numRejectReasons; r++ {
+		rejectedCounters[r] = metrics.NewCounter("txpool/rejected/" + r.String())
+	}
+}
+
+// txPoolStats counts the outcome of the transactions processed by the pool.
+type txPoolStats struct {
+	// The following variables must only be used atomically.
+	accepted uint64
+	orphaned uint64
+	rejected [numRejectReasons]uint64
+	latency  [len(latencyBuckets) + 1]uint64
+}
+
+// record counts the outcome of a processed transaction along with the time it
+// took to process it.
+func (s *txPoolStats) record(accepted []*types.TxDesc, err error, elapsed time.Duration) {
+	switch {
+	case err != nil:
+		reason := rejectReason(err)
+		atomic.AddUint64(&s.rejected[reason], 1)
+		rejectedCounters[reason].Inc(1)
+
+	case len(accepted) == 0:
+		atomic.AddUint64(&s.orphaned, 1)
+		orphanedCounter.Inc(1)
+
+	default:
+		atomic.AddUint64(&s.accepted, 1)
+		acceptedCounter.Inc(1)
+	}
+
+	bucket := len(latencyBuckets)
+	for i, bound := range latencyBuckets {
+		if elapsed < bound {
+			bucket = i
+			break
+		}
+	}
+	atomic.AddUint64(&s.latency[bucket], 1)
+	latencyTimer.Update(elapsed)
+}
+
+// rejectReason classifies an error returned while processing a transaction.
+func rejectReason(err error) RejectReason {
+	// Pull the underlying error out of a RuleError.
+	if rerr, ok := err.(RuleError); ok {
+		err = rerr.Err
+	}
+
+	switch err := err.(type) {
+	case blockchain.RuleError:
+		return ReasonInvalid
+
+	case TxRuleError:
+		if err.Reason != ReasonOther {
+			return err.Reason
+		}
+		switch err.RejectCode {
+		case message.RejectInsufficientFee:
+			return ReasonFeeTooLow
+		case message.RejectNonstandard, message.RejectDust:
+			return ReasonNonStandard
+		case message.RejectDuplicate:
+			return ReasonDuplicate
+		case message.RejectInvalid:
+			return ReasonInvalid
+		}
+	}
+	return ReasonOther
+}
+
+// LatencyBucket is a bucket of the acceptance latency histogram.
+type LatencyBucket struct {
+	// Bound is the upper bound of the bucket, zero for the last bucket
+	// which is unbounded.
+	Bound time.Duration
+
+	// Count is the number of transactions processed within the bounds of
+	// the bucket.
+	Count uint64
+}
+
+// Stats is a snapshot of the pool statistics.
+type Stats struct {
+	Accepted uint64
+	Orphaned uint64
+	Rejected map[RejectReason]uint64
+	Latency  []LatencyBucket
+}
+
+// Stats returns the number of transactions accepted, orphaned and rejected
+// by reason since the pool was created, as well as the histogram of the time
+// it took to process them.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) Stats() *Stats {
+	s := &mp.stats
+	stats := &Stats{
+		Accepted: atomic.LoadUint64(&s.accepted),
+		Orphaned: atomic.LoadUint64(&s.orphaned),
+		Rejected: make(map[RejectReason]uint64, numRejectReasons),
+		Latency:  make([]LatencyBucket, len(s.latency)),
+	}
+	for r := RejectReason(0); r < numRejectReasons; r++ {
+		stats.Rejected[r] = atomic.LoadUint64(&s.rejected[r])
+	}
+	for i := range s.latency {
+		stats.Latency[i].Count = atomic.LoadUint64(&s.latency[i])
+		if i < len(latencyBuckets) {
+			stats.Latency[i].Bound = latencyBuckets[i]
+		}
+	}
+	return stats
+}
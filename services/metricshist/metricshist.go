@@ -0,0 +1,289 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+// Package metricshist periodically takes a snapshot of the key metrics of the
+// node, such as the number of tips, the size of the memory pool, the number of
+// peers and the block rate, and keeps the latest ones in a ring buffer of the
+// database.  After an incident, operators can reconstruct its timeline from
+// the history even without an external monitoring.
+package metricshist
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/database"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// snapshotInterval is the interval between two snapshots of the metrics.
+const snapshotInterval = time.Minute
+
+// metricsHistoryBucketName is the name of the db bucket used to house the
+// ring buffer of the snapshots.
+var metricsHistoryBucketName = []byte("metricshistory")
+
+// -----------------------------------------------------------------------------
+// The history is a bucket used as a ring buffer of Size slots.
+//
+// The key is the slot of the snapshot, which is its sequence number modulo the
+// size, as a big endian uint32.  The value is the JSON encoding of the
+// snapshot.  A snapshot overwrites the oldest one once the ring is full.
+// -----------------------------------------------------------------------------
+
+// Metrics are the values of the metrics collected from the node.
+type Metrics struct {
+	Tips       uint `json:"tips"`
+	MempoolTxs uint `json:"mempooltxs"`
+	Peers      uint `json:"peers"`
+	Blocks     uint `json:"blocks"`
+}
+
+// Snapshot is the metrics of the node at a given time.  BlockRate is the
+// number of blocks per minute added since the previous snapshot.
+type Snapshot struct {
+	Seq       uint64  `json:"seq"`
+	Time      int64   `json:"time"`
+	BlockRate float64 `json:"blockrate"`
+	Metrics
+}
+
+// Config is the configuration of the metrics history.
+type Config struct {
+	// Size is the number of snapshots kept in the history.
+	Size uint
+
+	// DB is the database the history is stored in.
+	DB database.DB
+
+	// Collect returns the current values of the metrics.
+	Collect func() Metrics
+}
+
+// History periodically takes a snapshot of the metrics of the node and stores
+// it in the database.
+type History struct {
+	started  int32
+	shutdown int32
+
+	cfg Config
+
+	lock sync.Mutex
+	ring *ring
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// New returns the metrics history stored in the database, creating it when it
+// does not exist.  Use Start to begin taking snapshots.
+func New(cfg *Config) (*History, error) {
+	if cfg.Size == 0 {
+		return nil, fmt.Errorf("the metrics history must keep at least one snapshot")
+	}
+	h := &History{
+		cfg:  *cfg,
+		ring: newRing(cfg.Size),
+		quit: make(chan struct{}),
+	}
+	err := cfg.DB.Update(func(dbTx database.Tx) error {
+		bucket, err := dbTx.Metadata().CreateBucketIfNotExists(metricsHistoryBucketName)
+		if err != nil {
+			return err
+		}
+		var stale [][]byte
+		err = bucket.ForEach(func(k, v []byte) error {
+			var snap Snapshot
+			err := json.Unmarshal(v, &snap)
+			if err != nil {
+				return database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("failed to "+
+						"deserialize metrics snapshot %x: %v", k, err),
+				}
+			}
+			h.ring.load(&snap)
+			stale = append(stale, k)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		// The slots are rewritten from the ring, so that the history
+		// is still a ring buffer after the size changed.
+		for _, k := range stale {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		for _, snap := range h.ring.latest(0) {
+			if err := putSnapshot(bucket, cfg.Size, snap); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Metrics history is enabled", "snapshots", h.ring.len(), "size", cfg.Size)
+	return h, nil
+}
+
+// Start begins taking snapshots of the metrics.
+func (h *History) Start() {
+	if atomic.AddInt32(&h.started, 1) != 1 {
+		return
+	}
+	h.wg.Add(1)
+	go h.handler()
+}
+
+// Stop stops taking snapshots and waits for the history to exit.  A last
+// snapshot is taken, so that the timeline ends at the shutdown of the node.
+func (h *History) Stop() {
+	if atomic.AddInt32(&h.shutdown, 1) != 1 {
+		return
+	}
+	close(h.quit)
+	h.wg.Wait()
+	h.snapshot(time.Now())
+}
+
+// Latest returns at most count of the latest snapshots, from the oldest to the
+// newest, or all of them when count is zero.
+//
+// This function is safe for concurrent access.
+func (h *History) Latest(count uint) []Snapshot {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	snaps := h.ring.latest(count)
+	result := make([]Snapshot, 0, len(snaps))
+	for _, snap := range snaps {
+		result = append(result, *snap)
+	}
+	return result
+}
+
+// Size returns the number of snapshots kept in the history.
+func (h *History) Size() uint {
+	return h.cfg.Size
+}
+
+func (h *History) handler() {
+	defer h.wg.Done()
+
+	ticker := time.NewTicker(snapshotInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			h.snapshot(now)
+		case <-h.quit:
+			return
+		}
+	}
+}
+
+// snapshot takes a snapshot of the metrics and stores it in the database.
+func (h *History) snapshot(now time.Time) {
+	metrics := h.cfg.Collect()
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	snap := h.ring.add(now, metrics)
+	err := h.cfg.DB.Update(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(metricsHistoryBucketName)
+		return putSnapshot(bucket, h.cfg.Size, snap)
+	})
+	if err != nil {
+		log.Error("Failed to store the metrics snapshot", "seq", snap.Seq, "error", err)
+	}
+}
+
+func putSnapshot(bucket database.Bucket, size uint, snap *Snapshot) error {
+	value, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	var key [4]byte
+	binary.BigEndian.PutUint32(key[:], uint32(snap.Seq%uint64(size)))
+	return bucket.Put(key[:], value)
+}
+
+// ring is the in memory copy of the snapshots of the history.
+type ring struct {
+	snaps []*Snapshot
+	next  uint64
+}
+
+func newRing(size uint) *ring {
+	return &ring{snaps: make([]*Snapshot, size)}
+}
+
+func (r *ring) slot(seq uint64) int {
+	return int(seq % uint64(len(r.snaps)))
+}
+
+// load puts a snapshot read from the database into the ring, unless a more
+// recent snapshot already holds its slot.
+func (r *ring) load(snap *Snapshot) {
+	i := r.slot(snap.Seq)
+	if r.snaps[i] == nil || r.snaps[i].Seq < snap.Seq {
+		r.snaps[i] = snap
+	}
+	if snap.Seq >= r.next {
+		r.next = snap.Seq + 1
+	}
+}
+
+// add records a new snapshot of the metrics in the ring, replacing the oldest
+// one when it is full, and returns it.
+func (r *ring) add(now time.Time, metrics Metrics) *Snapshot {
+	snap := &Snapshot{Seq: r.next, Time: now.Unix(), Metrics: metrics}
+	if r.next > 0 {
+		prev := r.snaps[r.slot(r.next-1)]
+		if prev != nil && prev.Seq == r.next-1 && snap.Time > prev.Time &&
+			snap.Blocks >= prev.Blocks {
+			minutes := float64(snap.Time-prev.Time) / 60
+			snap.BlockRate = float64(snap.Blocks-prev.Blocks) / minutes
+		}
+	}
+	r.snaps[r.slot(r.next)] = snap
+	r.next++
+	return snap
+}
+
+// len returns the number of snapshots in the ring.
+func (r *ring) len() int {
+	n := 0
+	for _, snap := range r.snaps {
+		if snap != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// latest returns at most count of the latest snapshots of the ring, from the
+// oldest to the newest, or all of them when count is zero.
+func (r *ring) latest(count uint) []*Snapshot {
+	n := uint64(len(r.snaps))
+	if count > 0 && uint64(count) < n {
+		n = uint64(count)
+	}
+	if n > r.next {
+		n = r.next
+	}
+	result := make([]*Snapshot, 0, n)
+	for seq := r.next - n; seq < r.next; seq++ {
+		snap := r.snaps[r.slot(seq)]
+		if snap != nil && snap.Seq == seq {
+			result = append(result, snap)
+		}
+	}
+	return result
+}
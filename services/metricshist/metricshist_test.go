@@ -0,0 +1,46 @@
+package metricshist
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRing(t *testing.T) {
+	r := newRing(3)
+	if snaps := r.latest(0); len(snaps) != 0 {
+		t.Fatalf("%d snapshots in an empty ring", len(snaps))
+	}
+
+	start := time.Unix(1600000000, 0)
+	for i := 0; i < 5; i++ {
+		r.add(start.Add(time.Duration(i)*time.Minute), Metrics{Blocks: uint(i * 4)})
+	}
+	snaps := r.latest(0)
+	if len(snaps) != 3 || r.len() != 3 {
+		t.Fatalf("expected 3 snapshots, got %d", len(snaps))
+	}
+	for i, snap := range snaps {
+		if snap.Seq != uint64(i+2) {
+			t.Fatalf("snapshot %d has seq %d", i, snap.Seq)
+		}
+		if snap.BlockRate != 4 {
+			t.Fatalf("snapshot %d has block rate %f", i, snap.BlockRate)
+		}
+	}
+	if snaps := r.latest(2); len(snaps) != 2 || snaps[0].Seq != 3 {
+		t.Fatalf("unexpected latest snapshots %v", snaps)
+	}
+
+	// a smaller ring loaded from the snapshots keeps the latest ones
+	small := newRing(2)
+	for _, snap := range snaps {
+		small.load(snap)
+	}
+	snap := small.add(start.Add(5*time.Minute), Metrics{Blocks: 18})
+	if snap.Seq != 5 || snap.BlockRate != 2 {
+		t.Fatalf("unexpected snapshot %+v", snap)
+	}
+	if snaps := small.latest(0); len(snaps) != 2 || snaps[0].Seq != 4 {
+		t.Fatalf("unexpected snapshots of the smaller ring %v", snaps)
+	}
+}
@@ -71,10 +71,20 @@ func NewPublicMinerAPI(c *CPUMiner) *PublicMinerAPI {
 }
 
 //func (api *PublicMinerAPI) GetBlockTemplate(request *mining.TemplateRequest) (interface{}, error){
-func (api *PublicMinerAPI) GetBlockTemplate(capabilities []string, powType byte) (interface{}, error) {
+
+// GetBlockTemplate returns a block template of the pow type.  The optional
+// includeTxs and excludeTxs are the hashes of the transactions forced into or
+// kept out of the template, in addition to the ones of the configuration.
+func (api *PublicMinerAPI) GetBlockTemplate(capabilities []string, powType byte, includeTxs *[]string, excludeTxs *[]string) (interface{}, error) {
 	// Set the default mode and override it if supplied.
 	mode := "template"
 	request := json.TemplateRequest{Mode: mode, Capabilities: capabilities, PowType: powType}
+	if includeTxs != nil {
+		request.IncludeTxs = *includeTxs
+	}
+	if excludeTxs != nil {
+		request.ExcludeTxs = *excludeTxs
+	}
 	switch mode {
 	case "template":
 		return handleGetBlockTemplateRequest(api, &request)
@@ -119,7 +129,7 @@ func (api *PublicMinerAPI) SubmitBlock(hexBlock string) (interface{}, error) {
 	block.SetHeight(height)
 	// Process this block using the same rules as blocks coming from other
 	// nodes.  This will in turn relay it to the network like normal.
-	isOrphan, err := api.miner.blockManager.ProcessBlock(block, blockchain.BFNone)
+	isOrphan, err := api.miner.blockManager.ProcessMinedBlock(block)
 	if err != nil {
 		// Anything other than a rule violation is an unexpected error,
 		// so log that error as an internal error.
@@ -173,6 +183,7 @@ func handleGetBlockTemplateRequest(api *PublicMinerAPI, request *json.TemplateRe
 	// the request.  Default to only providing a coinbase value.
 	useCoinbaseValue := true
 	var powtyp byte
+	var txSelection *mining.TxSelection
 	if request != nil {
 		var hasCoinbaseValue, hasCoinbaseTxn bool
 		for _, capability := range request.Capabilities {
@@ -188,6 +199,12 @@ func handleGetBlockTemplateRequest(api *PublicMinerAPI, request *json.TemplateRe
 			useCoinbaseValue = false
 		}
 		powtyp = request.PowType
+
+		var err error
+		txSelection, err = mining.NewTxSelection(request.IncludeTxs, request.ExcludeTxs)
+		if err != nil {
+			return nil, rpc.RpcInvalidError("%s", err.Error())
+		}
 	}
 
 	// When a coinbase transaction has been requested, respond with an error
@@ -205,6 +222,9 @@ func handleGetBlockTemplateRequest(api *PublicMinerAPI, request *json.TemplateRe
 		return nil, rpc.RPCClientInInitialDownloadError("Client in initial download ",
 			"qitmeer is downloading blocks...")
 	}
+	if api.miner.blockManager.IsSafeMode() {
+		return nil, rpc.RPCSafeModeError()
+	}
 
 	// Protect concurrent access when updating block templates.
 	state := api.gbtWorkState
@@ -216,7 +236,7 @@ func handleGetBlockTemplateRequest(api *PublicMinerAPI, request *json.TemplateRe
 	// in the memory pool have been updated and it has been at least five
 	// seconds since the last template was generated.  Otherwise, the
 	// timestamp for the existing block template is updated .
-	if err := state.updateBlockTemplate(api, useCoinbaseValue, powtyp); err != nil {
+	if err := state.updateBlockTemplate(api, useCoinbaseValue, powtyp, txSelection); err != nil {
 		return nil, err
 	}
 	return state.blockTemplateResult(api, useCoinbaseValue, nil)
@@ -239,6 +259,10 @@ type gbtWorkState struct {
 	minTimestamp  time.Time
 	template      *types.BlockTemplate
 	timeSource    blockchain.MedianTimeSource
+
+	// txSelection is the selection of transactions the template was
+	// generated with, including the one of the policy.
+	txSelection *mining.TxSelection
 }
 
 // updateBlockTemplate creates or updates a block template for the work state.
@@ -250,11 +274,13 @@ type gbtWorkState struct {
 // useCoinbaseValue flag is false and the existing block template does not
 // already contain a valid payment address, the block template will be updated
 // with a randomly selected payment address from the list of configured
-// addresses.
+// addresses.  The txSelection of the request is added to the one of the
+// policy, and a new template is generated when the result changed.
 //
 // This function MUST be called with the state locked.
-func (state *gbtWorkState) updateBlockTemplate(api *PublicMinerAPI, useCoinbaseValue bool, powType byte) error {
+func (state *gbtWorkState) updateBlockTemplate(api *PublicMinerAPI, useCoinbaseValue bool, powType byte, txSelection *mining.TxSelection) error {
 	m := api.miner
+	txSelection = m.policy.TxSelection.Merge(txSelection)
 	lastTxUpdate := m.txSource.LastUpdated()
 	if lastTxUpdate.IsZero() {
 		lastTxUpdate = roughtime.Now()
@@ -272,6 +298,7 @@ func (state *gbtWorkState) updateBlockTemplate(api *PublicMinerAPI, useCoinbaseV
 	if template == nil || state.parentsSet == nil ||
 		!state.parentsSet.IsEqual(parentsSet) ||
 		state.template.Block.Header.Pow.GetPowType() != pow.PowType(powType) ||
+		!state.txSelection.IsEqual(txSelection) ||
 		(state.lastTxUpdate != lastTxUpdate &&
 			roughtime.Now().After(state.lastGenerated.Add(time.Second*
 				gbtRegenerateSeconds))) {
@@ -295,7 +322,13 @@ func (state *gbtWorkState) updateBlockTemplate(api *PublicMinerAPI, useCoinbaseV
 		// block template doesn't include the coinbase, so the caller
 		// will ultimately create their own coinbase which pays to the
 		// appropriate address(es).
-		template, err := mining.NewBlockTemplate(m.policy, m.params, m.sigCache, m.txSource, m.timeSource, m.blockManager, payToAddr, nil, pow.PowType(powType))
+		policy := m.policy
+		if txSelection != policy.TxSelection {
+			p := *m.policy
+			p.TxSelection = txSelection
+			policy = &p
+		}
+		template, err := mining.NewBlockTemplate(policy, m.params, m.sigCache, m.txSource, m.timeSource, m.blockManager, payToAddr, nil, pow.PowType(powType))
 		if err != nil {
 			return rpc.RpcInvalidError("Failed to create new block template: %s", err.Error())
 		}
@@ -315,6 +348,7 @@ func (state *gbtWorkState) updateBlockTemplate(api *PublicMinerAPI, useCoinbaseV
 		state.lastTxUpdate = lastTxUpdate
 		state.parentsSet.AddList(msgBlock.Parents)
 		state.minTimestamp = minTimestamp
+		state.txSelection = txSelection
 
 		log.Debug(fmt.Sprintf("Generated block template (timestamp %v, "+
 			"target %s, merkle root %s)",
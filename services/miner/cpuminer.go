@@ -127,6 +127,13 @@ func (m *CPUMiner) GenerateNBlocks(n uint32, powType pow.PowType) ([]*hash.Hash,
 			", as it's unlikely to be possible to CPU-mine a block.")
 	}
 
+	// Respond with an error while mining is paused by the safe mode.
+	if m.blockManager.IsSafeMode() {
+		m.Unlock()
+		return nil, errors.New("the node is in safe mode, mining is paused " +
+			"until the safe mode is acknowledged")
+	}
+
 	// Respond with an error if server is already mining.
 	if m.started || m.discreteMining {
 		m.Unlock()
@@ -363,7 +370,7 @@ func (m *CPUMiner) submitBlock(block *types.SerializedBlock) bool {
 
 	// Process this block using the same rules as blocks coming from other
 	// nodes. This will in turn relay it to the network like normal.
-	isOrphan, err := m.blockManager.ProcessBlock(block, blockchain.BFNone)
+	isOrphan, err := m.blockManager.ProcessMinedBlock(block)
 	if err != nil {
 		// Anything other than a rule violation is an unexpected error,
 		// so log that error as an internal error.
@@ -628,6 +635,11 @@ out:
 			m.submitBlockLock.Unlock()
 			continue
 		}
+		if m.blockManager.IsSafeMode() {
+			log.Trace("Node in safe mode, mining is paused")
+			m.submitBlockLock.Unlock()
+			continue
+		}
 		// Create a new block template using the available transactions
 		// in the memory pool as a source of transactions to potentially
 		// include in the block.
@@ -719,6 +731,13 @@ func (m *CPUMiner) GenerateBlockByParents(parents []*hash.Hash) (*hash.Hash, err
 			", as it's unlikely to be possible to CPU-mine a block.")
 	}
 
+	// Respond with an error while mining is paused by the safe mode.
+	if m.blockManager.IsSafeMode() {
+		m.Unlock()
+		return nil, errors.New("the node is in safe mode, mining is paused " +
+			"until the safe mode is acknowledged")
+	}
+
 	// Respond with an error if server is already mining.
 	if m.started || m.discreteMining {
 		m.Unlock()
@@ -0,0 +1,141 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+
+package miner
+
+import (
+	"encoding/hex"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/json"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/rpc"
+)
+
+// GetBlockTemplateWitness returns the current block template of the pow type
+// together with the data needed to check its fees offline: the outputs spent
+// by each of its transactions, as found in the utxo set or in the template
+// itself, and the transactions of the memory pool with their fees, so that a
+// pool operator can check which of them were left out of the template.
+func (api *PublicMinerAPI) GetBlockTemplateWitness(powType byte) (interface{}, error) {
+	bc := api.miner.blockManager.GetChain()
+	currentOrder := bc.BestSnapshot().GraphState.GetTotal() - 1
+	if currentOrder != 0 && !api.miner.blockManager.IsCurrent() {
+		return nil, rpc.RPCClientInInitialDownloadError("Client in initial download ",
+			"qitmeer is downloading blocks...")
+	}
+
+	state := api.gbtWorkState
+	state.Lock()
+	defer state.Unlock()
+	if err := state.updateBlockTemplate(api, true, powType, state.txSelection); err != nil {
+		return nil, err
+	}
+	template := state.template
+	msgBlock := template.Block
+
+	parents := make([]string, 0, len(msgBlock.Parents))
+	for _, v := range msgBlock.Parents {
+		parents = append(parents, v.String())
+	}
+	coinbase, err := msgBlock.Transactions[0].Serialize()
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to serialize transaction")
+	}
+	blockFeesMap := map[int]int64{}
+	for coinid, val := range template.BlockFeesMap {
+		blockFeesMap[int(coinid)] = val
+	}
+
+	// The outputs created by the template can be spent by its later
+	// transactions, so they are looked up before the utxo set.
+	templateTxs := make(map[hash.Hash]*types.Transaction, len(msgBlock.Transactions))
+	included := make(map[hash.Hash]struct{}, len(msgBlock.Transactions))
+	transactions := make([]json.TemplateWitnessTx, 0, len(msgBlock.Transactions)-1)
+	for i, tx := range msgBlock.Transactions {
+		txHash := tx.TxHash()
+		templateTxs[txHash] = tx
+		included[txHash] = struct{}{}
+
+		// Skip the coinbase transaction.
+		if i == 0 {
+			continue
+		}
+		txBuf, err := tx.Serialize()
+		if err != nil {
+			return nil, rpc.RpcInternalError(err.Error(), "Failed to serialize transaction")
+		}
+		witnessTx := json.TemplateWitnessTx{
+			Hash:   txHash.String(),
+			Data:   hex.EncodeToString(txBuf),
+			Fee:    template.Fees[i],
+			Inputs: []json.TemplateWitnessInput{},
+		}
+		// The inputs of the token transactions do not spend outputs.
+		if !types.IsTokenTx(tx) {
+			for _, txIn := range tx.TxIn {
+				input, err := witnessInput(api, templateTxs, txIn.PreviousOut)
+				if err != nil {
+					return nil, err
+				}
+				witnessTx.Inputs = append(witnessTx.Inputs, *input)
+			}
+		}
+		transactions = append(transactions, witnessTx)
+	}
+
+	descs := api.miner.txSource.MiningDescs()
+	mempool := make([]json.TemplateWitnessMempoolTx, 0, len(descs))
+	for _, desc := range descs {
+		_, ok := included[*desc.Tx.Hash()]
+		mempool = append(mempool, json.TemplateWitnessMempoolTx{
+			Hash:     desc.Tx.Hash().String(),
+			Size:     desc.Tx.Tx.SerializeSize(),
+			Fee:      desc.Fee,
+			FeePerKB: desc.FeePerKB,
+			Included: ok,
+		})
+	}
+
+	return &json.BlockTemplateWitnessResult{
+		Height:        int64(template.Height),
+		PreviousHash:  msgBlock.Header.ParentRoot.String(),
+		Parents:       parents,
+		CoinbaseTxn:   hex.EncodeToString(coinbase),
+		CoinbaseValue: uint64(msgBlock.Transactions[0].TxOut[0].Amount.Value),
+		BlockFeesMap:  blockFeesMap,
+		Transactions:  transactions,
+		Mempool:       mempool,
+	}, nil
+}
+
+// witnessInput returns the output spent by an input of a template transaction.
+func witnessInput(api *PublicMinerAPI, templateTxs map[hash.Hash]*types.Transaction,
+	outpoint types.TxOutPoint) (*json.TemplateWitnessInput, error) {
+	input := &json.TemplateWitnessInput{
+		TxID: outpoint.Hash.String(),
+		Vout: outpoint.OutIndex,
+	}
+	if tx, ok := templateTxs[outpoint.Hash]; ok {
+		if int(outpoint.OutIndex) >= len(tx.TxOut) {
+			return nil, rpc.RpcInvalidError("Output %v is unknown", outpoint)
+		}
+		txOut := tx.TxOut[outpoint.OutIndex]
+		input.Amount = uint64(txOut.Amount.Value)
+		input.CoinId = uint16(txOut.Amount.Id)
+		input.PkScript = hex.EncodeToString(txOut.PkScript)
+		input.InTemplate = true
+		return input, nil
+	}
+	entry, err := api.miner.blockManager.GetChain().FetchUtxoEntry(outpoint)
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to fetch utxo")
+	}
+	if entry == nil || entry.IsSpent() {
+		return nil, rpc.RpcInvalidError("Output %v is spent or unknown", outpoint)
+	}
+	input.Amount = uint64(entry.Amount().Value)
+	input.CoinId = uint16(entry.Amount().Id)
+	input.PkScript = hex.EncodeToString(entry.PkScript())
+	input.BlockHash = entry.BlockHash().String()
+	input.Coinbase = entry.IsCoinBase()
+	return input, nil
+}
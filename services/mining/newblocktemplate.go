@@ -111,6 +111,8 @@ func NewBlockTemplate(policy *Policy, params *params.Params,
 	parentsSet := blockdag.NewHashSet()
 	if parents == nil {
 		parents = blockManager.GetChain().GetMiningTips()
+		parents = filterWithheldTips(parents, policy.TipWithholdingDelay,
+			blockManager.GetChain().BlockDelay)
 		parentsSet.AddList(parents)
 		nextBlockHeight = uint64(blockManager.GetChain().BlockDAG().GetMainChainTip().GetHeight() + 1)
 	} else {
@@ -172,6 +174,13 @@ func NewBlockTemplate(policy *Policy, params *params.Params,
 	tokenSigOpCost := int64(0)
 	tokenSize := uint32(0)
 
+	// The block timestamp is chosen before the transactions, so that their
+	// lock times are checked against the time the consensus rules use.
+	ts := MedianAdjustedTime(blockManager.GetChain(), timeSource)
+	if mt := blockManager.GetChain().CalcParentsMedianTime(parents); ts.Before(mt) {
+		ts = mt
+	}
+
 	log.Debug("Inclusion to new block", "transactions", len(sourceTxns))
 mempoolLoop:
 	for _, txDesc := range sourceTxns {
@@ -182,6 +191,10 @@ mempoolLoop:
 			log.Trace(fmt.Sprintf("Skipping coinbase tx %s", tx.Hash()))
 			continue
 		}
+		if policy.TxSelection.IsExcluded(tx.Hash()) {
+			log.Trace(fmt.Sprintf("Skipping excluded tx %s", tx.Hash()))
+			continue
+		}
 		if types.IsTokenTx(tx.Tx) {
 			log.Trace(fmt.Sprintf("Skipping token tx %s", tx.Hash()))
 			blockTxns = append(blockTxns, tx)
@@ -192,8 +205,7 @@ mempoolLoop:
 			tokenSize += uint32(tx.Transaction().SerializeSize())
 			continue
 		}
-		if !blockchain.IsFinalizedTransaction(tx, nextBlockHeight,
-			timeSource.AdjustedTime()) {
+		if !blockchain.IsFinalizedTransaction(tx, nextBlockHeight, ts) {
 
 			log.Trace(fmt.Sprintf("Skipping non-finalized tx %s", tx.Hash()))
 			continue
@@ -214,7 +226,10 @@ mempoolLoop:
 		// Setup dependencies for any transactions which reference
 		// other transactions in the mempool so they can be properly
 		// ordered below.
-		weirandItem := &WeightedRandTx{tx: tx}
+		weirandItem := &WeightedRandTx{
+			tx:     tx,
+			forced: policy.TxSelection.IsIncluded(tx.Hash()),
+		}
 		for _, txIn := range tx.Tx.TxIn {
 			originHash := &txIn.PreviousOut.Hash
 			entry := utxos.LookupEntry(txIn.PreviousOut)
@@ -258,6 +273,11 @@ mempoolLoop:
 		weirandItem.feePerKB = txDesc.FeePerKB
 		weirandItem.fee = txDesc.Fee
 
+		// Transactions which waited long in the source pool gain
+		// selection weight so they are not starved by higher fees.
+		weirandItem.ageBonus = calcAgeBonus(policy, tx.Tx.SerializeSize(),
+			txDesc.Height, nextBlockHeight)
+
 		// Add the transaction to the priority queue to mark it ready
 		// for inclusion in the block unless it has dependencies.
 		if weirandItem.dependsOn == nil {
@@ -313,8 +333,8 @@ mempoolLoop:
 		}
 
 		// Skip free transactions once the block is larger than the
-		// minimum block size.
-		if sortedByFee &&
+		// minimum block size, unless they aged or are forced.
+		if sortedByFee && weirandItem.ageBonus == 0 && !weirandItem.forced &&
 			weirandItem.feePerKB < int64(policy.TxMinFreeFee) &&
 			(blockPlusTxSize >= policy.BlockMinSize) {
 			log.Trace(fmt.Sprintf("Skipping tx %s with feePerKB %.2d "+
@@ -344,6 +364,18 @@ mempoolLoop:
 			continue
 		}
 
+		// Skip the transactions spending frozen coins, which may have
+		// been frozen after they were accepted into the source pool.
+		if policy.FreezeList != nil {
+			err = policy.FreezeList.CheckTransaction(tx, blockUtxos)
+			if err != nil {
+				log.Trace(fmt.Sprintf("Skipping tx %s: %v",
+					tx.Hash(), err))
+				logSkippedDeps(tx, deps)
+				continue
+			}
+		}
+
 		// Spend the transaction inputs in the block utxo view and add
 		// an entry for it to ensure any transactions which reference
 		// this one have it available as an input and can ensure they
@@ -393,8 +425,6 @@ mempoolLoop:
 		return nil, miningRuleError(ErrCreatingCoinbase, err.Error())
 	}
 
-	ts := MedianAdjustedTime(blockManager.GetChain(), timeSource)
-
 	//
 	reqCompactDifficulty, err := blockManager.GetChain().CalcNextRequiredDifficulty(ts, powType)
 	if err != nil {
@@ -466,10 +496,11 @@ mempoolLoop:
 
 // UpdateBlockTime updates the timestamp in the header of the passed block to
 // the current time while taking into account the median time of the last
-// several blocks to ensure the new time is after that time per the chain
-// consensus rules.  Finally, it will update the target difficulty if needed
-// based on the new time for the test networks since their target difficulty can
-// change based upon time.
+// several blocks and of the parents of the block to ensure the new time is
+// after the former and not before the latter per the chain consensus rules.
+// Finally, it will update the target difficulty if needed based on the new time
+// for the test networks since their target difficulty can change based upon
+// time.
 func UpdateBlockTime(msgBlock *types.Block, chain *blockchain.BlockChain, timeSource blockchain.MedianTimeSource,
 	activeNetParams *params.Params) error {
 
@@ -477,6 +508,9 @@ func UpdateBlockTime(msgBlock *types.Block, chain *blockchain.BlockChain, timeSo
 	// the median time of the last several blocks per the chain consensus
 	// rules.
 	newTimestamp := MedianAdjustedTime(chain, timeSource)
+	if mt := chain.CalcParentsMedianTime(msgBlock.Parents); newTimestamp.Before(mt) {
+		newTimestamp = mt
+	}
 	msgBlock.Header.Timestamp = newTimestamp
 
 	// If running on a network that requires recalculating the difficulty,
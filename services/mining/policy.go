@@ -8,6 +8,8 @@ package mining
 
 import (
 	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/services/mempool"
+	"time"
 )
 
 // Policy houses the policy (configuration parameters) which is used to control
@@ -31,6 +33,17 @@ type Policy struct {
 	// (block template generation).
 	TxMinFreeFee int64
 
+	// TxAgingBlocks is the number of blocks a transaction waits in the
+	// source pool before it gains selection weight as if it paid another
+	// TxMinFreeFee per kilobyte.  The weight is gained again every
+	// TxAgingBlocks blocks, at most TxAgingMaxSteps times.  Aging is
+	// disabled when it is zero.
+	TxAgingBlocks uint32
+
+	// TxAgingMaxSteps bounds the selection weight a waiting transaction
+	// gains by aging.
+	TxAgingMaxSteps uint32
+
 	// StandardVerifyFlags defines the function to retrieve the flags to
 	// use for verifying scripts for the block after the current best block.
 	// It must set the verification flags properly depending on the result
@@ -38,4 +51,20 @@ type Policy struct {
 	//
 	// This function must be safe for concurrent access.
 	StandardVerifyFlags func() (txscript.ScriptFlags, error)
+
+	// FreezeList holds the coins which the transactions of the templates
+	// may not spend on a private network.  It is nil when the freeze list
+	// is disabled.
+	FreezeList *mempool.FreezeList
+
+	// TxSelection holds the transactions forced into or kept out of the
+	// templates.  It is nil when there are none.
+	TxSelection *TxSelection
+
+	// TipWithholdingDelay is the delay after its timestamp beyond which a
+	// block received is considered withheld by its miner.  The tips which
+	// were withheld or extend withheld blocks are left out of the
+	// templates, except for the main chain tip.  The policy is disabled
+	// when it is zero.
+	TipWithholdingDelay time.Duration
 }
@@ -0,0 +1,70 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mining
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/log"
+	"github.com/Qitmeer/qitmeer/metrics"
+	"time"
+)
+
+var (
+	selectedTipsCounter = metrics.NewCounter("mining/tips/selected")
+	withheldTipsCounter = metrics.NewCounter("mining/tips/withheld")
+)
+
+// blockDelayFunc returns how long after its timestamp a block was received
+// along with its parents, or false when it is unknown.
+type blockDelayFunc func(h *hash.Hash) (time.Duration, []*hash.Hash, bool)
+
+// isWithheldBlock returns whether the block was received more than the delay
+// after its timestamp, as the blocks withheld by their miners are.
+func isWithheldBlock(h *hash.Hash, delay time.Duration, blockDelay blockDelayFunc) bool {
+	d, _, ok := blockDelay(h)
+	return ok && d > delay
+}
+
+// isWithheldTip returns whether the tip was withheld by its miner or extends a
+// withheld block.
+func isWithheldTip(h *hash.Hash, delay time.Duration, blockDelay blockDelayFunc) bool {
+	d, parents, ok := blockDelay(h)
+	if !ok {
+		return false
+	}
+	if d > delay {
+		return true
+	}
+	for _, parent := range parents {
+		if isWithheldBlock(parent, delay, blockDelay) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterWithheldTips returns the tips leaving out those which were withheld by
+// their miners or which extend withheld blocks, so that the templates do not
+// merge the withheld branches and give them weight, as a selfish miner
+// releasing its blocks late expects.  The first tip, which is the main chain
+// tip, is always kept since the main chain is decided by the consensus.  The
+// tips are returned as they are when the delay is zero.
+func filterWithheldTips(tips []*hash.Hash, delay time.Duration, blockDelay blockDelayFunc) []*hash.Hash {
+	if delay <= 0 || len(tips) == 0 {
+		return tips
+	}
+	selected := []*hash.Hash{tips[0]}
+	for _, tip := range tips[1:] {
+		if isWithheldTip(tip, delay, blockDelay) {
+			log.Debug("Leaving out withheld tip of the block template",
+				"hash", tip)
+			continue
+		}
+		selected = append(selected, tip)
+	}
+	selectedTipsCounter.Inc(int64(len(selected)))
+	withheldTipsCounter.Inc(int64(len(tips) - len(selected)))
+	return selected
+}
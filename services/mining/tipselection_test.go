@@ -0,0 +1,47 @@
+package mining
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"testing"
+	"time"
+)
+
+func Test_FilterWithheldTips(t *testing.T) {
+	hashes := make([]*hash.Hash, 6)
+	for i := range hashes {
+		hashes[i] = &hash.Hash{byte(i + 1)}
+	}
+	// The withheld main chain tip, a timely tip, a withheld tip, a tip
+	// extending a withheld block, a tip whose delay is unknown and a
+	// withheld block.
+	delays := map[hash.Hash]struct {
+		delay   time.Duration
+		parents []*hash.Hash
+	}{
+		*hashes[0]: {time.Hour, nil},
+		*hashes[1]: {time.Second, nil},
+		*hashes[2]: {time.Minute, nil},
+		*hashes[3]: {time.Second, []*hash.Hash{hashes[5]}},
+		*hashes[5]: {2 * time.Minute, nil},
+	}
+	blockDelay := func(h *hash.Hash) (time.Duration, []*hash.Hash, bool) {
+		d, ok := delays[*h]
+		return d.delay, d.parents, ok
+	}
+	tips := hashes[:5]
+
+	selected := filterWithheldTips(tips, 30*time.Second, blockDelay)
+	expected := []*hash.Hash{hashes[0], hashes[1], hashes[4]}
+	if len(selected) != len(expected) {
+		t.Fatalf("%d tips selected, expected %d", len(selected), len(expected))
+	}
+	for i, h := range selected {
+		if !h.IsEqual(expected[i]) {
+			t.Fatalf("tip %d is %s, expected %s", i, h, expected[i])
+		}
+	}
+
+	if len(filterWithheldTips(tips, 0, blockDelay)) != len(tips) {
+		t.Fatal("tips left out with the policy disabled")
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2017-2018 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mining
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+)
+
+// TxSelection holds the transactions of the source pool which are forced into
+// the block templates, such as accelerated transactions, and the ones which
+// are kept out of them.  It is a policy of the node only: the blocks of the
+// other miners are not checked against it.
+type TxSelection struct {
+	// Include are the transactions selected before all the others, as long
+	// as they fit in the block and are valid.  A transaction depending on
+	// other transactions of the source pool is selected once they are.
+	Include map[hash.Hash]struct{}
+
+	// Exclude are the transactions never selected, together with the
+	// transactions depending on them.  Exclusion takes precedence over
+	// inclusion.
+	Exclude map[hash.Hash]struct{}
+}
+
+// NewTxSelection returns the selection of the transactions with the passed
+// hashes, or nil when both lists are empty.
+func NewTxSelection(include []string, exclude []string) (*TxSelection, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil, nil
+	}
+	ts := &TxSelection{
+		Include: make(map[hash.Hash]struct{}, len(include)),
+		Exclude: make(map[hash.Hash]struct{}, len(exclude)),
+	}
+	for _, str := range include {
+		h, err := hash.NewHashFromStr(str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid included transaction %s: %v", str, err)
+		}
+		ts.Include[*h] = struct{}{}
+	}
+	for _, str := range exclude {
+		h, err := hash.NewHashFromStr(str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid excluded transaction %s: %v", str, err)
+		}
+		ts.Exclude[*h] = struct{}{}
+	}
+	return ts, nil
+}
+
+// Merge returns the union of the two selections.  Either may be nil.
+func (ts *TxSelection) Merge(other *TxSelection) *TxSelection {
+	if ts == nil {
+		return other
+	}
+	if other == nil {
+		return ts
+	}
+	merged := &TxSelection{
+		Include: make(map[hash.Hash]struct{}, len(ts.Include)+len(other.Include)),
+		Exclude: make(map[hash.Hash]struct{}, len(ts.Exclude)+len(other.Exclude)),
+	}
+	for _, s := range []*TxSelection{ts, other} {
+		for h := range s.Include {
+			merged.Include[h] = struct{}{}
+		}
+		for h := range s.Exclude {
+			merged.Exclude[h] = struct{}{}
+		}
+	}
+	return merged
+}
+
+// IsIncluded returns whether the transaction is forced into the templates.
+func (ts *TxSelection) IsIncluded(h *hash.Hash) bool {
+	if ts == nil || ts.IsExcluded(h) {
+		return false
+	}
+	_, ok := ts.Include[*h]
+	return ok
+}
+
+// IsExcluded returns whether the transaction is kept out of the templates.
+func (ts *TxSelection) IsExcluded(h *hash.Hash) bool {
+	if ts == nil {
+		return false
+	}
+	_, ok := ts.Exclude[*h]
+	return ok
+}
+
+// IsEqual returns whether the two selections hold the same transactions.
+// Either may be nil.
+func (ts *TxSelection) IsEqual(other *TxSelection) bool {
+	if ts == nil || other == nil {
+		return ts == other
+	}
+	return isEqualHashSet(ts.Include, other.Include) &&
+		isEqualHashSet(ts.Exclude, other.Exclude)
+}
+
+func isEqualHashSet(a, b map[hash.Hash]struct{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for h := range a {
+		if _, ok := b[h]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -14,6 +14,14 @@ type WeightedRandTx struct {
 	priority float64
 	feePerKB int64
 
+	// ageBonus is the extra selection weight the transaction gained by
+	// waiting in the source pool.  It is not part of the fee.
+	ageBonus int64
+
+	// forced is whether the transaction is forced into the block by the
+	// TxSelection of the policy.
+	forced bool
+
 	dependsOn map[hash.Hash]struct{}
 }
 
@@ -21,17 +29,25 @@ type WeightedRandTx struct {
 type WeightedRandQueue struct {
 	totalFee int64
 	items    []*WeightedRandTx
+
+	// forced are the forced items, which are popped first in the order
+	// they were pushed.
+	forced []*WeightedRandTx
 }
 
 // The length of WeightedRandQueue
 func (wq *WeightedRandQueue) Len() int {
-	return len(wq.items)
+	return len(wq.items) + len(wq.forced)
 }
 
 // Push item to WeightedRandQueue
 func (wq *WeightedRandQueue) Push(tx *WeightedRandTx) {
+	if tx.forced {
+		wq.forced = append(wq.forced, tx)
+		return
+	}
 	wq.items = append(wq.items, tx)
-	wq.totalFee += tx.fee + 1
+	wq.totalFee += tx.fee + tx.ageBonus + 1
 }
 
 // Pop item from WeightedRandQueue
@@ -39,13 +55,18 @@ func (wq *WeightedRandQueue) Pop() *WeightedRandTx {
 	if wq.Len() <= 0 {
 		return nil
 	}
+	if len(wq.forced) > 0 {
+		item := wq.forced[0]
+		wq.forced = wq.forced[1:]
+		return item
+	}
 	factor := rand.Int63n(wq.totalFee)
 
 	total := int64(0)
 	index := int(0)
 	var item *WeightedRandTx
 	for index, item = range wq.items {
-		total += item.fee
+		total += item.fee + item.ageBonus
 		if total >= factor {
 			break
 		}
@@ -64,3 +85,21 @@ func newWeightedRandQueue(reserve int) *WeightedRandQueue {
 	}
 	return wq
 }
+
+// calcAgeBonus returns the selection weight a transaction of the passed size,
+// which entered the source pool at the given height, gains by aging when it is
+// considered for a block at nextBlockHeight.
+func calcAgeBonus(policy *Policy, txSize int, height int64, nextBlockHeight uint64) int64 {
+	if policy.TxAgingBlocks == 0 {
+		return 0
+	}
+	waited := int64(nextBlockHeight) - height
+	if waited < int64(policy.TxAgingBlocks) {
+		return 0
+	}
+	steps := waited / int64(policy.TxAgingBlocks)
+	if steps > int64(policy.TxAgingMaxSteps) {
+		steps = int64(policy.TxAgingMaxSteps)
+	}
+	return steps * policy.TxMinFreeFee * int64(txSize) / 1000
+}
@@ -2,6 +2,7 @@ package mining
 
 import (
 	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
 	"testing"
 )
 
@@ -18,3 +19,83 @@ func Test_TXWeightedRandom(t *testing.T) {
 		fmt.Println(item.fee)
 	}
 }
+
+func Test_CalcAgeBonus(t *testing.T) {
+	policy := &Policy{
+		TxMinFreeFee:    1000,
+		TxAgingBlocks:   10,
+		TxAgingMaxSteps: 3,
+	}
+	tests := []struct {
+		height          int64
+		nextBlockHeight uint64
+		want            int64
+	}{
+		{height: 100, nextBlockHeight: 100, want: 0},
+		{height: 100, nextBlockHeight: 109, want: 0},
+		{height: 100, nextBlockHeight: 110, want: 500},
+		{height: 100, nextBlockHeight: 125, want: 1000},
+		{height: 100, nextBlockHeight: 1000, want: 1500},
+	}
+	for _, test := range tests {
+		got := calcAgeBonus(policy, 500, test.height, test.nextBlockHeight)
+		if got != test.want {
+			t.Errorf("calcAgeBonus(%d, %d): got %d, want %d",
+				test.height, test.nextBlockHeight, got, test.want)
+		}
+	}
+
+	policy.TxAgingBlocks = 0
+	if got := calcAgeBonus(policy, 500, 0, 1000); got != 0 {
+		t.Errorf("calcAgeBonus with aging disabled: got %d, want 0", got)
+	}
+}
+
+func Test_TXWeightedRandomForced(t *testing.T) {
+	itemQueue := newWeightedRandQueue(4)
+	itemQueue.Push(&WeightedRandTx{fee: 100})
+	itemQueue.Push(&WeightedRandTx{fee: 1, forced: true})
+	itemQueue.Push(&WeightedRandTx{fee: 200})
+	itemQueue.Push(&WeightedRandTx{fee: 2, forced: true})
+
+	for _, want := range []int64{1, 2} {
+		item := itemQueue.Pop()
+		if !item.forced || item.fee != want {
+			t.Fatalf("got fee %d forced %v, want the forced fee %d",
+				item.fee, item.forced, want)
+		}
+	}
+	if itemQueue.Len() != 2 {
+		t.Fatalf("got len %d, want 2", itemQueue.Len())
+	}
+}
+
+func Test_TxSelection(t *testing.T) {
+	const (
+		txA = "0000000000000000000000000000000000000000000000000000000000000001"
+		txB = "0000000000000000000000000000000000000000000000000000000000000002"
+	)
+	ts, err := NewTxSelection([]string{txA, txB}, []string{txB})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := hash.NewHashFromStr(txA)
+	b, _ := hash.NewHashFromStr(txB)
+	if !ts.IsIncluded(a) || ts.IsExcluded(a) {
+		t.Errorf("tx %s should be included", txA)
+	}
+	if ts.IsIncluded(b) || !ts.IsExcluded(b) {
+		t.Errorf("tx %s should be excluded", txB)
+	}
+
+	var none *TxSelection
+	if none.IsIncluded(a) || none.IsExcluded(a) {
+		t.Error("empty selection should neither include nor exclude")
+	}
+	if !none.Merge(ts).IsEqual(ts) || none.IsEqual(ts) {
+		t.Error("merge with the empty selection should be a no-op")
+	}
+	if _, err := NewTxSelection([]string{"zz"}, nil); err == nil {
+		t.Error("invalid hash accepted")
+	}
+}
@@ -2,10 +2,12 @@ package notifymgr
 
 import (
 	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
 	"github.com/Qitmeer/qitmeer/core/types"
 	"github.com/Qitmeer/qitmeer/log"
 	"github.com/Qitmeer/qitmeer/p2p"
 	"github.com/Qitmeer/qitmeer/rpc"
+	"github.com/Qitmeer/qitmeer/services/cmdnotify"
 	"github.com/libp2p/go-libp2p-core/peer"
 )
 
@@ -14,6 +16,7 @@ import (
 type NotifyMgr struct {
 	Server    *p2p.Service
 	RpcServer *rpc.RpcServer
+	CmdNotify *cmdnotify.Notifier
 }
 
 // AnnounceNewTransactions generates and relays inventory vectors and notifies
@@ -35,6 +38,24 @@ func (ntmgr *NotifyMgr) AnnounceNewTransactions(newTxs []*types.TxDesc, filters
 	if ntmgr.RpcServer != nil {
 		ntmgr.RpcServer.NotifyNewTransactions(newTxs)
 	}
+
+	if ntmgr.CmdNotify != nil {
+		for _, tx := range newTxs {
+			ntmgr.CmdNotify.TransactionAccepted(tx.Tx)
+		}
+	}
+}
+
+// AnnounceNewPackage announces the transactions added to the mempool along
+// with the passed package like AnnounceNewTransactions, and relays the package
+// to the peers speaking the package relay protocol, which would otherwise
+// reject the parents paying less than the minimum relay fee on their own.
+func (ntmgr *NotifyMgr) AnnounceNewPackage(txs []*types.Tx, newTxs []*types.TxDesc, filters []peer.ID) {
+	if len(newTxs) <= 0 {
+		return
+	}
+	ntmgr.AnnounceNewTransactions(newTxs, filters)
+	ntmgr.Server.RelayTxPackage(txs, filters)
 }
 
 // RelayInventory relays the passed inventory vector to all connected peers
@@ -47,14 +68,64 @@ func (ntmgr *NotifyMgr) BroadcastMessage(data interface{}) {
 	ntmgr.Server.BroadcastMessage(data)
 }
 
+// AnnounceDoubleSpendProof relays the passed double spend proof to the peers
+// and notifies the websocket clients about it.
+func (ntmgr *NotifyMgr) AnnounceDoubleSpendProof(proof *types.DoubleSpendProof, filters []peer.ID) {
+	ntmgr.Server.RelayDoubleSpendProof(proof, filters)
+
+	if ntmgr.RpcServer != nil {
+		ntmgr.RpcServer.NotifyDoubleSpendProof(proof)
+	}
+}
+
 func (ntmgr *NotifyMgr) AddRebroadcastInventory(newTxs []*types.TxDesc) {
 	for _, tx := range newTxs {
 		ntmgr.Server.Rebroadcast().AddInventory(tx.Tx.Hash(), tx)
 	}
 }
 
+// TransactionEvicted stops rebroadcasting a transaction evicted from the
+// mempool and notifies the websocket clients about it.
+func (ntmgr *NotifyMgr) TransactionEvicted(tx *types.Tx, reason string) {
+	ntmgr.Server.Rebroadcast().RemoveInventory(tx.Hash())
+
+	if ntmgr.RpcServer != nil {
+		ntmgr.RpcServer.NotifyTxEvicted(tx, reason)
+	}
+}
+
+// MinedBlockStale notifies the websocket clients about a block mined by the
+// node which ended up red or moved back in the order.
+func (ntmgr *NotifyMgr) MinedBlockStale(h *hash.Hash, reason string, hints []string) {
+	if ntmgr.RpcServer != nil {
+		ntmgr.RpcServer.NotifyMinedBlockStale(h, reason, hints)
+	}
+}
+
+// MinedBlockFound pushes a block mined by the node to the peers of the mining
+// cluster.
+func (ntmgr *NotifyMgr) MinedBlockFound(block *types.SerializedBlock) {
+	ntmgr.Server.PushClusterBlock(block)
+}
+
+// ClusterTemplateHint notifies the websocket clients about a template hint
+// received from a peer of the mining cluster.
+func (ntmgr *NotifyMgr) ClusterTemplateHint(pid peer.ID, hint []byte) {
+	if ntmgr.RpcServer != nil {
+		ntmgr.RpcServer.NotifyClusterHint(pid.String(), hint)
+	}
+}
+
 // Transaction has one confirmation on the main chain. Now we can mark it as no
 // longer needing rebroadcasting.
 func (ntmgr *NotifyMgr) TransactionConfirmed(tx *types.Tx) {
 	ntmgr.Server.Rebroadcast().RemoveInventory(tx.Hash())
 }
+
+// BlockConnected executes the notification commands for a block connected to
+// the main chain.
+func (ntmgr *NotifyMgr) BlockConnected(block *types.SerializedBlock) {
+	if ntmgr.CmdNotify != nil {
+		ntmgr.CmdNotify.BlockConnected(block)
+	}
+}
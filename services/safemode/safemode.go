@@ -0,0 +1,253 @@
+// Copyright (c) 2017-2018 The qitmeer developers
+
+// Package safemode compares the graph states reported by the peers with the
+// chain of the node and enters a safe mode when many peers stay far ahead of
+// it, which suggests a split of the network or an attack.  In safe mode the
+// miner is paused and the inventory is not relayed to the peers, until the
+// operator acknowledges the alert.
+package safemode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/metrics"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// checkInterval is the interval between two comparisons with the peers.
+	checkInterval = time.Minute
+
+	// confirmChecks is the number of consecutive checks in which the peers
+	// must diverge, without the node catching up with them, to enter the
+	// safe mode.  It keeps a node which is downloading the missing blocks
+	// from entering the safe mode.
+	confirmChecks = 5
+
+	// minDivergingPeers is the minimum number of diverging peers to enter
+	// the safe mode.  They must also be at least a third of the peers.
+	minDivergingPeers = 2
+
+	// acknowledgeGrace is how long the safe mode is not entered again once
+	// acknowledged by the operator.
+	acknowledgeGrace = time.Hour
+
+	// webhookTimeout is the timeout of a webhook alert.
+	webhookTimeout = 10 * time.Second
+)
+
+var safeModeGauge = metrics.NewGauge("p2p/safemode")
+
+// Network is the part of the node watched and controlled by the monitor.
+type Network interface {
+	// MainHeights returns the main height of the chain of the node and the
+	// main heights of the graph states of the connected peers.
+	MainHeights() (uint, []uint)
+
+	// SetSafeMode stops or resumes the relay of the inventory and the
+	// mining.
+	SetSafeMode(safeMode bool)
+}
+
+// Config is the configuration of the safe mode monitor.
+type Config struct {
+	// Divergence is the number of blocks of the main chain by which a peer
+	// must be ahead of the node to diverge.
+	Divergence uint
+
+	// Webhook is an optional URL that receives a HTTP POST with an Alert
+	// whenever the safe mode is entered or left.
+	Webhook string
+
+	// Network is switched into safe mode while the peers diverge.
+	Network Network
+}
+
+// Alert is the json payload sent to the webhook.
+type Alert struct {
+	SafeMode bool   `json:"safemode"`
+	Reason   string `json:"reason"`
+	Time     int64  `json:"time"`
+}
+
+// State is the state of the safe mode.
+type State struct {
+	SafeMode bool
+	Since    time.Time
+	Reason   string
+}
+
+// Monitor periodically compares the graph states of the peers with the chain.
+type Monitor struct {
+	started  int32
+	shutdown int32
+
+	cfg Config
+
+	lock         sync.Mutex
+	state        State
+	strikes      int
+	lastGap      uint
+	acknowledged time.Time
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// New returns a new safe mode monitor.  Use Start to begin monitoring.
+func New(cfg *Config) *Monitor {
+	return &Monitor{
+		cfg:  *cfg,
+		quit: make(chan struct{}),
+	}
+}
+
+// Start begins comparing the peers with the chain.
+func (m *Monitor) Start() {
+	if atomic.AddInt32(&m.started, 1) != 1 {
+		return
+	}
+	m.wg.Add(1)
+	go m.handler()
+}
+
+// Stop stops monitoring and waits for the monitor to exit.
+func (m *Monitor) Stop() {
+	if atomic.AddInt32(&m.shutdown, 1) != 1 {
+		return
+	}
+	close(m.quit)
+	m.wg.Wait()
+}
+
+// State returns the state of the safe mode.
+func (m *Monitor) State() State {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.state
+}
+
+// Acknowledge leaves the safe mode on behalf of the operator.  The safe mode
+// is not entered again within acknowledgeGrace.
+func (m *Monitor) Acknowledge() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if !m.state.SafeMode {
+		return fmt.Errorf("the node is not in safe mode")
+	}
+	m.acknowledged = time.Now()
+	m.strikes = 0
+	m.lastGap = 0
+	m.setSafeMode(false, "acknowledged by the operator")
+	log.Info("Safe mode acknowledged, resume mining and relaying")
+	return nil
+}
+
+func (m *Monitor) handler() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.check()
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// check compares the main heights of the peers with the one of the chain and
+// enters the safe mode when enough peers stayed too far ahead for
+// confirmChecks checks.
+func (m *Monitor) check() {
+	height, peerHeights := m.cfg.Network.MainHeights()
+	diverging := 0
+	gap := uint(0)
+	for _, h := range peerHeights {
+		if h <= height+m.cfg.Divergence {
+			continue
+		}
+		diverging++
+		if gap == 0 || h-height < gap {
+			gap = h - height
+		}
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.state.SafeMode {
+		if diverging > 0 {
+			log.Warn("Still in safe mode, waiting for the operator acknowledgement",
+				"diverging", diverging, "peers", len(peerHeights))
+		}
+		return
+	}
+	if diverging < minDivergingPeers || diverging*3 < len(peerHeights) {
+		m.strikes = 0
+		m.lastGap = 0
+		return
+	}
+	// The node is catching up with the peers.
+	if m.strikes > 0 && gap < m.lastGap {
+		m.strikes = 0
+	}
+	m.lastGap = gap
+	m.strikes++
+	if m.strikes < confirmChecks || time.Since(m.acknowledged) < acknowledgeGrace {
+		return
+	}
+	reason := fmt.Sprintf("%d of %d peers are at least %d blocks ahead of "+
+		"the main height %d", diverging, len(peerHeights), gap, height)
+	m.setSafeMode(true, reason)
+	log.Error("Peers diverge, entering safe mode until acknowledged by the operator",
+		"reason", reason)
+}
+
+// setSafeMode switches the network into or out of the safe mode.
+//
+// This function MUST be called with the lock held.
+func (m *Monitor) setSafeMode(safeMode bool, reason string) {
+	m.state = State{SafeMode: safeMode, Since: time.Now(), Reason: reason}
+	if safeMode {
+		safeModeGauge.Update(1)
+	} else {
+		safeModeGauge.Update(0)
+	}
+	m.cfg.Network.SetSafeMode(safeMode)
+	if len(m.cfg.Webhook) > 0 {
+		m.wg.Add(1)
+		go m.sendAlert(&Alert{
+			SafeMode: safeMode,
+			Reason:   reason,
+			Time:     m.state.Since.Unix(),
+		})
+	}
+}
+
+func (m *Monitor) sendAlert(alert *Alert) {
+	defer m.wg.Done()
+
+	data, err := json.Marshal(alert)
+	if err != nil {
+		log.Error("Failed to encode the safe mode alert", "error", err)
+		return
+	}
+	client := http.Client{Timeout: webhookTimeout}
+	resp, err := client.Post(m.cfg.Webhook, "application/json", bytes.NewReader(data))
+	if err != nil {
+		log.Error("Failed to send the safe mode alert", "url", m.cfg.Webhook, "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.Warn("Safe mode alert was not accepted", "url", m.cfg.Webhook, "status", resp.Status)
+	}
+}
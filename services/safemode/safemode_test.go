@@ -0,0 +1,68 @@
+package safemode
+
+import (
+	"testing"
+)
+
+type testNetwork struct {
+	height      uint
+	peerHeights []uint
+	safeMode    bool
+}
+
+func (n *testNetwork) MainHeights() (uint, []uint) {
+	return n.height, n.peerHeights
+}
+
+func (n *testNetwork) SetSafeMode(safeMode bool) {
+	n.safeMode = safeMode
+}
+
+func TestSafeMode(t *testing.T) {
+	network := &testNetwork{height: 1000}
+	m := New(&Config{Divergence: 100, Network: network})
+
+	check := func(desc string, checks int, safeMode bool) {
+		for i := 0; i < checks; i++ {
+			m.check()
+		}
+		if m.State().SafeMode != safeMode || network.safeMode != safeMode {
+			t.Fatalf("%s: expected safe mode %v, got %v (network %v)", desc,
+				safeMode, m.State().SafeMode, network.safeMode)
+		}
+	}
+
+	// a single diverging peer is not enough
+	network.peerHeights = []uint{1000, 1001, 999, 1200}
+	check("one diverging peer", confirmChecks, false)
+
+	// the node catching up with the peers does not enter the safe mode
+	network.peerHeights = []uint{1000, 1300, 1300}
+	for i := 0; i < confirmChecks; i++ {
+		network.height += 20
+		check("catching up", 1, false)
+	}
+
+	network.peerHeights = nil
+	check("no peers", 1, false)
+
+	network.height = 1000
+	network.peerHeights = []uint{1000, 1300, 1300}
+	check("diverging peers", confirmChecks-1, false)
+	check("diverging peers", 1, true)
+
+	// the safe mode lasts until acknowledged
+	network.peerHeights = []uint{1000, 1000, 1000}
+	check("converged peers", confirmChecks, true)
+	if err := m.Acknowledge(); err != nil {
+		t.Fatal(err)
+	}
+	check("acknowledged", 0, false)
+	if err := m.Acknowledge(); err == nil {
+		t.Fatal("acknowledged twice")
+	}
+
+	// the safe mode is not entered again right after the acknowledgement
+	network.peerHeights = []uint{1000, 1300, 1300}
+	check("acknowledged recently", confirmChecks*2, false)
+}
@@ -0,0 +1,318 @@
+// Copyright (c) 2017-2018 The qitmeer developers
+
+// Package slomon computes rolling statistics of the interval between the
+// latest blocks and of the rate of red blocks, which are the DAG equivalent of
+// orphans, and compares them with the targets of the network.  It alerts when
+// they deviate beyond the configured thresholds for several checks in a row,
+// which is an early warning of a stressed network: a lost hash rate, a
+// propagation issue or a network split.
+package slomon
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/metrics"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// checkInterval is the interval between two computations of the
+	// statistics.
+	checkInterval = time.Minute
+
+	// confirmChecks is the number of consecutive checks in which the
+	// statistics must deviate to raise an alert, so that a short burst
+	// does not.
+	confirmChecks = 3
+
+	// minBlocks is the minimum number of blocks from which the statistics
+	// are meaningful.
+	minBlocks = 10
+
+	// webhookTimeout is the timeout of a webhook alert.
+	webhookTimeout = 10 * time.Second
+)
+
+var (
+	intervalGauge = metrics.NewGauge("chain/slo/interval")
+	redRateGauge  = metrics.NewGauge("chain/slo/redrate")
+	alertGauge    = metrics.NewGauge("chain/slo/alert")
+)
+
+// Chain is the part of the block chain watched by the monitor.
+type Chain interface {
+	// LatestBlocks returns the timestamps of at most count blocks, from
+	// the main chain tip backwards in the order, along with how many of
+	// them are red.
+	LatestBlocks(count uint) ([]time.Time, uint)
+}
+
+// Config is the configuration of the block interval and red rate monitor.
+type Config struct {
+	// Window is the number of latest blocks of the statistics.
+	Window uint
+
+	// TargetInterval is the target interval between two blocks of the
+	// network.
+	TargetInterval time.Duration
+
+	// IntervalDeviation is the deviation in percent of the mean interval
+	// from the target interval, in either direction, beyond which an alert
+	// is raised.
+	IntervalDeviation uint
+
+	// MaxRedRate is the percentage of red blocks beyond which an alert is
+	// raised.
+	MaxRedRate uint
+
+	// Webhook is an optional URL that receives a HTTP POST with an Alert
+	// whenever an alert is raised or cleared.
+	Webhook string
+
+	// Chain is the block chain of which the latest blocks are watched.
+	Chain Chain
+}
+
+// Stats are the statistics of the latest blocks.
+type Stats struct {
+	Blocks         uint
+	Reds           uint
+	RedRate        float64
+	MeanInterval   time.Duration
+	MedianInterval time.Duration
+	MaxInterval    time.Duration
+}
+
+// State is the state of the monitor.
+type State struct {
+	Stats    Stats
+	Alerting bool
+	Since    time.Time
+	Reasons  []string
+}
+
+// Alert is the json payload sent to the webhook.
+type Alert struct {
+	Alerting     bool     `json:"alerting"`
+	Reasons      []string `json:"reasons"`
+	MeanInterval float64  `json:"meaninterval"`
+	RedRate      float64  `json:"redrate"`
+	Time         int64    `json:"time"`
+}
+
+// Monitor periodically computes the statistics of the latest blocks.
+type Monitor struct {
+	started  int32
+	shutdown int32
+
+	cfg Config
+
+	lock    sync.Mutex
+	state   State
+	strikes int
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// New returns a new block interval and red rate monitor.  Use Start to begin
+// monitoring.
+func New(cfg *Config) *Monitor {
+	return &Monitor{
+		cfg:  *cfg,
+		quit: make(chan struct{}),
+	}
+}
+
+// Start begins computing the statistics.
+func (m *Monitor) Start() {
+	if atomic.AddInt32(&m.started, 1) != 1 {
+		return
+	}
+	m.wg.Add(1)
+	go m.handler()
+}
+
+// Stop stops monitoring and waits for the monitor to exit.
+func (m *Monitor) Stop() {
+	if atomic.AddInt32(&m.shutdown, 1) != 1 {
+		return
+	}
+	close(m.quit)
+	m.wg.Wait()
+}
+
+// Config returns the configuration of the monitor.
+func (m *Monitor) Config() Config {
+	return m.cfg
+}
+
+// State returns the latest statistics and whether an alert is raised.
+func (m *Monitor) State() State {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.state
+}
+
+func (m *Monitor) handler() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.check()
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// computeStats returns the statistics of the blocks of the passed timestamps
+// of which reds are red.  The timestamps of the blocks of a DAG are not
+// increasing in the order, so the intervals are the ones between the sorted
+// timestamps.
+func computeStats(timestamps []time.Time, reds uint) Stats {
+	stats := Stats{Blocks: uint(len(timestamps)), Reds: reds}
+	if len(timestamps) == 0 {
+		return stats
+	}
+	stats.RedRate = float64(reds) / float64(len(timestamps))
+	if len(timestamps) < 2 {
+		return stats
+	}
+	sorted := make([]time.Time, len(timestamps))
+	copy(sorted, timestamps)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Before(sorted[j])
+	})
+	intervals := make([]time.Duration, 0, len(sorted)-1)
+	for i := 1; i < len(sorted); i++ {
+		interval := sorted[i].Sub(sorted[i-1])
+		intervals = append(intervals, interval)
+		if interval > stats.MaxInterval {
+			stats.MaxInterval = interval
+		}
+	}
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i] < intervals[j]
+	})
+	stats.MeanInterval = sorted[len(sorted)-1].Sub(sorted[0]) / time.Duration(len(intervals))
+	stats.MedianInterval = intervals[len(intervals)/2]
+	return stats
+}
+
+// violations returns the reasons why the statistics deviate from the targets.
+func (m *Monitor) violations(stats *Stats) []string {
+	var reasons []string
+	target := m.cfg.TargetInterval
+	if target > 0 && m.cfg.IntervalDeviation > 0 {
+		deviation := stats.MeanInterval - target
+		if deviation < 0 {
+			deviation = -deviation
+		}
+		if deviation*100 > target*time.Duration(m.cfg.IntervalDeviation) {
+			reasons = append(reasons, fmt.Sprintf("the mean block interval "+
+				"%s of the latest %d blocks deviates from the target %s "+
+				"by more than %d%%", stats.MeanInterval, stats.Blocks,
+				target, m.cfg.IntervalDeviation))
+		}
+	}
+	if m.cfg.MaxRedRate > 0 && stats.RedRate*100 > float64(m.cfg.MaxRedRate) {
+		reasons = append(reasons, fmt.Sprintf("%d of the latest %d blocks "+
+			"are red, more than %d%%", stats.Reds, stats.Blocks,
+			m.cfg.MaxRedRate))
+	}
+	return reasons
+}
+
+// check computes the statistics of the latest blocks and raises an alert when
+// they deviated from the targets for confirmChecks checks, or clears it when
+// they are back within the thresholds.
+func (m *Monitor) check() {
+	timestamps, reds := m.cfg.Chain.LatestBlocks(m.cfg.Window)
+	stats := computeStats(timestamps, reds)
+	intervalGauge.Update(int64(stats.MeanInterval / time.Millisecond))
+	redRateGauge.Update(int64(stats.RedRate * 1000))
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.state.Stats = stats
+	if stats.Blocks < minBlocks {
+		m.strikes = 0
+		return
+	}
+	reasons := m.violations(&stats)
+	if len(reasons) == 0 {
+		m.strikes = 0
+		if m.state.Alerting {
+			m.setAlert(false, nil)
+			log.Info("Block interval and red rate are back within the targets",
+				"interval", stats.MeanInterval, "redrate", stats.RedRate)
+		}
+		return
+	}
+	m.strikes++
+	if m.strikes < confirmChecks {
+		return
+	}
+	if m.state.Alerting {
+		m.state.Reasons = reasons
+		return
+	}
+	m.setAlert(true, reasons)
+	for _, reason := range reasons {
+		log.Warn("Network stress, " + reason)
+	}
+}
+
+// setAlert raises or clears the alert.
+//
+// This function MUST be called with the lock held.
+func (m *Monitor) setAlert(alerting bool, reasons []string) {
+	m.state.Alerting = alerting
+	m.state.Since = time.Now()
+	m.state.Reasons = reasons
+	if alerting {
+		alertGauge.Update(1)
+	} else {
+		alertGauge.Update(0)
+	}
+	if len(m.cfg.Webhook) > 0 {
+		m.wg.Add(1)
+		go m.sendAlert(&Alert{
+			Alerting:     alerting,
+			Reasons:      reasons,
+			MeanInterval: m.state.Stats.MeanInterval.Seconds(),
+			RedRate:      m.state.Stats.RedRate,
+			Time:         m.state.Since.Unix(),
+		})
+	}
+}
+
+func (m *Monitor) sendAlert(alert *Alert) {
+	defer m.wg.Done()
+
+	data, err := json.Marshal(alert)
+	if err != nil {
+		log.Error("Failed to encode the block interval alert", "error", err)
+		return
+	}
+	client := http.Client{Timeout: webhookTimeout}
+	resp, err := client.Post(m.cfg.Webhook, "application/json", bytes.NewReader(data))
+	if err != nil {
+		log.Error("Failed to send the block interval alert", "url", m.cfg.Webhook, "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.Warn("Block interval alert was not accepted", "url", m.cfg.Webhook, "status", resp.Status)
+	}
+}
@@ -0,0 +1,105 @@
+package slomon
+
+import (
+	"testing"
+	"time"
+)
+
+type testChain struct {
+	interval time.Duration
+	blocks   uint
+	reds     uint
+}
+
+func (c *testChain) LatestBlocks(count uint) ([]time.Time, uint) {
+	n := c.blocks
+	if n > count {
+		n = count
+	}
+	start := time.Unix(1600000000, 0)
+	timestamps := make([]time.Time, 0, n)
+	for i := uint(0); i < n; i++ {
+		// The latest blocks come first.
+		timestamps = append(timestamps, start.Add(time.Duration(n-i)*c.interval))
+	}
+	reds := c.reds
+	if reds > n {
+		reds = n
+	}
+	return timestamps, reds
+}
+
+func TestComputeStats(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	timestamps := []time.Time{
+		start.Add(40 * time.Second),
+		start,
+		start.Add(10 * time.Second),
+		start.Add(100 * time.Second),
+	}
+	stats := computeStats(timestamps, 1)
+	if stats.Blocks != 4 || stats.Reds != 1 || stats.RedRate != 0.25 {
+		t.Fatalf("unexpected counts %+v", stats)
+	}
+	if stats.MeanInterval != 100*time.Second/3 {
+		t.Fatalf("mean interval %s", stats.MeanInterval)
+	}
+	if stats.MedianInterval != 30*time.Second {
+		t.Fatalf("median interval %s", stats.MedianInterval)
+	}
+	if stats.MaxInterval != 60*time.Second {
+		t.Fatalf("max interval %s", stats.MaxInterval)
+	}
+
+	stats = computeStats(nil, 0)
+	if stats.Blocks != 0 || stats.MeanInterval != 0 {
+		t.Fatalf("unexpected stats of no blocks %+v", stats)
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	chain := &testChain{interval: 30 * time.Second, blocks: 100}
+	m := New(&Config{
+		Window:            50,
+		TargetInterval:    30 * time.Second,
+		IntervalDeviation: 50,
+		MaxRedRate:        10,
+		Chain:             chain,
+	})
+
+	check := func(desc string, checks int, alerting bool, reasons int) {
+		for i := 0; i < checks; i++ {
+			m.check()
+		}
+		state := m.State()
+		if state.Alerting != alerting || len(state.Reasons) != reasons {
+			t.Fatalf("%s: expected alerting %v with %d reasons, got %v %v",
+				desc, alerting, reasons, state.Alerting, state.Reasons)
+		}
+	}
+
+	check("on target", confirmChecks, false, 0)
+	if m.State().Stats.Blocks != 50 {
+		t.Fatalf("%d blocks in the window", m.State().Stats.Blocks)
+	}
+
+	// a deviation shorter than confirmChecks is not alerted
+	chain.interval = 50 * time.Second
+	check("slow blocks", confirmChecks-1, false, 0)
+	chain.interval = 30 * time.Second
+	check("back on target", 1, false, 0)
+
+	chain.interval = 10 * time.Second
+	check("fast blocks", confirmChecks, true, 1)
+	chain.reds = 10
+	check("fast blocks and reds", 1, true, 2)
+
+	chain.interval = 30 * time.Second
+	chain.reds = 0
+	check("recovered", 1, false, 0)
+
+	// too few blocks for meaningful statistics
+	chain.blocks = minBlocks - 1
+	chain.reds = minBlocks - 1
+	check("few blocks", confirmChecks, false, 0)
+}
@@ -0,0 +1,68 @@
+package tx
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/address"
+	"github.com/Qitmeer/qitmeer/core/json"
+	"github.com/Qitmeer/qitmeer/rpc"
+)
+
+// GetAddressActivity returns the blocks where an address was first seen and
+// last active, the number of transactions involving it and the number of
+// orders since its last activity, such as for dormancy analysis.  Seen is
+// false when the address never appeared in the blockchain.
+func (api *PublicTxAPI) GetAddressActivity(addr string) (interface{}, error) {
+	addrActivityIndex := api.txManager.addrActivityIndex
+	if addrActivityIndex == nil {
+		return nil, fmt.Errorf("Address activity index must be enabled (--addractivityindex)")
+	}
+	a, err := address.DecodeAddress(addr)
+	if err != nil {
+		return nil, rpc.RpcInvalidError("Invalid address or key: %v", err)
+	}
+	activity, err := addrActivityIndex.AddrActivity(a)
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to fetch address activity")
+	}
+
+	result := &json.AddressActivityResult{Address: addr}
+	if activity == nil {
+		return result, nil
+	}
+	result.Seen = true
+	result.TxCount = activity.TxCount
+	result.FirstSeen, err = api.activityBlock(activity.FirstSeen)
+	if err != nil {
+		return nil, err
+	}
+	result.LastActive, err = api.activityBlock(activity.LastActive)
+	if err != nil {
+		return nil, err
+	}
+	mainOrder := uint64(api.txManager.bm.GetChain().BestSnapshot().GraphState.GetMainOrder())
+	if mainOrder > result.LastActive.Order {
+		result.Dormancy = mainOrder - result.LastActive.Order
+	}
+	return result, nil
+}
+
+// activityBlock returns the block at the order for the getAddressActivity
+// command.
+func (api *PublicTxAPI) activityBlock(order uint32) (*json.ActivityBlockResult, error) {
+	chain := api.txManager.bm.GetChain()
+	h := chain.BlockDAG().GetBlockHashByOrder(uint(order))
+	if h == nil {
+		return nil, rpc.RpcInternalError(fmt.Errorf("no block").Error(),
+			fmt.Sprintf("Block not found at order %d", order))
+	}
+	header, err := chain.HeaderByHash(h)
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(),
+			fmt.Sprintf("Block not found: %v", h))
+	}
+	return &json.ActivityBlockResult{
+		Order:     uint64(order),
+		Hash:      h.String(),
+		Timestamp: header.Timestamp.Unix(),
+	}, nil
+}
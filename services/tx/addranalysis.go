@@ -0,0 +1,171 @@
+package tx
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/address"
+	"github.com/Qitmeer/qitmeer/core/json"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/params"
+	"github.com/Qitmeer/qitmeer/rpc"
+	"github.com/Qitmeer/qitmeer/services/mempool"
+)
+
+// maxAnalyzeAddrTxs is the maximum number of transactions of an address read
+// by the analyzeAddress command.
+const maxAnalyzeAddrTxs = 10000
+
+// AnalyzeAddress reports the hygiene of an address for wallet developers: how
+// many times it was paid again after its first payment, its unspent outputs
+// which are dust at the minimum relay fee, and approximate linkability
+// metrics.  The addresses spent together with it in the same transactions are
+// likely owned by the same wallet, and a transaction spending from it and
+// paying back to it reveals its change.  Only the first maxAnalyzeAddrTxs
+// transactions are read, and Truncated is set when there are more.  The
+// address index must be enabled.
+func (api *PublicTxAPI) AnalyzeAddress(addr string) (interface{}, error) {
+	if api.txManager.addrIndex == nil {
+		return nil, fmt.Errorf("Address index must be enabled (--addrindex)")
+	}
+	param := api.txManager.bm.ChainParams()
+	a, err := address.DecodeAddress(addr)
+	if err != nil {
+		return nil, rpc.RpcAddressKeyError("Could not decode address: %v",
+			err)
+	}
+	if !address.IsForNetwork(a, param) {
+		return nil, rpc.RpcAddressKeyError("Wrong network: %v", a)
+	}
+	encoded := a.Encode()
+
+	var txs []*types.Transaction
+	err = api.txManager.db.View(func(dbTx database.Tx) error {
+		regions, _, err := api.txManager.addrIndex.TxRegionsForAddress(
+			dbTx, a, 0, maxAnalyzeAddrTxs+1, false)
+		if err != nil {
+			return err
+		}
+		serializedTxns, err := dbTx.FetchBlockRegions(regions)
+		if err != nil {
+			return err
+		}
+		for _, serializedTx := range serializedTxns {
+			var mtx types.Transaction
+			err := mtx.Deserialize(bytes.NewReader(serializedTx))
+			if err != nil {
+				return err
+			}
+			txs = append(txs, &mtx)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(),
+			"Failed to load address index entries")
+	}
+
+	result := &json.AnalyzeAddressResult{Address: addr}
+	if len(txs) > maxAnalyzeAddrTxs {
+		txs = txs[:maxAnalyzeAddrTxs]
+		result.Truncated = true
+	}
+	result.TxCount = len(txs)
+
+	chain := api.txManager.bm.GetChain()
+	minRelayFee := types.Amount{Value: api.txManager.txMemPool.MinRelayTxFee(),
+		Id: types.MEERID}
+	var dustValue int64
+	coSpent := make(map[string]struct{})
+	for _, mtx := range txs {
+		txHash := mtx.TxHash()
+		paid := false
+		for i, txOut := range mtx.TxOut {
+			if !pkScriptPays(txOut.PkScript, encoded, param) {
+				continue
+			}
+			paid = true
+			result.Outputs++
+			op := *types.NewOutPoint(&txHash, uint32(i))
+			entry, err := chain.FetchUtxoEntry(op)
+			if err != nil {
+				return nil, rpc.RpcInternalError(err.Error(),
+					"Failed to fetch the unspent output")
+			}
+			if entry == nil || entry.IsSpent() {
+				continue
+			}
+			result.Utxos++
+			if mempool.IsDust(txOut, minRelayFee) {
+				result.DustUtxos++
+				dustValue += txOut.Amount.Value
+			}
+		}
+		if paid {
+			result.ReceivingTxs++
+		}
+		if mtx.IsCoinBase() {
+			continue
+		}
+
+		// Find the addresses of the spent outputs to tell whether the
+		// transaction spends from the address.
+		prevOuts, err := api.fetchInputTxos(types.NewTx(mtx))
+		if err != nil {
+			return nil, err
+		}
+		spends := false
+		inputAddrs := make(map[string]struct{})
+		for _, txIn := range mtx.TxIn {
+			prevOut, ok := prevOuts[txIn.PreviousOut]
+			if !ok {
+				continue
+			}
+			_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+				prevOut.PkScript, param)
+			if err != nil {
+				continue
+			}
+			for _, inAddr := range addrs {
+				if inAddr.Encode() == encoded {
+					spends = true
+				} else {
+					inputAddrs[inAddr.Encode()] = struct{}{}
+				}
+			}
+		}
+		if !spends {
+			continue
+		}
+		result.SpendingTxs++
+		if paid {
+			result.SelfChangeTxs++
+		}
+		for inAddr := range inputAddrs {
+			coSpent[inAddr] = struct{}{}
+		}
+	}
+	if result.ReceivingTxs > 1 {
+		result.ReuseCount = result.ReceivingTxs - 1
+	}
+	result.CoSpentAddresses = len(coSpent)
+	dustAmount := types.Amount{Value: dustValue, Id: types.MEERID}
+	result.DustAmount = dustAmount.ToUnit(types.AmountCoin)
+	return result, nil
+}
+
+// pkScriptPays returns whether the public key script pays to the encoded
+// address.
+func pkScriptPays(pkScript []byte, encoded string, param *params.Params) bool {
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript, param)
+	if err != nil {
+		return false
+	}
+	for _, a := range addrs {
+		if a.Encode() == encoded {
+			return true
+		}
+	}
+	return false
+}
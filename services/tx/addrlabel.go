@@ -0,0 +1,90 @@
+package tx
+
+import (
+	"github.com/Qitmeer/qitmeer/core/address"
+	"github.com/Qitmeer/qitmeer/core/dbnamespace"
+	"github.com/Qitmeer/qitmeer/core/json"
+	"github.com/Qitmeer/qitmeer/database"
+	"github.com/Qitmeer/qitmeer/rpc"
+	"sort"
+)
+
+// maxAddressLabelLen is the maximum length of the label of an address.
+const maxAddressLabelLen = 128
+
+// dbFetchAddressesByLabel returns the sorted addresses with the label.
+func dbFetchAddressesByLabel(dbTx database.Tx, label string) ([]string, error) {
+	addrs := []string{}
+	bucket := dbTx.Metadata().Bucket(dbnamespace.AddressLabelBucketName)
+	if bucket == nil {
+		return addrs, nil
+	}
+	err := bucket.ForEach(func(k, v []byte) error {
+		if string(v) == label {
+			addrs = append(addrs, string(k))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(addrs)
+	return addrs, nil
+}
+
+// addressesByLabel returns the sorted addresses with the label.
+func (api *PublicTxAPI) addressesByLabel(label string) ([]string, error) {
+	var addrs []string
+	err := api.txManager.db.View(func(dbTx database.Tx) error {
+		var err error
+		addrs, err = dbFetchAddressesByLabel(dbTx, label)
+		return err
+	})
+	return addrs, err
+}
+
+// SetAddressLabel sets the label of the address, such as the user a deposit
+// address belongs to, or removes it when the label is empty.  An address has
+// at most one label, and a label any number of addresses.
+func (api *PrivateTxAPI) SetAddressLabel(addr string, label string) (interface{}, error) {
+	a, err := address.DecodeAddress(addr)
+	if err != nil {
+		return nil, rpc.RpcAddressKeyError("Could not decode address: %v",
+			err)
+	}
+	if !address.IsForNetwork(a, api.txManager.bm.ChainParams()) {
+		return nil, rpc.RpcAddressKeyError("Wrong network: %v", a)
+	}
+	if len(label) > maxAddressLabelLen {
+		return nil, rpc.RpcInvalidError("Label is longer than %d bytes",
+			maxAddressLabelLen)
+	}
+	encoded := a.Encode()
+	err = api.txManager.db.Update(func(dbTx database.Tx) error {
+		bucket, err := dbTx.Metadata().CreateBucketIfNotExists(
+			dbnamespace.AddressLabelBucketName)
+		if err != nil {
+			return err
+		}
+		if len(label) == 0 {
+			return bucket.Delete([]byte(encoded))
+		}
+		return bucket.Put([]byte(encoded), []byte(label))
+	})
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to set address label")
+	}
+	return &json.AddressLabelResult{Address: encoded, Label: label}, nil
+}
+
+// GetAddressesByLabel returns the addresses with the label, sorted.
+func (api *PublicTxAPI) GetAddressesByLabel(label string) (interface{}, error) {
+	if len(label) == 0 {
+		return nil, rpc.RpcInvalidError("Empty label")
+	}
+	addrs, err := api.addressesByLabel(label)
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to read address labels")
+	}
+	return addrs, nil
+}
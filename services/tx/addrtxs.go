@@ -0,0 +1,226 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/common/math"
+	"github.com/Qitmeer/qitmeer/core/address"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/json"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
+	"github.com/Qitmeer/qitmeer/rpc"
+	"sort"
+)
+
+const (
+	// defaultAddrTxsCount is the default number of transactions returned
+	// by the getRawTransactionsByAddress command.
+	defaultAddrTxsCount = 100
+
+	// maxAddrTxsCount is the maximum number of transactions returned by
+	// the getRawTransactionsByAddress command.
+	maxAddrTxsCount = 1000
+
+	// The directions of the transactions of an address.
+	addrTxSent     = "sent"
+	addrTxReceived = "received"
+)
+
+// addrTxEntry is a transaction of the address index along with the order of
+// its block.
+type addrTxEntry struct {
+	order  uint
+	region database.BlockRegion
+}
+
+// after returns whether the entry comes after the cursor in the listing.
+func (e *addrTxEntry) after(order uint, offset uint32, reverse bool) bool {
+	if e.order != order {
+		return (e.order > order) != reverse
+	}
+	return (e.region.Offset > offset) != reverse
+}
+
+// GetRawTransactionsByAddress returns the decoded confirmed transactions of an
+// address in the blocks between the start and end orders, both included, from
+// the oldest to the newest or the other way around when reverse is true.  A
+// transaction is sent when it spends from the address and received otherwise,
+// and direction keeps only the ones sent or received.  coinId keeps only the
+// transactions spending or paying the coin.  At most count transactions are
+// returned along with a cursor, which is passed back to get the next ones.
+// The cursor is the position of the last transaction, so it is not shifted by
+// the new blocks.  The address index must be enabled.
+func (api *PublicTxAPI) GetRawTransactionsByAddress(addr string, startOrder *uint32, endOrder *uint32,
+	direction *string, coinId *uint16, count *uint, cursor *string, reverse *bool) (interface{}, error) {
+
+	addrIndex := api.txManager.addrIndex
+	if addrIndex == nil {
+		return nil, fmt.Errorf("Address index must be enabled (--addrindex)")
+	}
+	param := api.txManager.bm.ChainParams()
+	a, err := address.DecodeAddress(addr)
+	if err != nil {
+		return nil, rpc.RpcInvalidError("Invalid address or key: %v", err)
+	}
+	if !address.IsForNetwork(a, param) {
+		return nil, rpc.RpcAddressKeyError("Wrong network: %v", a)
+	}
+	encoded := a.Encode()
+
+	start := uint32(0)
+	if startOrder != nil {
+		start = *startOrder
+	}
+	end := uint32(math.MaxUint32)
+	if endOrder != nil {
+		end = *endOrder
+	}
+	if start > end {
+		return nil, rpc.RpcInvalidError("Start order %d is after end "+
+			"order %d", start, end)
+	}
+	dir := ""
+	if direction != nil {
+		dir = *direction
+	}
+	if dir != "" && dir != addrTxSent && dir != addrTxReceived {
+		return nil, rpc.RpcInvalidError("Invalid direction %q, must be %s "+
+			"or %s", dir, addrTxSent, addrTxReceived)
+	}
+	numRequested := uint(defaultAddrTxsCount)
+	if count != nil {
+		numRequested = *count
+	}
+	if numRequested == 0 || numRequested > maxAddrTxsCount {
+		return nil, rpc.RpcInvalidError("Count must be in range [1, %d]",
+			maxAddrTxsCount)
+	}
+	rev := reverse != nil && *reverse
+	var cursorOrder uint
+	var cursorOffset uint32
+	hasCursor := cursor != nil && len(*cursor) > 0
+	if hasCursor {
+		_, err := fmt.Sscanf(*cursor, "%d:%d", &cursorOrder, &cursorOffset)
+		if err != nil {
+			return nil, rpc.RpcInvalidError("Invalid cursor %q", *cursor)
+		}
+	}
+
+	var regions []database.BlockRegion
+	err = api.txManager.db.View(func(dbTx database.Tx) error {
+		var err error
+		regions, _, err = addrIndex.TxRegionsForAddress(dbTx, a, 0,
+			math.MaxUint32, false)
+		return err
+	})
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(),
+			"Failed to load address index entries")
+	}
+
+	// Sort the transactions by block order within the range, and by
+	// position in the block.
+	hashes := make([]*hash.Hash, len(regions))
+	for i := range regions {
+		hashes[i] = regions[i].Hash
+	}
+	chain := api.txManager.bm.GetChain()
+	orders := chain.BlockDAG().GetBlockOrders(hashes)
+	entries := make([]addrTxEntry, 0, len(regions))
+	for i, order := range orders {
+		if order == blockdag.MaxBlockOrder || order < uint(start) ||
+			order > uint(end) {
+			continue
+		}
+		entries = append(entries, addrTxEntry{order: order, region: regions[i]})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].order != entries[j].order {
+			return (entries[i].order < entries[j].order) != rev
+		}
+		return (entries[i].region.Offset < entries[j].region.Offset) != rev
+	})
+
+	result := &json.AddressTransactionsResult{
+		Address:      addr,
+		Transactions: []json.AddressTransactionResult{},
+	}
+	var last *addrTxEntry
+	for i := range entries {
+		entry := &entries[i]
+		if hasCursor && !entry.after(cursorOrder, cursorOffset, rev) {
+			continue
+		}
+		if uint(len(result.Transactions)) == numRequested {
+			result.Cursor = fmt.Sprintf("%d:%d", last.order, last.region.Offset)
+			break
+		}
+
+		var txBytes []byte
+		err = api.txManager.db.View(func(dbTx database.Tx) error {
+			var err error
+			txBytes, err = dbTx.FetchBlockRegion(&entry.region)
+			return err
+		})
+		if err != nil {
+			return nil, rpc.RpcInternalError(err.Error(),
+				"Failed to load transaction")
+		}
+		var mtx types.Transaction
+		err = mtx.Deserialize(bytes.NewReader(txBytes))
+		if err != nil {
+			return nil, rpc.RpcInternalError(err.Error(),
+				"Failed to deserialize transaction")
+		}
+		tx := types.NewTx(&mtx)
+
+		// Find the spent outputs to tell whether the transaction spends
+		// from the address and which coins it spends.
+		var prevOuts map[types.TxOutPoint]types.TxOutput
+		if !mtx.IsCoinBase() {
+			prevOuts, err = api.fetchInputTxos(tx)
+			if err != nil {
+				return nil, err
+			}
+		}
+		txDir := addrTxReceived
+		hasCoin := coinId == nil
+		for _, txIn := range mtx.TxIn {
+			prevOut, ok := prevOuts[txIn.PreviousOut]
+			if !ok {
+				continue
+			}
+			if pkScriptPays(prevOut.PkScript, encoded, param) {
+				txDir = addrTxSent
+			}
+			if coinId != nil && uint16(prevOut.Amount.Id) == *coinId {
+				hasCoin = true
+			}
+		}
+		for _, txOut := range mtx.TxOut {
+			if coinId != nil && uint16(txOut.Amount.Id) == *coinId {
+				hasCoin = true
+			}
+		}
+		if !hasCoin || (dir != "" && dir != txDir) {
+			continue
+		}
+
+		txResult := json.AddressTransactionResult{
+			Order:     uint64(entry.order),
+			Direction: txDir,
+		}
+		txResult.Hex = hex.EncodeToString(txBytes)
+		err = api.fillRawTransactionResult(&txResult.GetRawTransactionsResult,
+			tx, entry.region.Hash, param, true, nil)
+		if err != nil {
+			return nil, err
+		}
+		result.Transactions = append(result.Transactions, txResult)
+		last = entry
+	}
+	return result, nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"github.com/Qitmeer/qitmeer/common/errcode"
 	"github.com/Qitmeer/qitmeer/common/hash"
 	"github.com/Qitmeer/qitmeer/common/marshal"
 	"github.com/Qitmeer/qitmeer/common/math"
@@ -12,7 +13,6 @@ import (
 	"github.com/Qitmeer/qitmeer/core/blockchain/token"
 	"github.com/Qitmeer/qitmeer/core/dbnamespace"
 	"github.com/Qitmeer/qitmeer/core/json"
-	"github.com/Qitmeer/qitmeer/core/message"
 	"github.com/Qitmeer/qitmeer/core/types"
 	"github.com/Qitmeer/qitmeer/crypto/ecc"
 	"github.com/Qitmeer/qitmeer/database"
@@ -27,7 +27,7 @@ import (
 )
 
 func (tm *TxManager) APIs() []rpc.API {
-	return []rpc.API{
+	apis := []rpc.API{
 		{
 			NameSpace: cmds.DefaultServiceNameSpace,
 			Service:   NewPublicTxAPI(tm),
@@ -40,6 +40,14 @@ func (tm *TxManager) APIs() []rpc.API {
 		},
 		tm.txMemPool.API(),
 	}
+	if tm.freezeList != nil {
+		apis = append(apis, rpc.API{
+			NameSpace: cmds.FreezeNameSpace,
+			Service:   NewFreezeAPI(tm.freezeList),
+			Public:    false,
+		})
+	}
+	return apis
 }
 
 type PublicTxAPI struct {
@@ -63,6 +71,26 @@ func (api *PublicTxAPI) CreateRawTransaction(inputs []json.TransactionInput, amo
 
 func (api *PublicTxAPI) CreateRawTransactionV2(inputs []json.TransactionInput,
 	amounts json.AdreesAmount, lockTime *int64) (interface{}, error) {
+	mtx, err := api.createRawTransaction(inputs, amounts, lockTime)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the serialized and hex-encoded transaction.  Note that this
+	// is intentionally not directly returning because the first return
+	// value is a string and it would result in returning an empty string to
+	// the client instead of nothing (nil) in the case of an error.
+	mtxHex, err := marshal.MessageToHex(mtx)
+	if err != nil {
+		return nil, err
+	}
+	return mtxHex, nil
+}
+
+// createRawTransaction returns a new unsigned transaction spending the inputs
+// to the amounts.
+func (api *PublicTxAPI) createRawTransaction(inputs []json.TransactionInput,
+	amounts json.AdreesAmount, lockTime *int64) (*types.Transaction, error) {
 
 	// Validate the locktime, if given.
 	if lockTime != nil &&
@@ -135,16 +163,7 @@ func (api *PublicTxAPI) CreateRawTransactionV2(inputs []json.TransactionInput,
 	if lockTime != nil {
 		mtx.LockTime = uint32(*lockTime)
 	}
-
-	// Return the serialized and hex-encoded transaction.  Note that this
-	// is intentionally not directly returning because the first return
-	// value is a string and it would result in returning an empty string to
-	// the client instead of nothing (nil) in the case of an error.
-	mtxHex, err := marshal.MessageToHex(mtx)
-	if err != nil {
-		return nil, err
-	}
-	return mtxHex, nil
+	return mtx, nil
 }
 
 func (api *PublicTxAPI) DecodeRawTransaction(hexTx string) (interface{}, error) {
@@ -211,15 +230,12 @@ func (api *PublicTxAPI) SendRawTransaction(hexTx string, allowHighFees *bool) (i
 		// JSON-RPC error is returned to the client with the
 		// deserialization error code (to match bitcoind behavior).
 		if _, ok := err.(mempool.RuleError); ok {
-			err = fmt.Errorf("Rejected transaction %v: %v", tx.Hash(),
-				err)
+			err = errcode.Wrap(errcode.CodeOf(err), err,
+				fmt.Sprintf("Rejected transaction %v", tx.Hash()))
 			log.Error("Failed to process transaction", "mempool.RuleError", err)
-			txRuleErr, ok := err.(mempool.TxRuleError)
-			if ok {
-				if txRuleErr.RejectCode == message.RejectDuplicate {
-					// return a dublicate tx error
-					return nil, rpc.RpcDuplicateTxError("%v", err)
-				}
+			if errcode.Is(err, errcode.Duplicate) {
+				// return a dublicate tx error
+				return nil, rpc.RpcDuplicateTxError("%v", err)
 			}
 
 			// return a generic rule error
@@ -237,6 +253,63 @@ func (api *PublicTxAPI) SendRawTransaction(hexTx string, allowHighFees *bool) (i
 	return tx.Hash().String(), nil
 }
 
+// SubmitTxPackage submits a package of dependent transactions, a child
+// transaction last along with its unconfirmed parents sorted before it, which
+// are accepted to the mempool together or not at all.  The fees of the package
+// are checked as a whole, so that the child can pay for parents whose fees are
+// below the minimum relay fee.  It returns the ids of the transactions.
+func (api *PublicTxAPI) SubmitTxPackage(hexTxs []string, allowHighFees *bool) (interface{}, error) {
+	highFees := false
+	if allowHighFees != nil {
+		highFees = *allowHighFees
+	}
+	if len(hexTxs) > mempool.MaxPackageCount {
+		return nil, rpc.RpcInvalidError("Package of %d transactions exceeds "+
+			"the limit of %d transactions", len(hexTxs), mempool.MaxPackageCount)
+	}
+	txs := make([]*types.Tx, 0, len(hexTxs))
+	for _, hexStr := range hexTxs {
+		if len(hexStr)%2 != 0 {
+			hexStr = "0" + hexStr
+		}
+		serializedTx, err := hex.DecodeString(hexStr)
+		if err != nil {
+			return nil, rpc.RpcDecodeHexError(hexStr)
+		}
+		msgtx := types.NewTransaction()
+		err = msgtx.Deserialize(bytes.NewReader(serializedTx))
+		if err != nil {
+			return nil, rpc.RpcDeserializationError("Could not decode Tx: %v",
+				err)
+		}
+		txs = append(txs, types.NewTx(msgtx))
+	}
+
+	acceptedTxs, err := api.txManager.bm.ProcessPackage(txs, false, highFees)
+	if err != nil {
+		if _, ok := err.(mempool.RuleError); ok {
+			err = errcode.Wrap(errcode.CodeOf(err), err, "Rejected package")
+			log.Error("Failed to process package", "mempool.RuleError", err)
+			if errcode.Is(err, errcode.Duplicate) {
+				return nil, rpc.RpcDuplicateTxError("%v", err)
+			}
+			return nil, rpc.RpcRuleError("%v", err)
+		}
+
+		log.Error("Failed to process package", "err", err)
+		return nil, rpc.RpcDeserializationError("rejected: failed to "+
+			"process package: %v", err)
+	}
+	api.txManager.ntmgr.AnnounceNewPackage(txs, acceptedTxs, nil)
+	api.txManager.ntmgr.AddRebroadcastInventory(acceptedTxs)
+
+	txIDs := make([]string, 0, len(txs))
+	for _, tx := range txs {
+		txIDs = append(txIDs, tx.Hash().String())
+	}
+	return txIDs, nil
+}
+
 func (api *PublicTxAPI) GetRawTransaction(txHash hash.Hash, verbose bool) (interface{}, error) {
 
 	var mtx *types.Tx
@@ -353,7 +426,12 @@ func (api *PublicTxAPI) GetRawTransaction(txHash hash.Hash, verbose bool) (inter
 	if tx != nil {
 		confirmations = 0
 	}
-	return marshal.MarshalJsonTransaction(mtx, api.txManager.bm.ChainParams(), blkHashStr, confirmations, coinbaseAmout, txsvalid)
+	txr, err := marshal.MarshalJsonTransaction(mtx, api.txManager.bm.ChainParams(), blkHashStr, confirmations, coinbaseAmout, txsvalid)
+	if err != nil {
+		return nil, err
+	}
+	txr.Finality = api.txFinality(mtx, blkHash)
+	return txr, nil
 }
 
 // Returns information about an unspent transaction output
@@ -641,53 +719,61 @@ func (api *PublicTxAPI) GetRawTransactions(addre string, vinext *bool, count *ui
 			mtx = types.NewTx(rtx.tx.Tx)
 		}
 
-		result := &srtList[i]
-		result.Hex = hexTxns[i]
-		result.Txid = mtx.Tx.TxHash().String()
-		result.Vin, err = api.createVinListPrevOut(mtx, params, vinExtra,
-			filterAddrMap)
+		srtList[i].Hex = hexTxns[i]
+		err = api.fillRawTransactionResult(&srtList[i], mtx, rtx.blkHash,
+			params, vinExtra, filterAddrMap)
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		if mtx.Tx.IsCoinBase() {
-			amountMap := api.txManager.bm.GetChain().GetFees(rtx.blkHash)
-			result.Vout = marshal.MarshJsonCoinbaseVout(mtx.Tx, filterAddrMap, params, amountMap)
-		} else {
-			result.Vout = marshal.MarshJsonVout(mtx.Tx, filterAddrMap, params)
-		}
-		result.Version = mtx.Tx.Version
-		result.LockTime = mtx.Tx.LockTime
-
-		// Transactions grabbed from the mempool aren't yet in a block,
-		// so conditionally fetch block details here.  This will be
-		// reflected in the final JSON output (mempool won't have
-		// confirmations or block information).
-		var blkHeader *types.BlockHeader
-		var blkHashStr string
-		if blkHash := rtx.blkHash; blkHash != nil {
-			// Fetch the header from chain.
-			header, err := api.txManager.bm.GetChain().HeaderByHash(blkHash)
-			if err != nil {
-				return nil, rpc.RpcInternalError("Block not found", "")
-			}
-			blkHeader = &header
-			blkHashStr = blkHash.String()
-		}
+	return srtList, nil
+}
 
-		// Add the block information to the result if there is any.
-		if blkHeader != nil {
-			// This is not a typo, they are identical in Bitcoin
-			// Core as well.
-			result.Time = blkHeader.Timestamp.Unix()
-			result.Blocktime = blkHeader.Timestamp.Unix()
-			result.BlockHash = blkHashStr
-			result.Confirmations = uint64(api.txManager.bm.GetChain().BlockDAG().GetConfirmations(
-				api.txManager.bm.GetChain().BlockDAG().GetBlockId(rtx.blkHash)))
-		}
+// fillRawTransactionResult decodes the transaction, contained in the block of
+// blkHash unless it is nil, into the result.
+func (api *PublicTxAPI) fillRawTransactionResult(result *json.GetRawTransactionsResult,
+	mtx *types.Tx, blkHash *hash.Hash, chainParams *params.Params, vinExtra bool,
+	filterAddrMap map[string]struct{}) error {
+
+	var err error
+	result.Txid = mtx.Tx.TxHash().String()
+	result.Vin, err = api.createVinListPrevOut(mtx, chainParams, vinExtra,
+		filterAddrMap)
+	if err != nil {
+		return err
 	}
 
-	return srtList, nil
+	if mtx.Tx.IsCoinBase() {
+		amountMap := api.txManager.bm.GetChain().GetFees(blkHash)
+		result.Vout = marshal.MarshJsonCoinbaseVout(mtx.Tx, filterAddrMap, chainParams, amountMap)
+	} else {
+		result.Vout = marshal.MarshJsonVout(mtx.Tx, filterAddrMap, chainParams)
+	}
+	result.Version = mtx.Tx.Version
+	result.LockTime = mtx.Tx.LockTime
+
+	// Transactions grabbed from the mempool aren't yet in a block,
+	// so conditionally fetch block details here.  This will be
+	// reflected in the final JSON output (mempool won't have
+	// confirmations or block information).
+	if blkHash == nil {
+		return nil
+	}
+	// Fetch the header from chain.
+	header, err := api.txManager.bm.GetChain().HeaderByHash(blkHash)
+	if err != nil {
+		return rpc.RpcInternalError("Block not found", "")
+	}
+
+	// This is not a typo, they are identical in Bitcoin
+	// Core as well.
+	result.Time = header.Timestamp.Unix()
+	result.Blocktime = header.Timestamp.Unix()
+	result.BlockHash = blkHash.String()
+	result.Confirmations = uint64(api.txManager.bm.GetChain().BlockDAG().GetConfirmations(
+		api.txManager.bm.GetChain().BlockDAG().GetBlockId(blkHash)))
+	return nil
 }
 
 func (api *PublicTxAPI) fetchMempoolTxnsForAddress(addr types.Address, numToSkip, numRequested uint32) ([]*types.Tx, uint32) {
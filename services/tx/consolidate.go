@@ -0,0 +1,226 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/common/marshal"
+	"github.com/Qitmeer/qitmeer/core/address"
+	"github.com/Qitmeer/qitmeer/core/json"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/params"
+	"github.com/Qitmeer/qitmeer/rpc"
+	"github.com/Qitmeer/qitmeer/services/mempool"
+	"sort"
+)
+
+// defaultConsolidateInputs is the default maximum number of inputs of a
+// consolidation transaction.
+const defaultConsolidateInputs = 200
+
+// ConsolidateUtxos builds the transactions spending the small unspent
+// outputs of a watched address (--walletnotifyaddr) back to the address, each
+// of them merging up to maxInputs outputs (200 by default) into one and
+// paying the fee at feeRate atoms/kB (the minimum relay fee by default).
+// Only the outputs worth less than maxValue atoms, when given, are merged,
+// the smallest first, and the outputs worth less than the fee of spending
+// them are left alone.
+//
+// The transactions of a pay-to-pubkey-hash address are returned unsigned.
+// The redeem script of a pay-to-script-hash address must be given, and the
+// transactions are returned as the partially signed transactions of spend
+// proposals, which the cosigners sign and import with importSpendProposal.
+// The address index must be enabled to find the outputs.
+func (api *PublicTxAPI) ConsolidateUtxos(addr string, feeRate *int64, maxValue *int64,
+	maxInputs *uint32, redeemScript *string) (interface{}, error) {
+
+	if api.txManager.addrIndex == nil {
+		return nil, fmt.Errorf("Address index must be enabled (--addrindex)")
+	}
+	param := api.txManager.bm.ChainParams()
+	consAddr, err := address.DecodeAddress(addr)
+	if err != nil {
+		return nil, rpc.RpcAddressKeyError("Could not decode address: %v",
+			err)
+	}
+	if !address.IsForNetwork(consAddr, param) {
+		return nil, rpc.RpcAddressKeyError("Wrong network: %v", consAddr)
+	}
+	watched := false
+	for _, watchAddr := range api.txManager.config.GetWalletNotifyAddrs() {
+		if watchAddr.Encode() == consAddr.Encode() {
+			watched = true
+			break
+		}
+	}
+	if !watched {
+		return nil, rpc.RpcInvalidError("Address %s is not watched "+
+			"(--walletnotifyaddr)", addr)
+	}
+	rate := api.txManager.txMemPool.MinRelayTxFee()
+	if feeRate != nil {
+		rate = *feeRate
+	}
+	if rate < 0 {
+		return nil, rpc.RpcInvalidError("Fee rate %d is negative", rate)
+	}
+	inputsPerTx := defaultConsolidateInputs
+	if maxInputs != nil {
+		inputsPerTx = int(*maxInputs)
+	}
+	if inputsPerTx < 2 {
+		return nil, rpc.RpcInvalidError("A consolidation transaction " +
+			"needs at least 2 inputs")
+	}
+
+	pkScript, err := txscript.PayToAddrScript(consAddr)
+	if err != nil {
+		return nil, rpc.RpcAddressKeyError(err.Error())
+	}
+	class := txscript.GetScriptClass(txscript.DefaultScriptVersion, pkScript)
+	var script []byte
+	sigScriptSize := p2pkhSigScriptSize
+	switch class {
+	case txscript.PubKeyHashTy:
+	case txscript.ScriptHashTy:
+		if redeemScript == nil {
+			return nil, rpc.RpcInvalidError("The redeem script of the " +
+				"pay-to-script-hash address is required")
+		}
+		script, err = hex.DecodeString(*redeemScript)
+		if err != nil {
+			return nil, rpc.RpcDecodeHexError(*redeemScript)
+		}
+		p2sh, err := txscript.PayToScriptHashScript(hash.Hash160(script))
+		if err != nil {
+			return nil, rpc.RpcInternalError(err.Error(),
+				"Pay to script hash script")
+		}
+		if !bytes.Equal(p2sh, pkScript) {
+			return nil, rpc.RpcInvalidError("The redeem script does not " +
+				"match the address")
+		}
+		sigScriptSize, err = multiSigScriptSize(script, param)
+		if err != nil {
+			return nil, rpc.RpcInvalidError(err.Error())
+		}
+	default:
+		return nil, rpc.RpcInvalidError("Unsupported %s address", class)
+	}
+
+	utxos, err := api.addressUtxos(consAddr, class,
+		make(map[types.TxOutPoint]struct{}))
+	if err != nil {
+		return nil, err
+	}
+	inputSize := (&types.TxInput{
+		SignScript: make([]byte, sigScriptSize)}).SerializeSize()
+	inputFee := feeForSize(inputSize, rate)
+	candidates := utxos[:0]
+	for _, utxo := range utxos {
+		if utxo.value <= inputFee {
+			continue
+		}
+		if maxValue != nil && utxo.value >= *maxValue {
+			continue
+		}
+		candidates = append(candidates, utxo)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].value < candidates[j].value
+	})
+
+	// Keep the transactions standard.  The input counts take up to 4 more
+	// bytes once there are many inputs.
+	baseTx := types.NewTransaction()
+	baseTx.AddTxOut(types.NewTxOutput(types.Amount{Id: types.MEERID}, pkScript))
+	baseSize := baseTx.SerializeSize() + 4
+	if maxSizeInputs := (mempool.MaxStandardTxSize - baseSize) /
+		inputSize; inputsPerTx > maxSizeInputs {
+		inputsPerTx = maxSizeInputs
+	}
+
+	minRelayFee := types.Amount{Value: api.txManager.txMemPool.MinRelayTxFee(),
+		Id: types.MEERID}
+	result := &json.ConsolidateUtxosResult{
+		Transactions: []json.ConsolidationTxResult{},
+	}
+	var totalFee int64
+	for len(candidates) >= 2 {
+		n := inputsPerTx
+		if n > len(candidates) {
+			n = len(candidates)
+		}
+		batch := candidates[:n]
+		candidates = candidates[n:]
+
+		mtx := types.NewTransaction()
+		var value int64
+		for i := range batch {
+			mtx.AddTxIn(types.NewTxInput(&batch[i].outPoint, []byte{}))
+			value += batch[i].value
+		}
+		mtx.AddTxOut(types.NewTxOutput(types.Amount{Id: types.MEERID}, pkScript))
+		fee := feeForSize(mtx.SerializeSize()+n*(inputSize-
+			mtx.TxIn[0].SerializeSize()), rate)
+		if value <= fee {
+			continue
+		}
+		mtx.TxOut[0].Amount.Value = value - fee
+		if mempool.IsDust(mtx.TxOut[0], minRelayFee) {
+			continue
+		}
+
+		txResult := json.ConsolidationTxResult{Inputs: n}
+		if script == nil {
+			txResult.Hex, err = marshal.MessageToHex(mtx)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			p, err := newSpendProposal(mtx, script, param)
+			if err != nil {
+				return nil, rpc.RpcInvalidError(err.Error())
+			}
+			txResult.Psbt, err = p.psbt()
+			if err != nil {
+				return nil, err
+			}
+		}
+		txResult.Amount = mtx.TxOut[0].Amount.ToUnit(types.AmountCoin)
+		feeAmount := types.Amount{Value: fee, Id: types.MEERID}
+		txResult.Fee = feeAmount.ToUnit(types.AmountCoin)
+		result.Transactions = append(result.Transactions, txResult)
+		result.Inputs += n
+		totalFee += fee
+	}
+	feeAmount := types.Amount{Value: totalFee, Id: types.MEERID}
+	result.Fee = feeAmount.ToUnit(types.AmountCoin)
+	return result, nil
+}
+
+// multiSigScriptSize returns the size of the signature script redeeming a
+// pay-to-script-hash output with the multisig redeem script, holding the
+// required signatures of the largest size.
+func multiSigScriptSize(redeemScript []byte, param *params.Params) (int, error) {
+	class, _, required, err := txscript.ExtractPkScriptAddrs(redeemScript, param)
+	if err != nil {
+		return 0, err
+	}
+	if class != txscript.MultiSigTy {
+		return 0, fmt.Errorf("redeem script is a %s script, not a "+
+			"multisig script", class)
+	}
+	builder := txscript.NewScriptBuilder()
+	for i := 0; i < required; i++ {
+		builder.AddData(make([]byte, 73))
+	}
+	builder.AddData(redeemScript)
+	sigScript, err := builder.Script()
+	if err != nil {
+		return 0, err
+	}
+	return len(sigScript), nil
+}
@@ -0,0 +1,121 @@
+package tx
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/json"
+	"github.com/Qitmeer/qitmeer/rpc"
+	"strings"
+)
+
+const (
+	// maxHourglassSearch is the maximum number of main chain blocks, from
+	// the main chain tip, searched for the latest hourglass block.
+	maxHourglassSearch = 100
+
+	// maxSafeTips is the number of DAG tips above which the fork activity is
+	// considered too high to credit a transaction.
+	maxSafeTips = 10
+)
+
+// IsTxSafeToCredit returns whether a transaction can be credited, such as the
+// deposit of an exchange.  The transaction must be in a valid blue block,
+// confirmed at least requiredConfidence times (blockdag.StableConfirmations
+// by default) and behind the latest hourglass block of the main chain, while
+// the node is synced and the fork activity is low.  The explanation of the
+// result lists every unmet condition.
+func (api *PublicTxAPI) IsTxSafeToCredit(txHash hash.Hash, requiredConfidence *uint32) (interface{}, error) {
+	required := uint64(blockdag.StableConfirmations)
+	if requiredConfidence != nil {
+		required = uint64(*requiredConfidence)
+	}
+	result := &json.TxSafeToCreditResult{
+		TxId:                  txHash.String(),
+		RequiredConfirmations: required,
+		HourglassDistance:     -1,
+	}
+
+	tx, _ := api.txManager.txMemPool.FetchTransaction(&txHash)
+	if tx != nil {
+		result.Explanation = "transaction is not in a block yet"
+		return result, nil
+	}
+	txIndex := api.txManager.txIndex
+	if txIndex == nil {
+		return nil, fmt.Errorf("the transaction index " +
+			"must be enabled to query the blockchain (specify --txindex in configuration)")
+	}
+	blockRegion, err := txIndex.TxBlockRegion(txHash)
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to retrieve transaction location")
+	}
+	if blockRegion == nil {
+		return nil, rpc.RpcNoTxInfoError(&txHash)
+	}
+	result.BlockHash = blockRegion.Hash.String()
+
+	chain := api.txManager.bm.GetChain()
+	bd := chain.BlockDAG()
+	ib := bd.GetBlock(blockRegion.Hash)
+	if ib == nil {
+		return nil, rpc.RpcInternalError(fmt.Errorf("no block").Error(),
+			fmt.Sprintf("Block not found: %v", blockRegion.Hash))
+	}
+	result.Confirmations = uint64(bd.GetConfirmations(ib.GetID()))
+	result.IsBlue = bd.IsBlue(ib.GetID())
+	result.Tips = bd.GetTips().Size()
+
+	var reasons []string
+	if !api.txManager.bm.IsCurrent() {
+		reasons = append(reasons, "node is not synced")
+	}
+	if ib.GetStatus().KnownInvalid() {
+		reasons = append(reasons, "block is invalid")
+	}
+	if chain.IsDuplicateTx(&txHash, blockRegion.Hash) {
+		reasons = append(reasons, "transaction is a duplicate")
+	}
+	if !result.IsBlue {
+		reasons = append(reasons, "block is not blue")
+	}
+	if result.Confirmations < required {
+		reasons = append(reasons, fmt.Sprintf("%d confirmations, %d required",
+			result.Confirmations, required))
+	}
+	hourglass := latestHourglass(bd)
+	if hourglass != nil && ib.IsOrdered() {
+		result.HourglassDistance = int64(hourglass.GetOrder()) - int64(ib.GetOrder())
+	}
+	if hourglass == nil {
+		reasons = append(reasons, "no recent hourglass block on the main chain")
+	} else if result.HourglassDistance < 0 {
+		reasons = append(reasons, "block is not behind the latest hourglass block")
+	}
+	if result.Tips > maxSafeTips {
+		reasons = append(reasons, fmt.Sprintf("high fork activity, %d tips",
+			result.Tips))
+	}
+
+	result.Safe = len(reasons) == 0
+	if result.Safe {
+		result.Explanation = "transaction is safe to credit"
+	} else {
+		result.Explanation = strings.Join(reasons, "; ")
+	}
+	return result, nil
+}
+
+// latestHourglass returns the latest hourglass block of the main chain, which
+// can not be reorganized anymore, or nil when none of the last
+// maxHourglassSearch main chain blocks is an hourglass.
+func latestHourglass(bd *blockdag.BlockDAG) blockdag.IBlock {
+	cur := bd.GetMainChainTip()
+	for i := 0; cur != nil && i < maxHourglassSearch; i++ {
+		if bd.IsHourglass(cur.GetID()) {
+			return cur
+		}
+		cur = bd.GetBlockById(cur.GetMainParent())
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"github.com/Qitmeer/qitmeer/core/json"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/rpc"
+	"github.com/Qitmeer/qitmeer/services/common"
+)
+
+// traceScriptNames names the scripts of a trace by script index.
+var traceScriptNames = []string{"signature", "pubkey", "redeem"}
+
+// DebugScript executes a signature script against a public key script with
+// the standard verification flags and returns the trace of every executed
+// opcode along with the failure, if any.  Without hexTx, the scripts are
+// executed by a transaction holding a single input, hence signature checks
+// fail.  With hexTx, the input at index of that transaction is executed: an
+// empty signature script stands for the one of the input and an empty public
+// key script for the one of the spent output, looked up in the mempool and in
+// the UTXO set.
+func (api *PublicTxAPI) DebugScript(signScript string, pkScript string, hexTx *string, index *uint32) (interface{}, error) {
+	sigScript, err := hex.DecodeString(signScript)
+	if err != nil {
+		return nil, rpc.RpcDecodeHexError(signScript)
+	}
+	script, err := hex.DecodeString(pkScript)
+	if err != nil {
+		return nil, rpc.RpcDecodeHexError(pkScript)
+	}
+
+	idx := 0
+	mtx := types.NewTransaction()
+	if hexTx == nil {
+		mtx.AddTxIn(types.NewTxInput(&types.TxOutPoint{}, sigScript))
+	} else {
+		serializedTx, err := hex.DecodeString(*hexTx)
+		if err != nil {
+			return nil, rpc.RpcDecodeHexError(*hexTx)
+		}
+		err = mtx.Deserialize(bytes.NewReader(serializedTx))
+		if err != nil {
+			return nil, rpc.RpcDeserializationError("Could not decode Tx: %v",
+				err)
+		}
+		if index != nil {
+			idx = int(*index)
+		}
+		if idx >= len(mtx.TxIn) {
+			return nil, rpc.RpcInvalidError("Input %d out of range, the "+
+				"transaction has %d inputs", idx, len(mtx.TxIn))
+		}
+		if len(sigScript) > 0 {
+			mtx.TxIn[idx].SignScript = sigScript
+		}
+		if len(script) == 0 {
+			script, err = api.spentPkScript(&mtx.TxIn[idx].PreviousOut)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	flags, err := common.StandardScriptVerifyFlags()
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Script verify flags")
+	}
+	result := &json.DebugScriptResult{
+		PkScriptType: txscript.GetScriptClass(txscript.DefaultScriptVersion, script).String(),
+		FailedStep:   -1,
+	}
+	vm, err := txscript.NewEngine(script, mtx, idx, flags,
+		txscript.DefaultScriptVersion, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	trace, err := vm.ExecuteWithTrace()
+	result.Success = err == nil
+	if err != nil {
+		result.Error = err.Error()
+		result.FailedStep = len(trace) - 1
+	}
+	result.Steps = make([]json.ScriptStepResult, 0, len(trace))
+	for _, step := range trace {
+		result.Steps = append(result.Steps, json.ScriptStepResult{
+			Script:   traceScriptNames[step.ScriptIdx],
+			Offset:   step.ScriptOff,
+			Opcode:   step.Opcode,
+			Stack:    hexStack(step.Stack),
+			AltStack: hexStack(step.AltStack),
+		})
+	}
+	return result, nil
+}
+
+// spentPkScript returns the public key script of the output spent by an
+// input, looked up in the mempool and then in the UTXO set.
+func (api *PublicTxAPI) spentPkScript(outpoint *types.TxOutPoint) ([]byte, error) {
+	tx, _ := api.txManager.txMemPool.FetchTransaction(&outpoint.Hash)
+	if tx != nil {
+		if outpoint.OutIndex >= uint32(len(tx.Transaction().TxOut)) {
+			return nil, rpc.RpcInvalidError("Output %v is unknown", outpoint)
+		}
+		return tx.Transaction().TxOut[outpoint.OutIndex].PkScript, nil
+	}
+	entry, err := api.txManager.bm.GetChain().FetchUtxoEntry(*outpoint)
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to fetch utxo")
+	}
+	if entry == nil || entry.IsSpent() {
+		return nil, rpc.RpcInvalidError("Output %v is spent or unknown",
+			outpoint)
+	}
+	return entry.PkScript(), nil
+}
+
+// hexStack returns the hex encoded items of a stack.
+func hexStack(stack [][]byte) []string {
+	items := make([]string, 0, len(stack))
+	for _, item := range stack {
+		items = append(items, hex.EncodeToString(item))
+	}
+	return items
+}
@@ -0,0 +1,120 @@
+package tx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/database"
+	"github.com/Qitmeer/qitmeer/rpc"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// externalSignerTimeout is the time given to the external signer to return
+// the signed transaction, long enough for the user to confirm the signature
+// on a hardware wallet.
+const externalSignerTimeout = 5 * time.Minute
+
+// runExternalSigner executes the external signer command, which reads the hex
+// encoded partially signed transaction on its standard input and writes it
+// back with its signatures on its standard output.
+func runExternalSigner(command string, psbt string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), externalSignerTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = strings.NewReader(psbt + "\n")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("external signer timed out after %v",
+				externalSignerTimeout)
+		}
+		return "", fmt.Errorf("external signer failed: %v: %s", err,
+			strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// ExternalSignSpendProposal sends the partially signed transaction of a stored
+// proposal to the external signer configured by --externalsigner, such as a
+// bridge to a hardware wallet, and adds the signatures it returns to the
+// proposal.  The private keys never reach the node, which only verifies the
+// signatures against the redeem script.
+func (api *PublicTxAPI) ExternalSignSpendProposal(id hash.Hash) (interface{}, error) {
+	command := api.txManager.config.ExternalSigner
+	if len(command) == 0 {
+		return nil, rpc.RpcInvalidError("No external signer, use " +
+			"--externalsigner to configure it")
+	}
+	param := api.txManager.bm.ChainParams()
+	var p *spendProposal
+	err := api.txManager.db.View(func(dbTx database.Tx) error {
+		var err error
+		p, err = dbFetchSpendProposal(dbTx, &id, param)
+		return err
+	})
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to fetch proposal")
+	}
+	if p == nil {
+		return nil, rpc.RpcInvalidError("Unknown spend proposal %s", id)
+	}
+	request, err := p.psbt()
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to encode proposal")
+	}
+
+	log.Info("Sending spend proposal to the external signer", "id", id)
+	response, err := runExternalSigner(command, request)
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "External signer")
+	}
+	signed, err := decodePsbt(response, param)
+	if err != nil {
+		return nil, err
+	}
+	if signed.tx.TxHash() != id {
+		return nil, rpc.RpcInvalidError("External signer returned the "+
+			"partially signed transaction %s of another proposal",
+			signed.tx.TxHash())
+	}
+
+	added := 0
+	err = api.txManager.db.Update(func(dbTx database.Tx) error {
+		var err error
+		p, err = dbFetchSpendProposal(dbTx, &id, param)
+		if err != nil {
+			return err
+		}
+		if p == nil {
+			return fmt.Errorf("spend proposal %s was removed", id)
+		}
+		before := p.numSignatures()
+		err = p.merge(signed)
+		if err != nil {
+			return err
+		}
+		added = p.numSignatures() - before
+		return dbPutSpendProposal(dbTx, p)
+	})
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to import proposal")
+	}
+	if added == 0 {
+		return nil, rpc.RpcInvalidError("External signer returned no new " +
+			"signature")
+	}
+	log.Info("Imported signatures of the external signer", "id", id,
+		"signatures", added)
+	return spendProposalResult(p)
+}
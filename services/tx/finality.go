@@ -0,0 +1,92 @@
+package tx
+
+import (
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockdag"
+	"github.com/Qitmeer/qitmeer/core/json"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"math"
+)
+
+// txFinality returns the finality of a transaction in the block of the hash,
+// or in the memory pool when the hash is nil.  The score is 0 until the block
+// is a valid blue block.  Half of it comes from the blue confirmations of the
+// block, up to blockdag.StableConfirmations, and the other half is given once
+// the block is behind the latest hourglass block of the main chain.  It is
+// then scaled by the confirmations of the outputs spent by the transaction
+// when they are known.
+func (api *PublicTxAPI) txFinality(tx *types.Tx, blkHash *hash.Hash) *json.TxFinalityResult {
+	stable := uint64(blockdag.StableConfirmations)
+	result := &json.TxFinalityResult{HourglassDistance: -1}
+
+	inputConfirms, inputsKnown := api.inputConfirmations(tx)
+	result.MinInputConfirmations = inputConfirms
+	result.InputsMatured = inputsKnown && inputConfirms >= stable
+	if blkHash == nil {
+		return result
+	}
+
+	bd := api.txManager.bm.GetChain().BlockDAG()
+	ib := bd.GetBlock(blkHash)
+	if ib == nil || ib.GetStatus().KnownInvalid() {
+		return result
+	}
+	result.Confirmations = uint64(bd.GetConfirmations(ib.GetID()))
+	result.IsBlue = bd.IsBlue(ib.GetID())
+	hourglass := latestHourglass(bd)
+	if hourglass != nil && ib.IsOrdered() {
+		result.HourglassDistance = int64(hourglass.GetOrder()) - int64(ib.GetOrder())
+	}
+	if !result.IsBlue {
+		return result
+	}
+
+	score := math.Min(float64(result.Confirmations)/float64(stable), 1) / 2
+	if result.HourglassDistance >= 0 {
+		score += 0.5
+	}
+	if inputsKnown {
+		score *= math.Min(float64(inputConfirms)/float64(stable), 1)
+	}
+	result.Score = score
+	return result
+}
+
+// inputConfirmations returns the lowest confirmations of the blocks of the
+// outputs spent by a transaction, and whether they are all known.  The
+// outputs of the memory pool have no confirmations.  A transaction spending
+// no outputs, such as a coinbase, is reported with stable confirmations.
+func (api *PublicTxAPI) inputConfirmations(tx *types.Tx) (uint64, bool) {
+	stable := uint64(blockdag.StableConfirmations)
+	if tx.Tx.IsCoinBase() || types.IsTokenTx(tx.Tx) {
+		return stable, true
+	}
+	txIndex := api.txManager.txIndex
+	bd := api.txManager.bm.GetChain().BlockDAG()
+	min := uint64(math.MaxUint64)
+	for _, txIn := range tx.Tx.TxIn {
+		prevHash := txIn.PreviousOut.Hash
+		if api.txManager.txMemPool.HaveTransaction(&prevHash) {
+			return 0, true
+		}
+		if txIndex == nil {
+			return 0, false
+		}
+		blockRegion, err := txIndex.TxBlockRegion(prevHash)
+		if err != nil || blockRegion == nil {
+			return 0, false
+		}
+		ib := bd.GetBlock(blockRegion.Hash)
+		if ib == nil {
+			return 0, false
+		}
+		confirmations := uint64(bd.GetConfirmations(ib.GetID()))
+		if confirmations < min {
+			min = confirmations
+		}
+	}
+	if min == math.MaxUint64 {
+		return stable, true
+	}
+	return min, true
+}
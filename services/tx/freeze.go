@@ -0,0 +1,84 @@
+package tx
+
+import (
+	"context"
+	"github.com/Qitmeer/qitmeer/core/json"
+	"github.com/Qitmeer/qitmeer/rpc"
+	"github.com/Qitmeer/qitmeer/services/mempool"
+)
+
+// FreezeAPI manages the coins frozen on a private network (--freezecoins).
+// Its methods are only served to the authenticated clients, and the calls
+// changing the freeze list are recorded in the audit log (--auditlog).
+type FreezeAPI struct {
+	freezeList *mempool.FreezeList
+}
+
+func NewFreezeAPI(fl *mempool.FreezeList) *FreezeAPI {
+	return &FreezeAPI{fl}
+}
+
+// checkAuthenticated returns an error when the client of the call did not
+// authenticate.
+func checkAuthenticated(ctx context.Context) error {
+	if _, ok := ctx.Value("user").(string); !ok {
+		return rpc.RpcInvalidError("The freeze list can only be managed " +
+			"by an authenticated client")
+	}
+	return nil
+}
+
+// FreezeCoins blocks the spending of the outpoints, formatted as
+// <txid>:<index>, and of the outputs paying to the addresses in the memory
+// pool and the block templates, and returns the entries of the freeze list.
+// Nothing is frozen when one of the targets is invalid.
+func (api *FreezeAPI) FreezeCoins(ctx context.Context, targets []string, reason *string) (interface{}, error) {
+	err := checkAuthenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r := ""
+	if reason != nil {
+		r = *reason
+	}
+	coins, err := api.freezeList.Freeze(targets, r)
+	if err != nil {
+		return nil, rpc.RpcInvalidError(err.Error())
+	}
+	return frozenCoinResults(coins), nil
+}
+
+// UnfreezeCoins removes the outpoints and the addresses from the freeze list.
+// Nothing is unfrozen when one of the targets is invalid or not frozen.
+func (api *FreezeAPI) UnfreezeCoins(ctx context.Context, targets []string) (interface{}, error) {
+	err := checkAuthenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = api.freezeList.Unfreeze(targets)
+	if err != nil {
+		return nil, rpc.RpcInvalidError(err.Error())
+	}
+	return len(targets), nil
+}
+
+// ListFrozenCoins returns the entries of the freeze list sorted by target.
+func (api *FreezeAPI) ListFrozenCoins(ctx context.Context) (interface{}, error) {
+	err := checkAuthenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return frozenCoinResults(api.freezeList.Coins()), nil
+}
+
+func frozenCoinResults(coins []mempool.FrozenCoin) []json.FrozenCoinResult {
+	results := make([]json.FrozenCoinResult, 0, len(coins))
+	for _, coin := range coins {
+		results = append(results, json.FrozenCoinResult{
+			Target: coin.Target,
+			Reason: coin.Reason,
+			Time:   coin.Time,
+		})
+	}
+	return results
+}
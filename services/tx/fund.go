@@ -0,0 +1,382 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/marshal"
+	"github.com/Qitmeer/qitmeer/core/address"
+	"github.com/Qitmeer/qitmeer/core/json"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/database"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/rpc"
+	"github.com/Qitmeer/qitmeer/services/mempool"
+	"sort"
+)
+
+const (
+	// p2pkhSigScriptSize is the size of the signature script redeeming a
+	// pay-to-pubkey-hash output with a compressed public key: OP_DATA_72,
+	// a signature of at most 72 bytes, OP_DATA_33 and the public key.
+	p2pkhSigScriptSize = 1 + 72 + 1 + 33
+
+	// fundAddrTxsPage is the number of transactions of a watched address
+	// loaded at once while searching its unspent outputs.
+	fundAddrTxsPage = 10000
+
+	// maxBnBTries is the maximum number of steps of the branch-and-bound
+	// coin selection before it gives up.
+	maxBnBTries = 100000
+)
+
+// These constants are the coin selection strategies of the
+// fundRawTransaction command.
+const (
+	// strategyBnB searches the inputs which pay the outputs and the fee
+	// without any change, wasting less than the cost of a change output,
+	// and falls back to strategyLargestFirst when there are none.
+	strategyBnB = "bnb"
+
+	// strategyLargestFirst adds the largest inputs until the outputs and
+	// the fee are paid.
+	strategyLargestFirst = "largestfirst"
+)
+
+// fundCandidate is an unspent output of a watched address which may be spent
+// by the transactions built for the address.
+type fundCandidate struct {
+	outPoint types.TxOutPoint
+	value    int64
+
+	// effValue is the value minus the fee of the input spending it.
+	effValue int64
+}
+
+// FundRawTransaction adds to the unsigned transaction the inputs spending
+// the unspent outputs of the watched addresses (--walletnotifyaddr) needed
+// to pay its MEER outputs and the fee at feeRate atoms/kB (the minimum relay
+// fee by default), along with a change output to changeAddress (the first
+// watched address by default) when the change is not dust.  The inputs are
+// selected with the strategy, bnb (the default) or largestfirst.  Only the
+// confirmed pay-to-pubkey-hash outputs which are not coinbases are spent,
+// and the address index must be enabled to find them.
+func (api *PublicTxAPI) FundRawTransaction(hexTx string, feeRate *int64, strategy *string, changeAddress *string) (interface{}, error) {
+	addrIndex := api.txManager.addrIndex
+	if addrIndex == nil {
+		return nil, fmt.Errorf("Address index must be enabled (--addrindex)")
+	}
+	watchAddrs := api.txManager.config.GetWalletNotifyAddrs()
+	if len(watchAddrs) == 0 {
+		return nil, fmt.Errorf("No watched address (--walletnotifyaddr)")
+	}
+	rate := api.txManager.txMemPool.MinRelayTxFee()
+	if feeRate != nil {
+		rate = *feeRate
+	}
+	if rate < 0 {
+		return nil, rpc.RpcInvalidError("Fee rate %d is negative", rate)
+	}
+	strat := strategyBnB
+	if strategy != nil {
+		strat = *strategy
+	}
+	if strat != strategyBnB && strat != strategyLargestFirst {
+		return nil, rpc.RpcInvalidError("Unknown strategy %s, expected %s "+
+			"or %s", strat, strategyBnB, strategyLargestFirst)
+	}
+	changeAddr := watchAddrs[0]
+	if changeAddress != nil {
+		addr, err := address.DecodeAddress(*changeAddress)
+		if err != nil {
+			return nil, rpc.RpcAddressKeyError("Could not decode "+
+				"address: %v", err)
+		}
+		if !address.IsForNetwork(addr, api.txManager.bm.ChainParams()) {
+			return nil, rpc.RpcAddressKeyError("Wrong network: %v",
+				addr)
+		}
+		changeAddr = addr
+	}
+	changeScript, err := txscript.PayToAddrScript(changeAddr)
+	if err != nil {
+		return nil, rpc.RpcAddressKeyError(err.Error())
+	}
+
+	hexStr := hexTx
+	if len(hexStr)%2 != 0 {
+		hexStr = "0" + hexStr
+	}
+	serializedTx, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, rpc.RpcDecodeHexError(hexStr)
+	}
+	mtx := types.NewTransaction()
+	err = mtx.Deserialize(bytes.NewReader(serializedTx))
+	if err != nil {
+		return nil, rpc.RpcDeserializationError("Could not decode Tx: %v",
+			err)
+	}
+
+	feeFor := func(size int) int64 {
+		return feeForSize(size, rate)
+	}
+
+	// Value the outputs to pay and the inputs already spent by the
+	// transaction.
+	var outValue, inValue int64
+	for _, txOut := range mtx.TxOut {
+		if txOut.Amount.Id != types.MEERID {
+			return nil, rpc.RpcInvalidError("Only MEER outputs can be "+
+				"funded, got coin %v", txOut.Amount.Id)
+		}
+		outValue += txOut.Amount.Value
+	}
+	spent := make(map[types.TxOutPoint]struct{}, len(mtx.TxIn))
+	chain := api.txManager.bm.GetChain()
+	for _, txIn := range mtx.TxIn {
+		entry, err := chain.FetchUtxoEntry(txIn.PreviousOut)
+		if err != nil {
+			return nil, rpc.RpcInternalError(err.Error(),
+				"Failed to fetch the spent output")
+		}
+		if entry == nil || entry.IsSpent() ||
+			entry.Amount().Id != types.MEERID {
+			return nil, rpc.RpcInvalidError("Input %v is not an "+
+				"unspent MEER output", txIn.PreviousOut)
+		}
+		inValue += entry.Amount().Value
+		spent[txIn.PreviousOut] = struct{}{}
+	}
+
+	var candidates []fundCandidate
+	for _, addr := range watchAddrs {
+		utxos, err := api.addressUtxos(addr, txscript.PubKeyHashTy, spent)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, utxos...)
+	}
+	inputSize := (&types.TxInput{
+		SignScript: make([]byte, p2pkhSigScriptSize)}).SerializeSize()
+	for i := range candidates {
+		candidates[i].effValue = candidates[i].value - feeFor(inputSize)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].effValue > candidates[j].effValue
+	})
+	for len(candidates) > 0 && candidates[len(candidates)-1].effValue <= 0 {
+		candidates = candidates[:len(candidates)-1]
+	}
+
+	// The size of the transaction without the added inputs, counting the
+	// signature scripts of its own unsigned inputs.
+	baseSize := mtx.SerializeSize()
+	for _, txIn := range mtx.TxIn {
+		if len(txIn.SignScript) == 0 {
+			baseSize += p2pkhSigScriptSize
+		}
+	}
+	target := outValue - inValue + feeFor(baseSize)
+	changeOut := types.NewTxOutput(types.Amount{Id: types.MEERID}, changeScript)
+	changeFee := feeFor(changeOut.SerializeSize())
+	costOfChange := changeFee + feeFor(inputSize)
+
+	// Select the inputs and the change.
+	var selected []int
+	if strat == strategyBnB {
+		effValues := make([]int64, len(candidates))
+		for i, c := range candidates {
+			effValues[i] = c.effValue
+		}
+		selected = selectBranchAndBound(effValues, target, costOfChange)
+	}
+	change := int64(0)
+	if selected == nil {
+		sum := int64(0)
+		for i := 0; i < len(candidates) && sum < target; i++ {
+			selected = append(selected, i)
+			sum += candidates[i].effValue
+		}
+		if sum < target {
+			return nil, rpc.RpcInvalidError("Insufficient funds: the "+
+				"watched addresses are missing %d atoms",
+				target-sum)
+		}
+		change = sum - target - changeFee
+		changeOut.Amount.Value = change
+		if change <= 0 || mempool.IsDust(changeOut,
+			types.Amount{Value: api.txManager.txMemPool.MinRelayTxFee(),
+				Id: types.MEERID}) {
+			change = 0
+		}
+	}
+
+	for _, i := range selected {
+		mtx.AddTxIn(types.NewTxInput(&candidates[i].outPoint, []byte{}))
+	}
+	changePos := -1
+	if change > 0 {
+		changeOut.Amount.Value = change
+		mtx.AddTxOut(changeOut)
+		changePos = len(mtx.TxOut) - 1
+	}
+
+	fee := inValue - outValue - change
+	for _, i := range selected {
+		fee += candidates[i].value
+	}
+	mtxHex, err := marshal.MessageToHex(mtx)
+	if err != nil {
+		return nil, err
+	}
+	feeAmount := types.Amount{Value: fee, Id: types.MEERID}
+	return &json.FundRawTransactionResult{
+		Hex:       mtxHex,
+		Fee:       feeAmount.ToUnit(types.AmountCoin),
+		ChangePos: changePos,
+	}, nil
+}
+
+// addressUtxos returns the confirmed unspent MEER outputs of the class paid
+// to the address which are neither coinbases, spent by the memory pool nor in
+// spent.  All the transactions of the address in the index are searched, page
+// by page.  The returned outputs are added to spent.
+func (api *PublicTxAPI) addressUtxos(addr types.Address, class txscript.ScriptClass,
+	spent map[types.TxOutPoint]struct{}) ([]fundCandidate, error) {
+
+	params := api.txManager.bm.ChainParams()
+	chain := api.txManager.bm.GetChain()
+	var candidates []fundCandidate
+	for skip := uint32(0); ; skip += fundAddrTxsPage {
+		txs, err := api.addressTxs(addr, skip)
+		if err != nil {
+			return nil, err
+		}
+		for _, mtx := range txs {
+			if mtx.IsCoinBase() {
+				continue
+			}
+			txHash := mtx.TxHash()
+			for i, txOut := range mtx.TxOut {
+				outClass, addrs, _, err := txscript.ExtractPkScriptAddrs(
+					txOut.PkScript, params)
+				if err != nil || outClass != class || len(addrs) != 1 ||
+					addrs[0].Encode() != addr.Encode() {
+					continue
+				}
+				op := *types.NewOutPoint(&txHash, uint32(i))
+				if _, ok := spent[op]; ok {
+					continue
+				}
+				spent[op] = struct{}{}
+				if api.txManager.txMemPool.CheckSpend(op) != nil {
+					continue
+				}
+				entry, err := chain.FetchUtxoEntry(op)
+				if err != nil {
+					return nil, rpc.RpcInternalError(err.Error(),
+						"Failed to fetch the unspent output")
+				}
+				if entry == nil || entry.IsSpent() ||
+					entry.Amount().Id != types.MEERID {
+					continue
+				}
+				candidates = append(candidates, fundCandidate{
+					outPoint: op,
+					value:    entry.Amount().Value,
+				})
+			}
+		}
+		if len(txs) < fundAddrTxsPage {
+			return candidates, nil
+		}
+	}
+}
+
+// addressTxs returns the page of at most fundAddrTxsPage transactions of the
+// address in the index after the first skip ones, oldest first.
+func (api *PublicTxAPI) addressTxs(addr types.Address, skip uint32) ([]*types.Transaction, error) {
+	var txs []*types.Transaction
+	err := api.txManager.db.View(func(dbTx database.Tx) error {
+		regions, _, err := api.txManager.addrIndex.TxRegionsForAddress(
+			dbTx, addr, skip, fundAddrTxsPage, false)
+		if err != nil {
+			return err
+		}
+		serializedTxns, err := dbTx.FetchBlockRegions(regions)
+		if err != nil {
+			return err
+		}
+		for _, serializedTx := range serializedTxns {
+			var mtx types.Transaction
+			err := mtx.Deserialize(bytes.NewReader(serializedTx))
+			if err != nil {
+				return err
+			}
+			txs = append(txs, &mtx)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(),
+			"Failed to load address index entries")
+	}
+	return txs, nil
+}
+
+// feeForSize returns the fee of size bytes at rate atoms/kB.  The fee is
+// rounded up, so that the fees of the parts of a transaction add up to at
+// least the fee of the whole.
+func feeForSize(size int, rate int64) int64 {
+	return (int64(size)*rate + 999) / 1000
+}
+
+// selectBranchAndBound returns the indexes of the subset of the values,
+// sorted in decreasing order, which adds up to at least target and at most
+// target plus tolerance, with the least excess.  It returns nil when there
+// is none or the search took too many steps.
+func selectBranchAndBound(values []int64, target, tolerance int64) []int {
+	remaining := int64(0)
+	for _, v := range values {
+		remaining += v
+	}
+	if remaining < target {
+		return nil
+	}
+
+	var best, selected []int
+	bestExcess := tolerance + 1
+	tries := 0
+	// search explores the subsets of the values from i on, and returns
+	// true once the search must stop.
+	var search func(i int, sum, remaining int64) bool
+	search = func(i int, sum, remaining int64) bool {
+		tries++
+		if tries > maxBnBTries {
+			return true
+		}
+		if sum > target+tolerance || sum+remaining < target {
+			return false
+		}
+		if sum >= target {
+			// Adding more values would only increase the excess.
+			if excess := sum - target; excess < bestExcess {
+				bestExcess = excess
+				best = append(best[:0], selected...)
+			}
+			return bestExcess == 0
+		}
+		if i == len(values) {
+			return false
+		}
+		selected = append(selected, i)
+		if search(i+1, sum+values[i], remaining-values[i]) {
+			return true
+		}
+		selected = selected[:len(selected)-1]
+		return search(i+1, sum, remaining-values[i])
+	}
+	search(0, 0, remaining)
+	return best
+}
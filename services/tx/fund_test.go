@@ -0,0 +1,105 @@
+package tx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFeeForSize(t *testing.T) {
+	tests := []struct {
+		size int
+		rate int64
+		fee  int64
+	}{
+		{0, 1000, 0},
+		{250, 0, 0},
+		{1000, 1000, 1000},
+		{250, 1000, 250},
+		// The fee is rounded up.
+		{1, 1, 1},
+		{225, 10, 3},
+		{999, 1001, 1000},
+	}
+	for _, test := range tests {
+		if fee := feeForSize(test.size, test.rate); fee != test.fee {
+			t.Errorf("fee of %d bytes at %d atoms/kB is %d, want %d",
+				test.size, test.rate, fee, test.fee)
+		}
+	}
+
+	// The fees of the parts add up to at least the fee of the whole.
+	whole := feeForSize(300, 333)
+	if parts := feeForSize(100, 333) * 3; parts < whole {
+		t.Errorf("fees of the parts %d below the fee of the whole %d",
+			parts, whole)
+	}
+}
+
+func TestSelectBranchAndBound(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    []int64
+		target    int64
+		tolerance int64
+		selected  []int
+	}{
+		{
+			name:     "exact match",
+			values:   []int64{50, 30, 20, 10},
+			target:   40,
+			selected: []int{1, 3},
+		},
+		{
+			name:      "least excess within the tolerance",
+			values:    []int64{50, 33, 21, 8},
+			target:    40,
+			tolerance: 5,
+			selected:  []int{1, 3},
+		},
+		{
+			name:      "first exact match",
+			values:    []int64{40, 30, 10},
+			target:    40,
+			tolerance: 10,
+			selected:  []int{0},
+		},
+		{
+			name:      "excess over the tolerance",
+			values:    []int64{50, 30},
+			target:    40,
+			tolerance: 5,
+			selected:  nil,
+		},
+		{
+			name:     "insufficient values",
+			values:   []int64{20, 10},
+			target:   40,
+			selected: nil,
+		},
+		{
+			name:     "no values",
+			target:   1,
+			selected: nil,
+		},
+	}
+	for _, test := range tests {
+		selected := selectBranchAndBound(test.values, test.target,
+			test.tolerance)
+		if !reflect.DeepEqual(selected, test.selected) {
+			t.Errorf("%s: selected %v, want %v", test.name, selected,
+				test.selected)
+		}
+	}
+}
+
+func TestSelectBranchAndBoundTries(t *testing.T) {
+	// No subset of the even values adds up to an odd target, and there
+	// are too many of them to explore.
+	values := make([]int64, 60)
+	for i := range values {
+		values[i] = int64(2 * (len(values) - i))
+	}
+	if selected := selectBranchAndBound(values, 1001, 0); selected != nil {
+		t.Fatalf("selected %v for an odd target", selected)
+	}
+}
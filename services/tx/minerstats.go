@@ -0,0 +1,79 @@
+package tx
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/math"
+	"github.com/Qitmeer/qitmeer/core/address"
+	"github.com/Qitmeer/qitmeer/core/json"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/rpc"
+)
+
+// GetMinerStats returns the income paid to a coinbase address by the valid
+// blocks between the start and end orders, both included, along with the
+// subsidy expected by the consensus rules, so that a miner can audit its
+// income.  The blocks are listed when verbose is true.
+func (api *PublicTxAPI) GetMinerStats(addr string, startOrder *uint32, endOrder *uint32, verbose *bool) (interface{}, error) {
+	minerIndex := api.txManager.minerIndex
+	if minerIndex == nil {
+		return nil, fmt.Errorf("Miner index must be enabled (--minerindex)")
+	}
+	a, err := address.DecodeAddress(addr)
+	if err != nil {
+		return nil, rpc.RpcInvalidError("Invalid address or key: %v", err)
+	}
+	start := uint32(0)
+	if startOrder != nil {
+		start = *startOrder
+	}
+	end := uint32(math.MaxUint32)
+	if endOrder != nil {
+		end = *endOrder
+	}
+	if start > end {
+		return nil, rpc.RpcInvalidError("Start order %d is after end "+
+			"order %d", start, end)
+	}
+
+	blocks, err := minerIndex.MinedBlocks(a, start, end)
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to fetch mined blocks")
+	}
+
+	bd := api.txManager.bm.GetChain().BlockDAG()
+	result := &json.MinerStatsResult{
+		Address:    addr,
+		StartOrder: uint64(start),
+		EndOrder:   uint64(end),
+		Blocks:     uint64(len(blocks)),
+	}
+	var subsidy, expectedSubsidy, fees int64
+	for _, b := range blocks {
+		subsidy += b.Subsidy
+		expectedSubsidy += b.ExpectedSubsidy
+		fees += b.Fees
+
+		blockResult := json.MinedBlockResult{
+			Order:           uint64(b.Order),
+			Subsidy:         coinAmount(b.Subsidy, types.MEERID),
+			ExpectedSubsidy: coinAmount(b.ExpectedSubsidy, types.MEERID),
+			Fees:            coinAmount(b.Fees, types.MEERID),
+		}
+		h := bd.GetBlockHashByOrder(uint(b.Order))
+		if h != nil {
+			blockResult.Hash = h.String()
+			blockResult.IsBlue = bd.IsBlue(bd.GetBlockId(h))
+		}
+		if blockResult.IsBlue {
+			result.BlueBlocks++
+		}
+		if verbose != nil && *verbose {
+			result.BlockList = append(result.BlockList, blockResult)
+		}
+	}
+	result.Subsidy = coinAmount(subsidy, types.MEERID)
+	result.ExpectedSubsidy = coinAmount(expectedSubsidy, types.MEERID)
+	result.Fees = coinAmount(fees, types.MEERID)
+	result.Reward = coinAmount(subsidy+fees, types.MEERID)
+	return result, nil
+}
@@ -0,0 +1,526 @@
+package tx
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/address"
+	"github.com/Qitmeer/qitmeer/core/dbnamespace"
+	"github.com/Qitmeer/qitmeer/core/json"
+	s "github.com/Qitmeer/qitmeer/core/serialization"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/crypto/ecc"
+	"github.com/Qitmeer/qitmeer/database"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/params"
+	"github.com/Qitmeer/qitmeer/rpc"
+	"io"
+)
+
+// spendProposalMagic starts the partially signed transaction of a spend
+// proposal.
+var spendProposalMagic = []byte("qpst")
+
+// spendProposal is a transaction spending outputs paid to a multisig redeem
+// script, whose signatures are collected from the cosigners until enough of
+// them are known to broadcast it.  Its partially signed transaction holds the
+// unsigned transaction, the redeem script and the collected signatures.
+type spendProposal struct {
+	tx           *types.Transaction
+	redeemScript []byte
+	pubKeys      []ecc.PublicKey
+	required     int
+
+	// sigs holds the signatures of each input by the index of the public
+	// key in the redeem script.
+	sigs []map[int][]byte
+
+	// broadcast tells whether the signed transaction was broadcast.
+	broadcast bool
+}
+
+// newSpendProposal returns a proposal without signatures for the unsigned
+// transaction spending outputs paid to the redeem script.
+func newSpendProposal(tx *types.Transaction, redeemScript []byte,
+	param *params.Params) (*spendProposal, error) {
+
+	class, addrs, required, err := txscript.ExtractPkScriptAddrs(redeemScript, param)
+	if err != nil {
+		return nil, err
+	}
+	if class != txscript.MultiSigTy {
+		return nil, fmt.Errorf("redeem script is a %s script, not a "+
+			"multisig script", class)
+	}
+	numPubKeys, _, err := txscript.CalcMultiSigStats(redeemScript)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) != numPubKeys {
+		return nil, fmt.Errorf("redeem script holds invalid public keys")
+	}
+	pubKeys := make([]ecc.PublicKey, 0, len(addrs))
+	for _, addr := range addrs {
+		pkAddr, ok := addr.(*address.SecpPubKeyAddress)
+		if !ok {
+			return nil, fmt.Errorf("redeem script holds a %T", addr)
+		}
+		pubKeys = append(pubKeys, pkAddr.PubKey())
+	}
+	if len(tx.TxIn) == 0 {
+		return nil, fmt.Errorf("transaction has no inputs")
+	}
+	sigs := make([]map[int][]byte, len(tx.TxIn))
+	for i := range sigs {
+		sigs[i] = make(map[int][]byte)
+	}
+	return &spendProposal{
+		tx:           tx,
+		redeemScript: redeemScript,
+		pubKeys:      pubKeys,
+		required:     required,
+		sigs:         sigs,
+	}, nil
+}
+
+// addSignature verifies the signature of an input and keeps it along with
+// the public key it matches.
+func (p *spendProposal) addSignature(idx int, sig []byte) error {
+	if idx < 0 || idx >= len(p.tx.TxIn) {
+		return fmt.Errorf("input %d out of range", idx)
+	}
+	if len(sig) < 1 {
+		return fmt.Errorf("empty signature for input %d", idx)
+	}
+	hashType := txscript.SigHashType(sig[len(sig)-1])
+	pSig, err := ecc.Secp256k1.ParseDERSignature(sig[:len(sig)-1])
+	if err != nil {
+		return err
+	}
+	sigHash, err := txscript.CalcSignatureHash(p.redeemScript, hashType, p.tx, idx, nil)
+	if err != nil {
+		return err
+	}
+	for i, pubKey := range p.pubKeys {
+		if ecc.Secp256k1.Verify(pubKey, sigHash, pSig.GetR(), pSig.GetS()) {
+			p.sigs[idx][i] = sig
+			return nil
+		}
+	}
+	return fmt.Errorf("signature of input %d matches no public key of the "+
+		"redeem script", idx)
+}
+
+// merge adds the signatures of another proposal of the same transaction.
+func (p *spendProposal) merge(other *spendProposal) error {
+	if p.tx.TxHash() != other.tx.TxHash() ||
+		!bytes.Equal(p.redeemScript, other.redeemScript) {
+		return fmt.Errorf("partially signed transaction of another proposal")
+	}
+	for idx, sigs := range other.sigs {
+		for k, sig := range sigs {
+			p.sigs[idx][k] = sig
+		}
+	}
+	return nil
+}
+
+// sign adds the signatures of all the inputs by the private key.
+func (p *spendProposal) sign(key ecc.PrivateKey) error {
+	for idx := range p.tx.TxIn {
+		sig, err := txscript.RawTxInSignature(p.tx, idx, p.redeemScript,
+			txscript.SigHashAll, key)
+		if err != nil {
+			return err
+		}
+		err = p.addSignature(idx, sig)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// complete returns whether every input has the required signatures.
+func (p *spendProposal) complete() bool {
+	for _, sigs := range p.sigs {
+		if len(sigs) < p.required {
+			return false
+		}
+	}
+	return true
+}
+
+// numSignatures returns the number of signatures of all the inputs.
+func (p *spendProposal) numSignatures() int {
+	count := 0
+	for _, sigs := range p.sigs {
+		count += len(sigs)
+	}
+	return count
+}
+
+// signedTx returns the transaction with the signature scripts built from the
+// collected signatures, in the order of the public keys of the redeem script.
+func (p *spendProposal) signedTx() (*types.Transaction, error) {
+	signed := *p.tx
+	signed.TxIn = make([]*types.TxInput, len(p.tx.TxIn))
+	for idx, txIn := range p.tx.TxIn {
+		builder := txscript.NewScriptBuilder()
+		count := 0
+		for k := 0; k < len(p.pubKeys) && count < p.required; k++ {
+			sig, ok := p.sigs[idx][k]
+			if !ok {
+				continue
+			}
+			builder.AddData(sig)
+			count++
+		}
+		if count < p.required {
+			return nil, fmt.Errorf("input %d has %d of the %d required "+
+				"signatures", idx, count, p.required)
+		}
+		builder.AddData(p.redeemScript)
+		script, err := builder.Script()
+		if err != nil {
+			return nil, err
+		}
+		in := *txIn
+		in.SignScript = script
+		signed.TxIn[idx] = &in
+	}
+	return &signed, nil
+}
+
+// encode writes the partially signed transaction of the proposal to w.
+func (p *spendProposal) encode(w io.Writer) error {
+	_, err := w.Write(spendProposalMagic)
+	if err != nil {
+		return err
+	}
+	err = p.tx.Encode(w, 0, types.TxSerializeFull)
+	if err != nil {
+		return err
+	}
+	err = s.WriteVarBytes(w, 0, p.redeemScript)
+	if err != nil {
+		return err
+	}
+	for _, sigs := range p.sigs {
+		err = s.WriteVarInt(w, 0, uint64(len(sigs)))
+		if err != nil {
+			return err
+		}
+		for k := range p.pubKeys {
+			sig, ok := sigs[k]
+			if !ok {
+				continue
+			}
+			err = s.WriteVarBytes(w, 0, sig)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// psbt returns the hex encoded partially signed transaction of the proposal.
+func (p *spendProposal) psbt() (string, error) {
+	var buf bytes.Buffer
+	err := p.encode(&buf)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf.Bytes()), nil
+}
+
+// decodeSpendProposal reads a partially signed transaction from r.  Every
+// signature is verified.
+func decodeSpendProposal(r io.Reader, param *params.Params) (*spendProposal, error) {
+	magic := make([]byte, len(spendProposalMagic))
+	_, err := io.ReadFull(r, magic)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(magic, spendProposalMagic) {
+		return nil, fmt.Errorf("not a partially signed transaction")
+	}
+	tx := types.NewTransaction()
+	err = tx.Decode(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	redeemScript, err := s.ReadVarBytes(r, 0, txscript.MaxScriptElementSize,
+		"redeem script")
+	if err != nil {
+		return nil, err
+	}
+	p, err := newSpendProposal(tx, redeemScript, param)
+	if err != nil {
+		return nil, err
+	}
+	for idx := range p.sigs {
+		count, err := s.ReadVarInt(r, 0)
+		if err != nil {
+			return nil, err
+		}
+		if count > uint64(len(p.pubKeys)) {
+			return nil, fmt.Errorf("too many signatures for input %d", idx)
+		}
+		for i := uint64(0); i < count; i++ {
+			sig, err := s.ReadVarBytes(r, 0, txscript.MaxScriptElementSize,
+				"signature")
+			if err != nil {
+				return nil, err
+			}
+			err = p.addSignature(idx, sig)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return p, nil
+}
+
+// decodePsbt decodes a hex encoded partially signed transaction.
+func decodePsbt(psbt string, param *params.Params) (*spendProposal, error) {
+	serialized, err := hex.DecodeString(psbt)
+	if err != nil {
+		return nil, rpc.RpcDecodeHexError(psbt)
+	}
+	p, err := decodeSpendProposal(bytes.NewReader(serialized), param)
+	if err != nil {
+		return nil, rpc.RpcDeserializationError("Could not decode "+
+			"partially signed transaction: %v", err)
+	}
+	return p, nil
+}
+
+// dbPutSpendProposal stores the proposal under its transaction hash.  The
+// broadcast flag comes first and is followed by the partially signed
+// transaction.
+func dbPutSpendProposal(dbTx database.Tx, p *spendProposal) error {
+	bucket, err := dbTx.Metadata().CreateBucketIfNotExists(
+		dbnamespace.SpendProposalBucketName)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	broadcast := byte(0)
+	if p.broadcast {
+		broadcast = 1
+	}
+	buf.WriteByte(broadcast)
+	err = p.encode(&buf)
+	if err != nil {
+		return err
+	}
+	id := p.tx.TxHash()
+	return bucket.Put(id[:], buf.Bytes())
+}
+
+// dbFetchSpendProposal returns the stored proposal, or nil when there is none.
+func dbFetchSpendProposal(dbTx database.Tx, id *hash.Hash,
+	param *params.Params) (*spendProposal, error) {
+
+	bucket := dbTx.Metadata().Bucket(dbnamespace.SpendProposalBucketName)
+	if bucket == nil {
+		return nil, nil
+	}
+	serialized := bucket.Get(id[:])
+	if len(serialized) == 0 {
+		return nil, nil
+	}
+	p, err := decodeSpendProposal(bytes.NewReader(serialized[1:]), param)
+	if err != nil {
+		return nil, err
+	}
+	p.broadcast = serialized[0] == 1
+	return p, nil
+}
+
+// spendProposalResult returns the json result of the proposal.
+func spendProposalResult(p *spendProposal) (*json.SpendProposalResult, error) {
+	psbt, err := p.psbt()
+	if err != nil {
+		return nil, err
+	}
+	signatures := make([]int, 0, len(p.sigs))
+	for _, sigs := range p.sigs {
+		signatures = append(signatures, len(sigs))
+	}
+	return &json.SpendProposalResult{
+		Id:           p.tx.TxHash().String(),
+		Psbt:         psbt,
+		RedeemScript: hex.EncodeToString(p.redeemScript),
+		Required:     p.required,
+		Signatures:   signatures,
+		Complete:     p.complete(),
+		Broadcast:    p.broadcast,
+	}, nil
+}
+
+// CreateSpendProposal creates a proposal to spend outputs paid to the P2SH
+// address of a multisig redeem script, such as a treasury, and stores it
+// until the cosigners signed it.  Every input must be an unspent output paid
+// to the redeem script.
+func (api *PublicTxAPI) CreateSpendProposal(redeemScript string, inputs []json.TransactionInput,
+	amounts json.AdreesAmount, lockTime *int64) (interface{}, error) {
+
+	script, err := hex.DecodeString(redeemScript)
+	if err != nil {
+		return nil, rpc.RpcDecodeHexError(redeemScript)
+	}
+	mtx, err := api.createRawTransaction(inputs, amounts, lockTime)
+	if err != nil {
+		return nil, err
+	}
+	param := api.txManager.bm.ChainParams()
+	p, err := newSpendProposal(mtx, script, param)
+	if err != nil {
+		return nil, rpc.RpcInvalidError(err.Error())
+	}
+
+	pkScript, err := txscript.PayToScriptHashScript(hash.Hash160(script))
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Pay to script hash script")
+	}
+	for _, txIn := range mtx.TxIn {
+		entry, err := api.txManager.bm.GetChain().FetchUtxoEntry(txIn.PreviousOut)
+		if err != nil {
+			return nil, rpc.RpcInternalError(err.Error(), "Failed to fetch utxo")
+		}
+		if entry == nil || entry.IsSpent() {
+			return nil, rpc.RpcInvalidError("Output %v is spent or unknown",
+				txIn.PreviousOut)
+		}
+		if !bytes.Equal(entry.PkScript(), pkScript) {
+			return nil, rpc.RpcInvalidError("Output %v is not paid to the "+
+				"redeem script", txIn.PreviousOut)
+		}
+	}
+
+	err = api.txManager.db.Update(func(dbTx database.Tx) error {
+		return dbPutSpendProposal(dbTx, p)
+	})
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to store proposal")
+	}
+	return spendProposalResult(p)
+}
+
+// GetSpendProposal returns a stored proposal along with its partially signed
+// transaction, which is sent to the cosigners.
+func (api *PublicTxAPI) GetSpendProposal(id hash.Hash) (interface{}, error) {
+	var p *spendProposal
+	err := api.txManager.db.View(func(dbTx database.Tx) error {
+		var err error
+		p, err = dbFetchSpendProposal(dbTx, &id, api.txManager.bm.ChainParams())
+		return err
+	})
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to fetch proposal")
+	}
+	if p == nil {
+		return nil, rpc.RpcInvalidError("Unknown spend proposal %s", id)
+	}
+	return spendProposalResult(p)
+}
+
+// ImportSpendProposal adds the signatures of a partially signed transaction
+// returned by a cosigner to the stored proposal.  The proposal is created when
+// it is not known yet.
+func (api *PublicTxAPI) ImportSpendProposal(psbt string) (interface{}, error) {
+	param := api.txManager.bm.ChainParams()
+	imported, err := decodePsbt(psbt, param)
+	if err != nil {
+		return nil, err
+	}
+	id := imported.tx.TxHash()
+	var p *spendProposal
+	err = api.txManager.db.Update(func(dbTx database.Tx) error {
+		var err error
+		p, err = dbFetchSpendProposal(dbTx, &id, param)
+		if err != nil {
+			return err
+		}
+		if p == nil {
+			p = imported
+		} else {
+			err = p.merge(imported)
+			if err != nil {
+				return err
+			}
+		}
+		return dbPutSpendProposal(dbTx, p)
+	})
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to import proposal")
+	}
+	return spendProposalResult(p)
+}
+
+// BroadcastSpendProposal builds the signed transaction of a stored proposal
+// which has the required signatures and sends it to the network.
+func (api *PublicTxAPI) BroadcastSpendProposal(id hash.Hash, allowHighFees *bool) (interface{}, error) {
+	param := api.txManager.bm.ChainParams()
+	var p *spendProposal
+	err := api.txManager.db.View(func(dbTx database.Tx) error {
+		var err error
+		p, err = dbFetchSpendProposal(dbTx, &id, param)
+		return err
+	})
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to fetch proposal")
+	}
+	if p == nil {
+		return nil, rpc.RpcInvalidError("Unknown spend proposal %s", id)
+	}
+	signed, err := p.signedTx()
+	if err != nil {
+		return nil, rpc.RpcInvalidError(err.Error())
+	}
+	serialized, err := signed.Serialize()
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to serialize transaction")
+	}
+	txID, err := api.SendRawTransaction(hex.EncodeToString(serialized), allowHighFees)
+	if err != nil {
+		return nil, err
+	}
+
+	p.broadcast = true
+	err = api.txManager.db.Update(func(dbTx database.Tx) error {
+		return dbPutSpendProposal(dbTx, p)
+	})
+	if err != nil {
+		log.Warn("Failed to store broadcast proposal", "id", id, "err", err)
+	}
+	return txID, nil
+}
+
+// SignSpendProposal signs every input of a partially signed transaction with
+// the private key of a cosigner and returns the updated partially signed
+// transaction, which is then imported by the proposer.
+func (api *PrivateTxAPI) SignSpendProposal(privkeyStr string, psbt string) (interface{}, error) {
+	privkeyByte, err := hex.DecodeString(privkeyStr)
+	if err != nil {
+		return nil, err
+	}
+	if len(privkeyByte) != 32 {
+		return nil, fmt.Errorf("error:%d", len(privkeyByte))
+	}
+	privateKey, _ := ecc.Secp256k1.PrivKeyFromBytes(privkeyByte)
+
+	p, err := decodePsbt(psbt, params.ActiveNetParams.Params)
+	if err != nil {
+		return nil, err
+	}
+	err = p.sign(privateKey)
+	if err != nil {
+		return nil, rpc.RpcInvalidError(err.Error())
+	}
+	return p.psbt()
+}
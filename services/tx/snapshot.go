@@ -0,0 +1,122 @@
+package tx
+
+import (
+	"github.com/Qitmeer/qitmeer/core/json"
+	"github.com/Qitmeer/qitmeer/rpc"
+	"github.com/Qitmeer/qitmeer/services/mempool"
+	"os"
+	"path/filepath"
+)
+
+// snapshotDir is the directory of the data directory holding the mempool
+// snapshot files.
+const snapshotDir = "mempool"
+
+// defaultSnapshotFile is the name of the mempool snapshot file when none is
+// specified.
+const defaultSnapshotFile = "mempool.json"
+
+// snapshotPath returns the path of the mempool snapshot file in the snapshot
+// directory.  Only .json file names are accepted, so that the RPC clients can
+// not read nor write the other files of the node.
+func (api *PrivateTxAPI) snapshotPath(file *string) (string, error) {
+	name := defaultSnapshotFile
+	if file != nil && len(*file) > 0 {
+		name = *file
+	}
+	if filepath.Base(name) != name || filepath.Ext(name) != ".json" ||
+		name == ".json" {
+		return "", rpc.RpcInvalidError("Invalid snapshot file %s, must be a "+
+			".json file name in the %s directory", name, snapshotDir)
+	}
+	return filepath.Join(api.txManager.config.DataDir, snapshotDir, name), nil
+}
+
+// DumpMempool writes a snapshot of the mempool to the file of the mempool
+// directory of the data directory, mempool.json by default.  Only a previous
+// snapshot can be overwritten.
+func (api *PrivateTxAPI) DumpMempool(file *string) (interface{}, error) {
+	path, err := api.snapshotPath(file)
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to create snapshot directory")
+	}
+	snap, err := api.txManager.txMemPool.Snapshot()
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to snapshot mempool")
+	}
+	err = mempool.WriteSnapshotFile(path, snap, 0600)
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to write mempool snapshot")
+	}
+	return &json.DumpMempoolResult{File: path, Size: len(snap.Txs)}, nil
+}
+
+// LoadMempool submits the transactions of a snapshot file of the mempool
+// directory, mempool.json by default, to the mempool.  The accepted
+// transactions are not relayed.
+func (api *PrivateTxAPI) LoadMempool(file *string) (interface{}, error) {
+	path, err := api.snapshotPath(file)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := mempool.ReadSnapshotFile(path)
+	if err != nil {
+		return nil, rpc.RpcInvalidError(err.Error())
+	}
+	accepted, skipped, rejected, err := api.txManager.txMemPool.LoadSnapshot(snap)
+	if err != nil {
+		return nil, rpc.RpcInvalidError(err.Error())
+	}
+	return &json.LoadMempoolResult{
+		Accepted: accepted,
+		Skipped:  skipped,
+		Rejected: rejected,
+	}, nil
+}
+
+// DiffMempool compares two snapshot files of the mempool directory, such as the
+// snapshots of two nodes whose block templates differ.
+func (api *PrivateTxAPI) DiffMempool(file1 string, file2 string) (interface{}, error) {
+	path1, err := api.snapshotPath(&file1)
+	if err != nil {
+		return nil, err
+	}
+	path2, err := api.snapshotPath(&file2)
+	if err != nil {
+		return nil, err
+	}
+	snap1, err := mempool.ReadSnapshotFile(path1)
+	if err != nil {
+		return nil, rpc.RpcInvalidError(err.Error())
+	}
+	snap2, err := mempool.ReadSnapshotFile(path2)
+	if err != nil {
+		return nil, rpc.RpcInvalidError(err.Error())
+	}
+	diff := mempool.DiffSnapshots(snap1, snap2)
+	result := &json.DiffMempoolResult{
+		Added:      make([]string, 0, len(diff.Added)),
+		Removed:    make([]string, 0, len(diff.Removed)),
+		FeeChanged: make([]json.MempoolFeeChangeResult, 0, len(diff.FeeChanged)),
+	}
+	for _, st := range diff.Added {
+		result.Added = append(result.Added, st.TxID)
+	}
+	for _, st := range diff.Removed {
+		result.Removed = append(result.Removed, st.TxID)
+	}
+	for _, fc := range diff.FeeChanged {
+		result.FeeChanged = append(result.FeeChanged, json.MempoolFeeChangeResult{
+			TxId:        fc.New.TxID,
+			OldFee:      fc.Old.Fee,
+			NewFee:      fc.New.Fee,
+			OldFeePerKB: fc.Old.FeePerKB,
+			NewFeePerKB: fc.New.FeePerKB,
+		})
+	}
+	return result, nil
+}
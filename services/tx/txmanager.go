@@ -23,6 +23,15 @@ type TxManager struct {
 
 	// addr index
 	addrIndex *index.AddrIndex
+
+	// addr activity index
+	addrActivityIndex *index.AddrActivityIndex
+
+	// utxo age index
+	utxoAgeIndex *index.UtxoAgeIndex
+
+	// miner index
+	minerIndex *index.MinerIndex
 	// mempool hold tx that need to be mined into blocks and relayed to other peers.
 	txMemPool *mempool.TxPool
 
@@ -34,6 +43,12 @@ type TxManager struct {
 
 	//invalidTx hash->block hash
 	invalidTx map[hash.Hash]*blockdag.HashSet
+
+	// config
+	config *config.Config
+
+	// coins frozen on a private network
+	freezeList *mempool.FreezeList
 }
 
 func (tm *TxManager) Start() error {
@@ -50,9 +65,37 @@ func (tm *TxManager) MemPool() blkmgr.TxPool {
 	return tm.txMemPool
 }
 
+// FreezeList returns the coins frozen on a private network, or nil when the
+// freeze list is disabled.
+func (tm *TxManager) FreezeList() *mempool.FreezeList {
+	return tm.freezeList
+}
+
 func NewTxManager(bm *blkmgr.BlockManager, txIndex *index.TxIndex,
-	addrIndex *index.AddrIndex, cfg *config.Config, ntmgr notify.Notify,
-	sigCache *txscript.SigCache, db database.DB) (*TxManager, error) {
+	addrIndex *index.AddrIndex, addrActivityIndex *index.AddrActivityIndex,
+	utxoAgeIndex *index.UtxoAgeIndex, minerIndex *index.MinerIndex,
+	cfg *config.Config, ntmgr notify.Notify, sigCache *txscript.SigCache,
+	db database.DB) (*TxManager, error) {
+	// acceptance plugins
+	for _, path := range cfg.AcceptPlugins {
+		err := mempool.LoadPlugin(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// coin freeze
+	var freezeList *mempool.FreezeList
+	if cfg.FreezeCoins {
+		var err error
+		freezeList, err = mempool.NewFreezeList(db, bm.ChainParams())
+		if err != nil {
+			return nil, err
+		}
+		err = mempool.RegisterPlugin(freezeList)
+		if err != nil {
+			return nil, err
+		}
+	}
 	// mem-pool
 	amt,_ := types.NewMeer(uint64(cfg.MinTxFee))
 	txC := mempool.Config{
@@ -81,8 +124,11 @@ func NewTxManager(bm *blkmgr.BlockManager, txIndex *index.TxIndex,
 		AddrIndex:        addrIndex,
 		BD:               bm.GetChain().BlockDAG(),
 		BC:               bm.GetChain(),
+		OnDoubleSpendProof: func(proof *types.DoubleSpendProof) {
+			ntmgr.AnnounceDoubleSpendProof(proof, nil)
+		},
 	}
 	txMemPool := mempool.New(&txC)
 	invalidTx := make(map[hash.Hash]*blockdag.HashSet)
-	return &TxManager{bm, txIndex, addrIndex, txMemPool, ntmgr, db, invalidTx}, nil
+	return &TxManager{bm, txIndex, addrIndex, addrActivityIndex, utxoAgeIndex, minerIndex, txMemPool, ntmgr, db, invalidTx, cfg, freezeList}, nil
 }
@@ -0,0 +1,99 @@
+package tx
+
+import (
+	"fmt"
+	"github.com/Qitmeer/qitmeer/core/json"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/rpc"
+	"github.com/Qitmeer/qitmeer/services/index"
+)
+
+// GetUtxoAgeDistribution returns the distribution of the unspent outputs of
+// a coin (MEER by default) by the order of the block creating them, in
+// buckets of bucketOrders orders, a multiple of index.UtxoAgeBucketOrders.
+// Only the buckets holding outputs are returned, the oldest first, along with
+// the share of the total amount each one holds.
+func (api *PublicTxAPI) GetUtxoAgeDistribution(coinId *uint16, bucketOrders *uint32) (interface{}, error) {
+	utxoAgeIndex := api.txManager.utxoAgeIndex
+	if utxoAgeIndex == nil {
+		return nil, fmt.Errorf("UTXO age index must be enabled (--utxoageindex)")
+	}
+	coin := types.MEERID
+	if coinId != nil {
+		coin = types.CoinID(*coinId)
+	}
+	width := uint32(index.UtxoAgeBucketOrders)
+	if bucketOrders != nil {
+		width = *bucketOrders
+	}
+	if width == 0 || width%index.UtxoAgeBucketOrders != 0 {
+		return nil, rpc.RpcInvalidError("Bucket orders %d is not a multiple "+
+			"of %d", width, index.UtxoAgeBucketOrders)
+	}
+	cache := api.txManager.bm.RPCCache()
+	cacheKey := fmt.Sprintf("getUtxoAgeDistribution/%d/%d", coin, width)
+	if result, ok := cache.Get(cacheKey); ok {
+		return result, nil
+	}
+
+	buckets, err := utxoAgeIndex.Distribution(coin)
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to fetch utxo age distribution")
+	}
+
+	// Merge the buckets of the index into the requested ones.
+	var merged []index.UtxoAgeBucket
+	var total index.UtxoAgeBucket
+	for _, b := range buckets {
+		start := b.StartOrder / width * width
+		if len(merged) == 0 || merged[len(merged)-1].StartOrder != start {
+			merged = append(merged, index.UtxoAgeBucket{StartOrder: start})
+		}
+		last := &merged[len(merged)-1]
+		last.Count += b.Count
+		last.Amount += b.Amount
+		total.Count += b.Count
+		total.Amount += b.Amount
+	}
+
+	mainOrder := uint64(api.txManager.bm.GetChain().BestSnapshot().GraphState.GetMainOrder())
+	age := func(order uint64) uint64 {
+		if order > mainOrder {
+			return 0
+		}
+		return mainOrder - order
+	}
+	result := &json.UtxoAgeDistributionResult{
+		CoinId:       uint16(coin),
+		MainOrder:    mainOrder,
+		BucketOrders: width,
+		Count:        total.Count,
+		Amount:       coinAmount(total.Amount, coin),
+		Buckets:      make([]json.UtxoAgeBucketResult, 0, len(merged)),
+	}
+	for _, b := range merged {
+		start := uint64(b.StartOrder)
+		end := start + uint64(width) - 1
+		share := 0.0
+		if total.Amount > 0 {
+			share = float64(b.Amount) / float64(total.Amount)
+		}
+		result.Buckets = append(result.Buckets, json.UtxoAgeBucketResult{
+			StartOrder: start,
+			EndOrder:   end,
+			MinAge:     age(end),
+			MaxAge:     age(start),
+			Count:      b.Count,
+			Amount:     coinAmount(b.Amount, coin),
+			Share:      share,
+		})
+	}
+	cache.Put(cacheKey, result)
+	return result, nil
+}
+
+// coinAmount returns the amount in atoms of the coin in coins.
+func coinAmount(atoms int64, coinId types.CoinID) float64 {
+	amount := types.Amount{Value: atoms, Id: coinId}
+	return amount.ToUnit(types.AmountCoin)
+}
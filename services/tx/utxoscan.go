@@ -0,0 +1,232 @@
+package tx
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/blockchain"
+	"github.com/Qitmeer/qitmeer/core/json"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/rpc"
+	"github.com/Qitmeer/qitmeer/wallet"
+	"strings"
+)
+
+const (
+	// defaultScanUtxoEntries is the default number of outputs of the utxo
+	// set read by a scanUtxoSet command.
+	defaultScanUtxoEntries = 100000
+
+	// maxScanUtxoEntries is the maximum number of outputs of the utxo set
+	// read by a scanUtxoSet command, which bounds the time it holds the
+	// chain lock.
+	maxScanUtxoEntries = 1000000
+)
+
+// utxoScanFilter holds the descriptors of a scanUtxoSet command.
+type utxoScanFilter struct {
+	// scripts maps the public key scripts to their descriptor.
+	scripts map[string]string
+
+	// addrs maps the encoded addresses to their descriptor.
+	addrs map[string]string
+}
+
+// newUtxoScanFilter parses the descriptors, which are output script
+// descriptors, bare addresses or labels of addresses.  The ranged descriptors
+// are expanded to the scripts of their first descRange child keys, and the
+// labels to their addresses.
+func newUtxoScanFilter(descriptors []string, descRange uint32, api *PublicTxAPI) (*utxoScanFilter, error) {
+	param := api.txManager.bm.ChainParams()
+	filter := &utxoScanFilter{
+		scripts: make(map[string]string),
+		addrs:   make(map[string]string),
+	}
+	for _, desc := range descriptors {
+		if strings.HasPrefix(desc, "label(") && strings.HasSuffix(desc, ")") {
+			addrs, err := api.addressesByLabel(desc[len("label(") : len(desc)-1])
+			if err != nil {
+				return nil, rpc.RpcInternalError(err.Error(),
+					"Failed to read address labels")
+			}
+			for _, a := range addrs {
+				filter.addrs[a] = desc
+			}
+			continue
+		}
+		if !strings.Contains(desc, "(") {
+			desc = "addr(" + desc + ")"
+		}
+		d, err := wallet.ParseDescriptor(desc, param)
+		if err != nil {
+			return nil, rpc.RpcInvalidError("Invalid descriptor %s: %v",
+				desc, err)
+		}
+		if a := d.Address(); a != nil {
+			filter.addrs[a.Encode()] = desc
+			continue
+		}
+		scripts, err := d.Scripts(0, descRange)
+		if err != nil {
+			return nil, rpc.RpcInvalidError("Invalid descriptor %s: %v",
+				desc, err)
+		}
+		for _, script := range scripts {
+			filter.scripts[string(script)] = desc
+		}
+	}
+	return filter, nil
+}
+
+// match returns the descriptor matching the public key script, or an empty
+// string.
+func (f *utxoScanFilter) match(pkScript []byte, api *PublicTxAPI) string {
+	if desc, ok := f.scripts[string(pkScript)]; ok {
+		return desc
+	}
+	if len(f.addrs) == 0 {
+		return ""
+	}
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript,
+		api.txManager.bm.ChainParams())
+	if err != nil {
+		return ""
+	}
+	for _, a := range addrs {
+		if desc, ok := f.addrs[a.Encode()]; ok {
+			return desc
+		}
+	}
+	return ""
+}
+
+// ScanUtxoSet scans the utxo set for the unspent outputs matching the
+// descriptors, such as to recover a wallet when the address index is not
+// enabled.  A descriptor is an output script descriptor, that is
+// addr(<address>), raw(<hex public key script>), pkh(<key>) or
+// sh(multi(<k>,<key>,...)), a bare address, or label(<label>) for the
+// addresses labeled by setAddressLabel.  The ranged descriptors cover
+// the scripts of their first descRange child keys.  At most maxEntries
+// outputs of the utxo set are read by a call, and the result has the cursor
+// to pass back to resume the scan along with the progress of the scan, until
+// the cursor is empty.  Since the utxo set changes between the calls, an
+// output created during the scan may be missed, and an output found may be
+// spent before the scan ends.
+func (api *PublicTxAPI) ScanUtxoSet(descriptors []string, cursor *string, maxEntries *uint, descRange *uint32) (interface{}, error) {
+	if len(descriptors) == 0 {
+		return nil, rpc.RpcInvalidError("No descriptors to scan for")
+	}
+	numKeys := uint32(wallet.DefaultDescriptorRange)
+	if descRange != nil {
+		numKeys = *descRange
+	}
+	filter, err := newUtxoScanFilter(descriptors, numKeys, api)
+	if err != nil {
+		return nil, err
+	}
+	numEntries := uint(defaultScanUtxoEntries)
+	if maxEntries != nil {
+		numEntries = *maxEntries
+	}
+	if numEntries == 0 || numEntries > maxScanUtxoEntries {
+		return nil, rpc.RpcInvalidError("Max entries must be in range "+
+			"[1, %d]", maxScanUtxoEntries)
+	}
+	var after *types.TxOutPoint
+	if cursor != nil && len(*cursor) > 0 {
+		after, err = parseUtxoScanCursor(*cursor)
+		if err != nil {
+			return nil, rpc.RpcInvalidError("Invalid cursor %q: %v",
+				*cursor, err)
+		}
+	}
+
+	result := &json.ScanUtxoSetResult{
+		Unspents: []json.ScannedUtxoResult{},
+		Amounts:  make(map[uint16]float64),
+	}
+	amounts := make(map[types.CoinID]int64)
+	last, progress, err := api.txManager.bm.GetChain().ScanUtxoSet(after, int(numEntries),
+		func(outpoint types.TxOutPoint, entry *blockchain.UtxoEntry) {
+			result.Scanned++
+			desc := filter.match(entry.PkScript(), api)
+			if len(desc) == 0 {
+				return
+			}
+			amount := entry.Amount()
+			amounts[amount.Id] += amount.Value
+			result.Unspents = append(result.Unspents, json.ScannedUtxoResult{
+				Txid:         outpoint.Hash.String(),
+				Vout:         outpoint.OutIndex,
+				Descriptor:   desc,
+				ScriptPubKey: hex.EncodeToString(entry.PkScript()),
+				CoinId:       uint16(amount.Id),
+				Amount:       amount.ToUnit(types.AmountCoin),
+				BlockHash:    entry.BlockHash().String(),
+				Coinbase:     entry.IsCoinBase(),
+			})
+		})
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to scan the utxo set")
+	}
+	for id, atoms := range amounts {
+		result.Amounts[uint16(id)] = coinAmount(atoms, id)
+	}
+	result.Progress = progress * 100
+	if last != nil {
+		result.Cursor = fmt.Sprintf("%s:%d", last.Hash, last.OutIndex)
+	}
+	return result, nil
+}
+
+// GetTxOutSetInfo returns the statistics of the utxo set at the main chain
+// tip, that is the number of unspent outputs, their size in the database, the
+// total amounts by coin and the MuHash commitment to the set, which operators
+// compare to audit the supply and check that the chain state of two nodes at
+// the same tip is the same.  The outputs of the invalid blocks are counted.
+// The statistics of a database created before they were maintained are
+// partial until the background scan of the utxo set completes.
+func (api *PublicTxAPI) GetTxOutSetInfo() (interface{}, error) {
+	stats, err := api.txManager.bm.GetChain().UtxoStats()
+	if err != nil {
+		return nil, rpc.RpcInternalError(err.Error(), "Failed to read the utxo set statistics")
+	}
+	if stats == nil {
+		return nil, rpc.RpcInternalError("utxo set statistics not started",
+			"The utxo set statistics are not computed yet")
+	}
+	result := &json.GetTxOutSetInfoResult{
+		BestBlock: stats.Hash.String(),
+		Order:     stats.Order,
+		TxOuts:    stats.Count,
+		Bytes:     stats.Size,
+		Amounts:   make(map[uint16]float64),
+		MuHash:    stats.MuHash.String(),
+		Complete:  stats.Complete,
+		Progress:  stats.Progress * 100,
+	}
+	for id, atoms := range stats.Amounts {
+		result.Amounts[uint16(id)] = coinAmount(atoms, id)
+	}
+	return result, nil
+}
+
+// parseUtxoScanCursor parses a cursor of the scanUtxoSet command, which is the
+// outpoint of the last output read as <txid>:<index>.
+func parseUtxoScanCursor(cursor string) (*types.TxOutPoint, error) {
+	sep := strings.LastIndex(cursor, ":")
+	if sep < 0 {
+		return nil, fmt.Errorf("missing index")
+	}
+	txHash, err := hash.NewHashFromStr(cursor[:sep])
+	if err != nil {
+		return nil, err
+	}
+	var index uint32
+	_, err = fmt.Sscanf(cursor[sep+1:], "%d", &index)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewOutPoint(txHash, index), nil
+}
@@ -0,0 +1,30 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package version
+
+var (
+	// Commit is the git commit the binary was built from, with a -dirty
+	// suffix when the working tree had local changes.  It is set during the
+	// build process with
+	// '-ldflags "-X github.com/Qitmeer/qitmeer/version.Commit=foo"'.
+	Commit = ""
+
+	// BuildTime is the time of the commit the binary was built from, rather
+	// than the time of the build, so that building a commit twice gives the
+	// same binary.  It is set during the build process with
+	// '-ldflags "-X github.com/Qitmeer/qitmeer/version.BuildTime=foo"'.
+	BuildTime = ""
+)
+
+// buildTags are the optional build tags the binary was built with.  Each of
+// them is appended by a file built only with the tag.
+var buildTags []string
+
+// BuildTags returns the optional build tags the binary was built with.
+func BuildTags() []string {
+	tags := make([]string, len(buildTags))
+	copy(tags, buildTags)
+	return tags
+}
@@ -0,0 +1,11 @@
+// +build zmq
+
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package version
+
+func init() {
+	buildTags = append(buildTags, "zmq")
+}
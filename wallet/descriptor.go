@@ -0,0 +1,309 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/Qitmeer/qitmeer/common/hash"
+	"github.com/Qitmeer/qitmeer/core/address"
+	"github.com/Qitmeer/qitmeer/core/types"
+	"github.com/Qitmeer/qitmeer/crypto/bip32"
+	"github.com/Qitmeer/qitmeer/crypto/ecc"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/params"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultDescriptorRange is the default number of child keys derived
+	// from a ranged descriptor.
+	DefaultDescriptorRange = 1000
+
+	// MaxDescriptorRange is the maximum number of child keys derived from
+	// a ranged descriptor at once.
+	MaxDescriptorRange = 100000
+
+	// maxScriptHashSize is the maximum size of a script redeemed by a
+	// pay-to-script-hash output, which must fit in a single push.
+	maxScriptHashSize = 520
+)
+
+// descriptorType is the type of the output script of a descriptor.
+type descriptorType int
+
+const (
+	descriptorRaw descriptorType = iota
+	descriptorAddr
+	descriptorPKH
+	descriptorSHMulti
+)
+
+// descriptorKey is a key expression of a descriptor: either a hex encoded
+// public key, or an extended public key followed by a derivation path of
+// non hardened children, which ends with /* when the descriptor is ranged.
+// The extended key is already derived along the path.
+type descriptorKey struct {
+	pubKey []byte
+	extKey *bip32.Key
+	ranged bool
+}
+
+// parseDescriptorKey parses the key expression of a descriptor.
+func parseDescriptorKey(s string, par *params.Params) (*descriptorKey, error) {
+	if b, err := hex.DecodeString(s); err == nil {
+		if _, err := ecc.Secp256k1.ParsePubKey(b); err != nil {
+			return nil, fmt.Errorf("invalid public key %s: %v", s, err)
+		}
+		return &descriptorKey{pubKey: b}, nil
+	}
+
+	components := strings.Split(s, "/")
+	version := bip32.Bip32Version{
+		PrivKeyVersion: par.HDPrivateKeyID[:],
+		PubKeyVersion:  par.HDPublicKeyID[:],
+	}
+	extKey, err := bip32.B58Deserialize(components[0], version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key %s: %v", components[0], err)
+	}
+	if extKey.IsPrivate {
+		return nil, fmt.Errorf("private key %s is not allowed, use the "+
+			"extended public key", components[0])
+	}
+	k := &descriptorKey{extKey: extKey}
+	for i, component := range components[1:] {
+		if component == "*" && i == len(components)-2 {
+			k.ranged = true
+			break
+		}
+		child, err := strconv.ParseUint(component, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path component "+
+				"%q, only non hardened children can be derived", component)
+		}
+		k.extKey, err = k.extKey.NewChildKey(uint32(child))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// derive returns the serialized public key of the key expression, at the
+// index when it is ranged.
+func (k *descriptorKey) derive(index uint32) ([]byte, error) {
+	if k.extKey == nil {
+		return k.pubKey, nil
+	}
+	if !k.ranged {
+		return k.extKey.Key, nil
+	}
+	key, err := k.extKey.NewChildKey(index)
+	if err != nil {
+		return nil, err
+	}
+	return key.Key, nil
+}
+
+// Descriptor is an output script descriptor, which describes the output
+// scripts of a wallet.  The supported descriptors are:
+//
+//	raw(<hex script>)           the script itself
+//	addr(<address>)             the script paying to the address
+//	pkh(<key>)                  pay-to-pubkey-hash of the key
+//	sh(multi(<k>,<key>,...))    pay-to-script-hash of a k-of-n multisig
+//
+// A key is a hex encoded public key, or an extended public key followed by a
+// path such as tpub.../0/*, where the trailing /* makes the descriptor ranged
+// so it describes the scripts of a whole range of child keys.
+type Descriptor struct {
+	desc     string
+	typ      descriptorType
+	script   []byte
+	addr     types.Address
+	keys     []*descriptorKey
+	required int
+	params   *params.Params
+}
+
+// descriptorArg returns the argument of the function call name(arg) in s.
+func descriptorArg(s string, name string) (string, bool) {
+	if strings.HasPrefix(s, name+"(") && strings.HasSuffix(s, ")") {
+		return s[len(name)+1 : len(s)-1], true
+	}
+	return "", false
+}
+
+// ParseDescriptor parses an output script descriptor for the network.
+func ParseDescriptor(desc string, par *params.Params) (*Descriptor, error) {
+	d := &Descriptor{desc: desc, params: par}
+	if arg, ok := descriptorArg(desc, "raw"); ok {
+		script, err := hex.DecodeString(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid script %s: %v", arg, err)
+		}
+		d.typ = descriptorRaw
+		d.script = script
+		return d, nil
+	}
+	if arg, ok := descriptorArg(desc, "addr"); ok {
+		addr, err := address.DecodeAddress(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %s: %v", arg, err)
+		}
+		if !address.IsForNetwork(addr, par) {
+			return nil, fmt.Errorf("address %s is not for %s", arg, par.Name)
+		}
+		d.typ = descriptorAddr
+		d.addr = addr
+		return d, nil
+	}
+	if arg, ok := descriptorArg(desc, "pkh"); ok {
+		key, err := parseDescriptorKey(arg, par)
+		if err != nil {
+			return nil, err
+		}
+		d.typ = descriptorPKH
+		d.keys = []*descriptorKey{key}
+		return d, nil
+	}
+	if arg, ok := descriptorArg(desc, "sh"); ok {
+		multi, ok := descriptorArg(arg, "multi")
+		if !ok {
+			return nil, fmt.Errorf("unsupported script %s in sh(), only "+
+				"multi() is supported", arg)
+		}
+		args := strings.Split(multi, ",")
+		if len(args) < 2 {
+			return nil, fmt.Errorf("multi() needs the number of required " +
+				"signatures and the keys")
+		}
+		required, err := strconv.Atoi(args[0])
+		if err != nil || required < 1 || required > len(args)-1 {
+			return nil, fmt.Errorf("invalid number of required signatures "+
+				"%s for %d keys", args[0], len(args)-1)
+		}
+		if len(args)-1 > txscript.MaxPubKeysPerMultiSig {
+			return nil, fmt.Errorf("%d keys exceed the limit of %d",
+				len(args)-1, txscript.MaxPubKeysPerMultiSig)
+		}
+		for _, s := range args[1:] {
+			key, err := parseDescriptorKey(s, par)
+			if err != nil {
+				return nil, err
+			}
+			d.keys = append(d.keys, key)
+		}
+		d.typ = descriptorSHMulti
+		d.required = required
+		// Check the size of the redeem script once, every index has
+		// the same size.
+		if _, err := d.Script(0); err != nil {
+			return nil, err
+		}
+		return d, nil
+	}
+	return nil, fmt.Errorf("unsupported descriptor %s", desc)
+}
+
+// String returns the descriptor as it was parsed.
+func (d *Descriptor) String() string {
+	return d.desc
+}
+
+// IsRange returns whether the descriptor describes a range of scripts.
+func (d *Descriptor) IsRange() bool {
+	for _, k := range d.keys {
+		if k.ranged {
+			return true
+		}
+	}
+	return false
+}
+
+// Address returns the address of an addr() descriptor, or nil.
+func (d *Descriptor) Address() types.Address {
+	return d.addr
+}
+
+// Script returns the output script of the descriptor at the index, which is
+// ignored unless the descriptor is ranged.
+func (d *Descriptor) Script(index uint32) ([]byte, error) {
+	switch d.typ {
+	case descriptorRaw:
+		return d.script, nil
+
+	case descriptorAddr:
+		return txscript.PayToAddrScript(d.addr)
+
+	case descriptorPKH:
+		pubKey, err := d.keys[0].derive(index)
+		if err != nil {
+			return nil, err
+		}
+		addr, err := address.NewPubKeyHashAddress(hash.Hash160(pubKey),
+			d.params, ecc.ECDSA_Secp256k1)
+		if err != nil {
+			return nil, err
+		}
+		return txscript.PayToAddrScript(addr)
+
+	case descriptorSHMulti:
+		pubKeys := make([]*address.SecpPubKeyAddress, 0, len(d.keys))
+		for _, k := range d.keys {
+			pubKey, err := k.derive(index)
+			if err != nil {
+				return nil, err
+			}
+			addr, err := address.NewSecpPubKeyAddress(pubKey, d.params)
+			if err != nil {
+				return nil, err
+			}
+			pubKeys = append(pubKeys, addr)
+		}
+		redeemScript, err := txscript.MultiSigScript(pubKeys, d.required)
+		if err != nil {
+			return nil, err
+		}
+		if len(redeemScript) > maxScriptHashSize {
+			return nil, fmt.Errorf("redeem script of %d bytes exceeds "+
+				"the limit of %d", len(redeemScript), maxScriptHashSize)
+		}
+		addr, err := address.NewScriptHashAddress(redeemScript, d.params)
+		if err != nil {
+			return nil, err
+		}
+		return txscript.PayToAddrScript(addr)
+	}
+	return nil, fmt.Errorf("unsupported descriptor %s", d.desc)
+}
+
+// Scripts returns the output scripts of the descriptor at the indexes in the
+// range [start, end).  A descriptor which is not ranged has a single script
+// whatever the range.
+func (d *Descriptor) Scripts(start uint32, end uint32) ([][]byte, error) {
+	if !d.IsRange() {
+		script, err := d.Script(0)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{script}, nil
+	}
+	if start >= end || end-start > MaxDescriptorRange {
+		return nil, fmt.Errorf("invalid range [%d, %d), at most %d scripts "+
+			"can be derived", start, end, MaxDescriptorRange)
+	}
+	scripts := make([][]byte, 0, end-start)
+	for index := start; index < end; index++ {
+		script, err := d.Script(index)
+		if err != nil {
+			return nil, err
+		}
+		scripts = append(scripts, script)
+	}
+	return scripts, nil
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2017-2020 The qitmeer developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"github.com/Qitmeer/qitmeer/core/address"
+	"github.com/Qitmeer/qitmeer/crypto/bip32"
+	"github.com/Qitmeer/qitmeer/engine/txscript"
+	"github.com/Qitmeer/qitmeer/params"
+	"testing"
+)
+
+// testMasterKey returns the master extended private key of a fixed seed.
+func testMasterKey(t *testing.T, par *params.Params) *bip32.Key {
+	version := bip32.Bip32Version{
+		PrivKeyVersion: par.HDPrivateKeyID[:],
+		PubKeyVersion:  par.HDPublicKeyID[:],
+	}
+	seed := bytes.Repeat([]byte{0x42}, 32)
+	master, err := bip32.NewMasterKey2(seed, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return master
+}
+
+// pkhScript returns the pay-to-pubkey-hash script of the public key.
+func pkhScript(t *testing.T, pubKey []byte, par *params.Params) []byte {
+	addr, err := address.NewSecpPubKeyAddress(pubKey, par)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := txscript.PayToAddrScript(addr.PKHAddress())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return script
+}
+
+func TestDescriptorPKH(t *testing.T) {
+	par := &params.TestNetParams
+	master := testMasterKey(t, par)
+	pubKey := master.PublicKey().Key
+
+	d, err := ParseDescriptor("pkh("+hex.EncodeToString(pubKey)+")", par)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.IsRange() {
+		t.Fatal("descriptor of a single key is ranged")
+	}
+	scripts, err := d.Scripts(0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scripts) != 1 || !bytes.Equal(scripts[0], pkhScript(t, pubKey, par)) {
+		t.Fatalf("unexpected scripts %x", scripts)
+	}
+}
+
+func TestDescriptorRange(t *testing.T) {
+	par := &params.TestNetParams
+	master := testMasterKey(t, par)
+	xpub := master.PublicKey().B58Serialize()
+
+	d, err := ParseDescriptor("pkh("+xpub+"/0/*)", par)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.IsRange() {
+		t.Fatal("descriptor ending with /* is not ranged")
+	}
+	scripts, err := d.Scripts(2, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scripts) != 3 {
+		t.Fatalf("%d scripts derived, expected 3", len(scripts))
+	}
+	// The public derivation must match the private one.
+	for i, script := range scripts {
+		change, err := master.NewChildKey(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		child, err := change.NewChildKey(uint32(2 + i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := pkhScript(t, child.PublicKey().Key, par)
+		if !bytes.Equal(script, expected) {
+			t.Fatalf("script %d is %x, expected %x", 2+i, script, expected)
+		}
+	}
+	if _, err := d.Scripts(0, MaxDescriptorRange+1); err == nil {
+		t.Fatal("range over the limit is accepted")
+	}
+}
+
+func TestDescriptorSHMulti(t *testing.T) {
+	par := &params.TestNetParams
+	master := testMasterKey(t, par)
+	xpub := master.PublicKey().B58Serialize()
+	other, err := master.NewChildKey(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKey := hex.EncodeToString(other.PublicKey().Key)
+
+	d, err := ParseDescriptor("sh(multi(2,"+xpub+"/1/*,"+pubKey+"))", par)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scripts, err := d.Scripts(0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scripts) != 2 || bytes.Equal(scripts[0], scripts[1]) {
+		t.Fatalf("unexpected scripts %x", scripts)
+	}
+	for _, script := range scripts {
+		class := txscript.GetScriptClass(txscript.DefaultScriptVersion, script)
+		if class != txscript.ScriptHashTy {
+			t.Fatalf("script %x is %v, expected pay-to-script-hash", script, class)
+		}
+	}
+}
+
+func TestDescriptorRaw(t *testing.T) {
+	d, err := ParseDescriptor("raw(6a0100)", &params.TestNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := d.Script(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(script) != "6a0100" {
+		t.Fatalf("unexpected script %x", script)
+	}
+}
+
+func TestDescriptorInvalid(t *testing.T) {
+	par := &params.TestNetParams
+	master := testMasterKey(t, par)
+	xpub := master.PublicKey().B58Serialize()
+	xprv := master.B58Serialize()
+	pubKey := hex.EncodeToString(master.PublicKey().Key)
+
+	tests := []string{
+		"wpkh(" + pubKey + ")",
+		"pkh(" + xprv + "/0/*)",
+		"pkh(" + xpub + "/0'/*)",
+		"pkh(" + xpub + "/*/0)",
+		"pkh(00" + pubKey + ")",
+		"sh(multi(3," + pubKey + "," + xpub + "/0/*))",
+		"sh(multi(0," + pubKey + "))",
+		"sh(pkh(" + pubKey + "))",
+		"raw(zz)",
+	}
+	for _, desc := range tests {
+		if _, err := ParseDescriptor(desc, par); err == nil {
+			t.Errorf("invalid descriptor %s is accepted", desc)
+		}
+	}
+}